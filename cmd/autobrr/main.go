@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/asaskevich/EventBus"
 	"github.com/r3labs/sse/v2"
@@ -11,19 +14,30 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/autobrr/autobrr/internal/action"
+	"github.com/autobrr/autobrr/internal/apikey"
 	"github.com/autobrr/autobrr/internal/auth"
+	"github.com/autobrr/autobrr/internal/backup"
 	"github.com/autobrr/autobrr/internal/config"
 	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/dedupe"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/download_client"
 	"github.com/autobrr/autobrr/internal/events"
+	"github.com/autobrr/autobrr/internal/feed"
 	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/health"
 	"github.com/autobrr/autobrr/internal/http"
 	"github.com/autobrr/autobrr/internal/indexer"
 	"github.com/autobrr/autobrr/internal/irc"
 	"github.com/autobrr/autobrr/internal/logger"
+	"github.com/autobrr/autobrr/internal/maintenance"
+	"github.com/autobrr/autobrr/internal/metrics"
+	"github.com/autobrr/autobrr/internal/notification"
 	"github.com/autobrr/autobrr/internal/release"
+	"github.com/autobrr/autobrr/internal/scheduler"
 	"github.com/autobrr/autobrr/internal/server"
+	"github.com/autobrr/autobrr/internal/session"
+	"github.com/autobrr/autobrr/internal/update"
 	"github.com/autobrr/autobrr/internal/user"
 )
 
@@ -51,54 +65,158 @@ func main() {
 	// setup internal eventbus
 	bus := EventBus.New()
 
-	// setup logger
-	logger.Setup(cfg, serverEvents)
+	// setup logger, recent lines are kept for the logs api
+	logs := logger.Setup(cfg, serverEvents)
 
 	log.Info().Msg("Starting autobrr")
 	log.Info().Msgf("Version: %v", version)
 	log.Info().Msgf("Log-level: %v", cfg.LogLevel)
 
+	dbSettings, err := cfg.DatabaseSettings()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid database config")
+	}
+
 	// open database connection
-	db := database.NewSqliteDB(configPath)
+	db := database.NewDB(configPath, dbSettings)
+	log.Info().Msgf("Database: %v", dbSettings.Type)
 	if err := db.Open(); err != nil {
 		log.Fatal().Err(err).Msg("could not open db connection")
 	}
 
+	encryptionKey, err := config.EncryptionKey(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not read encryption key")
+	}
+
+	if err := db.SetupEncryption(context.Background(), encryptionKey); err != nil {
+		log.Fatal().Err(err).Msg("could not set up database encryption")
+	}
+
 	// setup repos
 	var (
 		actionRepo         = database.NewActionRepo(db)
+		actionQueueRepo    = database.NewActionQueueRepo(db)
+		announceQueueRepo  = database.NewAnnounceQueueRepo(db)
+		apiKeyRepo         = database.NewAPIKeyRepo(db)
+		authAuditRepo      = database.NewAuthAuditRepo(db)
 		downloadClientRepo = database.NewDownloadClientRepo(db)
+		feedRepo           = database.NewFeedRepo(db)
+		feedCacheRepo      = database.NewFeedCacheRepo(db)
 		filterRepo         = database.NewFilterRepo(db)
 		indexerRepo        = database.NewIndexerRepo(db)
 		ircRepo            = database.NewIrcRepo(db)
+		notificationRepo   = database.NewNotificationRepo(db)
+		quotaRepo          = database.NewQuotaRepo(db)
 		releaseRepo        = database.NewReleaseRepo(db)
+		sessionRepo        = database.NewSessionRepo(db)
 		userRepo           = database.NewUserRepo(db)
 	)
 
+	oidcSettings, err := cfg.OIDCSettings()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid oidc config")
+	}
+
+	if _, err := cfg.ReverseProxies(); err != nil {
+		log.Fatal().Err(err).Msg("invalid trusted proxies config")
+	}
+
+	maintenanceSettings, err := cfg.MaintenanceSettings()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid maintenance config")
+	}
+
 	// setup services
 	var (
 		downloadClientService = download_client.NewService(downloadClientRepo)
-		actionService         = action.NewService(actionRepo, downloadClientService, bus)
+		downloadLimiter       = indexer.NewDownloadLimiter()
+		torrentCache          = indexer.NewTorrentCache(filepath.Join(configPath, "torrent-cache"), cfg.TorrentCacheSettings())
 		apiService            = indexer.NewAPIService()
-		indexerService        = indexer.NewService(indexerRepo, apiService)
-		filterService         = filter.NewService(filterRepo, actionRepo, apiService, indexerService)
-		releaseService        = release.NewService(releaseRepo, actionService)
-		ircService            = irc.NewService(ircRepo, filterService, indexerService, releaseService)
+		dedupeService         = dedupe.NewService(releaseRepo)
+		indexerService        = indexer.NewService(indexerRepo, apiService, downloadLimiter, torrentCache, bus, cfg.IndexerAuthSettings())
+		actionService         = action.NewService(actionRepo, actionQueueRepo, releaseRepo, downloadClientService, indexerService, bus, cfg.ActionQueueSettings())
+		filterService         = filter.NewService(filterRepo, actionRepo, releaseRepo, quotaRepo, cfg.QuotaSettings(), cfg.FilterMatchMode, cfg.ReleaseRules(), dedupeService, apiService, indexerService)
+		releaseService        = release.NewService(releaseRepo, actionService, filterService, bus, cfg.ReleaseRetention())
+		ircService            = irc.NewService(ircRepo, announceQueueRepo, filterService, indexerService, releaseService, bus, cfg.AnnounceSettings())
+		sessionService        = session.NewService(sessionRepo, cfg.SessionSettings())
 		userService           = user.NewService(userRepo)
-		authService           = auth.NewService(userService)
+		apiKeyService         = apikey.NewService(apiKeyRepo, userRepo)
+		authService           = auth.NewService(userService, authAuditRepo, cfg.LoginLimits(), oidcSettings)
+		schedulingService     = scheduler.NewService()
+		feedService           = feed.NewService(feedRepo, feedCacheRepo, filterService, indexerService, releaseService, schedulingService)
+		healthService         = health.NewService(db, ircService, downloadClientService)
+		configService         = config.NewService(cfg, logs, sessionService)
+		backupService         = backup.NewService(db, configService, version, cfg.BackupSettings())
+		maintenanceService    = maintenance.NewService(db, backupService, bus, maintenanceSettings)
+		notificationService   = notification.NewService(notificationRepo)
+		updateService         = update.NewService(version, configService, bus)
 	)
 
 	// register event subscribers
-	events.NewSubscribers(bus, releaseService)
+	events.NewSubscribers(bus, releaseService, filterService)
+	events.NewLive(bus, serverEvents)
+	metrics.NewSubscriber(bus)
+	notification.NewSubscriber(bus, notificationService, releaseRepo)
+
+	// pick up actions waiting for their delay or schedule window
+	if err := actionService.ResumeQueued(context.Background()); err != nil {
+		log.Error().Err(err).Msg("could not resume queued actions")
+	}
+
+	// and announces that were not through the filters yet
+	if err := ircService.ResumeQueued(context.Background()); err != nil {
+		log.Error().Err(err).Msg("could not resume queued announces")
+	}
+
+	if settings := cfg.BackupSettings(); settings.Interval > 0 {
+		if _, err := schedulingService.AddJob(backup.NewScheduledJob(backupService), settings.Interval, "backup"); err != nil {
+			log.Error().Err(err).Msg("could not schedule backups")
+		}
+	}
+
+	if cfg.ReleaseRetention().Enabled() {
+		if _, err := schedulingService.AddJob(release.NewPruneJob(releaseService), time.Hour, "release-prune"); err != nil {
+			log.Error().Err(err).Msg("could not schedule release pruning")
+		}
+	}
+
+	if cfg.TorrentCacheSettings().Enabled() {
+		if _, err := schedulingService.AddJob(indexer.NewCachePruneJob(torrentCache), time.Hour, "torrent-cache-prune"); err != nil {
+			log.Error().Err(err).Msg("could not schedule torrent cache pruning")
+		}
+	}
 
-	errorChannel := make(chan error)
+	if _, err := schedulingService.AddJob(feed.NewCachePruneJob(feedCacheRepo), time.Hour, "feed-cache-prune"); err != nil {
+		log.Error().Err(err).Msg("could not schedule feed cache pruning")
+	}
+
+	if _, err := schedulingService.AddJob(notification.NewDigestJob(notificationService), time.Minute, "notification-digest"); err != nil {
+		log.Error().Err(err).Msg("could not schedule notification digests")
+	}
+
+	// checkForUpdates is read on every check, turning it off in the settings takes effect without a restart
+	if _, err := schedulingService.AddJob(update.NewJob(updateService), update.Interval, "update-check"); err != nil {
+		log.Error().Err(err).Msg("could not schedule update checks")
+	}
+	go update.NewJob(updateService).Run()
+
+	if maintenanceSettings.Enabled() {
+		if _, err := schedulingService.AddJob(maintenance.NewJob(maintenanceService, maintenanceSettings), time.Minute, "database-maintenance"); err != nil {
+			log.Error().Err(err).Msg("could not schedule database maintenance")
+		}
+	}
+
+	httpServer := http.NewServer(cfg, serverEvents, version, commit, date, actionService, apiKeyService, authService, backupService, configService, downloadClientService, feedService, filterService, healthService, indexerService, ircService, logs, notificationService, releaseService, sessionService, updateService, userService)
 
 	go func() {
-		httpServer := http.NewServer(cfg, serverEvents, version, commit, date, actionService, authService, downloadClientService, filterService, indexerService, ircService, releaseService)
-		errorChannel <- httpServer.Open()
+		// a missing or invalid tls certificate ends up here
+		if err := httpServer.Open(); err != nil {
+			log.Fatal().Err(err).Msg("could not start http server")
+		}
 	}()
 
-	srv := server.NewServer(ircService, indexerService)
+	srv := server.NewServer(actionService, ircService, indexerService, feedService, downloadClientService, schedulingService)
 	srv.Hostname = cfg.Host
 	srv.Port = cfg.Port
 
@@ -113,18 +231,38 @@ func main() {
 	for sig := range sigCh {
 		switch sig {
 		case syscall.SIGHUP:
+			// with https served by autobrr sighup loads renewed certificates
+			if httpServer.TLSEnabled() {
+				if err := httpServer.ReloadTLS(); err != nil {
+					log.Error().Err(err).Msg("could not reload tls certificate, keeping the current one")
+				}
+				continue
+			}
+
 			log.Print("shutting down server sighup")
-			srv.Shutdown()
-			db.Close()
+			shutdown(srv, db, sigCh)
 			os.Exit(1)
 		case syscall.SIGINT, syscall.SIGQUIT:
-			srv.Shutdown()
-			db.Close()
+			shutdown(srv, db, sigCh)
 			os.Exit(1)
 		case syscall.SIGKILL, syscall.SIGTERM:
-			srv.Shutdown()
-			db.Close()
+			shutdown(srv, db, sigCh)
 			os.Exit(1)
 		}
 	}
 }
+
+// shutdown drain the release pipeline before the database is closed, a second signal doesn't wait for it
+func shutdown(srv *server.Server, db *database.DB, sigCh <-chan os.Signal) {
+	ctx, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+	defer cancel()
+
+	go func() {
+		<-sigCh
+		log.Warn().Msg("second signal, stopping without waiting")
+		os.Exit(1)
+	}()
+
+	srv.Shutdown(ctx)
+	db.Close()
+}
@@ -3,23 +3,50 @@ package main
 import (
 	"bufio"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/autobrr/autobrr/internal/apikey"
+	"github.com/autobrr/autobrr/internal/config"
 	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/dedupe"
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/provision"
 	"github.com/autobrr/autobrr/pkg/argon2id"
 )
 
 const usage = `usage: autobrrctl --config path <action>
 
-  create-user		 <username>		Create user
+  create-user		 <username> [role]	Create user, role is admin, operator or read-only (default admin)
   change-password	 <username>		Change password for user
+  reset-password	 <username>		Change password, turn off two-factor sign in and sign out every session
+  list-users					List users with their role and two-factor status
+  create-api-key	 <username> <name>	Create an api key for the user, sent in the X-API-Token header
+  list-api-keys		 [username]		List api keys, of every user without a username
+  delete-api-key	 <id>			Delete an api key
+  export		 [--secrets] [file]	Export indexers, irc networks and filters as json, to stdout without a file
+  import		 <file> [conflict]	Import an export, conflict for filters is rename, overwrite or skip (default rename)
+  validate-config				Check the config file, database connection and port before starting autobrr
+  import-sqlite		 <path>			Copy a sqlite autobrr.db into the empty postgres database
+  rekey						Encrypt the secrets in the database with a new encryption key
+  migrate-status				List database migrations
+  migrate-down		 <version>		Revert migrations down to the schema version of an older autobrr
   help						Show this help message
 `
 
@@ -38,13 +65,49 @@ func main() {
 		log.Fatal("--config required")
 	}
 
+	// read doesn't write a default config here, a missing file is what it should find
+	if flag.Arg(0) == "validate-config" {
+		if !validateConfig(configPath) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg := config.Read(configPath)
+
+	dbSettings, err := cfg.DatabaseSettings()
+	if err != nil {
+		log.Fatalf("invalid database config: %v", err)
+	}
+
 	// open database connection
-	db := database.NewSqliteDB(configPath)
-	if err := db.Open(); err != nil {
-		log.Fatal("could not open db connection")
+	db := database.NewDB(configPath, dbSettings)
+
+	// the migrate commands look at the schema as it is
+	open := db.Open
+	if strings.HasPrefix(flag.Arg(0), "migrate-") {
+		open = db.Connect
+	}
+
+	if err := open(); err != nil {
+		log.Fatalf("could not open db connection: %v", err)
+	}
+
+	encryptionKey, err := config.EncryptionKey(cfg)
+	if err != nil {
+		log.Fatalf("could not read encryption key: %v", err)
+	}
+
+	// users have encrypted totp secrets, import-sqlite copies the data key with everything else
+	switch flag.Arg(0) {
+	case "create-user", "change-password", "reset-password", "list-users", "create-api-key", "export", "import":
+		if err := db.SetupEncryption(context.Background(), encryptionKey); err != nil {
+			log.Fatalf("could not set up database encryption: %v", err)
+		}
 	}
 
 	userRepo := database.NewUserRepo(db)
+	apiKeyRepo := database.NewAPIKeyRepo(db)
 
 	switch cmd := flag.Arg(0); cmd {
 	case "create-user":
@@ -54,7 +117,23 @@ func main() {
 			os.Exit(1)
 		}
 
-		password, err := readPassword()
+		if err := domain.ValidateUsername(username); err != nil {
+			log.Fatalf("invalid username: %v", err)
+		}
+
+		role := domain.UserRoleAdmin
+		if flag.Arg(2) != "" {
+			role, err = domain.ParseUserRole(flag.Arg(2))
+			if err != nil {
+				log.Fatalf("invalid role: %v", err)
+			}
+		}
+
+		if existing, _ := userRepo.FindByUsername(context.Background(), username); existing != nil {
+			log.Fatalf("user %v already exists, use change-password or reset-password", username)
+		}
+
+		password, err := readPassword("Password: ")
 		if err != nil {
 			log.Fatalf("failed to read password: %v", err)
 		}
@@ -66,6 +145,7 @@ func main() {
 		user := domain.User{
 			Username: username,
 			Password: hashed,
+			Role:     role,
 		}
 		if err := userRepo.Store(context.Background(), user); err != nil {
 			log.Fatalf("failed to create user: %v", err)
@@ -86,7 +166,7 @@ func main() {
 			log.Fatalf("failed to get user: %v", err)
 		}
 
-		password, err := readPassword()
+		password, err := readPassword("Password: ")
 		if err != nil {
 			log.Fatalf("failed to read password: %v", err)
 		}
@@ -99,6 +179,247 @@ func main() {
 		if err := userRepo.Store(context.Background(), *user); err != nil {
 			log.Fatalf("failed to create user: %v", err)
 		}
+	case "reset-password":
+		username := flag.Arg(1)
+		if username == "" {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		user, err := userRepo.FindByUsername(context.Background(), username)
+		if err != nil || user == nil {
+			log.Fatalf("failed to get user %v: %v", username, err)
+		}
+
+		password, err := readPassword("New password: ")
+		if err != nil {
+			log.Fatalf("failed to read password: %v", err)
+		}
+		hashed, err := argon2id.CreateHash(string(password), argon2id.DefaultParams)
+		if err != nil {
+			log.Fatalf("failed to hash password: %v", err)
+		}
+
+		user.Password = hashed
+		if err := userRepo.Store(context.Background(), *user); err != nil {
+			log.Fatalf("failed to change password: %v", err)
+		}
+
+		// a lost authenticator is the usual reason to be locked out
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+		user.RecoveryCodes = nil
+		if err := userRepo.UpdateTOTP(context.Background(), *user); err != nil {
+			log.Fatalf("failed to turn off two-factor sign in: %v", err)
+		}
+
+		if err := database.NewSessionRepo(db).DeleteByUsername(context.Background(), user.Username, "password", 0); err != nil {
+			log.Fatalf("failed to sign out sessions: %v", err)
+		}
+
+		fmt.Printf("password of %v reset, two-factor sign in is off and every session signed out\n", user.Username)
+	case "list-users":
+		users, err := userRepo.List(context.Background())
+		if err != nil {
+			log.Fatalf("failed to list users: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tTWO-FACTOR\tCREATED")
+		for _, u := range users {
+			totp := "off"
+			if u.TOTPEnabled {
+				totp = "on"
+			}
+
+			fmt.Fprintf(w, "%d\t%v\t%v\t%v\t%v\n", u.ID, u.Username, u.Role, totp, u.CreatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	case "create-api-key":
+		username, name := flag.Arg(1), flag.Arg(2)
+		if username == "" || name == "" {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		key, apiKey, err := apikey.NewService(apiKeyRepo, userRepo).Create(context.Background(), username, name)
+		if err != nil {
+			log.Fatalf("failed to create api key: %v", err)
+		}
+
+		fmt.Printf("api key %v (id %d) for %v, it is only shown now:\n%v\n", apiKey.Name, apiKey.ID, apiKey.Username, key)
+	case "list-api-keys":
+		keys, err := apiKeyRepo.List(context.Background(), flag.Arg(1))
+		if err != nil {
+			log.Fatalf("failed to list api keys: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tUSERNAME\tKEY\tCREATED\tLAST USED")
+		for _, k := range keys {
+			lastUsed := "never"
+			if k.LastUsedAt != nil {
+				lastUsed = k.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+
+			fmt.Fprintf(w, "%d\t%v\t%v\t%v...\t%v\t%v\n", k.ID, k.Name, k.Username, k.Prefix, k.CreatedAt.Local().Format("2006-01-02 15:04"), lastUsed)
+		}
+		w.Flush()
+	case "delete-api-key":
+		id, err := strconv.Atoi(flag.Arg(1))
+		if err != nil {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		if err := apiKeyRepo.Delete(context.Background(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Fatalf("no api key with id %d", id)
+			}
+			log.Fatalf("failed to delete api key %d: %v", id, err)
+		}
+
+		fmt.Printf("deleted api key %d\n", id)
+	case "export":
+		flags := flag.NewFlagSet("export", flag.ExitOnError)
+		secrets := flags.Bool("secrets", false, "include api keys, passkeys and passwords")
+		if err := flags.Parse(flag.Args()[1:]); err != nil {
+			log.Fatalf("invalid arguments: %v", err)
+		}
+
+		export, err := newProvisionService(db, cfg).Export(context.Background(), *secrets)
+		if err != nil {
+			log.Fatalf("failed to export: %v", err)
+		}
+
+		b, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to export: %v", err)
+		}
+
+		if flags.Arg(0) == "" {
+			fmt.Println(string(b))
+			break
+		}
+
+		if err := ioutil.WriteFile(flags.Arg(0), append(b, '\n'), 0600); err != nil {
+			log.Fatalf("failed to write %v: %v", flags.Arg(0), err)
+		}
+
+		fmt.Printf("exported %d indexers, %d networks and %d filters to %v\n", len(export.Indexers), len(export.Networks), len(export.Filters), flags.Arg(0))
+	case "import":
+		path := flag.Arg(1)
+		if path == "" {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		conflict, err := domain.ParseFilterImportConflict(flag.Arg(2))
+		if err != nil {
+			log.Fatalf("invalid conflict: %v", err)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatalf("could not read %v: %v", path, err)
+		}
+
+		var data domain.ProvisionExport
+		if err := json.Unmarshal(b, &data); err != nil {
+			log.Fatalf("could not parse %v: %v", path, err)
+		}
+
+		result, err := newProvisionService(db, cfg).Import(context.Background(), data, conflict)
+		if err != nil {
+			log.Fatalf("failed to import: %v", err)
+		}
+
+		failed := false
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tNAME\tSTATUS\tID\tERROR")
+		for _, group := range []struct {
+			kind    string
+			results []domain.FilterImportResult
+		}{{"indexer", result.Indexers}, {"network", result.Networks}, {"filter", result.Filters}} {
+			for _, r := range group.results {
+				fmt.Fprintf(w, "%v\t%v\t%v\t%d\t%v\n", group.kind, r.Name, r.Status, r.ID, r.Error)
+				failed = failed || r.Status == domain.FilterImportFailed
+			}
+		}
+		w.Flush()
+
+		fmt.Println("restart autobrr if it is running to pick up indexers and networks")
+
+		if failed {
+			os.Exit(1)
+		}
+	case "import-sqlite":
+		path := flag.Arg(1)
+		if path == "" {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		if dbSettings.Type != domain.DatabaseTypePostgres {
+			log.Fatal("import-sqlite needs databaseType = \"postgres\" in the config")
+		}
+
+		src := database.NewSqliteFileDB(path)
+		if err := src.Open(); err != nil {
+			log.Fatalf("could not open %v: %v", path, err)
+		}
+		defer src.Close()
+
+		if err := database.Copy(context.Background(), src, db); err != nil {
+			log.Fatalf("failed to import database: %v", err)
+		}
+
+		fmt.Printf("imported %v\n", path)
+	case "rekey":
+		newKey, err := readPassword("New encryption key: ")
+		if err != nil {
+			log.Fatalf("failed to read encryption key: %v", err)
+		}
+
+		if err := db.Rekey(context.Background(), encryptionKey, string(newKey)); err != nil {
+			log.Fatalf("failed to change encryption key: %v", err)
+		}
+
+		fmt.Println("secrets encrypted with the new key, set it as encryptionKey, in encryptionKeyFile or " + config.EncryptionKeyEnv + " before starting autobrr")
+	case "migrate-status":
+		migrations, err := db.Migrations()
+		if err != nil {
+			log.Fatalf("failed to list migrations: %v", err)
+		}
+
+		for _, m := range migrations {
+			status := "pending"
+			if m.Applied {
+				status = "applied"
+				if !m.AppliedAt.IsZero() {
+					status += " " + m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+				}
+			}
+
+			reversible := ""
+			if !m.Reversible {
+				reversible = " (irreversible)"
+			}
+
+			fmt.Printf("%4d  %-32s %v%v\n", m.Version, m.Name, status, reversible)
+		}
+	case "migrate-down":
+		version, err := strconv.Atoi(flag.Arg(1))
+		if err != nil {
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		if err := db.MigrateDown(version); err != nil {
+			log.Fatalf("failed to migrate down: %v", err)
+		}
+
+		fmt.Printf("database at schema version %d\n", version)
 	default:
 		flag.Usage()
 		if cmd != "help" {
@@ -107,13 +428,84 @@ func main() {
 	}
 }
 
-func readPassword() ([]byte, error) {
+// newProvisionService with the services the filter import needs, like autobrr sets them up
+func newProvisionService(db *database.DB, cfg domain.Config) provision.Service {
+	var (
+		indexerRepo = database.NewIndexerRepo(db)
+		releaseRepo = database.NewReleaseRepo(db)
+		apiService  = indexer.NewAPIService()
+	)
+
+	filterService := filter.NewService(database.NewFilterRepo(db), database.NewActionRepo(db), releaseRepo, database.NewQuotaRepo(db), cfg.QuotaSettings(), cfg.FilterMatchMode, cfg.ReleaseRules(), dedupe.NewService(releaseRepo), apiService, indexer.NewService(indexerRepo, apiService, indexer.NewDownloadLimiter(), indexer.NewTorrentCache("", domain.TorrentCacheSettings{}), nil, cfg.IndexerAuthSettings()))
+
+	return provision.NewService(indexerRepo, database.NewIrcRepo(db), filterService)
+}
+
+// validateConfig print a line for every check, false when one failed
+func validateConfig(configPath string) bool {
+	ok := true
+	report := func(name string, err error) {
+		if err != nil {
+			ok = false
+			fmt.Printf("FAIL  %v: %v\n", name, err)
+			return
+		}
+		fmt.Printf("ok    %v\n", name)
+	}
+
+	cfg, errs := config.Check(configPath)
+	for _, err := range errs {
+		report("config", err)
+	}
+	if len(errs) > 0 {
+		return false
+	}
+	report("config", nil)
+
+	dbSettings, _ := cfg.DatabaseSettings()
+	db := database.NewDB(configPath, dbSettings)
+
+	err := db.Connect()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = db.Ping(ctx)
+		cancel()
+	}
+	report("database "+string(dbSettings.Type), err)
+
+	if err == nil {
+		migrations, err := db.Migrations()
+		if err == nil {
+			pending := 0
+			for _, m := range migrations {
+				if !m.Applied {
+					pending++
+				}
+			}
+			fmt.Printf("      %d migrations pending, applied on start\n", pending)
+		}
+		report("database schema", err)
+		db.Close()
+	}
+
+	// autobrr itself holds the port when it's running
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err == nil {
+		listener.Close()
+	}
+	report("listen on "+addr, err)
+
+	return ok
+}
+
+func readPassword(prompt string) ([]byte, error) {
 	var password []byte
 	var err error
 	fd := int(os.Stdin.Fd())
 
 	if terminal.IsTerminal(fd) {
-		fmt.Printf("Password: ")
+		fmt.Print(prompt)
 		password, err = terminal.ReadPassword(int(os.Stdin.Fd()))
 		if err != nil {
 			return nil, err
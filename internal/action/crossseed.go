@@ -0,0 +1,33 @@
+package action
+
+import (
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/qbittorrent"
+)
+
+// findCrossSeedMatch a completed torrent with the same content as the release, compared on name and exact size.
+// The size has to come from the torrent file, announced sizes are not precise enough.
+func findCrossSeedMatch(torrents []qbittorrent.Torrent, release domain.Release) *qbittorrent.Torrent {
+	if release.Size == 0 {
+		return nil
+	}
+
+	name := domain.NormalizeName(release.TorrentName)
+
+	for i, t := range torrents {
+		if t.Progress < 1 || uint64(t.TotalSize) != release.Size {
+			continue
+		}
+
+		// already in the client, nothing to cross-seed
+		if release.TorrentHash != "" && t.Hash == release.TorrentHash {
+			continue
+		}
+
+		if domain.NormalizeName(t.Name) == name {
+			return &torrents[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/qbittorrent"
+)
+
+func Test_findCrossSeedMatch(t *testing.T) {
+	torrents := []qbittorrent.Torrent{
+		{Hash: "incomplete", Name: "That.Movie.2021.1080p.BluRay.x264-GRP", TotalSize: 1000, Progress: 0.5, SavePath: "/downloads/incomplete"},
+		{Hash: "other", Name: "Other.Movie.2021.1080p.BluRay.x264-GRP", TotalSize: 1000, Progress: 1, SavePath: "/downloads/other"},
+		{Hash: "match", Name: "That Movie 2021 1080p BluRay x264-GRP", TotalSize: 1000, Progress: 1, SavePath: "/downloads/movies"},
+	}
+
+	tests := []struct {
+		name     string
+		release  domain.Release
+		wantHash string
+	}{
+		{name: "match", release: domain.Release{TorrentName: "That.Movie.2021.1080p.BluRay.x264-GRP", Size: 1000}, wantHash: "match"},
+		{name: "size_differs", release: domain.Release{TorrentName: "That.Movie.2021.1080p.BluRay.x264-GRP", Size: 1001}},
+		{name: "unknown_size", release: domain.Release{TorrentName: "That.Movie.2021.1080p.BluRay.x264-GRP"}},
+		{name: "same_torrent", release: domain.Release{TorrentName: "That.Movie.2021.1080p.BluRay.x264-GRP", Size: 1000, TorrentHash: "match"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCrossSeedMatch(torrents, tt.release)
+			if tt.wantHash == "" {
+				assert.Nil(t, got)
+				return
+			}
+
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.wantHash, got.Hash)
+				assert.Equal(t, "/downloads/movies", got.SavePath)
+			}
+		})
+	}
+}
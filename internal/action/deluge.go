@@ -5,7 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"io/ioutil"
-	"time"
+	"strings"
 
 	"github.com/autobrr/autobrr/internal/domain"
 
@@ -29,139 +29,114 @@ func (s *service) deluge(action domain.Action, release domain.Release) error {
 		return errors.New("no client found")
 	}
 
-	settings := delugeClient.Settings{
-		Hostname:             client.Host,
-		Port:                 uint(client.Port),
-		Login:                client.Username,
-		Password:             client.Password,
-		DebugServerResponses: true,
-		ReadWriteTimeout:     time.Second * 20,
-	}
-
-	switch client.Type {
-	case "DELUGE_V1":
-		if err = delugeV1(client, settings, action, release); err != nil {
-			return err
-		}
-
-	case "DELUGE_V2":
-		if err = delugeV2(client, settings, action, release); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return s.sessions.deluge(client, func(deluge delugeConn) error {
+		return delugeAddRelease(deluge, client, action, release)
+	})
 }
 
-func (s *service) delugeCheckRulesCanDownload(action domain.Action) (bool, error) {
+// delugeCheckRulesCanDownload returns a *rulesBlockedError if the client rules block the release
+func (s *service) delugeCheckRulesCanDownload(action domain.Action) error {
 	log.Trace().Msgf("action Deluge: %v check rules", action.Name)
 
 	// get client for action
 	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("error finding client: %v ID %v", action.Name, action.ClientID)
-		return false, err
+		return err
 	}
 
 	if client == nil {
-		return false, errors.New("no client found")
+		return errors.New("no client found")
 	}
 
-	settings := delugeClient.Settings{
-		Hostname:             client.Host,
-		Port:                 uint(client.Port),
-		Login:                client.Username,
-		Password:             client.Password,
-		DebugServerResponses: true,
-		ReadWriteTimeout:     time.Second * 20,
+	rules := client.Settings.Rules
+	if !rules.Enabled || action.IgnoreRules {
+		return nil
 	}
-	var deluge delugeClient.DelugeClient
 
-	switch client.Type {
-	case "DELUGE_V1":
-		deluge = delugeClient.NewV1(settings)
+	return s.sessions.deluge(client, func(deluge delugeConn) error {
+		// check for active downloads and other rules
+		stats, err := delugeStats(deluge, rules)
+		if err != nil {
+			return err
+		}
 
-	case "DELUGE_V2":
-		deluge = delugeClient.NewV2(settings)
-	}
+		if reason := checkClientRules(rules, stats); reason != "" {
+			log.Debug().Msgf("Deluge rules: %v", reason)
+			return &rulesBlockedError{Reason: reason, Rules: rules}
+		}
 
-	// perform connection to Deluge server
-	err = deluge.Connect()
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("error logging into client: %v %v", client.Name, client.Host)
-		return false, err
-	}
+		return nil
+	})
+}
 
-	defer deluge.Close()
+// delugeStats fetch what the enabled rules need
+func delugeStats(deluge delugeClient.DelugeClient, rules domain.DownloadClientRules) (clientStats, error) {
+	var stats clientStats
 
-	// check for active downloads and other rules
-	if client.Settings.Rules.Enabled && !action.IgnoreRules {
+	if rules.MaxActiveDownloads > 0 {
 		activeDownloads, err := deluge.TorrentsStatus(delugeClient.StateDownloading, nil)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("Deluge - could not fetch downloading torrents")
-			return false, err
+			return stats, err
 		}
 
-		// make sure it's not set to 0 by default
-		if client.Settings.Rules.MaxActiveDownloads > 0 {
-
-			// if max active downloads reached, check speed and if lower than threshold add anyways
-			if len(activeDownloads) >= client.Settings.Rules.MaxActiveDownloads {
-				log.Debug().Msg("max active downloads reached, skipping")
-				return false, nil
-
-				//	// TODO handle ignore slow torrents
-				//if client.Settings.Rules.IgnoreSlowTorrents {
-				//
-				//	// get session state
-				//	// gives type conversion errors
-				//	state, err := deluge.GetSessionStatus()
-				//	if err != nil {
-				//		log.Error().Err(err).Msg("could not get session state")
-				//		return err
-				//	}
-				//
-				//	if int64(state.DownloadRate)*1024 >= client.Settings.Rules.DownloadSpeedThreshold {
-				//		log.Trace().Msg("max active downloads reached, skip adding")
-				//		return nil
-				//	}
-				//
-				//	log.Trace().Msg("active downloads are slower than set limit, lets add it")
-				//}
-			}
+		stats.ActiveDownloads = len(activeDownloads)
+
+		// the session status gives type conversion errors, so sum up the torrents instead
+		for _, t := range activeDownloads {
+			stats.DownloadSpeed += t.DownloadPayloadRate
 		}
 	}
 
-	return true, nil
-}
+	if rules.MaxSeeding > 0 {
+		seeding, err := deluge.TorrentsStatus(delugeClient.StateSeeding, nil)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Deluge - could not fetch seeding torrents")
+			return stats, err
+		}
 
-func delugeV1(client *domain.DownloadClient, settings delugeClient.Settings, action domain.Action, release domain.Release) error {
+		stats.Seeding = len(seeding)
+	}
 
-	deluge := delugeClient.NewV1(settings)
+	if rules.MinFreeSpace > 0 {
+		// empty path is the default download location
+		free, err := deluge.GetFreeSpace("")
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Deluge - could not get free space")
+			return stats, err
+		}
 
-	// perform connection to Deluge server
-	err := deluge.Connect()
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("error logging into client: %v %v", client.Name, client.Host)
-		return err
+		stats.FreeSpace = free
 	}
 
-	defer deluge.Close()
+	return stats, nil
+}
+
+// delugeAddTorrent add magnet link or base64 encoded torrent file
+func delugeAddTorrent(deluge delugeClient.DelugeClient, release domain.Release, options *delugeClient.Options) (string, error) {
+	if release.HasMagnet() {
+		return deluge.AddTorrentMagnet(release.MagnetURI, options)
+	}
 
 	t, err := ioutil.ReadFile(release.TorrentTmpFile)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("could not read torrent file: %v", release.TorrentTmpFile)
-		return err
+		return "", err
 	}
 
 	// encode file to base64 before sending to deluge
 	encodedFile := base64.StdEncoding.EncodeToString(t)
 	if encodedFile == "" {
-		log.Error().Stack().Err(err).Msgf("could not encode torrent file: %v", release.TorrentTmpFile)
-		return err
+		log.Error().Msgf("could not encode torrent file: %v", release.TorrentTmpFile)
+		return "", errors.New("could not encode torrent file")
 	}
 
-	// set options
+	return deluge.AddTorrentFile(release.TorrentTmpFile, encodedFile, options)
+}
+
+// delugeOptions build the add torrent options for an action
+func delugeOptions(action domain.Action, release domain.Release) (*delugeClient.Options, error) {
 	options := delugeClient.Options{}
 
 	// macros handle args and replace vars
@@ -175,11 +150,23 @@ func delugeV1(client *domain.DownloadClient, settings delugeClient.Settings, act
 		savePathArgs, err := m.Parse(action.SavePath)
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.SavePath)
-			return err
+			return nil, err
 		}
 
 		options.DownloadLocation = &savePathArgs
 	}
+	if action.MoveCompletedPath != "" {
+		// parse and replace values in argument string before continuing
+		moveCompletedArgs, err := m.Parse(action.MoveCompletedPath)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.MoveCompletedPath)
+			return nil, err
+		}
+
+		moveCompleted := true
+		options.MoveCompleted = &moveCompleted
+		options.MoveCompletedPath = &moveCompletedArgs
+	}
 	if action.LimitDownloadSpeed > 0 {
 		maxDL := int(action.LimitDownloadSpeed)
 		options.MaxDownloadSpeed = &maxDL
@@ -188,103 +175,62 @@ func delugeV1(client *domain.DownloadClient, settings delugeClient.Settings, act
 		maxUL := int(action.LimitUploadSpeed)
 		options.MaxUploadSpeed = &maxUL
 	}
+	if action.SequentialDownload {
+		// only sent to v2 daemons
+		options.V2.SequentialDownload = &action.SequentialDownload
+	}
 
-	log.Trace().Msgf("action Deluge options: %+v", options)
+	return &options, nil
+}
 
-	torrentHash, err := deluge.AddTorrentFile(release.TorrentTmpFile, encodedFile, &options)
+// delugeSetLabel set label on torrent and create the label first if it does not exist yet
+func delugeSetLabel(p *delugeClient.LabelPlugin, client *domain.DownloadClient, action domain.Action, release domain.Release, torrentHash string) error {
+	if p == nil {
+		return nil
+	}
+
+	// parse and replace values in argument string before continuing
+	labelArgs, err := NewMacro(release).Parse(action.Label)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentTmpFile, client.Name)
+		log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Label)
 		return err
 	}
 
-	if action.Label != "" {
-		p, err := deluge.LabelPlugin()
-		if err != nil {
-			log.Error().Stack().Err(err).Msgf("could not load label plugin: %v", client.Name)
-			return err
-		}
+	// deluge only accepts lowercase labels
+	labelArgs = strings.ToLower(labelArgs)
 
-		// parse and replace values in argument string before continuing
-		labelArgs, err := m.Parse(action.Label)
-		if err != nil {
-			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Label)
-			return err
-		}
-
-		if p != nil {
-			// TODO first check if label exists, if not, add it, otherwise set
-			err = p.SetTorrentLabel(torrentHash, labelArgs)
-			if err != nil {
-				log.Error().Stack().Err(err).Msgf("could not set label: %v on client: %v", action.Label, client.Name)
-				return err
-			}
-		}
+	if err := p.SetTorrentLabel(torrentHash, labelArgs); err == nil {
+		return nil
 	}
 
-	log.Info().Msgf("torrent with hash %v successfully added to client: '%v'", torrentHash, client.Name)
-
-	return nil
-}
-
-func delugeV2(client *domain.DownloadClient, settings delugeClient.Settings, action domain.Action, release domain.Release) error {
-
-	deluge := delugeClient.NewV2(settings)
+	log.Debug().Msgf("could not set label: %v on client: %v, trying to create it", labelArgs, client.Name)
 
-	// perform connection to Deluge server
-	err := deluge.Connect()
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("error logging into client: %v %v", client.Name, client.Host)
+	if err := p.AddLabel(labelArgs); err != nil {
+		log.Error().Stack().Err(err).Msgf("could not add label: %v on client: %v", labelArgs, client.Name)
 		return err
 	}
 
-	defer deluge.Close()
-
-	t, err := ioutil.ReadFile(release.TorrentTmpFile)
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("could not read torrent file: %v", release.TorrentTmpFile)
+	if err := p.SetTorrentLabel(torrentHash, labelArgs); err != nil {
+		log.Error().Stack().Err(err).Msgf("could not set label: %v on client: %v", labelArgs, client.Name)
 		return err
 	}
 
-	// encode file to base64 before sending to deluge
-	encodedFile := base64.StdEncoding.EncodeToString(t)
-	if encodedFile == "" {
-		log.Error().Stack().Err(err).Msgf("could not encode torrent file: %v", release.TorrentTmpFile)
-		return err
-	}
+	return nil
+}
 
+// delugeAddRelease add the torrent with the action options and set its label
+func delugeAddRelease(deluge delugeConn, client *domain.DownloadClient, action domain.Action, release domain.Release) error {
 	// set options
-	options := delugeClient.Options{}
-
-	// macros handle args and replace vars
-	m := NewMacro(release)
-
-	if action.Paused {
-		options.AddPaused = &action.Paused
-	}
-	if action.SavePath != "" {
-		// parse and replace values in argument string before continuing
-		savePathArgs, err := m.Parse(action.SavePath)
-		if err != nil {
-			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.SavePath)
-			return err
-		}
-
-		options.DownloadLocation = &savePathArgs
-	}
-	if action.LimitDownloadSpeed > 0 {
-		maxDL := int(action.LimitDownloadSpeed)
-		options.MaxDownloadSpeed = &maxDL
-	}
-	if action.LimitUploadSpeed > 0 {
-		maxUL := int(action.LimitUploadSpeed)
-		options.MaxUploadSpeed = &maxUL
+	options, err := delugeOptions(action, release)
+	if err != nil {
+		return err
 	}
 
 	log.Trace().Msgf("action Deluge options: %+v", options)
 
-	torrentHash, err := deluge.AddTorrentFile(release.TorrentTmpFile, encodedFile, &options)
+	torrentHash, err := delugeAddTorrent(deluge, release, options)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentTmpFile, client.Name)
+		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentName, client.Name)
 		return err
 	}
 
@@ -295,21 +241,9 @@ func delugeV2(client *domain.DownloadClient, settings delugeClient.Settings, act
 			return err
 		}
 
-		// parse and replace values in argument string before continuing
-		labelArgs, err := m.Parse(action.Label)
-		if err != nil {
-			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Label)
+		if err := delugeSetLabel(p, client, action, release, torrentHash); err != nil {
 			return err
 		}
-
-		if p != nil {
-			// TODO first check if label exists, if not, add it, otherwise set
-			err = p.SetTorrentLabel(torrentHash, labelArgs)
-			if err != nil {
-				log.Error().Stack().Err(err).Msgf("could not set label: %v on client: %v", action.Label, client.Name)
-				return err
-			}
-		}
 	}
 
 	log.Info().Msgf("torrent with hash %v successfully added to client: '%v'", torrentHash, client.Name)
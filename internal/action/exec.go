@@ -1,53 +1,275 @@
 package action
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/autobrr/autobrr/internal/domain"
 
 	"github.com/rs/zerolog/log"
 )
 
-func (s *service) execCmd(release domain.Release, action domain.Action) {
+// maxExecOutput max bytes of command output kept in the release history
+const maxExecOutput = 4096
+
+// execWaitDelay how long the output is still read after a command ended, processes it left running
+// with the output open don't keep the action waiting. Replaced in tests.
+var execWaitDelay = 5 * time.Second
+
+func (s *service) execCmd(release domain.Release, action domain.Action) (string, error) {
 	log.Debug().Msgf("action exec: %v release: %v", action.Name, release.TorrentName)
 
 	// check if program exists
 	cmd, err := exec.LookPath(action.ExecCmd)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("exec failed, could not find program: %v", action.ExecCmd)
-		return
+		return "", err
 	}
 
 	// handle args and replace vars
 	m := NewMacro(release)
 
-	// parse and replace values in argument string before continuing
-	parsedArgs, err := m.Parse(action.ExecArgs)
+	args, err := ParseExecArgs(m, action.ExecArgs)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("exec failed, could not parse arguments: %v", action.ExecCmd)
-		return
+		return "", err
+	}
+
+	env, err := parseExecEnv(m, action.ExecEnv)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("exec failed, could not parse env: %v", action.ExecCmd)
+		return "", err
 	}
 
-	// we need to split on space into a string slice, so we can spread the args into exec
-	args := strings.Split(parsedArgs, " ")
+	ctx := context.Background()
+	if action.ExecTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(action.ExecTimeout)*time.Second)
+		defer cancel()
+	}
 
 	start := time.Now()
 
 	// setup command and args
 	command := exec.Command(cmd, args...)
+	command.Env = append(os.Environ(), env...)
+
+	if action.ExecWorkDir != "" {
+		workDir, err := m.Parse(action.ExecWorkDir)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("exec failed, could not parse working dir: %v", action.ExecWorkDir)
+			return "", err
+		}
+
+		command.Dir = workDir
+	}
 
 	// execute command
-	output, err := command.CombinedOutput()
+	var out bytes.Buffer
+	err = RunCommand(ctx, command, &out, true)
+	output := truncateOutput(out.Bytes())
+
+	log.Trace().Msgf("executed command: '%v'", output)
+
+	duration := time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Error().Msgf("command: %v args: %v timed out after %v, torrent: %v", cmd, args, duration, release.TorrentName)
+		return output, fmt.Errorf("command timed out after %v seconds", action.ExecTimeout)
+	}
+
 	if err != nil {
 		// everything other than exit 0 is considered an error
-		log.Error().Stack().Err(err).Msgf("command: %v args: %v failed, torrent: %v", cmd, parsedArgs, release.TorrentTmpFile)
+		log.Error().Stack().Err(err).Msgf("command: %v args: %v failed, torrent: %v", cmd, args, release.TorrentName)
+		return output, err
 	}
 
-	log.Trace().Msgf("executed command: '%v'", string(output))
+	log.Info().Msgf("executed command: '%v', args: '%v' %v,%v, total time %v", cmd, args, release.TorrentName, release.Indexer, duration)
 
-	duration := time.Since(start)
+	return output, nil
+}
+
+// RunCommand run cmd in its own process group and copy its output to w, stderr as well when combined is set.
+// When ctx is done the command and everything it started are killed. Output still held open by processes
+// the command left running is read for at most execWaitDelay, like exec.Cmd.WaitDelay in newer go versions.
+func RunCommand(ctx context.Context, cmd *exec.Cmd, w io.Writer, combined bool) error {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer pr.Close()
+
+	cmd.Stdout = pw
+	if combined {
+		cmd.Stderr = pw
+	}
+
+	setProcessGroup(cmd)
+
+	err = cmd.Start()
+
+	// the command has its own copy of the write end
+	pw.Close()
+
+	if err != nil {
+		return err
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		_, _ = io.Copy(w, pr)
+	}()
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-waited:
+	case <-ctx.Done():
+		if killErr := killProcessGroup(cmd); killErr != nil {
+			log.Debug().Err(killErr).Msgf("could not kill command: %v", cmd.Path)
+		}
+
+		err = <-waited
+	}
+
+	select {
+	case <-copied:
+	case <-time.After(execWaitDelay):
+		log.Debug().Msgf("command %v ended but its output is still open, not waiting for it", cmd.Path)
+
+		pr.Close()
+		<-copied
+	}
+
+	return err
+}
+
+// ParseExecArgs split the argument string like a shell would and replace vars in each argument,
+// so values containing spaces like the torrent name stay a single argument
+func ParseExecArgs(m Macro, text string) ([]string, error) {
+	fields, err := splitArgs(text)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, len(fields))
+	for _, field := range fields {
+		arg, err := m.Parse(field)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// parseExecEnv parse KEY=VALUE lines and replace vars in the values
+func parseExecEnv(m Macro, text string) ([]string, error) {
+	var env []string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid env var: %q, expected KEY=VALUE", line)
+		}
+
+		key, value := parts[0], parts[1]
+
+		parsed, err := m.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+
+		env = append(env, strings.TrimSpace(key)+"="+parsed)
+	}
+
+	return env, nil
+}
+
+// splitArgs split on whitespace outside of quotes and template actions
+func splitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	var inArg bool
+	depth := 0
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case depth == 0 && quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inArg = true
+
+		case depth == 0 && r == quote:
+			quote = 0
+
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			depth++
+			current.WriteString("{{")
+			inArg = true
+			i++
+
+		case depth > 0 && r == '}' && i+1 < len(runes) && runes[i+1] == '}':
+			depth--
+			current.WriteString("}}")
+			i++
+
+		case depth == 0 && quote == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in arguments: %v", text)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// truncateOutput keep the tail of the output, which usually has the interesting bits.
+// The cut is moved to the start of the next rune so no character is split.
+func truncateOutput(out []byte) string {
+	out = bytes.TrimSpace(out)
+	if len(out) > maxExecOutput {
+		out = out[len(out)-maxExecOutput:]
+
+		for len(out) > 0 && !utf8.RuneStart(out[0]) {
+			out = out[1:]
+		}
+	}
 
-	log.Info().Msgf("executed command: '%v', args: '%v' %v,%v, total time %v", cmd, parsedArgs, release.TorrentName, release.Indexer, duration)
+	return string(out)
 }
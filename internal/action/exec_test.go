@@ -0,0 +1,107 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseExecArgs(t *testing.T) {
+	release := domain.Release{
+		TorrentName:    "That Movie 2021 1080p BluRay",
+		TorrentTmpFile: "/tmp/autobrr-123456",
+		Indexer:        "mock",
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", text: "", want: []string{}},
+		{name: "vars", text: "add {{ .TorrentPathName }} --name {{ .TorrentName }}", want: []string{"add", "/tmp/autobrr-123456", "--name", "That Movie 2021 1080p BluRay"}},
+		{name: "quoted", text: `--msg "new release from {{.Indexer}}" 'single quoted'`, want: []string{"--msg", "new release from mock", "single quoted"}},
+		{name: "flag_value", text: "--indexer={{ .Indexer }}", want: []string{"--indexer=mock"}},
+		{name: "unterminated_quote", text: `--msg "oops`, wantErr: true},
+		{name: "bad_template", text: "{{ .Nope }}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExecArgs(NewMacro(release), tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseExecEnv(t *testing.T) {
+	release := domain.Release{TorrentName: "That Movie 2021", Indexer: "mock"}
+
+	got, err := parseExecEnv(NewMacro(release), "# comment\nINDEXER={{ .Indexer }}\n\n NAME = {{ .TorrentName }}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"INDEXER=mock", "NAME= That Movie 2021"}, got)
+
+	_, err = parseExecEnv(NewMacro(release), "NOT_AN_ENV_VAR")
+	assert.Error(t, err)
+}
+
+func Test_service_execCmd(t *testing.T) {
+	s := &service{}
+	release := domain.Release{TorrentName: "That Movie 2021", Indexer: "mock"}
+
+	output, err := s.execCmd(release, domain.Action{ExecCmd: "sh", ExecArgs: `-c "echo $INDEXER {{ .TorrentName }}"`, ExecEnv: "INDEXER={{ .Indexer }}"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mock That Movie 2021", output)
+
+	output, err = s.execCmd(release, domain.Action{ExecCmd: "sh", ExecArgs: `-c "echo failed && exit 1"`})
+	assert.Error(t, err)
+	assert.Equal(t, "failed", output)
+
+	_, err = s.execCmd(release, domain.Action{ExecCmd: "sleep", ExecArgs: "5", ExecTimeout: 1})
+	assert.EqualError(t, err, "command timed out after 1 seconds")
+}
+
+func Test_service_execCmd_children(t *testing.T) {
+	execWaitDelay = 100 * time.Millisecond
+	defer func() { execWaitDelay = 5 * time.Second }()
+
+	s := &service{}
+	release := domain.Release{TorrentName: "That Movie 2021", Indexer: "mock"}
+
+	// a child holding the output open is killed with the command on timeout
+	start := time.Now()
+	_, err := s.execCmd(release, domain.Action{ExecCmd: "sh", ExecArgs: `-c "sleep 10 & sleep 10"`, ExecTimeout: 1})
+	assert.EqualError(t, err, "command timed out after 1 seconds")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// a child left running after the command ended is not waited for
+	start = time.Now()
+	output, err := s.execCmd(release, domain.Action{ExecCmd: "sh", ExecArgs: `-c "sleep 3 & echo done"`})
+	assert.NoError(t, err)
+	assert.Equal(t, "done", output)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func Test_truncateOutput(t *testing.T) {
+	assert.Equal(t, "ok", truncateOutput([]byte(" ok\n")))
+
+	// the cut lands in the middle of a two byte rune
+	out := truncateOutput([]byte("é" + strings.Repeat("ü", maxExecOutput/2)))
+	assert.True(t, utf8.ValidString(out))
+	assert.Equal(t, strings.Repeat("ü", maxExecOutput/2), out)
+
+	out = truncateOutput([]byte(strings.Repeat("ü", maxExecOutput/2) + "x"))
+	assert.True(t, utf8.ValidString(out))
+	assert.Equal(t, strings.Repeat("ü", maxExecOutput/2-1)+"x", out)
+}
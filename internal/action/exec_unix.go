@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package action
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup start the command in its own process group, so the processes it starts can be killed with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kill the command and everything it started
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
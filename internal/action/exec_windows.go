@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package action
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup windows has no process groups to set up, only the command itself is killed
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kill the command
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
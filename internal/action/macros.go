@@ -2,6 +2,7 @@ package action
 
 import (
 	"bytes"
+	"encoding/json"
 	"text/template"
 	"time"
 
@@ -13,7 +14,12 @@ type Macro struct {
 	TorrentPathName string
 	TorrentHash     string
 	TorrentUrl      string
+	MagnetURI       string
 	Indexer         string
+	Filter          string
+	Title           string
+	Category        string
+	Group           string
 	Resolution      string
 	Source          string
 	HDR             string
@@ -33,9 +39,14 @@ func NewMacro(release domain.Release) Macro {
 	ma := Macro{
 		TorrentName:     release.TorrentName,
 		TorrentUrl:      release.TorrentURL,
+		MagnetURI:       release.MagnetURI,
 		TorrentPathName: release.TorrentTmpFile,
 		TorrentHash:     release.TorrentHash,
 		Indexer:         release.Indexer,
+		Filter:          release.FilterName,
+		Title:           release.Title,
+		Category:        release.Category,
+		Group:           release.Group,
 		Resolution:      release.Resolution,
 		Source:          release.Source,
 		HDR:             release.HDR,
@@ -52,11 +63,19 @@ func NewMacro(release domain.Release) Macro {
 	return ma
 }
 
+// macroFuncs extra template functions, json is handy to quote values in webhook payloads
+var macroFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
 // Parse takes a string and replaces valid vars
 func (m Macro) Parse(text string) (string, error) {
 
 	// setup template
-	tmpl, err := template.New("macro").Parse(text)
+	tmpl, err := template.New("macro").Funcs(macroFuncs).Parse(text)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,61 @@
+package action
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/nzbget"
+)
+
+func (s *service) nzbget(action domain.Action, release domain.Release) error {
+	log.Debug().Msgf("action NZBGet: %v", action.Name)
+
+	// get client for action
+	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("error finding client: %v", action.ClientID)
+		return err
+	}
+
+	if client == nil {
+		return errors.New("no client found")
+	}
+
+	nzb := nzbget.New(nzbget.Config{
+		Hostname:      client.Host,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	opts := nzbget.AppendOptions{
+		Name:   release.TorrentName,
+		Paused: action.Paused,
+	}
+
+	if action.Category != "" {
+		// parse and replace values in argument string before continuing
+		categoryArgs, err := NewMacro(release).Parse(action.Category)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Category)
+			return err
+		}
+
+		opts.Category = categoryArgs
+	}
+
+	log.Trace().Msgf("action NZBGet options: %+v", opts)
+
+	id, err := nzb.AppendURL(release.TorrentURL, opts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not add nzb %v to client: %v", release.TorrentName, client.Name)
+		return err
+	}
+
+	log.Info().Msgf("nzb %v successfully added to client: '%v' with id %v", release.TorrentName, client.Name, id)
+
+	return nil
+}
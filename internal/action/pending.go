@@ -0,0 +1,97 @@
+package action
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// ListPending the queued actions, the ones held on a timer are marked as waiting
+func (s *service) ListPending(ctx context.Context) ([]domain.PendingAction, error) {
+	pending, err := s.queueRepo.ListPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	waiting := make(map[int64]bool, len(s.pending))
+	for p := range s.pending {
+		if p.item != nil {
+			waiting[p.item.ID] = true
+		}
+	}
+	s.lock.Unlock()
+
+	for i := range pending {
+		pending[i].Waiting = waiting[pending[i].ID]
+	}
+
+	return pending, nil
+}
+
+// RunPending hand a waiting chain to a worker now instead of at its run time
+func (s *service) RunPending(ctx context.Context, id int64) error {
+	p, err := s.takePending(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("run queued action %v for '%v' now, it was held for: %v", p.chain[0].Name, p.release.TorrentName, p.reason)
+
+	atomic.AddInt64(&s.running, 1)
+	s.submit(p)
+
+	return nil
+}
+
+// CancelPending drop a waiting chain, the rest of the chain does not run either
+func (s *service) CancelPending(ctx context.Context, id int64) error {
+	p, err := s.takePending(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.dequeue(p)
+
+	action := p.chain[0]
+
+	log.Info().Msgf("cancelled queued action %v for '%v'", action.Name, p.release.TorrentName)
+
+	s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
+		ReleaseID:  p.release.ID,
+		FilterID:   p.release.FilterID,
+		Status:     domain.ReleasePushStatusRejected,
+		Action:     action.Name,
+		Type:       action.Type,
+		Rejections: []string{"cancelled while pending"},
+		Log:        "cancelled, it was held for: " + p.reason,
+		Timestamp:  time.Now(),
+	})
+
+	return nil
+}
+
+// takePending stop the timer of a queued action and take its chain
+func (s *service) takePending(ctx context.Context, id int64) (*pendingChain, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for p := range s.pending {
+		if p.item != nil && p.item.ID == id {
+			p.timer.Stop()
+			delete(s.pending, p)
+
+			return p, nil
+		}
+	}
+
+	if _, err := s.queueRepo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return nil, domain.ErrActionNotWaiting
+}
@@ -0,0 +1,70 @@
+package action
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_service_pending(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	queue := &fakeQueue{}
+	bus := EventBus.New()
+	s := &service{queueRepo: queue, bus: bus}
+
+	var statuses []domain.ReleaseActionStatus
+	record := func(status *domain.ReleaseActionStatus) {
+		statuses = append(statuses, *status)
+	}
+	require.NoError(t, bus.Subscribe("release:store-action-status", record))
+	require.NoError(t, bus.Subscribe("release:push-approved", record))
+
+	release := domain.Release{ID: 1, TorrentName: "That Movie 2021"}
+	delayed := domain.Action{ID: 2, Name: "delayed", Type: domain.ActionTypeTest, Enabled: true, Delay: 3600}
+
+	s.scheduleActionChain([]domain.Action{delayed}, release, true, time.Now().Add(time.Hour))
+	s.scheduleActionChain([]domain.Action{delayed}, release, true, time.Now().Add(2*time.Hour))
+
+	pending, err := s.ListPending(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.True(t, pending[0].Waiting)
+	assert.Equal(t, "delay of 1h0m0s", pending[0].Reason)
+
+	// unknown items
+	assert.ErrorIs(t, s.RunPending(context.Background(), 100), sql.ErrNoRows)
+
+	// cancel drops the chain and records it on the release
+	require.NoError(t, s.CancelPending(context.Background(), pending[1].ID))
+	assert.Equal(t, domain.ReleasePushStatusRejected, statuses[len(statuses)-1].Status)
+	assert.ErrorIs(t, s.CancelPending(context.Background(), pending[1].ID), sql.ErrNoRows)
+
+	// run now instead of in an hour
+	require.NoError(t, s.RunPending(context.Background(), pending[0].ID))
+	require.NoError(t, s.waitRunning(context.Background()))
+
+	items, _ := queue.List(context.Background())
+	assert.Empty(t, items)
+	assert.Empty(t, s.pending)
+	assert.Equal(t, domain.ReleasePushStatusApproved, statuses[len(statuses)-1].Status)
+}
+
+func Test_service_pending_notWaiting(t *testing.T) {
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue}
+
+	// queued for a worker, not on a timer
+	require.NoError(t, queue.Store(context.Background(), &domain.ActionQueueItem{ReleaseID: 1, ActionID: 2}))
+
+	assert.ErrorIs(t, s.RunPending(context.Background(), 1), domain.ErrActionNotWaiting)
+	assert.ErrorIs(t, s.CancelPending(context.Background(), 1), domain.ErrActionNotWaiting)
+}
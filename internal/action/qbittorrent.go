@@ -2,8 +2,8 @@ package action
 
 import (
 	"context"
+	"errors"
 	"strconv"
-	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -11,9 +11,6 @@ import (
 	"github.com/autobrr/autobrr/pkg/qbittorrent"
 )
 
-const ReannounceMaxAttempts = 50
-const ReannounceInterval = 7000
-
 func (s *service) qbittorrent(qbt *qbittorrent.Client, action domain.Action, release domain.Release) error {
 	log.Debug().Msgf("action qBittorrent: %v", action.Name)
 
@@ -62,17 +59,37 @@ func (s *service) qbittorrent(qbt *qbittorrent.Client, action domain.Action, rel
 	if action.LimitDownloadSpeed > 0 {
 		options["dlLimit"] = strconv.FormatInt(action.LimitDownloadSpeed, 10)
 	}
+	if action.SkipHashCheck {
+		options["skip_checking"] = "true"
+	}
+	if action.SequentialDownload {
+		options["sequentialDownload"] = "true"
+	}
+
+	if action.CrossSeed {
+		qbittorrentCrossSeed(qbt, release, options)
+	}
 
 	log.Trace().Msgf("action qBittorrent options: %+v", options)
 
-	err := qbt.AddTorrentFromFile(release.TorrentTmpFile, options)
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentTmpFile, qbt.Name)
-		return err
+	if release.HasMagnet() {
+		if err := qbt.AddTorrentFromUrl(release.MagnetURI, options); err != nil {
+			log.Error().Stack().Err(err).Msgf("could not add magnet %v to client: %v", release.TorrentName, qbt.Name)
+			return err
+		}
+	} else {
+		if err := qbt.AddTorrentFromFile(release.TorrentTmpFile, options); err != nil {
+			log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentTmpFile, qbt.Name)
+			return err
+		}
+	}
+
+	if action.BandwidthPriority != 0 && release.TorrentHash != "" {
+		qbittorrentSetPriority(qbt, action, release.TorrentHash)
 	}
 
-	if !action.Paused && release.TorrentHash != "" {
-		err = checkTrackerStatus(qbt, release.TorrentHash)
+	if !action.Paused && !action.ReannounceSkip && release.TorrentHash != "" {
+		err := reannounce(&qbittorrentReannouncer{qbt: qbt}, release.TorrentHash, reannounceOptionsFromAction(action))
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("could not reannounce torrent: %v", release.TorrentHash)
 			return err
@@ -84,134 +101,153 @@ func (s *service) qbittorrent(qbt *qbittorrent.Client, action domain.Action, rel
 	return nil
 }
 
-func (s *service) qbittorrentCheckRulesCanDownload(action domain.Action) (bool, *qbittorrent.Client, error) {
+// qbittorrentCrossSeed point the torrent at the data of a matching torrent already in the client and skip the hash check.
+// Without a match the torrent is added as usual.
+func qbittorrentCrossSeed(qbt *qbittorrent.Client, release domain.Release, options map[string]string) {
+	torrents, err := qbt.GetTorrentsFilter(qbittorrent.TorrentFilterCompleted)
+	if err != nil {
+		log.Warn().Err(err).Msgf("could not get torrents to cross-seed %v from client: %v", release.TorrentName, qbt.Name)
+		return
+	}
+
+	match := findCrossSeedMatch(torrents, release)
+	if match == nil {
+		log.Debug().Msgf("no torrent to cross-seed %v from on client: %v", release.TorrentName, qbt.Name)
+		return
+	}
+
+	log.Info().Msgf("cross-seed %v from existing torrent %v in: %v", release.TorrentName, match.Hash, match.SavePath)
+
+	options["savepath"] = match.SavePath
+	options["autoTMM"] = "false"
+	options["skip_checking"] = "true"
+}
+
+// qbittorrentSetPriority move the torrent to the top or bottom of the queue.
+// Failing is not fatal, qBittorrent refuses it when queueing is disabled.
+func qbittorrentSetPriority(qbt *qbittorrent.Client, action domain.Action, hash string) {
+	var err error
+
+	switch {
+	case action.BandwidthPriority > 0:
+		err = qbt.TopPrioTorrents([]string{hash})
+	case action.BandwidthPriority < 0:
+		err = qbt.BottomPrioTorrents([]string{hash})
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Msgf("could not set queue priority for torrent: %v on client: %v", hash, qbt.Name)
+	}
+}
+
+// qbittorrentCheckRulesCanDownload returns a logged in client, or a *rulesBlockedError if the client rules block the release
+func (s *service) qbittorrentCheckRulesCanDownload(action domain.Action) (*qbittorrent.Client, error) {
 	log.Trace().Msgf("action qBittorrent: %v check rules", action.Name)
 
 	// get client for action
 	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("error finding client: %v", action.ClientID)
-		return false, nil, err
+		return nil, err
 	}
 
 	if client == nil {
-		return false, nil, err
-	}
-
-	qbtSettings := qbittorrent.Settings{
-		Hostname: client.Host,
-		Port:     uint(client.Port),
-		Username: client.Username,
-		Password: client.Password,
-		SSL:      client.SSL,
+		return nil, errors.New("no client found")
 	}
 
-	qbt := qbittorrent.NewClient(qbtSettings)
-	qbt.Name = client.Name
-	// save cookies?
-	err = qbt.Login()
+	qbt, err := s.sessions.qbittorrent(client)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("error logging into client: %v", client.Host)
-		return false, nil, err
+		return nil, err
 	}
 
 	// check for active downloads and other rules
-	if client.Settings.Rules.Enabled && !action.IgnoreRules {
-		activeDownloads, err := qbt.GetTorrentsActiveDownloads()
+	rules := client.Settings.Rules
+	if rules.Enabled && !action.IgnoreRules {
+		stats, err := qbittorrentStats(qbt, rules)
 		if err != nil {
-			log.Error().Stack().Err(err).Msg("could not fetch downloading torrents")
-			return false, nil, err
+			return nil, err
 		}
 
-		// make sure it's not set to 0 by default
-		if client.Settings.Rules.MaxActiveDownloads > 0 {
-
-			// if max active downloads reached, check speed and if lower than threshold add anyways
-			if len(activeDownloads) >= client.Settings.Rules.MaxActiveDownloads {
-				if client.Settings.Rules.IgnoreSlowTorrents {
-					// check speeds of downloads
-					info, err := qbt.GetTransferInfo()
-					if err != nil {
-						log.Error().Err(err).Msg("could not get transfer info")
-						return false, nil, err
-					}
-
-					// if current transfer speed is more than threshold return out and skip
-					// DlInfoSpeed is in bytes so lets convert to KB to match DownloadSpeedThreshold
-					if info.DlInfoSpeed/1024 >= client.Settings.Rules.DownloadSpeedThreshold {
-						log.Debug().Msg("max active downloads reached, skipping")
-						return false, nil, nil
-					}
-
-					log.Debug().Msg("active downloads are slower than set limit, lets add it")
-				} else {
-					log.Debug().Msg("max active downloads reached, skipping")
-					return false, nil, nil
-				}
-			}
+		if reason := checkClientRules(rules, stats); reason != "" {
+			log.Debug().Msgf("qBittorrent rules: %v", reason)
+			return nil, &rulesBlockedError{Reason: reason, Rules: rules}
 		}
 	}
 
-	return true, qbt, nil
+	return qbt, nil
 }
 
-func checkTrackerStatus(qb *qbittorrent.Client, hash string) error {
-	announceOK := false
-	attempts := 0
-
-	// initial sleep to give tracker a head start
-	time.Sleep(6 * time.Second)
+// qbittorrentStats fetch what the enabled rules need
+func qbittorrentStats(qbt *qbittorrent.Client, rules domain.DownloadClientRules) (clientStats, error) {
+	var stats clientStats
 
-	for attempts < ReannounceMaxAttempts {
-		log.Debug().Msgf("qBittorrent - run re-announce %v attempt: %v", hash, attempts)
-
-		trackers, err := qb.GetTorrentTrackers(hash)
+	if rules.MaxActiveDownloads > 0 {
+		activeDownloads, err := qbt.GetTorrentsActiveDownloads()
 		if err != nil {
-			log.Error().Err(err).Msgf("qBittorrent - could not get trackers for torrent: %v", hash)
-			return err
+			log.Error().Stack().Err(err).Msg("could not fetch downloading torrents")
+			return stats, err
 		}
 
-		log.Trace().Msgf("qBittorrent - run re-announce %v attempt: %v trackers (%+v)", hash, attempts, trackers)
-
-		// check if status not working or something else
-		working := findTrackerStatus(trackers)
-		if working {
-			log.Debug().Msgf("qBittorrent - re-announce for %v OK", hash)
+		stats.ActiveDownloads = len(activeDownloads)
 
-			announceOK = true
+		if rules.IgnoreSlowTorrents && stats.ActiveDownloads >= rules.MaxActiveDownloads {
+			// check speeds of downloads
+			info, err := qbt.GetTransferInfo()
+			if err != nil {
+				log.Error().Err(err).Msg("could not get transfer info")
+				return stats, err
+			}
 
-			// if working lets return
-			return nil
+			stats.DownloadSpeed = info.DlInfoSpeed
 		}
+	}
 
-		log.Trace().Msgf("qBittorrent - not working yet, lets re-announce %v attempt: %v", hash, attempts)
-		err = qb.ReAnnounceTorrents([]string{hash})
+	if rules.MaxSeeding > 0 {
+		seeding, err := qbt.GetTorrentsSeeding()
 		if err != nil {
-			log.Error().Err(err).Msgf("qBittorrent - could not get re-announce torrent: %v", hash)
-			return err
+			log.Error().Stack().Err(err).Msg("could not fetch seeding torrents")
+			return stats, err
 		}
 
-		// add delay for next run
-		time.Sleep(ReannounceInterval * time.Millisecond)
+		stats.Seeding = len(seeding)
+	}
+
+	if rules.MinFreeSpace > 0 {
+		free, err := qbt.GetFreeSpaceOnDisk()
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("could not get free space")
+			return stats, err
+		}
 
-		attempts++
+		stats.FreeSpace = free
 	}
 
-	// add extra delay before delete
-	// TODO add setting: delete on failure to reannounce
-	time.Sleep(30 * time.Second)
+	return stats, nil
+}
 
-	if !announceOK {
-		log.Debug().Msgf("qBittorrent - re-announce for %v took too long, deleting torrent", hash)
+// qbittorrentReannouncer reannounce through the qBittorrent web api
+type qbittorrentReannouncer struct {
+	qbt *qbittorrent.Client
+}
 
-		err := qb.DeleteTorrents([]string{hash}, false)
-		if err != nil {
-			log.Error().Stack().Err(err).Msgf("qBittorrent - could not delete torrent: %v", hash)
-			return err
-		}
+func (r *qbittorrentReannouncer) announceOK(hash string) (bool, error) {
+	trackers, err := r.qbt.GetTorrentTrackers(hash)
+	if err != nil {
+		log.Error().Err(err).Msgf("qBittorrent - could not get trackers for torrent: %v", hash)
+		return false, err
 	}
 
-	return nil
+	log.Trace().Msgf("qBittorrent - trackers for %v: %+v", hash, trackers)
+
+	return findTrackerStatus(trackers), nil
+}
+
+func (r *qbittorrentReannouncer) reannounce(hash string) error {
+	return r.qbt.ReAnnounceTorrents([]string{hash})
+}
+
+func (r *qbittorrentReannouncer) delete(hash string) error {
+	return r.qbt.DeleteTorrents([]string{hash}, false)
 }
 
 // Check if status not working or something else
@@ -227,7 +263,8 @@ func findTrackerStatus(slice []qbittorrent.TorrentTracker) bool {
 			continue
 		}
 
-		if item.Status == qbittorrent.TrackerStatusOK {
+		// a working tracker without seeds or peers has not registered the torrent yet
+		if item.Status == qbittorrent.TrackerStatusOK && (item.NumSeeds > 0 || item.NumPeers > 0 || item.NumLeechers > 0) {
 			return true
 		}
 	}
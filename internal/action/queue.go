@@ -0,0 +1,279 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	// maxAttempts runs of a chain that failed or did not finish before it is given up
+	maxAttempts = 3
+
+	// retryBackoff wait before the first retry of a chain that failed, it doubles with every attempt
+	retryBackoff = 30 * time.Second
+)
+
+var errNotQueueable = errors.New("release or action is not stored")
+
+// actionFailedError an action of the chain returned an error, the chain is retried from that action
+type actionFailedError struct {
+	// chain the failed action and the rest of the chain after it
+	chain  []domain.Action
+	lastOK bool
+	err    error
+}
+
+func (e *actionFailedError) Error() string {
+	return fmt.Sprintf("action %v failed: %v", e.chain[0].Name, e.err)
+}
+
+func (e *actionFailedError) Unwrap() error {
+	return e.err
+}
+
+// enqueue store the chain in the queue and hand it to a worker.
+// The chain stays in the queue until it finished, so a chain cut short by a crash runs again on the next start.
+func (s *service) enqueue(p *pendingChain, run func() error) {
+	p.run = run
+
+	if err := s.store(p); err != nil && !errors.Is(err, errNotQueueable) {
+		log.Error().Err(err).Msgf("could not queue action: %v for '%v', holding it in memory", p.chain[0].Name, p.release.TorrentName)
+	}
+
+	atomic.AddInt64(&s.running, 1)
+	s.submit(p)
+}
+
+// submit hand the chain to a worker, the caller counts it in running first
+func (s *service) submit(p *pendingChain) {
+	s.workers.Do(func() {
+		size := s.settings.Size
+		if size < 0 {
+			size = 0
+		}
+
+		s.jobs = make(chan *pendingChain, size)
+
+		for i := 0; i < s.workerCount(); i++ {
+			go s.work()
+		}
+	})
+
+	s.jobs <- p
+}
+
+// workerCount action chains that run at the same time
+func (s *service) workerCount() int {
+	if s.settings.Workers < 1 {
+		return 1
+	}
+
+	return s.settings.Workers
+}
+
+func (s *service) work() {
+	for p := range s.jobs {
+		s.runJob(p)
+	}
+}
+
+// runJob run the chain and remove it from the queue. During shutdown it is left in the queue instead.
+func (s *service) runJob(p *pendingChain) {
+	defer atomic.AddInt64(&s.running, -1)
+
+	s.lock.Lock()
+	stopping := s.stopping
+	s.lock.Unlock()
+
+	if stopping {
+		s.persist(p)
+		return
+	}
+
+	for {
+		p.attempts++
+		s.updateItem(p)
+
+		err := runSafe(p.run)
+		if err == nil {
+			s.dequeue(p)
+			return
+		}
+
+		// the rest of a chain whose action gave up runs right away on this worker
+		if !s.retry(p, err) {
+			return
+		}
+	}
+}
+
+// runSafe run fn and turn a panic into an error so a worker survives it
+func runSafe(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	return fn()
+}
+
+// retry run the chain again after a backoff, until it used up its attempts.
+// A chain with a failed action starts again at that action. Once that action used up its attempts
+// the rest of the chain continues with it as failed, retry returns true when that should run now.
+func (s *service) retry(p *pendingChain, err error) bool {
+	var failed *actionFailedError
+	if errors.As(err, &failed) {
+		p.chain = failed.chain
+		p.lastOK = failed.lastOK
+		p.scheduled = true
+		p.run = s.resumeChain(p)
+	}
+
+	action := p.chain[0]
+
+	if p.attempts >= maxAttempts {
+		log.Error().Err(err).Msgf("action chain %v for '%v' failed %d times, giving up", action.Name, p.release.TorrentName, p.attempts)
+
+		if failed == nil {
+			s.dequeue(p)
+			return false
+		}
+
+		s.publishFailed(action, p.release, failed.err)
+
+		if len(p.chain) == 1 {
+			s.dequeue(p)
+			return false
+		}
+
+		// conditional actions after it see it as failed
+		p.chain = p.chain[1:]
+		p.lastOK = false
+		p.scheduled = false
+		p.attempts = 0
+		p.reason = "waiting for a worker"
+		p.run = s.resumeChain(p)
+
+		return true
+	}
+
+	backoff := retryBackoff << (p.attempts - 1)
+	p.runAt = time.Now().Add(backoff)
+	p.reason = "retry after failure: " + strings.SplitN(err.Error(), "\n", 2)[0]
+
+	log.Warn().Err(err).Msgf("action chain %v for '%v' failed, retry %d/%d in %v", action.Name, p.release.TorrentName, p.attempts, maxAttempts-1, backoff)
+
+	if failed != nil {
+		s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
+			ReleaseID:  p.release.ID,
+			FilterID:   p.release.FilterID,
+			Status:     domain.ReleasePushStatusPending,
+			Action:     action.Name,
+			Type:       action.Type,
+			Rejections: []string{failed.err.Error()},
+			Log:        fmt.Sprintf("failed, retry %d/%d in %v", p.attempts, maxAttempts-1, backoff),
+			Timestamp:  time.Now(),
+		})
+	}
+
+	s.updateItem(p)
+	s.runLater(p, p.run)
+
+	return false
+}
+
+// publishFailed record the error of an action that used up its attempts
+func (s *service) publishFailed(action domain.Action, release domain.Release, err error) {
+	s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
+		ReleaseID:  release.ID,
+		FilterID:   release.FilterID,
+		Status:     domain.ReleasePushStatusErr,
+		Action:     action.Name,
+		Type:       action.Type,
+		Rejections: []string{err.Error()},
+		Timestamp:  time.Now(),
+	})
+}
+
+// store queue the chain in the database unless it already is
+func (s *service) store(p *pendingChain) error {
+	if p.item != nil && p.item.ID != 0 {
+		return nil
+	}
+
+	action := p.chain[0]
+
+	if s.queueRepo == nil || p.release.ID == 0 || action.ID == 0 {
+		return errNotQueueable
+	}
+
+	item := &domain.ActionQueueItem{
+		ReleaseID: p.release.ID,
+		ActionID:  action.ID,
+		LastOK:    p.lastOK,
+		RunAt:     p.runAt,
+		Scheduled: p.scheduled,
+		Attempts:  p.attempts,
+		Reason:    p.reason,
+	}
+
+	if err := s.queueRepo.Store(context.Background(), item); err != nil {
+		return err
+	}
+
+	p.item = item
+
+	return nil
+}
+
+// updateItem record the attempts, next run and the action a queued chain continues at
+func (s *service) updateItem(p *pendingChain) {
+	if p.item == nil || p.item.ID == 0 {
+		return
+	}
+
+	p.item.Attempts = p.attempts
+	p.item.ActionID = p.chain[0].ID
+	p.item.LastOK = p.lastOK
+	p.item.Scheduled = p.scheduled
+	p.item.RunAt = p.runAt
+	p.item.Reason = p.reason
+
+	if err := s.queueRepo.Update(context.Background(), p.item); err != nil {
+		log.Error().Err(err).Msgf("could not update queued action: %v", p.item.ID)
+	}
+}
+
+// dequeue remove a finished chain from the queue
+func (s *service) dequeue(p *pendingChain) {
+	if p.item == nil || p.item.ID == 0 {
+		return
+	}
+
+	if err := s.queueRepo.Delete(context.Background(), p.item.ID); err != nil {
+		log.Error().Err(err).Msgf("could not remove queued action: %v", p.item.ID)
+	}
+}
+
+// HandlerCounts action chains on or waiting for a worker, and the ones waiting for their delay, schedule or a retry
+func (s *service) HandlerCounts() map[string]int {
+	s.lock.Lock()
+	scheduled := len(s.pending)
+	s.lock.Unlock()
+
+	return map[string]int{
+		"action_workers":          s.workerCount(),
+		"action_chains_running":   int(atomic.LoadInt64(&s.running)),
+		"action_chains_scheduled": scheduled,
+	}
+}
@@ -0,0 +1,149 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/indexer"
+)
+
+func Test_service_RunActions_queue(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue, bus: EventBus.New(), settings: domain.ActionQueueSettings{Workers: 2, Size: 4}}
+
+	release := domain.Release{ID: 1, TorrentName: "That Movie 2021"}
+
+	var actions []domain.Action
+	for i := 1; i <= 2+4+10; i++ {
+		actions = append(actions, domain.Action{ID: i, Name: "test", Type: domain.ActionTypeTest, Enabled: true})
+	}
+
+	// more chains than fit in the queue, RunActions waits for the workers instead of failing
+	require.NoError(t, s.RunActions(actions, release))
+	require.NoError(t, s.waitRunning(context.Background()))
+
+	// finished chains leave the queue
+	items, err := queue.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, int64(len(actions)), queue.next)
+}
+
+func Test_service_runJob_retry(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue}
+
+	p := &pendingChain{chain: []domain.Action{{ID: 2, Name: "panics"}}, release: domain.Release{ID: 1}, runAt: time.Now()}
+	require.NoError(t, s.store(p))
+
+	run := func() error { panic("client went away") }
+	p.run = run
+
+	// a chain that panicked stays queued and runs again after the backoff
+	s.runJob(p)
+
+	items, _ := queue.List(context.Background())
+	require.Len(t, items, 1)
+	assert.Equal(t, 1, items[0].Attempts)
+	assert.WithinDuration(t, time.Now().Add(retryBackoff), items[0].RunAt, time.Second)
+	assert.Len(t, s.pending, 1)
+
+	// until it used up its attempts
+	p.timer.Stop()
+	p.attempts = maxAttempts - 1
+	s.runJob(p)
+
+	items, _ = queue.List(context.Background())
+	assert.Empty(t, items)
+}
+
+type mockIndexerService struct {
+	indexer.Service
+	err error
+}
+
+func (m *mockIndexerService) DownloadTorrentFile(release *domain.Release) error {
+	return m.err
+}
+
+func Test_service_runJob_actionFailed(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	bus := EventBus.New()
+
+	var statuses []string
+	var mu sync.Mutex
+	record := func(status *domain.ReleaseActionStatus) {
+		mu.Lock()
+		statuses = append(statuses, status.Action+":"+string(status.Status))
+		mu.Unlock()
+	}
+	require.NoError(t, bus.Subscribe("release:push-approved", record))
+	require.NoError(t, bus.Subscribe("release:store-action-status", record))
+
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue, bus: bus, indexerSvc: &mockIndexerService{err: errors.New("tracker down")}}
+
+	// the torrent file can't be downloaded for exec
+	release := domain.Release{ID: 1, TorrentName: "That Movie 2021", TorrentURL: "https://tracker.example.com/dl/1"}
+	chain := []domain.Action{
+		{ID: 1, Name: "ok", Type: domain.ActionTypeTest},
+		{ID: 2, Name: "fail", Type: domain.ActionTypeExec, ExecCmd: "true"},
+		{ID: 3, Name: "fallback", Type: domain.ActionTypeTest, RunCondition: domain.ActionRunConditionOnFailure},
+	}
+
+	p := &pendingChain{chain: chain, release: release, lastOK: true, runAt: time.Now()}
+	require.NoError(t, s.store(p))
+	p.run = s.resumeChain(p)
+
+	// the failed action stays queued and is retried after the backoff, the ones before it don't run again
+	s.runJob(p)
+
+	items, _ := queue.List(context.Background())
+	require.Len(t, items, 1)
+	assert.Equal(t, 2, items[0].ActionID)
+	assert.True(t, items[0].LastOK)
+	assert.True(t, items[0].Scheduled)
+	assert.Equal(t, 1, items[0].Attempts)
+	assert.WithinDuration(t, time.Now().Add(retryBackoff), items[0].RunAt, time.Second)
+	assert.Contains(t, items[0].Reason, "retry after failure")
+	assert.Equal(t, []string{"ok:PUSH_APPROVED", "fail:PENDING"}, statuses)
+
+	// once it used up its attempts it is recorded as failed and the rest of the chain runs
+	p.timer.Stop()
+	delete(s.pending, p)
+	p.attempts = maxAttempts - 1
+	s.runJob(p)
+
+	items, _ = queue.List(context.Background())
+	assert.Empty(t, items)
+	assert.Equal(t, []string{"ok:PUSH_APPROVED", "fail:PENDING", "fail:PUSH_ERROR", "fallback:PUSH_APPROVED"}, statuses)
+}
+
+func Test_service_ResumeQueued_attempts(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue}
+
+	// started as often as allowed without finishing, a crash each time
+	require.NoError(t, queue.Store(context.Background(), &domain.ActionQueueItem{ReleaseID: 1, ActionID: 2, Attempts: maxAttempts}))
+
+	require.NoError(t, s.ResumeQueued(context.Background()))
+
+	items, _ := queue.List(context.Background())
+	assert.Empty(t, items)
+}
@@ -0,0 +1,93 @@
+package action
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	// ReannounceMaxAttempts default attempts before giving up
+	ReannounceMaxAttempts = 50
+	// ReannounceInterval default seconds between attempts
+	ReannounceInterval = 7
+)
+
+// reannouncer client specific part of the reannounce loop
+type reannouncer interface {
+	// announceOK whether a tracker knows the torrent and has seeds or peers for it
+	announceOK(hash string) (bool, error)
+	reannounce(hash string) error
+	delete(hash string) error
+}
+
+type reannounceOptions struct {
+	// InitialDelay give the tracker a head start before the first check
+	InitialDelay time.Duration
+	Interval     time.Duration
+	MaxAttempts  int
+	// Delete remove the torrent from the client when giving up
+	Delete bool
+}
+
+func reannounceOptionsFromAction(action domain.Action) reannounceOptions {
+	opts := reannounceOptions{
+		InitialDelay: 6 * time.Second,
+		Interval:     time.Duration(action.ReannounceInterval) * time.Second,
+		MaxAttempts:  action.ReannounceMaxAttempts,
+		Delete:       action.ReannounceDelete,
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = ReannounceInterval * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = ReannounceMaxAttempts
+	}
+
+	return opts
+}
+
+// reannounce poll the client until the torrent has seeds or peers, forcing a reannounce after every failed check.
+// Returns an error when it gives up so the release is marked as failed.
+func reannounce(r reannouncer, hash string, opts reannounceOptions) error {
+	time.Sleep(opts.InitialDelay)
+
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		ok, err := r.announceOK(hash)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			log.Debug().Msgf("re-announce for %v OK after %d attempts", hash, attempt)
+			return nil
+		}
+
+		log.Trace().Msgf("no seeds or peers yet, re-announce %v attempt: %d/%d", hash, attempt, opts.MaxAttempts)
+
+		if err := r.reannounce(hash); err != nil {
+			log.Error().Err(err).Msgf("could not re-announce torrent: %v", hash)
+			return err
+		}
+
+		// add delay for next run
+		time.Sleep(opts.Interval)
+	}
+
+	log.Debug().Msgf("re-announce for %v gave up after %d attempts", hash, opts.MaxAttempts)
+
+	if opts.Delete {
+		if err := r.delete(hash); err != nil {
+			log.Error().Stack().Err(err).Msgf("could not delete torrent: %v", hash)
+			return err
+		}
+
+		return fmt.Errorf("no seeds or peers after %d re-announce attempts, torrent deleted", opts.MaxAttempts)
+	}
+
+	return fmt.Errorf("no seeds or peers after %d re-announce attempts", opts.MaxAttempts)
+}
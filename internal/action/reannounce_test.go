@@ -0,0 +1,98 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/autobrr/autobrr/pkg/qbittorrent"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReannouncer struct {
+	okAfter     int
+	checks      int
+	reannounced int
+	deleted     bool
+}
+
+func (m *mockReannouncer) announceOK(hash string) (bool, error) {
+	m.checks++
+	return m.okAfter > 0 && m.checks >= m.okAfter, nil
+}
+
+func (m *mockReannouncer) reannounce(hash string) error {
+	m.reannounced++
+	return nil
+}
+
+func (m *mockReannouncer) delete(hash string) error {
+	m.deleted = true
+	return nil
+}
+
+func Test_reannounce(t *testing.T) {
+	tests := []struct {
+		name            string
+		okAfter         int
+		delete          bool
+		wantErr         bool
+		wantReannounced int
+		wantDeleted     bool
+	}{
+		{name: "ok_first_check", okAfter: 1, wantReannounced: 0},
+		{name: "ok_after_reannounce", okAfter: 3, wantReannounced: 2},
+		{name: "give_up", okAfter: 0, wantErr: true, wantReannounced: 5},
+		{name: "give_up_delete", okAfter: 0, delete: true, wantErr: true, wantReannounced: 5, wantDeleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &mockReannouncer{okAfter: tt.okAfter}
+
+			err := reannounce(r, "abc123", reannounceOptions{MaxAttempts: 5, Delete: tt.delete})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantReannounced, r.reannounced)
+			assert.Equal(t, tt.wantDeleted, r.deleted)
+		})
+	}
+}
+
+func Test_findTrackerStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		trackers []qbittorrent.TorrentTracker
+		want     bool
+	}{
+		{
+			name: "working_with_seeds",
+			trackers: []qbittorrent.TorrentTracker{
+				{Url: "** [DHT] **", Status: qbittorrent.TrackerStatusDisabled},
+				{Url: "https://tracker.example.test/announce", Status: qbittorrent.TrackerStatusOK, NumSeeds: 1},
+			},
+			want: true,
+		},
+		{
+			name: "working_no_seeds",
+			trackers: []qbittorrent.TorrentTracker{
+				{Url: "https://tracker.example.test/announce", Status: qbittorrent.TrackerStatusOK},
+			},
+			want: false,
+		},
+		{
+			name: "not_working",
+			trackers: []qbittorrent.TorrentTracker{
+				{Url: "https://tracker.example.test/announce", Status: qbittorrent.TrackerStatusNotWorking, Message: "unregistered torrent"},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, findTrackerStatus(tt.trackers))
+		})
+	}
+}
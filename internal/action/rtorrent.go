@@ -0,0 +1,117 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/rtorrent"
+)
+
+func (s *service) rtorrent(action domain.Action, release domain.Release) error {
+	log.Debug().Msgf("action rTorrent: %v", action.Name)
+
+	// get client for action
+	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("error finding client: %v", action.ClientID)
+		return err
+	}
+
+	if client == nil {
+		return errors.New("no client found")
+	}
+
+	rt := rtorrent.New(rtorrent.Config{
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	// macros handle args and replace vars
+	m := NewMacro(release)
+
+	opts := rtorrent.AddOptions{
+		Paused:   action.Paused,
+		Priority: rtorrentPriority(action.BandwidthPriority),
+	}
+
+	if action.Label != "" {
+		// parse and replace values in argument string before continuing
+		labelArgs, err := m.Parse(action.Label)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Label)
+			return err
+		}
+
+		opts.Label = labelArgs
+	}
+	if action.SavePath != "" {
+		// parse and replace values in argument string before continuing
+		savePathArgs, err := m.Parse(action.SavePath)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.SavePath)
+			return err
+		}
+
+		opts.Directory = savePathArgs
+	}
+
+	log.Trace().Msgf("action rTorrent options: %+v", opts)
+
+	if release.HasMagnet() {
+		if err := rt.AddMagnet(release.MagnetURI, opts); err != nil {
+			log.Error().Stack().Err(err).Msgf("could not add magnet %v to client: %v", release.TorrentName, client.Name)
+			return err
+		}
+
+		log.Info().Msgf("magnet %v successfully added to client: '%v'", release.TorrentName, client.Name)
+
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(release.TorrentTmpFile)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not read torrent file: %v", release.TorrentTmpFile)
+		return err
+	}
+
+	if action.FastResume {
+		if opts.Directory == "" {
+			return errors.New("fast resume needs a save path")
+		}
+
+		data, err = rtorrent.AddFastResume(data, opts.Directory)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not add fast resume data for: %v", release.TorrentName)
+			return err
+		}
+	}
+
+	if err := rt.AddTorrent(data, opts); err != nil {
+		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentTmpFile, client.Name)
+		return err
+	}
+
+	log.Info().Msgf("torrent with hash %v successfully added to client: '%v'", release.TorrentHash, client.Name)
+
+	return nil
+}
+
+// rtorrentPriority map the action priority -1, 0, 1 to rtorrent low, normal, high
+func rtorrentPriority(priority int) int {
+	switch {
+	case priority > 0:
+		return 3
+	case priority < 0:
+		return 1
+	}
+
+	return 0
+}
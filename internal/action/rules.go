@@ -0,0 +1,55 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const mib = 1024 * 1024
+
+// clientStats snapshot of a download client, only the values needed by the enabled rules are filled
+type clientStats struct {
+	ActiveDownloads int
+	// DownloadSpeed total download speed in bytes/s
+	DownloadSpeed int64
+	Seeding       int
+	// FreeSpace in bytes
+	FreeSpace int64
+}
+
+// checkClientRules returns why the rules block adding a torrent, or an empty string if it can be added
+func checkClientRules(rules domain.DownloadClientRules, stats clientStats) string {
+	if rules.MaxActiveDownloads > 0 && stats.ActiveDownloads >= rules.MaxActiveDownloads {
+		// if max active downloads reached, check speed and if lower than threshold add anyways
+		// speed is in bytes so lets convert to KB to match DownloadSpeedThreshold
+		if !rules.IgnoreSlowTorrents || stats.DownloadSpeed/1024 >= rules.DownloadSpeedThreshold {
+			return "max active downloads reached, skipping"
+		}
+	}
+
+	if rules.MaxSeeding > 0 && stats.Seeding > rules.MaxSeeding {
+		return fmt.Sprintf("seeding %d torrents, more than max %d, skipping", stats.Seeding, rules.MaxSeeding)
+	}
+
+	if rules.MinFreeSpace > 0 && stats.FreeSpace < rules.MinFreeSpace*mib {
+		return fmt.Sprintf("free space %d MiB below min %d MiB, skipping", stats.FreeSpace/mib, rules.MinFreeSpace)
+	}
+
+	return ""
+}
+
+// rulesBlockedError the client rules did not allow the release, it can be retried later
+type rulesBlockedError struct {
+	Reason string
+	Rules  domain.DownloadClientRules
+}
+
+func (e *rulesBlockedError) Error() string {
+	return e.Reason
+}
+
+// canDefer whether the release can be retried again after the given number of attempts
+func (e *rulesBlockedError) canDefer(attempt int) bool {
+	return e.Rules.DeferAttempts > attempt
+}
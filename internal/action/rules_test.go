@@ -0,0 +1,79 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkClientRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   domain.DownloadClientRules
+		stats   clientStats
+		blocked bool
+	}{
+		{
+			name:  "no_limits",
+			rules: domain.DownloadClientRules{Enabled: true},
+			stats: clientStats{ActiveDownloads: 10, Seeding: 100},
+		},
+		{
+			name:    "max_active_downloads",
+			rules:   domain.DownloadClientRules{Enabled: true, MaxActiveDownloads: 2},
+			stats:   clientStats{ActiveDownloads: 2},
+			blocked: true,
+		},
+		{
+			name:  "max_active_downloads_slow",
+			rules: domain.DownloadClientRules{Enabled: true, MaxActiveDownloads: 2, IgnoreSlowTorrents: true, DownloadSpeedThreshold: 500},
+			stats: clientStats{ActiveDownloads: 3, DownloadSpeed: 100 * 1024},
+		},
+		{
+			name:    "max_active_downloads_fast",
+			rules:   domain.DownloadClientRules{Enabled: true, MaxActiveDownloads: 2, IgnoreSlowTorrents: true, DownloadSpeedThreshold: 500},
+			stats:   clientStats{ActiveDownloads: 3, DownloadSpeed: 1000 * 1024},
+			blocked: true,
+		},
+		{
+			name:  "max_seeding_at_limit",
+			rules: domain.DownloadClientRules{Enabled: true, MaxSeeding: 50},
+			stats: clientStats{Seeding: 50},
+		},
+		{
+			name:    "max_seeding_above_limit",
+			rules:   domain.DownloadClientRules{Enabled: true, MaxSeeding: 50},
+			stats:   clientStats{Seeding: 51},
+			blocked: true,
+		},
+		{
+			name:  "free_space_ok",
+			rules: domain.DownloadClientRules{Enabled: true, MinFreeSpace: 1024},
+			stats: clientStats{FreeSpace: 2048 * mib},
+		},
+		{
+			name:    "free_space_low",
+			rules:   domain.DownloadClientRules{Enabled: true, MinFreeSpace: 1024},
+			stats:   clientStats{FreeSpace: 512 * mib},
+			blocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkClientRules(tt.rules, tt.stats)
+			assert.Equal(t, tt.blocked, got != "", got)
+		})
+	}
+}
+
+func Test_rulesBlockedError_canDefer(t *testing.T) {
+	blocked := &rulesBlockedError{Reason: "max active downloads reached, skipping", Rules: domain.DownloadClientRules{DeferAttempts: 2}}
+
+	assert.True(t, blocked.canDefer(0))
+	assert.True(t, blocked.canDefer(1))
+	assert.False(t, blocked.canDefer(2))
+
+	assert.False(t, (&rulesBlockedError{}).canDefer(0))
+}
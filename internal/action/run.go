@@ -1,227 +1,476 @@
 package action
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"os"
 	"path"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/metrics"
 )
 
+// defaultDeferInterval wait between retries when the client rules do not set one
+const defaultDeferInterval = 5 * time.Minute
+
 func (s *service) RunActions(actions []domain.Action, release domain.Release) error {
 
+	for _, chain := range buildActionChains(actions) {
+		chain := chain
+
+		p := &pendingChain{chain: chain, release: release, lastOK: true, runAt: time.Now(), reason: "waiting for a worker"}
+		s.enqueue(p, func() error {
+			return s.runActionChain(chain, release)
+		})
+	}
+
+	// safe to delete tmp file
+
+	return nil
+}
+
+// buildActionChains split the enabled actions into chains. Every action that always runs starts a new chain,
+// conditional actions are added to the chain before them so they can depend on its outcome.
+func buildActionChains(actions []domain.Action) [][]domain.Action {
+	var chains [][]domain.Action
+
 	for _, action := range actions {
 		// only run active actions
 		if !action.Enabled {
 			continue
 		}
 
-		log.Debug().Msgf("process action: %v for '%v'", action.Name, release.TorrentName)
+		if len(chains) == 0 || action.RunCondition.Always() {
+			chains = append(chains, []domain.Action{action})
+			continue
+		}
+
+		chains[len(chains)-1] = append(chains[len(chains)-1], action)
+	}
+
+	return chains
+}
+
+// runActionChain run the actions in order, skipping conditional actions that do not match the outcome of the last action that ran
+func (s *service) runActionChain(chain []domain.Action, release domain.Release) error {
+	return s.runActionChainAttempt(chain, release, true, 0, false)
+}
 
-		go func(release domain.Release, action domain.Action) {
-			err := s.runAction(action, release)
+// runActionChainAttempt run the chain, starting with lastOK as the outcome of the action before it.
+// When client rules block an action the rest of the chain is retried later if the rules allow it.
+// Actions with a delay or schedule window hold the rest of the chain until they may run,
+// scheduled is set when the first action already waited for that.
+// An action that fails stops the chain with an actionFailedError, so the worker can retry it.
+func (s *service) runActionChainAttempt(chain []domain.Action, release domain.Release, lastOK bool, attempt int, scheduled bool) error {
+	for i, action := range chain {
+		if !action.RunCondition.Match(lastOK) {
+			log.Debug().Msgf("skip action: %v for '%v', run condition %v not met", action.Name, release.TorrentName, action.RunCondition)
+			continue
+		}
+
+		if i > 0 || !scheduled {
+			now := time.Now()
+
+			runAt, err := action.NextRunAt(now)
 			if err != nil {
-				log.Err(err).Stack().Msgf("process action failed: %v for '%v'", action.Name, release.TorrentName)
+				log.Error().Err(err).Msgf("could not schedule action: %v for '%v'", action.Name, release.TorrentName)
 
 				s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
 					ReleaseID:  release.ID,
+					FilterID:   release.FilterID,
 					Status:     domain.ReleasePushStatusErr,
 					Action:     action.Name,
 					Type:       action.Type,
 					Rejections: []string{err.Error()},
-					Timestamp:  time.Now(),
+					Timestamp:  now,
 				})
-				return
+
+				lastOK = false
+				continue
 			}
-		}(release, action)
-	}
 
-	// safe to delete tmp file
+			if runAt.After(now) {
+				s.scheduleActionChain(chain[i:], release, lastOK, runAt)
+				return nil
+			}
+		}
+
+		log.Debug().Msgf("process action: %v for '%v'", action.Name, release.TorrentName)
+
+		started := time.Now()
+		approved, err := s.runAction(action, release)
+		metrics.ObserveDuration(metrics.ReleasePipelineDuration, started, metrics.StageAction)
+
+		var blocked *rulesBlockedError
+		if errors.As(err, &blocked) {
+			if blocked.canDefer(attempt) {
+				s.deferActionChain(chain[i:], release, lastOK, attempt, blocked)
+				return nil
+			}
+
+			s.bus.Publish("release:push-rejected", &domain.ReleaseActionStatus{
+				ReleaseID:  release.ID,
+				FilterID:   release.FilterID,
+				Status:     domain.ReleasePushStatusRejected,
+				Action:     action.Name,
+				Type:       action.Type,
+				Rejections: []string{blocked.Reason},
+				Timestamp:  time.Now(),
+			})
+
+			lastOK = false
+			continue
+		}
+
+		if err != nil {
+			log.Err(err).Stack().Msgf("process action failed: %v for '%v'", action.Name, release.TorrentName)
+
+			return &actionFailedError{chain: chain[i:], lastOK: lastOK, err: err}
+		}
+
+		lastOK = approved
+	}
 
 	return nil
 }
 
-func (s *service) runAction(action domain.Action, release domain.Release) error {
+// deferActionChain mark the blocked action as pending and run the rest of the chain again after the defer interval
+func (s *service) deferActionChain(chain []domain.Action, release domain.Release, lastOK bool, attempt int, blocked *rulesBlockedError) {
+	interval := time.Duration(blocked.Rules.DeferInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeferInterval
+	}
+
+	action := chain[0]
+
+	log.Info().Msgf("action %v for '%v' deferred, retry %d/%d in %v: %v", action.Name, release.TorrentName, attempt+1, blocked.Rules.DeferAttempts, interval, blocked.Reason)
+
+	s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
+		ReleaseID:  release.ID,
+		FilterID:   release.FilterID,
+		Status:     domain.ReleasePushStatusPending,
+		Action:     action.Name,
+		Type:       action.Type,
+		Rejections: []string{blocked.Reason},
+		Log:        fmt.Sprintf("deferred by client rules, retry %d/%d in %v", attempt+1, blocked.Rules.DeferAttempts, interval),
+		Timestamp:  time.Now(),
+	})
+
+	p := &pendingChain{chain: chain, release: release, lastOK: lastOK, runAt: time.Now().Add(interval), scheduled: true, reason: "deferred by client rules: " + blocked.Reason}
+	if err := s.store(p); err != nil && !errors.Is(err, errNotQueueable) {
+		log.Error().Err(err).Msgf("could not queue deferred action: %v for '%v', holding it in memory", action.Name, release.TorrentName)
+	}
+
+	s.runLater(p, func() error {
+		return s.runActionChainAttempt(chain, release, lastOK, attempt+1, true)
+	})
+}
+
+func (s *service) runAction(action domain.Action, release domain.Release) (bool, error) {
+	if !usesDownloadClient(action) {
+		return s.pushAction(action, &release, "")
+	}
+
+	clients, err := s.clientSvc.FailoverChain(context.TODO(), action.ClientID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not find client for action: %v", action.Name)
+		return false, err
+	}
+
+	var skipped []domain.DownloadClient
+
+	for i := range clients {
+		client := clients[i]
+		action.ClientID = int32(client.ID)
+
+		var actionLog string
+		if len(skipped) > 0 {
+			actionLog = failoverLog(skipped, &client)
+			log.Warn().Msgf("action %v: %v", action.Name, actionLog)
+		}
+
+		ok, err := s.pushAction(action, &release, actionLog)
+		if err == nil || !isConnectionError(err) || i == len(clients)-1 {
+			return ok, err
+		}
+
+		log.Warn().Err(err).Msgf("action %v: could not connect to client %v, trying the failover client", action.Name, client.Name)
+
+		s.clientSvc.MarkUnreachable(client, err)
+		skipped = append(skipped, client)
+	}
+
+	return false, nil
+}
+
+// pushAction send the release with the action, the torrent file it downloads is kept on the release for a failover client
+func (s *service) pushAction(action domain.Action, release *domain.Release, actionLog string) (bool, error) {
+	started := time.Now()
+	fetched := release.Timings.Fetch
 
 	var err error
 	var rejections []string
 
+	if rejection := checkActionProtocol(action, *release); rejection != "" {
+		s.bus.Publish("release:push-rejected", &domain.ReleaseActionStatus{
+			ReleaseID:  release.ID,
+			FilterID:   release.FilterID,
+			Status:     domain.ReleasePushStatusRejected,
+			Action:     action.Name,
+			Type:       action.Type,
+			Rejections: []string{rejection},
+			Log:        actionLog,
+			Timestamp:  time.Now(),
+		})
+
+		return false, nil
+	}
+
 	switch action.Type {
 	case domain.ActionTypeTest:
 		s.test(action.Name)
 
 	case domain.ActionTypeExec:
-		if release.TorrentTmpFile == "" {
-			if err := release.DownloadTorrentFile(nil); err != nil {
+		if release.TorrentTmpFile == "" && !release.HasMagnet() && !release.IsUsenet() {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
 				log.Error().Stack().Err(err)
-				return err
+				return false, err
 			}
 		}
 
-		s.execCmd(release, action)
+		actionLog, err = s.execCmd(*release, action)
+		if err != nil {
+			rejections = []string{fmt.Sprintf("exec failed: %v", err)}
+		}
 
 	case domain.ActionTypeWatchFolder:
+		if release.HasMagnet() {
+			rejections = []string{"watch folder needs a torrent file, release only has a magnet link"}
+			break
+		}
+
 		if release.TorrentTmpFile == "" {
-			if err := release.DownloadTorrentFile(nil); err != nil {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
 				log.Error().Stack().Err(err)
-				return err
+				return false, err
 			}
 		}
 
-		s.watchFolder(action, release)
+		err = s.watchFolder(action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error saving torrent to watch folder")
+			return false, err
+		}
 
 	case domain.ActionTypeDelugeV1, domain.ActionTypeDelugeV2:
-		canDownload, err := s.delugeCheckRulesCanDownload(action)
-		if err != nil {
+		if err := s.delugeCheckRulesCanDownload(action); err != nil {
 			log.Error().Stack().Err(err).Msgf("error checking client rules: %v", action.Name)
-			return err
-		}
-		if !canDownload {
-			rejections = []string{"max active downloads reached, skipping"}
-			break
+			return false, err
 		}
 
-		if release.TorrentTmpFile == "" {
-			if err := release.DownloadTorrentFile(nil); err != nil {
+		if release.TorrentTmpFile == "" && !release.HasMagnet() {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
 				log.Error().Stack().Err(err)
-				return err
+				return false, err
 			}
 		}
 
-		err = s.deluge(action, release)
+		err = s.deluge(action, *release)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error sending torrent to Deluge")
-			return err
+			return false, err
 		}
 
 	case domain.ActionTypeQbittorrent:
-		canDownload, client, err := s.qbittorrentCheckRulesCanDownload(action)
+		client, err := s.qbittorrentCheckRulesCanDownload(action)
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("error checking client rules: %v", action.Name)
-			return err
-		}
-		if !canDownload {
-			rejections = []string{"max active downloads reached, skipping"}
-			break
+			return false, err
 		}
 
-		if release.TorrentTmpFile == "" {
-			if err := release.DownloadTorrentFile(nil); err != nil {
+		if release.TorrentTmpFile == "" && !release.HasMagnet() {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
 				log.Error().Stack().Err(err)
-				return err
+				return false, err
 			}
 		}
 
-		err = s.qbittorrent(client, action, release)
+		err = s.qbittorrent(client, action, *release)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error sending torrent to qBittorrent")
-			return err
+			return false, err
+		}
+
+	case domain.ActionTypeTransmission:
+		tbt, client, err := s.transmissionCheckRulesCanDownload(action)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("error checking client rules: %v", action.Name)
+			return false, err
+		}
+
+		if release.TorrentTmpFile == "" && !release.HasMagnet() {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
+				log.Error().Stack().Err(err)
+				return false, err
+			}
+		}
+
+		err = s.transmission(tbt, client, action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending torrent to Transmission")
+			return false, err
+		}
+
+	case domain.ActionTypeRTorrent:
+		if release.TorrentTmpFile == "" && !release.HasMagnet() {
+			if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
+				log.Error().Stack().Err(err)
+				return false, err
+			}
+		}
+
+		err = s.rtorrent(action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending torrent to rTorrent")
+			return false, err
+		}
+
+	case domain.ActionTypeWebhook:
+		err = s.webhook(action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending webhook")
+			return false, err
 		}
 
 	case domain.ActionTypeRadarr:
-		rejections, err = s.radarr(release, action)
+		rejections, err = s.radarr(*release, action)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error sending torrent to radarr")
-			return err
+			return false, err
 		}
 
 	case domain.ActionTypeSonarr:
-		rejections, err = s.sonarr(release, action)
+		rejections, err = s.sonarr(*release, action)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error sending torrent to sonarr")
-			return err
+			return false, err
 		}
 
 	case domain.ActionTypeLidarr:
-		rejections, err = s.lidarr(release, action)
+		rejections, err = s.lidarr(*release, action)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error sending torrent to lidarr")
-			return err
+			return false, err
+		}
+
+	case domain.ActionTypeWhisparr:
+		rejections, err = s.whisparr(*release, action)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending torrent to whisparr")
+			return false, err
+		}
+
+	case domain.ActionTypeReadarr:
+		rejections, err = s.readarr(*release, action)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending torrent to readarr")
+			return false, err
+		}
+
+	case domain.ActionTypeSabnzbd:
+		err = s.sabnzbd(action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending nzb to SABnzbd")
+			return false, err
+		}
+
+	case domain.ActionTypeNzbget:
+		err = s.nzbget(action, *release)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("error sending nzb to NZBGet")
+			return false, err
 		}
 
 	default:
 		log.Warn().Msgf("unsupported action: %v type: %v", action.Name, action.Type)
-		return nil
+		return false, nil
+	}
+
+	// keep the torrent file download retries with the action result
+	if release.DownloadLog != "" {
+		actionLog = strings.TrimSpace(release.DownloadLog + "\n" + actionLog)
 	}
 
 	if rejections != nil {
 		s.bus.Publish("release:push-rejected", &domain.ReleaseActionStatus{
 			ReleaseID:  release.ID,
+			FilterID:   release.FilterID,
 			Status:     domain.ReleasePushStatusRejected,
 			Action:     action.Name,
 			Type:       action.Type,
 			Rejections: rejections,
+			Log:        actionLog,
 			Timestamp:  time.Now(),
 		})
 
-		return nil
+		return false, nil
 	}
 
+	s.storePushTimings(*release, time.Since(started), fetched)
+
 	s.bus.Publish("release:push-approved", &domain.ReleaseActionStatus{
 		ReleaseID:  release.ID,
+		FilterID:   release.FilterID,
 		Status:     domain.ReleasePushStatusApproved,
 		Action:     action.Name,
 		Type:       action.Type,
 		Rejections: []string{},
+		Log:        actionLog,
 		Timestamp:  time.Now(),
 	})
 
-	return nil
+	return true, nil
 }
 
-func (s *service) CheckCanDownload(actions []domain.Action) bool {
-	for _, action := range actions {
-		if !action.Enabled {
-			// only run active actions
-			continue
-		}
-
-		log.Debug().Msgf("action-service: check can download action: %v", action.Name)
-
-		switch action.Type {
-		case domain.ActionTypeDelugeV1, domain.ActionTypeDelugeV2:
-			canDownload, err := s.delugeCheckRulesCanDownload(action)
-			if err != nil {
-				log.Error().Stack().Err(err).Msgf("error checking client rules: %v", action.Name)
-				continue
-			}
-			if !canDownload {
-				continue
-			}
-
-			return true
+// storePushTimings record the time the action took to send the release, apart from the torrent file download
+func (s *service) storePushTimings(release domain.Release, took time.Duration, fetched int64) {
+	if s.releaseRepo == nil || release.ID == 0 {
+		return
+	}
 
-		case domain.ActionTypeQbittorrent:
-			canDownload, _, err := s.qbittorrentCheckRulesCanDownload(action)
-			if err != nil {
-				log.Error().Stack().Err(err).Msgf("error checking client rules: %v", action.Name)
-				continue
-			}
-			if !canDownload {
-				continue
-			}
+	release.Timings.Push = took.Microseconds() - (release.Timings.Fetch - fetched)
 
-			return true
-		}
+	if err := s.releaseRepo.StorePushTimings(context.Background(), release.ID, release.Timings); err != nil {
+		log.Error().Err(err).Msgf("could not store timings for release: %v", release.TorrentName)
 	}
-
-	return false
 }
 
 func (s *service) test(name string) {
 	log.Info().Msgf("action TEST: %v", name)
 }
 
-func (s *service) watchFolder(action domain.Action, release domain.Release) {
+func (s *service) watchFolder(action domain.Action, release domain.Release) error {
 	m := NewMacro(release)
 
 	// parse and replace values in argument string before continuing
 	watchFolderArgs, err := m.Parse(action.WatchFolder)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.WatchFolder)
+		return err
+	}
+
+	fileName, err := watchFolderFileName(m, action.WatchFolderFileName, release)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not parse file name macro: %v", action.WatchFolderFileName)
+		return err
 	}
 
 	log.Trace().Msgf("action WATCH_FOLDER: %v file: %v", watchFolderArgs, release.TorrentTmpFile)
@@ -230,18 +479,22 @@ func (s *service) watchFolder(action domain.Action, release domain.Release) {
 	original, err := os.Open(release.TorrentTmpFile)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("could not open temp file '%v'", release.TorrentTmpFile)
-		return
+		return err
 	}
 	defer original.Close()
 
-	_, tmpFileName := path.Split(release.TorrentTmpFile)
-	fullFileName := path.Join(watchFolderArgs, tmpFileName+".torrent")
+	if err := os.MkdirAll(watchFolderArgs, 0755); err != nil {
+		log.Error().Stack().Err(err).Msgf("could not create watch folder '%v'", watchFolderArgs)
+		return err
+	}
+
+	fullFileName := path.Join(watchFolderArgs, fileName)
 
 	// Create new file
 	newFile, err := os.Create(fullFileName)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("could not create new temp file '%v'", fullFileName)
-		return
+		return err
 	}
 	defer newFile.Close()
 
@@ -249,8 +502,100 @@ func (s *service) watchFolder(action domain.Action, release domain.Release) {
 	_, err = io.Copy(newFile, original)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("could not copy file %v to watch folder", fullFileName)
-		return
+		return err
 	}
 
 	log.Info().Msgf("saved file to watch folder: %v", fullFileName)
+
+	return nil
+}
+
+// watchFolderFileName render the file name template, falling back to the temp file name
+func watchFolderFileName(m Macro, tmpl string, release domain.Release) (string, error) {
+	var fileName string
+
+	if tmpl != "" {
+		parsed, err := m.Parse(tmpl)
+		if err != nil {
+			return "", err
+		}
+
+		fileName = sanitizeFileName(parsed)
+	}
+
+	if fileName == "" {
+		_, fileName = path.Split(release.TorrentTmpFile)
+	}
+
+	if !strings.HasSuffix(strings.ToLower(fileName), ".torrent") {
+		fileName += ".torrent"
+	}
+
+	return fileName, nil
+}
+
+// sanitizeFileName strip characters that are not allowed in file names on common file systems
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 32 {
+			return -1
+		}
+		return r
+	}, name)
+
+	return strings.Trim(strings.TrimSpace(name), ".")
+}
+
+// usesDownloadClient action sends to a configured download client, which can fail over to a backup client
+func usesDownloadClient(action domain.Action) bool {
+	switch action.Type {
+	case domain.ActionTypeTest, domain.ActionTypeExec, domain.ActionTypeWatchFolder, domain.ActionTypeWebhook:
+		return false
+	}
+
+	return action.ClientID != 0
+}
+
+func failoverLog(skipped []domain.DownloadClient, client *domain.DownloadClient) string {
+	names := make([]string, 0, len(skipped))
+	for _, c := range skipped {
+		names = append(names, c.Name)
+	}
+
+	return fmt.Sprintf("failover: %v unreachable, sent to %v", strings.Join(names, ", "), client.Name)
+}
+
+// isConnectionError the client could not be reached, as opposed to a client that refused the release
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EHOSTUNREACH)
+}
+
+// checkActionProtocol make sure torrents only go to torrent clients and nzbs to usenet clients
+func checkActionProtocol(action domain.Action, release domain.Release) string {
+	switch action.Type {
+	case domain.ActionTypeQbittorrent, domain.ActionTypeDelugeV1, domain.ActionTypeDelugeV2,
+		domain.ActionTypeTransmission, domain.ActionTypeRTorrent, domain.ActionTypeWatchFolder:
+		if release.IsUsenet() {
+			return fmt.Sprintf("%v can not handle usenet releases", action.Type)
+		}
+
+	case domain.ActionTypeSabnzbd, domain.ActionTypeNzbget:
+		if !release.IsUsenet() {
+			return fmt.Sprintf("%v can only handle usenet releases", action.Type)
+		}
+		if release.TorrentURL == "" {
+			return "release has no nzb url"
+		}
+	}
+
+	return ""
 }
@@ -0,0 +1,188 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/download_client"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_watchFolderFileName(t *testing.T) {
+	release := domain.Release{
+		TorrentName:    "That Show S01E01 1080p WEB-DL",
+		TorrentTmpFile: "/tmp/autobrr-123456",
+		Indexer:        "mock",
+		Group:          "GROUP",
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", tmpl: "", want: "autobrr-123456.torrent"},
+		{name: "template", tmpl: "[{{ .Indexer }}] {{ .TorrentName }}", want: "[mock] That Show S01E01 1080p WEB-DL.torrent"},
+		{name: "keep_extension", tmpl: "{{ .Group }}.torrent", want: "GROUP.torrent"},
+		{name: "sanitize", tmpl: "{{ .Indexer }}/../{{ .Group }}: new?", want: "mock_.._GROUP_ new_.torrent"},
+		{name: "empty_result", tmpl: "{{ .Filter }}", want: "autobrr-123456.torrent"},
+		{name: "bad_template", tmpl: "{{ .Indexer", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := watchFolderFileName(NewMacro(release), tt.tmpl, release)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_buildActionChains(t *testing.T) {
+	actions := []domain.Action{
+		{Name: "notify_first", Enabled: true, RunCondition: domain.ActionRunConditionOnSuccess},
+		{Name: "qbit", Enabled: true},
+		{Name: "notify", Enabled: true, RunCondition: domain.ActionRunConditionOnSuccess},
+		{Name: "disabled", Enabled: false, RunCondition: domain.ActionRunConditionOnFailure},
+		{Name: "fallback", Enabled: true, RunCondition: domain.ActionRunConditionOnFailure},
+		{Name: "exec", Enabled: true, RunCondition: domain.ActionRunConditionAlways},
+	}
+
+	var got [][]string
+	for _, chain := range buildActionChains(actions) {
+		var names []string
+		for _, action := range chain {
+			names = append(names, action.Name)
+		}
+		got = append(got, names)
+	}
+
+	assert.Equal(t, [][]string{{"notify_first"}, {"qbit", "notify", "fallback"}, {"exec"}}, got)
+}
+
+func Test_service_runActionChain(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	// magnet so exec does not try to download the torrent file
+	release := domain.Release{TorrentName: "That Movie 2021", MagnetURI: "magnet:?xt=urn:btih:mock"}
+
+	ok := domain.Action{Name: "ok", Type: domain.ActionTypeTest}
+	fail := domain.Action{Name: "fail", Type: domain.ActionTypeExec, ExecCmd: "false"}
+
+	onSuccess := func(name string) domain.Action {
+		return domain.Action{Name: name, Type: domain.ActionTypeTest, RunCondition: domain.ActionRunConditionOnSuccess}
+	}
+	onFailure := func(name string) domain.Action {
+		return domain.Action{Name: name, Type: domain.ActionTypeTest, RunCondition: domain.ActionRunConditionOnFailure}
+	}
+
+	tests := []struct {
+		name  string
+		chain []domain.Action
+		want  []string
+	}{
+		{name: "success", chain: []domain.Action{ok, onSuccess("notify"), onFailure("fallback")}, want: []string{"ok", "notify"}},
+		{name: "failure", chain: []domain.Action{fail, onSuccess("notify"), onFailure("fallback"), onSuccess("notify_fallback")}, want: []string{"fail", "fallback", "notify_fallback"}},
+		{name: "delayed", chain: []domain.Action{ok, {Name: "delayed", Type: domain.ActionTypeTest, RunCondition: domain.ActionRunConditionOnSuccess, Delay: 3600}, onSuccess("notify")}, want: []string{"ok"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := EventBus.New()
+
+			var got []string
+			var mu sync.Mutex
+			record := func(status *domain.ReleaseActionStatus) {
+				mu.Lock()
+				got = append(got, status.Action)
+				mu.Unlock()
+			}
+
+			assert.NoError(t, bus.Subscribe("release:push-approved", record))
+			assert.NoError(t, bus.Subscribe("release:push-rejected", record))
+
+			// on its last attempt, so a failed action is not held for a retry
+			s := &service{bus: bus}
+			p := &pendingChain{chain: tt.chain, release: release, lastOK: true, attempts: maxAttempts - 1}
+			p.run = s.resumeChain(p)
+			s.runJob(p)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type mockClientService struct {
+	download_client.Service
+	clients     map[int32]domain.DownloadClient
+	unreachable []int
+}
+
+func (m *mockClientService) FindByID(ctx context.Context, id int32) (*domain.DownloadClient, error) {
+	c, ok := m.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client %v not found", id)
+	}
+	return &c, nil
+}
+
+func (m *mockClientService) FailoverChain(ctx context.Context, id int32) ([]domain.DownloadClient, error) {
+	var chain []domain.DownloadClient
+	for id != 0 {
+		c := m.clients[id]
+		chain = append(chain, c)
+		id = c.Settings.FailoverClientID
+	}
+	return chain, nil
+}
+
+func (m *mockClientService) MarkUnreachable(client domain.DownloadClient, err error) {
+	m.unreachable = append(m.unreachable, client.ID)
+}
+
+func Test_service_runAction_failover(t *testing.T) {
+	var pushes int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushes++
+		w.Write([]byte(`[{"approved": true, "rejected": false, "rejections": []}]`))
+	}))
+	defer ts.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	clientSvc := &mockClientService{clients: map[int32]domain.DownloadClient{
+		1: {ID: 1, Name: "down", Type: domain.DownloadClientTypeRadarr, Host: downURL, Settings: domain.DownloadClientSettings{FailoverClientID: 2}},
+		2: {ID: 2, Name: "backup", Type: domain.DownloadClientTypeRadarr, Host: ts.URL},
+	}}
+
+	bus := EventBus.New()
+
+	var got *domain.ReleaseActionStatus
+	assert.NoError(t, bus.Subscribe("release:push-approved", func(status *domain.ReleaseActionStatus) { got = status }))
+
+	s := &service{bus: bus, clientSvc: clientSvc}
+
+	ok, err := s.runAction(domain.Action{Name: "radarr", Type: domain.ActionTypeRadarr, ClientID: 1}, domain.Release{TorrentName: "Movie.2022.1080p.BluRay.x264-GROUP"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, pushes)
+	assert.Equal(t, []int{1}, clientSvc.unreachable)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "failover: down unreachable, sent to backup", got.Log)
+	}
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/sabnzbd"
+)
+
+func (s *service) sabnzbd(action domain.Action, release domain.Release) error {
+	log.Debug().Msgf("action SABnzbd: %v", action.Name)
+
+	// get client for action
+	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("error finding client: %v", action.ClientID)
+		return err
+	}
+
+	if client == nil {
+		return errors.New("no client found")
+	}
+
+	sab := sabnzbd.New(sabnzbd.Config{
+		Hostname:      client.Host,
+		APIKey:        client.Settings.APIKey,
+		TLSSkipVerify: client.TLSSkipVerify,
+		BasicAuth:     client.Settings.Basic.Auth,
+		Username:      client.Settings.Basic.Username,
+		Password:      client.Settings.Basic.Password,
+	})
+
+	opts := sabnzbd.AddOptions{
+		Name:   release.TorrentName,
+		Paused: action.Paused,
+	}
+
+	if action.Category != "" {
+		// parse and replace values in argument string before continuing
+		categoryArgs, err := NewMacro(release).Parse(action.Category)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Category)
+			return err
+		}
+
+		opts.Category = categoryArgs
+	}
+
+	log.Trace().Msgf("action SABnzbd options: %+v", opts)
+
+	ids, err := sab.AddFromURL(release.TorrentURL, opts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not add nzb %v to client: %v", release.TorrentName, client.Name)
+		return err
+	}
+
+	log.Info().Msgf("nzb %v successfully added to client: '%v' %v", release.TorrentName, client.Name, ids)
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// scheduleActionChain hold the rest of the chain until its first action may run.
+// The action is queued in the database so it is resumed after a restart.
+func (s *service) scheduleActionChain(chain []domain.Action, release domain.Release, lastOK bool, runAt time.Time) {
+	action := chain[0]
+
+	p := &pendingChain{chain: chain, release: release, lastOK: lastOK, runAt: runAt, scheduled: true, reason: scheduleReason(action)}
+
+	// releases or actions that are not stored can only be held in memory
+	if err := s.store(p); err != nil && !errors.Is(err, errNotQueueable) {
+		log.Error().Err(err).Msgf("could not queue action: %v for '%v', holding it in memory", action.Name, release.TorrentName)
+	}
+
+	log.Info().Msgf("action %v for '%v' scheduled to run at %v", action.Name, release.TorrentName, runAt.Format(time.RFC3339))
+
+	s.bus.Publish("release:store-action-status", &domain.ReleaseActionStatus{
+		ReleaseID:  release.ID,
+		FilterID:   release.FilterID,
+		Status:     domain.ReleasePushStatusPending,
+		Action:     action.Name,
+		Type:       action.Type,
+		Rejections: []string{},
+		Log:        fmt.Sprintf("waiting for delay or schedule window, runs at %v", runAt.Format("2006-01-02 15:04:05")),
+		Timestamp:  time.Now(),
+	})
+
+	s.runLater(p, s.resumeChain(p))
+}
+
+// scheduleReason why the action waits for its run time
+func scheduleReason(action domain.Action) string {
+	if action.ScheduleStart == "" && action.ScheduleEnd == "" {
+		return fmt.Sprintf("delay of %v", time.Duration(action.Delay)*time.Second)
+	}
+
+	start, end := action.ScheduleStart, action.ScheduleEnd
+	if start == "" {
+		start = "00:00"
+	}
+	if end == "" {
+		end = "24:00"
+	}
+
+	if action.Delay > 0 {
+		return fmt.Sprintf("delay of %v and schedule window %v-%v", time.Duration(action.Delay)*time.Second, start, end)
+	}
+
+	return fmt.Sprintf("schedule window %v-%v", start, end)
+}
+
+// runQueued run the chain of a queued action when it is due
+func (s *service) runQueued(chain []domain.Action, release domain.Release, item *domain.ActionQueueItem) {
+	p := &pendingChain{chain: chain, release: release, lastOK: item.LastOK, runAt: item.RunAt, scheduled: item.Scheduled, attempts: item.Attempts, reason: item.Reason, item: item}
+	s.runLater(p, s.resumeChain(p))
+}
+
+// resumeChain run the chain of p from its first action, with the outcome and schedule state it is held with
+func (s *service) resumeChain(p *pendingChain) func() error {
+	chain, release, lastOK, scheduled := p.chain, p.release, p.lastOK, p.scheduled
+
+	return func() error {
+		return s.runActionChainAttempt(chain, release, lastOK, 0, scheduled)
+	}
+}
+
+// pendingChain the rest of an action chain waiting for its first action to be due or for a worker
+type pendingChain struct {
+	chain   []domain.Action
+	release domain.Release
+	lastOK  bool
+	runAt   time.Time
+	timer   *time.Timer
+	run     func() error
+
+	// scheduled the first action already waited for its delay or schedule window
+	scheduled bool
+
+	// attempts runs that were started, a run that failed or panicked is retried
+	attempts int
+
+	// reason why the chain is held
+	reason string
+
+	// item in the queue, nil for chains that are only held in memory
+	item *domain.ActionQueueItem
+}
+
+// runLater hand the chain to a worker at its time. During shutdown it goes to the queue instead.
+func (s *service) runLater(p *pendingChain, run func() error) {
+	p.run = run
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopping {
+		s.persist(p)
+		return
+	}
+
+	if s.pending == nil {
+		s.pending = map[*pendingChain]struct{}{}
+	}
+	s.pending[p] = struct{}{}
+
+	p.timer = time.AfterFunc(time.Until(p.runAt), func() {
+		s.lock.Lock()
+		if _, ok := s.pending[p]; !ok {
+			// taken by shutdown, RunPending or CancelPending
+			s.lock.Unlock()
+			return
+		}
+		delete(s.pending, p)
+		atomic.AddInt64(&s.running, 1)
+		s.lock.Unlock()
+
+		s.submit(p)
+	})
+}
+
+// persist queue the chain for ResumeQueued, a deferred retry starts counting its attempts again after the restart
+func (s *service) persist(p *pendingChain) {
+	if p.item != nil && p.item.ID != 0 {
+		return
+	}
+
+	action := p.chain[0]
+
+	if err := s.store(p); err != nil {
+		if errors.Is(err, errNotQueueable) {
+			log.Warn().Msgf("action %v for '%v' can't be queued, it is dropped on shutdown", action.Name, p.release.TorrentName)
+			return
+		}
+
+		log.Error().Err(err).Msgf("could not queue action: %v for '%v' on shutdown", action.Name, p.release.TorrentName)
+		return
+	}
+
+	log.Info().Msgf("queued action %v for '%v', resumes on the next start", action.Name, p.release.TorrentName)
+}
+
+// Shutdown stop handing chains to the workers and queue the chains waiting on a timer,
+// then wait for the running action chains until ctx is done
+func (s *service) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	s.stopping = true
+
+	for p := range s.pending {
+		p.timer.Stop()
+		s.persist(p)
+		delete(s.pending, p)
+	}
+	s.lock.Unlock()
+
+	return s.waitRunning(ctx)
+}
+
+func (s *service) waitRunning(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		running := atomic.LoadInt64(&s.running)
+		if running == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d action chains still running: %w", running, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResumeQueued schedule the actions that were still queued on shutdown or a crash.
+// Actions that are due by now run right away.
+func (s *service) ResumeQueued(ctx context.Context) error {
+	items, err := s.queueRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := items[i]
+
+		if item.Attempts >= maxAttempts {
+			log.Warn().Msgf("dropping queued action %v for release %v, started %d times without finishing", item.ActionID, item.ReleaseID, item.Attempts)
+
+			if err := s.queueRepo.Delete(ctx, item.ID); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		chain, release, err := s.queuedChain(ctx, item)
+		if err != nil {
+			log.Warn().Err(err).Msgf("dropping queued action %v for release %v", item.ActionID, item.ReleaseID)
+
+			if err := s.queueRepo.Delete(ctx, item.ID); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		log.Info().Msgf("resume queued action %v for '%v', runs at %v", chain[0].Name, release.TorrentName, item.RunAt.Format(time.RFC3339))
+
+		s.runQueued(chain, *release, &item)
+	}
+
+	return nil
+}
+
+// queuedChain load the release and the rest of the action chain for a queued action
+func (s *service) queuedChain(ctx context.Context, item domain.ActionQueueItem) ([]domain.Action, *domain.Release, error) {
+	release, err := s.releaseRepo.FindByID(ctx, item.ReleaseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find release: %w", err)
+	}
+
+	actions, err := s.repo.FindByFilterID(ctx, release.FilterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find actions for filter %v: %w", release.FilterID, err)
+	}
+
+	for _, chain := range buildActionChains(actions) {
+		for i, action := range chain {
+			if action.ID == item.ActionID {
+				return chain[i:], release, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("action is no longer enabled")
+}
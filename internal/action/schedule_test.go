@@ -0,0 +1,137 @@
+package action
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeQueue struct {
+	items []domain.ActionQueueItem
+	next  int64
+	mu    sync.Mutex
+}
+
+func (q *fakeQueue) Store(ctx context.Context, item *domain.ActionQueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.next++
+	item.ID = q.next
+	q.items = append(q.items, *item)
+
+	return nil
+}
+
+func (q *fakeQueue) Delete(ctx context.Context, id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.ID == id {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeQueue) List(ctx context.Context) ([]domain.ActionQueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]domain.ActionQueueItem(nil), q.items...), nil
+}
+
+func (q *fakeQueue) Update(ctx context.Context, item *domain.ActionQueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.items {
+		if q.items[i].ID == item.ID {
+			q.items[i] = *item
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeQueue) FindByID(ctx context.Context, id int64) (*domain.ActionQueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, item := range q.items {
+		if item.ID == id {
+			return &item, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+func (q *fakeQueue) ListPending(ctx context.Context) ([]domain.PendingAction, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var pending []domain.PendingAction
+	for _, item := range q.items {
+		pending = append(pending, domain.PendingAction{ID: item.ID, ReleaseID: item.ReleaseID, ActionID: item.ActionID, Reason: item.Reason, Attempts: item.Attempts, RunAt: item.RunAt})
+	}
+
+	return pending, nil
+}
+
+func Test_service_Shutdown(t *testing.T) {
+	queue := &fakeQueue{}
+	s := &service{queueRepo: queue}
+
+	runAt := time.Now().Add(time.Hour)
+	release := domain.Release{ID: 1, TorrentName: "That Movie 2021"}
+
+	var ran int32
+	run := func() error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	}
+
+	// a deferred retry is only in memory, a scheduled action is already queued
+	s.runLater(&pendingChain{chain: []domain.Action{{ID: 2, Name: "deferred"}}, release: release, runAt: runAt, lastOK: true}, run)
+	s.runLater(&pendingChain{chain: []domain.Action{{ID: 3, Name: "scheduled"}}, release: release, runAt: runAt, item: &domain.ActionQueueItem{ID: 10}}, run)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+
+	require.Len(t, queue.items, 1)
+	assert.Equal(t, 2, queue.items[0].ActionID)
+	assert.Equal(t, int64(1), queue.items[0].ReleaseID)
+	assert.True(t, queue.items[0].LastOK)
+	assert.True(t, queue.items[0].RunAt.Equal(runAt))
+	assert.Empty(t, s.pending)
+
+	// deferred after shutdown started goes to the queue right away
+	s.runLater(&pendingChain{chain: []domain.Action{{ID: 4, Name: "late"}}, release: release, runAt: time.Now()}, run)
+	assert.Len(t, queue.items, 2)
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&ran))
+}
+
+func Test_service_Shutdown_timeout(t *testing.T) {
+	s := &service{}
+	atomic.AddInt64(&s.running, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := s.Shutdown(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 action chains still running")
+}
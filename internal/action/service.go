@@ -2,11 +2,13 @@ package action
 
 import (
 	"context"
+	"sync"
 
 	"github.com/asaskevich/EventBus"
 
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/download_client"
+	"github.com/autobrr/autobrr/internal/indexer"
 )
 
 type Service interface {
@@ -17,17 +19,41 @@ type Service interface {
 	ToggleEnabled(actionID int) error
 
 	RunActions(actions []domain.Action, release domain.Release) error
-	CheckCanDownload(actions []domain.Action) bool
+	ResumeQueued(ctx context.Context) error
+	ListPending(ctx context.Context) ([]domain.PendingAction, error)
+	RunPending(ctx context.Context, id int64) error
+	CancelPending(ctx context.Context, id int64) error
+	Shutdown(ctx context.Context) error
+	HandlerCounts() map[string]int
 }
 
 type service struct {
-	repo      domain.ActionRepo
-	clientSvc download_client.Service
-	bus       EventBus.Bus
+	// action chains waiting for or running on a worker, first for 64-bit alignment
+	running int64
+
+	repo        domain.ActionRepo
+	queueRepo   domain.ActionQueueRepo
+	releaseRepo domain.ReleaseRepo
+	clientSvc   download_client.Service
+	indexerSvc  indexer.Service
+	bus         EventBus.Bus
+
+	// chains waiting on a timer for their delay, schedule window or a deferred retry
+	pending  map[*pendingChain]struct{}
+	stopping bool
+	lock     sync.Mutex
+
+	// sessions logged in download clients
+	sessions sessions
+
+	// jobs action chains waiting for a worker, the workers start with the first chain
+	jobs     chan *pendingChain
+	workers  sync.Once
+	settings domain.ActionQueueSettings
 }
 
-func NewService(repo domain.ActionRepo, clientSvc download_client.Service, bus EventBus.Bus) Service {
-	return &service{repo: repo, clientSvc: clientSvc, bus: bus}
+func NewService(repo domain.ActionRepo, queueRepo domain.ActionQueueRepo, releaseRepo domain.ReleaseRepo, clientSvc download_client.Service, indexerSvc indexer.Service, bus EventBus.Bus, settings domain.ActionQueueSettings) Service {
+	return &service{repo: repo, queueRepo: queueRepo, releaseRepo: releaseRepo, clientSvc: clientSvc, indexerSvc: indexerSvc, bus: bus, settings: settings}
 }
 
 func (s *service) Store(ctx context.Context, action domain.Action) (*domain.Action, error) {
@@ -0,0 +1,242 @@
+package action
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	delugeClient "github.com/gdm85/go-libdeluge"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/qbittorrent"
+	"github.com/autobrr/autobrr/pkg/transmission"
+)
+
+// sessions logged in download clients shared by the actions, so a push doesn't have to log in first.
+// A session is replaced when the connection settings of its client change.
+type sessions struct {
+	clients map[int]*session
+	lock    sync.Mutex
+}
+
+// session of one download client
+type session struct {
+	key sessionKey
+
+	// client logged in client, nil until the first use or after a deluge connection broke
+	client interface{}
+
+	// lock held while logging in, and by deluge for as long as it uses the connection
+	lock sync.Mutex
+}
+
+// sessionKey the settings a session was logged in with
+type sessionKey struct {
+	clientType    domain.DownloadClientType
+	host          string
+	port          int
+	ssl           bool
+	tlsSkipVerify bool
+	username      string
+	password      string
+	basicUser     string
+	basicPass     string
+}
+
+// delugeConn a connected deluge v1 or v2 client
+type delugeConn interface {
+	delugeClient.DelugeClient
+	LabelPlugin() (*delugeClient.LabelPlugin, error)
+}
+
+func newSessionKey(client *domain.DownloadClient) sessionKey {
+	key := sessionKey{
+		clientType:    client.Type,
+		host:          client.Host,
+		port:          client.Port,
+		ssl:           client.SSL,
+		tlsSkipVerify: client.TLSSkipVerify,
+		username:      client.Username,
+		password:      client.Password,
+	}
+
+	if client.Settings.Basic.Auth {
+		key.basicUser = client.Settings.Basic.Username
+		key.basicPass = client.Settings.Basic.Password
+	}
+
+	return key
+}
+
+// get the session of client, a new one if its settings changed
+func (s *sessions) get(client *domain.DownloadClient) *session {
+	key := newSessionKey(client)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.clients == nil {
+		s.clients = map[int]*session{}
+	}
+
+	sess, ok := s.clients[client.ID]
+	if ok && sess.key == key {
+		return sess
+	}
+
+	if ok {
+		go sess.close()
+	}
+
+	sess = &session{key: key}
+	s.clients[client.ID] = sess
+
+	return sess
+}
+
+// close the deluge connection of a replaced session once it is no longer used
+func (sess *session) close() {
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+
+	if deluge, ok := sess.client.(delugeConn); ok {
+		deluge.Close()
+	}
+
+	sess.client = nil
+}
+
+// qbittorrent a logged in client, qBittorrent logs in again by itself when the session expires
+func (s *sessions) qbittorrent(client *domain.DownloadClient) (*qbittorrent.Client, error) {
+	sess := s.get(client)
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+
+	if qbt, ok := sess.client.(*qbittorrent.Client); ok {
+		return qbt, nil
+	}
+
+	qbtSettings := qbittorrent.Settings{
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		Username:      client.Username,
+		Password:      client.Password,
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+	}
+
+	if client.Settings.Basic.Auth {
+		qbtSettings.BasicUser = client.Settings.Basic.Username
+		qbtSettings.BasicPass = client.Settings.Basic.Password
+	}
+
+	qbt := qbittorrent.NewClient(qbtSettings)
+	qbt.Name = client.Name
+
+	if err := qbt.Login(); err != nil {
+		log.Error().Stack().Err(err).Msgf("error logging into client: %v", client.Host)
+		return nil, err
+	}
+
+	sess.client = qbt
+
+	return qbt, nil
+}
+
+// transmission a client that keeps its session id, it picks up a new one when transmission asks for it
+func (s *sessions) transmission(client *domain.DownloadClient) transmission.Client {
+	sess := s.get(client)
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+
+	if tbt, ok := sess.client.(transmission.Client); ok {
+		return tbt
+	}
+
+	tbt := transmission.New(transmission.Config{
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	sess.client = tbt
+
+	return tbt
+}
+
+// deluge run fn with the connection to the daemon, only one action uses it at a time.
+// A connection that was kept open may have been closed by the daemon, fn then runs once more on a new connection.
+func (s *sessions) deluge(client *domain.DownloadClient, fn func(deluge delugeConn) error) error {
+	sess := s.get(client)
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+
+	if deluge, ok := sess.client.(delugeConn); ok {
+		err := fn(deluge)
+		if !connectionLost(err) {
+			return err
+		}
+
+		log.Debug().Err(err).Msgf("deluge: connection lost, reconnecting to client: %v", client.Name)
+
+		deluge.Close()
+		sess.client = nil
+	}
+
+	settings := delugeClient.Settings{
+		Hostname:             client.Host,
+		Port:                 uint(client.Port),
+		Login:                client.Username,
+		Password:             client.Password,
+		DebugServerResponses: true,
+		ReadWriteTimeout:     time.Second * 20,
+	}
+
+	var deluge delugeConn
+	switch client.Type {
+	case domain.DownloadClientTypeDelugeV1:
+		deluge = delugeClient.NewV1(settings)
+
+	default:
+		deluge = delugeClient.NewV2(settings)
+	}
+
+	if err := deluge.Connect(); err != nil {
+		log.Error().Stack().Err(err).Msgf("error logging into client: %v %v", client.Name, client.Host)
+		return err
+	}
+
+	sess.client = deluge
+
+	err := fn(deluge)
+	if connectionLost(err) {
+		deluge.Close()
+		sess.client = nil
+	}
+
+	return err
+}
+
+// connectionLost the error is from a connection that was closed or reset
+func connectionLost(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
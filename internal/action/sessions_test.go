@@ -0,0 +1,69 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_sessions_qbittorrent(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	logins := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: fmt.Sprintf("sid-%d", logins)})
+		w.Write([]byte("Ok."))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	client := &domain.DownloadClient{ID: 1, Name: "qbit", Type: domain.DownloadClientTypeQbittorrent, Host: u.Hostname(), Port: port, Username: "admin", Password: "adminadmin"}
+
+	var s sessions
+
+	first, err := s.qbittorrent(client)
+	require.NoError(t, err)
+
+	// the next push reuses the session
+	again, err := s.qbittorrent(client)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+	assert.Equal(t, 1, logins)
+
+	// changed settings log in again
+	client.Password = "changed"
+	changed, err := s.qbittorrent(client)
+	require.NoError(t, err)
+	assert.NotSame(t, first, changed)
+	assert.Equal(t, 2, logins)
+}
+
+func Test_sessions_transmission(t *testing.T) {
+	client := &domain.DownloadClient{ID: 1, Type: domain.DownloadClientTypeTransmission, Host: "localhost", Port: 9091}
+
+	var s sessions
+
+	assert.Same(t, s.transmission(client), s.transmission(client))
+}
+
+func Test_connectionLost(t *testing.T) {
+	assert.False(t, connectionLost(nil))
+	assert.False(t, connectionLost(fmt.Errorf("torrent already in session")))
+	assert.True(t, connectionLost(fmt.Errorf("read response: %w", io.EOF)))
+	assert.True(t, connectionLost(&net.OpError{Op: "read", Err: fmt.Errorf("connection reset")}))
+}
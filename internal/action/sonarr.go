@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/autobrr/autobrr/internal/domain"
@@ -44,21 +45,30 @@ func (s *service) sonarr(release domain.Release, action domain.Action) ([]string
 
 	r := sonarr.Release{
 		Title:            release.TorrentName,
-		DownloadUrl:      release.TorrentURL,
+		DownloadUrl:      release.DownloadLink(),
 		Size:             int64(release.Size),
-		Indexer:          release.Indexer,
-		DownloadProtocol: "torrent",
-		Protocol:         "torrent",
+		Indexer:          client.Settings.ArrIndexerName(release.Indexer),
+		DownloadProtocol: string(release.Protocol),
+		Protocol:         string(release.Protocol),
 		PublishDate:      time.Now().Format(time.RFC3339),
+		SeasonNumber:     release.Season,
 	}
 
-	rejections, err := arr.Push(r)
+	if release.IsSeasonPack() {
+		r.FullSeason = true
+	} else if release.Episode > 0 {
+		r.EpisodeNumbers = []int{release.Episode}
+	}
+
+	res, err := arr.PushRelease(r)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("sonarr: failed to push release: %v", r)
 		return nil, err
 	}
 
-	if rejections != nil {
+	if res != nil && res.Rejected {
+		rejections := sonarrRejections(res)
+
 		log.Debug().Msgf("sonarr: release push rejected: %v, indexer %v to %v reasons: '%v'", r.Title, r.Indexer, client.Host, rejections)
 
 		return rejections, nil
@@ -68,3 +78,22 @@ func (s *service) sonarr(release domain.Release, action domain.Action) ([]string
 
 	return nil, nil
 }
+
+// sonarrRejections add what sonarr parsed the release as, which makes it easier to tell why a season pack or episode got rejected
+func sonarrRejections(res *sonarr.PushResponse) []string {
+	rejections := make([]string, 0, len(res.Rejections)+1)
+	rejections = append(rejections, res.Rejections...)
+
+	if res.SeriesTitle == "" {
+		return rejections
+	}
+
+	switch {
+	case res.FullSeason:
+		rejections = append(rejections, fmt.Sprintf("parsed as season pack: %v season %v", res.SeriesTitle, res.SeasonNumber))
+	case len(res.EpisodeNumbers) > 0:
+		rejections = append(rejections, fmt.Sprintf("parsed as episode: %v S%02dE%02d", res.SeriesTitle, res.SeasonNumber, res.EpisodeNumbers[0]))
+	}
+
+	return rejections
+}
@@ -0,0 +1,208 @@
+package action
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/transmission"
+)
+
+func (s *service) transmission(tbt transmission.Client, client *domain.DownloadClient, action domain.Action, release domain.Release) error {
+	log.Debug().Msgf("action Transmission: %v", action.Name)
+
+	// macros handle args and replace vars
+	m := NewMacro(release)
+
+	args := transmission.AddTorrentArgs{
+		Paused:            action.Paused,
+		BandwidthPriority: transmission.BandwidthPriority(action.BandwidthPriority),
+	}
+
+	if action.SavePath != "" {
+		// parse and replace values in argument string before continuing
+		savePathArgs, err := m.Parse(action.SavePath)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.SavePath)
+			return err
+		}
+
+		args.DownloadDir = savePathArgs
+	}
+
+	if release.HasMagnet() {
+		args.Filename = release.MagnetURI
+	} else {
+		t, err := ioutil.ReadFile(release.TorrentTmpFile)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not read torrent file: %v", release.TorrentTmpFile)
+			return err
+		}
+
+		args.MetaInfo = base64.StdEncoding.EncodeToString(t)
+	}
+
+	log.Trace().Msgf("action Transmission options: download-dir: %v paused: %v priority: %v", args.DownloadDir, args.Paused, args.BandwidthPriority)
+
+	torrent, err := tbt.AddTorrent(args)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not add torrent %v to client: %v", release.TorrentName, client.Name)
+		return err
+	}
+
+	// labels and speed limits can only be set after the torrent is added
+	set := transmission.SetTorrentArgs{IDs: []string{torrent.HashString}}
+	update := false
+
+	if action.Label != "" {
+		// parse and replace values in argument string before continuing
+		labelArgs, err := m.Parse(action.Label)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not parse macro: %v", action.Label)
+			return err
+		}
+
+		for _, label := range strings.Split(labelArgs, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				set.Labels = append(set.Labels, label)
+			}
+		}
+
+		update = len(set.Labels) > 0
+	}
+	if action.LimitDownloadSpeed > 0 {
+		limited := true
+		set.DownloadLimit = action.LimitDownloadSpeed
+		set.DownloadLimited = &limited
+		update = true
+	}
+	if action.LimitUploadSpeed > 0 {
+		limited := true
+		set.UploadLimit = action.LimitUploadSpeed
+		set.UploadLimited = &limited
+		update = true
+	}
+
+	if update {
+		if err := tbt.SetTorrent(set); err != nil {
+			log.Error().Stack().Err(err).Msgf("could not set torrent options for %v on client: %v", torrent.HashString, client.Name)
+			return err
+		}
+	}
+
+	if !action.Paused && !action.ReannounceSkip {
+		err := reannounce(&transmissionReannouncer{tbt: tbt}, torrent.HashString, reannounceOptionsFromAction(action))
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("could not reannounce torrent: %v", torrent.HashString)
+			return err
+		}
+	}
+
+	log.Info().Msgf("torrent with hash %v successfully added to client: '%v'", torrent.HashString, client.Name)
+
+	return nil
+}
+
+// transmissionReannouncer reannounce through the Transmission rpc
+type transmissionReannouncer struct {
+	tbt transmission.Client
+}
+
+func (r *transmissionReannouncer) announceOK(hash string) (bool, error) {
+	stats, err := r.tbt.GetTrackerStats(hash)
+	if err != nil {
+		log.Error().Err(err).Msgf("Transmission - could not get tracker stats for torrent: %v", hash)
+		return false, err
+	}
+
+	for _, stat := range stats {
+		// seeder and leecher counts are -1 until the tracker answered
+		if stat.LastAnnounceSucceeded && (stat.SeederCount > 0 || stat.LeecherCount > 0) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *transmissionReannouncer) reannounce(hash string) error {
+	return r.tbt.Reannounce([]string{hash})
+}
+
+func (r *transmissionReannouncer) delete(hash string) error {
+	return r.tbt.RemoveTorrents([]string{hash}, false)
+}
+
+// transmissionCheckRulesCanDownload returns the client, or a *rulesBlockedError if the client rules block the release
+func (s *service) transmissionCheckRulesCanDownload(action domain.Action) (transmission.Client, *domain.DownloadClient, error) {
+	log.Trace().Msgf("action Transmission: %v check rules", action.Name)
+
+	// get client for action
+	client, err := s.clientSvc.FindByID(context.TODO(), action.ClientID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("error finding client: %v", action.ClientID)
+		return nil, nil, err
+	}
+
+	if client == nil {
+		return nil, nil, errors.New("no client found")
+	}
+
+	tbt := s.sessions.transmission(client)
+
+	// check for active downloads and other rules
+	rules := client.Settings.Rules
+	if rules.Enabled && !action.IgnoreRules {
+		stats, err := transmissionStats(tbt, rules)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if reason := checkClientRules(rules, stats); reason != "" {
+			log.Debug().Msgf("Transmission rules: %v", reason)
+			return nil, nil, &rulesBlockedError{Reason: reason, Rules: rules}
+		}
+	}
+
+	return tbt, client, nil
+}
+
+// transmissionStats fetch what the enabled rules need
+func transmissionStats(tbt transmission.Client, rules domain.DownloadClientRules) (clientStats, error) {
+	var stats clientStats
+
+	if rules.MaxActiveDownloads > 0 || rules.MaxSeeding > 0 {
+		torrents, err := tbt.GetTorrents(nil)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Transmission - could not fetch torrents")
+			return stats, err
+		}
+
+		for _, t := range torrents {
+			switch t.Status {
+			case transmission.TorrentStatusDownload:
+				stats.ActiveDownloads++
+				stats.DownloadSpeed += t.RateDownload
+			case transmission.TorrentStatusSeed:
+				stats.Seeding++
+			}
+		}
+	}
+
+	if rules.MinFreeSpace > 0 {
+		free, err := tbt.FreeSpace("")
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Transmission - could not get free space")
+			return stats, err
+		}
+
+		stats.FreeSpace = free
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,176 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+func (s *service) webhook(action domain.Action, release domain.Release) error {
+	log.Debug().Msgf("action WEBHOOK: %v release: %v", action.Name, release.TorrentName)
+
+	req, err := buildWebhookRequest(action, release)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("webhook: could not build request for action: %v", action.Name)
+		return err
+	}
+
+	attempts := action.WebhookRetryAttempts + 1
+	delay := time.Duration(action.WebhookRetryDelay) * time.Second
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		retry, err := sendWebhook(req)
+		if err == nil {
+			log.Info().Msgf("webhook: sent release %v to %v", release.TorrentName, req.host())
+			return nil
+		}
+
+		if !retry || attempt == attempts {
+			log.Error().Err(err).Msgf("webhook: request to %v failed after %v attempt(s)", req.host(), attempt)
+			return err
+		}
+
+		log.Debug().Err(err).Msgf("webhook: attempt %v/%v to %v failed, retrying in %v", attempt, attempts, req.host(), delay)
+		time.Sleep(delay)
+	}
+
+	return nil
+}
+
+type webhookRequest struct {
+	method      string
+	url         string
+	contentType string
+	body        []byte
+	headers     map[string]string
+}
+
+// host the host of the request url, the path and query can carry tokens and are not logged
+func (r *webhookRequest) host() string {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
+
+func buildWebhookRequest(action domain.Action, release domain.Release) (*webhookRequest, error) {
+	m := NewMacro(release)
+
+	host, err := m.Parse(action.WebhookHost)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse url: %w", err)
+	}
+
+	if host == "" {
+		return nil, fmt.Errorf("missing webhook url")
+	}
+
+	req := &webhookRequest{
+		method:  http.MethodPost,
+		url:     host,
+		headers: map[string]string{},
+	}
+
+	if action.WebhookMethod != "" {
+		req.method = strings.ToUpper(action.WebhookMethod)
+	}
+
+	data, err := m.Parse(action.WebhookData)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse payload: %w", err)
+	}
+
+	switch action.WebhookType {
+	case domain.WebhookTypeForm:
+		form := url.Values{}
+		for _, line := range strings.Split(data, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid form field: %q, expected key=value", line)
+			}
+
+			form.Add(strings.TrimSpace(parts[0]), parts[1])
+		}
+
+		req.contentType = "application/x-www-form-urlencoded"
+		req.body = []byte(form.Encode())
+
+	default:
+		if data != "" && !json.Valid([]byte(data)) {
+			return nil, fmt.Errorf("payload is not valid json, use {{ json .Field }} to quote values: %v", data)
+		}
+
+		req.contentType = "application/json"
+		req.body = []byte(data)
+	}
+
+	for k, v := range action.WebhookHeaders {
+		value, err := m.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse header %v: %w", k, err)
+		}
+
+		req.headers[k] = value
+	}
+
+	return req, nil
+}
+
+// sendWebhook send the request and report if a failure is worth retrying
+func sendWebhook(r *webhookRequest) (bool, error) {
+	req, err := http.NewRequest(r.method, r.url, bytes.NewReader(r.body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+	if len(r.body) > 0 {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		// drop the url from the error so it doesn't end up in the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return true, urlErr.Err
+		}
+
+		return true, err
+	}
+
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status code: %v body: %v", res.StatusCode, strings.TrimSpace(string(body)))
+
+	return res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,119 @@
+package action
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_service_webhook(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	release := domain.Release{TorrentName: `That "Movie" 2021`, Indexer: "mock", TorrentURL: "https://mock.example.test/dl/1"}
+
+	var calls int
+	var gotBody, gotType, gotHeader string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if r.URL.Path == "/flaky" && calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		gotType = r.Header.Get("Content-Type")
+		gotHeader = r.Header.Get("X-Api-Key")
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		action    domain.Action
+		wantErr   bool
+		wantCalls int
+		wantBody  string
+		wantType  string
+	}{
+		{
+			name: "json",
+			action: domain.Action{
+				WebhookHost:    ts.URL + "/hook",
+				WebhookData:    `{"name": {{ json .TorrentName }}, "url": {{ json .TorrentUrl }}}`,
+				WebhookHeaders: map[string]string{"X-Api-Key": "secret"},
+			},
+			wantCalls: 1,
+			wantBody:  `{"name": "That \"Movie\" 2021", "url": "https://mock.example.test/dl/1"}`,
+			wantType:  "application/json",
+		},
+		{
+			name: "form",
+			action: domain.Action{
+				WebhookHost:    ts.URL + "/hook",
+				WebhookType:    domain.WebhookTypeForm,
+				WebhookData:    "indexer={{ .Indexer }}\nname={{ .TorrentName }}",
+				WebhookHeaders: map[string]string{"X-Api-Key": "secret"},
+			},
+			wantCalls: 1,
+			wantBody:  "indexer=mock&name=That+%22Movie%22+2021",
+			wantType:  "application/x-www-form-urlencoded",
+		},
+		{
+			name:      "retry",
+			action:    domain.Action{WebhookHost: ts.URL + "/flaky", WebhookRetryAttempts: 3},
+			wantCalls: 3,
+			wantType:  "",
+		},
+		{
+			name:      "no_retry_client_error",
+			action:    domain.Action{WebhookHost: ts.URL + "/bad", WebhookRetryAttempts: 3},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "invalid_json",
+			action:    domain.Action{WebhookHost: ts.URL + "/hook", WebhookData: `{"name": "{{ .TorrentName }}"}`},
+			wantErr:   true,
+			wantCalls: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, gotBody, gotType, gotHeader = 0, "", "", ""
+
+			s := &service{}
+			err := s.webhook(tt.action, release)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, gotBody)
+				assert.Equal(t, tt.wantType, gotType)
+				assert.Equal(t, tt.action.WebhookHeaders["X-Api-Key"], gotHeader)
+			}
+
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func Test_webhookRequest_host(t *testing.T) {
+	req := &webhookRequest{url: "https://hooks.example.com/api/webhooks/123/token?key=secret"}
+	assert.Equal(t, "hooks.example.com", req.host())
+
+	req = &webhookRequest{url: "://bad"}
+	assert.Equal(t, "", req.host())
+}
@@ -8,10 +8,14 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/metrics"
 	"github.com/autobrr/autobrr/internal/release"
 
 	"github.com/rs/zerolog/log"
@@ -19,22 +23,52 @@ import (
 
 type Processor interface {
 	AddLineToQueue(channel string, line string) error
+	Drain(ctx context.Context) error
 }
 
+// indexerService current settings and accounts of an indexer
+type indexerService interface {
+	GetByIdentifier(identifier string) *domain.IndexerDefinition
+}
+
+// drainInterval how often Drain looks at the lines still in flight
+const drainInterval = 50 * time.Millisecond
+
 type announceProcessor struct {
+	// running count of matched releases for round-robin account selection, first for 64-bit alignment
+	accountCounter uint64
+
+	// inflight lines that are queued, parsed or still being processed
+	inflight int64
+
 	indexer domain.IndexerDefinition
 
 	filterSvc  filter.Service
+	indexerSvc indexerService
 	releaseSvc release.Service
 
 	queues map[string]chan string
+
+	// queueRepo keeps announces until their releases are handed to the actions
+	queueRepo domain.AnnounceQueueRepo
+
+	// limiter shared by all processors, coalesceWindow drops repeats of announces in seen
+	limiter        *Limiter
+	coalesceWindow time.Duration
+	seen           map[string]time.Time
+	seenLock       sync.Mutex
 }
 
-func NewAnnounceProcessor(indexer domain.IndexerDefinition, filterSvc filter.Service, releaseSvc release.Service) Processor {
+func NewAnnounceProcessor(indexer domain.IndexerDefinition, filterSvc filter.Service, indexerSvc indexerService, releaseSvc release.Service, queueRepo domain.AnnounceQueueRepo, limiter *Limiter, settings domain.AnnounceSettings) Processor {
 	ap := &announceProcessor{
-		indexer:    indexer,
-		filterSvc:  filterSvc,
-		releaseSvc: releaseSvc,
+		indexer:        indexer,
+		filterSvc:      filterSvc,
+		indexerSvc:     indexerSvc,
+		releaseSvc:     releaseSvc,
+		queueRepo:      queueRepo,
+		limiter:        limiter,
+		coalesceWindow: settings.CoalesceWindow,
+		seen:           map[string]time.Time{},
 	}
 
 	// setup queues and consumers
@@ -68,100 +102,285 @@ func (a *announceProcessor) setupQueueConsumers() {
 
 func (a *announceProcessor) processQueue(queue chan string) {
 	for {
-		tmpVars := map[string]string{}
-		parseFailed := false
-		//patternParsed := false
-
-		for _, pattern := range a.indexer.Parse.Lines {
-			line, err := a.getNextLine(queue)
-			if err != nil {
-				log.Error().Stack().Err(err).Msg("could not get line from queue")
-				return
-			}
-			log.Trace().Msgf("announce: process line: %v", line)
+		consumed, ok := a.processAnnounce(queue, false)
 
-			// check should ignore
+		// the lines are done once their releases are stored and handed to the actions
+		atomic.AddInt64(&a.inflight, -int64(consumed))
 
-			match, err := a.parseExtract(pattern.Pattern, pattern.Vars, tmpVars, line)
-			if err != nil {
-				log.Debug().Msgf("error parsing extract: %v", line)
+		if !ok {
+			return
+		}
+	}
+}
 
-				parseFailed = true
-				break
-			}
+// processAnnounce read the lines of one announce and run the release through the filters.
+// The announce is queued in the database until then, stored is set when it already is.
+// It returns the number of lines read, and false when the queue is closed.
+func (a *announceProcessor) processAnnounce(queue chan string, stored bool) (int, bool) {
+	consumed := 0
+	lines := make([]string, 0, len(a.indexer.Parse.Lines))
 
-			if !match {
-				log.Debug().Msgf("line not matching expected regex pattern: %v", line)
-				parseFailed = true
-				break
-			}
-		}
+	tmpVars := map[string]string{}
+	parseFailed := false
+	//patternParsed := false
 
-		if parseFailed {
-			log.Trace().Msg("announce: parse failed")
-			continue
-		}
+	// time spent parsing, not waiting for the next line of a multi-line announce
+	var parseTime time.Duration
 
-		newRelease, err := domain.NewRelease(a.indexer.Identifier, "")
+	for _, pattern := range a.indexer.Parse.Lines {
+		line, err := a.getNextLine(queue)
 		if err != nil {
-			log.Error().Err(err).Msg("could not create new release")
-			continue
+			log.Error().Stack().Err(err).Msg("could not get line from queue")
+			return consumed, false
 		}
+		consumed++
+		lines = append(lines, line)
+		log.Trace().Msgf("announce: process line: %v", line)
 
-		// on lines matched
-		err = a.onLinesMatched(a.indexer, tmpVars, newRelease)
+		// check should ignore
+
+		started := time.Now()
+		match, err := a.parseExtract(pattern.Pattern, pattern.Vars, tmpVars, line)
+		parseTime += time.Since(started)
 		if err != nil {
-			log.Debug().Msgf("error match line: %v", "")
-			continue
-		}
+			log.Debug().Msgf("error parsing extract: %v", line)
 
-		// send to filter service to take care of the rest
+			parseFailed = true
+			break
+		}
 
-		// find and check filter
-		filterOK, foundFilter, err := a.filterSvc.FindAndCheckFilters(newRelease)
-		if err != nil {
-			log.Error().Err(err).Msg("could not find filter")
-			continue
+		if !match {
+			log.Debug().Msgf("line not matching expected regex pattern: %v", line)
+			parseFailed = true
+			break
 		}
+	}
 
-		// no foundFilter found, lets return
-		if !filterOK || foundFilter == nil {
-			log.Trace().Msg("no matching filter found")
-			continue
+	if parseFailed {
+		log.Trace().Msg("announce: parse failed")
+		return consumed, true
+	}
 
-			// TODO check in config for "Save all releases"
-			// Save as rejected
-			//newRelease.FilterStatus = domain.ReleaseStatusFilterRejected
-			//err = s.releaseSvc.Store(ctx, newRelease)
-			//if err != nil {
-			//	log.Error().Err(err).Msgf("error writing release to database: %+v", newRelease)
-			//	return nil
-			//}
-			//return nil
-		}
+	// waiting for a slot and the filter checks, it is checked again after a restart until it is done
+	if !stored {
+		item := a.queueAnnounce(lines)
+		defer a.dequeueAnnounce(item)
+	}
 
-		// save release
-		newRelease.Filter = foundFilter
-		newRelease.FilterName = foundFilter.Name
-		newRelease.FilterID = foundFilter.ID
+	newRelease, err := domain.NewRelease(a.indexer.Identifier, "")
+	if err != nil {
+		log.Error().Err(err).Msg("could not create new release")
+		return consumed, true
+	}
 
-		newRelease.FilterStatus = domain.ReleaseStatusFilterApproved
-		err = a.releaseSvc.Store(context.Background(), newRelease)
-		if err != nil {
+	// settings and accounts edited since the processor was set up apply to this announce
+	current := a.currentIndexer()
+
+	// on lines matched
+	started := time.Now()
+	err = a.onLinesMatched(current, tmpVars, newRelease)
+	if err != nil {
+		log.Debug().Msgf("error match line: %v", "")
+		return consumed, true
+	}
+
+	newRelease.Timings.Parse = (parseTime + time.Since(started)).Microseconds()
+
+	if a.repeated(newRelease, time.Now()) {
+		log.Debug().Msgf("announce: dropped repeat of '%v' within %v", newRelease.TorrentName, a.coalesceWindow)
+		return consumed, true
+	}
+
+	metrics.AnnouncesProcessed.Inc(a.indexer.Identifier)
+
+	// wait for a slot, the filter checks and downloads of every indexer together are bounded
+	a.limiter.Acquire(a.indexer.Identifier)
+	defer a.limiter.Release()
+
+	// send to filter service to take care of the rest
+
+	// find and check filter
+	filters, err := a.filterSvc.FindAndCheckFilters(newRelease)
+	if err != nil {
+		log.Error().Err(err).Msg("could not find filter")
+		return consumed, true
+	}
+
+	metrics.ObserveDuration(metrics.ReleasePipelineDuration, newRelease.Timestamp, metrics.StageFilter)
+
+	// no foundFilter found, save as rejected so it shows up in the release history
+	if len(filters) == 0 {
+		log.Trace().Msg("no matching filter found")
+
+		newRelease.FilterStatus = domain.ReleaseStatusFilterRejected
+		if err := a.releaseSvc.Store(context.Background(), newRelease); err != nil {
 			log.Error().Err(err).Msgf("error writing release to database: %+v", newRelease)
-			continue
 		}
 
-		log.Info().Msgf("Matched '%v' (%v) for %v", newRelease.TorrentName, newRelease.Filter.Name, newRelease.Indexer)
+		return consumed, true
+	}
+
+	// every matching filter gets its own copy of the release, they can grab with other accounts and actions.
+	// The copies share the torrent file of the size check, a copy that needs another url forgets it instead of removing it.
+	announced := *newRelease
+	for i := range filters {
+		rls := announced
+		a.processMatch(&rls, &filters[i], current, tmpVars)
+	}
+
+	return consumed, true
+}
+
+// processMatch store the release as approved by the filter and run its actions
+func (a *announceProcessor) processMatch(release *domain.Release, foundFilter *domain.Filter, indexer domain.IndexerDefinition, vars map[string]string) {
+	// switch to another account if the filter pins one or the indexer rotates them
+	settings, err := a.applyAccount(indexer, release, foundFilter, vars)
+	if err != nil {
+		log.Error().Err(err).Msgf("could not select account for release: %v", release.TorrentName)
+		return
+	}
+
+	if err := a.applyFreeleechToken(indexer, release, foundFilter, vars, settings); err != nil {
+		log.Error().Err(err).Msgf("could not build freeleech token url for release: %v", release.TorrentName)
+		return
+	}
+
+	// save release
+	release.Filter = foundFilter
+	release.FilterName = foundFilter.Name
+	release.FilterID = foundFilter.ID
+
+	release.FilterStatus = domain.ReleaseStatusFilterApproved
+	if err := a.releaseSvc.Store(context.Background(), release); err != nil {
+		log.Error().Err(err).Msgf("error writing release to database: %+v", release)
+		return
+	}
+
+	log.Info().Msgf("Matched '%v' (%v) for %v", release.TorrentName, release.Filter.Name, release.Indexer)
 
-		// process release
-		go func(rel *domain.Release) {
-			err = a.releaseSvc.Process(*rel)
-			if err != nil {
-				log.Error().Err(err).Msgf("could not process release: %+v", newRelease)
+	// process release, the actions go to the action queue so this only blocks while it is full
+	if err := a.releaseSvc.Process(*release); err != nil {
+		log.Error().Err(err).Msgf("could not process release: %+v", release)
+	}
+}
+
+// queueAnnounce store the lines of an announce, nil when there is no queue or it could not be stored
+func (a *announceProcessor) queueAnnounce(lines []string) *domain.AnnounceQueueItem {
+	if a.queueRepo == nil {
+		return nil
+	}
+
+	item := &domain.AnnounceQueueItem{Indexer: a.indexer.Identifier, Lines: lines}
+	if err := a.queueRepo.Store(context.Background(), item); err != nil {
+		log.Error().Err(err).Msgf("announce: could not queue announce for %v, holding it in memory", a.indexer.Identifier)
+		return nil
+	}
+
+	return item
+}
+
+// dequeueAnnounce remove an announce that went through the filters from the queue
+func (a *announceProcessor) dequeueAnnounce(item *domain.AnnounceQueueItem) {
+	if item == nil {
+		return
+	}
+
+	if err := a.queueRepo.Delete(context.Background(), item.ID); err != nil {
+		log.Error().Err(err).Msgf("announce: could not remove queued announce: %v", item.ID)
+	}
+}
+
+// ResumeQueued check the announces again that were still waiting for the filters on shutdown or a crash
+func ResumeQueued(ctx context.Context, queueRepo domain.AnnounceQueueRepo, filterSvc filter.Service, indexerSvc indexerService, releaseSvc release.Service, limiter *Limiter) error {
+	items, err := queueRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := items[i]
+
+		indexer := indexerSvc.GetByIdentifier(item.Indexer)
+		if indexer == nil {
+			log.Warn().Msgf("announce: dropping queued announce %v, indexer %v is gone", item.ID, item.Indexer)
+
+			if err := queueRepo.Delete(ctx, item.ID); err != nil {
+				return err
 			}
-		}(newRelease)
+
+			continue
+		}
+
+		a := &announceProcessor{
+			indexer:    *indexer,
+			filterSvc:  filterSvc,
+			indexerSvc: indexerSvc,
+			releaseSvc: releaseSvc,
+			queueRepo:  queueRepo,
+			limiter:    limiter,
+			seen:       map[string]time.Time{},
+		}
+
+		log.Info().Msgf("announce: resume queued announce for %v from %v", item.Indexer, item.CreatedAt.Format(time.RFC3339))
+
+		go a.resume(item)
+	}
+
+	return nil
+}
+
+// resume run the stored lines of an announce through the filters and remove it from the queue
+func (a *announceProcessor) resume(item domain.AnnounceQueueItem) {
+	queue := make(chan string, len(item.Lines))
+	for _, line := range item.Lines {
+		queue <- line
+	}
+	close(queue)
+
+	a.processAnnounce(queue, true)
+	a.dequeueAnnounce(&item)
+}
+
+// currentIndexer the indexer as it is stored now, or as it was when the processor was set up if it is gone
+func (a *announceProcessor) currentIndexer() domain.IndexerDefinition {
+	if a.indexerSvc == nil {
+		return a.indexer
+	}
+
+	if current := a.indexerSvc.GetByIdentifier(a.indexer.Identifier); current != nil {
+		return *current
+	}
+
+	return a.indexer
+}
+
+// repeated the release was announced within the coalesce window already
+func (a *announceProcessor) repeated(release *domain.Release, now time.Time) bool {
+	if a.coalesceWindow <= 0 {
+		return false
 	}
+
+	key := release.TorrentURL
+	if key == "" {
+		key = release.TorrentName
+	}
+
+	a.seenLock.Lock()
+	defer a.seenLock.Unlock()
+
+	for k, at := range a.seen {
+		if now.Sub(at) >= a.coalesceWindow {
+			delete(a.seen, k)
+		}
+	}
+
+	if _, ok := a.seen[key]; ok {
+		return true
+	}
+
+	a.seen[key] = now
+
+	return false
 }
 
 func (a *announceProcessor) getNextLine(queue chan string) (string, error) {
@@ -182,12 +401,31 @@ func (a *announceProcessor) AddLineToQueue(channel string, line string) error {
 		return fmt.Errorf("no queue for channel (%v) found", channel)
 	}
 
+	atomic.AddInt64(&a.inflight, 1)
+
 	queue <- line
 	log.Trace().Msgf("announce: queued line: %v", line)
 
 	return nil
 }
 
+// Drain wait until the queued lines are parsed and the matched releases are handed to the actions.
+// A multi-line announce that never got its last line keeps it waiting until ctx is done.
+func (a *announceProcessor) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&a.inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%v announces still in flight: %w", a.indexer.Identifier, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
 func (a *announceProcessor) parseExtract(pattern string, vars []string, tmpVars map[string]string, line string) (bool, error) {
 
 	rxp, err := regExMatch(pattern, line)
@@ -240,6 +478,94 @@ func (a *announceProcessor) onLinesMatched(def domain.IndexerDefinition, vars ma
 	return nil
 }
 
+// applyAccount rebuild the torrent url with the selected account credentials, returns the settings the url is built with
+func (a *announceProcessor) applyAccount(indexer domain.IndexerDefinition, release *domain.Release, filter *domain.Filter, vars map[string]string) (map[string]string, error) {
+	if len(indexer.Accounts) == 0 {
+		return indexer.SettingsMap, nil
+	}
+
+	n := atomic.AddUint64(&a.accountCounter, 1) - 1
+
+	account, err := indexer.SelectAccount(filter.IndexerAccounts[indexer.Identifier], n)
+	if err != nil {
+		return nil, err
+	}
+
+	// torrent url is already built with the indexer settings
+	if account == nil {
+		return indexer.SettingsMap, nil
+	}
+
+	settings := indexer.AccountSettings(account)
+	if err := a.rebuildTorrentUrl(indexer, release, indexer.Parse.Match.TorrentURL, vars, settings); err != nil {
+		return nil, err
+	}
+
+	release.IndexerAccount = account.Name
+
+	log.Debug().Msgf("announce: using account '%v' for %v", account.Name, release.TorrentName)
+
+	return settings, nil
+}
+
+// applyFreeleechToken set the token url if the filter wants to spend a freeleech token on the release.
+// The release keeps the plain torrent url, the token url is only used for the first download.
+func (a *announceProcessor) applyFreeleechToken(indexer domain.IndexerDefinition, release *domain.Release, filter *domain.Filter, vars map[string]string, settings map[string]string) error {
+	tokenURL := indexer.Parse.Match.TokenURL
+	if !filter.UseFreeleechToken || tokenURL == "" {
+		return nil
+	}
+
+	// no point in spending a token on something already free
+	if release.Freeleech {
+		log.Debug().Msgf("announce: %v is already freeleech, not using a token", release.TorrentName)
+		return nil
+	}
+
+	use, err := filter.UseFreeleechTokenForSize(release.Size)
+	if err != nil {
+		return err
+	}
+
+	if !use {
+		log.Debug().Msgf("announce: %v smaller than freeleech token min size %v, not using a token", release.TorrentName, filter.FreeleechTokenMinSize)
+		return nil
+	}
+
+	token := *release
+	if err := token.ParseTorrentUrl(tokenURL, vars, settings, indexer.Parse.Match.Encode); err != nil {
+		return err
+	}
+
+	// the size check may have downloaded the torrent with the plain url, fetch it again with the token
+	if release.TorrentTmpFile != "" {
+		release.ForgetTorrentFile()
+	}
+
+	release.FreeleechTokenURL = domain.NewTokenURL(token.TorrentURL)
+	release.FreeleechToken = true
+
+	log.Info().Msgf("announce: using freeleech token for %v", release.TorrentName)
+
+	return nil
+}
+
+// rebuildTorrentUrl parse the torrent url again, a torrent already downloaded with the old url is fetched again when needed
+func (a *announceProcessor) rebuildTorrentUrl(indexer domain.IndexerDefinition, release *domain.Release, match string, vars map[string]string, settings map[string]string) error {
+	torrentURL := release.TorrentURL
+
+	if err := release.ParseTorrentUrl(match, vars, settings, indexer.Parse.Match.Encode); err != nil {
+		return err
+	}
+
+	// the size check may have downloaded the torrent with the old url, fetch it again
+	if release.TorrentTmpFile != "" && release.TorrentURL != torrentURL {
+		release.ForgetTorrentFile()
+	}
+
+	return nil
+}
+
 func (a *announceProcessor) processTorrentUrl(match string, vars map[string]string, extraVars map[string]string, encode []string) (string, error) {
 	tmpVars := map[string]string{}
 
@@ -1,7 +1,15 @@
 package announce
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -66,3 +74,302 @@ func Test_announceProcessor_processTorrentUrl(t *testing.T) {
 		})
 	}
 }
+
+func Test_announceProcessor_applyFreeleechToken(t *testing.T) {
+	indexer := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"torrent_pass": "abc123"},
+		Parse: domain.IndexerParse{
+			Match: domain.IndexerParseMatch{
+				TorrentURL: "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}",
+				TokenURL:   "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}&usetoken=1",
+			},
+		},
+	}
+	vars := map[string]string{"baseUrl": "https://mock.example.test/torrents.php?action=download&id=1"}
+
+	tests := []struct {
+		name      string
+		filter    domain.Filter
+		release   domain.Release
+		wantToken bool
+	}{
+		{
+			name:    "disabled",
+			filter:  domain.Filter{},
+			release: domain.Release{Size: 10000000000},
+		},
+		{
+			name:      "enabled",
+			filter:    domain.Filter{UseFreeleechToken: true},
+			release:   domain.Release{Size: 10000000000},
+			wantToken: true,
+		},
+		{
+			name:    "already_freeleech",
+			filter:  domain.Filter{UseFreeleechToken: true},
+			release: domain.Release{Size: 10000000000, Freeleech: true},
+		},
+		{
+			name:      "above_min_size",
+			filter:    domain.Filter{UseFreeleechToken: true, FreeleechTokenMinSize: "5 GiB"},
+			release:   domain.Release{Size: 10000000000},
+			wantToken: true,
+		},
+		{
+			name:    "below_min_size",
+			filter:  domain.Filter{UseFreeleechToken: true, FreeleechTokenMinSize: "5 GiB"},
+			release: domain.Release{Size: 1000000000},
+		},
+		{
+			name:    "unknown_size",
+			filter:  domain.Filter{UseFreeleechToken: true, FreeleechTokenMinSize: "5 GiB"},
+			release: domain.Release{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &announceProcessor{indexer: indexer}
+
+			release := tt.release
+			assert.NoError(t, release.ParseTorrentUrl(indexer.Parse.Match.TorrentURL, vars, indexer.SettingsMap, nil))
+
+			err := a.applyFreeleechToken(indexer, &release, &tt.filter, vars, indexer.SettingsMap)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantToken, release.FreeleechToken)
+			assert.False(t, strings.HasSuffix(release.TorrentURL, "&usetoken=1"), "stored url spends no token")
+
+			// only the first download spends the token
+			assert.Equal(t, tt.wantToken, strings.HasSuffix(release.DownloadLink(), "&usetoken=1"))
+			assert.False(t, strings.HasSuffix(release.DownloadLink(), "&usetoken=1"))
+		})
+	}
+}
+
+type mockIndexerService map[string]domain.IndexerDefinition
+
+func (m mockIndexerService) GetByIdentifier(identifier string) *domain.IndexerDefinition {
+	if indexer, ok := m[identifier]; ok {
+		return &indexer
+	}
+
+	return nil
+}
+
+func Test_announceProcessor_currentIndexer(t *testing.T) {
+	indexer := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"torrent_pass": "abc123"},
+		Parse: domain.IndexerParse{
+			Match: domain.IndexerParseMatch{TorrentURL: "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}"},
+		},
+	}
+	vars := map[string]string{"baseUrl": "https://mock.example.test/torrents.php?action=download&id=1"}
+
+	indexers := mockIndexerService{}
+	a := &announceProcessor{indexer: indexer, indexerSvc: indexers}
+
+	// not stored, the processor keeps its own copy
+	assert.Equal(t, indexer, a.currentIndexer())
+
+	// an account added after the processor was set up
+	edited := indexer
+	edited.Accounts = []domain.IndexerAccount{{Name: "second", Enabled: true, Settings: map[string]string{"torrent_pass": "def456"}}}
+	indexers["mock"] = edited
+
+	current := a.currentIndexer()
+	release := domain.Release{}
+	assert.NoError(t, release.ParseTorrentUrl(current.Parse.Match.TorrentURL, vars, current.SettingsMap, nil))
+
+	filter := domain.Filter{IndexerAccounts: map[string]string{"mock": "second"}}
+	_, err := a.applyAccount(current, &release, &filter, vars)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", release.IndexerAccount)
+	assert.True(t, strings.HasSuffix(release.TorrentURL, "&torrent_pass=def456"))
+}
+
+func Test_announceProcessor_Drain(t *testing.T) {
+	indexer := domain.IndexerDefinition{
+		Identifier: "mock",
+		IRC:        &domain.IndexerIRC{Channels: []string{"#announces"}},
+		Parse: domain.IndexerParse{Lines: []domain.IndexerParseExtract{
+			{Pattern: `^New: (.*)$`, Vars: []string{"torrentName"}},
+			{Pattern: `^Url: (.*)$`, Vars: []string{"baseUrl"}},
+		}},
+	}
+
+	a := NewAnnounceProcessor(indexer, nil, nil, nil, nil, NewLimiter(1), domain.AnnounceSettings{})
+
+	// a line that doesn't match ends the announce right away
+	assert.NoError(t, a.AddLineToQueue("#announces", "something else"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, a.Drain(ctx))
+
+	// the first line of two waits for the second until the deadline
+	assert.NoError(t, a.AddLineToQueue("#announces", "New: That.Movie.2021"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, a.Drain(ctx))
+}
+
+func Test_announceProcessor_repeated(t *testing.T) {
+	a := &announceProcessor{coalesceWindow: time.Minute, seen: map[string]time.Time{}}
+	now := time.Now()
+
+	movie := &domain.Release{TorrentName: "That.Movie.2021", TorrentURL: "https://mock.local/torrent/1"}
+	other := &domain.Release{TorrentName: "That.Movie.2021", TorrentURL: "https://mock.local/torrent/2"}
+
+	assert.False(t, a.repeated(movie, now))
+	assert.True(t, a.repeated(movie, now.Add(30*time.Second)))
+	assert.False(t, a.repeated(other, now.Add(30*time.Second)))
+
+	// announced again after the window
+	assert.False(t, a.repeated(movie, now.Add(2*time.Minute)))
+
+	// coalescing is off without a window
+	a.coalesceWindow = 0
+	assert.False(t, a.repeated(movie, now))
+}
+
+type fakeAnnounceQueue struct {
+	items []domain.AnnounceQueueItem
+	next  int64
+	mu    sync.Mutex
+}
+
+func (q *fakeAnnounceQueue) Store(ctx context.Context, item *domain.AnnounceQueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.next++
+	item.ID = q.next
+	q.items = append(q.items, *item)
+
+	return nil
+}
+
+func (q *fakeAnnounceQueue) Delete(ctx context.Context, id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.ID == id {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeAnnounceQueue) List(ctx context.Context) ([]domain.AnnounceQueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]domain.AnnounceQueueItem(nil), q.items...), nil
+}
+
+func Test_announceProcessor_queueAnnounce(t *testing.T) {
+	queue := &fakeAnnounceQueue{}
+	a := &announceProcessor{indexer: domain.IndexerDefinition{Identifier: "mock"}, queueRepo: queue}
+
+	item := a.queueAnnounce([]string{"New: That.Movie.2021", "Url: https://mock.local/torrent/1"})
+	assert.NotNil(t, item)
+
+	items, _ := queue.List(context.Background())
+	assert.Equal(t, []domain.AnnounceQueueItem{{ID: 1, Indexer: "mock", Lines: []string{"New: That.Movie.2021", "Url: https://mock.local/torrent/1"}}}, items)
+
+	a.dequeueAnnounce(item)
+
+	items, _ = queue.List(context.Background())
+	assert.Empty(t, items)
+
+	// without a queue the announce is only held in memory
+	a.queueRepo = nil
+	assert.Nil(t, a.queueAnnounce([]string{"New: That.Movie.2021"}))
+}
+
+func Test_ResumeQueued(t *testing.T) {
+	indexers := mockIndexerService{"mock": domain.IndexerDefinition{
+		Identifier: "mock",
+		Parse: domain.IndexerParse{Lines: []domain.IndexerParseExtract{
+			{Pattern: `^New: (.*)$`, Vars: []string{"torrentName"}},
+		}},
+	}}
+
+	queue := &fakeAnnounceQueue{}
+	assert.NoError(t, queue.Store(context.Background(), &domain.AnnounceQueueItem{Indexer: "gone", Lines: []string{"New: That.Movie.2021"}}))
+	assert.NoError(t, queue.Store(context.Background(), &domain.AnnounceQueueItem{Indexer: "mock", Lines: []string{"something else"}}))
+
+	assert.NoError(t, ResumeQueued(context.Background(), queue, nil, indexers, nil, NewLimiter(1)))
+
+	// announces of removed indexers are dropped, the others are checked again and leave the queue
+	assert.Eventually(t, func() bool {
+		items, _ := queue.List(context.Background())
+		return len(items) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_announceProcessor_applyFreeleechToken_sharedFile(t *testing.T) {
+	indexer := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"torrent_pass": "abc123"},
+		Parse: domain.IndexerParse{
+			Match: domain.IndexerParseMatch{
+				TorrentURL: "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}",
+				TokenURL:   "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}&usetoken=1",
+			},
+		},
+	}
+	vars := map[string]string{"baseUrl": "https://mock.example.test/torrents.php?action=download&id=1"}
+
+	// downloaded by the size check, shared by the copies of every matched filter
+	tmpFile := filepath.Join(t.TempDir(), "size-check.torrent")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte("d4:infode"), 0644))
+
+	announced := domain.Release{Size: 10000000000, TorrentTmpFile: tmpFile, TorrentHash: "abc"}
+	assert.NoError(t, announced.ParseTorrentUrl(indexer.Parse.Match.TorrentURL, vars, indexer.SettingsMap, nil))
+
+	plain, token := announced, announced
+
+	a := &announceProcessor{indexer: indexer}
+	assert.NoError(t, a.applyFreeleechToken(indexer, &token, &domain.Filter{UseFreeleechToken: true}, vars, indexer.SettingsMap))
+
+	assert.Empty(t, token.TorrentTmpFile, "the token copy downloads the torrent again")
+	assert.Equal(t, tmpFile, plain.TorrentTmpFile)
+	assert.FileExists(t, tmpFile, "the other copy still uses the file")
+}
+
+func Test_announceProcessor_applyAccount_sharedFile(t *testing.T) {
+	indexer := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"torrent_pass": "main"},
+		Accounts:    []domain.IndexerAccount{{Name: "seedbox", Enabled: true, Settings: map[string]string{"torrent_pass": "seedbox"}}},
+		Parse: domain.IndexerParse{
+			Match: domain.IndexerParseMatch{
+				TorrentURL: "{{ .baseUrl }}&torrent_pass={{ .torrent_pass }}",
+			},
+		},
+	}
+	vars := map[string]string{"baseUrl": "https://mock.example.test/torrents.php?action=download&id=1"}
+
+	tmpFile := filepath.Join(t.TempDir(), "size-check.torrent")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte("d4:infode"), 0644))
+
+	announced := domain.Release{TorrentTmpFile: tmpFile}
+	assert.NoError(t, announced.ParseTorrentUrl(indexer.Parse.Match.TorrentURL, vars, indexer.SettingsMap, nil))
+
+	main, seedbox := announced, announced
+
+	a := &announceProcessor{indexer: indexer}
+	_, err := a.applyAccount(indexer, &seedbox, &domain.Filter{IndexerAccounts: map[string]string{"mock": "seedbox"}}, vars)
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasSuffix(seedbox.TorrentURL, "torrent_pass=seedbox"))
+	assert.Empty(t, seedbox.TorrentTmpFile, "the file has the passkey of the main account")
+	assert.Equal(t, tmpFile, main.TorrentTmpFile)
+	assert.FileExists(t, tmpFile)
+}
@@ -0,0 +1,75 @@
+package announce
+
+import (
+	"sync"
+)
+
+// Limiter bounds the announces checked against the filters at the same time.
+// Waiting announces take turns per indexer, so a flood from one indexer doesn't hold up the others.
+type Limiter struct {
+	free int
+
+	// waiting announces per indexer, and the indexers with waiting announces in turn order
+	waiting map[string][]chan struct{}
+	turns   []string
+
+	lock sync.Mutex
+}
+
+func NewLimiter(concurrency int) *Limiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Limiter{
+		free:    concurrency,
+		waiting: map[string][]chan struct{}{},
+	}
+}
+
+// Acquire wait for a slot to check an announce of indexer
+func (l *Limiter) Acquire(indexer string) {
+	l.lock.Lock()
+
+	if l.free > 0 && len(l.turns) == 0 {
+		l.free--
+		l.lock.Unlock()
+		return
+	}
+
+	ready := make(chan struct{})
+	if len(l.waiting[indexer]) == 0 {
+		l.turns = append(l.turns, indexer)
+	}
+	l.waiting[indexer] = append(l.waiting[indexer], ready)
+
+	l.lock.Unlock()
+
+	<-ready
+}
+
+// Release hand the slot to the indexer whose turn it is
+func (l *Limiter) Release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.turns) == 0 {
+		l.free++
+		return
+	}
+
+	indexer := l.turns[0]
+	l.turns = l.turns[1:]
+
+	queue := l.waiting[indexer]
+	ready := queue[0]
+
+	if len(queue) > 1 {
+		l.waiting[indexer] = queue[1:]
+		l.turns = append(l.turns, indexer)
+	} else {
+		delete(l.waiting, indexer)
+	}
+
+	close(ready)
+}
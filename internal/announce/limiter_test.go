@@ -0,0 +1,73 @@
+package announce
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(1)
+
+	// the slot is taken, a flood from busy waits alongside one announce from quiet
+	l.Acquire("busy")
+
+	var (
+		order []string
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+
+	wait := func(indexer string) {
+		defer wg.Done()
+
+		l.Acquire(indexer)
+
+		mu.Lock()
+		order = append(order, indexer)
+		mu.Unlock()
+
+		l.Release()
+	}
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go wait("busy")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	wg.Add(1)
+	go wait("quiet")
+	time.Sleep(10 * time.Millisecond)
+
+	l.Release()
+	wg.Wait()
+
+	// quiet gets its turn after one busy announce instead of after all of them
+	assert.Equal(t, []string{"busy", "quiet", "busy", "busy"}, order)
+	assert.Equal(t, 1, l.free)
+}
+
+func TestLimiter_concurrency(t *testing.T) {
+	l := NewLimiter(2)
+
+	l.Acquire("a")
+	l.Acquire("b")
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire("c")
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired a third slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.Release()
+	<-acquired
+}
@@ -0,0 +1,120 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+// touchInterval last used is written at most this often, not on every request
+const touchInterval = time.Minute
+
+// prefixLength characters of the key kept in the clear to tell keys apart
+const prefixLength = 8
+
+type Service interface {
+	Create(ctx context.Context, username string, name string) (string, *domain.APIKey, error)
+	Validate(ctx context.Context, key string) (*domain.APIKey, error)
+	List(ctx context.Context, username string) ([]domain.APIKey, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type service struct {
+	repo     domain.APIKeyRepo
+	userRepo domain.UserRepo
+}
+
+func NewService(repo domain.APIKeyRepo, userRepo domain.UserRepo) Service {
+	return &service{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+// Create a key for the user, the key is only returned here
+func (s *service) Create(ctx context.Context, username string, name string) (string, *domain.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, errors.New("name can't be empty")
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil || user == nil {
+		return "", nil, fmt.Errorf("could not find user %v", username)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(b)
+
+	apiKey := &domain.APIKey{
+		Name:      name,
+		Username:  user.Username,
+		KeyHash:   hashKey(key),
+		Prefix:    key[:prefixLength],
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.Store(ctx, apiKey); err != nil {
+		return "", nil, err
+	}
+
+	log.Info().Msgf("apikey: created key %v for %v", name, user.Username)
+
+	return key, apiKey, nil
+}
+
+// Validate the stored key for the key from a request
+func (s *service) Validate(ctx context.Context, key string) (*domain.APIKey, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	apiKey, err := s.repo.FindByKey(ctx, hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey == nil {
+		return nil, ErrInvalidKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= touchInterval {
+		if err := s.repo.Touch(ctx, apiKey.ID, now); err != nil {
+			log.Error().Err(err).Msg("apikey: could not update last used")
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, nil
+}
+
+// List keys of the user, every key when username is empty
+func (s *service) List(ctx context.Context, username string) ([]domain.APIKey, error) {
+	return s.repo.List(ctx, username)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// loginLimiter counts failed logins per username and per address in memory,
+// a restart clears lockouts which is fine for the attacks it slows down
+type loginLimiter struct {
+	limits domain.LoginLimits
+
+	mu       sync.Mutex
+	attempts map[string]*loginAttempts
+}
+
+type loginAttempts struct {
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+func newLoginLimiter(limits domain.LoginLimits) *loginLimiter {
+	return &loginLimiter{
+		limits:   limits,
+		attempts: map[string]*loginAttempts{},
+	}
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// lockedUntil the end of the longest lockout of the username and address, zero when neither is locked
+func (l *loginLimiter) lockedUntil(username, ip string, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var until time.Time
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		if a, ok := l.attempts[key]; ok && a.lockedUntil.After(now) && a.lockedUntil.After(until) {
+			until = a.lockedUntil
+		}
+	}
+
+	return until
+}
+
+// fail count a failed login, and return the end of the lockout when it was the last allowed attempt
+func (l *loginLimiter) fail(username, ip string, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	var until time.Time
+	if u := l.count(userKey(username), l.limits.MaxAttempts, now); u.After(until) {
+		until = u
+	}
+	if ip != "" {
+		if u := l.count(ipKey(ip), l.limits.MaxAttemptsPerIP, now); u.After(until) {
+			until = u
+		}
+	}
+
+	return until
+}
+
+func (l *loginLimiter) count(key string, max int, now time.Time) time.Time {
+	a, ok := l.attempts[key]
+	if !ok {
+		a = &loginAttempts{}
+		l.attempts[key] = a
+	}
+
+	a.failures = append(recent(a.failures, now.Add(-l.limits.Lockout)), now)
+
+	if len(a.failures) >= max {
+		a.failures = nil
+		a.lockedUntil = now.Add(l.limits.Lockout)
+		return a.lockedUntil
+	}
+
+	return time.Time{}
+}
+
+// reset forget the failures of the username and address after a successful login
+func (l *loginLimiter) reset(username, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, userKey(username))
+	delete(l.attempts, ipKey(ip))
+}
+
+// prune drop keys without recent failures or lockout so the map does not grow with every address seen
+func (l *loginLimiter) prune(now time.Time) {
+	since := now.Add(-l.limits.Lockout)
+
+	for key, a := range l.attempts {
+		a.failures = recent(a.failures, since)
+		if len(a.failures) == 0 && !a.lockedUntil.After(now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func recent(failures []time.Time, since time.Time) []time.Time {
+	kept := failures[:0]
+	for _, t := range failures {
+		if t.After(since) {
+			kept = append(kept, t)
+		}
+	}
+
+	return kept
+}
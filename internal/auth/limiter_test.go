@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestLoginLimiter(t *testing.T) {
+	limits := domain.LoginLimits{MaxAttempts: 3, MaxAttemptsPerIP: 5, Lockout: 15 * time.Minute}
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("username", func(t *testing.T) {
+		l := newLoginLimiter(limits)
+
+		assert.True(t, l.fail("admin", "10.0.0.1", now).IsZero())
+		assert.True(t, l.fail("Admin", "10.0.0.2", now).IsZero())
+		assert.Equal(t, now.Add(15*time.Minute), l.fail("admin", "10.0.0.3", now))
+
+		assert.Equal(t, now.Add(15*time.Minute), l.lockedUntil("ADMIN", "10.0.0.4", now.Add(time.Minute)))
+		assert.True(t, l.lockedUntil("other", "10.0.0.4", now).IsZero())
+		assert.True(t, l.lockedUntil("admin", "10.0.0.4", now.Add(15*time.Minute)).IsZero())
+	})
+
+	t.Run("address", func(t *testing.T) {
+		l := newLoginLimiter(limits)
+
+		for _, user := range []string{"a", "b", "c", "d"} {
+			assert.True(t, l.fail(user, "10.0.0.1", now).IsZero())
+		}
+		assert.Equal(t, now.Add(15*time.Minute), l.fail("e", "10.0.0.1", now))
+		assert.False(t, l.lockedUntil("f", "10.0.0.1", now).IsZero())
+	})
+
+	t.Run("old failures and reset", func(t *testing.T) {
+		l := newLoginLimiter(limits)
+
+		l.fail("admin", "10.0.0.1", now)
+		l.fail("admin", "10.0.0.1", now)
+		// the first two are outside the window
+		assert.True(t, l.fail("admin", "10.0.0.1", now.Add(20*time.Minute)).IsZero())
+
+		l.fail("admin", "10.0.0.1", now.Add(20*time.Minute))
+		l.reset("admin", "10.0.0.1")
+		assert.True(t, l.fail("admin", "10.0.0.1", now.Add(20*time.Minute)).IsZero())
+		assert.Len(t, l.attempts, 2)
+	})
+}
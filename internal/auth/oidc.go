@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/oidc"
+)
+
+// oidcClient provider discovery is done on the first login so autobrr starts when the provider is down
+type oidcClient struct {
+	settings domain.OIDCSettings
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (c *oidcClient) config() oidc.Config {
+	return oidc.Config{
+		ClientID:     c.settings.ClientID,
+		ClientSecret: c.settings.ClientSecret,
+		RedirectURL:  c.settings.RedirectURL,
+		Scopes:       c.settings.Scopes,
+	}
+}
+
+func (c *oidcClient) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	provider, err := oidc.Discover(ctx, nil, c.settings.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	c.provider = provider
+
+	return provider, nil
+}
+
+func (s *service) OIDCEnabled() bool {
+	return s.oidc.settings.Enabled
+}
+
+// OIDCLogin the provider url to send the user to, and the state to keep until the callback
+func (s *service) OIDCLogin(ctx context.Context) (string, *domain.OIDCLoginState, error) {
+	if !s.OIDCEnabled() {
+		return "", nil, errors.New("oidc is not enabled")
+	}
+
+	provider, err := s.oidc.getProvider(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var state domain.OIDCLoginState
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *value, err = oidc.RandomString(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return provider.AuthCodeURL(s.oidc.config(), state.State, state.Nonce, state.Verifier), &state, nil
+}
+
+// OIDCCallback verify the login at the provider and map the groups of the user to a role
+func (s *service) OIDCCallback(ctx context.Context, code string, returnedState string, pending domain.OIDCLoginState, client domain.AuthClient) (*domain.OIDCIdentity, error) {
+	identity, err := s.oidcCallback(ctx, code, returnedState, pending)
+	if err != nil {
+		s.audit(ctx, domain.AuthEventLoginFailed, "", "oidc", client, err.Error())
+		return nil, err
+	}
+
+	s.audit(ctx, domain.AuthEventLoginSuccess, identity.Username, "oidc", client, "")
+
+	return identity, nil
+}
+
+func (s *service) oidcCallback(ctx context.Context, code string, returnedState string, pending domain.OIDCLoginState) (*domain.OIDCIdentity, error) {
+	if !s.OIDCEnabled() {
+		return nil, errors.New("oidc is not enabled")
+	}
+
+	if pending.State == "" || subtle.ConstantTimeCompare([]byte(returnedState), []byte(pending.State)) != 1 {
+		return nil, errors.New("login state does not match, start the login again")
+	}
+
+	provider, err := s.oidc.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, s.oidc.config(), code, pending.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.Verify(ctx, s.oidc.config(), rawIDToken, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &domain.OIDCIdentity{
+		Subject: claims.String("sub"),
+		Name:    claims.String("preferred_username"),
+		Email:   claims.String("email"),
+		Groups:  claims.Strings(s.oidc.settings.GroupsClaim),
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	identity.Username = domain.OIDCUsername(identity.Subject)
+
+	if identity.Name == "" {
+		identity.Name = identity.Email
+	}
+	if identity.Name == "" {
+		identity.Name = identity.Subject
+	}
+
+	identity.Role = s.oidc.settings.RoleForGroups(identity.Groups)
+	if identity.Role == "" {
+		log.Warn().Msgf("auth.oidc: denied login for %v (%v), none of the groups %v has a role", identity.Name, identity.Username, identity.Groups)
+		return nil, fmt.Errorf("user %v has no role in autobrr", identity.Name)
+	}
+
+	log.Info().Msgf("auth.oidc: %v (%v) signed in as %v", identity.Name, identity.Username, identity.Role)
+
+	return identity, nil
+}
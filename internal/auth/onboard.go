@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// minOnboardPasswordLength the endpoint is open to anyone who reaches a fresh instance, a trivial password isn't taken
+const minOnboardPasswordLength = 8
+
+// CanOnboard no user exists yet
+func (s *service) CanOnboard(ctx context.Context) (bool, error) {
+	users, err := s.userSvc.List(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return len(users) == 0, nil
+}
+
+// Onboard create the first user as admin, only while there are no users
+func (s *service) Onboard(ctx context.Context, req domain.CreateUserRequest, client domain.AuthClient) (*domain.User, error) {
+	if len(req.Password) < minOnboardPasswordLength {
+		return nil, fmt.Errorf("password has to be at least %d characters", minOnboardPasswordLength)
+	}
+
+	if err := domain.ValidateUsername(req.Username); err != nil {
+		return nil, err
+	}
+
+	s.onboard.Lock()
+	defer s.onboard.Unlock()
+
+	ok, err := s.CanOnboard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, domain.ErrOnboardingUnavailable
+	}
+
+	u, err := s.userSvc.Create(ctx, domain.CreateUserRequest{Username: req.Username, Password: req.Password, Role: domain.UserRoleAdmin})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, domain.AuthEventOnboard, u.Username, "password", client, "")
+
+	log.Info().Msgf("auth: onboarding created the first user %v", u.Username)
+
+	return u, nil
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/user"
+)
+
+func TestService_Onboard(t *testing.T) {
+	ctx := context.Background()
+
+	db := database.NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	svc := NewService(user.NewService(database.NewUserRepo(db)), database.NewAuthAuditRepo(db), domain.LoginLimits{}, domain.OIDCSettings{})
+	client := domain.AuthClient{IP: "127.0.0.1"}
+
+	ok, err := svc.CanOnboard(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = svc.Onboard(ctx, domain.CreateUserRequest{Username: "bob", Password: "short"}, client)
+	require.Error(t, err)
+
+	// only one of the requests racing for the first account gets it
+	var wg sync.WaitGroup
+	created := make(chan *domain.User, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if u, err := svc.Onboard(ctx, domain.CreateUserRequest{Username: "bob", Password: "password123", Role: domain.UserRoleReadOnly}, client); err == nil {
+				created <- u
+			} else {
+				assert.ErrorIs(t, err, domain.ErrOnboardingUnavailable)
+			}
+		}()
+	}
+	wg.Wait()
+	close(created)
+
+	require.Len(t, created, 1)
+	u := <-created
+	assert.Equal(t, domain.UserRoleAdmin, u.Role)
+
+	ok, err = svc.CanOnboard(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	events, _, err := svc.FindAuthEvents(ctx, domain.AuthEventQueryParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, domain.AuthEventOnboard, events[0].Type)
+}
@@ -2,7 +2,12 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/user"
@@ -10,20 +15,73 @@ import (
 )
 
 type Service interface {
-	Login(ctx context.Context, username, password string) (*domain.User, error)
+	Login(ctx context.Context, req domain.LoginRequest) (*domain.User, error)
+	CanOnboard(ctx context.Context) (bool, error)
+	Onboard(ctx context.Context, req domain.CreateUserRequest, client domain.AuthClient) (*domain.User, error)
+	FindAuthEvents(ctx context.Context, params domain.AuthEventQueryParams) ([]domain.AuthEvent, int64, error)
+	TOTPStatus(ctx context.Context, username string) (*domain.TOTPStatus, error)
+	TOTPEnroll(ctx context.Context, username string) (*domain.TOTPEnrollment, error)
+	TOTPConfirm(ctx context.Context, username, code string) ([]string, error)
+	TOTPDisable(ctx context.Context, username, code string) error
+	OIDCEnabled() bool
+	OIDCLogin(ctx context.Context) (string, *domain.OIDCLoginState, error)
+	OIDCCallback(ctx context.Context, code string, returnedState string, pending domain.OIDCLoginState, client domain.AuthClient) (*domain.OIDCIdentity, error)
 }
 
 type service struct {
-	userSvc user.Service
+	userSvc   user.Service
+	auditRepo domain.AuthAuditRepo
+	limiter   *loginLimiter
+	oidc      *oidcClient
+
+	// onboard holds off a second request until the first user is stored
+	onboard sync.Mutex
 }
 
-func NewService(userSvc user.Service) Service {
+func NewService(userSvc user.Service, auditRepo domain.AuthAuditRepo, limits domain.LoginLimits, oidcSettings domain.OIDCSettings) Service {
 	return &service{
-		userSvc: userSvc,
+		userSvc:   userSvc,
+		auditRepo: auditRepo,
+		limiter:   newLoginLimiter(limits),
+		oidc:      &oidcClient{settings: oidcSettings},
 	}
 }
 
-func (s *service) Login(ctx context.Context, username, password string) (*domain.User, error) {
+// Login check the credentials unless the username or address is locked out after too many failures.
+// Every attempt that is decided ends up in the audit log.
+func (s *service) Login(ctx context.Context, req domain.LoginRequest) (*domain.User, error) {
+	now := time.Now()
+
+	if until := s.limiter.lockedUntil(req.Username, req.Client.IP, now); !until.IsZero() {
+		err := &domain.LoginLockedError{Until: until}
+		s.audit(ctx, domain.AuthEventLoginLocked, req.Username, "password", req.Client, err.Error())
+		return nil, err
+	}
+
+	u, err := s.login(ctx, req.Username, req.Password, req.Code)
+	if err != nil {
+		// the password was right, the ui asks for the code next
+		if errors.Is(err, domain.ErrTOTPRequired) {
+			return nil, err
+		}
+
+		s.audit(ctx, domain.AuthEventLoginFailed, req.Username, "password", req.Client, err.Error())
+
+		if until := s.limiter.fail(req.Username, req.Client.IP, now); !until.IsZero() {
+			log.Warn().Msgf("auth: too many failed logins for %v from %v, locked until %v", req.Username, req.Client.IP, until.Format(time.RFC3339))
+		}
+
+		return nil, err
+	}
+
+	s.limiter.reset(req.Username, req.Client.IP)
+	s.audit(ctx, domain.AuthEventLoginSuccess, u.Username, "password", req.Client, "")
+
+	return u, nil
+}
+
+// login check the password, and the totp or a recovery code when the user has two-factor authentication
+func (s *service) login(ctx context.Context, username, password, code string) (*domain.User, error) {
 	if username == "" || password == "" {
 		return nil, errors.New("bad credentials")
 	}
@@ -31,6 +89,9 @@ func (s *service) Login(ctx context.Context, username, password string) (*domain
 	// find user
 	u, err := s.userSvc.FindByUsername(ctx, username)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("bad credentials")
+		}
 		return nil, err
 	}
 
@@ -48,5 +109,36 @@ func (s *service) Login(ctx context.Context, username, password string) (*domain
 		return nil, errors.New("bad credentials")
 	}
 
+	if u.TOTPEnabled {
+		if code == "" {
+			return nil, domain.ErrTOTPRequired
+		}
+
+		if err := s.checkSecondFactor(ctx, u, code); err != nil {
+			return nil, err
+		}
+	}
+
 	return u, nil
 }
+
+func (s *service) FindAuthEvents(ctx context.Context, params domain.AuthEventQueryParams) ([]domain.AuthEvent, int64, error) {
+	return s.auditRepo.Find(ctx, params)
+}
+
+// audit store the event, a failure is logged and does not fail the login
+func (s *service) audit(ctx context.Context, eventType domain.AuthEventType, username string, method string, client domain.AuthClient, message string) {
+	event := &domain.AuthEvent{
+		Type:      eventType,
+		Username:  username,
+		Method:    method,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+
+	if err := s.auditRepo.Store(ctx, event); err != nil {
+		log.Error().Err(err).Msg("auth: could not store audit event")
+	}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/argon2id"
+	"github.com/autobrr/autobrr/pkg/totp"
+)
+
+const (
+	totpIssuer = "autobrr"
+
+	// recoveryCodeCount codes handed out when two-factor authentication is enabled
+	recoveryCodeCount = 10
+)
+
+func (s *service) findUser(ctx context.Context, username string) (*domain.User, error) {
+	if username == "" {
+		return nil, errors.New("not logged in with a local user")
+	}
+
+	u, err := s.userSvc.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return u, nil
+}
+
+func (s *service) TOTPStatus(ctx context.Context, username string) (*domain.TOTPStatus, error) {
+	u, err := s.findUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TOTPStatus{Enabled: u.TOTPEnabled, RecoveryCodes: len(u.RecoveryCodes)}, nil
+}
+
+// TOTPEnroll store a new secret, it is not used for login until it is confirmed with a code
+func (s *service) TOTPEnroll(ctx context.Context, username string) (*domain.TOTPEnrollment, error) {
+	u, err := s.findUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	u.TOTPSecret = secret
+	u.RecoveryCodes = nil
+
+	if err := s.userSvc.UpdateTOTP(ctx, *u); err != nil {
+		return nil, err
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, u.Username, secret),
+	}, nil
+}
+
+// TOTPConfirm enable two-factor authentication when the code matches the pending secret.
+// The recovery codes are returned once, only their hashes are stored.
+func (s *service) TOTPConfirm(ctx context.Context, username, code string) ([]string, error) {
+	u, err := s.findUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if u.TOTPSecret == "" {
+		return nil, errors.New("no pending enrollment")
+	}
+
+	if !totp.Validate(u.TOTPSecret, code, time.Now()) {
+		return nil, errors.New("invalid code")
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		recoveryCode, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := argon2id.CreateHash(recoveryCode, argon2id.DefaultParams)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, hash)
+	}
+
+	u.TOTPEnabled = true
+	u.RecoveryCodes = hashes
+
+	if err := s.userSvc.UpdateTOTP(ctx, *u); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("auth: two-factor authentication enabled for user: %v", u.Username)
+
+	return codes, nil
+}
+
+// TOTPDisable turn off two-factor authentication, a totp or recovery code is needed
+func (s *service) TOTPDisable(ctx context.Context, username, code string) error {
+	u, err := s.findUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if !u.TOTPEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.checkSecondFactor(ctx, u, code); err != nil {
+		return err
+	}
+
+	u.TOTPEnabled = false
+	u.TOTPSecret = ""
+	u.RecoveryCodes = nil
+
+	if err := s.userSvc.UpdateTOTP(ctx, *u); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("auth: two-factor authentication disabled for user: %v", u.Username)
+
+	return nil
+}
+
+// checkSecondFactor accept the current totp, or a recovery code which is removed so it only works once
+func (s *service) checkSecondFactor(ctx context.Context, u *domain.User, code string) error {
+	if totp.Validate(u.TOTPSecret, code, time.Now()) {
+		return nil
+	}
+
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return errors.New("invalid code")
+	}
+
+	for i, hash := range u.RecoveryCodes {
+		match, err := argon2id.ComparePasswordAndHash(code, hash)
+		if err != nil || !match {
+			continue
+		}
+
+		remaining := make([]string, 0, len(u.RecoveryCodes)-1)
+		remaining = append(remaining, u.RecoveryCodes[:i]...)
+		remaining = append(remaining, u.RecoveryCodes[i+1:]...)
+		u.RecoveryCodes = remaining
+
+		if err := s.userSvc.UpdateTOTP(ctx, *u); err != nil {
+			return err
+		}
+
+		log.Info().Msgf("auth: recovery code used by user: %v, %d left", u.Username, len(remaining))
+
+		return nil
+	}
+
+	return errors.New("invalid code")
+}
+
+// newRecoveryCode 10 random base32 characters formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode the code as generated, users might type it in upper case or without the dash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 10 {
+		return ""
+	}
+
+	return code[:5] + "-" + code[5:]
+}
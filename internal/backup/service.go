@@ -0,0 +1,342 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// names of the files in the archive
+const (
+	manifestFile = "manifest.json"
+	databaseFile = "autobrr.db"
+	configFile   = "config.toml"
+)
+
+// maxConfigSize config files are small, anything bigger is not one
+const maxConfigSize = 1 << 20
+
+type Service interface {
+	Backup(ctx context.Context, w io.Writer, opts domain.BackupOptions) error
+	Restore(ctx context.Context, r io.Reader, opts domain.RestoreOptions) (*domain.RestoreResult, error)
+	RunScheduled(ctx context.Context) (string, error)
+}
+
+type backupDB interface {
+	SchemaVersion(ctx context.Context) (int, error)
+	Backup(ctx context.Context, path string, redact bool) error
+	StageRestore(ctx context.Context, path string) (int, error)
+	DiscardRestore() error
+}
+
+type configFiles interface {
+	File() string
+	Export(redact bool) ([]byte, error)
+	Restore(content []byte, keepSecrets bool) error
+}
+
+type service struct {
+	db       backupDB
+	config   configFiles
+	version  string
+	settings domain.BackupSettings
+}
+
+func NewService(db backupDB, config configFiles, version string, settings domain.BackupSettings) Service {
+	return &service{
+		db:       db,
+		config:   config,
+		version:  version,
+		settings: settings,
+	}
+}
+
+// Backup write a tar.gz with the manifest, a copy of the database and the config file
+func (s *service) Backup(ctx context.Context, w io.Writer, opts domain.BackupOptions) error {
+	dir, err := ioutil.TempDir("", "autobrr-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	schema, err := s.db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(dir, databaseFile)
+	if err := s.db.Backup(ctx, dbPath, opts.Redact); err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(domain.BackupManifest{
+		Version:   s.version,
+		Schema:    schema,
+		CreatedAt: time.Now().UTC(),
+		Redacted:  opts.Redact,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeEntry(tw, manifestFile, manifest); err != nil {
+		return err
+	}
+
+	if err := writeFileEntry(tw, databaseFile, dbPath); err != nil {
+		return err
+	}
+
+	// without a config file autobrr runs on defaults, there's nothing to add
+	if s.config.File() != "" {
+		cfg, err := s.config.Export(opts.Redact)
+		if err != nil {
+			return err
+		}
+
+		if err := writeEntry(tw, configFile, cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+// Restore check the archive and stage the database, autobrr has to restart to use it.
+// The config of a redacted backup keeps the secrets of the current config.
+func (s *service) Restore(ctx context.Context, r io.Reader, opts domain.RestoreOptions) (*domain.RestoreResult, error) {
+	dir, err := ioutil.TempDir("", "autobrr-restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gr.Close()
+
+	var (
+		manifest    *domain.BackupManifest
+		cfg         []byte
+		dbPath      = filepath.Join(dir, databaseFile)
+		hasDatabase bool
+	)
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a backup archive: %w", err)
+		}
+
+		switch hdr.Name {
+		case manifestFile:
+			manifest = &domain.BackupManifest{}
+			if err := json.NewDecoder(io.LimitReader(tr, maxConfigSize)).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+
+		case databaseFile:
+			if err := writeTo(dbPath, tr); err != nil {
+				return nil, err
+			}
+			hasDatabase = true
+
+		case configFile:
+			if hdr.Size > maxConfigSize {
+				return nil, errors.New("config file in backup is too big")
+			}
+
+			if cfg, err = ioutil.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if manifest == nil || !hasDatabase {
+		return nil, errors.New("not a backup archive: manifest or database missing")
+	}
+
+	if _, err := s.db.StageRestore(ctx, dbPath); err != nil {
+		return nil, err
+	}
+
+	result := &domain.RestoreResult{
+		Manifest:        *manifest,
+		RestartRequired: true,
+	}
+
+	if opts.Config && cfg != nil {
+		if err := s.config.Restore(cfg, manifest.Redacted); err != nil {
+			// all or nothing, a database without its config is not what was asked for
+			if discardErr := s.db.DiscardRestore(); discardErr != nil {
+				log.Error().Err(discardErr).Msg("backup: could not discard staged database")
+			}
+			return nil, err
+		}
+
+		result.Config = true
+	}
+
+	log.Info().Msgf("backup: restore from %v staged, restart autobrr to apply it", manifest.CreatedAt.Format(time.RFC3339))
+
+	return result, nil
+}
+
+// RunScheduled write a backup to the backups folder next to the config and remove the oldest ones.
+// Returns the path of the new backup.
+func (s *service) RunScheduled(ctx context.Context) (string, error) {
+	dir := s.dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, domain.BackupFileName(time.Now()))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.Backup(ctx, f, domain.BackupOptions{}); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	log.Debug().Msgf("backup: wrote %v", path)
+
+	return path, s.prune(dir)
+}
+
+func (s *service) dir() string {
+	return filepath.Join(filepath.Dir(s.config.File()), "backups")
+}
+
+// prune keep the newest backups, the names sort by time
+func (s *service) prune(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "autobrr-backup-") && strings.HasSuffix(entry.Name(), ".tar.gz") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	for len(names) > s.settings.Keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+
+		log.Debug().Msgf("backup: removed old backup %v", names[0])
+
+		names = names[1:]
+	}
+
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, content []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(content)
+	return err
+}
+
+func writeFileEntry(tw *tar.Writer, name string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func writeTo(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ScheduledJob write a backup on the configured interval
+type ScheduledJob struct {
+	service Service
+}
+
+func NewScheduledJob(service Service) *ScheduledJob {
+	return &ScheduledJob{service: service}
+}
+
+func (j *ScheduledJob) Run() {
+	if _, err := j.service.RunScheduled(context.Background()); err != nil {
+		log.Error().Err(err).Msg("backup: scheduled backup failed")
+	}
+}
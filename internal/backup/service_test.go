@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeConfig struct {
+	file     string
+	restored []byte
+	secrets  bool
+}
+
+func (c *fakeConfig) File() string { return c.file }
+
+func (c *fakeConfig) Export(redact bool) ([]byte, error) {
+	if redact {
+		return []byte(`sessionSecret = ""`), nil
+	}
+	return []byte(`sessionSecret = "secret"`), nil
+}
+
+func (c *fakeConfig) Restore(content []byte, keepSecrets bool) error {
+	c.restored = content
+	c.secrets = keepSecrets
+	return nil
+}
+
+func openDB(t *testing.T, dir string) *database.DB {
+	db := database.NewSqliteDB(dir)
+	require.NoError(t, db.Open())
+	return db
+}
+
+func Test_service_BackupRestore(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "autobrr-backup-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	target := filepath.Join(dir, "target")
+	require.NoError(t, os.MkdirAll(source, 0700))
+	require.NoError(t, os.MkdirAll(target, 0700))
+
+	db := openDB(t, source)
+	defer db.Close()
+
+	clients := database.NewDownloadClientRepo(db)
+	_, err = clients.Store(domain.DownloadClient{Name: "qbit", Type: domain.DownloadClientTypeQbittorrent, Host: "localhost", Password: "hunter2"})
+	require.NoError(t, err)
+
+	cfg := &fakeConfig{file: filepath.Join(source, "config.toml")}
+	svc := NewService(db, cfg, "test", domain.BackupSettings{Keep: 1})
+
+	var archive bytes.Buffer
+	require.NoError(t, svc.Backup(ctx, &archive, domain.BackupOptions{Redact: true}))
+
+	targetDB := openDB(t, target)
+	targetCfg := &fakeConfig{file: filepath.Join(target, "config.toml")}
+
+	result, err := NewService(targetDB, targetCfg, "test", domain.BackupSettings{}).Restore(ctx, &archive, domain.RestoreOptions{Config: true})
+	require.NoError(t, err)
+	assert.True(t, result.Manifest.Redacted)
+	assert.True(t, result.Config)
+	assert.True(t, result.RestartRequired)
+	assert.Equal(t, `sessionSecret = ""`, string(targetCfg.restored))
+	assert.True(t, targetCfg.secrets)
+
+	// the staged database replaces the one in target when it's opened again
+	require.NoError(t, targetDB.Close())
+	targetDB = openDB(t, target)
+	defer targetDB.Close()
+
+	restored, err := database.NewDownloadClientRepo(targetDB).List()
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	assert.Equal(t, "qbit", restored[0].Name)
+	assert.Equal(t, "", restored[0].Password)
+
+	_, err = os.Stat(filepath.Join(target, "autobrr.db.restore"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_service_Restore_invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "autobrr-backup-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db := openDB(t, dir)
+	defer db.Close()
+
+	svc := NewService(db, &fakeConfig{}, "test", domain.BackupSettings{})
+
+	_, err = svc.Restore(context.Background(), bytes.NewBufferString("not a backup"), domain.RestoreOptions{})
+	assert.Error(t, err)
+}
+
+func Test_service_RunScheduled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "autobrr-backup-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db := openDB(t, dir)
+	defer db.Close()
+
+	backups := filepath.Join(dir, "backups")
+	require.NoError(t, os.MkdirAll(backups, 0700))
+	for _, name := range []string{"autobrr-backup-20220101T000000Z.tar.gz", "autobrr-backup-20220102T000000Z.tar.gz", "notes.txt"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(backups, name), nil, 0600))
+	}
+
+	svc := NewService(db, &fakeConfig{file: filepath.Join(dir, "config.toml")}, "test", domain.BackupSettings{Keep: 2})
+	path, err := svc.RunScheduled(context.Background())
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	entries, err := ioutil.ReadDir(backups)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	assert.Len(t, names, 3)
+	assert.Contains(t, names, "autobrr-backup-20220102T000000Z.tar.gz")
+	assert.Contains(t, names, "notes.txt")
+	assert.NotContains(t, names, "autobrr-backup-20220101T000000Z.tar.gz")
+}
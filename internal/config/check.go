@@ -0,0 +1,77 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// Check read the config file like autobrr does on start and validate the settings. Unlike Read it doesn't
+// write a default config when there is none, and every problem is returned instead of the first.
+func Check(configPath string) (domain.Config, []error) {
+	file := path.Join(path.Clean(configPath), "config.toml")
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return domain.Config{}, []error{err}
+	}
+
+	cfg, err := parse(content)
+	if err != nil {
+		return domain.Config{}, []error{fmt.Errorf("could not parse %v: %w", file, err)}
+	}
+
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", name, err))
+		}
+	}
+
+	if strings.TrimSpace(cfg.Host) == "" {
+		check("host", errors.New("can't be empty"))
+	}
+
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		check("port", errors.New("has to be between 1 and 65535"))
+	}
+
+	if cfg.BaseURL != "" && (!strings.HasPrefix(cfg.BaseURL, "/") || !strings.HasSuffix(cfg.BaseURL, "/")) {
+		check("baseUrl", errors.New("has to start and end with /, like /autobrr/"))
+	}
+
+	check("log", cfg.LogSettings().Validate())
+
+	_, err = cfg.DatabaseSettings()
+	check("database", err)
+
+	_, err = cfg.MaintenanceSettings()
+	check("maintenance", err)
+
+	_, err = cfg.OIDCSettings()
+	check("oidc", err)
+
+	_, err = cfg.ReverseProxies()
+	check("trusted proxies", err)
+
+	check("release retention", cfg.ReleaseRetention().Validate())
+
+	_, err = EncryptionKey(cfg)
+	check("encryption key", err)
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			check("tls", errors.New("tlsCertFile and tlsKeyFile are both required"))
+		} else {
+			_, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+			check("tls", err)
+		}
+	}
+
+	return cfg, errs
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	_, errs := Check(dir)
+	require.Len(t, errs, 1, "missing config file")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.toml"), []byte("host = \"127.0.0.1\"\nport = 7474\n"), 0600))
+
+	cfg, errs := Check(dir)
+	assert.Empty(t, errs)
+	assert.Equal(t, 7474, cfg.Port)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.toml"), []byte("host = \"\"\nport = 70000\nbaseUrl = \"autobrr\"\ntlsCertFile = \"cert.pem\"\n"), 0600))
+
+	_, errs = Check(dir)
+	assert.Len(t, errs, 4)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.toml"), []byte("port = \"\n"), 0600))
+
+	_, errs = Check(dir)
+	assert.Len(t, errs, 1, "invalid toml")
+}
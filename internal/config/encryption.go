@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// EncryptionKeyEnv environment variable with the encryption key, it keeps the key out of the config file
+const EncryptionKeyEnv = "AUTOBRR_ENCRYPTION_KEY"
+
+// EncryptionKey the key for the secrets in the database from the environment, the key file or the config,
+// in that order. Empty when none is set.
+func EncryptionKey(cfg domain.Config) (string, error) {
+	if key := os.Getenv(EncryptionKeyEnv); key != "" {
+		return key, nil
+	}
+
+	if cfg.EncryptionKeyFile != "" {
+		b, err := ioutil.ReadFile(cfg.EncryptionKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read encryption key file: %w", err)
+		}
+
+		key := strings.TrimSpace(string(b))
+		if key == "" {
+			return "", fmt.Errorf("encryption key file %v is empty", cfg.EncryptionKeyFile)
+		}
+
+		return key, nil
+	}
+
+	return cfg.EncryptionKey, nil
+}
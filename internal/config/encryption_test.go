@@ -0,0 +1,35 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestEncryptionKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := ioutil.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := EncryptionKey(domain.Config{EncryptionKey: "from-config"})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-config", key)
+
+	key, err = EncryptionKey(domain.Config{EncryptionKey: "from-config", EncryptionKeyFile: keyFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file", key)
+
+	_, err = EncryptionKey(domain.Config{EncryptionKeyFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+
+	t.Setenv(EncryptionKeyEnv, "from-env")
+
+	key, err = EncryptionKey(domain.Config{EncryptionKey: "from-config", EncryptionKeyFile: keyFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", key)
+}
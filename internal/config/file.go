@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// updateFile set the keys in the toml file and keep everything else, comments included.
+// A commented out key from the template is replaced, keys that are not in the file are appended.
+func updateFile(path string, values map[string]interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, []byte(setKeys(string(b), values)))
+}
+
+func setKeys(content string, values map[string]interface{}) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		line := fmt.Sprintf("%v = %v", key, tomlValue(values[key]))
+
+		set := keyPattern(key, false)
+		commented := keyPattern(key, true)
+
+		switch {
+		case set.MatchString(content):
+			content = replaceFirst(set, content, line)
+		case commented.MatchString(content):
+			content = replaceFirst(commented, content, line)
+		default:
+			if !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+			content += line + "\n"
+		}
+	}
+
+	return content
+}
+
+// writeFile replace the file and keep its permissions.
+// It's written next to the config and renamed, a crash can't leave half a config behind.
+func writeFile(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// blankKey set the key to an empty string when it's set in the file
+func blankKey(content string, key string) string {
+	set := keyPattern(key, false)
+	if !set.MatchString(content) {
+		return content
+	}
+
+	return replaceFirst(set, content, key+` = ""`)
+}
+
+// keyPattern matches the line of the key, or the commented out line like in the template
+func keyPattern(key string, commented bool) *regexp.Regexp {
+	prefix := `(?m)^[ \t]*`
+	if commented {
+		prefix += `#[ \t]*`
+	}
+
+	return regexp.MustCompile(prefix + regexp.QuoteMeta(key) + `[ \t]*=.*$`)
+}
+
+func replaceFirst(rxp *regexp.Regexp, content string, line string) string {
+	loc := rxp.FindStringIndex(content)
+	return content[:loc[0]] + line + content[loc[1]:]
+}
+
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_updateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "autobrr-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.toml")
+
+	content := `# config.toml
+
+host = "127.0.0.1"
+
+# Log level
+#
+# Default: "DEBUG"
+#
+#logLevel = "DEBUG"
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = updateFile(path, map[string]interface{}{
+		"host":            "0.0.0.0",
+		"logLevel":        "TRACE",
+		"port":            7575,
+		"checkForUpdates": false,
+	})
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	want := `# config.toml
+
+host = "0.0.0.0"
+
+# Log level
+#
+# Default: "DEBUG"
+#
+logLevel = "TRACE"
+checkForUpdates = false
+port = 7575
+`
+	assert.Equal(t, want, string(b))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_updateFile_missing(t *testing.T) {
+	err := updateFile(filepath.Join(os.TempDir(), "autobrr-does-not-exist.toml"), map[string]interface{}{"port": 7474})
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type Service interface {
+	Get() domain.Config
+	RestartRequired() []string
+	Update(update domain.ConfigUpdate) (domain.Config, error)
+	File() string
+	Export(redact bool) ([]byte, error)
+	Restore(content []byte, keepSecrets bool) error
+}
+
+type logSettings interface {
+	Settings() domain.LogSettings
+	UpdateSettings(settings domain.LogSettings) error
+}
+
+type sessionSettings interface {
+	UpdateSettings(settings domain.SessionSettings)
+}
+
+type service struct {
+	mu sync.Mutex
+
+	// running the config autobrr started with, current includes the changes made since
+	running domain.Config
+	current domain.Config
+	file    string
+
+	logs     logSettings
+	sessions sessionSettings
+}
+
+// NewService changes are written to the config file that was read on start
+func NewService(cfg domain.Config, logs logSettings, sessions sessionSettings) Service {
+	return &service{
+		running:  cfg,
+		current:  cfg,
+		file:     viper.ConfigFileUsed(),
+		logs:     logs,
+		sessions: sessions,
+	}
+}
+
+func (s *service) Get() domain.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.current
+}
+
+// RestartRequired toml keys of saved settings that only apply after a restart
+func (s *service) RestartRequired() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.restartRequired()
+}
+
+func (s *service) restartRequired() []string {
+	keys := []string{}
+	for _, key := range domain.ConfigRestartKeys {
+		switch {
+		case key == "host" && s.current.Host != s.running.Host,
+			key == "port" && s.current.Port != s.running.Port,
+			key == "baseUrl" && s.current.BaseURL != s.running.BaseURL:
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Update validate, apply what can change while running and save the config file
+func (s *service) Update(update domain.ConfigUpdate) (domain.Config, error) {
+	if err := update.Validate(); err != nil {
+		return domain.Config{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == "" {
+		return domain.Config{}, errors.New("no config file to save to")
+	}
+
+	cfg, values := update.Apply(s.current)
+	if len(values) == 0 {
+		return s.current, nil
+	}
+
+	// the log file is opened before saving, a path autobrr can't write to is rejected
+	if update.LogLevel != nil || update.LogPath != nil {
+		logs := s.logs.Settings()
+		logs.Level = cfg.LogLevel
+		logs.Path = cfg.LogPath
+
+		if err := s.logs.UpdateSettings(logs); err != nil {
+			return domain.Config{}, err
+		}
+	}
+
+	if update.SessionLifetimeHours != nil || update.SessionIdleTimeoutMinutes != nil {
+		s.sessions.UpdateSettings(cfg.SessionSettings())
+	}
+
+	if err := updateFile(s.file, values); err != nil {
+		return domain.Config{}, err
+	}
+
+	s.current = cfg
+	Config = cfg
+
+	log.Info().Msgf("config: saved %v settings to %v", len(values), s.file)
+
+	return cfg, nil
+}
+
+// File path of the config file, empty when autobrr started without one
+func (s *service) File() string {
+	return s.file
+}
+
+// Export the config file as it is on disk, with redact the secrets are blanked
+func (s *service) Export(redact bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == "" {
+		return nil, errors.New("no config file to export")
+	}
+
+	b, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !redact {
+		return b, nil
+	}
+
+	content := string(b)
+	for key := range s.current.Secrets() {
+		content = blankKey(content, key)
+	}
+
+	return []byte(content), nil
+}
+
+// Restore replace the config file, it's used on the next start.
+// With keepSecrets the secrets of the current config are kept, for restoring a redacted backup.
+func (s *service) Restore(content []byte, keepSecrets bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == "" {
+		return errors.New("no config file to restore to")
+	}
+
+	if keepSecrets {
+		secrets := map[string]interface{}{}
+		for key, value := range s.current.Secrets() {
+			if value != "" {
+				secrets[key] = value
+			}
+		}
+
+		content = []byte(setKeys(string(content), secrets))
+	}
+
+	cfg, err := parse(content)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := writeFile(s.file, content); err != nil {
+		return err
+	}
+
+	s.current = cfg
+
+	log.Info().Msgf("config: restored %v", s.file)
+
+	return nil
+}
+
+// parse toml the same way Read does, on top of the defaults
+func parse(content []byte) (domain.Config, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return domain.Config{}, err
+	}
+
+	cfg := Defaults()
+	if err := v.Unmarshal(&cfg); err != nil {
+		return domain.Config{}, err
+	}
+
+	return cfg, nil
+}
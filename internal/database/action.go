@@ -3,16 +3,19 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+
 	"github.com/autobrr/autobrr/internal/domain"
 
 	"github.com/rs/zerolog/log"
 )
 
 type ActionRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewActionRepo(db *SqliteDB) domain.ActionRepo {
+func NewActionRepo(db *DB) domain.ActionRepo {
 	return &ActionRepo{db: db}
 }
 
@@ -20,9 +23,11 @@ func (r *ActionRepo) FindByFilterID(ctx context.Context, filterID int) ([]domain
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.Query("SELECT id, name, type, enabled, exec_cmd, exec_args, watch_folder, category, tags, label, save_path, paused, ignore_rules, limit_download_speed, limit_upload_speed, client_id FROM action WHERE action.filter_id = ?", filterID)
+	// actions are stored in the order they are chained
+	rows, err := r.db.handler.QueryContext(ctx, "SELECT id, name, type, enabled, exec_cmd, exec_args, exec_work_dir, exec_env, exec_timeout, watch_folder, watch_folder_file_name, category, tags, label, save_path, move_completed_path, paused, fast_resume, ignore_rules, limit_download_speed, limit_upload_speed, bandwidth_priority, webhook_host, webhook_type, webhook_method, webhook_data, webhook_headers, webhook_retry_attempts, webhook_retry_delay, run_condition, reannounce_skip, reannounce_delete, reannounce_interval, reannounce_max_attempts, sequential_download, skip_hash_check, cross_seed, delay, schedule_start, schedule_end, client_id FROM action WHERE action.filter_id = ? ORDER BY id ASC", filterID)
 	if err != nil {
-		log.Fatal().Err(err)
+		log.Error().Stack().Err(err).Msg("actions: error executing query")
+		return nil, err
 	}
 
 	defer rows.Close()
@@ -31,32 +36,63 @@ func (r *ActionRepo) FindByFilterID(ctx context.Context, filterID int) ([]domain
 	for rows.Next() {
 		var a domain.Action
 
-		var execCmd, execArgs, watchFolder, category, tags, label, savePath sql.NullString
+		var execCmd, execArgs, execWorkDir, execEnv, watchFolder, watchFolderFileName, category, tags, label, savePath, moveCompletedPath sql.NullString
+		var webhookHost, webhookType, webhookMethod, webhookData, webhookHeaders, runCondition, scheduleStart, scheduleEnd sql.NullString
 		var limitUl, limitDl sql.NullInt64
+		var execTimeout, webhookRetryAttempts, webhookRetryDelay, reannounceInterval, reannounceMaxAttempts, delay sql.NullInt32
+		var bandwidthPriority sql.NullInt32
 		var clientID sql.NullInt32
 		// filterID
-		var paused, ignoreRules sql.NullBool
+		var paused, fastResume, ignoreRules, reannounceSkip, reannounceDelete, sequentialDownload, skipHashCheck, crossSeed sql.NullBool
 
-		if err := rows.Scan(&a.ID, &a.Name, &a.Type, &a.Enabled, &execCmd, &execArgs, &watchFolder, &category, &tags, &label, &savePath, &paused, &ignoreRules, &limitDl, &limitUl, &clientID); err != nil {
-			log.Fatal().Err(err)
-		}
-		if err != nil {
+		if err := rows.Scan(&a.ID, &a.Name, &a.Type, &a.Enabled, &execCmd, &execArgs, &execWorkDir, r.db.scanSecret(&execEnv), &execTimeout, &watchFolder, &watchFolderFileName, &category, &tags, &label, &savePath, &moveCompletedPath, &paused, &fastResume, &ignoreRules, &limitDl, &limitUl, &bandwidthPriority, &webhookHost, &webhookType, &webhookMethod, &webhookData, r.db.scanSecret(&webhookHeaders), &webhookRetryAttempts, &webhookRetryDelay, &runCondition, &reannounceSkip, &reannounceDelete, &reannounceInterval, &reannounceMaxAttempts, &sequentialDownload, &skipHashCheck, &crossSeed, &delay, &scheduleStart, &scheduleEnd, &clientID); err != nil {
+			log.Error().Stack().Err(err).Msg("actions: error scanning data to struct")
 			return nil, err
 		}
 
 		a.ExecCmd = execCmd.String
 		a.ExecArgs = execArgs.String
+		a.ExecWorkDir = execWorkDir.String
+		a.ExecEnv = execEnv.String
+		a.ExecTimeout = int(execTimeout.Int32)
 		a.WatchFolder = watchFolder.String
+		a.WatchFolderFileName = watchFolderFileName.String
 		a.Category = category.String
 		a.Tags = tags.String
 		a.Label = label.String
 		a.SavePath = savePath.String
+		a.MoveCompletedPath = moveCompletedPath.String
 		a.Paused = paused.Bool
+		a.FastResume = fastResume.Bool
 		a.IgnoreRules = ignoreRules.Bool
 		a.LimitUploadSpeed = limitUl.Int64
 		a.LimitDownloadSpeed = limitDl.Int64
+		a.BandwidthPriority = int(bandwidthPriority.Int32)
+		a.WebhookHost = webhookHost.String
+		a.WebhookType = domain.WebhookType(webhookType.String)
+		a.WebhookMethod = webhookMethod.String
+		a.WebhookData = webhookData.String
+		a.WebhookRetryAttempts = int(webhookRetryAttempts.Int32)
+		a.WebhookRetryDelay = int(webhookRetryDelay.Int32)
+		a.RunCondition = domain.ActionRunCondition(runCondition.String)
+		a.ReannounceSkip = reannounceSkip.Bool
+		a.ReannounceDelete = reannounceDelete.Bool
+		a.ReannounceInterval = int(reannounceInterval.Int32)
+		a.ReannounceMaxAttempts = int(reannounceMaxAttempts.Int32)
+		a.SequentialDownload = sequentialDownload.Bool
+		a.SkipHashCheck = skipHashCheck.Bool
+		a.CrossSeed = crossSeed.Bool
+		a.Delay = int(delay.Int32)
+		a.ScheduleStart = scheduleStart.String
+		a.ScheduleEnd = scheduleEnd.String
 		a.ClientID = clientID.Int32
 
+		if webhookHeaders.String != "" {
+			if err := json.Unmarshal([]byte(webhookHeaders.String), &a.WebhookHeaders); err != nil {
+				return nil, err
+			}
+		}
+
 		actions = append(actions, a)
 	}
 	if err := rows.Err(); err != nil {
@@ -70,9 +106,10 @@ func (r *ActionRepo) List() ([]domain.Action, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.Query("SELECT id, name, type, enabled, exec_cmd, exec_args, watch_folder, category, tags, label, save_path, paused, ignore_rules, limit_download_speed, limit_upload_speed, client_id FROM action")
+	rows, err := r.db.handler.Query("SELECT id, name, type, enabled, exec_cmd, exec_args, exec_work_dir, exec_env, exec_timeout, watch_folder, watch_folder_file_name, category, tags, label, save_path, move_completed_path, paused, fast_resume, ignore_rules, limit_download_speed, limit_upload_speed, bandwidth_priority, webhook_host, webhook_type, webhook_method, webhook_data, webhook_headers, webhook_retry_attempts, webhook_retry_delay, run_condition, reannounce_skip, reannounce_delete, reannounce_interval, reannounce_max_attempts, sequential_download, skip_hash_check, cross_seed, delay, schedule_start, schedule_end, client_id FROM action ORDER BY id ASC")
 	if err != nil {
-		log.Fatal().Err(err)
+		log.Error().Stack().Err(err).Msg("actions: error executing query")
+		return nil, err
 	}
 
 	defer rows.Close()
@@ -81,28 +118,62 @@ func (r *ActionRepo) List() ([]domain.Action, error) {
 	for rows.Next() {
 		var a domain.Action
 
-		var execCmd, execArgs, watchFolder, category, tags, label, savePath sql.NullString
+		var execCmd, execArgs, execWorkDir, execEnv, watchFolder, watchFolderFileName, category, tags, label, savePath, moveCompletedPath sql.NullString
+		var webhookHost, webhookType, webhookMethod, webhookData, webhookHeaders, runCondition, scheduleStart, scheduleEnd sql.NullString
 		var limitUl, limitDl sql.NullInt64
+		var execTimeout, webhookRetryAttempts, webhookRetryDelay, reannounceInterval, reannounceMaxAttempts, delay sql.NullInt32
+		var bandwidthPriority sql.NullInt32
 		var clientID sql.NullInt32
-		var paused, ignoreRules sql.NullBool
+		var paused, fastResume, ignoreRules, reannounceSkip, reannounceDelete, sequentialDownload, skipHashCheck, crossSeed sql.NullBool
 
-		if err := rows.Scan(&a.ID, &a.Name, &a.Type, &a.Enabled, &execCmd, &execArgs, &watchFolder, &category, &tags, &label, &savePath, &paused, &ignoreRules, &limitDl, &limitUl, &clientID); err != nil {
-			log.Fatal().Err(err)
-		}
-		if err != nil {
+		if err := rows.Scan(&a.ID, &a.Name, &a.Type, &a.Enabled, &execCmd, &execArgs, &execWorkDir, r.db.scanSecret(&execEnv), &execTimeout, &watchFolder, &watchFolderFileName, &category, &tags, &label, &savePath, &moveCompletedPath, &paused, &fastResume, &ignoreRules, &limitDl, &limitUl, &bandwidthPriority, &webhookHost, &webhookType, &webhookMethod, &webhookData, r.db.scanSecret(&webhookHeaders), &webhookRetryAttempts, &webhookRetryDelay, &runCondition, &reannounceSkip, &reannounceDelete, &reannounceInterval, &reannounceMaxAttempts, &sequentialDownload, &skipHashCheck, &crossSeed, &delay, &scheduleStart, &scheduleEnd, &clientID); err != nil {
+			log.Error().Stack().Err(err).Msg("actions: error scanning data to struct")
 			return nil, err
 		}
 
+		a.ExecCmd = execCmd.String
+		a.ExecArgs = execArgs.String
+		a.ExecWorkDir = execWorkDir.String
+		a.ExecEnv = execEnv.String
+		a.ExecTimeout = int(execTimeout.Int32)
+		a.WatchFolder = watchFolder.String
+		a.WatchFolderFileName = watchFolderFileName.String
 		a.Category = category.String
 		a.Tags = tags.String
 		a.Label = label.String
 		a.SavePath = savePath.String
+		a.MoveCompletedPath = moveCompletedPath.String
 		a.Paused = paused.Bool
+		a.FastResume = fastResume.Bool
 		a.IgnoreRules = ignoreRules.Bool
 		a.LimitUploadSpeed = limitUl.Int64
 		a.LimitDownloadSpeed = limitDl.Int64
+		a.BandwidthPriority = int(bandwidthPriority.Int32)
+		a.WebhookHost = webhookHost.String
+		a.WebhookType = domain.WebhookType(webhookType.String)
+		a.WebhookMethod = webhookMethod.String
+		a.WebhookData = webhookData.String
+		a.WebhookRetryAttempts = int(webhookRetryAttempts.Int32)
+		a.WebhookRetryDelay = int(webhookRetryDelay.Int32)
+		a.RunCondition = domain.ActionRunCondition(runCondition.String)
+		a.ReannounceSkip = reannounceSkip.Bool
+		a.ReannounceDelete = reannounceDelete.Bool
+		a.ReannounceInterval = int(reannounceInterval.Int32)
+		a.ReannounceMaxAttempts = int(reannounceMaxAttempts.Int32)
+		a.SequentialDownload = sequentialDownload.Bool
+		a.SkipHashCheck = skipHashCheck.Bool
+		a.CrossSeed = crossSeed.Bool
+		a.Delay = int(delay.Int32)
+		a.ScheduleStart = scheduleStart.String
+		a.ScheduleEnd = scheduleEnd.String
 		a.ClientID = clientID.Int32
 
+		if webhookHeaders.String != "" {
+			if err := json.Unmarshal([]byte(webhookHeaders.String), &a.WebhookHeaders); err != nil {
+				return nil, err
+			}
+		}
+
 		actions = append(actions, a)
 	}
 	if err := rows.Err(); err != nil {
@@ -149,33 +220,56 @@ func (r *ActionRepo) Store(ctx context.Context, action domain.Action) (*domain.A
 
 	execCmd := toNullString(action.ExecCmd)
 	execArgs := toNullString(action.ExecArgs)
+	execWorkDir := toNullString(action.ExecWorkDir)
+	execEnv := toNullString(action.ExecEnv)
 	watchFolder := toNullString(action.WatchFolder)
+	watchFolderFileName := toNullString(action.WatchFolderFileName)
 	category := toNullString(action.Category)
 	tags := toNullString(action.Tags)
 	label := toNullString(action.Label)
 	savePath := toNullString(action.SavePath)
+	moveCompletedPath := toNullString(action.MoveCompletedPath)
+	webhookHost := toNullString(action.WebhookHost)
+	webhookType := toNullString(string(action.WebhookType))
+	webhookMethod := toNullString(action.WebhookMethod)
+	webhookData := toNullString(action.WebhookData)
+	runCondition := toNullString(string(action.RunCondition))
+	scheduleStart := toNullString(action.ScheduleStart)
+	scheduleEnd := toNullString(action.ScheduleEnd)
 
 	limitDL := toNullInt64(action.LimitDownloadSpeed)
 	limitUL := toNullInt64(action.LimitUploadSpeed)
 	clientID := toNullInt32(action.ClientID)
 	filterID := toNullInt32(int32(action.FilterID))
 
-	var err error
+	var webhookHeaders sql.NullString
+	if len(action.WebhookHeaders) > 0 {
+		h, err := json.Marshal(action.WebhookHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		webhookHeaders = toNullString(string(h))
+	}
+
 	if action.ID != 0 {
 		log.Debug().Msg("actions: update existing record")
-		_, err = r.db.handler.ExecContext(ctx, `UPDATE action SET name = ?, type = ?, enabled = ?, exec_cmd = ?, exec_args = ?, watch_folder = ? , category =? , tags = ?, label = ?, save_path = ?, paused = ?, ignore_rules = ?, limit_upload_speed = ?, limit_download_speed = ?, client_id = ? 
-			 WHERE id = ?`, action.Name, action.Type, action.Enabled, execCmd, execArgs, watchFolder, category, tags, label, savePath, action.Paused, action.IgnoreRules, limitUL, limitDL, clientID, action.ID)
+		_, err := r.db.handler.ExecContext(ctx, `UPDATE action SET name = ?, type = ?, enabled = ?, exec_cmd = ?, exec_args = ?, exec_work_dir = ?, exec_env = ?, exec_timeout = ?, watch_folder = ?, watch_folder_file_name = ?, category = ? , tags = ?, label = ?, save_path = ?, move_completed_path = ?, paused = ?, fast_resume = ?, ignore_rules = ?, limit_upload_speed = ?, limit_download_speed = ?, bandwidth_priority = ?, webhook_host = ?, webhook_type = ?, webhook_method = ?, webhook_data = ?, webhook_headers = ?, webhook_retry_attempts = ?, webhook_retry_delay = ?, run_condition = ?, reannounce_skip = ?, reannounce_delete = ?, reannounce_interval = ?, reannounce_max_attempts = ?, sequential_download = ?, skip_hash_check = ?, cross_seed = ?, delay = ?, schedule_start = ?, schedule_end = ?, client_id = ? 
+			 WHERE id = ?`, action.Name, action.Type, action.Enabled, execCmd, execArgs, execWorkDir, r.db.secret(execEnv), action.ExecTimeout, watchFolder, watchFolderFileName, category, tags, label, savePath, moveCompletedPath, action.Paused, action.FastResume, action.IgnoreRules, limitUL, limitDL, action.BandwidthPriority, webhookHost, webhookType, webhookMethod, webhookData, r.db.secret(webhookHeaders), action.WebhookRetryAttempts, action.WebhookRetryDelay, runCondition, action.ReannounceSkip, action.ReannounceDelete, action.ReannounceInterval, action.ReannounceMaxAttempts, action.SequentialDownload, action.SkipHashCheck, action.CrossSeed, action.Delay, scheduleStart, scheduleEnd, clientID, action.ID)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("actions: error updating record")
+			return nil, err
+		}
 	} else {
-		var res sql.Result
+		var resId int64
 
-		res, err = r.db.handler.ExecContext(ctx, `INSERT INTO action(name, type, enabled, exec_cmd, exec_args, watch_folder, category, tags, label, save_path, paused, ignore_rules, limit_upload_speed, limit_download_speed, client_id, filter_id)
-			VALUES (?, ?, ?, ?, ?,? ,?, ?,?,?,?,?,?,?,?,?) ON CONFLICT DO NOTHING`, action.Name, action.Type, action.Enabled, execCmd, execArgs, watchFolder, category, tags, label, savePath, action.Paused, action.IgnoreRules, limitUL, limitDL, clientID, filterID)
-		if err != nil {
-			log.Error().Err(err)
+		err := r.db.handler.QueryRowContext(ctx, `INSERT INTO action(name, type, enabled, exec_cmd, exec_args, exec_work_dir, exec_env, exec_timeout, watch_folder, watch_folder_file_name, category, tags, label, save_path, move_completed_path, paused, fast_resume, ignore_rules, limit_upload_speed, limit_download_speed, bandwidth_priority, webhook_host, webhook_type, webhook_method, webhook_data, webhook_headers, webhook_retry_attempts, webhook_retry_delay, run_condition, reannounce_skip, reannounce_delete, reannounce_interval, reannounce_max_attempts, sequential_download, skip_hash_check, cross_seed, delay, schedule_start, schedule_end, client_id, filter_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`, action.Name, action.Type, action.Enabled, execCmd, execArgs, execWorkDir, r.db.secret(execEnv), action.ExecTimeout, watchFolder, watchFolderFileName, category, tags, label, savePath, moveCompletedPath, action.Paused, action.FastResume, action.IgnoreRules, limitUL, limitDL, action.BandwidthPriority, webhookHost, webhookType, webhookMethod, webhookData, r.db.secret(webhookHeaders), action.WebhookRetryAttempts, action.WebhookRetryDelay, runCondition, action.ReannounceSkip, action.ReannounceDelete, action.ReannounceInterval, action.ReannounceMaxAttempts, action.SequentialDownload, action.SkipHashCheck, action.CrossSeed, action.Delay, scheduleStart, scheduleEnd, clientID, filterID).Scan(&resId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Stack().Err(err).Msg("actions: error executing query")
 			return nil, err
 		}
 
-		resId, _ := res.LastInsertId()
 		log.Debug().Msgf("actions: added new %v", resId)
 		action.ID = int(resId)
 	}
@@ -200,31 +294,50 @@ func (r *ActionRepo) StoreFilterActions(ctx context.Context, actions []domain.Ac
 		return nil, err
 	}
 
-	for _, action := range actions {
+	for i, action := range actions {
 		execCmd := toNullString(action.ExecCmd)
 		execArgs := toNullString(action.ExecArgs)
+		execWorkDir := toNullString(action.ExecWorkDir)
+		execEnv := toNullString(action.ExecEnv)
 		watchFolder := toNullString(action.WatchFolder)
+		watchFolderFileName := toNullString(action.WatchFolderFileName)
 		category := toNullString(action.Category)
 		tags := toNullString(action.Tags)
 		label := toNullString(action.Label)
 		savePath := toNullString(action.SavePath)
+		moveCompletedPath := toNullString(action.MoveCompletedPath)
+		webhookHost := toNullString(action.WebhookHost)
+		webhookType := toNullString(string(action.WebhookType))
+		webhookMethod := toNullString(action.WebhookMethod)
+		webhookData := toNullString(action.WebhookData)
+		runCondition := toNullString(string(action.RunCondition))
+		scheduleStart := toNullString(action.ScheduleStart)
+		scheduleEnd := toNullString(action.ScheduleEnd)
 
 		limitDL := toNullInt64(action.LimitDownloadSpeed)
 		limitUL := toNullInt64(action.LimitUploadSpeed)
 		clientID := toNullInt32(action.ClientID)
 
-		var err error
-		var res sql.Result
+		var webhookHeaders sql.NullString
+		if len(action.WebhookHeaders) > 0 {
+			h, err := json.Marshal(action.WebhookHeaders)
+			if err != nil {
+				return nil, err
+			}
 
-		res, err = tx.ExecContext(ctx, `INSERT INTO action(name, type, enabled, exec_cmd, exec_args, watch_folder, category, tags, label, save_path, paused, ignore_rules, limit_upload_speed, limit_download_speed, client_id, filter_id)
-			VALUES (?, ?, ?, ?, ?,? ,?, ?,?,?,?,?,?,?,?,?) ON CONFLICT DO NOTHING`, action.Name, action.Type, action.Enabled, execCmd, execArgs, watchFolder, category, tags, label, savePath, action.Paused, action.IgnoreRules, limitUL, limitDL, clientID, filterID)
-		if err != nil {
+			webhookHeaders = toNullString(string(h))
+		}
+
+		var resId int64
+
+		err = tx.QueryRowContext(ctx, `INSERT INTO action(name, type, enabled, exec_cmd, exec_args, exec_work_dir, exec_env, exec_timeout, watch_folder, watch_folder_file_name, category, tags, label, save_path, move_completed_path, paused, fast_resume, ignore_rules, limit_upload_speed, limit_download_speed, bandwidth_priority, webhook_host, webhook_type, webhook_method, webhook_data, webhook_headers, webhook_retry_attempts, webhook_retry_delay, run_condition, reannounce_skip, reannounce_delete, reannounce_interval, reannounce_max_attempts, sequential_download, skip_hash_check, cross_seed, delay, schedule_start, schedule_end, client_id, filter_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`, action.Name, action.Type, action.Enabled, execCmd, execArgs, execWorkDir, r.db.secret(execEnv), action.ExecTimeout, watchFolder, watchFolderFileName, category, tags, label, savePath, moveCompletedPath, action.Paused, action.FastResume, action.IgnoreRules, limitUL, limitDL, action.BandwidthPriority, webhookHost, webhookType, webhookMethod, webhookData, r.db.secret(webhookHeaders), action.WebhookRetryAttempts, action.WebhookRetryDelay, runCondition, action.ReannounceSkip, action.ReannounceDelete, action.ReannounceInterval, action.ReannounceMaxAttempts, action.SequentialDownload, action.SkipHashCheck, action.CrossSeed, action.Delay, scheduleStart, scheduleEnd, clientID, filterID).Scan(&resId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			log.Error().Stack().Err(err).Msg("actions: error executing query")
 			return nil, err
 		}
 
-		resId, _ := res.LastInsertId()
-		action.ID = int(resId)
+		actions[i].ID = int(resId)
 
 		log.Debug().Msgf("actions: store '%v' type: '%v' on filter: %v", action.Name, action.Type, filterID)
 	}
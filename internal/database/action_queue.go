@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+type ActionQueueRepo struct {
+	db *DB
+}
+
+func NewActionQueueRepo(db *DB) domain.ActionQueueRepo {
+	return &ActionQueueRepo{db: db}
+}
+
+func (r *ActionQueueRepo) Store(ctx context.Context, item *domain.ActionQueueItem) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Insert("action_queue").
+		Columns("release_id", "action_id", "last_ok", "run_at", "scheduled", "attempts", "reason").
+		Values(item.ReleaseID, item.ActionID, item.LastOK, item.RunAt, item.Scheduled, item.Attempts, item.Reason).
+		Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	err = r.db.handler.QueryRowContext(ctx, query, args...).Scan(&item.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("action_queue.Store: error executing query")
+		return err
+	}
+
+	log.Debug().Msgf("action_queue.Store: queued action %v for release %v", item.ActionID, item.ReleaseID)
+
+	return nil
+}
+
+func (r *ActionQueueRepo) Delete(ctx context.Context, id int64) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	_, err := r.db.handler.ExecContext(ctx, `DELETE FROM action_queue WHERE id = ?`, id)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("action_queue.Delete: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *ActionQueueRepo) Update(ctx context.Context, item *domain.ActionQueueItem) error {
+	query, args, err := sq.
+		Update("action_queue").
+		Set("action_id", item.ActionID).
+		Set("last_ok", item.LastOK).
+		Set("scheduled", item.Scheduled).
+		Set("run_at", item.RunAt).
+		Set("attempts", item.Attempts).
+		Set("reason", item.Reason).
+		Where("id = ?", item.ID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.handler.ExecContext(ctx, query, args...); err != nil {
+		log.Error().Stack().Err(err).Msg("action_queue.Update: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *ActionQueueRepo) List(ctx context.Context) ([]domain.ActionQueueItem, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.
+		Select(queueColumns...).
+		From("action_queue").
+		OrderBy("run_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("action_queue.List: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []domain.ActionQueueItem
+	for rows.Next() {
+		item, err := scanQueueItem(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("action_queue.List: error scanning data to struct")
+			return nil, err
+		}
+
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *ActionQueueRepo) FindByID(ctx context.Context, id int64) (*domain.ActionQueueItem, error) {
+	query, args, err := sq.
+		Select(queueColumns...).
+		From("action_queue").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := scanQueueItem(r.db.handler.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Error().Stack().Err(err).Msg("action_queue.FindByID: error executing query")
+		}
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ListPending the queued actions with their release, the next to run first
+func (r *ActionQueueRepo) ListPending(ctx context.Context) ([]domain.PendingAction, error) {
+	query, args, err := sq.
+		Select("q.id", "q.release_id", "r.torrent_name", "r.indexer", "r.filter_id", "r.filter", "q.action_id", "a.name", "a.type", "q.reason", "q.attempts", "q.run_at", "q.created_at").
+		From("action_queue q").
+		Join(`"release" r ON r.id = q.release_id`).
+		Join("action a ON a.id = q.action_id").
+		OrderBy("q.run_at ASC", "q.id ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("action_queue.ListPending: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	pending := []domain.PendingAction{}
+	for rows.Next() {
+		var p domain.PendingAction
+		var torrentName, indexer, filter sql.NullString
+		var filterID sql.NullInt32
+
+		if err := rows.Scan(&p.ID, &p.ReleaseID, &torrentName, &indexer, &filterID, &filter, &p.ActionID, &p.Action, &p.Type, &p.Reason, &p.Attempts, &p.RunAt, &p.CreatedAt); err != nil {
+			log.Error().Stack().Err(err).Msg("action_queue.ListPending: error scanning data to struct")
+			return nil, err
+		}
+
+		p.TorrentName = torrentName.String
+		p.Indexer = indexer.String
+		p.FilterID = int(filterID.Int32)
+		p.Filter = filter.String
+
+		pending = append(pending, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+var queueColumns = []string{"id", "release_id", "action_id", "last_ok", "run_at", "scheduled", "attempts", "reason", "created_at"}
+
+func scanQueueItem(row rowScanner) (*domain.ActionQueueItem, error) {
+	var item domain.ActionQueueItem
+
+	if err := row.Scan(&item.ID, &item.ReleaseID, &item.ActionID, &item.LastOK, &item.RunAt, &item.Scheduled, &item.Attempts, &item.Reason, &item.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
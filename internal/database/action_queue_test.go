@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestActionQueueRepo(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	filter, err := NewFilterRepo(db).Store(ctx, domain.Filter{Name: "Filter", Enabled: true, Resolutions: []string{}, Codecs: []string{}, Sources: []string{}, Containers: []string{}})
+	require.NoError(t, err)
+
+	action, err := NewActionRepo(db).Store(ctx, domain.Action{Name: "Action", Type: domain.ActionTypeTest, Enabled: true, FilterID: filter.ID})
+	require.NoError(t, err)
+
+	rls, err := NewReleaseRepo(db).Store(ctx, &domain.Release{TorrentName: "Release", Timestamp: time.Now(), Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+	require.NoError(t, err)
+
+	repo := NewActionQueueRepo(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	item := &domain.ActionQueueItem{ReleaseID: rls.ID, ActionID: action.ID, LastOK: true, RunAt: now, Reason: "delay of 1m0s"}
+	require.NoError(t, repo.Store(ctx, item))
+
+	// a failed action is retried later, with the outcome of the action before it
+	item.Attempts = 2
+	item.LastOK = false
+	item.Scheduled = true
+	item.RunAt = now.Add(time.Minute)
+	require.NoError(t, repo.Update(ctx, item))
+
+	items, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, 2, items[0].Attempts)
+	assert.False(t, items[0].LastOK)
+	assert.True(t, items[0].Scheduled)
+	assert.True(t, items[0].RunAt.Equal(now.Add(time.Minute)))
+
+	pending, err := repo.ListPending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "Release", pending[0].TorrentName)
+	assert.Equal(t, "Action", pending[0].Action)
+	assert.Equal(t, "delay of 1m0s", pending[0].Reason)
+
+	_, err = repo.FindByID(ctx, item.ID+1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestActionRepo_StoreFilterActions(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	filter, err := NewFilterRepo(db).Store(ctx, domain.Filter{Name: "movies", Codecs: []string{}, Sources: []string{}, Containers: []string{}, Resolutions: []string{}})
+	require.NoError(t, err)
+
+	repo := NewActionRepo(db)
+
+	actions, err := repo.StoreFilterActions(ctx, []domain.Action{
+		{Name: "qbit", Type: domain.ActionTypeQbittorrent, Enabled: true, SkipHashCheck: true, SequentialDownload: true, ReannounceDelete: true},
+		{Name: "hook", Type: domain.ActionTypeWebhook, Enabled: true, WebhookHost: "http://localhost/hook", WebhookHeaders: map[string]string{"X-Api-Key": "secret"}, RunCondition: domain.ActionRunConditionOnSuccess},
+	}, int64(filter.ID))
+	require.NoError(t, err)
+	require.NotZero(t, actions[0].ID)
+	require.NotZero(t, actions[1].ID)
+
+	found, err := repo.FindByFilterID(ctx, filter.ID)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+
+	assert.Equal(t, "qbit", found[0].Name)
+	assert.True(t, found[0].SkipHashCheck)
+	assert.False(t, found[0].FastResume)
+	assert.True(t, found[0].SequentialDownload)
+	assert.Equal(t, map[string]string{"X-Api-Key": "secret"}, found[1].WebhookHeaders)
+	assert.Equal(t, domain.ActionRunConditionOnSuccess, found[1].RunCondition)
+
+	found[0].SkipHashCheck = false
+	_, err = repo.Store(ctx, found[0])
+	require.NoError(t, err)
+
+	list, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.False(t, list[0].SkipHashCheck)
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+type AnnounceQueueRepo struct {
+	db *DB
+}
+
+func NewAnnounceQueueRepo(db *DB) domain.AnnounceQueueRepo {
+	return &AnnounceQueueRepo{db: db}
+}
+
+func (r *AnnounceQueueRepo) Store(ctx context.Context, item *domain.AnnounceQueueItem) error {
+	// irc lines can't hold a line break
+	queryBuilder := sq.
+		Insert("announce_queue").
+		Columns("indexer", "lines").
+		Values(item.Indexer, strings.Join(item.Lines, "\n")).
+		Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	err = r.db.handler.QueryRowContext(ctx, query, args...).Scan(&item.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("announce_queue.Store: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *AnnounceQueueRepo) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.handler.ExecContext(ctx, `DELETE FROM announce_queue WHERE id = ?`, id)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("announce_queue.Delete: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *AnnounceQueueRepo) List(ctx context.Context) ([]domain.AnnounceQueueItem, error) {
+	query, args, err := sq.
+		Select("id", "indexer", "lines", "created_at").
+		From("announce_queue").
+		OrderBy("id ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("announce_queue.List: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []domain.AnnounceQueueItem
+	for rows.Next() {
+		var item domain.AnnounceQueueItem
+		var lines string
+
+		if err := rows.Scan(&item.ID, &item.Indexer, &lines, &item.CreatedAt); err != nil {
+			log.Error().Stack().Err(err).Msg("announce_queue.List: error scanning data to struct")
+			return nil, err
+		}
+
+		item.Lines = strings.Split(lines, "\n")
+
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestAnnounceQueueRepo(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewAnnounceQueueRepo(db)
+
+	first := &domain.AnnounceQueueItem{Indexer: "mock", Lines: []string{"New: That.Movie.2021", "Url: https://mock.local/torrent/1"}}
+	require.NoError(t, repo.Store(ctx, first))
+
+	second := &domain.AnnounceQueueItem{Indexer: "other", Lines: []string{"That.Show.S01E01"}}
+	require.NoError(t, repo.Store(ctx, second))
+
+	items, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "mock", items[0].Indexer)
+	assert.Equal(t, first.Lines, items[0].Lines)
+	assert.False(t, items[0].CreatedAt.IsZero())
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+
+	items, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, second.ID, items[0].ID)
+}
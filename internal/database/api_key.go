@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type APIKeyRepo struct {
+	db *DB
+}
+
+func NewAPIKeyRepo(db *DB) domain.APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+var apiKeyColumns = []string{"id", "name", "username", "key_hash", "prefix", "created_at", "last_used_at"}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var createdAt, lastUsedAt sql.NullTime
+
+	if err := row.Scan(&k.ID, &k.Name, &k.Username, &k.KeyHash, &k.Prefix, &createdAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+
+	k.CreatedAt = createdAt.Time
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &k, nil
+}
+
+func (r *APIKeyRepo) Store(ctx context.Context, key *domain.APIKey) error {
+	query, args, err := sq.
+		Insert("api_key").
+		Columns("name", "username", "key_hash", "prefix", "created_at").
+		Values(key.Name, key.Username, key.KeyHash, key.Prefix, key.CreatedAt.UTC()).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.store: error building query")
+		return err
+	}
+
+	if err := r.db.handler.QueryRowContext(ctx, query, args...).Scan(&key.ID); err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.store: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// FindByKey the key with the hash, nil when there is none
+func (r *APIKeyRepo) FindByKey(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query, args, err := sq.Select(apiKeyColumns...).From("api_key").Where(sq.Eq{"key_hash": keyHash}).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.find: error building query")
+		return nil, err
+	}
+
+	key, err := scanAPIKey(r.db.handler.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Error().Stack().Err(err).Msg("api_key.find: error scanning row")
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// List keys of the user, every key when username is empty
+func (r *APIKeyRepo) List(ctx context.Context, username string) ([]domain.APIKey, error) {
+	queryBuilder := sq.Select(apiKeyColumns...).From("api_key").OrderBy("username ASC", "id ASC")
+	if username != "" {
+		queryBuilder = queryBuilder.Where(sq.Eq{"username": username})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.list: error building query")
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.list: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("api_key.list: error scanning row")
+			return nil, err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Touch(ctx context.Context, id int, lastUsed time.Time) error {
+	query := `UPDATE api_key SET last_used_at = ? WHERE id = ?`
+	if _, err := r.db.handler.ExecContext(ctx, query, lastUsed.UTC(), id); err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.touch: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// Delete the key, sql.ErrNoRows when there is no key with the id
+func (r *APIKeyRepo) Delete(ctx context.Context, id int) error {
+	res, err := r.db.handler.ExecContext(ctx, `DELETE FROM api_key WHERE id = ?`, id)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("api_key.delete: error executing query")
+		return err
+	}
+
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
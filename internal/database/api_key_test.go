@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestAPIKeyRepo(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewAPIKeyRepo(db)
+
+	key := &domain.APIKey{Name: "grafana", Username: "bob", KeyHash: "hash", Prefix: "abcdefgh", CreatedAt: time.Now()}
+	require.NoError(t, repo.Store(ctx, key))
+	require.NoError(t, repo.Store(ctx, &domain.APIKey{Name: "script", Username: "alice", KeyHash: "other", Prefix: "12345678", CreatedAt: time.Now()}))
+
+	found, err := repo.FindByKey(ctx, "hash")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, "bob", found.Username)
+	assert.Nil(t, found.LastUsedAt)
+
+	missing, err := repo.FindByKey(ctx, "nope")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	used := time.Date(2022, 4, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Touch(ctx, key.ID, used))
+
+	keys, err := repo.List(ctx, "bob")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt)
+	assert.True(t, used.Equal(*keys[0].LastUsedAt))
+
+	keys, err = repo.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	require.NoError(t, repo.Delete(ctx, key.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, key.ID), sql.ErrNoRows)
+}
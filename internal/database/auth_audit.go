@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type AuthAuditRepo struct {
+	db *DB
+}
+
+func NewAuthAuditRepo(db *DB) domain.AuthAuditRepo {
+	return &AuthAuditRepo{db: db}
+}
+
+func (r *AuthAuditRepo) Store(ctx context.Context, event *domain.AuthEvent) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Insert("auth_audit").
+		Columns("type", "username", "method", "ip", "user_agent", "message", "timestamp").
+		Values(event.Type, event.Username, event.Method, event.IP, event.UserAgent, event.Message, event.Timestamp.UTC()).
+		Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.store: error building query")
+		return err
+	}
+
+	if err := r.db.handler.QueryRowContext(ctx, query, args...).Scan(&event.ID); err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.store: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// Find audit events newest first, and the number of events matching the params
+func (r *AuthAuditRepo) Find(ctx context.Context, params domain.AuthEventQueryParams) ([]domain.AuthEvent, int64, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	where := sq.And{}
+	if params.Username != "" {
+		where = append(where, sq.Eq{"username": params.Username})
+	}
+	if params.IP != "" {
+		where = append(where, sq.Eq{"ip": params.IP})
+	}
+	if params.Type != "" {
+		where = append(where, sq.Eq{"type": params.Type})
+	}
+	if !params.From.IsZero() {
+		where = append(where, r.db.compareTime("timestamp", ">=", params.From))
+	}
+
+	countQuery, countArgs, err := sq.Select("COUNT(*)").From("auth_audit").Where(where).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.find: error building query")
+		return nil, 0, err
+	}
+
+	var count int64
+	if err := r.db.handler.QueryRowContext(ctx, countQuery, countArgs...).Scan(&count); err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.find: error executing query")
+		return nil, 0, err
+	}
+
+	queryBuilder := sq.
+		Select("id", "type", "username", "method", "ip", "user_agent", "message", "timestamp").
+		From("auth_audit").
+		Where(where).
+		OrderBy("id DESC")
+
+	// sqlite only takes an offset together with a limit
+	if params.Limit > 0 {
+		queryBuilder = queryBuilder.Limit(params.Limit).Offset(params.Offset)
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.find: error building query")
+		return nil, 0, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("authAudit.find: error executing query")
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	events := make([]domain.AuthEvent, 0)
+	for rows.Next() {
+		var e domain.AuthEvent
+		var username, method, ip, userAgent, message sql.NullString
+
+		if err := rows.Scan(&e.ID, &e.Type, &username, &method, &ip, &userAgent, &message, &e.Timestamp); err != nil {
+			log.Error().Stack().Err(err).Msg("authAudit.find: error scanning row")
+			return nil, 0, err
+		}
+
+		e.Username = username.String
+		e.Method = method.String
+		e.IP = ip.String
+		e.UserAgent = userAgent.String
+		e.Message = message.String
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return events, count, nil
+}
@@ -0,0 +1,413 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// restoreSuffix a database waiting next to autobrr.db to replace it on the next start
+const restoreSuffix = ".restore"
+
+// SchemaVersion migration the database is at
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := db.handler.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Backup write a consistent copy of the database to path while autobrr keeps running.
+// With redact the copy has no passwords, api keys, tracker credentials or sessions.
+// Postgres is copied into a sqlite file, so every backup restores the same way.
+func (db *DB) Backup(ctx context.Context, path string, redact bool) error {
+	if db.postgres() {
+		if err := db.copyToSqlite(ctx, path); err != nil {
+			return fmt.Errorf("could not copy database: %w", err)
+		}
+	} else if _, err := db.handler.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("could not copy database: %w", err)
+	}
+
+	if !redact {
+		return nil
+	}
+
+	backup, err := sql.Open(driverSqlite, path)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	if err := redactSecrets(ctx, backup, db.secrets); err != nil {
+		return fmt.Errorf("could not redact database: %w", err)
+	}
+
+	// the blanked values are still in the free pages until the file is rebuilt
+	if _, err := backup.ExecContext(ctx, "VACUUM"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *DB) copyToSqlite(ctx context.Context, path string) error {
+	backup := NewSqliteFileDB(path)
+	if err := backup.Open(); err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	return Copy(ctx, db, backup)
+}
+
+// redactSecrets blank the secret columns, settings that also hold other values are decrypted and stored
+// without the secrets. The data key goes too, nothing is encrypted with it anymore.
+func redactSecrets(ctx context.Context, db *sql.DB, box *secretBox) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`UPDATE users SET totp_secret = '', totp_enabled = false, recovery_codes = '{}'`,
+		`DELETE FROM sessions`,
+		`DELETE FROM api_key`,
+		`UPDATE client SET password = ''`,
+		`UPDATE irc_network SET pass = '', nickserv_password = '', invite_command = ''`,
+		`UPDATE irc_channel SET password = ''`,
+		`UPDATE indexer SET proxy = NULL`,
+		`UPDATE feed SET api_key = '', cookie = '', headers = ''`,
+		`UPDATE action SET exec_env = '', webhook_headers = ''`,
+		`UPDATE "release" SET torrent_url = ''`,
+		`DELETE FROM encryption_key`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if err := redactClientSettings(ctx, tx, box); err != nil {
+		return err
+	}
+
+	if err := redactIndexerSettings(ctx, tx, box); err != nil {
+		return err
+	}
+
+	if err := redactNotificationSettings(ctx, tx, box); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// redactClientSettings blank the api key and basic auth password, the rules stay
+func redactClientSettings(ctx context.Context, tx *sql.Tx, box *secretBox) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, settings FROM client WHERE settings IS NOT NULL AND settings != ''`)
+	if err != nil {
+		return err
+	}
+
+	updated := map[int]string{}
+	for rows.Next() {
+		var id int
+		var raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+
+		raw, err := box.decrypt(raw)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		var settings domain.DownloadClientSettings
+		if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+			settings = domain.DownloadClientSettings{}
+		}
+
+		settings.APIKey = ""
+		settings.Basic.Password = ""
+
+		b, err := json.Marshal(settings)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		updated[id] = string(b)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, settings := range updated {
+		if _, err := tx.ExecContext(ctx, `UPDATE client SET settings = ? WHERE id = ?`, settings, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// redactNotificationSettings blank the tokens, keys and passwords of the agents, servers and priorities stay
+func redactNotificationSettings(ctx context.Context, tx *sql.Tx, box *secretBox) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, settings FROM notification WHERE settings IS NOT NULL AND settings != ''`)
+	if err != nil {
+		return err
+	}
+
+	updated := map[int]string{}
+	for rows.Next() {
+		var id int
+		var raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+
+		raw, err := box.decrypt(raw)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		var settings domain.NotificationSettings
+		if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+			settings = domain.NotificationSettings{}
+		}
+
+		settings.Token = ""
+		settings.UserKey = ""
+		settings.Webhook = ""
+		settings.Password = ""
+		settings.Secret = ""
+
+		// header values are usually auth tokens, the names show what to fill in
+		for name := range settings.Headers {
+			settings.Headers[name] = ""
+		}
+
+		b, err := json.Marshal(settings)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		updated[id] = string(b)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, settings := range updated {
+		if _, err := tx.ExecContext(ctx, `UPDATE notification SET settings = ? WHERE id = ?`, settings, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// redactIndexerSettings blank every value of the indexer and account settings, they are all
+// credentials like passkeys and api keys. The keys stay so the indexer shows what to fill in.
+func redactIndexerSettings(ctx context.Context, tx *sql.Tx, box *secretBox) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, settings, accounts FROM indexer`)
+	if err != nil {
+		return err
+	}
+
+	type redacted struct {
+		settings string
+		accounts string
+	}
+
+	updated := map[int]redacted{}
+	for rows.Next() {
+		var id int
+		var settings, accounts sql.NullString
+		if err := rows.Scan(&id, &settings, &accounts); err != nil {
+			rows.Close()
+			return err
+		}
+
+		settings.String, err = box.decrypt(settings.String)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		accounts.String, err = box.decrypt(accounts.String)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		var settingsMap map[string]string
+		_ = json.Unmarshal([]byte(settings.String), &settingsMap)
+		blankValues(settingsMap)
+
+		var accountList []domain.IndexerAccount
+		_ = json.Unmarshal([]byte(accounts.String), &accountList)
+		for _, account := range accountList {
+			blankValues(account.Settings)
+		}
+
+		s, err := json.Marshal(settingsMap)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		a, err := json.Marshal(accountList)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		updated[id] = redacted{settings: string(s), accounts: string(a)}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, r := range updated {
+		if _, err := tx.ExecContext(ctx, `UPDATE indexer SET settings = ?, accounts = ? WHERE id = ?`, r.settings, r.accounts, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func blankValues(m map[string]string) {
+	for k := range m {
+		m[k] = ""
+	}
+}
+
+// StageRestore check the database at path and put it next to autobrr.db, it replaces it on the next start.
+// A database from a newer autobrr is rejected, older ones are migrated when they are opened.
+func (db *DB) StageRestore(ctx context.Context, path string) (int, error) {
+	if db.postgres() {
+		return 0, errors.New("backups can't be restored into postgres while autobrr runs, import the database from the backup with autobrrctl import-sqlite")
+	}
+
+	restore, err := sql.Open(driverSqlite, path)
+	if err != nil {
+		return 0, err
+	}
+	defer restore.Close()
+
+	var result string
+	if err := restore.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return 0, fmt.Errorf("not an autobrr database: %w", err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("database is damaged: %v", result)
+	}
+
+	var version int
+	if err := restore.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case version == 0:
+		return 0, errors.New("not an autobrr database")
+	case version > latestVersion():
+		return 0, fmt.Errorf("database is from a newer autobrr (schema %d, this version knows %d)", version, latestVersion())
+	}
+
+	if err := restore.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := copyFile(path, db.DSN+restoreSuffix); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// applyRestore replace the database with a staged restore, before it is opened
+func (db *DB) applyRestore() error {
+	staged := db.DSN + restoreSuffix
+	if _, err := os.Stat(staged); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	// the wal and shared memory files belong to the old database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(db.DSN + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.Rename(staged, db.DSN); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("database restored from backup: %v", db.DSN)
+
+	return nil
+}
+
+// copyFile write to a temporary file and rename, the destination is never half written
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// DiscardRestore remove a staged restore that has not been applied yet
+func (db *DB) DiscardRestore() error {
+	if err := os.Remove(db.DSN + restoreSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
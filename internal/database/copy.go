@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+var createTablePattern = regexp.MustCompile(`(?i)CREATE TABLE\s+(?:IF NOT EXISTS\s+)?"?(\w+)"?`)
+
+// copyTables every table of the schema in the order they are created, referenced tables come first
+func copyTables() []string {
+	var tables []string
+	for _, match := range createTablePattern.FindAllStringSubmatch(schema, -1) {
+		tables = append(tables, match[1])
+	}
+
+	return tables
+}
+
+// Copy every row from src to the empty dst, like a sqlite database into postgres.
+// Both have to be open and at the same schema version.
+func Copy(ctx context.Context, src *DB, dst *DB) error {
+	srcVersion, err := src.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	dstVersion, err := dst.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if srcVersion != dstVersion {
+		return fmt.Errorf("schema versions differ: source %d, destination %d", srcVersion, dstVersion)
+	}
+
+	tables := copyTables()
+
+	for _, table := range tables {
+		var count int
+		if err := dst.handler.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM "%v"`, table)).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("destination is not empty, table %v has %d rows", table, count)
+		}
+	}
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		rows, err := copyTable(ctx, src, tx, table)
+		if err != nil {
+			return fmt.Errorf("could not copy %v: %w", table, err)
+		}
+
+		log.Debug().Msgf("database copy: %v rows from %v", rows, table)
+	}
+
+	if dst.postgres() {
+		if err := resetSequences(ctx, tx, tables); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func copyTable(ctx context.Context, src *DB, tx *Tx, table string) (int, error) {
+	// columns both sides have, a column only one side has can't be copied anyway
+	dstColumns, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := src.handler.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM "%v"`, table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	srcColumns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	var columns []string
+	var indexes []int
+	for i, column := range srcColumns {
+		if dstColumns[column] {
+			columns = append(columns, column)
+			indexes = append(indexes, i)
+		}
+	}
+
+	values := make([]interface{}, len(srcColumns))
+	pointers := make([]interface{}, len(srcColumns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+
+		row := make([]interface{}, len(indexes))
+		for i, index := range indexes {
+			// text comes back as bytes, postgres would store those as bytea escapes
+			if b, ok := values[index].([]byte); ok {
+				row[i] = string(b)
+			} else {
+				row[i] = values[index]
+			}
+		}
+
+		query, args, err := sq.Insert(fmt.Sprintf(`"%v"`, table)).Columns(columns...).Values(row...).ToSql()
+		if err != nil {
+			return count, err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+func tableColumns(ctx context.Context, tx *Tx, table string) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM "%v" WHERE 1 = 0`, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]bool{}
+	for _, name := range names {
+		columns[name] = true
+	}
+
+	return columns, nil
+}
+
+// resetSequences continue the postgres id sequences after the copied ids
+func resetSequences(ctx context.Context, tx *Tx, tables []string) error {
+	for _, table := range tables {
+		columns, err := tableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		if !columns["id"] {
+			continue
+		}
+
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('"%[1]v"', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM "%[1]v"`, table)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("could not reset id sequence of %v: %w", table, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestCopyTables(t *testing.T) {
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	rows, err := db.handler.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_version'`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		tables = append(tables, name)
+	}
+
+	assert.ElementsMatch(t, tables, copyTables())
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	src := NewSqliteDB(dir)
+	require.NoError(t, src.Open())
+	defer src.Close()
+
+	require.NoError(t, NewUserRepo(src).Store(ctx, domain.User{Username: "admin", Password: "hash"}))
+
+	indexer, err := NewIndexerRepo(src).Store(domain.Indexer{Name: "Indexer", Identifier: "indexer", Enabled: true, Settings: map[string]string{"passkey": "secret"}})
+	require.NoError(t, err)
+
+	filter, err := NewFilterRepo(src).Store(ctx, domain.Filter{Name: "Filter", Enabled: true, Resolutions: []string{"1080p"}, Codecs: []string{}, Sources: []string{}, Containers: []string{}})
+	require.NoError(t, err)
+	require.NoError(t, NewFilterRepo(src).StoreIndexerConnection(ctx, filter.ID, int(indexer.ID)))
+
+	dst := NewSqliteFileDB(filepath.Join(dir, "copy.db"))
+	require.NoError(t, dst.Open())
+	defer dst.Close()
+
+	require.NoError(t, Copy(ctx, src, dst))
+
+	user, err := NewUserRepo(dst).FindByUsername(ctx, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", user.Password)
+
+	copied, err := NewFilterRepo(dst).FindByID(ctx, filter.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1080p"}, copied.Resolutions)
+
+	var connections int
+	require.NoError(t, dst.handler.QueryRow(`SELECT COUNT(*) FROM filter_indexer`).Scan(&connections))
+	assert.Equal(t, 1, connections)
+
+	// a second copy would duplicate everything
+	assert.Error(t, Copy(ctx, src, dst))
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// drivers registered with database/sql
+const (
+	driverSqlite   = "sqlite3"
+	driverPostgres = "postgres"
+)
+
+type DB struct {
+	lock    sync.RWMutex
+	handler handler
+	ctx     context.Context
+	cancel  func()
+
+	Driver   string
+	DSN      string
+	settings domain.DatabaseSettings
+	secrets  *secretBox
+}
+
+// NewDB sqlite in autobrr.db next to the config, or postgres when the settings say so
+func NewDB(configPath string, settings domain.DatabaseSettings) *DB {
+	if settings.Type == domain.DatabaseTypePostgres {
+		return newDB(driverPostgres, settings.DSN, settings)
+	}
+
+	return newDB(driverSqlite, dataSourceName(configPath, "autobrr.db"), settings)
+}
+
+// NewSqliteDB sqlite in autobrr.db in the folder
+func NewSqliteDB(source string) *DB {
+	return NewDB(source, domain.DatabaseSettings{Type: domain.DatabaseTypeSqlite})
+}
+
+// NewSqliteFileDB sqlite in the file, for copies and exports
+func NewSqliteFileDB(path string) *DB {
+	return newDB(driverSqlite, path, domain.DatabaseSettings{Type: domain.DatabaseTypeSqlite})
+}
+
+func newDB(driver string, dsn string, settings domain.DatabaseSettings) *DB {
+	db := &DB{
+		Driver:   driver,
+		DSN:      dsn,
+		settings: settings,
+	}
+
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+
+	return db
+}
+
+func (db *DB) Open() error {
+	if err := db.Connect(); err != nil {
+		return err
+	}
+
+	// migrate db
+	if err := db.migrate(); err != nil {
+		return fmt.Errorf("could not migrate db: %w", err)
+	}
+
+	return nil
+}
+
+// Connect open the database without migrating it, for tools that manage the schema themselves
+func (db *DB) Connect() error {
+	if db.DSN == "" {
+		return fmt.Errorf("DSN required")
+	}
+
+	switch db.Driver {
+	case driverPostgres:
+		return db.openPostgres()
+	default:
+		return db.openSqlite()
+	}
+}
+
+func (db *DB) Close() error {
+	// cancel background context
+	db.cancel()
+
+	// close database
+	if db.handler.DB != nil {
+		return db.handler.Close()
+	}
+	return nil
+}
+
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.handler.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{
+		handlerTx: tx,
+		handler:   db,
+	}, nil
+}
+
+type Tx struct {
+	*handlerTx
+	handler *DB
+}
+
+// Ping run a query to check the database answers
+func (db *DB) Ping(ctx context.Context) error {
+	var one int
+	return db.handler.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+func (db *DB) postgres() bool {
+	return db.Driver == driverPostgres
+}
+
+// compareTime column against t. Sqlite stores timestamps as text with their zone offset,
+// so both sides are compared as utc datetimes there.
+func (db *DB) compareTime(column string, op string, t time.Time) sq.Sqlizer {
+	if db.postgres() {
+		return sq.Expr(fmt.Sprintf("%v %v ?", column, op), t.UTC())
+	}
+
+	return sq.Expr(fmt.Sprintf("datetime(%v) %v datetime(?)", column, op), t.UTC().Format(sqliteTimeFormat))
+}
+
+// utcDate the day of the timestamp column in utc as YYYY-MM-DD
+func (db *DB) utcDate(column string) string {
+	if db.postgres() {
+		return fmt.Sprintf("to_char(%v AT TIME ZONE 'UTC', 'YYYY-MM-DD')", column)
+	}
+
+	return fmt.Sprintf("date(%v)", column)
+}
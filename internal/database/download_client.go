@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 
 	"github.com/autobrr/autobrr/internal/domain"
 
@@ -11,7 +12,7 @@ import (
 )
 
 type DownloadClientRepo struct {
-	db    *SqliteDB
+	db    *DB
 	cache *clientCache
 }
 
@@ -41,7 +42,7 @@ func (c *clientCache) Pop(id int) {
 	delete(c.clients, id)
 }
 
-func NewDownloadClientRepo(db *SqliteDB) domain.DownloadClientRepo {
+func NewDownloadClientRepo(db *DB) domain.DownloadClientRepo {
 	return &DownloadClientRepo{
 		db:    db,
 		cache: NewClientCache(),
@@ -52,7 +53,7 @@ func (r *DownloadClientRepo) List() ([]domain.DownloadClient, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.Query("SELECT id, name, type, enabled, host, port, ssl, username, password, settings FROM client")
+	rows, err := r.db.handler.Query("SELECT id, name, type, enabled, host, port, ssl, tls_skip_verify, username, password, settings FROM client")
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("could not query download client rows")
 		return nil, err
@@ -66,7 +67,7 @@ func (r *DownloadClientRepo) List() ([]domain.DownloadClient, error) {
 		var f domain.DownloadClient
 		var settingsJsonStr string
 
-		if err := rows.Scan(&f.ID, &f.Name, &f.Type, &f.Enabled, &f.Host, &f.Port, &f.SSL, &f.Username, &f.Password, &settingsJsonStr); err != nil {
+		if err := rows.Scan(&f.ID, &f.Name, &f.Type, &f.Enabled, &f.Host, &f.Port, &f.SSL, &f.TLSSkipVerify, &f.Username, r.db.scanSecret(&f.Password), r.db.scanSecret(&settingsJsonStr)); err != nil {
 			log.Error().Stack().Err(err).Msg("could not scan download client to struct")
 			return nil, err
 		}
@@ -99,7 +100,7 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 	}
 
 	query := `
-		SELECT id, name, type, enabled, host, port, ssl, username, password, settings FROM client WHERE id = ?
+		SELECT id, name, type, enabled, host, port, ssl, tls_skip_verify, username, password, settings FROM client WHERE id = ?
 	`
 
 	row := r.db.handler.QueryRowContext(ctx, query, id)
@@ -111,7 +112,7 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 	var client domain.DownloadClient
 	var settingsJsonStr string
 
-	if err := row.Scan(&client.ID, &client.Name, &client.Type, &client.Enabled, &client.Host, &client.Port, &client.SSL, &client.Username, &client.Password, &settingsJsonStr); err != nil {
+	if err := row.Scan(&client.ID, &client.Name, &client.Type, &client.Enabled, &client.Host, &client.Port, &client.SSL, &client.TLSSkipVerify, &client.Username, r.db.scanSecret(&client.Password), r.db.scanSecret(&settingsJsonStr)); err != nil {
 		log.Error().Stack().Err(err).Msg("could not scan download client to struct")
 		return nil, err
 	}
@@ -136,6 +137,9 @@ func (r *DownloadClientRepo) Store(client domain.DownloadClient) (*domain.Downlo
 		APIKey: client.Settings.APIKey,
 		Basic:  client.Settings.Basic,
 		Rules:  client.Settings.Rules,
+
+		IndexerMap:       client.Settings.IndexerMap,
+		FailoverClientID: client.Settings.FailoverClientID,
 	}
 
 	settingsJson, err := json.Marshal(&settings)
@@ -155,6 +159,7 @@ func (r *DownloadClientRepo) Store(client domain.DownloadClient) (*domain.Downlo
 			    host = ?, 
 			    port = ?, 
 			    ssl = ?, 
+			    tls_skip_verify = ?, 
 			    username = ?, 
 			    password = ?, 
 			    settings = (?) 
@@ -166,9 +171,10 @@ func (r *DownloadClientRepo) Store(client domain.DownloadClient) (*domain.Downlo
 			client.Host,
 			client.Port,
 			client.SSL,
+			client.TLSSkipVerify,
 			client.Username,
-			client.Password,
-			string(settingsJson),
+			r.db.secret(client.Password),
+			r.db.secret(settingsJson),
 			client.ID,
 		)
 		if err != nil {
@@ -176,9 +182,7 @@ func (r *DownloadClientRepo) Store(client domain.DownloadClient) (*domain.Downlo
 			return nil, err
 		}
 	} else {
-		var res sql.Result
-
-		res, err = r.db.handler.Exec(`INSERT INTO 
+		err = r.db.handler.QueryRow(`INSERT INTO 
     		client(
     		       name,
     		       type, 
@@ -186,28 +190,27 @@ func (r *DownloadClientRepo) Store(client domain.DownloadClient) (*domain.Downlo
     		       host,
     		       port,
     		       ssl,
+    		       tls_skip_verify,
     		       username,
     		       password,
     		       settings)
-			VALUES (?, ?, ?, ?, ?, ? , ?, ?, ?) ON CONFLICT DO NOTHING`,
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`,
 			client.Name,
 			client.Type,
 			client.Enabled,
 			client.Host,
 			client.Port,
 			client.SSL,
+			client.TLSSkipVerify,
 			client.Username,
-			client.Password,
-			string(settingsJson),
-		)
-		if err != nil {
+			r.db.secret(client.Password),
+			r.db.secret(settingsJson),
+		).Scan(&client.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			log.Error().Stack().Err(err).Msgf("could not store new download client: %v", client)
 			return nil, err
 		}
 
-		resId, _ := res.LastInsertId()
-		client.ID = int(resId)
-
 		log.Trace().Msgf("download_client: store new record %d", client.ID)
 	}
 
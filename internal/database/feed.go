@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+type FeedRepo struct {
+	db *DB
+}
+
+func NewFeedRepo(db *DB) domain.FeedRepo {
+	return &FeedRepo{
+		db: db,
+	}
+}
+
+func (r *FeedRepo) FindByID(ctx context.Context, id int) (*domain.Feed, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := r.baseQuery().Where("f.id = ?", id)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.FindByID: error building query")
+		return nil, err
+	}
+
+	row := r.db.handler.QueryRowContext(ctx, query, args...)
+
+	f, err := r.scanFeed(row)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.FindByID: error scanning row")
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (r *FeedRepo) FindByIndexerIdentifier(ctx context.Context, indexer string) (*domain.Feed, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := r.baseQuery().Where("f.indexer = ?", indexer)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.FindByIndexerIdentifier: error building query")
+		return nil, err
+	}
+
+	row := r.db.handler.QueryRowContext(ctx, query, args...)
+
+	f, err := r.scanFeed(row)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.FindByIndexerIdentifier: error scanning row")
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (r *FeedRepo) Find(ctx context.Context) ([]domain.Feed, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := r.baseQuery().OrderBy("f.name ASC").ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Find: error building query")
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Find: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	feeds := make([]domain.Feed, 0)
+	for rows.Next() {
+		f, err := r.scanFeed(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("feed.Find: error scanning row")
+			return nil, err
+		}
+
+		feeds = append(feeds, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feeds, nil
+}
+
+func (r *FeedRepo) Store(ctx context.Context, feed *domain.Feed) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	settings, headers, parseRules, err := marshalFeedJSON(feed)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Store: error marshaling json data")
+		return err
+	}
+
+	queryBuilder := sq.
+		Insert("feed").
+		Columns("name", "indexer", "type", "enabled", "url", "interval", "api_key", "cookie", "headers", "parse_rules", "settings", "indexer_id").
+		Values(feed.Name, feed.Indexer, feed.Type, feed.Enabled, feed.URL, feed.Interval, r.db.secret(feed.ApiKey), r.db.secret(feed.Cookie), r.db.secret(headers), parseRules, settings, toNullInt32(int32(feed.IndexerID))).
+		Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Store: error building query")
+		return err
+	}
+
+	err = r.db.handler.QueryRowContext(ctx, query, args...).Scan(&feed.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Store: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *FeedRepo) Update(ctx context.Context, feed *domain.Feed) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	settings, headers, parseRules, err := marshalFeedJSON(feed)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Update: error marshaling json data")
+		return err
+	}
+
+	queryBuilder := sq.
+		Update("feed").
+		Set("name", feed.Name).
+		Set("indexer", feed.Indexer).
+		Set("type", feed.Type).
+		Set("enabled", feed.Enabled).
+		Set("url", feed.URL).
+		Set("interval", feed.Interval).
+		Set("api_key", r.db.secret(feed.ApiKey)).
+		Set("cookie", r.db.secret(feed.Cookie)).
+		Set("headers", r.db.secret(headers)).
+		Set("parse_rules", parseRules).
+		Set("settings", settings).
+		Set("indexer_id", toNullInt32(int32(feed.IndexerID))).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("id = ?", feed.ID)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Update: error building query")
+		return err
+	}
+
+	_, err = r.db.handler.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Update: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *FeedRepo) ToggleEnabled(ctx context.Context, id int, enabled bool) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Update("feed").
+		Set("enabled", enabled).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("id = ?", id)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.ToggleEnabled: error building query")
+		return err
+	}
+
+	_, err = r.db.handler.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.ToggleEnabled: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *FeedRepo) Delete(ctx context.Context, id int) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.Delete("feed").Where("id = ?", id).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Delete: error building query")
+		return err
+	}
+
+	_, err = r.db.handler.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed.Delete: error executing query")
+		return err
+	}
+
+	log.Debug().Msgf("feed.Delete: id %v", id)
+
+	return nil
+}
+
+func (r *FeedRepo) baseQuery() sq.SelectBuilder {
+	return sq.
+		Select("f.id", "f.indexer", "f.name", "f.type", "f.enabled", "f.url", "f.interval", "f.api_key", "f.cookie", "f.headers", "f.parse_rules", "f.settings", "f.indexer_id", "f.created_at", "f.updated_at").
+		From("feed f")
+}
+
+type feedScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *FeedRepo) scanFeed(row feedScanner) (*domain.Feed, error) {
+	var f domain.Feed
+
+	var indexer, apiKey, cookie, headers, parseRules, settings sql.NullString
+	var indexerID sql.NullInt32
+
+	if err := row.Scan(&f.ID, &indexer, &f.Name, &f.Type, &f.Enabled, &f.URL, &f.Interval, r.db.scanSecret(&apiKey), r.db.scanSecret(&cookie), r.db.scanSecret(&headers), &parseRules, &settings, &indexerID, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	f.Indexer = indexer.String
+	f.ApiKey = apiKey.String
+	f.Cookie = cookie.String
+	f.IndexerID = int(indexerID.Int32)
+
+	if settings.String != "" {
+		if err := json.Unmarshal([]byte(settings.String), &f.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	if headers.String != "" {
+		if err := json.Unmarshal([]byte(headers.String), &f.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	if parseRules.String != "" {
+		if err := json.Unmarshal([]byte(parseRules.String), &f.ParseRules); err != nil {
+			return nil, err
+		}
+	}
+
+	return &f, nil
+}
+
+func marshalFeedJSON(feed *domain.Feed) (settings string, headers string, parseRules string, err error) {
+	s, err := json.Marshal(feed.Settings)
+	if err != nil {
+		return
+	}
+
+	h, err := json.Marshal(feed.Headers)
+	if err != nil {
+		return
+	}
+
+	p, err := json.Marshal(feed.ParseRules)
+	if err != nil {
+		return
+	}
+
+	return string(s), string(h), string(p), nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+type FeedCacheRepo struct {
+	db *DB
+}
+
+func NewFeedCacheRepo(db *DB) domain.FeedCacheRepo {
+	return &FeedCacheRepo{
+		db: db,
+	}
+}
+
+func (r *FeedCacheRepo) Get(bucket string, key string) ([]byte, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	row := r.db.handler.QueryRow("SELECT value FROM feed_cache WHERE bucket = ? AND key = ? AND ttl > ?", bucket, key, time.Now())
+	if err := row.Err(); err != nil {
+		log.Error().Stack().Err(err).Msg("feed_cache.Get: query error")
+		return nil, err
+	}
+
+	var value []byte
+
+	if err := row.Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Error().Stack().Err(err).Msg("feed_cache.Get: scan error")
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (r *FeedCacheRepo) Exists(bucket string, key string) (bool, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	var exists bool
+	err := r.db.handler.QueryRow("SELECT EXISTS (SELECT 1 FROM feed_cache WHERE bucket = ? AND key = ? AND ttl > ?)", bucket, key, time.Now()).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		log.Error().Stack().Err(err).Msg("feed_cache.Exists: query error")
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *FeedCacheRepo) Put(bucket string, key string, val []byte, ttl time.Time) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	_, err := r.db.handler.Exec("INSERT INTO feed_cache (bucket, key, value, ttl) VALUES (?, ?, ?, ?)", bucket, key, val, ttl)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed_cache.Put: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *FeedCacheRepo) Delete(bucket string, key string) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	_, err := r.db.handler.Exec("DELETE FROM feed_cache WHERE bucket = ? AND key = ?", bucket, key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed_cache.Delete: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the items past their ttl and returns how many were removed
+func (r *FeedCacheRepo) DeleteExpired() (int64, error) {
+	res, err := r.db.handler.Exec("DELETE FROM feed_cache WHERE ttl <= ?", time.Now())
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed_cache.DeleteExpired: error executing query")
+		return 0, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("feed_cache.DeleteExpired: error getting affected rows")
+		return 0, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestFeedRepo_indexer(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewFeedRepo(db)
+
+	// no indexer row behind the feed
+	feed := &domain.Feed{Name: "torznab", Indexer: "torznab-feed", Type: "TORZNAB", Enabled: true, URL: "http://localhost/api", Interval: 15}
+	require.NoError(t, repo.Store(ctx, feed))
+
+	found, err := repo.FindByID(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "torznab-feed", found.Indexer)
+
+	found, err = repo.FindByIndexerIdentifier(ctx, "torznab-feed")
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, found.ID)
+}
+
+func TestFeedCacheRepo_expired(t *testing.T) {
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewFeedCacheRepo(db)
+
+	require.NoError(t, repo.Put("feed", "old", []byte("old"), time.Now().Add(-time.Hour)))
+	require.NoError(t, repo.Put("feed", "new", []byte("new"), time.Now().Add(time.Hour)))
+
+	exists, err := repo.Exists("feed", "old")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = repo.Exists("feed", "new")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	removed, err := repo.DeleteExpired()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	value, err := repo.Get("feed", "new")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), value)
+}
@@ -3,7 +3,11 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
@@ -12,10 +16,10 @@ import (
 )
 
 type FilterRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewFilterRepo(db *SqliteDB) domain.FilterRepo {
+func NewFilterRepo(db *DB) domain.FilterRepo {
 	return &FilterRepo{db: db}
 }
 
@@ -23,7 +27,12 @@ func (r *FilterRepo) ListFilters(ctx context.Context) ([]domain.Filter, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.QueryContext(ctx, "SELECT id, enabled, name, match_releases, except_releases, created_at, updated_at FROM filter ORDER BY name ASC")
+	rows, err := r.db.handler.QueryContext(ctx, `
+		SELECT f.id, f.enabled, f.name, f.priority, f.match_releases, f.except_releases, f.created_at, f.updated_at,
+			s.evaluated, s.matched, s.actions_succeeded, s.actions_failed, s.last_match_at
+		FROM filter f
+		LEFT JOIN filter_stats s ON s.filter_id = f.id
+		ORDER BY f.priority DESC, f.name ASC`)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("filters_list: error query data")
 		return nil, err
@@ -36,8 +45,11 @@ func (r *FilterRepo) ListFilters(ctx context.Context) ([]domain.Filter, error) {
 		var f domain.Filter
 
 		var matchReleases, exceptReleases sql.NullString
+		var evaluated, matched, actionsSucceeded, actionsFailed sql.NullInt64
+		var lastMatchAt sql.NullTime
 
-		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &matchReleases, &exceptReleases, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &f.Priority, &matchReleases, &exceptReleases, &f.CreatedAt, &f.UpdatedAt,
+			&evaluated, &matched, &actionsSucceeded, &actionsFailed, &lastMatchAt); err != nil {
 			log.Error().Stack().Err(err).Msg("filters_list: error scanning data to struct")
 			return nil, err
 		}
@@ -45,6 +57,17 @@ func (r *FilterRepo) ListFilters(ctx context.Context) ([]domain.Filter, error) {
 		f.MatchReleases = matchReleases.String
 		f.ExceptReleases = exceptReleases.String
 
+		// filters that were never checked have no stats row yet
+		f.Stats = &domain.FilterStats{
+			Evaluated:        evaluated.Int64,
+			Matched:          matched.Int64,
+			ActionsSucceeded: actionsSucceeded.Int64,
+			ActionsFailed:    actionsFailed.Int64,
+		}
+		if lastMatchAt.Valid {
+			f.Stats.LastMatchAt = &lastMatchAt.Time
+		}
+
 		filters = append(filters, f)
 	}
 	if err := rows.Err(); err != nil {
@@ -58,17 +81,35 @@ func (r *FilterRepo) FindByID(ctx context.Context, filterID int) (*domain.Filter
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	row := r.db.handler.QueryRowContext(ctx, "SELECT id, enabled, name, min_size, max_size, delay, priority, match_releases, except_releases, use_regex, match_release_groups, except_release_groups, scene, freeleech, freeleech_percent, shows, seasons, episodes, resolutions, codecs, sources, containers, match_hdr, except_hdr, years, artists, albums, release_types_match, formats, quality, media,  log_score, has_log, has_cue, perfect_flac, match_categories, except_categories, match_uploaders, except_uploaders, tags, except_tags, created_at, updated_at FROM filter WHERE id = ?", filterID)
+	row := r.db.handler.QueryRowContext(ctx, "SELECT id, enabled, name, min_size, max_size, delay, priority, match_releases, except_releases, use_regex, match_release_groups, except_release_groups, scene, freeleech, freeleech_percent, shows, seasons, episodes, resolutions, codecs, sources, containers, match_hdr, except_hdr, years, artists, albums, release_types_match, formats, quality, media, log_score, has_log, has_cue, perfect_flac, match_categories, except_categories, match_uploaders, except_uploaders, tags, except_tags, indexer_accounts, verify_size, use_freeleech_token, freeleech_token_min_size, quota_grabs_per_hour, quota_grabs_per_day, quota_bytes_per_day, skip_duplicates, duplicate_window, duplicate_prefer_indexers, match_releases_regex, except_releases_regex, external_script_enabled, external_script_cmd, external_script_args, external_script_expect_status, external_script_expect_output, external_script_timeout, external_webhook_enabled, external_webhook_host, external_webhook_data, external_webhook_expect_status, external_webhook_expect_field, season_packs, daily_max_age, match_audio, except_audio, audio_channels, origins, except_origins, tags_match_logic, except_tags_match_logic, expression, indexer_overrides, active_days, active_start, active_end, smart_episode, smart_episode_window, quota_grabs_per_week, quota_grabs_per_month, keyword_scores, min_keyword_score, match_languages, except_languages, match_subtitles, except_subtitles, subtitle_type, match_events, except_events, created_at, updated_at FROM filter WHERE id = ?", filterID)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
 
 	var f domain.Filter
+	var freeleechTokenMinSize, quotaBytesPerDay, duplicatePreferIndexers, matchReleasesRegex, exceptReleasesRegex sql.NullString
+	var quotaGrabsPerHour, quotaGrabsPerDay, duplicateWindow sql.NullInt32
+	var skipDuplicates sql.NullBool
+	var externalScriptCmd, externalScriptArgs, externalScriptOutput sql.NullString
+	var externalScriptStatus, externalScriptTimeout sql.NullInt32
+	var externalScriptEnabled sql.NullBool
+	var externalWebhookHost, externalWebhookData, externalWebhookField sql.NullString
+	var externalWebhookStatus sql.NullInt32
+	var externalWebhookEnabled sql.NullBool
+	var seasonPacks sql.NullString
+	var dailyMaxAge sql.NullInt32
+	var origins, exceptOrigins sql.NullString
+	var tagsMatchLogic, exceptTagsMatchLogic, expression sql.NullString
 	var minSize, maxSize, matchReleases, exceptReleases, matchReleaseGroups, exceptReleaseGroups, freeleechPercent, shows, seasons, episodes, years, artists, albums, matchCategories, exceptCategories, matchUploaders, exceptUploaders, tags, exceptTags sql.NullString
-	var useRegex, scene, freeleech, hasLog, hasCue, perfectFlac sql.NullBool
+	var useRegex, scene, freeleech, hasLog, hasCue, perfectFlac, verifySize, useFreeleechToken sql.NullBool
 	var delay, logScore sql.NullInt32
+	var indexerAccounts, indexerOverrides sql.NullString
+	var activeStart, activeEnd sql.NullString
+	var smartEpisode sql.NullBool
+	var smartEpisodeWindow, quotaGrabsPerWeek, quotaGrabsPerMonth, minKeywordScore sql.NullInt32
+	var keywordScores, subtitleType, matchEvents, exceptEvents sql.NullString
 
-	if err := row.Scan(&f.ID, &f.Enabled, &f.Name, &minSize, &maxSize, &delay, &f.Priority, &matchReleases, &exceptReleases, &useRegex, &matchReleaseGroups, &exceptReleaseGroups, &scene, &freeleech, &freeleechPercent, &shows, &seasons, &episodes, pq.Array(&f.Resolutions), pq.Array(&f.Codecs), pq.Array(&f.Sources), pq.Array(&f.Containers), pq.Array(&f.MatchHDR), pq.Array(&f.ExceptHDR), &years, &artists, &albums, pq.Array(&f.MatchReleaseTypes), pq.Array(&f.Formats), pq.Array(&f.Quality), pq.Array(&f.Media), &logScore, &hasLog, &hasCue, &perfectFlac, &matchCategories, &exceptCategories, &matchUploaders, &exceptUploaders, &tags, &exceptTags, &f.CreatedAt, &f.UpdatedAt); err != nil {
+	if err := row.Scan(&f.ID, &f.Enabled, &f.Name, &minSize, &maxSize, &delay, &f.Priority, &matchReleases, &exceptReleases, &useRegex, &matchReleaseGroups, &exceptReleaseGroups, &scene, &freeleech, &freeleechPercent, &shows, &seasons, &episodes, pq.Array(&f.Resolutions), pq.Array(&f.Codecs), pq.Array(&f.Sources), pq.Array(&f.Containers), pq.Array(&f.MatchHDR), pq.Array(&f.ExceptHDR), &years, &artists, &albums, pq.Array(&f.MatchReleaseTypes), pq.Array(&f.Formats), pq.Array(&f.Quality), pq.Array(&f.Media), &logScore, &hasLog, &hasCue, &perfectFlac, &matchCategories, &exceptCategories, &matchUploaders, &exceptUploaders, &tags, &exceptTags, &indexerAccounts, &verifySize, &useFreeleechToken, &freeleechTokenMinSize, &quotaGrabsPerHour, &quotaGrabsPerDay, &quotaBytesPerDay, &skipDuplicates, &duplicateWindow, &duplicatePreferIndexers, &matchReleasesRegex, &exceptReleasesRegex, &externalScriptEnabled, &externalScriptCmd, &externalScriptArgs, &externalScriptStatus, &externalScriptOutput, &externalScriptTimeout, &externalWebhookEnabled, &externalWebhookHost, &externalWebhookData, &externalWebhookStatus, &externalWebhookField, &seasonPacks, &dailyMaxAge, pq.Array(&f.MatchAudio), pq.Array(&f.ExceptAudio), pq.Array(&f.AudioChannels), &origins, &exceptOrigins, &tagsMatchLogic, &exceptTagsMatchLogic, &expression, &indexerOverrides, pq.Array(&f.ActiveDays), &activeStart, &activeEnd, &smartEpisode, &smartEpisodeWindow, &quotaGrabsPerWeek, &quotaGrabsPerMonth, &keywordScores, &minKeywordScore, pq.Array(&f.MatchLanguages), pq.Array(&f.ExceptLanguages), pq.Array(&f.MatchSubtitles), pq.Array(&f.ExceptSubtitles), &subtitleType, &matchEvents, &exceptEvents, &f.CreatedAt, &f.UpdatedAt); err != nil {
 		log.Error().Stack().Err(err).Msgf("filter: %v : error scanning data to struct", filterID)
 		return nil, err
 	}
@@ -100,6 +141,58 @@ func (r *FilterRepo) FindByID(ctx context.Context, filterID int) (*domain.Filter
 	f.UseRegex = useRegex.Bool
 	f.Scene = scene.Bool
 	f.Freeleech = freeleech.Bool
+	f.VerifySize = verifySize.Bool
+	f.UseFreeleechToken = useFreeleechToken.Bool
+	f.FreeleechTokenMinSize = freeleechTokenMinSize.String
+	f.QuotaGrabsPerHour = int(quotaGrabsPerHour.Int32)
+	f.QuotaGrabsPerDay = int(quotaGrabsPerDay.Int32)
+	f.QuotaGrabsPerWeek = int(quotaGrabsPerWeek.Int32)
+	f.QuotaGrabsPerMonth = int(quotaGrabsPerMonth.Int32)
+	f.QuotaBytesPerDay = quotaBytesPerDay.String
+	f.SkipDuplicates = skipDuplicates.Bool
+	f.DuplicateWindow = int(duplicateWindow.Int32)
+	f.DuplicatePreferIndexers = duplicatePreferIndexers.String
+	f.MatchReleasesRegex = matchReleasesRegex.String
+	f.ExceptReleasesRegex = exceptReleasesRegex.String
+	f.ExternalScriptEnabled = externalScriptEnabled.Bool
+	f.ExternalScriptCmd = externalScriptCmd.String
+	f.ExternalScriptArgs = externalScriptArgs.String
+	f.ExternalScriptStatus = int(externalScriptStatus.Int32)
+	f.ExternalScriptOutput = externalScriptOutput.String
+	f.ExternalScriptTimeout = int(externalScriptTimeout.Int32)
+	f.ExternalWebhookEnabled = externalWebhookEnabled.Bool
+	f.ExternalWebhookHost = externalWebhookHost.String
+	f.ExternalWebhookData = externalWebhookData.String
+	f.ExternalWebhookStatus = int(externalWebhookStatus.Int32)
+	f.ExternalWebhookField = externalWebhookField.String
+	f.SeasonPacks = domain.SeasonPacks(seasonPacks.String)
+	f.DailyMaxAge = int(dailyMaxAge.Int32)
+	f.Origins = origins.String
+	f.ExceptOrigins = exceptOrigins.String
+	f.TagsMatchLogic = domain.TagsMatchLogic(tagsMatchLogic.String)
+	f.ExceptTagsMatchLogic = domain.TagsMatchLogic(exceptTagsMatchLogic.String)
+	f.Expression = expression.String
+	f.ActiveStart = activeStart.String
+	f.ActiveEnd = activeEnd.String
+	f.SmartEpisode = smartEpisode.Bool
+	f.SmartEpisodeWindow = int(smartEpisodeWindow.Int32)
+	f.KeywordScores = keywordScores.String
+	f.MinKeywordScore = int(minKeywordScore.Int32)
+	f.SubtitleType = domain.SubtitleType(subtitleType.String)
+	f.MatchEvents = matchEvents.String
+	f.ExceptEvents = exceptEvents.String
+
+	if indexerAccounts.String != "" {
+		if err := json.Unmarshal([]byte(indexerAccounts.String), &f.IndexerAccounts); err != nil {
+			return nil, fmt.Errorf("could not unmarshal indexer accounts: %w", err)
+		}
+	}
+
+	if indexerOverrides.String != "" {
+		if err := json.Unmarshal([]byte(indexerOverrides.String), &f.IndexerOverrides); err != nil {
+			return nil, fmt.Errorf("could not unmarshal indexer overrides: %w", err)
+		}
+	}
 
 	return &f, nil
 }
@@ -136,22 +229,72 @@ func (r *FilterRepo) FindByIndexerIdentifier(indexer string) ([]domain.Filter, e
 		       f.match_hdr,
 		       f.except_hdr,
 		       f.years,
-			   f.artists,
-			   f.albums,
-			   f.release_types_match,
-			   f.formats,
-			   f.quality,
+		       f.artists,
+		       f.albums,
+		       f.release_types_match,
+		       f.formats,
+		       f.quality,
 		       f.media,
-			   f.log_score,
-			   f.has_log,
-			   f.has_cue,
-			   f.perfect_flac,
+		       f.log_score,
+		       f.has_log,
+		       f.has_cue,
+		       f.perfect_flac,
 		       f.match_categories,
 		       f.except_categories,
 		       f.match_uploaders,
 		       f.except_uploaders,
 		       f.tags,
 		       f.except_tags,
+		       f.indexer_accounts,
+		       f.verify_size,
+		       f.use_freeleech_token,
+		       f.freeleech_token_min_size,
+		       f.quota_grabs_per_hour,
+		       f.quota_grabs_per_day,
+		       f.quota_bytes_per_day,
+		       f.skip_duplicates,
+		       f.duplicate_window,
+		       f.duplicate_prefer_indexers,
+		       f.match_releases_regex,
+		       f.except_releases_regex,
+		       f.external_script_enabled,
+		       f.external_script_cmd,
+		       f.external_script_args,
+		       f.external_script_expect_status,
+		       f.external_script_expect_output,
+		       f.external_script_timeout,
+		       f.external_webhook_enabled,
+		       f.external_webhook_host,
+		       f.external_webhook_data,
+		       f.external_webhook_expect_status,
+		       f.external_webhook_expect_field,
+		       f.season_packs,
+		       f.daily_max_age,
+		       f.match_audio,
+		       f.except_audio,
+		       f.audio_channels,
+		       f.origins,
+		       f.except_origins,
+		       f.tags_match_logic,
+		       f.except_tags_match_logic,
+		       f.expression,
+		       f.indexer_overrides,
+		       f.active_days,
+		       f.active_start,
+		       f.active_end,
+		       f.smart_episode,
+		       f.smart_episode_window,
+		       f.quota_grabs_per_week,
+		       f.quota_grabs_per_month,
+		       f.keyword_scores,
+		       f.min_keyword_score,
+		       f.match_languages,
+		       f.except_languages,
+		       f.match_subtitles,
+		       f.except_subtitles,
+		       f.subtitle_type,
+		       f.match_events,
+		       f.except_events,
 		       f.created_at,
 		       f.updated_at
 		FROM filter f
@@ -159,7 +302,7 @@ func (r *FilterRepo) FindByIndexerIdentifier(indexer string) ([]domain.Filter, e
 				 JOIN indexer i on i.id = fi.indexer_id
 		WHERE i.identifier = ?
 		AND f.enabled = true
-		ORDER BY f.priority DESC`, indexer)
+		ORDER BY f.priority DESC, f.id ASC`, indexer)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("error querying filter row")
 		return nil, err
@@ -171,11 +314,29 @@ func (r *FilterRepo) FindByIndexerIdentifier(indexer string) ([]domain.Filter, e
 	for rows.Next() {
 		var f domain.Filter
 
+		var freeleechTokenMinSize, quotaBytesPerDay, duplicatePreferIndexers, matchReleasesRegex, exceptReleasesRegex sql.NullString
+		var quotaGrabsPerHour, quotaGrabsPerDay, duplicateWindow sql.NullInt32
+		var skipDuplicates sql.NullBool
+		var externalScriptCmd, externalScriptArgs, externalScriptOutput sql.NullString
+		var externalScriptStatus, externalScriptTimeout sql.NullInt32
+		var externalScriptEnabled sql.NullBool
+		var externalWebhookHost, externalWebhookData, externalWebhookField sql.NullString
+		var externalWebhookStatus sql.NullInt32
+		var externalWebhookEnabled sql.NullBool
+		var seasonPacks sql.NullString
+		var dailyMaxAge sql.NullInt32
+		var origins, exceptOrigins sql.NullString
+		var tagsMatchLogic, exceptTagsMatchLogic, expression sql.NullString
 		var minSize, maxSize, matchReleases, exceptReleases, matchReleaseGroups, exceptReleaseGroups, freeleechPercent, shows, seasons, episodes, years, artists, albums, matchCategories, exceptCategories, matchUploaders, exceptUploaders, tags, exceptTags sql.NullString
-		var useRegex, scene, freeleech, hasLog, hasCue, perfectFlac sql.NullBool
+		var useRegex, scene, freeleech, hasLog, hasCue, perfectFlac, verifySize, useFreeleechToken sql.NullBool
 		var delay, logScore sql.NullInt32
+		var indexerAccounts, indexerOverrides sql.NullString
+		var activeStart, activeEnd sql.NullString
+		var smartEpisode sql.NullBool
+		var smartEpisodeWindow, quotaGrabsPerWeek, quotaGrabsPerMonth, minKeywordScore sql.NullInt32
+		var keywordScores, subtitleType, matchEvents, exceptEvents sql.NullString
 
-		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &minSize, &maxSize, &delay, &f.Priority, &matchReleases, &exceptReleases, &useRegex, &matchReleaseGroups, &exceptReleaseGroups, &scene, &freeleech, &freeleechPercent, &shows, &seasons, &episodes, pq.Array(&f.Resolutions), pq.Array(&f.Codecs), pq.Array(&f.Sources), pq.Array(&f.Containers), pq.Array(&f.MatchHDR), pq.Array(&f.ExceptHDR), &years, &artists, &albums, pq.Array(&f.MatchReleaseTypes), pq.Array(&f.Formats), pq.Array(&f.Quality), pq.Array(&f.Media), &logScore, &hasLog, &hasCue, &perfectFlac, &matchCategories, &exceptCategories, &matchUploaders, &exceptUploaders, &tags, &exceptTags, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &minSize, &maxSize, &delay, &f.Priority, &matchReleases, &exceptReleases, &useRegex, &matchReleaseGroups, &exceptReleaseGroups, &scene, &freeleech, &freeleechPercent, &shows, &seasons, &episodes, pq.Array(&f.Resolutions), pq.Array(&f.Codecs), pq.Array(&f.Sources), pq.Array(&f.Containers), pq.Array(&f.MatchHDR), pq.Array(&f.ExceptHDR), &years, &artists, &albums, pq.Array(&f.MatchReleaseTypes), pq.Array(&f.Formats), pq.Array(&f.Quality), pq.Array(&f.Media), &logScore, &hasLog, &hasCue, &perfectFlac, &matchCategories, &exceptCategories, &matchUploaders, &exceptUploaders, &tags, &exceptTags, &indexerAccounts, &verifySize, &useFreeleechToken, &freeleechTokenMinSize, &quotaGrabsPerHour, &quotaGrabsPerDay, &quotaBytesPerDay, &skipDuplicates, &duplicateWindow, &duplicatePreferIndexers, &matchReleasesRegex, &exceptReleasesRegex, &externalScriptEnabled, &externalScriptCmd, &externalScriptArgs, &externalScriptStatus, &externalScriptOutput, &externalScriptTimeout, &externalWebhookEnabled, &externalWebhookHost, &externalWebhookData, &externalWebhookStatus, &externalWebhookField, &seasonPacks, &dailyMaxAge, pq.Array(&f.MatchAudio), pq.Array(&f.ExceptAudio), pq.Array(&f.AudioChannels), &origins, &exceptOrigins, &tagsMatchLogic, &exceptTagsMatchLogic, &expression, &indexerOverrides, pq.Array(&f.ActiveDays), &activeStart, &activeEnd, &smartEpisode, &smartEpisodeWindow, &quotaGrabsPerWeek, &quotaGrabsPerMonth, &keywordScores, &minKeywordScore, pq.Array(&f.MatchLanguages), pq.Array(&f.ExceptLanguages), pq.Array(&f.MatchSubtitles), pq.Array(&f.ExceptSubtitles), &subtitleType, &matchEvents, &exceptEvents, &f.CreatedAt, &f.UpdatedAt); err != nil {
 			log.Error().Stack().Err(err).Msg("error scanning data to struct")
 			return nil, err
 		}
@@ -207,6 +368,58 @@ func (r *FilterRepo) FindByIndexerIdentifier(indexer string) ([]domain.Filter, e
 		f.UseRegex = useRegex.Bool
 		f.Scene = scene.Bool
 		f.Freeleech = freeleech.Bool
+		f.VerifySize = verifySize.Bool
+		f.UseFreeleechToken = useFreeleechToken.Bool
+		f.FreeleechTokenMinSize = freeleechTokenMinSize.String
+		f.QuotaGrabsPerHour = int(quotaGrabsPerHour.Int32)
+		f.QuotaGrabsPerDay = int(quotaGrabsPerDay.Int32)
+		f.QuotaGrabsPerWeek = int(quotaGrabsPerWeek.Int32)
+		f.QuotaGrabsPerMonth = int(quotaGrabsPerMonth.Int32)
+		f.QuotaBytesPerDay = quotaBytesPerDay.String
+		f.SkipDuplicates = skipDuplicates.Bool
+		f.DuplicateWindow = int(duplicateWindow.Int32)
+		f.DuplicatePreferIndexers = duplicatePreferIndexers.String
+		f.MatchReleasesRegex = matchReleasesRegex.String
+		f.ExceptReleasesRegex = exceptReleasesRegex.String
+		f.ExternalScriptEnabled = externalScriptEnabled.Bool
+		f.ExternalScriptCmd = externalScriptCmd.String
+		f.ExternalScriptArgs = externalScriptArgs.String
+		f.ExternalScriptStatus = int(externalScriptStatus.Int32)
+		f.ExternalScriptOutput = externalScriptOutput.String
+		f.ExternalScriptTimeout = int(externalScriptTimeout.Int32)
+		f.ExternalWebhookEnabled = externalWebhookEnabled.Bool
+		f.ExternalWebhookHost = externalWebhookHost.String
+		f.ExternalWebhookData = externalWebhookData.String
+		f.ExternalWebhookStatus = int(externalWebhookStatus.Int32)
+		f.ExternalWebhookField = externalWebhookField.String
+		f.SeasonPacks = domain.SeasonPacks(seasonPacks.String)
+		f.DailyMaxAge = int(dailyMaxAge.Int32)
+		f.Origins = origins.String
+		f.ExceptOrigins = exceptOrigins.String
+		f.TagsMatchLogic = domain.TagsMatchLogic(tagsMatchLogic.String)
+		f.ExceptTagsMatchLogic = domain.TagsMatchLogic(exceptTagsMatchLogic.String)
+		f.Expression = expression.String
+		f.ActiveStart = activeStart.String
+		f.ActiveEnd = activeEnd.String
+		f.SmartEpisode = smartEpisode.Bool
+		f.SmartEpisodeWindow = int(smartEpisodeWindow.Int32)
+		f.KeywordScores = keywordScores.String
+		f.MinKeywordScore = int(minKeywordScore.Int32)
+		f.SubtitleType = domain.SubtitleType(subtitleType.String)
+		f.MatchEvents = matchEvents.String
+		f.ExceptEvents = exceptEvents.String
+
+		if indexerAccounts.String != "" {
+			if err := json.Unmarshal([]byte(indexerAccounts.String), &f.IndexerAccounts); err != nil {
+				return nil, fmt.Errorf("could not unmarshal indexer accounts: %w", err)
+			}
+		}
+
+		if indexerOverrides.String != "" {
+			if err := json.Unmarshal([]byte(indexerOverrides.String), &f.IndexerOverrides); err != nil {
+				return nil, fmt.Errorf("could not unmarshal indexer overrides: %w", err)
+			}
+		}
 
 		filters = append(filters, f)
 	}
@@ -221,15 +434,26 @@ func (r *FilterRepo) Store(ctx context.Context, filter domain.Filter) (*domain.F
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	var err error
 	if filter.ID != 0 {
 		log.Debug().Msg("update existing record")
-	} else {
-		var res sql.Result
+		return &filter, nil
+	}
 
-		res, err = r.db.handler.ExecContext(ctx, `INSERT INTO filter (
-                    name,
+	indexerAccounts, err := marshalIndexerAccounts(filter.IndexerAccounts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	indexerOverrides, err := marshalIndexerOverrides(filter.IndexerOverrides)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	err = r.db.handler.QueryRowContext(ctx, `INSERT INTO filter (
                     enabled,
+                    name,
                     min_size,
                     max_size,
                     delay,
@@ -252,12 +476,6 @@ func (r *FilterRepo) Store(ctx context.Context, filter domain.Filter) (*domain.F
                     match_hdr,
                     except_hdr,
                     years,
-                    match_categories,
-                    except_categories,
-                    match_uploaders,
-                    except_uploaders,
-                    tags,
-                    except_tags,
                     artists,
                     albums,
                     release_types_match,
@@ -267,57 +485,159 @@ func (r *FilterRepo) Store(ctx context.Context, filter domain.Filter) (*domain.F
                     log_score,
                     has_log,
                     has_cue,
-                    perfect_flac
+                    perfect_flac,
+                    match_categories,
+                    except_categories,
+                    match_uploaders,
+                    except_uploaders,
+                    tags,
+                    except_tags,
+                    indexer_accounts,
+                    verify_size,
+                    use_freeleech_token,
+                    freeleech_token_min_size,
+                    quota_grabs_per_hour,
+                    quota_grabs_per_day,
+                    quota_bytes_per_day,
+                    skip_duplicates,
+                    duplicate_window,
+                    duplicate_prefer_indexers,
+                    match_releases_regex,
+                    except_releases_regex,
+                    external_script_enabled,
+                    external_script_cmd,
+                    external_script_args,
+                    external_script_expect_status,
+                    external_script_expect_output,
+                    external_script_timeout,
+                    external_webhook_enabled,
+                    external_webhook_host,
+                    external_webhook_data,
+                    external_webhook_expect_status,
+                    external_webhook_expect_field,
+                    season_packs,
+                    daily_max_age,
+                    match_audio,
+                    except_audio,
+                    audio_channels,
+                    origins,
+                    except_origins,
+                    tags_match_logic,
+                    except_tags_match_logic,
+                    expression,
+                    indexer_overrides,
+                    active_days,
+                    active_start,
+                    active_end,
+                    smart_episode,
+                    smart_episode_window,
+                    quota_grabs_per_week,
+                    quota_grabs_per_month,
+                    keyword_scores,
+                    min_keyword_score,
+                    match_languages,
+                    except_languages,
+                    match_subtitles,
+                    except_subtitles,
+                    subtitle_type,
+                    match_events,
+                    except_events
                     )
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40) ON CONFLICT DO NOTHING`,
-			filter.Name,
-			filter.Enabled,
-			filter.MinSize,
-			filter.MaxSize,
-			filter.Delay,
-			filter.Priority,
-			filter.MatchReleases,
-			filter.ExceptReleases,
-			filter.UseRegex,
-			filter.MatchReleaseGroups,
-			filter.ExceptReleaseGroups,
-			filter.Scene,
-			filter.Freeleech,
-			filter.FreeleechPercent,
-			filter.Shows,
-			filter.Seasons,
-			filter.Episodes,
-			pq.Array(filter.Resolutions),
-			pq.Array(filter.Codecs),
-			pq.Array(filter.Sources),
-			pq.Array(filter.Containers),
-			pq.Array(filter.MatchHDR),
-			pq.Array(filter.ExceptHDR),
-			filter.Years,
-			filter.MatchCategories,
-			filter.ExceptCategories,
-			filter.MatchUploaders,
-			filter.ExceptUploaders,
-			filter.Tags,
-			filter.ExceptTags,
-			filter.Artists,
-			filter.Albums,
-			pq.Array(filter.MatchReleaseTypes),
-			pq.Array(filter.Formats),
-			pq.Array(filter.Quality),
-			pq.Array(filter.Media),
-			filter.LogScore,
-			filter.Log,
-			filter.Cue,
-			filter.PerfectFlac,
-		)
-		if err != nil {
-			log.Error().Stack().Err(err).Msg("error executing query")
-			return nil, err
-		}
-
-		resId, _ := res.LastInsertId()
-		filter.ID = int(resId)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41, $42, $43, $44, $45, $46, $47, $48, $49, $50, $51, $52, $53, $54, $55, $56, $57, $58, $59, $60, $61, $62, $63, $64, $65, $66, $67, $68, $69, $70, $71, $72, $73, $74, $75, $76, $77, $78, $79, $80, $81, $82, $83, $84, $85, $86, $87, $88, $89, $90) ON CONFLICT DO NOTHING RETURNING id`,
+		filter.Enabled,
+		filter.Name,
+		filter.MinSize,
+		filter.MaxSize,
+		filter.Delay,
+		filter.Priority,
+		filter.MatchReleases,
+		filter.ExceptReleases,
+		filter.UseRegex,
+		filter.MatchReleaseGroups,
+		filter.ExceptReleaseGroups,
+		filter.Scene,
+		filter.Freeleech,
+		filter.FreeleechPercent,
+		filter.Shows,
+		filter.Seasons,
+		filter.Episodes,
+		pq.Array(filter.Resolutions),
+		pq.Array(filter.Codecs),
+		pq.Array(filter.Sources),
+		pq.Array(filter.Containers),
+		pq.Array(filter.MatchHDR),
+		pq.Array(filter.ExceptHDR),
+		filter.Years,
+		filter.Artists,
+		filter.Albums,
+		pq.Array(filter.MatchReleaseTypes),
+		pq.Array(filter.Formats),
+		pq.Array(filter.Quality),
+		pq.Array(filter.Media),
+		filter.LogScore,
+		filter.Log,
+		filter.Cue,
+		filter.PerfectFlac,
+		filter.MatchCategories,
+		filter.ExceptCategories,
+		filter.MatchUploaders,
+		filter.ExceptUploaders,
+		filter.Tags,
+		filter.ExceptTags,
+		indexerAccounts,
+		filter.VerifySize,
+		filter.UseFreeleechToken,
+		filter.FreeleechTokenMinSize,
+		filter.QuotaGrabsPerHour,
+		filter.QuotaGrabsPerDay,
+		filter.QuotaBytesPerDay,
+		filter.SkipDuplicates,
+		filter.DuplicateWindow,
+		filter.DuplicatePreferIndexers,
+		filter.MatchReleasesRegex,
+		filter.ExceptReleasesRegex,
+		filter.ExternalScriptEnabled,
+		filter.ExternalScriptCmd,
+		filter.ExternalScriptArgs,
+		filter.ExternalScriptStatus,
+		filter.ExternalScriptOutput,
+		filter.ExternalScriptTimeout,
+		filter.ExternalWebhookEnabled,
+		filter.ExternalWebhookHost,
+		filter.ExternalWebhookData,
+		filter.ExternalWebhookStatus,
+		filter.ExternalWebhookField,
+		filter.SeasonPacks,
+		filter.DailyMaxAge,
+		pq.Array(filter.MatchAudio),
+		pq.Array(filter.ExceptAudio),
+		pq.Array(filter.AudioChannels),
+		filter.Origins,
+		filter.ExceptOrigins,
+		filter.TagsMatchLogic,
+		filter.ExceptTagsMatchLogic,
+		filter.Expression,
+		indexerOverrides,
+		pq.Array(filter.ActiveDays),
+		filter.ActiveStart,
+		filter.ActiveEnd,
+		filter.SmartEpisode,
+		filter.SmartEpisodeWindow,
+		filter.QuotaGrabsPerWeek,
+		filter.QuotaGrabsPerMonth,
+		filter.KeywordScores,
+		filter.MinKeywordScore,
+		pq.Array(filter.MatchLanguages),
+		pq.Array(filter.ExceptLanguages),
+		pq.Array(filter.MatchSubtitles),
+		pq.Array(filter.ExceptSubtitles),
+		filter.SubtitleType,
+		filter.MatchEvents,
+		filter.ExceptEvents,
+	).Scan(&filter.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return nil, err
 	}
 
 	return &filter, nil
@@ -327,17 +647,26 @@ func (r *FilterRepo) Update(ctx context.Context, filter domain.Filter) (*domain.
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	//var res sql.Result
+	indexerAccounts, err := marshalIndexerAccounts(filter.IndexerAccounts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	indexerOverrides, err := marshalIndexerOverrides(filter.IndexerOverrides)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
 
-	var err error
 	_, err = r.db.handler.ExecContext(ctx, `
 			UPDATE filter SET 
-                    name = ?,
                     enabled = ?,
+                    name = ?,
                     min_size = ?,
                     max_size = ?,
                     delay = ?,
-			        priority = ?,
+                    priority = ?,
                     match_releases = ?,
                     except_releases = ?,
                     use_regex = ?,
@@ -353,15 +682,9 @@ func (r *FilterRepo) Update(ctx context.Context, filter domain.Filter) (*domain.
                     codecs = ?,
                     sources = ?,
                     containers = ?,
-			        match_hdr = ?,
-			        except_hdr = ?,
+                    match_hdr = ?,
+                    except_hdr = ?,
                     years = ?,
-                    match_categories = ?,
-                    except_categories = ?,
-                    match_uploaders = ?,
-                    except_uploaders = ?,
-                    tags = ?,
-                    except_tags = ?,
                     artists = ?,
                     albums = ?,
                     release_types_match = ?,
@@ -372,10 +695,67 @@ func (r *FilterRepo) Update(ctx context.Context, filter domain.Filter) (*domain.
                     has_log = ?,
                     has_cue = ?,
                     perfect_flac = ?,
+                    match_categories = ?,
+                    except_categories = ?,
+                    match_uploaders = ?,
+                    except_uploaders = ?,
+                    tags = ?,
+                    except_tags = ?,
+                    indexer_accounts = ?,
+                    verify_size = ?,
+                    use_freeleech_token = ?,
+                    freeleech_token_min_size = ?,
+                    quota_grabs_per_hour = ?,
+                    quota_grabs_per_day = ?,
+                    quota_bytes_per_day = ?,
+                    skip_duplicates = ?,
+                    duplicate_window = ?,
+                    duplicate_prefer_indexers = ?,
+                    match_releases_regex = ?,
+                    except_releases_regex = ?,
+                    external_script_enabled = ?,
+                    external_script_cmd = ?,
+                    external_script_args = ?,
+                    external_script_expect_status = ?,
+                    external_script_expect_output = ?,
+                    external_script_timeout = ?,
+                    external_webhook_enabled = ?,
+                    external_webhook_host = ?,
+                    external_webhook_data = ?,
+                    external_webhook_expect_status = ?,
+                    external_webhook_expect_field = ?,
+                    season_packs = ?,
+                    daily_max_age = ?,
+                    match_audio = ?,
+                    except_audio = ?,
+                    audio_channels = ?,
+                    origins = ?,
+                    except_origins = ?,
+                    tags_match_logic = ?,
+                    except_tags_match_logic = ?,
+                    expression = ?,
+                    indexer_overrides = ?,
+                    active_days = ?,
+                    active_start = ?,
+                    active_end = ?,
+                    smart_episode = ?,
+                    smart_episode_window = ?,
+                    quota_grabs_per_week = ?,
+                    quota_grabs_per_month = ?,
+                    keyword_scores = ?,
+                    min_keyword_score = ?,
+                    match_languages = ?,
+                    except_languages = ?,
+                    match_subtitles = ?,
+                    except_subtitles = ?,
+                    subtitle_type = ?,
+                    match_events = ?,
+                    except_events = ?,
+                    paused_by_indexer = CASE WHEN ? THEN NULL ELSE paused_by_indexer END,
 				    updated_at = CURRENT_TIMESTAMP
             WHERE id = ?`,
-		filter.Name,
 		filter.Enabled,
+		filter.Name,
 		filter.MinSize,
 		filter.MaxSize,
 		filter.Delay,
@@ -398,12 +778,6 @@ func (r *FilterRepo) Update(ctx context.Context, filter domain.Filter) (*domain.
 		pq.Array(filter.MatchHDR),
 		pq.Array(filter.ExceptHDR),
 		filter.Years,
-		filter.MatchCategories,
-		filter.ExceptCategories,
-		filter.MatchUploaders,
-		filter.ExceptUploaders,
-		filter.Tags,
-		filter.ExceptTags,
 		filter.Artists,
 		filter.Albums,
 		pq.Array(filter.MatchReleaseTypes),
@@ -414,6 +788,63 @@ func (r *FilterRepo) Update(ctx context.Context, filter domain.Filter) (*domain.
 		filter.Log,
 		filter.Cue,
 		filter.PerfectFlac,
+		filter.MatchCategories,
+		filter.ExceptCategories,
+		filter.MatchUploaders,
+		filter.ExceptUploaders,
+		filter.Tags,
+		filter.ExceptTags,
+		indexerAccounts,
+		filter.VerifySize,
+		filter.UseFreeleechToken,
+		filter.FreeleechTokenMinSize,
+		filter.QuotaGrabsPerHour,
+		filter.QuotaGrabsPerDay,
+		filter.QuotaBytesPerDay,
+		filter.SkipDuplicates,
+		filter.DuplicateWindow,
+		filter.DuplicatePreferIndexers,
+		filter.MatchReleasesRegex,
+		filter.ExceptReleasesRegex,
+		filter.ExternalScriptEnabled,
+		filter.ExternalScriptCmd,
+		filter.ExternalScriptArgs,
+		filter.ExternalScriptStatus,
+		filter.ExternalScriptOutput,
+		filter.ExternalScriptTimeout,
+		filter.ExternalWebhookEnabled,
+		filter.ExternalWebhookHost,
+		filter.ExternalWebhookData,
+		filter.ExternalWebhookStatus,
+		filter.ExternalWebhookField,
+		filter.SeasonPacks,
+		filter.DailyMaxAge,
+		pq.Array(filter.MatchAudio),
+		pq.Array(filter.ExceptAudio),
+		pq.Array(filter.AudioChannels),
+		filter.Origins,
+		filter.ExceptOrigins,
+		filter.TagsMatchLogic,
+		filter.ExceptTagsMatchLogic,
+		filter.Expression,
+		indexerOverrides,
+		pq.Array(filter.ActiveDays),
+		filter.ActiveStart,
+		filter.ActiveEnd,
+		filter.SmartEpisode,
+		filter.SmartEpisodeWindow,
+		filter.QuotaGrabsPerWeek,
+		filter.QuotaGrabsPerMonth,
+		filter.KeywordScores,
+		filter.MinKeywordScore,
+		pq.Array(filter.MatchLanguages),
+		pq.Array(filter.ExceptLanguages),
+		pq.Array(filter.MatchSubtitles),
+		pq.Array(filter.ExceptSubtitles),
+		filter.SubtitleType,
+		filter.MatchEvents,
+		filter.ExceptEvents,
+		filter.Enabled,
 		filter.ID,
 	)
 	if err != nil {
@@ -432,6 +863,7 @@ func (r *FilterRepo) ToggleEnabled(ctx context.Context, filterID int, enabled bo
 	_, err = r.db.handler.ExecContext(ctx, `
 			UPDATE filter SET 
                     enabled = ?,
+                    paused_by_indexer = NULL,
 				    updated_at = CURRENT_TIMESTAMP
             WHERE id = ?`,
 		enabled,
@@ -445,6 +877,175 @@ func (r *FilterRepo) ToggleEnabled(ctx context.Context, filterID int, enabled bo
 	return nil
 }
 
+// PauseByIndexer disable the enabled filters that use no other indexer, they remember it so ResumeByIndexer can enable them again
+func (r *FilterRepo) PauseByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error) {
+	rows, err := r.db.handler.QueryContext(ctx, `
+			UPDATE filter SET
+				enabled = ?,
+				paused_by_indexer = ?,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE enabled = ? AND id IN (
+				SELECT fi.filter_id
+				FROM filter_indexer fi
+					JOIN indexer i ON i.id = fi.indexer_id
+				GROUP BY fi.filter_id
+				HAVING COUNT(*) = 1 AND MAX(i.identifier) = ?
+			)
+			RETURNING id, name`,
+		false,
+		indexer,
+		true,
+		indexer,
+	)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("filter.pause_by_indexer: error executing query")
+		return nil, err
+	}
+
+	return scanFilterNames(rows)
+}
+
+// ResumeByIndexer enable the filters that were paused for indexer again
+func (r *FilterRepo) ResumeByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error) {
+	rows, err := r.db.handler.QueryContext(ctx, `
+			UPDATE filter SET
+				enabled = ?,
+				paused_by_indexer = NULL,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE paused_by_indexer = ?
+			RETURNING id, name`,
+		true,
+		indexer,
+	)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("filter.resume_by_indexer: error executing query")
+		return nil, err
+	}
+
+	return scanFilterNames(rows)
+}
+
+// scanFilterNames the id and name of the filters in rows
+func scanFilterNames(rows *sql.Rows) ([]domain.Filter, error) {
+	defer rows.Close()
+
+	var filters []domain.Filter
+	for rows.Next() {
+		var f domain.Filter
+
+		if err := rows.Scan(&f.ID, &f.Name); err != nil {
+			log.Error().Stack().Err(err).Msg("filter: error scanning data to struct")
+			return nil, err
+		}
+
+		filters = append(filters, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return filters, nil
+}
+
+// UpdatePriorities set the priority of several filters at once
+func (r *FilterRepo) UpdatePriorities(ctx context.Context, priorities map[int]int32) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	tx, err := r.db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for filterID, priority := range priorities {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE filter SET
+				priority = ?,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`,
+			priority,
+			filterID,
+		)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("filter.UpdatePriorities: error updating filter: %v", filterID)
+			return err
+		}
+
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return fmt.Errorf("filter %v: %w", filterID, sql.ErrNoRows)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Stack().Err(err).Msg("filter.UpdatePriorities: error committing transaction")
+		return err
+	}
+
+	return nil
+}
+
+// RecordChecks count the filters a release was checked against and the ones it matched
+func (r *FilterRepo) RecordChecks(ctx context.Context, evaluated []int, matched []int, at time.Time) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	tx, err := r.db.handler.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for _, filterID := range evaluated {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO filter_stats (filter_id, evaluated) VALUES (?, 1)
+			ON CONFLICT (filter_id) DO UPDATE SET evaluated = filter_stats.evaluated + 1`,
+			filterID,
+		); err != nil {
+			log.Error().Stack().Err(err).Msgf("filter.RecordChecks: error updating stats for filter: %v", filterID)
+			return err
+		}
+	}
+
+	for _, filterID := range matched {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO filter_stats (filter_id, matched, last_match_at) VALUES (?, 1, ?)
+			ON CONFLICT (filter_id) DO UPDATE SET matched = filter_stats.matched + 1, last_match_at = excluded.last_match_at`,
+			filterID,
+			at,
+		); err != nil {
+			log.Error().Stack().Err(err).Msgf("filter.RecordChecks: error updating stats for filter: %v", filterID)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RecordActionResult count the outcome of an action run for a filter
+func (r *FilterRepo) RecordActionResult(ctx context.Context, filterID int, succeeded bool) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	column := "actions_failed"
+	if succeeded {
+		column = "actions_succeeded"
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO filter_stats (filter_id, %[1]v) VALUES (?, 1)
+		ON CONFLICT (filter_id) DO UPDATE SET %[1]v = filter_stats.%[1]v + 1`, column)
+
+	if _, err := r.db.handler.ExecContext(ctx, query, filterID); err != nil {
+		log.Error().Stack().Err(err).Msgf("filter.RecordActionResult: error updating stats for filter: %v", filterID)
+		return err
+	}
+
+	return nil
+}
+
 func (r *FilterRepo) StoreIndexerConnections(ctx context.Context, filterID int, indexers []domain.Indexer) error {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
@@ -526,6 +1127,34 @@ func (r *FilterRepo) Delete(ctx context.Context, filterID int) error {
 	return nil
 }
 
+// marshalIndexerAccounts store pinned accounts as json, empty maps are stored as null
+func marshalIndexerAccounts(accounts map[string]string) (sql.NullString, error) {
+	if len(accounts) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(accounts)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return toNullString(string(data)), nil
+}
+
+// marshalIndexerOverrides store overrides as json, empty maps are stored as null
+func marshalIndexerOverrides(overrides domain.IndexerOverrides) (sql.NullString, error) {
+	if len(overrides) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return toNullString(string(data)), nil
+}
+
 // Split string to slice. We store comma separated strings and convert to slice
 func stringToSlice(str string) []string {
 	if str == "" {
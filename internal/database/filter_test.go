@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestFilterRepo_Store(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewFilterRepo(db)
+
+	filter, err := repo.Store(ctx, domain.Filter{
+		Name:            "movies",
+		Enabled:         true,
+		MinSize:         "1 GB",
+		Resolutions:     []string{"1080p"},
+		Codecs:          []string{},
+		Sources:         []string{},
+		Containers:      []string{},
+		VerifySize:      true,
+		IndexerAccounts: map[string]string{"mock": "second"},
+		ActiveDays:      []string{"mon"},
+		MatchEvents:     "UFC*",
+	})
+	require.NoError(t, err)
+	require.NotZero(t, filter.ID)
+
+	found, err := repo.FindByID(ctx, filter.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "movies", found.Name)
+	assert.Equal(t, "1 GB", found.MinSize)
+	assert.Equal(t, []string{"1080p"}, found.Resolutions)
+	assert.True(t, found.VerifySize)
+	assert.Equal(t, map[string]string{"mock": "second"}, found.IndexerAccounts)
+	assert.Equal(t, []string{"mon"}, found.ActiveDays)
+	assert.Equal(t, "UFC*", found.MatchEvents)
+
+	found.VerifySize = false
+	found.MaxSize = "10 GB"
+	_, err = repo.Update(ctx, *found)
+	require.NoError(t, err)
+
+	found, err = repo.FindByID(ctx, filter.ID)
+	require.NoError(t, err)
+	assert.False(t, found.VerifySize)
+	assert.Equal(t, "10 GB", found.MaxSize)
+	assert.Equal(t, "UFC*", found.MatchEvents)
+}
+
+func TestFilterRepo_PauseByIndexer(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewFilterRepo(db)
+	indexers := NewIndexerRepo(db)
+
+	failing, err := indexers.Store(domain.Indexer{Name: "Failing", Identifier: "failing", Enabled: true, Settings: map[string]string{}})
+	require.NoError(t, err)
+	healthy, err := indexers.Store(domain.Indexer{Name: "Healthy", Identifier: "healthy", Enabled: true, Settings: map[string]string{}})
+	require.NoError(t, err)
+
+	store := func(name string, enabled bool, with ...*domain.Indexer) *domain.Filter {
+		f, err := repo.Store(ctx, domain.Filter{Name: name, Enabled: enabled, Resolutions: []string{}, Codecs: []string{}, Sources: []string{}, Containers: []string{}})
+		require.NoError(t, err)
+
+		for _, i := range with {
+			require.NoError(t, repo.StoreIndexerConnection(ctx, f.ID, int(i.ID)))
+		}
+
+		return f
+	}
+
+	only := store("only", true, failing)
+	store("both", true, failing, healthy)
+	store("disabled", false, failing)
+	manual := store("manual", true, failing)
+
+	enabled := func(f *domain.Filter) bool {
+		found, err := repo.FindByID(ctx, f.ID)
+		require.NoError(t, err)
+		return found.Enabled
+	}
+
+	// filters that also use a healthy indexer keep running
+	paused, err := repo.PauseByIndexer(ctx, "failing")
+	require.NoError(t, err)
+	require.Len(t, paused, 2)
+	assert.ElementsMatch(t, []string{"only", "manual"}, []string{paused[0].Name, paused[1].Name})
+	assert.False(t, enabled(only))
+
+	// disabled by hand since, it is left alone
+	require.NoError(t, repo.ToggleEnabled(ctx, manual.ID, false))
+
+	resumed, err := repo.ResumeByIndexer(ctx, "failing")
+	require.NoError(t, err)
+	require.Len(t, resumed, 1)
+	assert.Equal(t, "only", resumed[0].Name)
+	assert.True(t, enabled(only))
+	assert.False(t, enabled(manual))
+
+	resumed, err = repo.ResumeByIndexer(ctx, "failing")
+	require.NoError(t, err)
+	assert.Empty(t, resumed)
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/autobrr/autobrr/internal/metrics"
+)
+
+// handler runs statements with the placeholders of the driver and records how long they take
+// for the query latency metric. The repos write ? placeholders for both sqlite and postgres.
+type handler struct {
+	*sql.DB
+	placeholders sq.PlaceholderFormat
+}
+
+// rowScanner a single row of *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// rebind ? placeholders to $1, $2 for postgres
+func rebind(placeholders sq.PlaceholderFormat, query string) string {
+	if placeholders == nil {
+		return query
+	}
+
+	rebound, err := placeholders.ReplacePlaceholders(query)
+	if err != nil {
+		return query
+	}
+
+	return rebound
+}
+
+func (db handler) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "exec")
+	return db.DB.ExecContext(ctx, rebind(db.placeholders, query), args...)
+}
+
+func (db handler) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db handler) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "query")
+	return db.DB.QueryContext(ctx, rebind(db.placeholders, query), args...)
+}
+
+func (db handler) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext the row is read on Scan, so this only covers running the statement
+func (db handler) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "query")
+	return db.DB.QueryRowContext(ctx, rebind(db.placeholders, query), args...)
+}
+
+func (db handler) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db handler) BeginTx(ctx context.Context, opts *sql.TxOptions) (*handlerTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlerTx{Tx: tx, placeholders: db.placeholders}, nil
+}
+
+func (db handler) Begin() (*handlerTx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// handlerTx transaction with the same placeholder rewriting and timing as handler
+type handlerTx struct {
+	*sql.Tx
+	placeholders sq.PlaceholderFormat
+}
+
+func (tx *handlerTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "exec")
+	return tx.Tx.ExecContext(ctx, rebind(tx.placeholders, query), args...)
+}
+
+func (tx *handlerTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *handlerTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "query")
+	return tx.Tx.QueryContext(ctx, rebind(tx.placeholders, query), args...)
+}
+
+func (tx *handlerTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *handlerTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer metrics.ObserveDuration(metrics.DatabaseQueryDuration, time.Now(), "query")
+	return tx.Tx.QueryRowContext(ctx, rebind(tx.placeholders, query), args...)
+}
+
+func (tx *handlerTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
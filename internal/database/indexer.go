@@ -2,16 +2,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/rs/zerolog/log"
 )
 
 type IndexerRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewIndexerRepo(db *SqliteDB) domain.IndexerRepo {
+func NewIndexerRepo(db *DB) domain.IndexerRepo {
 	return &IndexerRepo{
 		db: db,
 	}
@@ -27,14 +28,29 @@ func (r *IndexerRepo) Store(indexer domain.Indexer) (*domain.Indexer, error) {
 		return nil, err
 	}
 
-	res, err := r.db.handler.Exec(`INSERT INTO indexer (enabled, name, identifier, settings) VALUES (?, ?, ?, ?)`, indexer.Enabled, indexer.Name, indexer.Identifier, settings)
+	limits, err := json.Marshal(indexer.DownloadLimits)
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("error executing query")
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	retry, err := json.Marshal(indexer.DownloadRetry)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	accounts, err := json.Marshal(indexer.Accounts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
 		return nil, err
 	}
 
-	id, _ := res.LastInsertId()
-	indexer.ID = id
+	err = r.db.handler.QueryRow(`INSERT INTO indexer (enabled, name, identifier, implementation, settings, download_limits, download_retry, proxy, accounts, account_selection) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`, indexer.Enabled, indexer.Name, indexer.Identifier, indexer.Implementation, r.db.secret(settings), limits, retry, r.db.secret(toNullString(indexer.Proxy)), r.db.secret(accounts), toNullString(string(indexer.AccountSelection))).Scan(&indexer.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return nil, err
+	}
 
 	return &indexer, nil
 }
@@ -49,7 +65,25 @@ func (r *IndexerRepo) Update(indexer domain.Indexer) (*domain.Indexer, error) {
 		return nil, err
 	}
 
-	_, err = r.db.handler.Exec(`UPDATE indexer SET enabled = ?, name = ?, settings = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, indexer.Enabled, indexer.Name, sett, indexer.ID)
+	limits, err := json.Marshal(indexer.DownloadLimits)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	retry, err := json.Marshal(indexer.DownloadRetry)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	accounts, err := json.Marshal(indexer.Accounts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error marshaling json data")
+		return nil, err
+	}
+
+	_, err = r.db.handler.Exec(`UPDATE indexer SET enabled = ?, name = ?, settings = ?, download_limits = ?, download_retry = ?, proxy = ?, accounts = ?, account_selection = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, indexer.Enabled, indexer.Name, r.db.secret(sett), limits, retry, r.db.secret(toNullString(indexer.Proxy)), r.db.secret(accounts), toNullString(string(indexer.AccountSelection)), indexer.ID)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("error executing query")
 		return nil, err
@@ -62,7 +96,7 @@ func (r *IndexerRepo) List() ([]domain.Indexer, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.Query("SELECT id, enabled, name, identifier, settings FROM indexer ORDER BY name ASC")
+	rows, err := r.db.handler.Query("SELECT id, enabled, name, identifier, implementation, settings, download_limits, download_retry, proxy, accounts, account_selection FROM indexer ORDER BY name ASC")
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("indexer.list: error query indexer")
 		return nil, err
@@ -74,10 +108,11 @@ func (r *IndexerRepo) List() ([]domain.Indexer, error) {
 	for rows.Next() {
 		var f domain.Indexer
 
+		var implementation, limits, retry, proxy, accounts, accountSelection sql.NullString
 		var settings string
 		var settingsMap map[string]string
 
-		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &f.Identifier, &settings); err != nil {
+		if err := rows.Scan(&f.ID, &f.Enabled, &f.Name, &f.Identifier, &implementation, r.db.scanSecret(&settings), &limits, &retry, r.db.scanSecret(&proxy), r.db.scanSecret(&accounts), &accountSelection); err != nil {
 			log.Error().Stack().Err(err).Msg("indexer.list: error scanning data to struct")
 			return nil, err
 		}
@@ -88,6 +123,30 @@ func (r *IndexerRepo) List() ([]domain.Indexer, error) {
 			return nil, err
 		}
 
+		if limits.String != "" {
+			if err := json.Unmarshal([]byte(limits.String), &f.DownloadLimits); err != nil {
+				log.Error().Stack().Err(err).Msg("indexer.list: error unmarshal download limits")
+				return nil, err
+			}
+		}
+
+		if retry.String != "" {
+			if err := json.Unmarshal([]byte(retry.String), &f.DownloadRetry); err != nil {
+				log.Error().Stack().Err(err).Msg("indexer.list: error unmarshal download retry")
+				return nil, err
+			}
+		}
+
+		if accounts.String != "" {
+			if err := json.Unmarshal([]byte(accounts.String), &f.Accounts); err != nil {
+				log.Error().Stack().Err(err).Msg("indexer.list: error unmarshal accounts")
+				return nil, err
+			}
+		}
+
+		f.Implementation = implementation.String
+		f.Proxy = proxy.String
+		f.AccountSelection = domain.IndexerAccountSelection(accountSelection.String)
 		f.Settings = settingsMap
 
 		indexers = append(indexers, f)
@@ -161,3 +220,42 @@ func (r *IndexerRepo) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// SetCredentialsInvalid flag the credentials of an indexer with the reason, an empty reason clears the flag
+func (r *IndexerRepo) SetCredentialsInvalid(ctx context.Context, identifier string, reason string) error {
+	_, err := r.db.handler.ExecContext(ctx, `UPDATE indexer SET credentials_invalid = ? WHERE identifier = ?`, toNullString(reason), identifier)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("indexer.set_credentials_invalid: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// ListCredentialsInvalid the reason per indexer identifier for the indexers with flagged credentials
+func (r *IndexerRepo) ListCredentialsInvalid(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.handler.QueryContext(ctx, `SELECT identifier, credentials_invalid FROM indexer WHERE credentials_invalid IS NOT NULL`)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("indexer.list_credentials_invalid: error query indexer")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	flagged := map[string]string{}
+	for rows.Next() {
+		var identifier, reason string
+
+		if err := rows.Scan(&identifier, &reason); err != nil {
+			log.Error().Stack().Err(err).Msg("indexer.list_credentials_invalid: error scanning data")
+			return nil, err
+		}
+
+		flagged[identifier] = reason
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return flagged, nil
+}
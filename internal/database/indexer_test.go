@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestIndexerRepo_CredentialsInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewIndexerRepo(db)
+
+	indexer, err := repo.Store(domain.Indexer{Name: "Mock", Identifier: "mock", Enabled: true, Settings: map[string]string{}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SetCredentialsInvalid(ctx, "mock", "status code: 403"))
+
+	flagged, err := repo.ListCredentialsInvalid(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"mock": "status code: 403"}, flagged)
+
+	// saving the indexer does not clear it, the service does
+	_, err = repo.Update(*indexer)
+	require.NoError(t, err)
+
+	flagged, err = repo.ListCredentialsInvalid(ctx)
+	require.NoError(t, err)
+	assert.Len(t, flagged, 1)
+
+	require.NoError(t, repo.SetCredentialsInvalid(ctx, "mock", ""))
+
+	flagged, err = repo.ListCredentialsInvalid(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, flagged)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	sq "github.com/Masterminds/squirrel"
 
@@ -12,10 +13,10 @@ import (
 )
 
 type IrcRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewIrcRepo(db *SqliteDB) domain.IrcRepo {
+func NewIrcRepo(db *DB) domain.IrcRepo {
 	return &IrcRepo{db: db}
 }
 
@@ -35,7 +36,7 @@ func (r *IrcRepo) GetNetworkByID(id int64) (*domain.IrcNetwork, error) {
 	var nsAccount, nsPassword sql.NullString
 	var tls sql.NullBool
 
-	if err := row.Scan(&n.ID, &n.Enabled, &n.Name, &n.Server, &n.Port, &tls, &pass, &inviteCmd, &nsAccount, &nsPassword); err != nil {
+	if err := row.Scan(&n.ID, &n.Enabled, &n.Name, &n.Server, &n.Port, &tls, r.db.scanSecret(&pass), r.db.scanSecret(&inviteCmd), &nsAccount, r.db.scanSecret(&nsPassword)); err != nil {
 		log.Fatal().Err(err)
 	}
 
@@ -96,7 +97,7 @@ func (r *IrcRepo) FindActiveNetworks(ctx context.Context) ([]domain.IrcNetwork,
 		var pass, inviteCmd sql.NullString
 		var tls sql.NullBool
 
-		if err := rows.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, &pass, &inviteCmd, &net.NickServ.Account, &net.NickServ.Password); err != nil {
+		if err := rows.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, r.db.scanSecret(&pass), r.db.scanSecret(&inviteCmd), &net.NickServ.Account, r.db.scanSecret(&net.NickServ.Password)); err != nil {
 			log.Fatal().Err(err)
 		}
 
@@ -131,7 +132,7 @@ func (r *IrcRepo) ListNetworks(ctx context.Context) ([]domain.IrcNetwork, error)
 		var pass, inviteCmd sql.NullString
 		var tls sql.NullBool
 
-		if err := rows.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, &pass, &inviteCmd, &net.NickServ.Account, &net.NickServ.Password); err != nil {
+		if err := rows.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, r.db.scanSecret(&pass), r.db.scanSecret(&inviteCmd), &net.NickServ.Account, r.db.scanSecret(&net.NickServ.Password)); err != nil {
 			log.Fatal().Err(err)
 		}
 
@@ -152,7 +153,7 @@ func (r *IrcRepo) ListChannels(networkID int64) ([]domain.IrcChannel, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	rows, err := r.db.handler.Query("SELECT id, name, enabled FROM irc_channel WHERE network_id = ?", networkID)
+	rows, err := r.db.handler.Query("SELECT id, name, enabled, password FROM irc_channel WHERE network_id = ?", networkID)
 	if err != nil {
 		log.Fatal().Err(err)
 	}
@@ -161,11 +162,14 @@ func (r *IrcRepo) ListChannels(networkID int64) ([]domain.IrcChannel, error) {
 	var channels []domain.IrcChannel
 	for rows.Next() {
 		var ch domain.IrcChannel
+		var pass sql.NullString
 
-		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Enabled); err != nil {
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Enabled, r.db.scanSecret(&pass)); err != nil {
 			log.Fatal().Err(err)
 		}
 
+		ch.Password = pass.String
+
 		channels = append(channels, ch)
 	}
 	if err := rows.Err(); err != nil {
@@ -199,7 +203,7 @@ func (r *IrcRepo) CheckExistingNetwork(ctx context.Context, network *domain.IrcN
 	var pass, inviteCmd, nickPass sql.NullString
 	var tls sql.NullBool
 
-	err = row.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, &pass, &inviteCmd, &net.NickServ.Account, &nickPass)
+	err = row.Scan(&net.ID, &net.Enabled, &net.Name, &net.Server, &net.Port, &tls, r.db.scanSecret(&pass), r.db.scanSecret(&inviteCmd), &net.NickServ.Account, r.db.scanSecret(&nickPass))
 	if err == sql.ErrNoRows {
 		// no result is not an error in our case
 		return nil, nil
@@ -221,11 +225,11 @@ func (r *IrcRepo) StoreNetwork(network *domain.IrcNetwork) error {
 	//defer r.db.lock.RUnlock()
 
 	netName := toNullString(network.Name)
-	pass := toNullString(network.Pass)
-	inviteCmd := toNullString(network.InviteCommand)
+	pass := r.db.secret(toNullString(network.Pass))
+	inviteCmd := r.db.secret(toNullString(network.InviteCommand))
 
 	nsAccount := toNullString(network.NickServ.Account)
-	nsPassword := toNullString(network.NickServ.Password)
+	nsPassword := r.db.secret(toNullString(network.NickServ.Password))
 
 	var err error
 	if network.ID != 0 {
@@ -258,9 +262,7 @@ func (r *IrcRepo) StoreNetwork(network *domain.IrcNetwork) error {
 			return err
 		}
 	} else {
-		var res sql.Result
-
-		res, err = r.db.handler.Exec(`INSERT INTO irc_network (
+		err = r.db.handler.QueryRow(`INSERT INTO irc_network (
                          enabled,
                          name,
                          server,
@@ -270,7 +272,7 @@ func (r *IrcRepo) StoreNetwork(network *domain.IrcNetwork) error {
                          invite_command,
 			    		 nickserv_account,
 			             nickserv_password
-                         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+                         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`,
 			network.Enabled,
 			netName,
 			network.Server,
@@ -280,16 +282,15 @@ func (r *IrcRepo) StoreNetwork(network *domain.IrcNetwork) error {
 			inviteCmd,
 			nsAccount,
 			nsPassword,
-		)
-		if err != nil {
+		).Scan(&network.ID)
+		// nothing is returned for a network that already exists
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			log.Error().Stack().Err(err).Msg("irc.store_network: error executing query")
 			return err
 		}
-
-		network.ID, err = res.LastInsertId()
 	}
 
-	return err
+	return nil
 }
 
 func (r *IrcRepo) UpdateNetwork(ctx context.Context, network *domain.IrcNetwork) error {
@@ -297,11 +298,11 @@ func (r *IrcRepo) UpdateNetwork(ctx context.Context, network *domain.IrcNetwork)
 	//defer r.db.lock.RUnlock()
 
 	netName := toNullString(network.Name)
-	pass := toNullString(network.Pass)
-	inviteCmd := toNullString(network.InviteCommand)
+	pass := r.db.secret(toNullString(network.Pass))
+	inviteCmd := r.db.secret(toNullString(network.InviteCommand))
 
 	nsAccount := toNullString(network.NickServ.Account)
-	nsPassword := toNullString(network.NickServ.Password)
+	nsPassword := r.db.secret(toNullString(network.NickServ.Password))
 
 	var err error
 	// update record
@@ -356,28 +357,25 @@ func (r *IrcRepo) StoreNetworkChannels(ctx context.Context, networkID int64, cha
 	}
 
 	for _, channel := range channels {
-		var res sql.Result
-		pass := toNullString(channel.Password)
+		pass := r.db.secret(toNullString(channel.Password))
 
-		res, err = tx.ExecContext(ctx, `INSERT INTO irc_channel (
+		err = tx.QueryRowContext(ctx, `INSERT INTO irc_channel (
                          enabled,
                          detached,
                          name,
                          password,
                          network_id
-                         ) VALUES (?, ?, ?, ?, ?)`,
+                         ) VALUES (?, ?, ?, ?, ?) RETURNING id`,
 			channel.Enabled,
 			true,
 			channel.Name,
 			pass,
 			networkID,
-		)
+		).Scan(&channel.ID)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error executing query")
 			return err
 		}
-
-		channel.ID, err = res.LastInsertId()
 	}
 
 	err = tx.Commit()
@@ -393,7 +391,7 @@ func (r *IrcRepo) StoreChannel(networkID int64, channel *domain.IrcChannel) erro
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	pass := toNullString(channel.Password)
+	pass := r.db.secret(toNullString(channel.Password))
 
 	var err error
 	if channel.ID != 0 {
@@ -413,27 +411,27 @@ func (r *IrcRepo) StoreChannel(networkID int64, channel *domain.IrcChannel) erro
 			channel.ID,
 		)
 	} else {
-		var res sql.Result
-
-		res, err = r.db.handler.Exec(`INSERT INTO irc_channel (
+		err = r.db.handler.QueryRow(`INSERT INTO irc_channel (
                          enabled,
                          detached,
                          name,
                          password,
                          network_id
-                         ) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+                         ) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`,
 			channel.Enabled,
 			true,
 			channel.Name,
 			pass,
 			networkID,
-		)
+		).Scan(&channel.ID)
+		// nothing is returned for a channel the network already has
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error executing query")
 			return err
 		}
-
-		channel.ID, err = res.LastInsertId()
 	}
 
 	return err
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Size bytes the database takes up, for sqlite without the wal file
+func (db *DB) Size(ctx context.Context) (int64, error) {
+	query := `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`
+	if db.postgres() {
+		query = `SELECT pg_database_size(current_database())`
+	}
+
+	var size int64
+	if err := db.handler.QueryRowContext(ctx, query).Scan(&size); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// Vacuum compact the database and update the query planner statistics
+func (db *DB) Vacuum(ctx context.Context) error {
+	statements := []string{`VACUUM`, `ANALYZE`, `PRAGMA wal_checkpoint(TRUNCATE)`}
+	if db.postgres() {
+		// can't run in a transaction, database/sql runs it on its own
+		statements = []string{`VACUUM ANALYZE`}
+	}
+
+	for _, statement := range statements {
+		if _, err := db.handler.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("could not run %v: %w", statement, err)
+		}
+	}
+
+	return nil
+}
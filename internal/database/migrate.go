@@ -2,404 +2,470 @@ package database
 
 import (
 	"database/sql"
+	"embed"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
 	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
 )
 
-const schema = `
-CREATE TABLE users
-(
-    id         INTEGER PRIMARY KEY,
-    username   TEXT NOT NULL,
-    password   TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE (username)
-);
-
-CREATE TABLE indexer
-(
-    id         INTEGER PRIMARY KEY,
-    identifier TEXT,
-    enabled    BOOLEAN,
-    name       TEXT NOT NULL,
-    settings   TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE (identifier)
-);
-
-CREATE TABLE irc_network
-(
-    id                  INTEGER PRIMARY KEY,
-    enabled             BOOLEAN,
-    name                TEXT NOT NULL,
-    server              TEXT NOT NULL,
-    port                INTEGER NOT NULL,
-    tls                 BOOLEAN,
-    pass                TEXT,
-    invite_command      TEXT,
-    nickserv_account    TEXT,
-    nickserv_password   TEXT,
-    connected           BOOLEAN,
-    connected_since     TIMESTAMP,
-    created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE (server, port, nickserv_account)
-);
-
-CREATE TABLE irc_channel
-(
-    id          INTEGER PRIMARY KEY,
-    enabled     BOOLEAN,
-    name        TEXT NOT NULL,
-    password    TEXT,
-    detached    BOOLEAN,
-    network_id  INTEGER NOT NULL,
-    FOREIGN KEY (network_id) REFERENCES irc_network(id),
-    UNIQUE (network_id, name)
-);
-
-CREATE TABLE filter
-(
-    id                    INTEGER PRIMARY KEY,
-    enabled               BOOLEAN,
-    name                  TEXT NOT NULL,
-    min_size              TEXT,
-    max_size              TEXT,
-    delay                 INTEGER,
-    priority              INTEGER DEFAULT 0 NOT NULL,
-    match_releases        TEXT,
-    except_releases       TEXT,
-    use_regex             BOOLEAN,
-    match_release_groups  TEXT,
-    except_release_groups TEXT,
-    scene                 BOOLEAN,
-    freeleech             BOOLEAN,
-    freeleech_percent     TEXT,
-    shows                 TEXT,
-    seasons               TEXT,
-    episodes              TEXT,
-    resolutions           TEXT []   DEFAULT '{}' NOT NULL,
-    codecs                TEXT []   DEFAULT '{}' NOT NULL,
-    sources               TEXT []   DEFAULT '{}' NOT NULL,
-    containers            TEXT []   DEFAULT '{}' NOT NULL,
-    match_hdr             TEXT []   DEFAULT '{}',
-    except_hdr            TEXT []   DEFAULT '{}',
-    years                 TEXT,
-    artists               TEXT,
-    albums                TEXT,
-    release_types_match   TEXT []   DEFAULT '{}',
-    release_types_ignore  TEXT []   DEFAULT '{}',
-    formats               TEXT []   DEFAULT '{}',
-    quality               TEXT []   DEFAULT '{}',
-	media 				  TEXT []   DEFAULT '{}',
-    log_score             INTEGER,
-    has_log               BOOLEAN,
-    has_cue               BOOLEAN,
-    perfect_flac          BOOLEAN,
-    match_categories      TEXT,
-    except_categories     TEXT,
-    match_uploaders       TEXT,
-    except_uploaders      TEXT,
-    tags                  TEXT,
-    except_tags           TEXT,
-    created_at            TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at            TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE filter_indexer
-(
-    filter_id  INTEGER,
-    indexer_id INTEGER,
-    FOREIGN KEY (filter_id) REFERENCES filter(id),
-    FOREIGN KEY (indexer_id) REFERENCES indexer(id),
-    PRIMARY KEY (filter_id, indexer_id)
-);
-
-CREATE TABLE client
-(
-    id       INTEGER PRIMARY KEY,
-    name     TEXT NOT NULL,
-    enabled  BOOLEAN,
-    type     TEXT,
-    host     TEXT NOT NULL,
-    port     INTEGER,
-    ssl      BOOLEAN,
-    username TEXT,
-    password TEXT,
-    settings JSON
-);
-
-CREATE TABLE action
-(
-    id                   INTEGER PRIMARY KEY,
-    name                 TEXT,
-    type                 TEXT,
-    enabled              BOOLEAN,
-    exec_cmd             TEXT,
-    exec_args            TEXT,
-    watch_folder         TEXT,
-    category             TEXT,
-    tags                 TEXT,
-    label                TEXT,
-    save_path            TEXT,
-    paused               BOOLEAN,
-    ignore_rules         BOOLEAN,
-    limit_upload_speed   INT,
-    limit_download_speed INT,
-    client_id            INTEGER,
-    filter_id            INTEGER,
-    FOREIGN KEY (client_id) REFERENCES client(id),
-    FOREIGN KEY (filter_id) REFERENCES filter(id)
-);
-
-CREATE TABLE "release"
-(
-    id                INTEGER PRIMARY KEY,
-    filter_status     TEXT,
-    rejections        TEXT []   DEFAULT '{}' NOT NULL,
-    indexer           TEXT,
-    filter            TEXT,
-    protocol          TEXT,
-    implementation    TEXT,
-    timestamp         TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    group_id          TEXT,
-    torrent_id        TEXT,
-    torrent_name      TEXT,
-    size              INTEGER,
-    raw               TEXT,
-    title             TEXT,
-    category          TEXT,
-    season            INTEGER,
-    episode           INTEGER,
-    year              INTEGER,
-    resolution        TEXT,
-    source            TEXT,
-    codec             TEXT,
-    container         TEXT,
-    hdr               TEXT,
-    audio             TEXT,
-    release_group     TEXT,
-    region            TEXT,
-    language          TEXT,
-    edition           TEXT,
-    unrated           BOOLEAN,
-    hybrid            BOOLEAN,
-    proper            BOOLEAN,
-    repack            BOOLEAN,
-    website           TEXT,
-    artists           TEXT []   DEFAULT '{}' NOT NULL,
-    type              TEXT,
-    format            TEXT,
-    quality           TEXT,
-    log_score         INTEGER,
-    has_log           BOOLEAN,
-    has_cue           BOOLEAN,
-    is_scene          BOOLEAN,
-    origin            TEXT,
-    tags              TEXT []   DEFAULT '{}' NOT NULL,
-    freeleech         BOOLEAN,
-    freeleech_percent INTEGER,
-    uploader          TEXT,
-    pre_time          TEXT
-);
-
-CREATE TABLE release_action_status
-(
-		id            INTEGER PRIMARY KEY,
-		status        TEXT,
-		action        TEXT NOT NULL,
-		type          TEXT NOT NULL,
-		rejections    TEXT []   DEFAULT '{}' NOT NULL,
-    	timestamp     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		raw           TEXT,
-		log           TEXT,
-		release_id    INTEGER NOT NULL,
-		FOREIGN KEY (release_id) REFERENCES "release"(id)
-);
-`
-
-var migrations = []string{
-	"",
-	`
-	CREATE TABLE "release"
-	(
-		id                INTEGER PRIMARY KEY,
-		filter_status     TEXT,
-		push_status       TEXT,
-		rejections        TEXT []   DEFAULT '{}' NOT NULL,
-		indexer           TEXT,
-		filter            TEXT,
-		protocol          TEXT,
-		implementation    TEXT,
-		timestamp         TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		group_id          TEXT,
-		torrent_id        TEXT,
-		torrent_name      TEXT,
-		size              INTEGER,
-		raw               TEXT,
-		title             TEXT,
-		category          TEXT,
-		season            INTEGER,
-		episode           INTEGER,
-		year              INTEGER,
-		resolution        TEXT,
-		source            TEXT,
-		codec             TEXT,
-		container         TEXT,
-		hdr               TEXT,
-		audio             TEXT,
-		release_group     TEXT,
-		region            TEXT,
-		language          TEXT,
-		edition           TEXT,
-		unrated           BOOLEAN,
-		hybrid            BOOLEAN,
-		proper            BOOLEAN,
-		repack            BOOLEAN,
-		website           TEXT,
-		artists           TEXT []   DEFAULT '{}' NOT NULL,
-		type              TEXT,
-		format            TEXT,
-		bitrate           TEXT,
-		log_score         INTEGER,
-		has_log           BOOLEAN,
-		has_cue           BOOLEAN,
-		is_scene          BOOLEAN,
-		origin            TEXT,
-		tags              TEXT []   DEFAULT '{}' NOT NULL,
-		freeleech         BOOLEAN,
-		freeleech_percent INTEGER,
-		uploader          TEXT,
-		pre_time          TEXT
-	);
-	`,
-	`
-	CREATE TABLE release_action_status
-	(
-		id            INTEGER PRIMARY KEY,
-		status        TEXT,
-		action        TEXT NOT NULL,
-		type          TEXT NOT NULL,
-		rejections    TEXT []   DEFAULT '{}' NOT NULL,
-    	timestamp     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		raw           TEXT,
-		log           TEXT,
-		release_id    INTEGER NOT NULL,
-		FOREIGN KEY (release_id) REFERENCES "release"(id)
-	);
-
-	INSERT INTO "release_action_status" (status, action, type, timestamp, release_id)
-	SELECT push_status, 'DEFAULT', 'QBITTORRENT', timestamp, id FROM "release";
-
-	ALTER TABLE "release"
-	DROP COLUMN push_status;
-	`,
-	`
-	ALTER TABLE "filter"
-		ADD COLUMN match_hdr TEXT []   DEFAULT '{}';
-
-	ALTER TABLE "filter"
-		ADD COLUMN except_hdr TEXT []   DEFAULT '{}';
-	`,
-	`
-	ALTER TABLE "release"
-		RENAME COLUMN bitrate TO quality;
-
-	ALTER TABLE "filter"
-		ADD COLUMN artists TEXT;
-
-	ALTER TABLE "filter"
-		ADD COLUMN albums TEXT;
-
-	ALTER TABLE "filter"
-		ADD COLUMN release_types_match TEXT []   DEFAULT '{}';
-
-	ALTER TABLE "filter"
-		ADD COLUMN release_types_ignore TEXT []   DEFAULT '{}';
-
-	ALTER TABLE "filter"
-		ADD COLUMN formats TEXT []   DEFAULT '{}';
-
-	ALTER TABLE "filter"
-		ADD COLUMN quality TEXT []   DEFAULT '{}';
-
-	ALTER TABLE "filter"
-		ADD COLUMN log_score INTEGER;
-
-	ALTER TABLE "filter"
-		ADD COLUMN has_log BOOLEAN;
-
-	ALTER TABLE "filter"
-		ADD COLUMN has_cue BOOLEAN;
-
-	ALTER TABLE "filter"
-		ADD COLUMN perfect_flac BOOLEAN;
-	`,
-	`
-	ALTER TABLE "filter"
-		ADD COLUMN media TEXT []   DEFAULT '{}';
-	`,
-	`
-	ALTER TABLE "filter"
-		ADD COLUMN priority INTEGER DEFAULT 0 NOT NULL;
-	`,
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// schema the current schema, new databases start here instead of running every migration
+//
+//go:embed migrations/schema.sql
+var schema string
+
+// migration one schema change. Down reverts it and is empty when the change can't be reverted.
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus a migration and whether the database has it
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied    bool
+	AppliedAt  time.Time // zero when it came with the schema the database was created with
+	Reversible bool
+}
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrations every schema change after the first release, which is version 1
+var migrations = mustLoadMigrations()
+
+func mustLoadMigrations() []migration {
+	m, err := loadMigrations(migrationFiles)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
 }
 
-func (db *SqliteDB) migrate() error {
+// loadMigrations read 0002_name.up.sql and the optional 0002_name.down.sql files from the migrations folder.
+// Versions have to follow each other without gaps, starting at 2.
+func loadMigrations(files fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		if entry.Name() == "schema.sql" {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %v", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(files, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %v and %v", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	for i, m := range list {
+		if m.Version != i+2 {
+			return nil, fmt.Errorf("migration %d missing", i+2)
+		}
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d %v has no up file", m.Version, m.Name)
+		}
+	}
+
+	return list, nil
+}
+
+// latestVersion the schema version this autobrr creates and migrates to
+func latestVersion() int {
+	return len(migrations) + 1
+}
+
+func migrationName(version int) string {
+	switch {
+	case version == 1:
+		return "initial"
+	case version > 1 && version <= latestVersion():
+		return migrations[version-2].Name
+	}
+
+	return "unknown"
+}
+
+// migrationHooks data migrations in go, run after the migration with the same version
+var migrationHooks = map[int]func(tx *handlerTx) error{
+	6:  customMigrateCopySourcesToMedia,
+	58: customMigrateNormalizedReleaseNames,
+	59: customMigrateNormalizedReleaseTitles,
+}
+
+func (db *DB) migrate() error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
+	version, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	latest := latestVersion()
+
+	switch {
+	case version == latest:
+		return nil
+	case version > latest:
+		return fmt.Errorf("database schema version %d is newer than this autobrr knows (%d), revert it with autobrrctl migrate-down %d of the newer autobrr first", version, latest, latest)
+	case version == 0:
+		return db.createSchema(latest)
+	}
+
+	log.Info().Msgf("database schema version %d, migrating to %d", version, latest)
+
+	if err := db.backupBeforeMigrate(version); err != nil {
+		return fmt.Errorf("could not back up database before migrating: %w", err)
+	}
+
+	for _, m := range migrations[version-1:] {
+		if err := db.runMigration(m, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown revert migrations until the database is at version target, before going back to an older autobrr
+func (db *DB) MigrateDown(target int) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	version, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case target < 1:
+		return fmt.Errorf("invalid schema version %d", target)
+	case target > version:
+		return fmt.Errorf("database is at version %d, can't migrate down to %d", version, target)
+	case target == version:
+		return nil
+	case version > latestVersion():
+		return fmt.Errorf("database schema version %d is newer than this autobrr knows (%d)", version, latestVersion())
+	}
+
+	// check everything first, a partial downgrade leaves a schema no release has
+	for v := version; v > target; v-- {
+		if m := migrations[v-2]; m.Down == "" {
+			return fmt.Errorf("migration %d %v can't be reverted", m.Version, m.Name)
+		}
+	}
+
+	if err := db.backupBeforeMigrate(version); err != nil {
+		return fmt.Errorf("could not back up database before migrating: %w", err)
+	}
+
+	for v := version; v > target; v-- {
+		if err := db.runMigration(migrations[v-2], false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Migrations every migration this autobrr knows and whether the database has it
+func (db *DB) Migrations() ([]MigrationStatus, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, err := db.schemaVersion(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.handler.Query(`SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	version := 0
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+
+		appliedAt[v] = at
+		if v > version {
+			version = v
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status = append(status, MigrationStatus{
+			Version:    m.Version,
+			Name:       m.Name,
+			Applied:    m.Version <= version,
+			AppliedAt:  appliedAt[m.Version],
+			Reversible: m.Down != "",
+		})
+	}
+
+	return status, nil
+}
+
+// schemaVersion create the version table when needed and read the version.
+// Sqlite databases from before the table have their version in user_version.
+func (db *DB) schemaVersion() (int, error) {
+	if _, err := db.handler.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)`); err != nil {
+		return 0, fmt.Errorf("failed to create schema version table: %w", err)
+	}
+
 	var version int
+	if err := db.handler.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+
+	if version > 0 || db.postgres() {
+		return version, nil
+	}
+
 	if err := db.handler.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
-		return fmt.Errorf("failed to query schema version: %v", err)
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
 	}
 
-	if version == len(migrations) {
-		return nil
-	} else if version > len(migrations) {
-		return fmt.Errorf("autobrr (version %d) older than schema (version: %d)", len(migrations), version)
+	if version > 0 {
+		if _, err := db.handler.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`, version, migrationName(version), time.Now().UTC()); err != nil {
+			return 0, err
+		}
 	}
 
+	return version, nil
+}
+
+func (db *DB) createSchema(version int) error {
 	tx, err := db.handler.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	if version == 0 {
-		if _, err := tx.Exec(schema); err != nil {
-			return fmt.Errorf("failed to initialize schema: %v", err)
+	if _, err := tx.Exec(db.statements(schema)); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	if err := db.setVersion(tx, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// runMigration apply or revert one migration in its own transaction, a failed one leaves the database at the previous version
+func (db *DB) runMigration(m migration, up bool) error {
+	start := time.Now()
+
+	statements, version, direction := m.Up, m.Version, "applied"
+	if !up {
+		statements, version, direction = m.Down, m.Version-1, "reverted"
+	}
+
+	tx, err := db.handler.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(db.statements(statements)); err != nil {
+		return fmt.Errorf("failed to execute migration %d %v: %w", m.Version, m.Name, err)
+	}
+
+	if hook, ok := migrationHooks[m.Version]; ok && up {
+		if err := hook(tx); err != nil {
+			return fmt.Errorf("could not run data migration %d %v: %w", m.Version, m.Name, err)
 		}
-	} else {
-		for i := version; i < len(migrations); i++ {
-			if _, err := tx.Exec(migrations[i]); err != nil {
-				return fmt.Errorf("failed to execute migration #%v: %v", i, err)
-			}
+	}
+
+	if err := db.setVersion(tx, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("database migration %d %v %v in %v", m.Version, m.Name, direction, time.Since(start))
+
+	return nil
+}
+
+// setVersion record the version, rows of reverted migrations are removed and applied ones keep their time
+func (db *DB) setVersion(tx *handlerTx, version int) error {
+	if _, err := tx.Exec(`DELETE FROM schema_version WHERE version > ?`, version); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?) ON CONFLICT (version) DO NOTHING`, version, migrationName(version), time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+
+	// older autobrr versions read the version from here
+	if !db.postgres() {
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+			return fmt.Errorf("failed to update schema version: %w", err)
 		}
 	}
 
-	// temp custom data migration
-	// get data from filter.sources, check if specific types, move to new table and clear
-	// if migration 6
-	// TODO 2022-01-30 remove this in future version
-	if version == 5 && len(migrations) == 6 {
-		if err := customMigrateCopySourcesToMedia(tx); err != nil {
+	return nil
+}
+
+// backupBeforeMigrate copy a sqlite database to the backups folder before its schema changes.
+// Postgres has its own backup tools and runs each migration in a transaction.
+func (db *DB) backupBeforeMigrate(version int) error {
+	if db.postgres() {
+		return nil
+	}
+
+	dir := filepath.Join(filepath.Dir(db.DSN), "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("autobrr-pre-migration-v%d-%v.db", version, time.Now().UTC().Format("20060102T150405Z")))
+	if _, err := db.handler.Exec("VACUUM INTO ?", path); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("database backed up before migrating: %v", path)
+
+	return nil
+}
+
+// statements the schema and migrations are written for sqlite
+func (db *DB) statements(statements string) string {
+	if db.postgres() {
+		return postgresStatements(statements)
+	}
+
+	return statements
+}
+
+// customMigrateNormalizedReleaseNames fill the normalized name of approved releases, the only ones checked for duplicates
+func customMigrateNormalizedReleaseNames(tx *handlerTx) error {
+	rows, err := tx.Query(`SELECT id, torrent_name FROM "release" WHERE filter_status = ?`, domain.ReleaseStatusFilterApproved)
+	if err != nil {
+		return fmt.Errorf("could not run custom data migration: %v", err)
+	}
+
+	defer rows.Close()
+
+	names := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var name sql.NullString
+
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+
+		names[id] = domain.NormalizeName(name.String)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, name := range names {
+		if _, err := tx.Exec(`UPDATE "release" SET normalized_name = ? WHERE id = ?`, name, id); err != nil {
 			return fmt.Errorf("could not run custom data migration: %v", err)
 		}
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations)))
+	return nil
+}
+
+// customMigrateNormalizedReleaseTitles fill the normalized title of approved releases, the only ones checked for grabbed episodes
+func customMigrateNormalizedReleaseTitles(tx *handlerTx) error {
+	rows, err := tx.Query(`SELECT id, title FROM "release" WHERE filter_status = ?`, domain.ReleaseStatusFilterApproved)
 	if err != nil {
-		return fmt.Errorf("failed to bump schema version: %v", err)
+		return fmt.Errorf("could not run custom data migration: %v", err)
 	}
 
-	return tx.Commit()
+	defer rows.Close()
+
+	titles := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var title sql.NullString
+
+		if err := rows.Scan(&id, &title); err != nil {
+			return err
+		}
+
+		titles[id] = domain.NormalizeName(title.String)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, title := range titles {
+		if _, err := tx.Exec(`UPDATE "release" SET normalized_title = ? WHERE id = ?`, title, id); err != nil {
+			return fmt.Errorf("could not run custom data migration: %v", err)
+		}
+	}
+
+	return nil
 }
 
 // customMigrateCopySourcesToMedia move music specific sources to media
-func customMigrateCopySourcesToMedia(tx *sql.Tx) error {
+func customMigrateCopySourcesToMedia(tx *handlerTx) error {
 	rows, err := tx.Query(`
 		SELECT id, sources
 		FROM filter
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	list, err := loadMigrations(fstest.MapFS{
+		"migrations/schema.sql":             file("CREATE TABLE users (id INTEGER PRIMARY KEY);"),
+		"migrations/0002_feed.up.sql":       file("CREATE TABLE feed (id INTEGER PRIMARY KEY);"),
+		"migrations/0002_feed.down.sql":     file("DROP TABLE feed;"),
+		"migrations/0003_users_role.up.sql": file("ALTER TABLE users ADD COLUMN role TEXT;"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []migration{
+		{Version: 2, Name: "feed", Up: "CREATE TABLE feed (id INTEGER PRIMARY KEY);", Down: "DROP TABLE feed;"},
+		{Version: 3, Name: "users_role", Up: "ALTER TABLE users ADD COLUMN role TEXT;"},
+	}, list)
+
+	_, err = loadMigrations(fstest.MapFS{"migrations/0003_users_role.up.sql": file("ALTER TABLE users ADD COLUMN role TEXT;")})
+	assert.Error(t, err, "gap")
+
+	_, err = loadMigrations(fstest.MapFS{"migrations/0002_feed.down.sql": file("DROP TABLE feed;")})
+	assert.Error(t, err, "no up")
+
+	_, err = loadMigrations(fstest.MapFS{"migrations/feed.sql": file("DROP TABLE feed;")})
+	assert.Error(t, err, "name")
+}
+
+func TestMigrateDown(t *testing.T) {
+	dir := t.TempDir()
+
+	db := NewSqliteDB(dir)
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	want := schemaColumns(t, db)
+
+	// the oldest version every later migration can be reverted to
+	lowest := latestVersion()
+	for lowest > 1 && migrations[lowest-2].Down != "" {
+		lowest--
+	}
+	require.Less(t, lowest, latestVersion())
+
+	require.NoError(t, db.MigrateDown(lowest))
+
+	version, err := db.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, lowest, version)
+
+	assert.Error(t, db.MigrateDown(lowest-1), "not reversible")
+
+	backups, err := filepath.Glob(filepath.Join(dir, "backups", "autobrr-pre-migration-*.db"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	require.NoError(t, db.migrate())
+	assert.Equal(t, want, schemaColumns(t, db))
+
+	status, err := db.Migrations()
+	require.NoError(t, err)
+	require.Len(t, status, len(migrations))
+
+	last := status[len(status)-1]
+	assert.True(t, last.Applied)
+	assert.False(t, last.AppliedAt.IsZero())
+}
+
+func TestMigrate_userVersion(t *testing.T) {
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	// a database from before the version table
+	require.NoError(t, db.MigrateDown(47))
+	_, err := db.handler.Exec(`DROP TABLE schema_version; PRAGMA user_version = 47;`)
+	require.NoError(t, err)
+
+	require.NoError(t, db.migrate())
+
+	version, err := db.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, latestVersion(), version)
+
+	var count int
+	assert.NoError(t, db.handler.QueryRow(`SELECT COUNT(*) FROM auth_audit`).Scan(&count))
+}
+
+// schemaColumns columns by table, sorted because migrations add them at the end
+func schemaColumns(t *testing.T, db *DB) map[string][]string {
+	rows, err := db.handler.Query(`SELECT m.name, p.name FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' ORDER BY m.name, p.name`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns := map[string][]string{}
+	for rows.Next() {
+		var table, column string
+		require.NoError(t, rows.Scan(&table, &column))
+		columns[table] = append(columns[table], column)
+	}
+
+	return columns
+}
+
+func TestMigrate_reannounceDelete(t *testing.T) {
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	// an action from before the reannounce settings keeps deleting unregistered torrents
+	require.NoError(t, db.MigrateDown(20))
+	_, err := db.handler.Exec(`INSERT INTO action (name, type) VALUES ('qbit', 'QBITTORRENT')`)
+	require.NoError(t, err)
+
+	require.NoError(t, db.migrate())
+
+	var reannounceDelete bool
+	require.NoError(t, db.handler.QueryRow(`SELECT reannounce_delete FROM action WHERE name = 'qbit'`).Scan(&reannounceDelete))
+	assert.True(t, reannounceDelete)
+}
+
+func TestMigrate_normalizedReleaseNames(t *testing.T) {
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	// grabbed before duplicates were matched on the normalized name
+	require.NoError(t, db.MigrateDown(57))
+	_, err := db.handler.Exec(`INSERT INTO "release" (filter_status, torrent_name, title) VALUES ('FILTER_APPROVED', 'That.Show.S01E01.1080p-GRP', 'That Show')`)
+	require.NoError(t, err)
+
+	require.NoError(t, db.migrate())
+
+	var name, title string
+	require.NoError(t, db.handler.QueryRow(`SELECT normalized_name, normalized_title FROM "release"`).Scan(&name, &title))
+	assert.Equal(t, "thatshows01e011080pgrp", name)
+	assert.Equal(t, "thatshow", title)
+}
@@ -0,0 +1,51 @@
+CREATE TABLE "release"
+(
+    id                INTEGER PRIMARY KEY,
+    filter_status     TEXT,
+    push_status       TEXT,
+    rejections        TEXT []   DEFAULT '{}' NOT NULL,
+    indexer           TEXT,
+    filter            TEXT,
+    protocol          TEXT,
+    implementation    TEXT,
+    timestamp         TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    group_id          TEXT,
+    torrent_id        TEXT,
+    torrent_name      TEXT,
+    size              INTEGER,
+    raw               TEXT,
+    title             TEXT,
+    category          TEXT,
+    season            INTEGER,
+    episode           INTEGER,
+    year              INTEGER,
+    resolution        TEXT,
+    source            TEXT,
+    codec             TEXT,
+    container         TEXT,
+    hdr               TEXT,
+    audio             TEXT,
+    release_group     TEXT,
+    region            TEXT,
+    language          TEXT,
+    edition           TEXT,
+    unrated           BOOLEAN,
+    hybrid            BOOLEAN,
+    proper            BOOLEAN,
+    repack            BOOLEAN,
+    website           TEXT,
+    artists           TEXT []   DEFAULT '{}' NOT NULL,
+    type              TEXT,
+    format            TEXT,
+    bitrate           TEXT,
+    log_score         INTEGER,
+    has_log           BOOLEAN,
+    has_cue           BOOLEAN,
+    is_scene          BOOLEAN,
+    origin            TEXT,
+    tags              TEXT []   DEFAULT '{}' NOT NULL,
+    freeleech         BOOLEAN,
+    freeleech_percent INTEGER,
+    uploader          TEXT,
+    pre_time          TEXT
+);
@@ -0,0 +1,2 @@
+ALTER TABLE "filter"
+    ADD COLUMN priority INTEGER DEFAULT 0 NOT NULL;
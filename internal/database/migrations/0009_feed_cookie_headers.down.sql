@@ -0,0 +1,8 @@
+ALTER TABLE "feed"
+    DROP COLUMN parse_rules;
+
+ALTER TABLE "feed"
+    DROP COLUMN headers;
+
+ALTER TABLE "feed"
+    DROP COLUMN cookie;
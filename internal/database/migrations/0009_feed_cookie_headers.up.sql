@@ -0,0 +1,8 @@
+ALTER TABLE "feed"
+    ADD COLUMN cookie TEXT;
+
+ALTER TABLE "feed"
+    ADD COLUMN headers TEXT;
+
+ALTER TABLE "feed"
+    ADD COLUMN parse_rules TEXT;
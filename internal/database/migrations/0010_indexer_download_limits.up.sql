@@ -0,0 +1,2 @@
+ALTER TABLE "indexer"
+    ADD COLUMN download_limits TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE "indexer"
+    DROP COLUMN proxy;
@@ -0,0 +1,2 @@
+ALTER TABLE "indexer"
+    ADD COLUMN proxy TEXT;
@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    DROP COLUMN indexer_accounts;
+
+ALTER TABLE "indexer"
+    DROP COLUMN account_selection;
+
+ALTER TABLE "indexer"
+    DROP COLUMN accounts;
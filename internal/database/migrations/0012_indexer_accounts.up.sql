@@ -0,0 +1,8 @@
+ALTER TABLE "indexer"
+    ADD COLUMN accounts TEXT;
+
+ALTER TABLE "indexer"
+    ADD COLUMN account_selection TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN indexer_accounts TEXT;
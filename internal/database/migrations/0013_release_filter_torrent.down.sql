@@ -0,0 +1,11 @@
+ALTER TABLE "release"
+    DROP COLUMN indexer_account;
+
+ALTER TABLE "release"
+    DROP COLUMN magnet_uri;
+
+ALTER TABLE "release"
+    DROP COLUMN torrent_url;
+
+ALTER TABLE "release"
+    DROP COLUMN filter_id;
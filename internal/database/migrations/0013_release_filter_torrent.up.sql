@@ -0,0 +1,11 @@
+ALTER TABLE "release"
+    ADD COLUMN filter_id INTEGER;
+
+ALTER TABLE "release"
+    ADD COLUMN torrent_url TEXT;
+
+ALTER TABLE "release"
+    ADD COLUMN magnet_uri TEXT;
+
+ALTER TABLE "release"
+    ADD COLUMN indexer_account TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE "client"
+    DROP COLUMN tls_skip_verify;
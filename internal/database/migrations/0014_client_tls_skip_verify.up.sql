@@ -0,0 +1,2 @@
+ALTER TABLE "client"
+    ADD COLUMN tls_skip_verify BOOLEAN DEFAULT FALSE;
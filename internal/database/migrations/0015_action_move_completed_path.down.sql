@@ -0,0 +1,5 @@
+ALTER TABLE "action"
+    DROP COLUMN bandwidth_priority;
+
+ALTER TABLE "action"
+    DROP COLUMN move_completed_path;
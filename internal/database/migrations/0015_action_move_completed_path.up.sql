@@ -0,0 +1,5 @@
+ALTER TABLE "action"
+    ADD COLUMN move_completed_path TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN bandwidth_priority INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    DROP COLUMN fast_resume;
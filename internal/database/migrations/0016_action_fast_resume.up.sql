@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN fast_resume BOOLEAN;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    DROP COLUMN watch_folder_file_name;
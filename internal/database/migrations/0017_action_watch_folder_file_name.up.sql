@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN watch_folder_file_name TEXT;
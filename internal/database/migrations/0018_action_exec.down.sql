@@ -0,0 +1,8 @@
+ALTER TABLE "action"
+    DROP COLUMN exec_timeout;
+
+ALTER TABLE "action"
+    DROP COLUMN exec_env;
+
+ALTER TABLE "action"
+    DROP COLUMN exec_work_dir;
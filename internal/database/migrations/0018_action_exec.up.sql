@@ -0,0 +1,8 @@
+ALTER TABLE "action"
+    ADD COLUMN exec_work_dir TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN exec_env TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN exec_timeout INTEGER;
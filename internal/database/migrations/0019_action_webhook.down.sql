@@ -0,0 +1,20 @@
+ALTER TABLE "action"
+    DROP COLUMN webhook_retry_delay;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_retry_attempts;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_headers;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_data;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_method;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_type;
+
+ALTER TABLE "action"
+    DROP COLUMN webhook_host;
@@ -0,0 +1,20 @@
+ALTER TABLE "action"
+    ADD COLUMN webhook_host TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_type TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_method TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_data TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_headers TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_retry_attempts INTEGER;
+
+ALTER TABLE "action"
+    ADD COLUMN webhook_retry_delay INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN run_condition TEXT;
@@ -0,0 +1,11 @@
+ALTER TABLE "action"
+    DROP COLUMN reannounce_max_attempts;
+
+ALTER TABLE "action"
+    DROP COLUMN reannounce_interval;
+
+ALTER TABLE "action"
+    DROP COLUMN reannounce_delete;
+
+ALTER TABLE "action"
+    DROP COLUMN reannounce_skip;
@@ -0,0 +1,11 @@
+ALTER TABLE "action"
+    ADD COLUMN reannounce_skip BOOLEAN DEFAULT false;
+
+ALTER TABLE "action"
+    ADD COLUMN reannounce_delete BOOLEAN DEFAULT true;
+
+ALTER TABLE "action"
+    ADD COLUMN reannounce_interval INTEGER DEFAULT 7;
+
+ALTER TABLE "action"
+    ADD COLUMN reannounce_max_attempts INTEGER DEFAULT 50;
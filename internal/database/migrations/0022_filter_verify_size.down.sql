@@ -0,0 +1,2 @@
+ALTER TABLE "filter"
+    DROP COLUMN verify_size;
@@ -0,0 +1,2 @@
+ALTER TABLE "filter"
+    ADD COLUMN verify_size BOOLEAN DEFAULT false;
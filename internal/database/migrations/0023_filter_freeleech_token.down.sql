@@ -0,0 +1,8 @@
+ALTER TABLE "release"
+    DROP COLUMN freeleech_token;
+
+ALTER TABLE "filter"
+    DROP COLUMN freeleech_token_min_size;
+
+ALTER TABLE "filter"
+    DROP COLUMN use_freeleech_token;
@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    ADD COLUMN use_freeleech_token BOOLEAN DEFAULT false;
+
+ALTER TABLE "filter"
+    ADD COLUMN freeleech_token_min_size TEXT;
+
+ALTER TABLE "release"
+    ADD COLUMN freeleech_token BOOLEAN DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN sequential_download BOOLEAN DEFAULT false;
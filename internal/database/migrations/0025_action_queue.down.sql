@@ -0,0 +1,10 @@
+DROP TABLE action_queue;
+
+ALTER TABLE "action"
+    DROP COLUMN schedule_end;
+
+ALTER TABLE "action"
+    DROP COLUMN schedule_start;
+
+ALTER TABLE "action"
+    DROP COLUMN delay;
@@ -0,0 +1,20 @@
+ALTER TABLE "action"
+    ADD COLUMN delay INTEGER DEFAULT 0;
+
+ALTER TABLE "action"
+    ADD COLUMN schedule_start TEXT;
+
+ALTER TABLE "action"
+    ADD COLUMN schedule_end TEXT;
+
+CREATE TABLE action_queue
+(
+    id         INTEGER PRIMARY KEY,
+    release_id INTEGER NOT NULL,
+    action_id  INTEGER NOT NULL,
+    last_ok    BOOLEAN DEFAULT true,
+    run_at     TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (release_id) REFERENCES "release"(id) ON DELETE CASCADE,
+    FOREIGN KEY (action_id) REFERENCES action(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    DROP COLUMN quota_bytes_per_day;
+
+ALTER TABLE "filter"
+    DROP COLUMN quota_grabs_per_day;
+
+ALTER TABLE "filter"
+    DROP COLUMN quota_grabs_per_hour;
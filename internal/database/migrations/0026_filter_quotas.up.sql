@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    ADD COLUMN quota_grabs_per_hour INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN quota_grabs_per_day INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN quota_bytes_per_day TEXT;
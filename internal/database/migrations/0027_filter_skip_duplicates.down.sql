@@ -0,0 +1,11 @@
+ALTER TABLE "filter"
+    DROP COLUMN duplicate_prefer_indexers;
+
+ALTER TABLE "filter"
+    DROP COLUMN duplicate_window;
+
+ALTER TABLE "filter"
+    DROP COLUMN skip_duplicates;
+
+ALTER TABLE "release"
+    DROP COLUMN info_hash;
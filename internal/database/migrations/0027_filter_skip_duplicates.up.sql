@@ -0,0 +1,11 @@
+ALTER TABLE "release"
+    ADD COLUMN info_hash TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN skip_duplicates BOOLEAN DEFAULT false;
+
+ALTER TABLE "filter"
+    ADD COLUMN duplicate_window INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN duplicate_prefer_indexers TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    DROP COLUMN cross_seed;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN cross_seed BOOLEAN DEFAULT false;
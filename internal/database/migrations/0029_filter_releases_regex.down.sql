@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN except_releases_regex;
+
+ALTER TABLE "filter"
+    DROP COLUMN match_releases_regex;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN match_releases_regex TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN except_releases_regex TEXT;
@@ -0,0 +1,17 @@
+ALTER TABLE "filter"
+    DROP COLUMN external_script_timeout;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_script_expect_output;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_script_expect_status;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_script_args;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_script_cmd;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_script_enabled;
@@ -0,0 +1,17 @@
+ALTER TABLE "filter"
+    ADD COLUMN external_script_enabled BOOLEAN DEFAULT false;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_script_cmd TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_script_args TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_script_expect_status INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_script_expect_output TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_script_timeout INTEGER DEFAULT 0;
@@ -0,0 +1,14 @@
+ALTER TABLE "filter"
+    DROP COLUMN external_webhook_expect_field;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_webhook_expect_status;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_webhook_data;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_webhook_host;
+
+ALTER TABLE "filter"
+    DROP COLUMN external_webhook_enabled;
@@ -0,0 +1,14 @@
+ALTER TABLE "filter"
+    ADD COLUMN external_webhook_enabled BOOLEAN DEFAULT false;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_webhook_host TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_webhook_data TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_webhook_expect_status INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN external_webhook_expect_field TEXT;
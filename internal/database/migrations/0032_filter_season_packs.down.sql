@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN daily_max_age;
+
+ALTER TABLE "filter"
+    DROP COLUMN season_packs;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN season_packs TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN daily_max_age INTEGER DEFAULT 0;
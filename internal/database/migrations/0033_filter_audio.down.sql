@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    DROP COLUMN audio_channels;
+
+ALTER TABLE "filter"
+    DROP COLUMN except_audio;
+
+ALTER TABLE "filter"
+    DROP COLUMN match_audio;
@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    ADD COLUMN match_audio TEXT []   DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN except_audio TEXT []   DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN audio_channels TEXT []   DEFAULT '{}';
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN except_origins;
+
+ALTER TABLE "filter"
+    DROP COLUMN origins;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN origins TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN except_origins TEXT;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN except_tags_match_logic;
+
+ALTER TABLE "filter"
+    DROP COLUMN tags_match_logic;
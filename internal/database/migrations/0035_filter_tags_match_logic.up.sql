@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN tags_match_logic TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN except_tags_match_logic TEXT;
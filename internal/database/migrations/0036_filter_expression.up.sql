@@ -0,0 +1,2 @@
+ALTER TABLE "filter"
+    ADD COLUMN expression TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE "filter"
+    DROP COLUMN indexer_overrides;
@@ -0,0 +1,10 @@
+CREATE TABLE filter_stats
+(
+    filter_id         INTEGER PRIMARY KEY,
+    evaluated         INTEGER DEFAULT 0 NOT NULL,
+    matched           INTEGER DEFAULT 0 NOT NULL,
+    actions_succeeded INTEGER DEFAULT 0 NOT NULL,
+    actions_failed    INTEGER DEFAULT 0 NOT NULL,
+    last_match_at     TIMESTAMP,
+    FOREIGN KEY (filter_id) REFERENCES filter(id) ON DELETE CASCADE
+);
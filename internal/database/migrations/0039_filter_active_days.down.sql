@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    DROP COLUMN active_end;
+
+ALTER TABLE "filter"
+    DROP COLUMN active_start;
+
+ALTER TABLE "filter"
+    DROP COLUMN active_days;
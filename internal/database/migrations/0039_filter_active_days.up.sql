@@ -0,0 +1,8 @@
+ALTER TABLE "filter"
+    ADD COLUMN active_days TEXT [] DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN active_start TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN active_end TEXT;
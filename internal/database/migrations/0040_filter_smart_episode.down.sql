@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN smart_episode_window;
+
+ALTER TABLE "filter"
+    DROP COLUMN smart_episode;
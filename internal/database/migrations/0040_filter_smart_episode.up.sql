@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN smart_episode BOOLEAN DEFAULT false;
+
+ALTER TABLE "filter"
+    ADD COLUMN smart_episode_window INTEGER DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN quota_grabs_per_month;
+
+ALTER TABLE "filter"
+    DROP COLUMN quota_grabs_per_week;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN quota_grabs_per_week INTEGER DEFAULT 0;
+
+ALTER TABLE "filter"
+    ADD COLUMN quota_grabs_per_month INTEGER DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN min_keyword_score;
+
+ALTER TABLE "filter"
+    DROP COLUMN keyword_scores;
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN keyword_scores TEXT DEFAULT '';
+
+ALTER TABLE "filter"
+    ADD COLUMN min_keyword_score INTEGER DEFAULT 0;
@@ -0,0 +1,14 @@
+ALTER TABLE "filter"
+    DROP COLUMN subtitle_type;
+
+ALTER TABLE "filter"
+    DROP COLUMN except_subtitles;
+
+ALTER TABLE "filter"
+    DROP COLUMN match_subtitles;
+
+ALTER TABLE "filter"
+    DROP COLUMN except_languages;
+
+ALTER TABLE "filter"
+    DROP COLUMN match_languages;
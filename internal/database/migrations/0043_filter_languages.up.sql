@@ -0,0 +1,14 @@
+ALTER TABLE "filter"
+    ADD COLUMN match_languages TEXT [] DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN except_languages TEXT [] DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN match_subtitles TEXT [] DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN except_subtitles TEXT [] DEFAULT '{}';
+
+ALTER TABLE "filter"
+    ADD COLUMN subtitle_type TEXT DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    DROP COLUMN except_events;
+
+ALTER TABLE "filter"
+    DROP COLUMN match_events;
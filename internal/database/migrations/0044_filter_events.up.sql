@@ -0,0 +1,5 @@
+ALTER TABLE "filter"
+    ADD COLUMN match_events TEXT;
+
+ALTER TABLE "filter"
+    ADD COLUMN except_events TEXT;
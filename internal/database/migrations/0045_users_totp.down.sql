@@ -0,0 +1,8 @@
+ALTER TABLE users
+    DROP COLUMN recovery_codes;
+
+ALTER TABLE users
+    DROP COLUMN totp_enabled;
+
+ALTER TABLE users
+    DROP COLUMN totp_secret;
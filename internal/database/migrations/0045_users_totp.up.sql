@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT DEFAULT '';
+
+ALTER TABLE users
+    ADD COLUMN totp_enabled BOOLEAN DEFAULT false;
+
+ALTER TABLE users
+    ADD COLUMN recovery_codes TEXT [] DEFAULT '{}' NOT NULL;
@@ -0,0 +1,17 @@
+CREATE TABLE sessions
+(
+    id           INTEGER PRIMARY KEY,
+    token_hash   TEXT NOT NULL,
+    username     TEXT NOT NULL,
+    role         TEXT,
+    auth_method  TEXT,
+    ip           TEXT,
+    user_agent   TEXT,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at   TIMESTAMP NOT NULL,
+    UNIQUE (token_hash)
+);
+
+CREATE INDEX sessions_username_index
+    ON sessions (username);
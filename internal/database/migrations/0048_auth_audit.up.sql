@@ -0,0 +1,14 @@
+CREATE TABLE auth_audit
+(
+    id          INTEGER PRIMARY KEY,
+    type        TEXT NOT NULL,
+    username    TEXT,
+    method      TEXT,
+    ip          TEXT,
+    user_agent  TEXT,
+    message     TEXT,
+    timestamp   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX auth_audit_timestamp_index
+    ON auth_audit (timestamp);
@@ -0,0 +1,6 @@
+CREATE TABLE encryption_key
+(
+    id          INTEGER PRIMARY KEY,
+    wrapped_key TEXT NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+CREATE TABLE notification
+(
+    id         INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    enabled    BOOLEAN,
+    events     TEXT []   DEFAULT '{}' NOT NULL,
+    settings   TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+ALTER TABLE notification
+    DROP COLUMN indexers;
+
+ALTER TABLE notification
+    DROP COLUMN filters;
@@ -0,0 +1,5 @@
+ALTER TABLE notification
+    ADD COLUMN filters INTEGER [] DEFAULT '{}' NOT NULL;
+
+ALTER TABLE notification
+    ADD COLUMN indexers TEXT [] DEFAULT '{}' NOT NULL;
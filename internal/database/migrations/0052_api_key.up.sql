@@ -0,0 +1,11 @@
+CREATE TABLE api_key
+(
+    id           INTEGER PRIMARY KEY,
+    name         TEXT NOT NULL,
+    username     TEXT NOT NULL,
+    key_hash     TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    UNIQUE (key_hash)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE action_queue
+    DROP COLUMN attempts;
+
+ALTER TABLE action_queue
+    DROP COLUMN scheduled;
@@ -0,0 +1,5 @@
+ALTER TABLE action_queue
+    ADD COLUMN scheduled BOOLEAN DEFAULT true NOT NULL;
+
+ALTER TABLE action_queue
+    ADD COLUMN attempts INTEGER DEFAULT 0 NOT NULL;
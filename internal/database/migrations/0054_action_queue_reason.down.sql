@@ -0,0 +1,2 @@
+ALTER TABLE action_queue
+    DROP COLUMN reason;
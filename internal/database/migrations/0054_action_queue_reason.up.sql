@@ -0,0 +1,2 @@
+ALTER TABLE action_queue
+    ADD COLUMN reason TEXT DEFAULT '' NOT NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE "release"
+    DROP COLUMN push_us;
+
+ALTER TABLE "release"
+    DROP COLUMN fetch_us;
+
+ALTER TABLE "release"
+    DROP COLUMN filter_us;
+
+ALTER TABLE "release"
+    DROP COLUMN parse_us;
@@ -0,0 +1,11 @@
+ALTER TABLE "release"
+    ADD COLUMN parse_us INTEGER;
+
+ALTER TABLE "release"
+    ADD COLUMN filter_us INTEGER;
+
+ALTER TABLE "release"
+    ADD COLUMN fetch_us INTEGER;
+
+ALTER TABLE "release"
+    ADD COLUMN push_us INTEGER;
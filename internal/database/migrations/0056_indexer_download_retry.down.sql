@@ -0,0 +1,2 @@
+ALTER TABLE "indexer"
+    DROP COLUMN download_retry;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    DROP COLUMN skip_hash_check;
@@ -0,0 +1,2 @@
+ALTER TABLE "action"
+    ADD COLUMN skip_hash_check BOOLEAN DEFAULT false;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS release_normalized_name_index;
+
+ALTER TABLE "release"
+    DROP COLUMN normalized_name;
@@ -0,0 +1,5 @@
+ALTER TABLE "release"
+    ADD COLUMN normalized_name TEXT;
+
+CREATE INDEX release_normalized_name_index
+    ON "release" (normalized_name);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS release_normalized_title_index;
+
+ALTER TABLE "release"
+    DROP COLUMN normalized_title;
@@ -0,0 +1,5 @@
+ALTER TABLE "release"
+    ADD COLUMN normalized_title TEXT;
+
+CREATE INDEX release_normalized_title_index
+    ON "release" (normalized_title);
@@ -0,0 +1 @@
+DROP TABLE announce_queue;
@@ -0,0 +1,7 @@
+CREATE TABLE announce_queue
+(
+    id         INTEGER PRIMARY KEY,
+    indexer    TEXT NOT NULL,
+    lines      TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+ALTER TABLE filter
+    DROP COLUMN paused_by_indexer;
+
+ALTER TABLE indexer
+    DROP COLUMN credentials_invalid;
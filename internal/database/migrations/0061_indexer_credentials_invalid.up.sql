@@ -0,0 +1,5 @@
+ALTER TABLE indexer
+    ADD COLUMN credentials_invalid TEXT;
+
+ALTER TABLE filter
+    ADD COLUMN paused_by_indexer TEXT;
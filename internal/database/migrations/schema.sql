@@ -0,0 +1,445 @@
+CREATE TABLE users
+(
+    id             INTEGER PRIMARY KEY,
+    username       TEXT NOT NULL,
+    password       TEXT NOT NULL,
+    role           TEXT DEFAULT 'admin' NOT NULL,
+    totp_secret    TEXT DEFAULT '',
+    totp_enabled   BOOLEAN DEFAULT false,
+    recovery_codes TEXT [] DEFAULT '{}' NOT NULL,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (username)
+);
+
+CREATE TABLE sessions
+(
+    id           INTEGER PRIMARY KEY,
+    token_hash   TEXT NOT NULL,
+    username     TEXT NOT NULL,
+    role         TEXT,
+    auth_method  TEXT,
+    ip           TEXT,
+    user_agent   TEXT,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at   TIMESTAMP NOT NULL,
+    UNIQUE (token_hash)
+);
+
+CREATE INDEX sessions_username_index
+    ON sessions (username);
+
+CREATE TABLE auth_audit
+(
+    id          INTEGER PRIMARY KEY,
+    type        TEXT NOT NULL,
+    username    TEXT,
+    method      TEXT,
+    ip          TEXT,
+    user_agent  TEXT,
+    message     TEXT,
+    timestamp   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX auth_audit_timestamp_index
+    ON auth_audit (timestamp);
+
+CREATE TABLE indexer
+(
+    id         INTEGER PRIMARY KEY,
+    identifier     TEXT,
+    implementation TEXT,
+    enabled        BOOLEAN,
+    name           TEXT NOT NULL,
+    settings       TEXT,
+    download_limits TEXT,
+    download_retry TEXT,
+    proxy          TEXT,
+    accounts       TEXT,
+    account_selection TEXT,
+    credentials_invalid TEXT,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (identifier)
+);
+
+CREATE TABLE irc_network
+(
+    id                  INTEGER PRIMARY KEY,
+    enabled             BOOLEAN,
+    name                TEXT NOT NULL,
+    server              TEXT NOT NULL,
+    port                INTEGER NOT NULL,
+    tls                 BOOLEAN,
+    pass                TEXT,
+    invite_command      TEXT,
+    nickserv_account    TEXT,
+    nickserv_password   TEXT,
+    connected           BOOLEAN,
+    connected_since     TIMESTAMP,
+    created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (server, port, nickserv_account)
+);
+
+CREATE TABLE irc_channel
+(
+    id          INTEGER PRIMARY KEY,
+    enabled     BOOLEAN,
+    name        TEXT NOT NULL,
+    password    TEXT,
+    detached    BOOLEAN,
+    network_id  INTEGER NOT NULL,
+    FOREIGN KEY (network_id) REFERENCES irc_network(id),
+    UNIQUE (network_id, name)
+);
+
+CREATE TABLE filter
+(
+    id                    INTEGER PRIMARY KEY,
+    enabled               BOOLEAN,
+    name                  TEXT NOT NULL,
+    min_size              TEXT,
+    max_size              TEXT,
+    delay                 INTEGER,
+    priority              INTEGER DEFAULT 0 NOT NULL,
+    match_releases        TEXT,
+    except_releases       TEXT,
+    use_regex             BOOLEAN,
+    match_release_groups  TEXT,
+    except_release_groups TEXT,
+    scene                 BOOLEAN,
+    freeleech             BOOLEAN,
+    freeleech_percent     TEXT,
+    shows                 TEXT,
+    seasons               TEXT,
+    episodes              TEXT,
+    resolutions           TEXT []   DEFAULT '{}' NOT NULL,
+    codecs                TEXT []   DEFAULT '{}' NOT NULL,
+    sources               TEXT []   DEFAULT '{}' NOT NULL,
+    containers            TEXT []   DEFAULT '{}' NOT NULL,
+    match_hdr             TEXT []   DEFAULT '{}',
+    except_hdr            TEXT []   DEFAULT '{}',
+    years                 TEXT,
+    artists               TEXT,
+    albums                TEXT,
+    release_types_match   TEXT []   DEFAULT '{}',
+    release_types_ignore  TEXT []   DEFAULT '{}',
+    formats               TEXT []   DEFAULT '{}',
+    quality               TEXT []   DEFAULT '{}',
+	media 				  TEXT []   DEFAULT '{}',
+    log_score             INTEGER,
+    has_log               BOOLEAN,
+    has_cue               BOOLEAN,
+    perfect_flac          BOOLEAN,
+    match_categories      TEXT,
+    except_categories     TEXT,
+    match_uploaders       TEXT,
+    except_uploaders      TEXT,
+    tags                  TEXT,
+    except_tags           TEXT,
+    indexer_accounts      TEXT,
+    verify_size           BOOLEAN DEFAULT false,
+    use_freeleech_token   BOOLEAN DEFAULT false,
+    freeleech_token_min_size TEXT,
+    quota_grabs_per_hour  INTEGER DEFAULT 0,
+    quota_grabs_per_day   INTEGER DEFAULT 0,
+    quota_bytes_per_day   TEXT,
+    skip_duplicates       BOOLEAN DEFAULT false,
+    duplicate_window      INTEGER DEFAULT 0,
+    duplicate_prefer_indexers TEXT,
+    match_releases_regex  TEXT,
+    except_releases_regex TEXT,
+    external_script_enabled BOOLEAN DEFAULT false,
+    external_script_cmd   TEXT,
+    external_script_args  TEXT,
+    external_script_expect_status INTEGER DEFAULT 0,
+    external_script_expect_output TEXT,
+    external_script_timeout INTEGER DEFAULT 0,
+    external_webhook_enabled BOOLEAN DEFAULT false,
+    external_webhook_host TEXT,
+    external_webhook_data TEXT,
+    external_webhook_expect_status INTEGER DEFAULT 0,
+    external_webhook_expect_field TEXT,
+    season_packs          TEXT,
+    daily_max_age         INTEGER DEFAULT 0,
+    match_audio           TEXT []   DEFAULT '{}',
+    except_audio          TEXT []   DEFAULT '{}',
+    audio_channels        TEXT []   DEFAULT '{}',
+    origins               TEXT,
+    except_origins        TEXT,
+    tags_match_logic      TEXT,
+    except_tags_match_logic TEXT,
+    expression            TEXT,
+    indexer_overrides     TEXT,
+    active_days           TEXT []   DEFAULT '{}',
+    active_start          TEXT,
+    active_end            TEXT,
+    smart_episode         BOOLEAN DEFAULT false,
+    smart_episode_window  INTEGER DEFAULT 0,
+    quota_grabs_per_week  INTEGER DEFAULT 0,
+    quota_grabs_per_month INTEGER DEFAULT 0,
+    keyword_scores        TEXT DEFAULT '',
+    min_keyword_score     INTEGER DEFAULT 0,
+    match_languages       TEXT []   DEFAULT '{}',
+    except_languages      TEXT []   DEFAULT '{}',
+    match_subtitles       TEXT []   DEFAULT '{}',
+    except_subtitles      TEXT []   DEFAULT '{}',
+    subtitle_type         TEXT DEFAULT '',
+    match_events          TEXT,
+    except_events         TEXT,
+    paused_by_indexer     TEXT,
+    created_at            TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at            TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE filter_indexer
+(
+    filter_id  INTEGER,
+    indexer_id INTEGER,
+    FOREIGN KEY (filter_id) REFERENCES filter(id),
+    FOREIGN KEY (indexer_id) REFERENCES indexer(id),
+    PRIMARY KEY (filter_id, indexer_id)
+);
+
+CREATE TABLE client
+(
+    id       INTEGER PRIMARY KEY,
+    name     TEXT NOT NULL,
+    enabled  BOOLEAN,
+    type     TEXT,
+    host     TEXT NOT NULL,
+    port     INTEGER,
+    ssl      BOOLEAN,
+    tls_skip_verify BOOLEAN DEFAULT FALSE,
+    username TEXT,
+    password TEXT,
+    settings JSON
+);
+
+CREATE TABLE action
+(
+    id                   INTEGER PRIMARY KEY,
+    name                 TEXT,
+    type                 TEXT,
+    enabled              BOOLEAN,
+    exec_cmd             TEXT,
+    exec_args            TEXT,
+    exec_work_dir        TEXT,
+    exec_env             TEXT,
+    exec_timeout         INTEGER,
+    watch_folder         TEXT,
+    watch_folder_file_name TEXT,
+    category             TEXT,
+    tags                 TEXT,
+    label                TEXT,
+    save_path            TEXT,
+    move_completed_path  TEXT,
+    paused               BOOLEAN,
+    fast_resume          BOOLEAN,
+    ignore_rules         BOOLEAN,
+    limit_upload_speed   INT,
+    limit_download_speed INT,
+    bandwidth_priority   INTEGER,
+    webhook_host         TEXT,
+    webhook_type         TEXT,
+    webhook_method       TEXT,
+    webhook_data         TEXT,
+    webhook_headers      TEXT,
+    webhook_retry_attempts INTEGER,
+    webhook_retry_delay  INTEGER,
+    run_condition        TEXT,
+    reannounce_skip      BOOLEAN DEFAULT false,
+    reannounce_delete    BOOLEAN DEFAULT true,
+    reannounce_interval  INTEGER DEFAULT 7,
+    reannounce_max_attempts INTEGER DEFAULT 50,
+    sequential_download  BOOLEAN DEFAULT false,
+    skip_hash_check      BOOLEAN DEFAULT false,
+    cross_seed           BOOLEAN DEFAULT false,
+    delay                INTEGER DEFAULT 0,
+    schedule_start       TEXT,
+    schedule_end         TEXT,
+    client_id            INTEGER,
+    filter_id            INTEGER,
+    FOREIGN KEY (client_id) REFERENCES client(id),
+    FOREIGN KEY (filter_id) REFERENCES filter(id)
+);
+
+CREATE TABLE "release"
+(
+    id                INTEGER PRIMARY KEY,
+    filter_status     TEXT,
+    rejections        TEXT []   DEFAULT '{}' NOT NULL,
+    indexer           TEXT,
+    filter            TEXT,
+    protocol          TEXT,
+    implementation    TEXT,
+    timestamp         TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    group_id          TEXT,
+    torrent_id        TEXT,
+    torrent_name      TEXT,
+    size              INTEGER,
+    raw               TEXT,
+    title             TEXT,
+    category          TEXT,
+    season            INTEGER,
+    episode           INTEGER,
+    year              INTEGER,
+    resolution        TEXT,
+    source            TEXT,
+    codec             TEXT,
+    container         TEXT,
+    hdr               TEXT,
+    audio             TEXT,
+    release_group     TEXT,
+    region            TEXT,
+    language          TEXT,
+    edition           TEXT,
+    unrated           BOOLEAN,
+    hybrid            BOOLEAN,
+    proper            BOOLEAN,
+    repack            BOOLEAN,
+    website           TEXT,
+    artists           TEXT []   DEFAULT '{}' NOT NULL,
+    type              TEXT,
+    format            TEXT,
+    quality           TEXT,
+    log_score         INTEGER,
+    has_log           BOOLEAN,
+    has_cue           BOOLEAN,
+    is_scene          BOOLEAN,
+    origin            TEXT,
+    tags              TEXT []   DEFAULT '{}' NOT NULL,
+    freeleech         BOOLEAN,
+    freeleech_percent INTEGER,
+    uploader          TEXT,
+    pre_time          TEXT,
+    filter_id         INTEGER,
+    torrent_url       TEXT,
+    magnet_uri        TEXT,
+    indexer_account   TEXT,
+    freeleech_token   BOOLEAN DEFAULT false,
+    info_hash         TEXT,
+    parse_us          INTEGER,
+    filter_us         INTEGER,
+    fetch_us          INTEGER,
+    push_us           INTEGER,
+    normalized_name   TEXT,
+    normalized_title  TEXT
+);
+
+CREATE INDEX release_normalized_name_index
+    ON "release" (normalized_name);
+
+CREATE INDEX release_normalized_title_index
+    ON "release" (normalized_title);
+
+CREATE TABLE release_action_status
+(
+		id            INTEGER PRIMARY KEY,
+		status        TEXT,
+		action        TEXT NOT NULL,
+		type          TEXT NOT NULL,
+		rejections    TEXT []   DEFAULT '{}' NOT NULL,
+    	timestamp     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		raw           TEXT,
+		log           TEXT,
+		release_id    INTEGER NOT NULL,
+		FOREIGN KEY (release_id) REFERENCES "release"(id)
+);
+
+CREATE TABLE feed
+(
+    id           INTEGER PRIMARY KEY,
+    indexer      TEXT,
+    name         TEXT,
+    type         TEXT,
+    enabled      BOOLEAN,
+    url          TEXT,
+    interval     INTEGER,
+    api_key      TEXT,
+    cookie       TEXT,
+    headers      TEXT,
+    parse_rules  TEXT,
+    settings     TEXT,
+    indexer_id   INTEGER,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (indexer_id) REFERENCES indexer(id) ON DELETE SET NULL
+);
+
+CREATE TABLE feed_cache
+(
+    bucket TEXT,
+    key    TEXT,
+    value  TEXT,
+    ttl    TIMESTAMP
+);
+
+CREATE TABLE action_queue
+(
+    id         INTEGER PRIMARY KEY,
+    release_id INTEGER NOT NULL,
+    action_id  INTEGER NOT NULL,
+    last_ok    BOOLEAN DEFAULT true,
+    run_at     TIMESTAMP,
+    scheduled  BOOLEAN DEFAULT true NOT NULL,
+    attempts   INTEGER DEFAULT 0 NOT NULL,
+    reason     TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (release_id) REFERENCES "release"(id) ON DELETE CASCADE,
+    FOREIGN KEY (action_id) REFERENCES action(id) ON DELETE CASCADE
+);
+
+CREATE TABLE announce_queue
+(
+    id         INTEGER PRIMARY KEY,
+    indexer    TEXT NOT NULL,
+    lines      TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE filter_stats
+(
+    filter_id         INTEGER PRIMARY KEY,
+    evaluated         INTEGER DEFAULT 0 NOT NULL,
+    matched           INTEGER DEFAULT 0 NOT NULL,
+    actions_succeeded INTEGER DEFAULT 0 NOT NULL,
+    actions_failed    INTEGER DEFAULT 0 NOT NULL,
+    last_match_at     TIMESTAMP,
+    FOREIGN KEY (filter_id) REFERENCES filter(id) ON DELETE CASCADE
+);
+
+CREATE TABLE encryption_key
+(
+    id          INTEGER PRIMARY KEY,
+    wrapped_key TEXT NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE notification
+(
+    id         INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    enabled    BOOLEAN,
+    events     TEXT []   DEFAULT '{}' NOT NULL,
+    filters    INTEGER [] DEFAULT '{}' NOT NULL,
+    indexers   TEXT []   DEFAULT '{}' NOT NULL,
+    settings   TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE api_key
+(
+    id           INTEGER PRIMARY KEY,
+    name         TEXT NOT NULL,
+    username     TEXT NOT NULL,
+    key_hash     TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    UNIQUE (key_hash)
+);
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type NotificationRepo struct {
+	db *DB
+}
+
+func NewNotificationRepo(db *DB) domain.NotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+func (r *NotificationRepo) List(ctx context.Context) ([]domain.Notification, error) {
+	query, args, err := r.baseQuery().OrderBy("name ASC").ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.List: error building query")
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.List: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	notifications := make([]domain.Notification, 0)
+	for rows.Next() {
+		n, err := r.scanNotification(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("notification.List: error scanning row")
+			return nil, err
+		}
+
+		notifications = append(notifications, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepo) FindByID(ctx context.Context, id int) (*domain.Notification, error) {
+	query, args, err := r.baseQuery().Where("id = ?", id).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.FindByID: error building query")
+		return nil, err
+	}
+
+	n, err := r.scanNotification(r.db.handler.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.FindByID: error scanning row")
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (r *NotificationRepo) Store(ctx context.Context, notification *domain.Notification) error {
+	settings, err := json.Marshal(notification.Settings)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sq.
+		Insert("notification").
+		Columns("name", "type", "enabled", "events", "filters", "indexers", "settings").
+		Values(notification.Name, notification.Type, notification.Enabled, pq.Array(eventStrings(notification.Events)), pq.Array(filterIDs(notification.Filters)), pq.Array(nonNil(notification.Indexers)), r.db.secret(settings)).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Store: error building query")
+		return err
+	}
+
+	if err := r.db.handler.QueryRowContext(ctx, query, args...).Scan(&notification.ID); err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Store: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *NotificationRepo) Update(ctx context.Context, notification *domain.Notification) error {
+	settings, err := json.Marshal(notification.Settings)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sq.
+		Update("notification").
+		Set("name", notification.Name).
+		Set("type", notification.Type).
+		Set("enabled", notification.Enabled).
+		Set("events", pq.Array(eventStrings(notification.Events))).
+		Set("filters", pq.Array(filterIDs(notification.Filters))).
+		Set("indexers", pq.Array(nonNil(notification.Indexers))).
+		Set("settings", r.db.secret(settings)).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where("id = ?", notification.ID).
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Update: error building query")
+		return err
+	}
+
+	res, err := r.db.handler.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Update: error executing query")
+		return err
+	}
+
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *NotificationRepo) Delete(ctx context.Context, id int) error {
+	query, args, err := sq.Delete("notification").Where("id = ?", id).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Delete: error building query")
+		return err
+	}
+
+	if _, err := r.db.handler.ExecContext(ctx, query, args...); err != nil {
+		log.Error().Stack().Err(err).Msg("notification.Delete: error executing query")
+		return err
+	}
+
+	log.Debug().Msgf("notification.Delete: id %v", id)
+
+	return nil
+}
+
+func (r *NotificationRepo) baseQuery() sq.SelectBuilder {
+	return sq.
+		Select("id", "name", "type", "enabled", "events", "filters", "indexers", "settings", "created_at", "updated_at").
+		From("notification")
+}
+
+func (r *NotificationRepo) scanNotification(row rowScanner) (*domain.Notification, error) {
+	var n domain.Notification
+	var enabled sql.NullBool
+	var events []string
+	var filters []int64
+	var settings sql.NullString
+
+	n.Indexers = []string{}
+
+	if err := row.Scan(&n.ID, &n.Name, &n.Type, &enabled, pq.Array(&events), pq.Array(&filters), pq.Array(&n.Indexers), r.db.scanSecret(&settings), &n.CreatedAt, &n.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	n.Enabled = enabled.Bool
+
+	n.Events = make([]domain.NotificationEventType, 0, len(events))
+	for _, event := range events {
+		n.Events = append(n.Events, domain.NotificationEventType(event))
+	}
+
+	n.Filters = make([]int, 0, len(filters))
+	for _, id := range filters {
+		n.Filters = append(n.Filters, int(id))
+	}
+
+	if settings.String != "" {
+		if err := json.Unmarshal([]byte(settings.String), &n.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return &n, nil
+}
+
+// eventStrings the events as a text array, never null
+func eventStrings(events []domain.NotificationEventType) []string {
+	s := make([]string, 0, len(events))
+	for _, event := range events {
+		s = append(s, string(event))
+	}
+
+	return s
+}
+
+// filterIDs the ids as an int array, never null
+func filterIDs(ids []int) []int64 {
+	s := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, int64(id))
+	}
+
+	return s
+}
+
+// nonNil an empty slice for nil, arrays are written as {} rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestNotificationRepo(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	require.NoError(t, db.SetupEncryption(ctx, "key"))
+
+	repo := NewNotificationRepo(db)
+
+	n := &domain.Notification{
+		Name:     "phone",
+		Type:     domain.NotificationTypePushover,
+		Enabled:  true,
+		Events:   []domain.NotificationEventType{domain.NotificationEventPushError, domain.NotificationEventSystem},
+		Filters:  []int{3, 7},
+		Indexers: []string{"torrentleech"},
+		Settings: domain.NotificationSettings{
+			Token:      "app-token",
+			UserKey:    "user-key",
+			Priorities: map[domain.NotificationEventType]int{domain.NotificationEventPushError: 1},
+		},
+	}
+	require.NoError(t, repo.Store(ctx, n))
+
+	var raw string
+	require.NoError(t, db.handler.QueryRow(`SELECT settings FROM notification WHERE id = ?`, n.ID).Scan(&raw))
+	assert.True(t, strings.HasPrefix(raw, encryptedPrefix))
+
+	stored, err := repo.FindByID(ctx, n.ID)
+	require.NoError(t, err)
+	assert.Equal(t, n.Events, stored.Events)
+	assert.Equal(t, n.Filters, stored.Filters)
+	assert.Equal(t, n.Indexers, stored.Indexers)
+	assert.Equal(t, n.Settings, stored.Settings)
+
+	n.Enabled = false
+	require.NoError(t, repo.Update(ctx, n))
+
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.False(t, list[0].Enabled)
+
+	n.Filters = nil
+	require.NoError(t, repo.Update(ctx, n))
+
+	stored, err = repo.FindByID(ctx, n.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Filters)
+
+	// no events is an empty array, not null
+	require.NoError(t, repo.Store(ctx, &domain.Notification{Name: "gotify", Type: domain.NotificationTypeGotify}))
+
+	require.NoError(t, repo.Delete(ctx, n.ID))
+	assert.Error(t, repo.Update(ctx, n))
+}
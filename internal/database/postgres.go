@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	sq "github.com/Masterminds/squirrel"
+	_ "github.com/lib/pq"
+)
+
+func (db *DB) openPostgres() error {
+	conn, err := sql.Open(driverPostgres, db.DSN)
+	if err != nil {
+		return fmt.Errorf("could not open db connection: %w", err)
+	}
+
+	if db.settings.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(db.settings.MaxOpenConns)
+	}
+	if db.settings.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(db.settings.MaxIdleConns)
+	}
+	if db.settings.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(db.settings.ConnMaxLifetime)
+	}
+
+	db.handler = handler{DB: conn, placeholders: sq.Dollar}
+
+	// sql.Open doesn't connect, a wrong dsn or password should fail on start
+	if err := conn.PingContext(db.ctx); err != nil {
+		return fmt.Errorf("could not connect to postgres: %w", err)
+	}
+
+	return nil
+}
+
+// postgresTypes the sqlite schema and migrations with postgres types. Ids are generated by
+// sequences and integers are 64 bit like in sqlite, sizes don't fit in 32 bits.
+var postgresTypes = []struct {
+	rxp  *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\bINTEGER PRIMARY KEY\b`), "BIGSERIAL PRIMARY KEY"},
+	{regexp.MustCompile(`\b(INTEGER|INT)\b`), "BIGINT"},
+	{regexp.MustCompile(`\bTIMESTAMP\b`), "TIMESTAMPTZ"},
+	{regexp.MustCompile(`\bJSON\b`), "TEXT"},
+}
+
+func postgresStatements(statements string) string {
+	for _, t := range postgresTypes {
+		statements = t.rxp.ReplaceAllString(statements, t.repl)
+	}
+
+	return statements
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM filter WHERE name = ? AND enabled = ?"
+
+	assert.Equal(t, query, rebind(nil, query))
+	assert.Equal(t, "SELECT id FROM filter WHERE name = $1 AND enabled = $2", rebind(sq.Dollar, query))
+}
+
+func TestPostgresStatements(t *testing.T) {
+	statements := `CREATE TABLE feed
+(
+    id         INTEGER PRIMARY KEY,
+    indexer_id INTEGER,
+    interval   INT,
+    settings   JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+	want := `CREATE TABLE feed
+(
+    id         BIGSERIAL PRIMARY KEY,
+    indexer_id BIGINT,
+    interval   BIGINT,
+    settings   TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);`
+
+	assert.Equal(t, want, postgresStatements(statements))
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+)
+
+type QuotaRepo struct {
+	db *DB
+}
+
+func NewQuotaRepo(db *DB) domain.QuotaRepo {
+	return &QuotaRepo{db: db}
+}
+
+// Usage counts approved releases since the given time. A release counts as grabbed unless every action
+// that ran for it was rejected or failed, so releases still being processed are included.
+func (r *QuotaRepo) Usage(ctx context.Context, filterID int, since time.Time) (int64, int64, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Select("COUNT(*)", "COALESCE(SUM(r.size), 0)").
+		From("release r").
+		Where(sq.Eq{"r.filter_status": domain.ReleaseStatusFilterApproved}).
+		Where(r.db.compareTime("r.timestamp", ">=", since)).
+		Where(`(NOT EXISTS (SELECT 1 FROM release_action_status ras WHERE ras.release_id = r.id)
+ OR EXISTS (SELECT 1 FROM release_action_status ras WHERE ras.release_id = r.id AND ras.status IN (?, ?)))`,
+			domain.ReleasePushStatusApproved, domain.ReleasePushStatusPending)
+
+	if filterID != 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.filter_id": filterID})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("quota.Usage: error building query")
+		return 0, 0, err
+	}
+
+	var grabs, bytes int64
+	if err := r.db.handler.QueryRowContext(ctx, query, args...).Scan(&grabs, &bytes); err != nil {
+		log.Error().Stack().Err(err).Msg("quota.Usage: error executing query")
+		return 0, 0, err
+	}
+
+	return grabs, bytes, nil
+}
@@ -3,6 +3,11 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
 	sq "github.com/Masterminds/squirrel"
 	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
@@ -11,10 +16,10 @@ import (
 )
 
 type ReleaseRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewReleaseRepo(db *SqliteDB) domain.ReleaseRepo {
+func NewReleaseRepo(db *DB) domain.ReleaseRepo {
 	return &ReleaseRepo{db: db}
 }
 
@@ -24,19 +29,17 @@ func (repo *ReleaseRepo) Store(ctx context.Context, r *domain.Release) (*domain.
 
 	query, args, err := sq.
 		Insert("release").
-		Columns("filter_status", "rejections", "indexer", "filter", "protocol", "implementation", "timestamp", "group_id", "torrent_id", "torrent_name", "size", "raw", "title", "category", "season", "episode", "year", "resolution", "source", "codec", "container", "hdr", "audio", "release_group", "region", "language", "edition", "unrated", "hybrid", "proper", "repack", "website", "artists", "type", "format", "quality", "log_score", "has_log", "has_cue", "is_scene", "origin", "tags", "freeleech", "freeleech_percent", "uploader", "pre_time").
-		Values(r.FilterStatus, pq.Array(r.Rejections), r.Indexer, r.FilterName, r.Protocol, r.Implementation, r.Timestamp, r.GroupID, r.TorrentID, r.TorrentName, r.Size, r.Raw, r.Title, r.Category, r.Season, r.Episode, r.Year, r.Resolution, r.Source, r.Codec, r.Container, r.HDR, r.Audio, r.Group, r.Region, r.Language, r.Edition, r.Unrated, r.Hybrid, r.Proper, r.Repack, r.Website, pq.Array(r.Artists), r.Type, r.Format, r.Quality, r.LogScore, r.HasLog, r.HasCue, r.IsScene, r.Origin, pq.Array(r.Tags), r.Freeleech, r.FreeleechPercent, r.Uploader, r.PreTime).
+		Columns("filter_status", "rejections", "indexer", "filter", "protocol", "implementation", "timestamp", "group_id", "torrent_id", "torrent_name", "size", "raw", "title", "category", "season", "episode", "year", "resolution", "source", "codec", "container", "hdr", "audio", "release_group", "region", "language", "edition", "unrated", "hybrid", "proper", "repack", "website", "artists", "type", "format", "quality", "log_score", "has_log", "has_cue", "is_scene", "origin", "tags", "freeleech", "freeleech_percent", "uploader", "pre_time", "filter_id", "torrent_url", "magnet_uri", "indexer_account", "freeleech_token", "info_hash", "parse_us", "filter_us", "fetch_us", "normalized_name", "normalized_title").
+		Values(r.FilterStatus, pq.Array(r.Rejections), r.Indexer, r.FilterName, r.Protocol, r.Implementation, r.Timestamp, r.GroupID, r.TorrentID, r.TorrentName, r.Size, r.Raw, r.Title, r.Category, r.Season, r.Episode, r.Year, r.Resolution, r.Source, r.Codec, r.Container, r.HDR, r.Audio, r.Group, r.Region, r.Language, r.Edition, r.Unrated, r.Hybrid, r.Proper, r.Repack, r.Website, pq.Array(r.Artists), r.Type, r.Format, r.Quality, r.LogScore, r.HasLog, r.HasCue, r.IsScene, r.Origin, pq.Array(r.Tags), r.Freeleech, r.FreeleechPercent, r.Uploader, r.PreTime, r.FilterID, repo.db.secret(r.TorrentURL), r.MagnetURI, r.IndexerAccount, r.FreeleechToken, toNullString(r.TorrentHash), toNullInt64(r.Timings.Parse), toNullInt64(r.Timings.Filter), toNullInt64(r.Timings.Fetch), domain.NormalizeName(r.TorrentName), domain.NormalizeName(r.Title)).
+		Suffix("RETURNING id").
 		ToSql()
 
-	res, err := repo.db.handler.ExecContext(ctx, query, args...)
+	err = repo.db.handler.QueryRowContext(ctx, query, args...).Scan(&r.ID)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("error inserting release")
 		return nil, err
 	}
 
-	resId, _ := res.LastInsertId()
-	r.ID = resId
-
 	log.Trace().Msgf("release.store: %+v", r)
 
 	return r, nil
@@ -51,6 +54,7 @@ func (repo *ReleaseRepo) StoreReleaseActionStatus(ctx context.Context, a *domain
 			Update("release_action_status").
 			Set("status", a.Status).
 			Set("rejections", pq.Array(a.Rejections)).
+			Set("log", toNullString(a.Log)).
 			Set("timestamp", a.Timestamp).
 			Where("id = ?", a.ID).
 			Where("release_id = ?", a.ReleaseID).
@@ -65,18 +69,16 @@ func (repo *ReleaseRepo) StoreReleaseActionStatus(ctx context.Context, a *domain
 	} else {
 		query, args, err := sq.
 			Insert("release_action_status").
-			Columns("status", "action", "type", "rejections", "timestamp", "release_id").
-			Values(a.Status, a.Action, a.Type, pq.Array(a.Rejections), a.Timestamp, a.ReleaseID).
+			Columns("status", "action", "type", "rejections", "log", "timestamp", "release_id").
+			Values(a.Status, a.Action, a.Type, pq.Array(a.Rejections), toNullString(a.Log), a.Timestamp, a.ReleaseID).
+			Suffix("RETURNING id").
 			ToSql()
 
-		res, err := repo.db.handler.ExecContext(ctx, query, args...)
+		err = repo.db.handler.QueryRowContext(ctx, query, args...).Scan(&a.ID)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error inserting status of release")
 			return err
 		}
-
-		resId, _ := res.LastInsertId()
-		a.ID = resId
 	}
 
 	log.Trace().Msgf("release.store_release_action_status: %+v", a)
@@ -89,7 +91,7 @@ func (repo *ReleaseRepo) Find(ctx context.Context, params domain.ReleaseQueryPar
 	//defer r.db.lock.RUnlock()
 
 	queryBuilder := sq.
-		Select("r.id", "r.filter_status", "r.rejections", "r.indexer", "r.filter", "r.protocol", "r.title", "r.torrent_name", "r.size", "r.timestamp", "COUNT() OVER() AS total_count").
+		Select("r.id", "r.filter_status", "r.rejections", "r.indexer", "r.filter", "r.protocol", "r.title", "r.torrent_name", "r.size", "r.timestamp", "r.parse_us", "r.filter_us", "r.fetch_us", "r.push_us", "COUNT(*) OVER() AS total_count").
 		From("release r").
 		OrderBy("r.timestamp DESC")
 
@@ -107,17 +109,33 @@ func (repo *ReleaseRepo) Find(ctx context.Context, params domain.ReleaseQueryPar
 		queryBuilder = queryBuilder.Where(sq.Lt{"r.id": params.Cursor})
 	}
 
-	if params.Filters.Indexers != nil {
-		filter := sq.And{}
-		for _, v := range params.Filters.Indexers {
-			filter = append(filter, sq.Eq{"r.indexer": v})
-		}
+	if len(params.Filters.Indexers) > 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.indexer": params.Filters.Indexers})
+	}
 
-		queryBuilder = queryBuilder.Where(filter)
+	if len(params.Filters.FilterNames) > 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.filter": params.Filters.FilterNames})
+	}
+
+	if params.Filters.FilterStatus != "" {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.filter_status": params.Filters.FilterStatus})
 	}
 
 	if params.Filters.PushStatus != "" {
-		queryBuilder = queryBuilder.InnerJoin("release_action_status ras ON r.id = ras.release_id").Where(sq.Eq{"ras.status": params.Filters.PushStatus})
+		// exists instead of join so releases with several actions are only returned once
+		queryBuilder = queryBuilder.Where("EXISTS (SELECT 1 FROM release_action_status ras WHERE ras.release_id = r.id AND ras.status = ?)", params.Filters.PushStatus)
+	}
+
+	if !params.Filters.From.IsZero() {
+		queryBuilder = queryBuilder.Where(repo.db.compareTime("r.timestamp", ">=", params.Filters.From))
+	}
+
+	if !params.Filters.To.IsZero() {
+		queryBuilder = queryBuilder.Where(repo.db.compareTime("r.timestamp", "<=", params.Filters.To))
+	}
+
+	if params.Search != "" {
+		queryBuilder = queryBuilder.Where(sq.Like{"r.torrent_name": "%" + params.Search + "%"})
 	}
 
 	query, args, err := queryBuilder.ToSql()
@@ -144,14 +162,16 @@ func (repo *ReleaseRepo) Find(ctx context.Context, params domain.ReleaseQueryPar
 		var rls domain.Release
 
 		var indexer, filter sql.NullString
+		var timings nullTimings
 
-		if err := rows.Scan(&rls.ID, &rls.FilterStatus, pq.Array(&rls.Rejections), &indexer, &filter, &rls.Protocol, &rls.Title, &rls.TorrentName, &rls.Size, &rls.Timestamp, &countItems); err != nil {
+		if err := rows.Scan(&rls.ID, &rls.FilterStatus, pq.Array(&rls.Rejections), &indexer, &filter, &rls.Protocol, &rls.Title, &rls.TorrentName, &rls.Size, &rls.Timestamp, &timings.parse, &timings.filter, &timings.fetch, &timings.push, &countItems); err != nil {
 			log.Error().Stack().Err(err).Msg("release.find: error scanning data to struct")
 			return res, 0, 0, err
 		}
 
 		rls.Indexer = indexer.String
 		rls.FilterName = filter.String
+		rls.Timings = timings.timings()
 
 		// get action status
 		actionStatus, err := repo.GetActionStatusByReleaseID(ctx, rls.ID)
@@ -175,6 +195,173 @@ func (repo *ReleaseRepo) Find(ctx context.Context, params domain.ReleaseQueryPar
 	return res, nextCursor, countItems, nil
 }
 
+// releaseColumns all columns of a release, in the order scanRelease expects
+var releaseColumns = []string{
+	"id", "filter_status", "rejections", "indexer", "filter", "protocol", "implementation", "timestamp", "group_id", "torrent_id", "torrent_name", "size", "raw", "title", "category", "season", "episode", "year", "resolution", "source", "codec", "container", "hdr", "audio", "release_group", "region", "language", "edition", "unrated", "hybrid", "proper", "repack", "website", "artists", "type", "format", "quality", "log_score", "has_log", "has_cue", "is_scene", "origin", "tags", "freeleech", "freeleech_percent", "uploader", "pre_time", "filter_id", "torrent_url", "magnet_uri", "indexer_account", "freeleech_token", "parse_us", "filter_us", "fetch_us", "push_us",
+}
+
+func (repo *ReleaseRepo) FindByID(ctx context.Context, id int64) (*domain.Release, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.
+		Select(releaseColumns...).
+		From("release").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.findByID: error building query")
+		return nil, err
+	}
+
+	row := repo.db.handler.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != nil {
+		log.Error().Stack().Err(err).Msg("release.findByID: error query row")
+		return nil, err
+	}
+
+	rls, err := repo.scanRelease(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+
+		log.Error().Stack().Err(err).Msg("release.findByID: error scanning data to struct")
+		return nil, err
+	}
+
+	return rls, nil
+}
+
+// FindRecent latest releases with all fields, newest first
+func (repo *ReleaseRepo) FindRecent(ctx context.Context, limit uint64) ([]domain.Release, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.
+		Select(releaseColumns...).
+		From("release").
+		OrderBy("id DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.findRecent: error building query")
+		return nil, err
+	}
+
+	rows, err := repo.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.findRecent: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []domain.Release
+	for rows.Next() {
+		rls, err := repo.scanRelease(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("release.findRecent: error scanning data to struct")
+			return nil, err
+		}
+
+		res = append(res, *rls)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *ReleaseRepo) scanRelease(row rowScanner) (*domain.Release, error) {
+	var rls domain.Release
+
+	var indexer, filter, torrentURL, magnetURI, indexerAccount sql.NullString
+	var filterID sql.NullInt32
+	var freeleechToken sql.NullBool
+	var timings nullTimings
+
+	if err := row.Scan(&rls.ID, &rls.FilterStatus, pq.Array(&rls.Rejections), &indexer, &filter, &rls.Protocol, &rls.Implementation, &rls.Timestamp, &rls.GroupID, &rls.TorrentID, &rls.TorrentName, &rls.Size, &rls.Raw, &rls.Title, &rls.Category, &rls.Season, &rls.Episode, &rls.Year, &rls.Resolution, &rls.Source, &rls.Codec, &rls.Container, &rls.HDR, &rls.Audio, &rls.Group, &rls.Region, &rls.Language, &rls.Edition, &rls.Unrated, &rls.Hybrid, &rls.Proper, &rls.Repack, &rls.Website, pq.Array(&rls.Artists), &rls.Type, &rls.Format, &rls.Quality, &rls.LogScore, &rls.HasLog, &rls.HasCue, &rls.IsScene, &rls.Origin, pq.Array(&rls.Tags), &rls.Freeleech, &rls.FreeleechPercent, &rls.Uploader, &rls.PreTime, &filterID, repo.db.scanSecret(&torrentURL), &magnetURI, &indexerAccount, &freeleechToken, &timings.parse, &timings.filter, &timings.fetch, &timings.push); err != nil {
+		return nil, err
+	}
+
+	rls.Indexer = indexer.String
+	rls.FilterName = filter.String
+	rls.FilterID = int(filterID.Int32)
+	// restores the info hash for magnet releases
+	if magnetURI.String != "" {
+		rls.SetDownloadURL(magnetURI.String)
+	}
+	rls.TorrentURL = torrentURL.String
+	rls.IndexerAccount = indexerAccount.String
+	rls.FreeleechToken = freeleechToken.Bool
+	rls.Timings = timings.timings()
+
+	return &rls, nil
+}
+
+// nullTimings stage timings as stored, stages a release did not go through are null
+type nullTimings struct {
+	parse, filter, fetch, push sql.NullInt64
+}
+
+func (t nullTimings) timings() domain.ReleaseTimings {
+	return domain.ReleaseTimings{
+		Parse:  t.parse.Int64,
+		Filter: t.filter.Int64,
+		Fetch:  t.fetch.Int64,
+		Push:   t.push.Int64,
+	}
+}
+
+// StorePushTimings record the download and push time of the first action that sent the release, later actions don't change it
+func (repo *ReleaseRepo) StorePushTimings(ctx context.Context, releaseID int64, timings domain.ReleaseTimings) error {
+	query, args, err := sq.
+		Update("release").
+		Set("fetch_us", toNullInt64(timings.Fetch)).
+		Set("push_us", toNullInt64(timings.Push)).
+		Where("id = ?", releaseID).
+		Where("push_us IS NULL").
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.storePushTimings: error building query")
+		return err
+	}
+
+	if _, err := repo.db.handler.ExecContext(ctx, query, args...); err != nil {
+		log.Error().Stack().Err(err).Msg("release.storePushTimings: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateFilter set the filter and filter status, used when a rejected release is retried with a filter
+func (repo *ReleaseRepo) UpdateFilter(ctx context.Context, r *domain.Release) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.
+		Update("release").
+		Set("filter_status", r.FilterStatus).
+		Set("filter", r.FilterName).
+		Set("filter_id", r.FilterID).
+		Where("id = ?", r.ID).
+		ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.updateFilter: error building query")
+		return err
+	}
+
+	if _, err := repo.db.handler.ExecContext(ctx, query, args...); err != nil {
+		log.Error().Stack().Err(err).Msg("release.updateFilter: error updating release")
+		return err
+	}
+
+	return nil
+}
+
 func (repo *ReleaseRepo) GetIndexerOptions(ctx context.Context) ([]string, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
@@ -220,7 +407,7 @@ func (repo *ReleaseRepo) GetActionStatusByReleaseID(ctx context.Context, release
 	//defer r.db.lock.RUnlock()
 
 	queryBuilder := sq.
-		Select("id", "status", "action", "type", "rejections", "timestamp").
+		Select("id", "status", "action", "type", "rejections", "log", "timestamp").
 		From("release_action_status").
 		Where("release_id = ?", releaseID)
 
@@ -243,47 +430,291 @@ func (repo *ReleaseRepo) GetActionStatusByReleaseID(ctx context.Context, release
 
 	for rows.Next() {
 		var rls domain.ReleaseActionStatus
+		var actionLog sql.NullString
 
-		if err := rows.Scan(&rls.ID, &rls.Status, &rls.Action, &rls.Type, pq.Array(&rls.Rejections), &rls.Timestamp); err != nil {
+		if err := rows.Scan(&rls.ID, &rls.Status, &rls.Action, &rls.Type, pq.Array(&rls.Rejections), &actionLog, &rls.Timestamp); err != nil {
 			log.Error().Stack().Err(err).Msg("release.find: error scanning data to struct")
 			return res, err
 		}
 
+		rls.Log = actionLog.String
+
 		res = append(res, rls)
 	}
 
 	return res, nil
 }
 
-func (repo *ReleaseRepo) Stats(ctx context.Context) (*domain.ReleaseStats, error) {
+func (repo *ReleaseRepo) Stats(ctx context.Context, params domain.ReleaseStatsParams) (*domain.ReleaseStats, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	query := `SELECT COUNT(*)                                                                      total,
-       IFNULL(SUM(CASE WHEN filter_status = 'FILTER_APPROVED' THEN 1 ELSE 0 END), 0) filtered_count,
-       IFNULL(SUM(CASE WHEN filter_status = 'FILTER_REJECTED' THEN 1 ELSE 0 END), 0) filter_rejected_count,
-       (SELECT IFNULL(SUM(CASE WHEN status = 'PUSH_APPROVED' THEN 1 ELSE 0 END), 0)
-        FROM "release_action_status") AS                                             push_approved_count,
-       (SELECT IFNULL(SUM(CASE WHEN status = 'PUSH_REJECTED' THEN 1 ELSE 0 END), 0)
-        FROM "release_action_status") AS                                             push_rejected_count
-FROM "release";`
+	totals, err := repo.statsGroups(ctx, "", params)
+	if err != nil {
+		return nil, err
+	}
 
-	row := repo.db.handler.QueryRowContext(ctx, query)
-	if err := row.Err(); err != nil {
+	var rls domain.ReleaseStats
+
+	if len(totals) > 0 {
+		rls.TotalCount = totals[0].TotalCount
+		rls.FilteredCount = totals[0].FilteredCount
+		rls.FilterRejectedCount = totals[0].FilterRejectedCount
+		rls.PushApprovedCount = totals[0].PushApprovedCount
+		rls.PushRejectedCount = totals[0].PushRejectedCount
+		rls.Latency = totals[0].Latency
+	}
+
+	for _, groupBy := range params.GroupBy {
+		switch groupBy {
+		case domain.ReleaseStatsGroupByIndexer:
+			rls.Indexers, err = repo.statsGroups(ctx, "COALESCE(r.indexer, '')", params)
+		case domain.ReleaseStatsGroupByFilter:
+			rls.Filters, err = repo.statsGroups(ctx, "COALESCE(r.filter, '')", params)
+		case domain.ReleaseStatsGroupByDay:
+			// day in utc, timestamps are stored with their zone offset
+			rls.Days, err = repo.statsGroups(ctx, repo.db.utcDate("r.timestamp"), params)
+		default:
+			err = fmt.Errorf("unsupported stats group: %v", groupBy)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &rls, nil
+}
+
+// statsGroups count releases and push statuses grouped by the key expression, or all together if key is empty
+func (repo *ReleaseRepo) statsGroups(ctx context.Context, key string, params domain.ReleaseStatsParams) ([]domain.ReleaseStatsGroup, error) {
+	keyColumn := "''"
+	if key != "" {
+		keyColumn = key
+	}
+
+	queryBuilder := sq.
+		Select(
+			keyColumn+" AS stats_key",
+			"COUNT(*)",
+			"COALESCE(SUM(CASE WHEN r.filter_status = 'FILTER_APPROVED' THEN 1 ELSE 0 END), 0)",
+			"COALESCE(SUM(CASE WHEN r.filter_status = 'FILTER_REJECTED' THEN 1 ELSE 0 END), 0)",
+			"COALESCE(SUM(ras.push_approved), 0)",
+			"COALESCE(SUM(ras.push_rejected), 0)",
+			"COUNT(r.parse_us)", "COALESCE(AVG(r.parse_us), 0)", "COALESCE(MAX(r.parse_us), 0)",
+			"COUNT(r.filter_us)", "COALESCE(AVG(r.filter_us), 0)", "COALESCE(MAX(r.filter_us), 0)",
+			"COUNT(r.fetch_us)", "COALESCE(AVG(r.fetch_us), 0)", "COALESCE(MAX(r.fetch_us), 0)",
+			"COUNT(r.push_us)", "COALESCE(AVG(r.push_us), 0)", "COALESCE(MAX(r.push_us), 0)",
+		).
+		From("release r").
+		LeftJoin(`(SELECT release_id,
+       SUM(CASE WHEN status = 'PUSH_APPROVED' THEN 1 ELSE 0 END) AS push_approved,
+       SUM(CASE WHEN status = 'PUSH_REJECTED' THEN 1 ELSE 0 END) AS push_rejected
+FROM release_action_status
+GROUP BY release_id) ras ON ras.release_id = r.id`)
+
+	if len(params.Indexers) > 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.indexer": params.Indexers})
+	}
+
+	if !params.From.IsZero() {
+		queryBuilder = queryBuilder.Where(repo.db.compareTime("r.timestamp", ">=", params.From))
+	}
+
+	if !params.To.IsZero() {
+		queryBuilder = queryBuilder.Where(repo.db.compareTime("r.timestamp", "<=", params.To))
+	}
+
+	if key != "" {
+		queryBuilder = queryBuilder.GroupBy("stats_key").OrderBy("stats_key")
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.stats: error building query")
+		return nil, err
+	}
+
+	rows, err := repo.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
 		log.Error().Stack().Err(err).Msg("release.stats: error querying stats")
 		return nil, err
 	}
 
-	var rls domain.ReleaseStats
+	defer rows.Close()
+
+	res := make([]domain.ReleaseStatsGroup, 0)
+
+	for rows.Next() {
+		var g domain.ReleaseStatsGroup
+
+		// averages are fractional, they are rounded to whole microseconds
+		var parseAvg, filterAvg, fetchAvg, pushAvg float64
+
+		if err := rows.Scan(&g.Key, &g.TotalCount, &g.FilteredCount, &g.FilterRejectedCount, &g.PushApprovedCount, &g.PushRejectedCount,
+			&g.Latency.Parse.Count, &parseAvg, &g.Latency.Parse.Max,
+			&g.Latency.Filter.Count, &filterAvg, &g.Latency.Filter.Max,
+			&g.Latency.Fetch.Count, &fetchAvg, &g.Latency.Fetch.Max,
+			&g.Latency.Push.Count, &pushAvg, &g.Latency.Push.Max); err != nil {
+			log.Error().Stack().Err(err).Msg("release.stats: error scanning stats data to struct")
+			return nil, err
+		}
+
+		g.Latency.Parse.Avg = int64(math.Round(parseAvg))
+		g.Latency.Filter.Avg = int64(math.Round(filterAvg))
+		g.Latency.Fetch.Avg = int64(math.Round(fetchAvg))
+		g.Latency.Push.Avg = int64(math.Round(pushAvg))
+
+		res = append(res, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Stack().Err(err).Msg("release.stats: error reading stats rows")
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// releaseGrabbed approved releases unless every action that ran for them was rejected or failed,
+// so releases still being processed are included
+var releaseGrabbed = sq.And{
+	sq.Eq{"r.filter_status": domain.ReleaseStatusFilterApproved},
+	sq.Expr(`(NOT EXISTS (SELECT 1 FROM release_action_status ras WHERE ras.release_id = r.id)
+ OR EXISTS (SELECT 1 FROM release_action_status ras WHERE ras.release_id = r.id AND ras.status IN (?, ?)))`,
+		domain.ReleasePushStatusApproved, domain.ReleasePushStatusPending),
+}
+
+// FindDuplicate the latest release grabbed since params.Since that matches, nil when there is none
+func (repo *ReleaseRepo) FindDuplicate(ctx context.Context, params domain.DuplicateQuery) (*domain.Release, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
 
-	if err := row.Scan(&rls.TotalCount, &rls.FilteredCount, &rls.FilterRejectedCount, &rls.PushApprovedCount, &rls.PushRejectedCount); err != nil {
-		log.Error().Stack().Err(err).Msg("release.stats: error scanning stats data to struct")
+	match := sq.And{sq.Eq{"r.normalized_name": params.Name}}
+
+	// an unknown size on either side matches any size
+	if params.Size > 0 {
+		match = append(match, sq.Or{
+			sq.Eq{"r.size": nil},
+			sq.Eq{"r.size": 0},
+			sq.Expr(fmt.Sprintf("ABS(r.size - ?) <= %v * (CASE WHEN r.size > ? THEN r.size ELSE ? END)", params.SizeTolerance), int64(params.Size), int64(params.Size), int64(params.Size)),
+		})
+	}
+
+	// the hash decides when both are known
+	var where sq.Sqlizer = match
+	if params.InfoHash != "" {
+		where = sq.Or{
+			sq.Eq{"LOWER(r.info_hash)": strings.ToLower(params.InfoHash)},
+			sq.And{sq.Eq{"r.info_hash": nil}, match},
+		}
+	}
+
+	queryBuilder := sq.
+		Select("r.id", "r.indexer", "r.torrent_name", "r.size", "r.info_hash", "r.timestamp").
+		From("release r").
+		Where(releaseGrabbed).
+		Where(repo.db.compareTime("r.timestamp", ">=", params.Since)).
+		Where(where).
+		OrderBy("r.id DESC").
+		Limit(1)
+
+	if params.ExcludeID != 0 {
+		queryBuilder = queryBuilder.Where(sq.NotEq{"r.id": params.ExcludeID})
+	}
+
+	if len(params.Indexers) > 0 {
+		queryBuilder = queryBuilder.Where(sq.Eq{"r.indexer": params.Indexers})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
 		return nil, err
 	}
 
+	var rls domain.Release
+	var indexer, infoHash sql.NullString
+	var size sql.NullInt64
+
+	if err := repo.db.handler.QueryRowContext(ctx, query, args...).Scan(&rls.ID, &indexer, &rls.TorrentName, &size, &infoHash, &rls.Timestamp); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Error().Stack().Err(err).Msg("release.find_duplicate: error executing query")
+		return nil, err
+	}
+
+	rls.Indexer = indexer.String
+	rls.Size = uint64(size.Int64)
+	rls.TorrentHash = infoHash.String
+
 	return &rls, nil
 }
 
+// FindGrabbedEpisodes releases grabbed since params.Since of the episode or a season pack that contains it,
+// with the fields needed to compare their quality
+func (repo *ReleaseRepo) FindGrabbedEpisodes(ctx context.Context, params domain.EpisodeQuery) ([]domain.Release, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Select("r.id", "r.indexer", "r.torrent_name", "r.title", "r.season", "r.episode", "r.resolution", "r.source", "r.proper", "r.repack").
+		From("release r").
+		Where(releaseGrabbed).
+		Where(repo.db.compareTime("r.timestamp", ">=", params.Since)).
+		Where(sq.Eq{"r.normalized_title": params.Title}).
+		Where(sq.Eq{"r.season": params.Season}).
+		Where(sq.Eq{"r.episode": []int{params.Episode, 0}}).
+		OrderBy("r.id DESC")
+
+	if params.ExcludeID != 0 {
+		queryBuilder = queryBuilder.Where(sq.NotEq{"r.id": params.ExcludeID})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.find_grabbed_episodes: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []domain.Release
+	for rows.Next() {
+		var rls domain.Release
+		var indexer, title, resolution, source sql.NullString
+		var season, episode sql.NullInt32
+		var proper, repack sql.NullBool
+
+		if err := rows.Scan(&rls.ID, &indexer, &rls.TorrentName, &title, &season, &episode, &resolution, &source, &proper, &repack); err != nil {
+			log.Error().Stack().Err(err).Msg("release.find_grabbed_episodes: error scanning data to struct")
+			return nil, err
+		}
+
+		rls.Indexer = indexer.String
+		rls.Title = title.String
+		rls.Season = int(season.Int32)
+		rls.Episode = int(episode.Int32)
+		rls.Resolution = resolution.String
+		rls.Source = source.String
+		rls.Proper = proper.Bool
+		rls.Repack = repack.Bool
+
+		res = append(res, rls)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (repo *ReleaseRepo) Delete(ctx context.Context) error {
 	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -313,3 +744,69 @@ func (repo *ReleaseRepo) Delete(ctx context.Context) error {
 
 	return nil
 }
+
+// Prune delete releases from before olderThan and beyond the newest keep, with their action results.
+// Releases with queued actions are kept. A zero olderThan or keep is no limit.
+func (repo *ReleaseRepo) Prune(ctx context.Context, olderThan time.Time, keep int) (*domain.ReleasePruneResult, error) {
+	where := sq.Or{}
+
+	if !olderThan.IsZero() {
+		where = append(where, repo.db.compareTime("timestamp", "<", olderThan))
+	}
+
+	if keep > 0 {
+		// the newest id that is not kept, new releases coming in while pruning don't move it
+		var maxID int64
+		err := repo.db.handler.QueryRowContext(ctx, `SELECT id FROM "release" ORDER BY id DESC LIMIT 1 OFFSET ?`, keep).Scan(&maxID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if maxID > 0 {
+			where = append(where, sq.LtOrEq{"id": maxID})
+		}
+	}
+
+	res := &domain.ReleasePruneResult{}
+	if len(where) == 0 {
+		return res, nil
+	}
+
+	cond, args, err := sq.And{where, sq.Expr("id NOT IN (SELECT release_id FROM action_queue)")}.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM release_action_status WHERE release_id IN (SELECT id FROM "release" WHERE %v)`, cond), args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.prune: error deleting action status")
+		return nil, err
+	}
+
+	if res.ActionStatus, err = result.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	result, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "release" WHERE %v`, cond), args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("release.prune: error deleting releases")
+		return nil, err
+	}
+
+	if res.Releases, err = result.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,455 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestReleaseRepo_Prune(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	filter, err := NewFilterRepo(db).Store(ctx, domain.Filter{Name: "Filter", Enabled: true, Resolutions: []string{}, Codecs: []string{}, Sources: []string{}, Containers: []string{}})
+	require.NoError(t, err)
+
+	action, err := NewActionRepo(db).Store(ctx, domain.Action{Name: "Action", Type: domain.ActionTypeTest, Enabled: true, FilterID: filter.ID})
+	require.NoError(t, err)
+
+	repo := NewReleaseRepo(db)
+	now := time.Now().UTC()
+
+	// ten days old, the oldest first
+	var ids []int64
+	for i := 10; i > 0; i-- {
+		rls, err := repo.Store(ctx, &domain.Release{TorrentName: "Release", Timestamp: now.AddDate(0, 0, -i), Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+		require.NoError(t, err)
+		require.NoError(t, repo.StoreReleaseActionStatus(ctx, &domain.ReleaseActionStatus{Status: domain.ReleasePushStatusApproved, Action: "Action", Type: domain.ActionTypeTest, Rejections: []string{}, Timestamp: rls.Timestamp, ReleaseID: rls.ID}))
+		ids = append(ids, rls.ID)
+	}
+
+	// waiting on a delayed action
+	require.NoError(t, NewActionQueueRepo(db).Store(ctx, &domain.ActionQueueItem{ReleaseID: ids[0], ActionID: action.ID, RunAt: now}))
+
+	res, err := repo.Prune(ctx, now.AddDate(0, 0, -7).Add(-time.Hour), 0)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.ReleasePruneResult{Releases: 2, ActionStatus: 2}, res)
+
+	res, err = repo.Prune(ctx, time.Time{}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.ReleasePruneResult{Releases: 3, ActionStatus: 3}, res)
+
+	var remaining []int64
+	rows, err := db.handler.Query(`SELECT id FROM "release" ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		remaining = append(remaining, id)
+	}
+
+	assert.Equal(t, append([]int64{ids[0]}, ids[6:]...), remaining)
+
+	// nothing left to prune
+	res, err = repo.Prune(ctx, time.Time{}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.Releases)
+}
+
+func TestReleaseRepo_Timings(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+	now := time.Now().UTC()
+
+	fast, err := repo.Store(ctx, &domain.Release{TorrentName: "Fast", Indexer: "a", Timestamp: now, Rejections: []string{}, Artists: []string{}, Tags: []string{}, Timings: domain.ReleaseTimings{Parse: 100, Filter: 300}})
+	require.NoError(t, err)
+
+	slow, err := repo.Store(ctx, &domain.Release{TorrentName: "Slow", Indexer: "b", Timestamp: now, Rejections: []string{}, Artists: []string{}, Tags: []string{}, Timings: domain.ReleaseTimings{Parse: 200, Filter: 500, Fetch: 40000}})
+	require.NoError(t, err)
+
+	// rejected by the filters, it never got to the actions
+	_, err = repo.Store(ctx, &domain.Release{TorrentName: "Rejected", Indexer: "b", Timestamp: now, Rejections: []string{}, Artists: []string{}, Tags: []string{}, Timings: domain.ReleaseTimings{Parse: 300, Filter: 400}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.StorePushTimings(ctx, fast.ID, domain.ReleaseTimings{Fetch: 20000, Push: 1000}))
+	require.NoError(t, repo.StorePushTimings(ctx, slow.ID, domain.ReleaseTimings{Fetch: 40000, Push: 3001}))
+
+	// a second action doesn't replace the timings of the first
+	require.NoError(t, repo.StorePushTimings(ctx, fast.ID, domain.ReleaseTimings{Fetch: 90000, Push: 9000}))
+
+	found, err := repo.FindByID(ctx, fast.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReleaseTimings{Parse: 100, Filter: 300, Fetch: 20000, Push: 1000}, found.Timings)
+
+	stats, err := repo.Stats(ctx, domain.ReleaseStatsParams{GroupBy: []domain.ReleaseStatsGroupBy{domain.ReleaseStatsGroupByIndexer}})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ReleaseLatency{
+		Parse:  domain.StageLatency{Count: 3, Avg: 200, Max: 300},
+		Filter: domain.StageLatency{Count: 3, Avg: 400, Max: 500},
+		Fetch:  domain.StageLatency{Count: 2, Avg: 30000, Max: 40000},
+		Push:   domain.StageLatency{Count: 2, Avg: 2001, Max: 3001},
+	}, stats.Latency)
+
+	require.Len(t, stats.Indexers, 2)
+	assert.Equal(t, domain.StageLatency{Count: 1, Avg: 1000, Max: 1000}, stats.Indexers[0].Latency.Push)
+	assert.Equal(t, domain.StageLatency{Count: 2, Avg: 250, Max: 300}, stats.Indexers[1].Latency.Parse)
+}
+
+func TestReleaseRepo_FindDuplicate(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+	now := time.Now().UTC()
+
+	store := func(r domain.Release) int64 {
+		r.FilterStatus = domain.ReleaseStatusFilterApproved
+		r.Timestamp = now.Add(-time.Hour)
+		r.Rejections, r.Artists, r.Tags = []string{}, []string{}, []string{}
+
+		rls, err := repo.Store(ctx, &r)
+		require.NoError(t, err)
+		return rls.ID
+	}
+
+	hashed := store(domain.Release{Indexer: "mock", TorrentName: "That.Movie.2021.1080p.BluRay.x264-GRP", TorrentHash: "ABCDEF", Size: 10_000_000_000})
+	sized := store(domain.Release{Indexer: "mock", TorrentName: "That.Show.S01E01.1080p.WEB-DL.H.264-GRP", Size: 10_000_000_000})
+	unsized := store(domain.Release{Indexer: "preferred", TorrentName: "Other.Show.S01E01.1080p.WEB-DL.H.264-GRP"})
+
+	// rejected by the filters, never grabbed
+	rejected := domain.Release{FilterStatus: domain.ReleaseStatusFilterRejected, TorrentName: "Rejected.Show.S01E01.1080p.WEB-DL.H.264-GRP", Timestamp: now, Rejections: []string{}, Artists: []string{}, Tags: []string{}}
+	_, err := repo.Store(ctx, &rejected)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params domain.DuplicateQuery
+		want   int64
+	}{
+		{
+			name:   "same_hash",
+			params: domain.DuplicateQuery{InfoHash: "abcdef", Name: domain.NormalizeName("That Movie 2021")},
+			want:   hashed,
+		},
+		{
+			name:   "different_hash",
+			params: domain.DuplicateQuery{InfoHash: "123456", Name: domain.NormalizeName("That.Movie.2021.1080p.BluRay.x264-GRP")},
+		},
+		{
+			name:   "same_name_size_within_tolerance",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("That Show S01E01 1080p WEB-DL H 264-GRP"), Size: 10_100_000_000},
+			want:   sized,
+		},
+		{
+			name:   "hash_unknown_on_grab",
+			params: domain.DuplicateQuery{InfoHash: "123456", Name: domain.NormalizeName("That Show S01E01 1080p WEB-DL H 264-GRP"), Size: 10_100_000_000},
+			want:   sized,
+		},
+		{
+			name:   "same_name_size_too_different",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP"), Size: 12_000_000_000},
+		},
+		{
+			name:   "same_name_unknown_size",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("Other.Show.S01E01.1080p.WEB-DL.H.264-GRP"), Size: 10_000_000_000},
+			want:   unsized,
+		},
+		{
+			name:   "different_group",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-OTHER")},
+		},
+		{
+			name:   "other_indexers",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP"), Indexers: []string{"preferred"}},
+		},
+		{
+			name:   "excluded",
+			params: domain.DuplicateQuery{ExcludeID: sized, Name: domain.NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP")},
+		},
+		{
+			name:   "outside_window",
+			params: domain.DuplicateQuery{Since: now, Name: domain.NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP")},
+		},
+		{
+			name:   "not_grabbed",
+			params: domain.DuplicateQuery{Name: domain.NormalizeName("Rejected.Show.S01E01.1080p.WEB-DL.H.264-GRP")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.SizeTolerance = 0.02
+			if tt.params.Since.IsZero() {
+				tt.params.Since = now.Add(-24 * time.Hour)
+			}
+
+			got, err := repo.FindDuplicate(ctx, tt.params)
+			require.NoError(t, err)
+
+			if tt.want == 0 {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, got.ID)
+		})
+	}
+}
+
+func TestReleaseRepo_FindGrabbedEpisodes(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+	now := time.Now().UTC()
+
+	store := func(title string, season int, episode int) int64 {
+		rls, err := repo.Store(ctx, &domain.Release{FilterStatus: domain.ReleaseStatusFilterApproved, TorrentName: "Release", Title: title, Season: season, Episode: episode, Timestamp: now.Add(-time.Hour), Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+		require.NoError(t, err)
+		return rls.ID
+	}
+
+	episode := store("That.Show", 1, 5)
+	pack := store("That Show", 1, 0)
+	store("That Show", 1, 6)
+	store("That Show", 2, 5)
+	store("Other Show", 1, 5)
+	store("", 1, 5)
+
+	grabbed, err := repo.FindGrabbedEpisodes(ctx, domain.EpisodeQuery{Since: now.Add(-24 * time.Hour), Title: domain.NormalizeName("That Show"), Season: 1, Episode: 5})
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, g := range grabbed {
+		ids = append(ids, g.ID)
+	}
+	assert.Equal(t, []int64{pack, episode}, ids)
+
+	grabbed, err = repo.FindGrabbedEpisodes(ctx, domain.EpisodeQuery{Since: now, Title: domain.NormalizeName("That Show"), Season: 1, Episode: 5})
+	require.NoError(t, err)
+	assert.Empty(t, grabbed)
+}
+
+func TestReleaseRepo_Find(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+	now := time.Now().UTC()
+
+	// the oldest first, the history is newest first
+	store := func(indexer string, filter string, status domain.ReleaseFilterStatus, rejections []string, age time.Duration) int64 {
+		rls, err := repo.Store(ctx, &domain.Release{TorrentName: "Release", Indexer: indexer, FilterName: filter, FilterStatus: status, Timestamp: now.Add(-age), Rejections: rejections, Artists: []string{}, Tags: []string{}})
+		require.NoError(t, err)
+		return rls.ID
+	}
+
+	pushed := store("a", "F1", domain.ReleaseStatusFilterApproved, []string{}, 4*time.Hour)
+	rejected := store("b", "F2", domain.ReleaseStatusFilterRejected, []string{"size: 50 GB above max 10 GB"}, 3*time.Hour)
+	pushRejected := store("a", "F2", domain.ReleaseStatusFilterApproved, []string{}, 2*time.Hour)
+	latest := store("b", "F1", domain.ReleaseStatusFilterRejected, []string{"resolution: 720p not in 1080p"}, time.Hour)
+
+	require.NoError(t, repo.StoreReleaseActionStatus(ctx, &domain.ReleaseActionStatus{Status: domain.ReleasePushStatusApproved, Action: "Action", Type: domain.ActionTypeTest, Rejections: []string{}, Timestamp: now, ReleaseID: pushed}))
+	require.NoError(t, repo.StoreReleaseActionStatus(ctx, &domain.ReleaseActionStatus{Status: domain.ReleasePushStatusRejected, Action: "Action", Type: domain.ActionTypeTest, Rejections: []string{"client full"}, Timestamp: now, ReleaseID: pushRejected}))
+
+	tests := []struct {
+		name    string
+		filters domain.ReleaseQueryFilters
+		want    []int64
+	}{
+		{
+			name: "all",
+			want: []int64{latest, pushRejected, rejected, pushed},
+		},
+		{
+			name:    "indexer",
+			filters: domain.ReleaseQueryFilters{Indexers: []string{"a"}},
+			want:    []int64{pushRejected, pushed},
+		},
+		{
+			name:    "filter_name",
+			filters: domain.ReleaseQueryFilters{FilterNames: []string{"F2"}},
+			want:    []int64{pushRejected, rejected},
+		},
+		{
+			name:    "filter_status",
+			filters: domain.ReleaseQueryFilters{FilterStatus: string(domain.ReleaseStatusFilterRejected)},
+			want:    []int64{latest, rejected},
+		},
+		{
+			name:    "push_status",
+			filters: domain.ReleaseQueryFilters{PushStatus: string(domain.ReleasePushStatusRejected)},
+			want:    []int64{pushRejected},
+		},
+		{
+			name:    "time_range",
+			filters: domain.ReleaseQueryFilters{From: now.Add(-3*time.Hour - time.Minute), To: now.Add(-90 * time.Minute)},
+			want:    []int64{pushRejected, rejected},
+		},
+		{
+			name:    "combined",
+			filters: domain.ReleaseQueryFilters{Indexers: []string{"b"}, FilterNames: []string{"F1"}, FilterStatus: string(domain.ReleaseStatusFilterRejected)},
+			want:    []int64{latest},
+		},
+		{
+			name:    "no_match",
+			filters: domain.ReleaseQueryFilters{Indexers: []string{"c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _, count, err := repo.Find(ctx, domain.ReleaseQueryParams{Filters: tt.filters})
+			require.NoError(t, err)
+
+			var ids []int64
+			for _, rls := range res {
+				ids = append(ids, rls.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+
+	t.Run("rejected_release", func(t *testing.T) {
+		res, _, _, err := repo.Find(ctx, domain.ReleaseQueryParams{Filters: domain.ReleaseQueryFilters{Indexers: []string{"b"}, FilterNames: []string{"F2"}}})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		assert.Equal(t, domain.ReleaseStatusFilterRejected, res[0].FilterStatus)
+		assert.Equal(t, []string{"size: 50 GB above max 10 GB"}, res[0].Rejections)
+		assert.Empty(t, res[0].ActionStatus)
+	})
+
+	t.Run("action_status", func(t *testing.T) {
+		res, _, _, err := repo.Find(ctx, domain.ReleaseQueryParams{Filters: domain.ReleaseQueryFilters{PushStatus: string(domain.ReleasePushStatusRejected)}})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Len(t, res[0].ActionStatus, 1)
+
+		assert.Equal(t, domain.ReleasePushStatusRejected, res[0].ActionStatus[0].Status)
+		assert.Equal(t, []string{"client full"}, res[0].ActionStatus[0].Rejections)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		res, cursor, count, err := repo.Find(ctx, domain.ReleaseQueryParams{Limit: 3})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		assert.Equal(t, int64(4), count)
+		assert.Equal(t, rejected, cursor)
+
+		res, cursor, count, err = repo.Find(ctx, domain.ReleaseQueryParams{Limit: 3, Cursor: uint64(cursor)})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, pushed, res[0].ID)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, pushed, cursor)
+
+		res, cursor, _, err = repo.Find(ctx, domain.ReleaseQueryParams{Limit: 3, Cursor: uint64(cursor)})
+		require.NoError(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, int64(0), cursor)
+	})
+}
+
+func TestReleaseRepo_Stats(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := func(indexer string, filter string, status domain.ReleaseFilterStatus, timestamp time.Time, pushes ...domain.ReleasePushStatus) {
+		rls, err := repo.Store(ctx, &domain.Release{TorrentName: "Release", Indexer: indexer, FilterName: filter, FilterStatus: status, Timestamp: timestamp, Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+		require.NoError(t, err)
+
+		for _, push := range pushes {
+			require.NoError(t, repo.StoreReleaseActionStatus(ctx, &domain.ReleaseActionStatus{Status: push, Action: "Action", Type: domain.ActionTypeTest, Rejections: []string{}, Timestamp: timestamp, ReleaseID: rls.ID}))
+		}
+	}
+
+	store("a", "F1", domain.ReleaseStatusFilterApproved, day, domain.ReleasePushStatusApproved, domain.ReleasePushStatusApproved)
+	store("a", "F2", domain.ReleaseStatusFilterRejected, day)
+	store("b", "F1", domain.ReleaseStatusFilterApproved, day.AddDate(0, 0, 1), domain.ReleasePushStatusRejected)
+	// the 3rd local time, still the 2nd in utc
+	store("b", "", domain.ReleaseStatusFilterRejected, time.Date(2024, 5, 3, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+	store("a", "F2", domain.ReleaseStatusFilterApproved, day.AddDate(0, 0, 2), domain.ReleasePushStatusApproved)
+
+	// seen, filtered, filter rejected, push approved, push rejected
+	counts := func(groups []domain.ReleaseStatsGroup) map[string][5]int64 {
+		res := make(map[string][5]int64)
+		for _, g := range groups {
+			res[g.Key] = [5]int64{g.TotalCount, g.FilteredCount, g.FilterRejectedCount, g.PushApprovedCount, g.PushRejectedCount}
+		}
+		return res
+	}
+
+	stats, err := repo.Stats(ctx, domain.ReleaseStatsParams{GroupBy: []domain.ReleaseStatsGroupBy{domain.ReleaseStatsGroupByIndexer, domain.ReleaseStatsGroupByFilter, domain.ReleaseStatsGroupByDay}})
+	require.NoError(t, err)
+
+	assert.Equal(t, [5]int64{5, 3, 2, 3, 1}, [5]int64{stats.TotalCount, stats.FilteredCount, stats.FilterRejectedCount, stats.PushApprovedCount, stats.PushRejectedCount})
+
+	assert.Equal(t, map[string][5]int64{
+		"a": {3, 2, 1, 3, 0},
+		"b": {2, 1, 1, 0, 1},
+	}, counts(stats.Indexers))
+
+	assert.Equal(t, map[string][5]int64{
+		"":   {1, 0, 1, 0, 0},
+		"F1": {2, 2, 0, 2, 1},
+		"F2": {2, 1, 1, 1, 0},
+	}, counts(stats.Filters))
+
+	assert.Equal(t, map[string][5]int64{
+		"2024-05-01": {2, 1, 1, 2, 0},
+		"2024-05-02": {2, 1, 1, 0, 1},
+		"2024-05-03": {1, 1, 0, 1, 0},
+	}, counts(stats.Days))
+
+	// ordered by key
+	require.Len(t, stats.Days, 3)
+	assert.Equal(t, "2024-05-01", stats.Days[0].Key)
+
+	// one indexer over the 2nd in utc
+	stats, err = repo.Stats(ctx, domain.ReleaseStatsParams{
+		Indexers: []string{"b"},
+		From:     time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 5, 2, 23, 59, 59, 0, time.UTC),
+		GroupBy:  []domain.ReleaseStatsGroupBy{domain.ReleaseStatsGroupByFilter},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, [5]int64{2, 1, 1, 0, 1}, [5]int64{stats.TotalCount, stats.FilteredCount, stats.FilterRejectedCount, stats.PushApprovedCount, stats.PushRejectedCount})
+	assert.Equal(t, map[string][5]int64{
+		"":   {1, 0, 1, 0, 0},
+		"F1": {1, 1, 0, 0, 1},
+	}, counts(stats.Filters))
+	assert.Empty(t, stats.Indexers)
+	assert.Empty(t, stats.Days)
+}
@@ -0,0 +1,423 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// encryptedPrefix marks encrypted values, values without it are plain text from before encryption was set up
+const encryptedPrefix = "enc:v1:"
+
+var ErrSecretsEncrypted = errors.New("database secrets are encrypted, set the encryption key")
+
+// secretColumns columns with credentials, encrypted when an encryption key is set.
+// These are the values a redacted backup leaves out as well.
+var secretColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"users", []string{"totp_secret"}},
+	{"irc_network", []string{"pass", "invite_command", "nickserv_password"}},
+	{"irc_channel", []string{"password"}},
+	{"indexer", []string{"settings", "accounts", "proxy"}},
+	{"client", []string{"password", "settings"}},
+	{"feed", []string{"api_key", "cookie", "headers"}},
+	{"action", []string{"exec_env", "webhook_headers"}},
+	{"notification", []string{"settings"}},
+	{"release", []string{"torrent_url"}}, // download links with the passkey
+}
+
+// secretBox aes-256-gcm with the data key, the data key itself is stored encrypted with the key from the config
+type secretBox struct {
+	aead cipher.AEAD
+}
+
+func newSecretBox(key []byte) (*secretBox, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretBox{aead: aead}, nil
+}
+
+// encryptionKey the key from the config as aes key, a base64 encoded 32 byte key is used as it is
+// and anything else is hashed so a long passphrase works too
+func encryptionKey(key string) []byte {
+	if b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key)); err == nil && len(b) == 32 {
+		return b
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+func (b *secretBox) seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *secretBox) open(ciphertext []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("encrypted value too short")
+	}
+
+	return b.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
+
+func (b *secretBox) encrypt(value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	sealed, err := b.seal([]byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt the value, plain text is returned as it is. A nil box can only read plain text.
+func (b *secretBox) decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	if b == nil {
+		return "", ErrSecretsEncrypted
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	plaintext, err := b.open(sealed)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// secret a value for a secret column, encrypted when it is written. Takes a string, []byte or sql.NullString.
+func (db *DB) secret(value interface{}) driver.Valuer {
+	return secretValue{db: db, value: value}
+}
+
+type secretValue struct {
+	db    *DB
+	value interface{}
+}
+
+func (v secretValue) Value() (driver.Value, error) {
+	var s string
+	switch value := v.value.(type) {
+	case string:
+		s = value
+	case []byte:
+		s = string(value)
+	case sql.NullString:
+		if !value.Valid {
+			return nil, nil
+		}
+		s = value.String
+	default:
+		return nil, fmt.Errorf("unsupported secret type %T", v.value)
+	}
+
+	if v.db.secrets == nil {
+		return s, nil
+	}
+
+	return v.db.secrets.encrypt(s)
+}
+
+// scanSecret scan a secret column into a *string or *sql.NullString and decrypt it
+func (db *DB) scanSecret(dest interface{}) sql.Scanner {
+	return secretScanner{db: db, dest: dest}
+}
+
+type secretScanner struct {
+	db   *DB
+	dest interface{}
+}
+
+func (s secretScanner) Scan(src interface{}) error {
+	var value sql.NullString
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+
+	plaintext, err := s.db.secrets.decrypt(value.String)
+	if err != nil {
+		return err
+	}
+
+	switch dest := s.dest.(type) {
+	case *string:
+		*dest = plaintext
+	case *sql.NullString:
+		*dest = sql.NullString{String: plaintext, Valid: value.Valid}
+	default:
+		return fmt.Errorf("unsupported secret destination %T", s.dest)
+	}
+
+	return nil
+}
+
+// SetupEncryption encrypt secrets with the key from the config. The first time a data key is created
+// and existing secrets are encrypted, an empty key only works for databases without encrypted secrets.
+func (db *DB) SetupEncryption(ctx context.Context, key string) error {
+	wrapped, err := db.wrappedDataKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if key == "" {
+		if wrapped != "" {
+			return ErrSecretsEncrypted
+		}
+		return nil
+	}
+
+	keyBox, err := newSecretBox(encryptionKey(key))
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var box *secretBox
+	if wrapped == "" {
+		box, wrapped, err = newDataKey(keyBox)
+		if err != nil {
+			return err
+		}
+
+		if err := storeDataKey(ctx, tx, wrapped); err != nil {
+			return err
+		}
+
+		log.Info().Msg("database encryption key created")
+	} else {
+		box, err = openDataKey(keyBox, wrapped)
+		if err != nil {
+			return err
+		}
+	}
+
+	// secrets stored before encryption was set up, or written by tools without the key
+	if err := reencryptSecrets(ctx, tx, box, box); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	db.secrets = box
+
+	return nil
+}
+
+// Rekey encrypt every secret with a new data key and protect that with the new key, the old key stops working
+func (db *DB) Rekey(ctx context.Context, oldKey string, newKey string) error {
+	if newKey == "" {
+		return errors.New("new encryption key required")
+	}
+
+	wrapped, err := db.wrappedDataKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	var oldBox *secretBox
+	if wrapped != "" {
+		oldKeyBox, err := newSecretBox(encryptionKey(oldKey))
+		if err != nil {
+			return err
+		}
+
+		if oldBox, err = openDataKey(oldKeyBox, wrapped); err != nil {
+			return err
+		}
+	}
+
+	newKeyBox, err := newSecretBox(encryptionKey(newKey))
+	if err != nil {
+		return err
+	}
+
+	newBox, newWrapped, err := newDataKey(newKeyBox)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reencryptSecrets(ctx, tx, oldBox, newBox); err != nil {
+		return err
+	}
+
+	if err := storeDataKey(ctx, tx, newWrapped); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	db.secrets = newBox
+
+	return nil
+}
+
+func (db *DB) wrappedDataKey(ctx context.Context) (string, error) {
+	var wrapped string
+	err := db.handler.QueryRowContext(ctx, `SELECT wrapped_key FROM encryption_key ORDER BY id DESC LIMIT 1`).Scan(&wrapped)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	return wrapped, nil
+}
+
+func newDataKey(keyBox *secretBox) (*secretBox, string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, "", err
+	}
+
+	box, err := newSecretBox(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sealed, err := keyBox.seal(dataKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return box, base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openDataKey(keyBox *secretBox, wrapped string) (*secretBox, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+
+	dataKey, err := keyBox.open(sealed)
+	if err != nil {
+		return nil, errors.New("wrong encryption key, it does not match the key the secrets were encrypted with")
+	}
+
+	return newSecretBox(dataKey)
+}
+
+// storeDataKey replace the data key, there is only ever one
+func storeDataKey(ctx context.Context, tx *Tx, wrapped string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM encryption_key`); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO encryption_key (wrapped_key, created_at) VALUES (?, ?)`, wrapped, time.Now().UTC())
+	return err
+}
+
+// reencryptSecrets decrypt every secret column with from and encrypt it with to, plain text values are encrypted
+func reencryptSecrets(ctx context.Context, tx *Tx, from *secretBox, to *secretBox) error {
+	for _, secret := range secretColumns {
+		for _, column := range secret.columns {
+			if err := reencryptColumn(ctx, tx, secret.table, column, from, to); err != nil {
+				return fmt.Errorf("could not encrypt %v.%v: %w", secret.table, column, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func reencryptColumn(ctx context.Context, tx *Tx, table string, column string, from *secretBox, to *secretBox) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT id, %v FROM "%v" WHERE %[1]v IS NOT NULL AND %[1]v != ''`, column, table))
+	if err != nil {
+		return err
+	}
+
+	updated := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+
+		// already encrypted with the same key
+		if from == to && strings.HasPrefix(value, encryptedPrefix) {
+			continue
+		}
+
+		plaintext, err := from.decrypt(value)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		encrypted, err := to.encrypt(plaintext)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		if encrypted != value {
+			updated[id] = encrypted
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, value := range updated {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE "%v" SET %v = ? WHERE id = ?`, table, column), value, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
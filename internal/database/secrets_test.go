@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestSecretBox(t *testing.T) {
+	box, err := newSecretBox(encryptionKey("a long passphrase"))
+	require.NoError(t, err)
+
+	encrypted, err := box.encrypt("passkey")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, encryptedPrefix))
+
+	plaintext, err := box.decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "passkey", plaintext)
+
+	empty, err := box.encrypt("")
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	// stored before encryption was set up
+	plaintext, err = box.decrypt("passkey")
+	require.NoError(t, err)
+	assert.Equal(t, "passkey", plaintext)
+
+	var noKey *secretBox
+	_, err = noKey.decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrSecretsEncrypted)
+
+	other, err := newSecretBox(encryptionKey("another passphrase"))
+	require.NoError(t, err)
+	_, err = other.decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestEncryptionKey(t *testing.T) {
+	raw := []byte("0123456789abcdef0123456789abcdef")
+
+	assert.Equal(t, raw, encryptionKey(base64.StdEncoding.EncodeToString(raw)))
+	assert.Len(t, encryptionKey("passphrase"), 32)
+}
+
+func TestDB_SetupEncryption(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db := NewSqliteDB(dir)
+	require.NoError(t, db.Open())
+
+	repo := NewIrcRepo(db)
+	network := &domain.IrcNetwork{Name: "Network", Server: "irc.example.com", Port: 6697, Pass: "server-pass", NickServ: domain.NickServ{Account: "bot", Password: "nickserv-pass"}}
+	require.NoError(t, repo.StoreNetwork(network))
+
+	require.NoError(t, db.SetupEncryption(ctx, "first key"))
+
+	var pass string
+	require.NoError(t, db.handler.QueryRow(`SELECT nickserv_password FROM irc_network WHERE id = ?`, network.ID).Scan(&pass))
+	assert.True(t, strings.HasPrefix(pass, encryptedPrefix), "existing secrets are encrypted")
+
+	stored, err := repo.GetNetworkByID(network.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "server-pass", stored.Pass)
+	assert.Equal(t, "nickserv-pass", stored.NickServ.Password)
+
+	require.NoError(t, db.Rekey(ctx, "first key", "second key"))
+	require.NoError(t, db.Close())
+
+	db = NewSqliteDB(dir)
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	assert.ErrorIs(t, db.SetupEncryption(ctx, ""), ErrSecretsEncrypted)
+	assert.Error(t, db.SetupEncryption(ctx, "first key"))
+	require.NoError(t, db.SetupEncryption(ctx, "second key"))
+
+	stored, err = NewIrcRepo(db).GetNetworkByID(network.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "nickserv-pass", stored.NickServ.Password)
+}
+
+func TestDB_SetupEncryption_releaseTorrentURL(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewReleaseRepo(db)
+
+	// grabbed before encryption was set up
+	before, err := repo.Store(ctx, &domain.Release{TorrentName: "Release", TorrentURL: "https://tracker.example.com/dl/1?passkey=secret", Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetupEncryption(ctx, "key"))
+
+	after, err := repo.Store(ctx, &domain.Release{TorrentName: "Release", TorrentURL: "https://tracker.example.com/dl/2?passkey=secret", Rejections: []string{}, Artists: []string{}, Tags: []string{}})
+	require.NoError(t, err)
+
+	for _, rls := range []*domain.Release{before, after} {
+		var url string
+		require.NoError(t, db.handler.QueryRow(`SELECT torrent_url FROM "release" WHERE id = ?`, rls.ID).Scan(&url))
+		assert.True(t, strings.HasPrefix(url, encryptedPrefix))
+
+		stored, err := repo.FindByID(ctx, rls.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rls.TorrentURL, stored.TorrentURL)
+	}
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type SessionRepo struct {
+	db *DB
+}
+
+func NewSessionRepo(db *DB) domain.SessionRepo {
+	return &SessionRepo{db: db}
+}
+
+var sessionColumns = []string{"id", "token_hash", "username", "role", "auth_method", "ip", "user_agent", "created_at", "last_seen_at", "expires_at"}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	var s domain.Session
+	var role, authMethod, ip, userAgent sql.NullString
+	var createdAt, lastSeenAt sql.NullTime
+
+	if err := row.Scan(&s.ID, &s.TokenHash, &s.Username, &role, &authMethod, &ip, &userAgent, &createdAt, &lastSeenAt, &s.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	s.Role = domain.UserRole(role.String)
+	s.AuthMethod = authMethod.String
+	s.IP = ip.String
+	s.UserAgent = userAgent.String
+	s.CreatedAt = createdAt.Time
+	s.LastSeenAt = lastSeenAt.Time
+
+	return &s, nil
+}
+
+func (r *SessionRepo) Store(ctx context.Context, session domain.Session) (*domain.Session, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.
+		Insert("sessions").
+		Columns("token_hash", "username", "role", "auth_method", "ip", "user_agent", "created_at", "last_seen_at", "expires_at").
+		Values(session.TokenHash, session.Username, session.Role, session.AuthMethod, session.IP, session.UserAgent, session.CreatedAt.UTC(), session.LastSeenAt.UTC(), session.ExpiresAt.UTC()).
+		Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.store: error building query")
+		return nil, err
+	}
+
+	err = r.db.handler.QueryRowContext(ctx, query, args...).Scan(&session.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.store: error executing query")
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepo) FindByID(ctx context.Context, id int) (*domain.Session, error) {
+	return r.findOne(ctx, sq.Eq{"id": id})
+}
+
+func (r *SessionRepo) FindByToken(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	return r.findOne(ctx, sq.Eq{"token_hash": tokenHash})
+}
+
+func (r *SessionRepo) findOne(ctx context.Context, where sq.Eq) (*domain.Session, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query, args, err := sq.Select(sessionColumns...).From("sessions").Where(where).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.find: error building query")
+		return nil, err
+	}
+
+	session, err := scanSession(r.db.handler.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Error().Stack().Err(err).Msg("session.find: error scanning row")
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// List sessions of the user signed in with authMethod, every session when username is empty
+func (r *SessionRepo) List(ctx context.Context, username string, authMethod string) ([]domain.Session, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	queryBuilder := sq.Select(sessionColumns...).From("sessions").OrderBy("last_seen_at DESC")
+	if username != "" {
+		queryBuilder = queryBuilder.Where(sq.Eq{"username": username, "auth_method": authMethod})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.list: error building query")
+		return nil, err
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.list: error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := make([]domain.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("session.list: error scanning row")
+			return nil, err
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepo) Touch(ctx context.Context, id int, lastSeen time.Time) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `UPDATE sessions SET last_seen_at = ? WHERE id = ?`
+	if _, err := r.db.handler.ExecContext(ctx, query, lastSeen.UTC(), id); err != nil {
+		log.Error().Stack().Err(err).Msg("session.touch: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) Delete(ctx context.Context, id int) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `DELETE FROM sessions WHERE id = ?`
+	if _, err := r.db.handler.ExecContext(ctx, query, id); err != nil {
+		log.Error().Stack().Err(err).Msg("session.delete: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteByUsername sign out every session of the user signed in with authMethod except one, usually the one making the request
+func (r *SessionRepo) DeleteByUsername(ctx context.Context, username string, authMethod string, exceptID int) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `DELETE FROM sessions WHERE username = ? AND auth_method = ? AND id != ?`
+	if _, err := r.db.handler.ExecContext(ctx, query, username, authMethod, exceptID); err != nil {
+		log.Error().Stack().Err(err).Msg("session.delete: error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpired remove sessions past their expiry or last seen before idleBefore, a zero idleBefore skips the idle check
+func (r *SessionRepo) DeleteExpired(ctx context.Context, now time.Time, idleBefore time.Time) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	expired := sq.Or{r.db.compareTime("expires_at", "<=", now)}
+	if !idleBefore.IsZero() {
+		expired = append(expired, r.db.compareTime("last_seen_at", "<", idleBefore))
+	}
+
+	query, args, err := sq.Delete("sessions").Where(expired).ToSql()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.deleteExpired: error building query")
+		return err
+	}
+
+	res, err := r.db.handler.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("session.deleteExpired: error executing query")
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		log.Debug().Msgf("session: removed %d expired sessions", rows)
+	}
+
+	return nil
+}
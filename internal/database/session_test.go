@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestSessionRepo_authMethod(t *testing.T) {
+	ctx := context.Background()
+
+	db := NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	repo := NewSessionRepo(db)
+	expires := time.Now().Add(time.Hour)
+
+	local, err := repo.Store(ctx, domain.Session{TokenHash: "a", Username: "admin", Role: domain.UserRoleAdmin, AuthMethod: "password", ExpiresAt: expires})
+	require.NoError(t, err)
+
+	// a provider account with the same name, signed in before oidc usernames were namespaced
+	_, err = repo.Store(ctx, domain.Session{TokenHash: "b", Username: "admin", Role: domain.UserRoleReadOnly, AuthMethod: "oidc", ExpiresAt: expires})
+	require.NoError(t, err)
+
+	list, err := repo.List(ctx, "admin", "oidc")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "oidc", list[0].AuthMethod)
+
+	require.NoError(t, repo.DeleteByUsername(ctx, "admin", "oidc", 0))
+
+	list, err = repo.List(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, local.ID, list[0].ID)
+}
@@ -2,38 +2,112 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/autobrr/internal/domain"
 )
 
 type UserRepo struct {
-	db *SqliteDB
+	db *DB
 }
 
-func NewUserRepo(db *SqliteDB) domain.UserRepo {
+func NewUserRepo(db *DB) domain.UserRepo {
 	return &UserRepo{db: db}
 }
 
+const userColumns = `id, username, password, role, totp_secret, totp_enabled, recovery_codes, created_at`
+
+func (r *UserRepo) scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	var role, totpSecret sql.NullString
+	var totpEnabled sql.NullBool
+	var createdAt sql.NullTime
+
+	if err := row.Scan(&user.ID, &user.Username, &user.Password, &role, r.db.scanSecret(&totpSecret), &totpEnabled, pq.Array(&user.RecoveryCodes), &createdAt); err != nil {
+		return nil, err
+	}
+
+	user.Role = domain.UserRole(role.String)
+	user.TOTPSecret = totpSecret.String
+	user.TOTPEnabled = totpEnabled.Bool
+	user.CreatedAt = createdAt.Time
+
+	return &user, nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, id int) (*domain.User, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+
+	row := r.db.handler.QueryRowContext(ctx, query, id)
+	if err := row.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := r.scanUser(row)
+	if err != nil {
+		log.Error().Err(err).Msg("could not scan user to struct")
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (r *UserRepo) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
 	//r.db.lock.RLock()
 	//defer r.db.lock.RUnlock()
 
-	query := `SELECT id, username, password FROM users WHERE username = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
 
 	row := r.db.handler.QueryRowContext(ctx, query, username)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
 
-	var user domain.User
-
-	if err := row.Scan(&user.ID, &user.Username, &user.Password); err != nil {
+	user, err := r.scanUser(row)
+	if err != nil {
 		log.Error().Err(err).Msg("could not scan user to struct")
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+func (r *UserRepo) List(ctx context.Context) ([]domain.User, error) {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY username`
+
+	rows, err := r.db.handler.QueryContext(ctx, query)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := make([]domain.User, 0)
+	for rows.Next() {
+		user, err := r.scanUser(rows)
+		if err != nil {
+			log.Error().Err(err).Msg("could not scan user to struct")
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
 }
 
 func (r *UserRepo) Store(ctx context.Context, user domain.User) error {
@@ -50,8 +124,14 @@ func (r *UserRepo) Store(ctx context.Context, user domain.User) error {
 		}
 
 	} else {
-		query := `INSERT INTO users (username, password) VALUES (?, ?)`
-		_, err = r.db.handler.ExecContext(ctx, query, user.Username, user.Password)
+		// users created without a role, like the first user from autobrrctl, are admins
+		role := user.Role
+		if role == "" {
+			role = domain.UserRoleAdmin
+		}
+
+		query := `INSERT INTO users (username, password, role) VALUES (?, ?, ?)`
+		_, err = r.db.handler.ExecContext(ctx, query, user.Username, user.Password, role)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("error executing query")
 			return err
@@ -60,3 +140,51 @@ func (r *UserRepo) Store(ctx context.Context, user domain.User) error {
 
 	return err
 }
+
+func (r *UserRepo) UpdateRole(ctx context.Context, id int, role domain.UserRole) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `UPDATE users SET role = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := r.db.handler.ExecContext(ctx, query, role, id)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTOTP store the totp secret, state and recovery code hashes of the user
+func (r *UserRepo) UpdateTOTP(ctx context.Context, user domain.User) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	recoveryCodes := user.RecoveryCodes
+	if recoveryCodes == nil {
+		recoveryCodes = []string{}
+	}
+
+	query := `UPDATE users SET totp_secret = ?, totp_enabled = ?, recovery_codes = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := r.db.handler.ExecContext(ctx, query, r.db.secret(user.TOTPSecret), user.TOTPEnabled, pq.Array(recoveryCodes), user.ID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepo) Delete(ctx context.Context, id int) error {
+	//r.db.lock.RLock()
+	//defer r.db.lock.RUnlock()
+
+	query := `DELETE FROM users WHERE id = ?`
+	_, err := r.db.handler.ExecContext(ctx, query, id)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("error executing query")
+		return err
+	}
+
+	return nil
+}
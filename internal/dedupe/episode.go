@@ -0,0 +1,115 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// DefaultEpisodeWindow how far back grabbed episodes are remembered when the filter does not set a window
+const DefaultEpisodeWindow = 7 * 24 * time.Hour
+
+// checkEpisode returns why the release is not better than an episode or season pack grabbed within the window
+func (s *service) checkEpisode(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+	// daily shows and movies have no season or episode to compare
+	if release.Season == 0 && release.Episode == 0 {
+		return "", nil
+	}
+
+	window := DefaultEpisodeWindow
+	if f.SmartEpisodeWindow > 0 {
+		window = time.Duration(f.SmartEpisodeWindow) * time.Hour
+	}
+
+	// an untitled release can't be told apart from other shows
+	title := domain.NormalizeName(release.Title)
+	if title == "" {
+		return "", nil
+	}
+
+	grabbed, err := s.releaseRepo.FindGrabbedEpisodes(ctx, domain.EpisodeQuery{
+		ExcludeID: release.ID,
+		Since:     time.Now().Add(-window),
+		Title:     title,
+		Season:    release.Season,
+		Episode:   release.Episode,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not find grabbed episodes: %w", err)
+	}
+
+	quality := episodeQuality(release)
+
+	for _, grab := range grabbed {
+		if quality.better(episodeQuality(&grab)) {
+			continue
+		}
+
+		return fmt.Sprintf("episode already grabbed in equal or better quality: '%v' from %v", grab.TorrentName, grab.Indexer), nil
+	}
+
+	return "", nil
+}
+
+type quality struct {
+	resolution int
+	source     int
+	revision   int // proper and repack
+}
+
+// better compare by resolution, then source, then proper or repack
+func (q quality) better(other quality) bool {
+	if q.resolution != other.resolution {
+		return q.resolution > other.resolution
+	}
+
+	if q.source != other.source {
+		return q.source > other.source
+	}
+
+	return q.revision > other.revision
+}
+
+var resolutionRanks = map[string]int{
+	"480p":  1,
+	"576p":  2,
+	"720p":  3,
+	"1080i": 4,
+	"1080p": 5,
+	"2160p": 6,
+}
+
+// sourceRanks substrings of the parsed source, checked in order
+var sourceRanks = []struct {
+	name string
+	rank int
+}{
+	{name: "remux", rank: 6},
+	{name: "bluray", rank: 5},
+	{name: "web-dl", rank: 4},
+	{name: "webrip", rank: 3},
+	{name: "web", rank: 3},
+	{name: "hdtv", rank: 2},
+	{name: "dvd", rank: 1},
+}
+
+func episodeQuality(r *domain.Release) quality {
+	q := quality{resolution: resolutionRanks[strings.ToLower(r.Resolution)]}
+
+	source := strings.ToLower(strings.ReplaceAll(r.Source, " ", ""))
+	for _, s := range sourceRanks {
+		if strings.Contains(source, s.name) {
+			q.source = s.rank
+			break
+		}
+	}
+
+	if r.Proper || r.Repack {
+		q.revision = 1
+	}
+
+	return q
+}
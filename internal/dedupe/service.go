@@ -0,0 +1,98 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	// DefaultWindow how far back to look for duplicates when the filter does not set a window
+	DefaultWindow = 24 * time.Hour
+
+	// sizeTolerance sizes announced by different indexers are rounded differently
+	sizeTolerance = 0.02
+)
+
+type Service interface {
+	Check(ctx context.Context, f domain.Filter, release *domain.Release) (string, error)
+}
+
+type service struct {
+	releaseRepo domain.ReleaseRepo
+}
+
+func NewService(releaseRepo domain.ReleaseRepo) Service {
+	return &service{releaseRepo: releaseRepo}
+}
+
+// Check returns why the release is a duplicate of one already grabbed within the filter window, or an episode
+// already grabbed in equal or better quality. An empty string if it is not or the filter does not skip them.
+// Releases from preferred indexers are still grabbed unless the earlier grab came from a preferred indexer too.
+func (s *service) Check(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+	if f.SkipDuplicates {
+		rejection, err := s.checkDuplicate(ctx, f, release)
+		if err != nil || rejection != "" {
+			return rejection, err
+		}
+	}
+
+	if f.SmartEpisode {
+		return s.checkEpisode(ctx, f, release)
+	}
+
+	return "", nil
+}
+
+// checkDuplicate returns why the release is a duplicate of a release grabbed within the window
+func (s *service) checkDuplicate(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+
+	window := DefaultWindow
+	if f.DuplicateWindow > 0 {
+		window = time.Duration(f.DuplicateWindow) * time.Hour
+	}
+
+	params := domain.DuplicateQuery{
+		ExcludeID:     release.ID,
+		Since:         time.Now().Add(-window),
+		InfoHash:      release.TorrentHash,
+		Name:          domain.NormalizeName(release.TorrentName),
+		Size:          release.Size,
+		SizeTolerance: sizeTolerance,
+	}
+
+	// a grab from a non-preferred indexer does not stop one from a preferred indexer
+	preferred := preferredIndexers(f.DuplicatePreferIndexers)
+	for _, indexer := range preferred {
+		if indexer == release.Indexer {
+			params.Indexers = preferred
+			break
+		}
+	}
+
+	grab, err := s.releaseRepo.FindDuplicate(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("could not find duplicate releases: %w", err)
+	}
+
+	if grab == nil {
+		return "", nil
+	}
+
+	return fmt.Sprintf("duplicate of '%v' grabbed from %v at %v", grab.TorrentName, grab.Indexer, grab.Timestamp.Format("2006-01-02 15:04:05")), nil
+}
+
+func preferredIndexers(value string) []string {
+	var indexers []string
+
+	for _, indexer := range strings.Split(value, ",") {
+		if indexer = strings.TrimSpace(indexer); indexer != "" {
+			indexers = append(indexers, indexer)
+		}
+	}
+
+	return indexers
+}
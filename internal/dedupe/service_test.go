@@ -0,0 +1,54 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestEpisodeQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		release domain.Release
+		grabbed domain.Release
+		want    bool
+	}{
+		{
+			name:    "higher_resolution",
+			release: domain.Release{Resolution: "2160p", Source: "WEB-DL"},
+			grabbed: domain.Release{Resolution: "1080p", Source: "WEB-DL"},
+			want:    true,
+		},
+		{
+			name:    "better_source",
+			release: domain.Release{Resolution: "1080p", Source: "WEB-DL"},
+			grabbed: domain.Release{Resolution: "1080p", Source: "HDTV"},
+			want:    true,
+		},
+		{
+			name:    "same",
+			release: domain.Release{Resolution: "1080p", Source: "WEBRip"},
+			grabbed: domain.Release{Resolution: "1080p", Source: "WEBRip"},
+			want:    false,
+		},
+		{
+			name:    "proper",
+			release: domain.Release{Resolution: "1080p", Source: "WEB-DL", Proper: true},
+			grabbed: domain.Release{Resolution: "1080p", Source: "WEB-DL"},
+			want:    true,
+		},
+		{
+			name:    "lower_resolution_better_source",
+			release: domain.Release{Resolution: "720p", Source: "BluRay"},
+			grabbed: domain.Release{Resolution: "1080p", Source: "HDTV"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, episodeQuality(&tt.release).better(episodeQuality(&tt.grabbed)))
+		})
+	}
+}
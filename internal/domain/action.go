@@ -1,6 +1,11 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 type ActionRepo interface {
 	Store(ctx context.Context, action Action) (*Action, error)
@@ -12,36 +17,216 @@ type ActionRepo interface {
 	ToggleEnabled(actionID int) error
 }
 
+type ActionQueueRepo interface {
+	Store(ctx context.Context, item *ActionQueueItem) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]ActionQueueItem, error)
+	Update(ctx context.Context, item *ActionQueueItem) error
+	FindByID(ctx context.Context, id int64) (*ActionQueueItem, error)
+	ListPending(ctx context.Context) ([]PendingAction, error)
+}
+
+// ErrActionNotWaiting the queued action already runs or waits for a worker, it can't be run early or cancelled
+var ErrActionNotWaiting = errors.New("action is not waiting, it is running or about to")
+
 type Action struct {
-	ID                 int        `json:"id"`
-	Name               string     `json:"name"`
-	Type               ActionType `json:"type"`
-	Enabled            bool       `json:"enabled"`
-	ExecCmd            string     `json:"exec_cmd,omitempty"`
-	ExecArgs           string     `json:"exec_args,omitempty"`
-	WatchFolder        string     `json:"watch_folder,omitempty"`
-	Category           string     `json:"category,omitempty"`
-	Tags               string     `json:"tags,omitempty"`
-	Label              string     `json:"label,omitempty"`
-	SavePath           string     `json:"save_path,omitempty"`
-	Paused             bool       `json:"paused,omitempty"`
-	IgnoreRules        bool       `json:"ignore_rules,omitempty"`
-	LimitUploadSpeed   int64      `json:"limit_upload_speed,omitempty"`
-	LimitDownloadSpeed int64      `json:"limit_download_speed,omitempty"`
-	FilterID           int        `json:"filter_id,omitempty"`
-	ClientID           int32      `json:"client_id,omitempty"`
+	ID                    int                `json:"id"`
+	Name                  string             `json:"name"`
+	Type                  ActionType         `json:"type"`
+	Enabled               bool               `json:"enabled"`
+	ExecCmd               string             `json:"exec_cmd,omitempty"`
+	ExecArgs              string             `json:"exec_args,omitempty"`
+	ExecWorkDir           string             `json:"exec_work_dir,omitempty"`
+	ExecEnv               string             `json:"exec_env,omitempty"`     // KEY=VALUE per line
+	ExecTimeout           int                `json:"exec_timeout,omitempty"` // seconds
+	WatchFolder           string             `json:"watch_folder,omitempty"`
+	WatchFolderFileName   string             `json:"watch_folder_file_name,omitempty"`
+	Category              string             `json:"category,omitempty"`
+	Tags                  string             `json:"tags,omitempty"`
+	Label                 string             `json:"label,omitempty"`
+	SavePath              string             `json:"save_path,omitempty"`
+	MoveCompletedPath     string             `json:"move_completed_path,omitempty"`
+	Paused                bool               `json:"paused,omitempty"`
+	FastResume            bool               `json:"fast_resume,omitempty"`
+	SkipHashCheck         bool               `json:"skip_hash_check,omitempty"`
+	SequentialDownload    bool               `json:"sequential_download,omitempty"`
+	CrossSeed             bool               `json:"cross_seed,omitempty"` // add to the data of a matching torrent, qBittorrent only
+	IgnoreRules           bool               `json:"ignore_rules,omitempty"`
+	LimitUploadSpeed      int64              `json:"limit_upload_speed,omitempty"`
+	LimitDownloadSpeed    int64              `json:"limit_download_speed,omitempty"`
+	BandwidthPriority     int                `json:"bandwidth_priority,omitempty"` // -1 low, 0 normal, 1 high
+	WebhookHost           string             `json:"webhook_host,omitempty"`
+	WebhookType           WebhookType        `json:"webhook_type,omitempty"`
+	WebhookMethod         string             `json:"webhook_method,omitempty"`
+	WebhookData           string             `json:"webhook_data,omitempty"`
+	WebhookHeaders        map[string]string  `json:"webhook_headers,omitempty"`
+	WebhookRetryAttempts  int                `json:"webhook_retry_attempts,omitempty"`
+	WebhookRetryDelay     int                `json:"webhook_retry_delay,omitempty"` // seconds
+	RunCondition          ActionRunCondition `json:"run_condition,omitempty"`
+	ReannounceSkip        bool               `json:"reannounce_skip"`
+	ReannounceDelete      bool               `json:"reannounce_delete"`
+	ReannounceInterval    int                `json:"reannounce_interval"` // seconds
+	ReannounceMaxAttempts int                `json:"reannounce_max_attempts"`
+	Delay                 int                `json:"delay,omitempty"`          // seconds
+	ScheduleStart         string             `json:"schedule_start,omitempty"` // HH:MM
+	ScheduleEnd           string             `json:"schedule_end,omitempty"`   // HH:MM
+	FilterID              int                `json:"filter_id,omitempty"`
+	ClientID              int32              `json:"client_id,omitempty"`
+}
+
+// ActionQueueSettings the workers that run action chains
+type ActionQueueSettings struct {
+	Workers int // action chains that run at the same time
+	Size    int // chains waiting for a worker, adding more blocks until a worker is free
+}
+
+// ActionQueueItem an action waiting for its delay or schedule window, stored so it survives a restart
+type ActionQueueItem struct {
+	ID        int64     `json:"id"`
+	ReleaseID int64     `json:"release_id"`
+	ActionID  int       `json:"action_id"`
+	LastOK    bool      `json:"last_ok"` // outcome of the action before it in the chain
+	RunAt     time.Time `json:"run_at"`
+	Scheduled bool      `json:"scheduled"` // the action already waited for its delay or schedule window
+	Attempts  int       `json:"attempts"`  // runs that were started but did not finish
+	Reason    string    `json:"reason"`    // why the action is held
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PendingAction a release held in the action queue
+type PendingAction struct {
+	ID          int64      `json:"id"`
+	ReleaseID   int64      `json:"release_id"`
+	TorrentName string     `json:"torrent_name"`
+	Indexer     string     `json:"indexer"`
+	FilterID    int        `json:"filter_id"`
+	Filter      string     `json:"filter"`
+	ActionID    int        `json:"action_id"`
+	Action      string     `json:"action"`
+	Type        ActionType `json:"type"`
+	Reason      string     `json:"reason"`
+	Attempts    int        `json:"attempts"`
+	RunAt       time.Time  `json:"run_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Waiting on its timer, only waiting actions can be run early or cancelled
+	Waiting bool `json:"waiting"`
+}
+
+// Redacted the action without the env and headers of exec and webhook actions, they often hold tokens
+func (a Action) Redacted() Action {
+	a.ExecEnv = ""
+	a.WebhookHeaders = nil
+	return a
+}
+
+// NextRunAt when the action may run for a release it reached at now. The delay is added first and if that
+// falls outside the schedule window it is moved to the next window start. A window can wrap past midnight.
+func (a Action) NextRunAt(now time.Time) (time.Time, error) {
+	runAt := now.Add(time.Duration(a.Delay) * time.Second)
+
+	if a.ScheduleStart == "" && a.ScheduleEnd == "" {
+		return runAt, nil
+	}
+
+	start, err := parseScheduleClock(a.ScheduleStart, 0)
+	if err != nil {
+		return runAt, fmt.Errorf("invalid schedule start: %w", err)
+	}
+
+	end, err := parseScheduleClock(a.ScheduleEnd, 24*60)
+	if err != nil {
+		return runAt, fmt.Errorf("invalid schedule end: %w", err)
+	}
+
+	minute := runAt.Hour()*60 + runAt.Minute()
+
+	var inWindow bool
+	switch {
+	case start == end:
+		inWindow = true
+	case start < end:
+		inWindow = minute >= start && minute < end
+	default:
+		inWindow = minute >= start || minute < end
+	}
+
+	if inWindow {
+		return runAt, nil
+	}
+
+	next := time.Date(runAt.Year(), runAt.Month(), runAt.Day(), start/60, start%60, 0, 0, runAt.Location())
+	if !next.After(runAt) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next, nil
+}
+
+// parseScheduleClock minutes since midnight for HH:MM, empty returns the fallback
+func parseScheduleClock(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
 }
 
 type ActionType string
 
 const (
-	ActionTypeTest        ActionType = "TEST"
-	ActionTypeExec        ActionType = "EXEC"
-	ActionTypeQbittorrent ActionType = "QBITTORRENT"
-	ActionTypeDelugeV1    ActionType = "DELUGE_V1"
-	ActionTypeDelugeV2    ActionType = "DELUGE_V2"
-	ActionTypeWatchFolder ActionType = "WATCH_FOLDER"
-	ActionTypeRadarr      ActionType = "RADARR"
-	ActionTypeSonarr      ActionType = "SONARR"
-	ActionTypeLidarr      ActionType = "LIDARR"
+	ActionTypeTest         ActionType = "TEST"
+	ActionTypeExec         ActionType = "EXEC"
+	ActionTypeQbittorrent  ActionType = "QBITTORRENT"
+	ActionTypeDelugeV1     ActionType = "DELUGE_V1"
+	ActionTypeDelugeV2     ActionType = "DELUGE_V2"
+	ActionTypeTransmission ActionType = "TRANSMISSION"
+	ActionTypeRTorrent     ActionType = "RTORRENT"
+	ActionTypeSabnzbd      ActionType = "SABNZBD"
+	ActionTypeNzbget       ActionType = "NZBGET"
+	ActionTypeWatchFolder  ActionType = "WATCH_FOLDER"
+	ActionTypeWebhook      ActionType = "WEBHOOK"
+	ActionTypeRadarr       ActionType = "RADARR"
+	ActionTypeSonarr       ActionType = "SONARR"
+	ActionTypeLidarr       ActionType = "LIDARR"
+	ActionTypeReadarr      ActionType = "READARR"
+	ActionTypeWhisparr     ActionType = "WHISPARR"
 )
+
+type WebhookType string
+
+const (
+	WebhookTypeJSON WebhookType = "JSON"
+	WebhookTypeForm WebhookType = "FORM"
+)
+
+// ActionRunCondition decides if an action runs based on the outcome of the action before it
+type ActionRunCondition string
+
+const (
+	ActionRunConditionAlways    ActionRunCondition = "ALWAYS"
+	ActionRunConditionOnSuccess ActionRunCondition = "ON_SUCCESS"
+	ActionRunConditionOnFailure ActionRunCondition = "ON_FAILURE"
+)
+
+// Always action does not depend on other actions, this is the default
+func (c ActionRunCondition) Always() bool {
+	return c != ActionRunConditionOnSuccess && c != ActionRunConditionOnFailure
+}
+
+// Match check the condition against the outcome of the last action that ran
+func (c ActionRunCondition) Match(lastOK bool) bool {
+	switch c {
+	case ActionRunConditionOnSuccess:
+		return lastOK
+	case ActionRunConditionOnFailure:
+		return !lastOK
+	default:
+		return true
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAction_NextRunAt(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2022, 3, 10, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		action  Action
+		now     time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "no_delay_no_schedule", action: Action{}, now: at(12, 0), want: at(12, 0)},
+		{name: "delay", action: Action{Delay: 120}, now: at(12, 0), want: at(12, 2)},
+		{name: "in_window", action: Action{ScheduleStart: "00:00", ScheduleEnd: "08:00"}, now: at(3, 30), want: at(3, 30)},
+		{name: "before_window", action: Action{ScheduleStart: "10:00", ScheduleEnd: "18:00"}, now: at(8, 0), want: at(10, 0)},
+		{name: "after_window", action: Action{ScheduleStart: "00:00", ScheduleEnd: "08:00"}, now: at(12, 0), want: at(0, 0).AddDate(0, 0, 1)},
+		{name: "window_end_exclusive", action: Action{ScheduleStart: "00:00", ScheduleEnd: "08:00"}, now: at(8, 0), want: at(0, 0).AddDate(0, 0, 1)},
+		{name: "wrapping_window_late", action: Action{ScheduleStart: "22:00", ScheduleEnd: "06:00"}, now: at(23, 0), want: at(23, 0)},
+		{name: "wrapping_window_early", action: Action{ScheduleStart: "22:00", ScheduleEnd: "06:00"}, now: at(5, 0), want: at(5, 0)},
+		{name: "wrapping_window_outside", action: Action{ScheduleStart: "22:00", ScheduleEnd: "06:00"}, now: at(12, 0), want: at(22, 0)},
+		{name: "delay_pushes_out_of_window", action: Action{Delay: 3600, ScheduleStart: "00:00", ScheduleEnd: "08:00"}, now: at(7, 30), want: at(0, 0).AddDate(0, 0, 1)},
+		{name: "only_end", action: Action{ScheduleEnd: "08:00"}, now: at(9, 0), want: at(0, 0).AddDate(0, 0, 1)},
+		{name: "invalid_schedule", action: Action{ScheduleStart: "8am"}, now: at(9, 0), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.action.NextRunAt(tt.now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
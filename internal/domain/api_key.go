@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type APIKeyRepo interface {
+	Store(ctx context.Context, key *APIKey) error
+	FindByKey(ctx context.Context, keyHash string) (*APIKey, error)
+	List(ctx context.Context, username string) ([]APIKey, error)
+	Touch(ctx context.Context, id int, lastUsed time.Time) error
+	Delete(ctx context.Context, id int) error
+}
+
+// APIKey lets scripts use the api as the user, only the hash of the key is stored
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	KeyHash    string     `json:"-"`
+	Prefix     string     `json:"prefix"` // first characters of the key, to tell keys apart
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
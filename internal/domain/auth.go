@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserRole what a signed in user is allowed to do
+type UserRole string
+
+const (
+	UserRoleAdmin    UserRole = "admin"
+	UserRoleOperator UserRole = "operator"
+	UserRoleReadOnly UserRole = "read-only"
+)
+
+// userRoleRanks higher ranks include the permissions of the lower ones
+var userRoleRanks = map[UserRole]int{
+	UserRoleReadOnly: 1,
+	UserRoleOperator: 2,
+	UserRoleAdmin:    3,
+}
+
+// Allows the role has at least the permissions of the required role
+func (r UserRole) Allows(required UserRole) bool {
+	rank, ok := userRoleRanks[r]
+	return ok && rank >= userRoleRanks[required]
+}
+
+// ParseUserRole case insensitive, readonly and viewer are accepted for read-only
+func ParseUserRole(value string) (UserRole, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "admin":
+		return UserRoleAdmin, nil
+	case "operator":
+		return UserRoleOperator, nil
+	case "read-only", "readonly", "viewer":
+		return UserRoleReadOnly, nil
+	}
+
+	return "", fmt.Errorf("unknown role %q", value)
+}
+
+// OIDCSettings single sign-on through an OpenID Connect provider
+type OIDCSettings struct {
+	Enabled      bool
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // <public url>/api/auth/oidc/callback
+	Scopes       []string
+	GroupsClaim  string
+	GroupRoles   map[string]UserRole // group name in lower case to role
+	DefaultRole  UserRole            // role of users without a mapped group, they can not sign in when empty
+}
+
+// RoleForGroups the highest role of the groups, the default role when none of them is mapped
+func (s OIDCSettings) RoleForGroups(groups []string) UserRole {
+	role := s.DefaultRole
+
+	for _, group := range groups {
+		mapped, ok := s.GroupRoles[strings.ToLower(group)]
+		if ok && userRoleRanks[mapped] > userRoleRanks[role] {
+			role = mapped
+		}
+	}
+
+	return role
+}
+
+// ParseOIDCGroupRoles comma separated "group:role" entries like "autobrr-admins:admin, media:read-only"
+func ParseOIDCGroupRoles(list string) (map[string]UserRole, error) {
+	roles := map[string]UserRole{}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("%q is not group:role", entry)
+		}
+
+		role, err := ParseUserRole(entry[i+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		roles[strings.ToLower(strings.TrimSpace(entry[:i]))] = role
+	}
+
+	return roles, nil
+}
+
+// OIDCIdentity user signed in through the provider
+type OIDCIdentity struct {
+	Subject  string   `json:"subject"`
+	Username string   `json:"username"` // namespaced subject, see OIDCUsername
+	Name     string   `json:"name"`     // preferred username, email or subject, for display only
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups"`
+	Role     UserRole `json:"role"`
+}
+
+// OIDCUsername the username of a provider account. It is namespaced so an account named like a local user
+// can't be taken for that user, local usernames can't contain a colon.
+func OIDCUsername(subject string) string {
+	return "oidc:" + subject
+}
+
+// OIDCLoginState kept in a cookie between the redirect to the provider and the callback
+type OIDCLoginState struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+type AuthAuditRepo interface {
+	Store(ctx context.Context, event *AuthEvent) error
+	Find(ctx context.Context, params AuthEventQueryParams) ([]AuthEvent, int64, error)
+}
+
+type AuthEventType string
+
+const (
+	AuthEventLoginSuccess AuthEventType = "login_success"
+	AuthEventLoginFailed  AuthEventType = "login_failed"
+	AuthEventLoginLocked  AuthEventType = "login_locked"
+	AuthEventOnboard      AuthEventType = "onboard"
+)
+
+// AuthEvent sign in attempt in the audit log
+type AuthEvent struct {
+	ID        int64         `json:"id"`
+	Type      AuthEventType `json:"type"`
+	Username  string        `json:"username"`
+	Method    string        `json:"method"` // password or oidc
+	IP        string        `json:"ip"`
+	UserAgent string        `json:"user_agent"`
+	Message   string        `json:"message"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+type AuthEventQueryParams struct {
+	Limit    uint64
+	Offset   uint64
+	Username string
+	IP       string
+	Type     AuthEventType
+	From     time.Time
+}
+
+// AuthClient where a sign in comes from, for rate limiting and the audit log
+type AuthClient struct {
+	IP        string
+	UserAgent string
+}
+
+// TrustedProxies reverse proxies in front of autobrr, only their X-Forwarded-For header is used for the client address
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies comma separated addresses or cidr ranges, like "127.0.0.1, 172.18.0.0/16"
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", entry)
+		}
+
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+// Contains the address is one of the proxies
+func (p TrustedProxies) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoginRequest username and password login, code is the totp or a recovery code
+type LoginRequest struct {
+	Username string
+	Password string
+	Code     string
+	Client   AuthClient
+}
+
+// LoginLimits failed logins allowed before the username or address is locked out
+type LoginLimits struct {
+	MaxAttempts      int // per username
+	MaxAttemptsPerIP int
+	Lockout          time.Duration // how long a lockout lasts, failures are counted within the same period
+}
+
+// LoginLockedError too many failed logins, no login is checked until the lockout ends
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, locked until %v", e.Until.Format(time.RFC3339))
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCSettings_RoleForGroups(t *testing.T) {
+	roles, err := ParseOIDCGroupRoles("autobrr-admins:admin, Media:operator,viewers:readonly")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]UserRole{"autobrr-admins": UserRoleAdmin, "media": UserRoleOperator, "viewers": UserRoleReadOnly}, roles)
+
+	settings := OIDCSettings{GroupRoles: roles}
+	assert.Equal(t, UserRoleAdmin, settings.RoleForGroups([]string{"viewers", "autobrr-admins"}))
+	assert.Equal(t, UserRoleOperator, settings.RoleForGroups([]string{"MEDIA", "other"}))
+	assert.Equal(t, UserRole(""), settings.RoleForGroups([]string{"other"}))
+
+	settings.DefaultRole = UserRoleReadOnly
+	assert.Equal(t, UserRoleReadOnly, settings.RoleForGroups(nil))
+
+	_, err = ParseOIDCGroupRoles("admins:root")
+	assert.Error(t, err)
+	_, err = ParseOIDCGroupRoles("admins")
+	assert.Error(t, err)
+}
+
+func TestConfig_OIDCSettings(t *testing.T) {
+	settings, err := Config{}.OIDCSettings()
+	assert.NoError(t, err)
+	assert.False(t, settings.Enabled)
+
+	_, err = Config{OIDCEnabled: true, OIDCIssuer: "https://auth.example.com"}.OIDCSettings()
+	assert.Error(t, err)
+
+	settings, err = Config{
+		OIDCEnabled:     true,
+		OIDCIssuer:      "https://auth.example.com",
+		OIDCClientID:    "autobrr",
+		OIDCRedirectURL: "https://autobrr.example.com/api/auth/oidc/callback",
+		OIDCDefaultRole: "read-only",
+	}.OIDCSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"openid", "profile", "email", "groups"}, settings.Scopes)
+	assert.Equal(t, "groups", settings.GroupsClaim)
+	assert.Equal(t, UserRoleReadOnly, settings.DefaultRole)
+}
+
+func TestUserRole_Allows(t *testing.T) {
+	assert.True(t, UserRoleAdmin.Allows(UserRoleOperator))
+	assert.True(t, UserRoleOperator.Allows(UserRoleOperator))
+	assert.True(t, UserRoleReadOnly.Allows(UserRoleReadOnly))
+	assert.False(t, UserRoleReadOnly.Allows(UserRoleOperator))
+	assert.False(t, UserRoleOperator.Allows(UserRoleAdmin))
+	assert.False(t, UserRole("").Allows(UserRoleReadOnly))
+}
+
+func TestValidateUsername(t *testing.T) {
+	assert.NoError(t, ValidateUsername("admin"))
+	assert.Error(t, ValidateUsername(" "))
+	assert.Error(t, ValidateUsername(OIDCUsername("admin")), "can't be taken for a provider account")
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("127.0.0.1, ::1, 172.18.0.0/16")
+	assert.NoError(t, err)
+
+	assert.True(t, proxies.Contains(net.ParseIP("127.0.0.1")))
+	assert.True(t, proxies.Contains(net.ParseIP("::1")))
+	assert.True(t, proxies.Contains(net.ParseIP("172.18.4.2")))
+	assert.False(t, proxies.Contains(net.ParseIP("127.0.0.2")))
+	assert.False(t, proxies.Contains(net.ParseIP("10.0.0.1")))
+	assert.False(t, proxies.Contains(nil))
+
+	none, err := ParseTrustedProxies("")
+	assert.NoError(t, err)
+	assert.False(t, none.Contains(net.ParseIP("127.0.0.1")))
+
+	_, err = ParseTrustedProxies("proxy.local")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// BackupManifest stored in the archive next to the database and config
+type BackupManifest struct {
+	Version   string    `json:"version"`
+	Schema    int       `json:"schema"`
+	CreatedAt time.Time `json:"created_at"`
+	Redacted  bool      `json:"redacted"`
+}
+
+// BackupOptions what goes into a backup
+type BackupOptions struct {
+	// Redact blank passwords, api keys and tracker credentials, for sharing a setup
+	Redact bool
+}
+
+// RestoreOptions what is taken from a backup
+type RestoreOptions struct {
+	// Config replace the config file, without it only the database is restored
+	Config bool
+}
+
+// RestoreResult the restore is applied on the next start
+type RestoreResult struct {
+	Manifest        BackupManifest `json:"manifest"`
+	Config          bool           `json:"config"`
+	RestartRequired bool           `json:"restart_required"`
+}
+
+// BackupFile a scheduled backup in the backup folder
+type BackupFile struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupSettings scheduled backups, off when Interval is 0
+type BackupSettings struct {
+	Interval time.Duration
+	Keep     int
+}
+
+// BackupFileName name of an archive made at t
+func BackupFileName(t time.Time) string {
+	return fmt.Sprintf("autobrr-backup-%v.tar.gz", t.UTC().Format("20060102T150405Z"))
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type DownloadClientRepo interface {
 	//FindByActionID(actionID int) ([]DownloadClient, error)
@@ -11,22 +14,39 @@ type DownloadClientRepo interface {
 }
 
 type DownloadClient struct {
-	ID       int                    `json:"id"`
-	Name     string                 `json:"name"`
-	Type     DownloadClientType     `json:"type"`
-	Enabled  bool                   `json:"enabled"`
-	Host     string                 `json:"host"`
-	Port     int                    `json:"port"`
-	SSL      bool                   `json:"ssl"`
-	Username string                 `json:"username"`
-	Password string                 `json:"password"`
-	Settings DownloadClientSettings `json:"settings,omitempty"`
+	ID            int                    `json:"id"`
+	Name          string                 `json:"name"`
+	Type          DownloadClientType     `json:"type"`
+	Enabled       bool                   `json:"enabled"`
+	Host          string                 `json:"host"`
+	Port          int                    `json:"port"`
+	SSL           bool                   `json:"ssl"`
+	TLSSkipVerify bool                   `json:"tls_skip_verify"`
+	Username      string                 `json:"username"`
+	Password      string                 `json:"password"`
+	Settings      DownloadClientSettings `json:"settings,omitempty"`
+	Health        *DownloadClientHealth  `json:"health,omitempty"`
 }
 
 type DownloadClientSettings struct {
 	APIKey string              `json:"apikey,omitempty"`
 	Basic  BasicAuth           `json:"basic,omitempty"`
 	Rules  DownloadClientRules `json:"rules,omitempty"`
+
+	// IndexerMap autobrr indexer identifier to indexer name in *arr
+	IndexerMap map[string]string `json:"indexer_map,omitempty"`
+
+	// FailoverClientID client to send to when this one is unreachable, backups can have their own backup
+	FailoverClientID int32 `json:"failover_client_id,omitempty"`
+}
+
+// ArrIndexerName name of the indexer as known by the *arr client, falls back to the autobrr indexer
+func (s DownloadClientSettings) ArrIndexerName(indexer string) string {
+	if name, ok := s.IndexerMap[indexer]; ok && name != "" {
+		return name
+	}
+
+	return indexer
 }
 
 type DownloadClientRules struct {
@@ -34,6 +54,16 @@ type DownloadClientRules struct {
 	MaxActiveDownloads     int   `json:"max_active_downloads"`
 	IgnoreSlowTorrents     bool  `json:"ignore_slow_torrents"`
 	DownloadSpeedThreshold int64 `json:"download_speed_threshold"`
+
+	// MinFreeSpace in MiB, skip when the client has less free disk space than this
+	MinFreeSpace int64 `json:"min_free_space,omitempty"`
+	// MaxSeeding skip when the client is seeding more torrents than this
+	MaxSeeding int `json:"max_seeding,omitempty"`
+
+	// DeferAttempts retry a blocked release this many times instead of rejecting it right away
+	DeferAttempts int `json:"defer_attempts,omitempty"`
+	// DeferInterval seconds between retries
+	DeferInterval int `json:"defer_interval,omitempty"`
 }
 
 type BasicAuth struct {
@@ -42,13 +72,33 @@ type BasicAuth struct {
 	Password string `json:"password,omitempty"`
 }
 
+type DownloadClientHealthStatus string
+
+const (
+	DownloadClientHealthOK      DownloadClientHealthStatus = "OK"
+	DownloadClientHealthError   DownloadClientHealthStatus = "ERROR"
+	DownloadClientHealthUnknown DownloadClientHealthStatus = "UNKNOWN"
+)
+
+type DownloadClientHealth struct {
+	Status    DownloadClientHealthStatus `json:"status"`
+	Message   string                     `json:"message,omitempty"`
+	CheckedAt time.Time                  `json:"checked_at"`
+}
+
 type DownloadClientType string
 
 const (
-	DownloadClientTypeQbittorrent DownloadClientType = "QBITTORRENT"
-	DownloadClientTypeDelugeV1    DownloadClientType = "DELUGE_V1"
-	DownloadClientTypeDelugeV2    DownloadClientType = "DELUGE_V2"
-	DownloadClientTypeRadarr      DownloadClientType = "RADARR"
-	DownloadClientTypeSonarr      DownloadClientType = "SONARR"
-	DownloadClientTypeLidarr      DownloadClientType = "LIDARR"
+	DownloadClientTypeQbittorrent  DownloadClientType = "QBITTORRENT"
+	DownloadClientTypeDelugeV1     DownloadClientType = "DELUGE_V1"
+	DownloadClientTypeDelugeV2     DownloadClientType = "DELUGE_V2"
+	DownloadClientTypeTransmission DownloadClientType = "TRANSMISSION"
+	DownloadClientTypeRTorrent     DownloadClientType = "RTORRENT"
+	DownloadClientTypeSabnzbd      DownloadClientType = "SABNZBD"
+	DownloadClientTypeNzbget       DownloadClientType = "NZBGET"
+	DownloadClientTypeRadarr       DownloadClientType = "RADARR"
+	DownloadClientTypeSonarr       DownloadClientType = "SONARR"
+	DownloadClientTypeLidarr       DownloadClientType = "LIDARR"
+	DownloadClientTypeReadarr      DownloadClientType = "READARR"
+	DownloadClientTypeWhisparr     DownloadClientType = "WHISPARR"
 )
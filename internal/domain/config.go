@@ -1,5 +1,12 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type Config struct {
 	Host          string `toml:"host"`
 	Port          int    `toml:"port"`
@@ -7,4 +14,396 @@ type Config struct {
 	LogPath       string `toml:"logPath"`
 	BaseURL       string `toml:"baseUrl"`
 	SessionSecret string `toml:"sessionSecret"`
+
+	CheckForUpdates bool `toml:"checkForUpdates"`
+
+	DatabaseType                   string `toml:"databaseType"`
+	DatabaseDSN                    string `toml:"databaseDsn"`
+	DatabaseMaxOpenConns           int    `toml:"databaseMaxOpenConns"`
+	DatabaseMaxIdleConns           int    `toml:"databaseMaxIdleConns"`
+	DatabaseConnMaxLifetimeMinutes int    `toml:"databaseConnMaxLifetimeMinutes"`
+
+	EncryptionKey     string `toml:"encryptionKey"`
+	EncryptionKeyFile string `toml:"encryptionKeyFile"`
+
+	LogMaxSize    int  `toml:"logMaxSize"`
+	LogMaxBackups int  `toml:"logMaxBackups"`
+	LogMaxAge     int  `toml:"logMaxAge"`
+	LogCompress   bool `toml:"logCompress"`
+
+	TLSCertFile     string `toml:"tlsCertFile"`
+	TLSKeyFile      string `toml:"tlsKeyFile"`
+	TLSClientCAFile string `toml:"tlsClientCaFile"`
+
+	SessionLifetimeHours      int `toml:"sessionLifetimeHours"`
+	SessionIdleTimeoutMinutes int `toml:"sessionIdleTimeoutMinutes"`
+
+	LoginMaxAttempts      int `toml:"loginMaxAttempts"`
+	LoginMaxAttemptsPerIP int `toml:"loginMaxAttemptsPerIp"`
+	LoginLockoutMinutes   int `toml:"loginLockoutMinutes"`
+
+	TrustedProxies string `toml:"trustedProxies"`
+
+	BackupIntervalHours int `toml:"backupIntervalHours"`
+	BackupKeep          int `toml:"backupKeep"`
+
+	ReleaseRetentionDays    int `toml:"releaseRetentionDays"`
+	ReleaseRetentionMaxRows int `toml:"releaseRetentionMaxRows"`
+
+	MaintenanceTimes  string `toml:"maintenanceTimes"`
+	MaintenanceBackup bool   `toml:"maintenanceBackup"`
+
+	MetricsEnabled bool   `toml:"metricsEnabled"`
+	MetricsToken   string `toml:"metricsToken"`
+
+	PprofEnabled bool `toml:"pprofEnabled"`
+
+	QuotaGrabsPerHour  int    `toml:"quotaGrabsPerHour"`
+	QuotaGrabsPerDay   int    `toml:"quotaGrabsPerDay"`
+	QuotaGrabsPerWeek  int    `toml:"quotaGrabsPerWeek"`
+	QuotaGrabsPerMonth int    `toml:"quotaGrabsPerMonth"`
+	QuotaBytesPerDay   string `toml:"quotaBytesPerDay"`
+	QuotaResetHour     int    `toml:"quotaResetHour"`
+
+	FilterMatchMode FilterMatchMode `toml:"filterMatchMode"`
+
+	AnnounceConcurrency     int `toml:"announceConcurrency"`
+	AnnounceCoalesceSeconds int `toml:"announceCoalesceSeconds"`
+
+	ActionWorkers   int `toml:"actionWorkers"`
+	ActionQueueSize int `toml:"actionQueueSize"`
+
+	TorrentCacheMinutes int `toml:"torrentCacheMinutes"`
+
+	IndexerAuthFailures     int  `toml:"indexerAuthFailures"`
+	IndexerAuthPauseFilters bool `toml:"indexerAuthPauseFilters"`
+
+	RejectReleases      string `toml:"rejectReleases"`
+	RejectReleaseGroups string `toml:"rejectReleaseGroups"`
+	AllowReleaseGroups  string `toml:"allowReleaseGroups"`
+
+	OIDCEnabled      bool   `toml:"oidcEnabled"`
+	OIDCIssuer       string `toml:"oidcIssuer"`
+	OIDCClientID     string `toml:"oidcClientId"`
+	OIDCClientSecret string `toml:"oidcClientSecret"`
+	OIDCRedirectURL  string `toml:"oidcRedirectUrl"`
+	OIDCScopes       string `toml:"oidcScopes"`
+	OIDCGroupsClaim  string `toml:"oidcGroupsClaim"`
+	OIDCGroupRoles   string `toml:"oidcGroupRoles"`
+	OIDCDefaultRole  string `toml:"oidcDefaultRole"`
+}
+
+// ConfigUpdate settings changed through the api, fields that are nil are left as they are
+type ConfigUpdate struct {
+	Host                      *string `json:"host"`
+	Port                      *int    `json:"port"`
+	BaseURL                   *string `json:"base_url"`
+	LogLevel                  *string `json:"log_level"`
+	LogPath                   *string `json:"log_path"`
+	SessionLifetimeHours      *int    `json:"session_lifetime_hours"`
+	SessionIdleTimeoutMinutes *int    `json:"session_idle_timeout_minutes"`
+	CheckForUpdates           *bool   `json:"check_for_updates"`
+}
+
+// ConfigRestartKeys settings that are only picked up on start
+var ConfigRestartKeys = []string{"host", "port", "baseUrl"}
+
+func (u ConfigUpdate) Validate() error {
+	if u.Host != nil && strings.TrimSpace(*u.Host) == "" {
+		return errors.New("host can't be empty")
+	}
+
+	if u.Port != nil && (*u.Port < 1 || *u.Port > 65535) {
+		return errors.New("port has to be between 1 and 65535")
+	}
+
+	if u.BaseURL != nil && (!strings.HasPrefix(*u.BaseURL, "/") || !strings.HasSuffix(*u.BaseURL, "/")) {
+		return errors.New("base url has to start and end with /, like /autobrr/")
+	}
+
+	if u.LogLevel != nil {
+		if err := (LogSettings{Level: *u.LogLevel, MaxSize: 1}).Validate(); err != nil {
+			return err
+		}
+	}
+
+	if u.SessionLifetimeHours != nil && *u.SessionLifetimeHours < 0 {
+		return errors.New("session lifetime can't be negative")
+	}
+
+	if u.SessionIdleTimeoutMinutes != nil && *u.SessionIdleTimeoutMinutes < 0 {
+		return errors.New("session idle timeout can't be negative")
+	}
+
+	return nil
+}
+
+// Apply the update to the config, the values are returned by toml key for writing them to the config file
+func (u ConfigUpdate) Apply(c Config) (Config, map[string]interface{}) {
+	values := map[string]interface{}{}
+
+	if u.Host != nil {
+		c.Host = strings.TrimSpace(*u.Host)
+		values["host"] = c.Host
+	}
+	if u.Port != nil {
+		c.Port = *u.Port
+		values["port"] = c.Port
+	}
+	if u.BaseURL != nil {
+		c.BaseURL = *u.BaseURL
+		values["baseUrl"] = c.BaseURL
+	}
+	if u.LogLevel != nil {
+		c.LogLevel = strings.ToUpper(*u.LogLevel)
+		values["logLevel"] = c.LogLevel
+	}
+	if u.LogPath != nil {
+		c.LogPath = *u.LogPath
+		values["logPath"] = c.LogPath
+	}
+	if u.SessionLifetimeHours != nil {
+		c.SessionLifetimeHours = *u.SessionLifetimeHours
+		values["sessionLifetimeHours"] = c.SessionLifetimeHours
+	}
+	if u.SessionIdleTimeoutMinutes != nil {
+		c.SessionIdleTimeoutMinutes = *u.SessionIdleTimeoutMinutes
+		values["sessionIdleTimeoutMinutes"] = c.SessionIdleTimeoutMinutes
+	}
+	if u.CheckForUpdates != nil {
+		c.CheckForUpdates = *u.CheckForUpdates
+		values["checkForUpdates"] = c.CheckForUpdates
+	}
+
+	return c, values
+}
+
+// Secrets config values by toml key that are left out of a redacted backup
+func (c Config) Secrets() map[string]string {
+	return map[string]string{
+		"sessionSecret":    c.SessionSecret,
+		"databaseDsn":      c.DatabaseDSN,
+		"encryptionKey":    c.EncryptionKey,
+		"metricsToken":     c.MetricsToken,
+		"oidcClientSecret": c.OIDCClientSecret,
+	}
+}
+
+// BackupSettings scheduled backups from the config, the last 7 are kept when not set
+func (c Config) BackupSettings() BackupSettings {
+	settings := BackupSettings{
+		Interval: time.Duration(c.BackupIntervalHours) * time.Hour,
+		Keep:     c.BackupKeep,
+	}
+
+	if settings.Keep <= 0 {
+		settings.Keep = 7
+	}
+
+	return settings
+}
+
+// LogSettings log level and file rotation from the config, files rotate at 100 MB and 3 are kept when not set
+func (c Config) LogSettings() LogSettings {
+	settings := LogSettings{
+		Level:      c.LogLevel,
+		Path:       c.LogPath,
+		MaxSize:    c.LogMaxSize,
+		MaxBackups: c.LogMaxBackups,
+		MaxAge:     c.LogMaxAge,
+		Compress:   c.LogCompress,
+	}
+
+	if settings.MaxSize <= 0 {
+		settings.MaxSize = 100
+	}
+
+	if settings.MaxBackups <= 0 {
+		settings.MaxBackups = 3
+	}
+
+	return settings
+}
+
+// SessionSettings session lifetime and idle timeout from the config, sessions last 30 days when not set
+func (c Config) SessionSettings() SessionSettings {
+	settings := SessionSettings{
+		Lifetime:    time.Duration(c.SessionLifetimeHours) * time.Hour,
+		IdleTimeout: time.Duration(c.SessionIdleTimeoutMinutes) * time.Minute,
+	}
+
+	if settings.Lifetime <= 0 {
+		settings.Lifetime = 30 * 24 * time.Hour
+	}
+
+	if settings.IdleTimeout < 0 {
+		settings.IdleTimeout = 0
+	}
+
+	return settings
+}
+
+// LoginLimits login rate limits from the config, with defaults for the values that are not set
+func (c Config) LoginLimits() LoginLimits {
+	limits := LoginLimits{
+		MaxAttempts:      c.LoginMaxAttempts,
+		MaxAttemptsPerIP: c.LoginMaxAttemptsPerIP,
+		Lockout:          time.Duration(c.LoginLockoutMinutes) * time.Minute,
+	}
+
+	if limits.MaxAttempts <= 0 {
+		limits.MaxAttempts = 5
+	}
+
+	if limits.MaxAttemptsPerIP <= 0 {
+		limits.MaxAttemptsPerIP = 20
+	}
+
+	if limits.Lockout <= 0 {
+		limits.Lockout = 15 * time.Minute
+	}
+
+	return limits
+}
+
+// ReverseProxies the trusted proxies from the config, none when not set
+func (c Config) ReverseProxies() (TrustedProxies, error) {
+	return ParseTrustedProxies(c.TrustedProxies)
+}
+
+// AnnounceSettings announce processing from the config, 4 announces are checked at the same time when not set
+func (c Config) AnnounceSettings() AnnounceSettings {
+	settings := AnnounceSettings{
+		Concurrency:    c.AnnounceConcurrency,
+		CoalesceWindow: time.Duration(c.AnnounceCoalesceSeconds) * time.Second,
+	}
+
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = 4
+	}
+
+	if settings.CoalesceWindow < 0 {
+		settings.CoalesceWindow = 0
+	}
+
+	return settings
+}
+
+// ActionQueueSettings action workers from the config, 8 chains run at the same time and 256 wait for a worker when not set
+func (c Config) ActionQueueSettings() ActionQueueSettings {
+	settings := ActionQueueSettings{
+		Workers: c.ActionWorkers,
+		Size:    c.ActionQueueSize,
+	}
+
+	if settings.Workers <= 0 {
+		settings.Workers = 8
+	}
+
+	if settings.Size <= 0 {
+		settings.Size = 256
+	}
+
+	return settings
+}
+
+// IndexerAuthSettings auth failure handling from the config, 3 failed downloads in a row flag the credentials when not set
+func (c Config) IndexerAuthSettings() IndexerAuthSettings {
+	settings := IndexerAuthSettings{
+		Failures:     c.IndexerAuthFailures,
+		PauseFilters: c.IndexerAuthPauseFilters,
+	}
+
+	if settings.Failures <= 0 {
+		settings.Failures = 3
+	}
+
+	return settings
+}
+
+// TorrentCacheSettings how long downloaded torrent files are kept, the cache is off when not set
+func (c Config) TorrentCacheSettings() TorrentCacheSettings {
+	settings := TorrentCacheSettings{
+		TTL: time.Duration(c.TorrentCacheMinutes) * time.Minute,
+	}
+
+	if settings.TTL < 0 {
+		settings.TTL = 0
+	}
+
+	return settings
+}
+
+// QuotaSettings global download quota from the config
+func (c Config) QuotaSettings() QuotaSettings {
+	return QuotaSettings{
+		Global: Quota{
+			GrabsPerHour:  c.QuotaGrabsPerHour,
+			GrabsPerDay:   c.QuotaGrabsPerDay,
+			GrabsPerWeek:  c.QuotaGrabsPerWeek,
+			GrabsPerMonth: c.QuotaGrabsPerMonth,
+			BytesPerDay:   c.QuotaBytesPerDay,
+		},
+		ResetHour: c.QuotaResetHour,
+	}
+}
+
+// ReleaseRules global reject lists from the config
+func (c Config) ReleaseRules() ReleaseRules {
+	return ReleaseRules{
+		RejectReleases:      c.RejectReleases,
+		RejectReleaseGroups: c.RejectReleaseGroups,
+		AllowReleaseGroups:  c.AllowReleaseGroups,
+	}
+}
+
+// ReleaseRetention release history kept from the config, everything is kept when not set
+func (c Config) ReleaseRetention() ReleaseRetention {
+	return ReleaseRetention{
+		MaxAgeDays: c.ReleaseRetentionDays,
+		MaxRows:    c.ReleaseRetentionMaxRows,
+	}
+}
+
+// OIDCSettings single sign-on settings from the config
+func (c Config) OIDCSettings() (OIDCSettings, error) {
+	settings := OIDCSettings{
+		Enabled:      c.OIDCEnabled,
+		Issuer:       c.OIDCIssuer,
+		ClientID:     c.OIDCClientID,
+		ClientSecret: c.OIDCClientSecret,
+		RedirectURL:  c.OIDCRedirectURL,
+		Scopes:       strings.Fields(strings.ReplaceAll(c.OIDCScopes, ",", " ")),
+		GroupsClaim:  c.OIDCGroupsClaim,
+	}
+
+	if !settings.Enabled {
+		return settings, nil
+	}
+
+	if settings.Issuer == "" || settings.ClientID == "" || settings.RedirectURL == "" {
+		return settings, errors.New("oidcIssuer, oidcClientId and oidcRedirectUrl are required")
+	}
+
+	if len(settings.Scopes) == 0 {
+		settings.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+
+	if settings.GroupsClaim == "" {
+		settings.GroupsClaim = "groups"
+	}
+
+	groupRoles, err := ParseOIDCGroupRoles(c.OIDCGroupRoles)
+	if err != nil {
+		return settings, fmt.Errorf("oidcGroupRoles: %w", err)
+	}
+	settings.GroupRoles = groupRoles
+
+	if c.OIDCDefaultRole != "" {
+		if settings.DefaultRole, err = ParseUserRole(c.OIDCDefaultRole); err != nil {
+			return settings, fmt.Errorf("oidcDefaultRole: %w", err)
+		}
+	}
+
+	return settings, nil
 }
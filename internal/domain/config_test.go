@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigUpdate_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(i int) *int { return &i }
+
+	assert.NoError(t, ConfigUpdate{}.Validate())
+	assert.NoError(t, ConfigUpdate{Port: num(7474), BaseURL: str("/autobrr/"), LogLevel: str("trace")}.Validate())
+	assert.Error(t, ConfigUpdate{Port: num(0)}.Validate())
+	assert.Error(t, ConfigUpdate{Host: str(" ")}.Validate())
+	assert.Error(t, ConfigUpdate{BaseURL: str("autobrr")}.Validate())
+	assert.Error(t, ConfigUpdate{LogLevel: str("LOUD")}.Validate())
+	assert.Error(t, ConfigUpdate{SessionLifetimeHours: num(-1)}.Validate())
+}
+
+func TestConfigUpdate_Apply(t *testing.T) {
+	level := "info"
+	enabled := false
+
+	cfg, values := ConfigUpdate{LogLevel: &level, CheckForUpdates: &enabled}.Apply(Config{Port: 7474, LogLevel: "DEBUG", CheckForUpdates: true})
+
+	assert.Equal(t, Config{Port: 7474, LogLevel: "INFO"}, cfg)
+	assert.Equal(t, map[string]interface{}{"logLevel": "INFO", "checkForUpdates": false}, values)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_DatabaseSettings(t *testing.T) {
+	settings, err := Config{}.DatabaseSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, DatabaseTypeSqlite, settings.Type)
+
+	settings, err = Config{DatabaseType: "Postgres", DatabaseDSN: "postgres://localhost/autobrr", DatabaseConnMaxLifetimeMinutes: 30}.DatabaseSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, DatabaseTypePostgres, settings.Type)
+	assert.Equal(t, 30*time.Minute, settings.ConnMaxLifetime)
+
+	_, err = Config{DatabaseType: "postgres"}.DatabaseSettings()
+	assert.Error(t, err)
+
+	_, err = Config{DatabaseType: "mysql"}.DatabaseSettings()
+	assert.Error(t, err)
+
+	_, err = Config{DatabaseMaxOpenConns: -1}.DatabaseSettings()
+	assert.Error(t, err)
+}
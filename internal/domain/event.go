@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventPattern compiled match_events entry.
+// Separators match each other, * and ? are wildcards, {01-24} matches a number in the range
+// and {2024-10-01..2024-10-31} a date in the range.
+type eventPattern struct {
+	rxp    *regexp.Regexp
+	checks []func(value string) bool // one per capture group
+}
+
+var (
+	eventSeparatorsRegexp  = regexp.MustCompile(`[\s._\-]+`)
+	eventNumberRangeRegexp = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+	// dates in release names after the separators are normalized, rewritten to year month day
+	eventNumericDateRegexp  = regexp.MustCompile(`\b(\d{1,2}) (\d{1,2}) (\d{4})\b`)
+	eventDayMonthNameRegexp = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)? (jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]* (\d{4})\b`)
+	eventMonthNameDayRegexp = regexp.MustCompile(`\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]* (\d{1,2})(?:st|nd|rd|th)? (\d{4})\b`)
+	eventMonthNames         = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+)
+
+// newEventPattern compile an event pattern like "F1.2024.Round{01-24}*" or "NBA 2024 10 *"
+func newEventPattern(pattern string) (*eventPattern, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	p := &eventPattern{}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch {
+		case c == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("missing } in %q", pattern)
+			}
+
+			check, err := eventRangeCheck(pattern[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+
+			p.checks = append(p.checks, check)
+			expr.WriteString(`(\d{4} \d{2} \d{2}|\d+)`)
+			i += end
+
+		case c == '*':
+			expr.WriteString(".*")
+
+		case c == '?':
+			expr.WriteString(".")
+
+		case strings.IndexByte(" ._-", c) >= 0:
+			for i+1 < len(pattern) && strings.IndexByte(" ._-", pattern[i+1]) >= 0 {
+				i++
+			}
+			expr.WriteString(" ")
+
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expr.WriteString("$")
+
+	rxp, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	p.rxp = rxp
+
+	return p, nil
+}
+
+// eventRangeCheck a number range like 01-24 or a date range like 2024-10-01..2024-10-31
+func eventRangeCheck(token string) (func(string) bool, error) {
+	if i := strings.Index(token, ".."); i >= 0 {
+		start, err := parseEventDate(token[:i])
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseEventDate(token[i+2:])
+		if err != nil {
+			return nil, err
+		}
+
+		if end.Before(start) {
+			return nil, fmt.Errorf("date range {%v} ends before it starts", token)
+		}
+
+		return func(value string) bool {
+			date, err := time.Parse("2006 01 02", value)
+			if err != nil {
+				return false
+			}
+
+			return !date.Before(start) && !date.After(end)
+		}, nil
+	}
+
+	matches := eventNumberRangeRegexp.FindStringSubmatch(token)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid range {%v}, use {01-24} or {2024-10-01..2024-10-31}", token)
+	}
+
+	low, _ := strconv.Atoi(matches[1])
+	high, _ := strconv.Atoi(matches[2])
+	if high < low {
+		return nil, fmt.Errorf("number range {%v} ends before it starts", token)
+	}
+
+	return func(value string) bool {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+
+		return n >= low && n <= high
+	}, nil
+}
+
+func parseEventDate(value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", eventSeparatorsRegexp.ReplaceAllString(strings.TrimSpace(value), "-"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+	}
+
+	return date, nil
+}
+
+func (p *eventPattern) match(name string) bool {
+	matches := p.rxp.FindStringSubmatch(normalizeEventName(name))
+	if matches == nil {
+		return false
+	}
+
+	for i, check := range p.checks {
+		if !check(matches[i+1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeEventName lower case name with single spaces as separators and dates as year month day.
+// Numeric dates with the year last are read as day month year unless the day can only be the second number.
+func normalizeEventName(name string) string {
+	name = strings.TrimSpace(eventSeparatorsRegexp.ReplaceAllString(strings.ToLower(name), " "))
+
+	name = eventNumericDateRegexp.ReplaceAllStringFunc(name, func(s string) string {
+		m := eventNumericDateRegexp.FindStringSubmatch(s)
+		day, month := m[1], m[2]
+		if first, _ := strconv.Atoi(m[1]); first <= 12 {
+			if second, _ := strconv.Atoi(m[2]); second > 12 {
+				day, month = m[2], m[1]
+			}
+		}
+
+		return eventDate(s, m[3], month, day)
+	})
+
+	name = eventDayMonthNameRegexp.ReplaceAllStringFunc(name, func(s string) string {
+		m := eventDayMonthNameRegexp.FindStringSubmatch(s)
+		return eventDate(s, m[3], eventMonth(m[2]), m[1])
+	})
+
+	name = eventMonthNameDayRegexp.ReplaceAllStringFunc(name, func(s string) string {
+		m := eventMonthNameDayRegexp.FindStringSubmatch(s)
+		return eventDate(s, m[3], eventMonth(m[1]), m[2])
+	})
+
+	return name
+}
+
+// eventDate the date as "yyyy mm dd", or the original text if it is not a valid date
+func eventDate(original string, year string, month string, day string) string {
+	date, err := time.Parse("2006 1 2", fmt.Sprintf("%v %v %v", year, month, day))
+	if err != nil {
+		return original
+	}
+
+	return date.Format("2006 01 02")
+}
+
+func eventMonth(name string) string {
+	for i, month := range eventMonthNames {
+		if month == name {
+			return strconv.Itoa(i + 1)
+		}
+	}
+
+	return ""
+}
+
+// eventPatternCache compiled patterns of stored filters
+var eventPatternCache sync.Map
+
+func cachedEventPattern(pattern string) (*eventPattern, error) {
+	if p, ok := eventPatternCache.Load(pattern); ok {
+		return p.(*eventPattern), nil
+	}
+
+	p, err := newEventPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	eventPatternCache.Store(pattern, p)
+
+	return p, nil
+}
+
+// validateEventPatterns comma separated event patterns
+func validateEventPatterns(list string) error {
+	for _, pattern := range strings.Split(list, ",") {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+
+		if _, err := newEventPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFilterEvents any of the comma separated event patterns matches the name
+func checkFilterEvents(list string, name string) bool {
+	for _, pattern := range strings.Split(list, ",") {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+
+		p, err := cachedEventPattern(pattern)
+		if err != nil {
+			continue
+		}
+
+		if p.match(name) {
+			return true
+		}
+	}
+
+	return false
+}
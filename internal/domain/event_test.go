@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_normalizeEventName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "NBA.2024.10.22.Lakers.vs.Celtics.720p", want: "nba 2024 10 22 lakers vs celtics 720p"},
+		{name: "NBA.22.10.2024.Lakers.vs.Celtics.720p", want: "nba 2024 10 22 lakers vs celtics 720p"},
+		{name: "NBA.10.22.2024.Lakers.vs.Celtics.720p", want: "nba 2024 10 22 lakers vs celtics 720p"},
+		{name: "UFC_Fight_Night_Oct_5th_2024_1080p", want: "ufc fight night 2024 10 05 1080p"},
+		{name: "EPL 5 October 2024 Arsenal vs Southampton", want: "epl 2024 10 05 arsenal vs southampton"},
+		{name: "NHL.31.02.2024.Invalid", want: "nhl 31 02 2024 invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeEventName(tt.name))
+		})
+	}
+}
+
+func Test_checkFilterEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    bool
+	}{
+		{name: "F1.2024.Round05.Miami.Grand.Prix.Race.1080p.WEB.h264-GROUP", pattern: "F1.2024.Round{01-24}*", want: true},
+		{name: "F1.2024.Round25.Exhibition.1080p.WEB.h264-GROUP", pattern: "F1.2024.Round{01-24}*", want: false},
+		{name: "F1.2023.Round05.Miami.Grand.Prix.Race.1080p.WEB.h264-GROUP", pattern: "F1.2024.Round{01-24}*", want: false},
+		{name: "NBA.22.10.2024.Lakers.vs.Celtics.720p.WEB.h264-GROUP", pattern: "NBA 2024 10 *", want: true},
+		{name: "NBA.2024.11.01.Lakers.vs.Celtics.720p.WEB.h264-GROUP", pattern: "NBA 2024 10 *", want: false},
+		{name: "NBA.2024.10.22.Lakers.vs.Celtics.720p.WEB.h264-GROUP", pattern: "NBA.{2024-10-15..2024-10-31}*", want: true},
+		{name: "NBA.2024.10.02.Lakers.vs.Celtics.720p.WEB.h264-GROUP", pattern: "NBA.{2024-10-15..2024-10-31}*", want: false},
+		{name: "NBA.2024.10.22.Lakers.vs.Celtics.720p.WEB.h264-GROUP", pattern: "NHL*, NBA*Lakers*", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"_"+tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkFilterEvents(tt.pattern, tt.name))
+		})
+	}
+}
+
+func Test_validateEventPatterns(t *testing.T) {
+	assert.NoError(t, validateEventPatterns("F1.2024.Round{01-24}*, NBA.{2024-10-01..2024-10-31}*"))
+
+	for _, list := range []string{"F1.Round{24-01}*", "F1.Round{01-24*", "NBA.{2024-10-31..2024-10-01}*", "NBA.{yesterday}*", "NBA.{2024-13-01..2024-13-31}*"} {
+		assert.Error(t, validateEventPatterns(list), list)
+	}
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/autobrr/autobrr/pkg/expr"
+)
+
+// ExpressionVariables release fields a filter expression can use
+var ExpressionVariables = []string{
+	"torrent_name", "title", "category", "season", "episode", "air_date", "year", "resolution", "source", "codec",
+	"container", "hdr", "audio", "audio_formats", "audio_channels", "group", "region", "language", "languages",
+	"subtitles", "edition",
+	"proper", "repack", "website", "type", "format", "quality", "log_score", "has_log", "has_cue", "scene", "origin",
+	"internal", "tags", "freeleech", "freeleech_percent", "uploader", "size", "indexer",
+}
+
+// NewFilterExpression compile a filter expression, only release fields from ExpressionVariables are allowed
+func NewFilterExpression(source string) (*expr.Program, error) {
+	return expr.Compile(source, ExpressionVariables)
+}
+
+// filterExpressionCache compiled expressions of stored filters
+var filterExpressionCache sync.Map
+
+func cachedFilterExpression(source string) (*expr.Program, error) {
+	if program, ok := filterExpressionCache.Load(source); ok {
+		return program.(*expr.Program), nil
+	}
+
+	program, err := NewFilterExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	filterExpressionCache.Store(source, program)
+
+	return program, nil
+}
+
+// expressionEnv the release fields by their expression variable names
+func (r *Release) expressionEnv() map[string]interface{} {
+	audioFormats := r.AudioFormats
+	if audioFormats == nil {
+		audioFormats = []string{}
+	}
+
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	languages := r.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+
+	subtitles := r.Subtitles
+	if subtitles == nil {
+		subtitles = []string{}
+	}
+
+	return map[string]interface{}{
+		"torrent_name":      r.TorrentName,
+		"title":             r.Title,
+		"category":          r.Category,
+		"season":            r.Season,
+		"episode":           r.Episode,
+		"air_date":          r.AirDate,
+		"year":              r.Year,
+		"resolution":        r.Resolution,
+		"source":            r.Source,
+		"codec":             r.Codec,
+		"container":         r.Container,
+		"hdr":               r.HDR,
+		"audio":             r.Audio,
+		"audio_formats":     audioFormats,
+		"audio_channels":    r.AudioChannels,
+		"group":             r.Group,
+		"region":            r.Region,
+		"language":          r.Language,
+		"languages":         languages,
+		"subtitles":         subtitles,
+		"edition":           r.Edition,
+		"proper":            r.Proper,
+		"repack":            r.Repack,
+		"website":           r.Website,
+		"type":              r.Type,
+		"format":            r.Format,
+		"quality":           r.Quality,
+		"log_score":         r.LogScore,
+		"has_log":           r.HasLog,
+		"has_cue":           r.HasCue,
+		"scene":             r.origin() == OriginScene,
+		"origin":            r.origin(),
+		"internal":          r.origin() == OriginInternal,
+		"tags":              tags,
+		"freeleech":         r.Freeleech || r.freeleechPercent() == 100,
+		"freeleech_percent": r.freeleechPercent(),
+		"uploader":          r.Uploader,
+		"size":              r.Size,
+		"indexer":           r.Indexer,
+	}
+}
+
+// CheckExpression evaluate the filter expression against the release.
+// Returns why the release is rejected, or an error if the expression could not be evaluated.
+func (r *Release) CheckExpression(filter Filter) (string, error) {
+	if filter.Expression == "" {
+		return "", nil
+	}
+
+	program, err := cachedFilterExpression(filter.Expression)
+	if err != nil {
+		return "", err
+	}
+
+	match, err := program.Eval(r.expressionEnv())
+	if err != nil {
+		return "", err
+	}
+
+	if !match {
+		return "expression not matching", nil
+	}
+
+	return "", nil
+}
+
+// expressionNeedsSize the expression reads the size, which some indexers do not announce
+func expressionNeedsSize(filter Filter) bool {
+	program, err := cachedFilterExpression(filter.Expression)
+	if err != nil {
+		return false
+	}
+
+	return program.Uses("size")
+}
+
+// checkFilterExpression used by CheckFilter, a broken expression rejects the release
+func (r *Release) checkFilterExpression(filter Filter) bool {
+	if r.Size == 0 && expressionNeedsSize(filter) {
+		// checked again once the size is known
+		r.AdditionalSizeCheckRequired = true
+		return true
+	}
+
+	rejection, err := r.CheckExpression(filter)
+	if err != nil {
+		rejection = fmt.Sprintf("expression: %v", err)
+	}
+
+	if rejection != "" {
+		r.addRejection(rejection)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type FeedCacheRepo interface {
+	Get(bucket string, key string) ([]byte, error)
+	Exists(bucket string, key string) (bool, error)
+	Put(bucket string, key string, val []byte, ttl time.Time) error
+	Delete(bucket string, key string) error
+	DeleteExpired() (int64, error)
+}
+
+type FeedRepo interface {
+	FindByID(ctx context.Context, id int) (*Feed, error)
+	FindByIndexerIdentifier(ctx context.Context, indexer string) (*Feed, error)
+	Find(ctx context.Context) ([]Feed, error)
+	Store(ctx context.Context, feed *Feed) error
+	Update(ctx context.Context, feed *Feed) error
+	ToggleEnabled(ctx context.Context, id int, enabled bool) error
+	Delete(ctx context.Context, id int) error
+}
+
+type Feed struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`
+	Indexer    string            `json:"indexer"`
+	Type       string            `json:"type"`
+	Enabled    bool              `json:"enabled"`
+	URL        string            `json:"url"`
+	Interval   int               `json:"interval"`
+	ApiKey     string            `json:"api_key"`
+	Cookie     string            `json:"cookie"`
+	Headers    map[string]string `json:"headers"`
+	ParseRules FeedParseRules    `json:"parse_rules"`
+	Settings   map[string]string `json:"settings"`
+	IndexerID  int               `json:"indexer_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Redacted the feed without its api key, cookie and headers
+func (f Feed) Redacted() Feed {
+	f.ApiKey = ""
+	f.Cookie = ""
+	f.Headers = nil
+	return f
+}
+
+// FeedParseRules per feed rules for extracting fields from rss items
+type FeedParseRules struct {
+	SizePattern        string `json:"size_pattern,omitempty"`         // regex matched against title and description
+	CategoryPattern    string `json:"category_pattern,omitempty"`     // regex matched against title and description
+	DownloadURLSource  string `json:"download_url_source,omitempty"`  // enclosure, link or guid
+	DownloadURLPattern string `json:"download_url_pattern,omitempty"` // regex matched against description
+}
+
+type FeedType string
+
+const (
+	FeedTypeTorznab FeedType = "TORZNAB"
+	FeedTypeNewznab FeedType = "NEWZNAB"
+	FeedTypeRSS     FeedType = "RSS"
+)
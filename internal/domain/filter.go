@@ -2,7 +2,14 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/dustin/go-humanize"
 )
 
 /*
@@ -17,60 +24,575 @@ type FilterRepo interface {
 	Store(ctx context.Context, filter Filter) (*Filter, error)
 	Update(ctx context.Context, filter Filter) (*Filter, error)
 	ToggleEnabled(ctx context.Context, filterID int, enabled bool) error
+	PauseByIndexer(ctx context.Context, indexer string) ([]Filter, error)
+	ResumeByIndexer(ctx context.Context, indexer string) ([]Filter, error)
 	Delete(ctx context.Context, filterID int) error
 	StoreIndexerConnection(ctx context.Context, filterID int, indexerID int) error
 	StoreIndexerConnections(ctx context.Context, filterID int, indexers []Indexer) error
 	DeleteIndexerConnections(ctx context.Context, filterID int) error
+	UpdatePriorities(ctx context.Context, priorities map[int]int32) error
+	RecordChecks(ctx context.Context, evaluated []int, matched []int, at time.Time) error
+	RecordActionResult(ctx context.Context, filterID int, succeeded bool) error
+}
+
+// FilterStats counters since the filter was created, filters that never match stand out
+type FilterStats struct {
+	Evaluated        int64      `json:"evaluated"` // releases checked against the filter
+	Matched          int64      `json:"matched"`
+	ActionsSucceeded int64      `json:"actions_succeeded"`
+	ActionsFailed    int64      `json:"actions_failed"` // errors and rejections by the client
+	LastMatchAt      *time.Time `json:"last_match_at"`
+}
+
+// SeasonPacks whether a filter wants season packs, any release when empty
+type SeasonPacks string
+
+const (
+	SeasonPacksOnly    SeasonPacks = "ONLY"
+	SeasonPacksExclude SeasonPacks = "EXCLUDE"
+)
+
+// TagsMatchLogic whether a release needs any or all of the filter tags
+type TagsMatchLogic string
+
+const (
+	TagsMatchAny TagsMatchLogic = "ANY"
+	TagsMatchAll TagsMatchLogic = "ALL"
+)
+
+// FilterMatchMode how many filters can match a single release
+type FilterMatchMode string
+
+const (
+	// FilterMatchFirst stop at the matching filter with the highest priority
+	FilterMatchFirst FilterMatchMode = "first"
+	// FilterMatchAll run the actions of every matching filter
+	FilterMatchAll FilterMatchMode = "all"
+)
+
+// FilterPriorities priorities for filters in the given order, the first one gets the highest
+func FilterPriorities(filterIDs []int) map[int]int32 {
+	priorities := make(map[int]int32, len(filterIDs))
+	for i, id := range filterIDs {
+		priorities[id] = int32(len(filterIDs) - i)
+	}
+
+	return priorities
+}
+
+// FilterBulkOperation change applied to several filters at once
+type FilterBulkOperation string
+
+const (
+	FilterBulkEnable      FilterBulkOperation = "enable"
+	FilterBulkDisable     FilterBulkOperation = "disable"
+	FilterBulkDelete      FilterBulkOperation = "delete"
+	FilterBulkSetIndexers FilterBulkOperation = "set_indexers" // replaces the indexers of every filter
+)
+
+// FilterBulkUpdate the operation and the filters it applies to
+type FilterBulkUpdate struct {
+	FilterIDs  []int               `json:"filter_ids"`
+	Operation  FilterBulkOperation `json:"operation"`
+	IndexerIDs []int               `json:"indexer_ids,omitempty"` // for set_indexers
+}
+
+// Validate check the operation before any filter is changed
+func (u FilterBulkUpdate) Validate() error {
+	if len(u.FilterIDs) == 0 {
+		return &FilterValidationError{Field: "filter_ids", Err: errors.New("no filters")}
+	}
+
+	switch u.Operation {
+	case FilterBulkEnable, FilterBulkDisable, FilterBulkDelete:
+		if len(u.IndexerIDs) > 0 {
+			return &FilterValidationError{Field: "indexer_ids", Err: fmt.Errorf("not used by %v", u.Operation)}
+		}
+	case FilterBulkSetIndexers:
+	default:
+		return &FilterValidationError{Field: "operation", Err: fmt.Errorf("unknown operation %q", u.Operation)}
+	}
+
+	return nil
 }
 
 type Filter struct {
-	ID                  int       `json:"id"`
-	Name                string    `json:"name"`
-	Enabled             bool      `json:"enabled"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
-	MinSize             string    `json:"min_size"`
-	MaxSize             string    `json:"max_size"`
-	Delay               int       `json:"delay"`
-	Priority            int32     `json:"priority"`
-	MatchReleases       string    `json:"match_releases"`
-	ExceptReleases      string    `json:"except_releases"`
-	UseRegex            bool      `json:"use_regex"`
-	MatchReleaseGroups  string    `json:"match_release_groups"`
-	ExceptReleaseGroups string    `json:"except_release_groups"`
-	Scene               bool      `json:"scene"`
-	Origins             string    `json:"origins"`
-	Freeleech           bool      `json:"freeleech"`
-	FreeleechPercent    string    `json:"freeleech_percent"`
-	Shows               string    `json:"shows"`
-	Seasons             string    `json:"seasons"`
-	Episodes            string    `json:"episodes"`
-	Resolutions         []string  `json:"resolutions"` // SD, 480i, 480p, 576p, 720p, 810p, 1080i, 1080p.
-	Codecs              []string  `json:"codecs"`      // XviD, DivX, x264, h.264 (or h264), mpeg2 (or mpeg-2), VC-1 (or VC1), WMV, Remux, h.264 Remux (or h264 Remux), VC-1 Remux (or VC1 Remux).
-	Sources             []string  `json:"sources"`     // DSR, PDTV, HDTV, HR.PDTV, HR.HDTV, DVDRip, DVDScr, BDr, BD5, BD9, BDRip, BRRip, DVDR, MDVDR, HDDVD, HDDVDRip, BluRay, WEB-DL, TVRip, CAM, R5, TELESYNC, TS, TELECINE, TC. TELESYNC and TS are synonyms (you don't need both). Same for TELECINE and TC
-	Containers          []string  `json:"containers"`
-	MatchHDR            []string  `json:"match_hdr"`
-	ExceptHDR           []string  `json:"except_hdr"`
-	Years               string    `json:"years"`
-	Artists             string    `json:"artists"`
-	Albums              string    `json:"albums"`
-	MatchReleaseTypes   []string  `json:"match_release_types"` // Album,Single,EP
-	ExceptReleaseTypes  string    `json:"except_release_types"`
-	Formats             []string  `json:"formats"` // MP3, FLAC, Ogg, AAC, AC3, DTS
-	Quality             []string  `json:"quality"` // 192, 320, APS (VBR), V2 (VBR), V1 (VBR), APX (VBR), V0 (VBR), q8.x (VBR), Lossless, 24bit Lossless, Other
-	Media               []string  `json:"media"`   // CD, DVD, Vinyl, Soundboard, SACD, DAT, Cassette, WEB, Other
-	PerfectFlac         bool      `json:"perfect_flac"`
-	Cue                 bool      `json:"cue"`
-	Log                 bool      `json:"log"`
-	LogScore            int       `json:"log_score"`
-	MatchCategories     string    `json:"match_categories"`
-	ExceptCategories    string    `json:"except_categories"`
-	MatchUploaders      string    `json:"match_uploaders"`
-	ExceptUploaders     string    `json:"except_uploaders"`
-	Tags                string    `json:"tags"`
-	ExceptTags          string    `json:"except_tags"`
-	TagsAny             string    `json:"tags_any"`
-	ExceptTagsAny       string    `json:"except_tags_any"`
-	Actions             []Action  `json:"actions"`
-	Indexers            []Indexer `json:"indexers"`
+	ID                      int               `json:"id"`
+	Name                    string            `json:"name"`
+	Enabled                 bool              `json:"enabled"`
+	CreatedAt               time.Time         `json:"created_at"`
+	UpdatedAt               time.Time         `json:"updated_at"`
+	MinSize                 string            `json:"min_size"`
+	MaxSize                 string            `json:"max_size"`
+	VerifySize              bool              `json:"verify_size"` // check min and max size against the torrent file even when the announce has a size
+	Delay                   int               `json:"delay"`
+	Priority                int32             `json:"priority"`
+	MatchReleases           string            `json:"match_releases"`
+	ExceptReleases          string            `json:"except_releases"`
+	MatchReleasesRegex      string            `json:"match_releases_regex"`  // RE2, case insensitive
+	ExceptReleasesRegex     string            `json:"except_releases_regex"` // RE2, case insensitive
+	KeywordScores           string            `json:"keyword_scores"`        // keyword:points, comma separated
+	MinKeywordScore         int               `json:"min_keyword_score"`     // only used with keyword scores
+	MatchEvents             string            `json:"match_events"`          // comma separated, like F1.2024.Round{01-24}* or NBA.{2024-10-01..2024-10-31}*
+	ExceptEvents            string            `json:"except_events"`
+	UseRegex                bool              `json:"use_regex"`
+	MatchReleaseGroups      string            `json:"match_release_groups"`
+	ExceptReleaseGroups     string            `json:"except_release_groups"`
+	Scene                   bool              `json:"scene"`
+	Origins                 string            `json:"origins"` // SCENE, P2P, INTERNAL
+	ExceptOrigins           string            `json:"except_origins"`
+	Freeleech               bool              `json:"freeleech"`
+	FreeleechPercent        string            `json:"freeleech_percent"`
+	UseFreeleechToken       bool              `json:"use_freeleech_token"`
+	FreeleechTokenMinSize   string            `json:"freeleech_token_min_size"` // only use a token for releases at least this big
+	QuotaGrabsPerHour       int               `json:"quota_grabs_per_hour"`
+	QuotaGrabsPerDay        int               `json:"quota_grabs_per_day"`
+	QuotaGrabsPerWeek       int               `json:"quota_grabs_per_week"`
+	QuotaGrabsPerMonth      int               `json:"quota_grabs_per_month"`
+	QuotaBytesPerDay        string            `json:"quota_bytes_per_day"`
+	SkipDuplicates          bool              `json:"skip_duplicates"`
+	DuplicateWindow         int               `json:"duplicate_window"`          // hours
+	DuplicatePreferIndexers string            `json:"duplicate_prefer_indexers"` // still grab duplicates from these indexers
+	SmartEpisode            bool              `json:"smart_episode"`             // skip episodes already grabbed in equal or better quality
+	SmartEpisodeWindow      int               `json:"smart_episode_window"`      // hours
+	ExternalScriptEnabled   bool              `json:"external_script_enabled"`
+	ExternalScriptCmd       string            `json:"external_script_cmd"`
+	ExternalScriptArgs      string            `json:"external_script_args"`          // same vars as exec actions
+	ExternalScriptStatus    int               `json:"external_script_expect_status"` // exit code that counts as a match
+	ExternalScriptOutput    string            `json:"external_script_expect_output"` // optional RE2 the stdout has to match
+	ExternalScriptTimeout   int               `json:"external_script_timeout"`       // seconds
+	ExternalWebhookEnabled  bool              `json:"external_webhook_enabled"`
+	ExternalWebhookHost     string            `json:"external_webhook_host"`
+	ExternalWebhookData     string            `json:"external_webhook_data"`          // json payload with the same vars as webhook actions, the release when empty
+	ExternalWebhookStatus   int               `json:"external_webhook_expect_status"` // any 2xx when 0
+	ExternalWebhookField    string            `json:"external_webhook_expect_field"`  // optional dot separated path to a json field that has to be true
+	Shows                   string            `json:"shows"`
+	Seasons                 string            `json:"seasons"`
+	Episodes                string            `json:"episodes"`
+	SeasonPacks             SeasonPacks       `json:"season_packs"`
+	DailyMaxAge             int               `json:"daily_max_age"` // days, only applies to releases with an air date
+	Resolutions             []string          `json:"resolutions"`   // SD, 480i, 480p, 576p, 720p, 810p, 1080i, 1080p.
+	Codecs                  []string          `json:"codecs"`        // XviD, DivX, x264, h.264 (or h264), mpeg2 (or mpeg-2), VC-1 (or VC1), WMV, Remux, h.264 Remux (or h264 Remux), VC-1 Remux (or VC1 Remux).
+	Sources                 []string          `json:"sources"`       // DSR, PDTV, HDTV, HR.PDTV, HR.HDTV, DVDRip, DVDScr, BDr, BD5, BD9, BDRip, BRRip, DVDR, MDVDR, HDDVD, HDDVDRip, BluRay, WEB-DL, TVRip, CAM, R5, TELESYNC, TS, TELECINE, TC. TELESYNC and TS are synonyms (you don't need both). Same for TELECINE and TC
+	Containers              []string          `json:"containers"`
+	MatchHDR                []string          `json:"match_hdr"`
+	ExceptHDR               []string          `json:"except_hdr"`
+	MatchAudio              []string          `json:"match_audio"` // Atmos, TrueHD, DTS-X, DTS-HD MA, DTS-HD, DTS, DD+, DD, FLAC, LPCM, Opus, AAC, MP3
+	ExceptAudio             []string          `json:"except_audio"`
+	AudioChannels           []string          `json:"audio_channels"` // 2.0, 5.1, 7.1
+	MatchLanguages          []string          `json:"match_languages"`
+	ExceptLanguages         []string          `json:"except_languages"`
+	MatchSubtitles          []string          `json:"match_subtitles"`
+	ExceptSubtitles         []string          `json:"except_subtitles"`
+	SubtitleType            SubtitleType      `json:"subtitle_type"` // HARDCODED, SOFTCODED, any when empty
+	Years                   string            `json:"years"`
+	Artists                 string            `json:"artists"`
+	Albums                  string            `json:"albums"`
+	MatchReleaseTypes       []string          `json:"match_release_types"` // Album,Single,EP
+	ExceptReleaseTypes      string            `json:"except_release_types"`
+	Formats                 []string          `json:"formats"` // MP3, FLAC, Ogg, AAC, AC3, DTS
+	Quality                 []string          `json:"quality"` // 192, 320, APS (VBR), V2 (VBR), V1 (VBR), APX (VBR), V0 (VBR), q8.x (VBR), Lossless, 24bit Lossless, Other
+	Media                   []string          `json:"media"`   // CD, DVD, Vinyl, Soundboard, SACD, DAT, Cassette, WEB, Other
+	PerfectFlac             bool              `json:"perfect_flac"`
+	Cue                     bool              `json:"cue"`
+	Log                     bool              `json:"log"`
+	LogScore                int               `json:"log_score"` // minimum, requires log
+	MatchCategories         string            `json:"match_categories"`
+	ExceptCategories        string            `json:"except_categories"`
+	MatchUploaders          string            `json:"match_uploaders"` // exact names or wildcards, comma separated
+	ExceptUploaders         string            `json:"except_uploaders"`
+	Tags                    string            `json:"tags"`
+	ExceptTags              string            `json:"except_tags"`
+	TagsMatchLogic          TagsMatchLogic    `json:"tags_match_logic"`        // ANY when empty
+	ExceptTagsMatchLogic    TagsMatchLogic    `json:"except_tags_match_logic"` // ANY when empty
+	Expression              string            `json:"expression"`              // checked after the other fields, see ExpressionVariables
+	ActiveDays              []string          `json:"active_days"`             // mon, tue, wed, thu, fri, sat, sun, every day when empty
+	ActiveStart             string            `json:"active_start"`            // HH:MM in local time, a window can wrap past midnight
+	ActiveEnd               string            `json:"active_end"`
+	Actions                 []Action          `json:"actions"`
+	Indexers                []Indexer         `json:"indexers"`
+	IndexerAccounts         map[string]string `json:"indexer_accounts,omitempty"` // indexer identifier to pinned account name
+	IndexerOverrides        IndexerOverrides  `json:"indexer_overrides,omitempty"`
+	Stats                   *FilterStats      `json:"stats,omitempty"`    // only set in the filter list
+	Warnings                []FilterIssue     `json:"warnings,omitempty"` // not stored, set when the filter is loaded or saved
+}
+
+// IndexerOverrides indexer identifier to the override of the filter settings
+type IndexerOverrides map[string]FilterIndexerOverride
+
+// FilterIndexerOverride settings that replace the filter settings for releases from one indexer
+type FilterIndexerOverride struct {
+	MinSize string   `json:"min_size,omitempty"`
+	MaxSize string   `json:"max_size,omitempty"`
+	Actions []string `json:"actions,omitempty"` // names of the filter actions to run, all when empty
+}
+
+// ForIndexer the filter with the overrides of the indexer applied
+func (f Filter) ForIndexer(indexer string) Filter {
+	override, ok := f.IndexerOverrides[indexer]
+	if !ok {
+		return f
+	}
+
+	if override.MinSize != "" {
+		f.MinSize = override.MinSize
+	}
+
+	if override.MaxSize != "" {
+		f.MaxSize = override.MaxSize
+	}
+
+	return f
+}
+
+// Redacted the filter with the credentials of its actions blanked
+func (f Filter) Redacted() Filter {
+	if f.Actions == nil {
+		return f
+	}
+
+	actions := make([]Action, len(f.Actions))
+	for i, action := range f.Actions {
+		actions[i] = action.Redacted()
+	}
+	f.Actions = actions
+
+	return f
+}
+
+// IndexerActions the actions to run for releases from the indexer.
+// Actions are picked by name, ids change every time the filter is saved.
+func (f Filter) IndexerActions(indexer string, actions []Action) []Action {
+	override, ok := f.IndexerOverrides[indexer]
+	if !ok || len(override.Actions) == 0 {
+		return actions
+	}
+
+	var picked []Action
+	for _, action := range actions {
+		for _, name := range override.Actions {
+			if strings.EqualFold(action.Name, name) {
+				picked = append(picked, action)
+				break
+			}
+		}
+	}
+
+	return picked
+}
+
+// FilterIssue a problem with the value of a filter field
+type FilterIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// CheckWarnings checks that do not stop the filter from being saved
+func (f Filter) CheckWarnings() []FilterIssue {
+	var warnings []FilterIssue
+
+	if f.MatchReleaseGroups != "" {
+		warnings = append(warnings, releaseGroupWarnings("match_release_groups", f.MatchReleaseGroups)...)
+	}
+
+	if f.ExceptReleaseGroups != "" {
+		warnings = append(warnings, releaseGroupWarnings("except_release_groups", f.ExceptReleaseGroups)...)
+	}
+
+	return warnings
+}
+
+// MaxExternalScriptTimeout seconds, releases are held up while the script runs
+const MaxExternalScriptTimeout = 300
+
+// FilterValidationError a filter field has a value that can not be stored
+type FilterValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *FilterValidationError) Error() string {
+	return fmt.Sprintf("validation: %v: %v", e.Field, e.Err)
+}
+
+func (e *FilterValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate check the fields that would otherwise only fail when releases are checked
+func (f Filter) Validate() error {
+	if f.MatchReleasesRegex != "" {
+		if _, err := NewFilterRegex(f.MatchReleasesRegex); err != nil {
+			return &FilterValidationError{Field: "match_releases_regex", Err: err}
+		}
+	}
+
+	if f.ExceptReleasesRegex != "" {
+		if _, err := NewFilterRegex(f.ExceptReleasesRegex); err != nil {
+			return &FilterValidationError{Field: "except_releases_regex", Err: err}
+		}
+	}
+
+	if err := validateEventPatterns(f.MatchEvents); err != nil {
+		return &FilterValidationError{Field: "match_events", Err: err}
+	}
+
+	if err := validateEventPatterns(f.ExceptEvents); err != nil {
+		return &FilterValidationError{Field: "except_events", Err: err}
+	}
+
+	if _, err := ParseKeywordScores(f.KeywordScores); err != nil {
+		return &FilterValidationError{Field: "keyword_scores", Err: err}
+	}
+
+	if f.ExternalScriptEnabled && f.ExternalScriptCmd == "" {
+		return &FilterValidationError{Field: "external_script_cmd", Err: errors.New("required when the external script is enabled")}
+	}
+
+	if f.ExternalScriptOutput != "" {
+		if _, err := NewFilterRegex(f.ExternalScriptOutput); err != nil {
+			return &FilterValidationError{Field: "external_script_expect_output", Err: err}
+		}
+	}
+
+	switch f.SeasonPacks {
+	case "", SeasonPacksOnly, SeasonPacksExclude:
+	default:
+		return &FilterValidationError{Field: "season_packs", Err: fmt.Errorf("unknown value %q", f.SeasonPacks)}
+	}
+
+	if err := validateSubtitleType(f.SubtitleType); err != nil {
+		return &FilterValidationError{Field: "subtitle_type", Err: err}
+	}
+
+	if err := validateTagsMatchLogic(f.TagsMatchLogic); err != nil {
+		return &FilterValidationError{Field: "tags_match_logic", Err: err}
+	}
+
+	if err := validateTagsMatchLogic(f.ExceptTagsMatchLogic); err != nil {
+		return &FilterValidationError{Field: "except_tags_match_logic", Err: err}
+	}
+
+	if f.Expression != "" {
+		if _, err := NewFilterExpression(f.Expression); err != nil {
+			return &FilterValidationError{Field: "expression", Err: err}
+		}
+	}
+
+	for _, day := range f.ActiveDays {
+		if _, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]; !ok {
+			return &FilterValidationError{Field: "active_days", Err: fmt.Errorf("unknown day %q", day)}
+		}
+	}
+
+	if _, err := parseScheduleClock(f.ActiveStart, 0); err != nil {
+		return &FilterValidationError{Field: "active_start", Err: err}
+	}
+
+	if _, err := parseScheduleClock(f.ActiveEnd, 24*60); err != nil {
+		return &FilterValidationError{Field: "active_end", Err: err}
+	}
+
+	if f.ExternalWebhookEnabled && f.ExternalWebhookHost == "" {
+		return &FilterValidationError{Field: "external_webhook_host", Err: errors.New("required when the external webhook is enabled")}
+	}
+
+	for indexer, override := range f.IndexerOverrides {
+		if err := validateIndexerOverride(override); err != nil {
+			return &FilterValidationError{Field: fmt.Sprintf("indexer_overrides.%v", indexer), Err: err}
+		}
+	}
+
+	if f.ExternalScriptTimeout < 0 || f.ExternalScriptTimeout > MaxExternalScriptTimeout {
+		return &FilterValidationError{Field: "external_script_timeout", Err: fmt.Errorf("must be between 0 and %d seconds", MaxExternalScriptTimeout)}
+	}
+
+	return nil
+}
+
+func validateIndexerOverride(override FilterIndexerOverride) error {
+	if override.MinSize != "" {
+		if _, err := humanize.ParseBytes(override.MinSize); err != nil {
+			return fmt.Errorf("invalid min size %q", override.MinSize)
+		}
+	}
+
+	if override.MaxSize != "" {
+		if _, err := humanize.ParseBytes(override.MaxSize); err != nil {
+			return fmt.Errorf("invalid max size %q", override.MaxSize)
+		}
+	}
+
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ActiveAt whether the schedule of the filter allows matching at t.
+// A window that wraps past midnight belongs to the day it started, fri 22:00-02:00 is still active early saturday.
+func (f Filter) ActiveAt(t time.Time) bool {
+	if len(f.ActiveDays) == 0 && f.ActiveStart == "" && f.ActiveEnd == "" {
+		return true
+	}
+
+	start, err := parseScheduleClock(f.ActiveStart, 0)
+	if err != nil {
+		return false
+	}
+
+	end, err := parseScheduleClock(f.ActiveEnd, 24*60)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case start == end:
+	case start < end:
+		if minute < start || minute >= end {
+			return false
+		}
+	default:
+		if minute < end {
+			// early part of the window that started the day before
+			day = (day + 6) % 7
+		} else if minute < start {
+			return false
+		}
+	}
+
+	return f.activeOn(day)
+}
+
+func (f Filter) activeOn(day time.Weekday) bool {
+	if len(f.ActiveDays) == 0 {
+		return true
+	}
+
+	for _, d := range f.ActiveDays {
+		if weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]; ok && weekday == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateTagsMatchLogic(logic TagsMatchLogic) error {
+	switch logic {
+	case "", TagsMatchAny, TagsMatchAll:
+		return nil
+	}
+
+	return fmt.Errorf("unknown value %q", logic)
+}
+
+// NewFilterRegex compile a release name pattern, matching is case insensitive like the wildcard fields
+func NewFilterRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// filterRegexCache compiled patterns of stored filters, they are checked for every announce
+var filterRegexCache sync.Map
+
+func cachedFilterRegex(pattern string) (*regexp.Regexp, error) {
+	if rxp, ok := filterRegexCache.Load(pattern); ok {
+		return rxp.(*regexp.Regexp), nil
+	}
+
+	rxp, err := NewFilterRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	filterRegexCache.Store(pattern, rxp)
+
+	return rxp, nil
+}
+
+// FilterRegexTestResult outcome of a pattern against a sample release name
+type FilterRegexTestResult struct {
+	Sample string   `json:"sample"`
+	Match  bool     `json:"match"`
+	Groups []string `json:"groups,omitempty"` // capture groups of the first match
+}
+
+// TryFilterRegex match the pattern against sample release names the same way filters do
+func TryFilterRegex(pattern string, samples []string) ([]FilterRegexTestResult, error) {
+	rxp, err := NewFilterRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]FilterRegexTestResult, 0, len(samples))
+
+	for _, sample := range samples {
+		result := FilterRegexTestResult{Sample: sample}
+
+		if matches := rxp.FindStringSubmatch(sample); matches != nil {
+			result.Match = true
+			result.Groups = matches[1:]
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// FilterTestParams releases to dry-run a filter against, release names are used instead of stored releases when set
+type FilterTestParams struct {
+	ReleaseNames []string `json:"release_names"`
+	Indexer      string   `json:"indexer"` // indexer the release names are checked as
+	Limit        uint64   `json:"limit"`   // number of recent stored releases
+}
+
+// FilterTestResult whether the filter would match a release and why not
+type FilterTestResult struct {
+	ReleaseID         int64    `json:"release_id,omitempty"`
+	TorrentName       string   `json:"torrent_name"`
+	Indexer           string   `json:"indexer,omitempty"`
+	Match             bool     `json:"match"`
+	Rejections        []string `json:"rejections,omitempty"`
+	SizeCheckRequired bool     `json:"size_check_required,omitempty"` // the match still depends on the size from the torrent file
+}
+
+// Quota download limits of the filter
+func (f Filter) Quota() Quota {
+	return Quota{
+		GrabsPerHour:  f.QuotaGrabsPerHour,
+		GrabsPerDay:   f.QuotaGrabsPerDay,
+		GrabsPerWeek:  f.QuotaGrabsPerWeek,
+		GrabsPerMonth: f.QuotaGrabsPerMonth,
+		BytesPerDay:   f.QuotaBytesPerDay,
+	}
+}
+
+// UseFreeleechTokenForSize whether a release of this size is big enough to spend a freeleech token on.
+// An unknown size only passes when no min size is set.
+func (f Filter) UseFreeleechTokenForSize(size uint64) (bool, error) {
+	if f.FreeleechTokenMinSize == "" {
+		return true, nil
+	}
+
+	minSize, err := humanize.ParseBytes(f.FreeleechTokenMinSize)
+	if err != nil {
+		return false, fmt.Errorf("could not parse freeleech token min size %q: %w", f.FreeleechTokenMinSize, err)
+	}
+
+	return size > 0 && size >= minSize, nil
 }
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// FilterDiagnostics result of checking a filter before it is saved.
+// Errors stop the filter from being saved, warnings are likely mistakes.
+type FilterDiagnostics struct {
+	Valid    bool          `json:"valid"`
+	Errors   []FilterIssue `json:"errors"`
+	Warnings []FilterIssue `json:"warnings"`
+}
+
+// Diagnose check the filter for invalid values and contradicting fields
+func (f Filter) Diagnose() FilterDiagnostics {
+	d := FilterDiagnostics{Errors: []FilterIssue{}, Warnings: []FilterIssue{}}
+
+	if strings.TrimSpace(f.Name) == "" {
+		d.Errors = append(d.Errors, FilterIssue{Field: "name", Message: "can't be empty"})
+	}
+
+	if err := f.Validate(); err != nil {
+		var validationErr *FilterValidationError
+		if errors.As(err, &validationErr) {
+			d.Errors = append(d.Errors, FilterIssue{Field: validationErr.Field, Message: validationErr.Err.Error()})
+		} else {
+			d.Errors = append(d.Errors, FilterIssue{Message: err.Error()})
+		}
+	}
+
+	d.Errors = append(d.Errors, f.sizeIssues()...)
+
+	d.Warnings = append(d.Warnings, f.CheckWarnings()...)
+	d.Warnings = append(d.Warnings, f.contradictions()...)
+
+	if len(f.Indexers) == 0 {
+		d.Warnings = append(d.Warnings, FilterIssue{Field: "indexers", Message: "no indexers, the filter is never checked"})
+	}
+
+	if len(f.Actions) == 0 {
+		d.Warnings = append(d.Warnings, FilterIssue{Field: "actions", Message: "no actions, matching releases are not grabbed"})
+	}
+
+	d.Valid = len(d.Errors) == 0
+
+	return d
+}
+
+// sizeIssues sizes that can not be parsed, or a min size above the max size
+func (f Filter) sizeIssues() []FilterIssue {
+	var issues []FilterIssue
+
+	var minSize, maxSize uint64
+	var err error
+
+	if f.MinSize != "" {
+		if minSize, err = humanize.ParseBytes(f.MinSize); err != nil {
+			issues = append(issues, FilterIssue{Field: "min_size", Message: fmt.Sprintf("invalid size %q", f.MinSize)})
+		}
+	}
+
+	if f.MaxSize != "" {
+		if maxSize, err = humanize.ParseBytes(f.MaxSize); err != nil {
+			issues = append(issues, FilterIssue{Field: "max_size", Message: fmt.Sprintf("invalid size %q", f.MaxSize)})
+		}
+	}
+
+	if minSize > 0 && maxSize > 0 && minSize > maxSize {
+		issues = append(issues, FilterIssue{Field: "min_size", Message: fmt.Sprintf("min size %v is larger than max size %v", f.MinSize, f.MaxSize)})
+	}
+
+	return issues
+}
+
+// contradictions match and except fields that reject everything the filter is meant to match
+func (f Filter) contradictions() []FilterIssue {
+	var issues []FilterIssue
+
+	// except releases are substring matches, a match entry containing an excluded term is always rejected
+	for _, match := range splitFilterList(f.MatchReleases) {
+		for _, except := range splitFilterList(f.ExceptReleases) {
+			if strings.ContainsAny(except, "?*") || !strings.Contains(match, except) {
+				continue
+			}
+
+			issues = append(issues, FilterIssue{Field: "except_releases", Message: fmt.Sprintf("%q excludes every release matching %q", except, match)})
+		}
+	}
+
+	lists := []struct {
+		field  string
+		match  []string
+		except []string
+	}{
+		{field: "except_release_groups", match: normalizeGroupList(f.MatchReleaseGroups), except: normalizeGroupList(f.ExceptReleaseGroups)},
+		{field: "except_categories", match: splitFilterList(f.MatchCategories), except: splitFilterList(f.ExceptCategories)},
+		{field: "except_uploaders", match: splitFilterList(f.MatchUploaders), except: splitFilterList(f.ExceptUploaders)},
+		{field: "except_tags", match: splitFilterList(f.Tags), except: splitFilterList(f.ExceptTags)},
+		{field: "except_origins", match: splitFilterList(f.Origins), except: splitFilterList(f.ExceptOrigins)},
+		{field: "except_hdr", match: lowerList(f.MatchHDR), except: lowerList(f.ExceptHDR)},
+		{field: "except_audio", match: lowerList(f.MatchAudio), except: lowerList(f.ExceptAudio)},
+		{field: "except_languages", match: lowerList(f.MatchLanguages), except: lowerList(f.ExceptLanguages)},
+		{field: "except_subtitles", match: lowerList(f.MatchSubtitles), except: lowerList(f.ExceptSubtitles)},
+	}
+
+	for _, list := range lists {
+		for _, match := range list.match {
+			for _, except := range list.except {
+				if match == except {
+					issues = append(issues, FilterIssue{Field: list.field, Message: fmt.Sprintf("%q is both matched and excluded", except)})
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// splitFilterList lower case entries of a comma separated list, empty entries left out
+func splitFilterList(list string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func normalizeGroupList(list string) []string {
+	var groups []string
+	for _, entry := range strings.Split(list, ",") {
+		if group := NormalizeReleaseGroup(entry); group != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+func lowerList(values []string) []string {
+	lower := make([]string, 0, len(values))
+	for _, value := range values {
+		lower = append(lower, strings.ToLower(strings.TrimSpace(value)))
+	}
+
+	return lower
+}
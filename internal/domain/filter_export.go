@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FilterExportVersion format version of exported filters
+const FilterExportVersion = 1
+
+// FilterExport portable filters, ids and secrets are left out so they can be shared and imported on other instances
+type FilterExport struct {
+	Version int      `json:"version"`
+	Filters []Filter `json:"filters"`
+}
+
+// FilterImportConflict what to do with an imported filter that has the name of an existing filter
+type FilterImportConflict string
+
+const (
+	FilterImportRename    FilterImportConflict = "rename"
+	FilterImportOverwrite FilterImportConflict = "overwrite"
+	FilterImportSkip      FilterImportConflict = "skip"
+)
+
+// FilterImportResult what happened to one imported filter
+type FilterImportResult struct {
+	Name   string `json:"name"` // name in the import
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	FilterImportCreated     = "created"
+	FilterImportRenamed     = "renamed"
+	FilterImportOverwritten = "overwritten"
+	FilterImportSkipped     = "skipped"
+	FilterImportFailed      = "failed"
+)
+
+// ParseFilterImportConflict rename when empty
+func ParseFilterImportConflict(value string) (FilterImportConflict, error) {
+	switch FilterImportConflict(value) {
+	case "":
+		return FilterImportRename, nil
+	case FilterImportRename, FilterImportOverwrite, FilterImportSkip:
+		return FilterImportConflict(value), nil
+	}
+
+	return "", fmt.Errorf("unknown conflict handling %q", value)
+}
+
+// Portable the filter without ids, timestamps and secrets.
+// Indexers are kept by identifier and actions lose their download client, those differ between instances.
+func (f Filter) Portable() Filter {
+	f.ID = 0
+	f.CreatedAt = time.Time{}
+	f.UpdatedAt = time.Time{}
+	f.IndexerAccounts = nil
+	f.Warnings = nil
+
+	indexers := make([]Indexer, 0, len(f.Indexers))
+	for _, indexer := range f.Indexers {
+		indexers = append(indexers, Indexer{Identifier: indexer.Identifier, Name: indexer.Name})
+	}
+	f.Indexers = indexers
+
+	actions := make([]Action, 0, len(f.Actions))
+	for _, action := range f.Actions {
+		actions = append(actions, action.portable())
+	}
+	f.Actions = actions
+
+	return f
+}
+
+// portable the action without the download client and fields that usually hold credentials
+func (a Action) portable() Action {
+	a.ID = 0
+	a.FilterID = 0
+	a.ClientID = 0
+	a.ExecEnv = ""
+	a.WebhookHeaders = nil
+
+	return a
+}
+
+// restoreSecrets keep the client and credentials of the existing action when an import overwrites it.
+// Credentials are only kept for the same command or webhook host, so an import can't send them elsewhere.
+func (a Action) restoreSecrets(existing []Action) Action {
+	for _, e := range existing {
+		if !strings.EqualFold(e.Name, a.Name) || e.Type != a.Type {
+			continue
+		}
+
+		a.ClientID = e.ClientID
+
+		if e.ExecCmd == a.ExecCmd {
+			a.ExecEnv = e.ExecEnv
+		}
+
+		if e.WebhookHost == a.WebhookHost {
+			a.WebhookHeaders = e.WebhookHeaders
+		}
+
+		return a
+	}
+
+	return a
+}
+
+// RestoreActionSecrets the imported actions with the client and credentials of existing actions with the same name and type
+func RestoreActionSecrets(imported []Action, existing []Action) []Action {
+	actions := make([]Action, 0, len(imported))
+	for _, action := range imported {
+		actions = append(actions, action.restoreSecrets(existing))
+	}
+
+	return actions
+}
+
+// UniqueFilterName the name, or the name with a number like "Movies (2)" when it is taken
+func UniqueFilterName(name string, taken map[string]bool) string {
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%v (%d)", name, i)
+		if !taken[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
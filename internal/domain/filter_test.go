@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Validate(t *testing.T) {
+	assert.NoError(t, Filter{Name: "ok", MatchReleasesRegex: `^that\.show`, ExceptReleasesRegex: `(?:hdtv|xvid)`}.Validate())
+
+	err := Filter{Name: "bad", ExceptReleasesRegex: `(hdtv`}.Validate()
+
+	var validationErr *FilterValidationError
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, "except_releases_regex", validationErr.Field)
+	}
+	err = Filter{Name: "bad", Expression: `resolution == "1080p" && bitrate > 10`}.Validate()
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, "expression", validationErr.Field)
+	}
+	err = Filter{Name: "bad", SubtitleType: "BURNED"}.Validate()
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, "subtitle_type", validationErr.Field)
+	}
+}
+
+func TestTryFilterRegex(t *testing.T) {
+	results, err := TryFilterRegex(`^that\.show\.s(\d{2})e(\d{2})`, []string{"That.Show.S01E05.1080p.WEB-DL-GRP", "Other.Show.S01E05.1080p.WEB-DL-GRP"})
+	assert.NoError(t, err)
+	assert.Equal(t, []FilterRegexTestResult{
+		{Sample: "That.Show.S01E05.1080p.WEB-DL-GRP", Match: true, Groups: []string{"01", "05"}},
+		{Sample: "Other.Show.S01E05.1080p.WEB-DL-GRP"},
+	}, results)
+
+	_, err = TryFilterRegex(`(that`, []string{"That.Show"})
+	assert.Error(t, err)
+}
+
+func TestFilterPriorities(t *testing.T) {
+	assert.Equal(t, map[int]int32{4: 3, 1: 2, 7: 1}, FilterPriorities([]int{4, 1, 7}))
+	assert.Empty(t, FilterPriorities(nil))
+}
+
+func TestFilter_IndexerOverrides(t *testing.T) {
+	f := Filter{
+		Name:    "movies",
+		MinSize: "1GB",
+		MaxSize: "10GB",
+		IndexerOverrides: IndexerOverrides{
+			"ptp": {MaxSize: "40GB", Actions: []string{"qbit remux"}},
+		},
+	}
+	actions := []Action{{Name: "qbit"}, {Name: "Qbit Remux"}}
+
+	assert.Equal(t, "10GB", f.ForIndexer("btn").MaxSize)
+	assert.Equal(t, "40GB", f.ForIndexer("ptp").MaxSize)
+	assert.Equal(t, "1GB", f.ForIndexer("ptp").MinSize)
+
+	assert.Equal(t, actions, f.IndexerActions("btn", actions))
+	assert.Equal(t, []Action{{Name: "Qbit Remux"}}, f.IndexerActions("ptp", actions))
+
+	f.IndexerOverrides["ptp"] = FilterIndexerOverride{MaxSize: "lots"}
+
+	var validationErr *FilterValidationError
+	if assert.True(t, errors.As(f.Validate(), &validationErr)) {
+		assert.Equal(t, "indexer_overrides.ptp", validationErr.Field)
+	}
+}
+
+func TestFilter_Portable(t *testing.T) {
+	f := Filter{
+		ID:              4,
+		Name:            "movies",
+		IndexerAccounts: map[string]string{"ptp": "second"},
+		Indexers:        []Indexer{{ID: 2, Identifier: "ptp", Name: "PassThePopcorn", Settings: map[string]string{"passkey": "secret"}}},
+		Actions:         []Action{{ID: 9, FilterID: 4, ClientID: 1, Name: "qbit", Type: ActionTypeQbittorrent, WebhookHeaders: map[string]string{"Authorization": "secret"}, ExecEnv: "TOKEN=secret", Category: "movies"}},
+	}
+
+	got := f.Portable()
+	assert.Equal(t, Filter{
+		Name:     "movies",
+		Indexers: []Indexer{{Identifier: "ptp", Name: "PassThePopcorn"}},
+		Actions:  []Action{{Name: "qbit", Type: ActionTypeQbittorrent, Category: "movies"}},
+	}, got)
+
+	restored := RestoreActionSecrets(got.Actions, f.Actions)
+	assert.Equal(t, int32(1), restored[0].ClientID)
+	assert.Equal(t, "TOKEN=secret", restored[0].ExecEnv)
+
+	// credentials are not handed to a different command or webhook
+	existing := []Action{{Name: "hook", Type: ActionTypeWebhook, WebhookHost: "https://hooks.example.com", WebhookHeaders: map[string]string{"Authorization": "secret"}, ExecCmd: "/bin/notify", ExecEnv: "TOKEN=secret"}}
+
+	restored = RestoreActionSecrets([]Action{{Name: "hook", Type: ActionTypeWebhook, WebhookHost: "https://evil.example.com", ExecCmd: "/bin/notify"}}, existing)
+	assert.Nil(t, restored[0].WebhookHeaders)
+	assert.Equal(t, "TOKEN=secret", restored[0].ExecEnv)
+
+	restored = RestoreActionSecrets([]Action{{Name: "hook", Type: ActionTypeWebhook, WebhookHost: "https://hooks.example.com", ExecCmd: "/bin/sh"}}, existing)
+	assert.Equal(t, map[string]string{"Authorization": "secret"}, restored[0].WebhookHeaders)
+	assert.Empty(t, restored[0].ExecEnv)
+}
+
+func TestUniqueFilterName(t *testing.T) {
+	taken := map[string]bool{"movies": true, "movies (2)": true}
+
+	assert.Equal(t, "shows", UniqueFilterName("shows", taken))
+	assert.Equal(t, "Movies (3)", UniqueFilterName("Movies", taken))
+}
+
+func TestFilterBulkUpdate_Validate(t *testing.T) {
+	assert.NoError(t, FilterBulkUpdate{FilterIDs: []int{1, 2}, Operation: FilterBulkDisable}.Validate())
+	assert.NoError(t, FilterBulkUpdate{FilterIDs: []int{1}, Operation: FilterBulkSetIndexers}.Validate())
+
+	tests := []struct {
+		update FilterBulkUpdate
+		field  string
+	}{
+		{update: FilterBulkUpdate{Operation: FilterBulkEnable}, field: "filter_ids"},
+		{update: FilterBulkUpdate{FilterIDs: []int{1}, Operation: "rename"}, field: "operation"},
+		{update: FilterBulkUpdate{FilterIDs: []int{1}, Operation: FilterBulkDelete, IndexerIDs: []int{3}}, field: "indexer_ids"},
+	}
+	for _, tt := range tests {
+		var validationErr *FilterValidationError
+		if assert.True(t, errors.As(tt.update.Validate(), &validationErr)) {
+			assert.Equal(t, tt.field, validationErr.Field)
+		}
+	}
+}
+
+func TestFilter_ActiveAt(t *testing.T) {
+	// 2022-04-01 is a friday
+	at := func(day int, clock string) time.Time {
+		tm, _ := time.Parse("15:04", clock)
+		return time.Date(2022, 4, day, tm.Hour(), tm.Minute(), 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		at     time.Time
+		want   bool
+	}{
+		{name: "no_schedule", filter: Filter{}, at: at(1, "12:00"), want: true},
+		{name: "weekend", filter: Filter{ActiveDays: []string{"sat", "Sun"}}, at: at(2, "12:00"), want: true},
+		{name: "weekend_friday", filter: Filter{ActiveDays: []string{"sat", "sun"}}, at: at(1, "12:00"), want: false},
+		{name: "window", filter: Filter{ActiveStart: "01:00", ActiveEnd: "07:00"}, at: at(1, "06:59"), want: true},
+		{name: "window_end", filter: Filter{ActiveStart: "01:00", ActiveEnd: "07:00"}, at: at(1, "07:00"), want: false},
+		{name: "wrap_same_day", filter: Filter{ActiveDays: []string{"fri"}, ActiveStart: "22:00", ActiveEnd: "02:00"}, at: at(1, "23:00"), want: true},
+		{name: "wrap_next_day", filter: Filter{ActiveDays: []string{"fri"}, ActiveStart: "22:00", ActiveEnd: "02:00"}, at: at(2, "01:00"), want: true},
+		{name: "wrap_started_thursday", filter: Filter{ActiveDays: []string{"fri"}, ActiveStart: "22:00", ActiveEnd: "02:00"}, at: at(1, "01:00"), want: false},
+		{name: "wrap_outside", filter: Filter{ActiveStart: "22:00", ActiveEnd: "02:00"}, at: at(1, "12:00"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.ActiveAt(tt.at))
+		})
+	}
+
+	var validationErr *FilterValidationError
+	if assert.True(t, errors.As(Filter{Name: "bad", ActiveDays: []string{"someday"}}.Validate(), &validationErr)) {
+		assert.Equal(t, "active_days", validationErr.Field)
+	}
+	if assert.True(t, errors.As(Filter{Name: "bad", ActiveEnd: "25:00"}.Validate(), &validationErr)) {
+		assert.Equal(t, "active_end", validationErr.Field)
+	}
+}
+
+func TestNormalizeReleaseGroup(t *testing.T) {
+	for _, name := range []string{"NTb", " ntb", "[NTb]", "-NTb", "NTb-", "(NTB)"} {
+		assert.Equal(t, "ntb", NormalizeReleaseGroup(name), name)
+	}
+
+	assert.Equal(t, "d-z0n3", NormalizeReleaseGroup("D-Z0N3"))
+	assert.Equal(t, "de[42]", NormalizeReleaseGroup("de[42]"))
+}
+
+func TestFilter_CheckWarnings(t *testing.T) {
+	assert.Empty(t, Filter{MatchReleaseGroups: "NTb, FLUX,D-Z0N3"}.CheckWarnings())
+
+	warnings := Filter{MatchReleaseGroups: "NTb FLUX", ExceptReleaseGroups: "NTb,,FLUX-"}.CheckWarnings()
+	assert.Equal(t, []FilterIssue{
+		{Field: "match_release_groups", Message: `"NTb FLUX" contains a space, groups are separated by commas`},
+		{Field: "except_release_groups", Message: "empty entry, check for a double or trailing comma"},
+		{Field: "except_release_groups", Message: `"FLUX-" has a leading or trailing dash, it is ignored`},
+	}, warnings)
+}
+
+func TestFilter_Diagnose(t *testing.T) {
+	ok := Filter{Name: "ok", MinSize: "1 GB", MaxSize: "10 GB", Indexers: []Indexer{{ID: 1}}, Actions: []Action{{Name: "qbit"}}}
+	assert.Equal(t, FilterDiagnostics{Valid: true, Errors: []FilterIssue{}, Warnings: []FilterIssue{}}, ok.Diagnose())
+
+	d := Filter{
+		MinSize:             "10 GB",
+		MaxSize:             "1 GB",
+		MatchReleasesRegex:  "(1080p",
+		MatchReleases:       "*1080p.web*",
+		ExceptReleases:      "web",
+		MatchReleaseGroups:  "NTb",
+		ExceptReleaseGroups: "[ntb]",
+	}.Diagnose()
+
+	assert.False(t, d.Valid)
+	assert.Equal(t, []FilterIssue{
+		{Field: "name", Message: "can't be empty"},
+		{Field: "match_releases_regex", Message: "error parsing regexp: missing closing ): `(?i)(1080p`"},
+		{Field: "min_size", Message: "min size 10 GB is larger than max size 1 GB"},
+	}, d.Errors)
+	assert.Equal(t, []FilterIssue{
+		{Field: "except_releases", Message: `"web" excludes every release matching "*1080p.web*"`},
+		{Field: "except_release_groups", Message: `"ntb" is both matched and excluded`},
+		{Field: "indexers", Message: "no indexers, the filter is never checked"},
+		{Field: "actions", Message: "no actions, matching releases are not grabbed"},
+	}, d.Warnings)
+}
+
+func TestFilter_Redacted(t *testing.T) {
+	f := Filter{Name: "f", Actions: []Action{{Name: "exec", ExecEnv: "TOKEN=secret", WebhookHeaders: map[string]string{"Authorization": "Bearer secret"}}}}
+
+	r := f.Redacted()
+	assert.Equal(t, []Action{{Name: "exec"}}, r.Actions)
+	assert.Equal(t, "TOKEN=secret", f.Actions[0].ExecEnv, "the original filter keeps its credentials")
+}
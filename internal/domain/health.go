@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HealthStatusOK          HealthStatus = "ok"
+	HealthStatusDegraded    HealthStatus = "degraded"    // working, but a network or client is down
+	HealthStatusUnavailable HealthStatus = "unavailable" // can't work without it, like the database
+)
+
+// HealthCheck result of checking one dependency
+type HealthCheck struct {
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+// HealthReport liveness or readiness of autobrr and the checked dependencies
+type HealthReport struct {
+	Status    HealthStatus           `json:"status"`
+	StartedAt time.Time              `json:"started_at"`
+	Checks    map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// Ready false only when a required dependency is unavailable, a degraded instance still grabs
+func (r HealthReport) Ready() bool {
+	return r.Status != HealthStatusUnavailable
+}
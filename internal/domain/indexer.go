@@ -2,8 +2,13 @@ package domain
 
 import (
 	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
 )
 
 type IndexerRepo interface {
@@ -12,32 +17,159 @@ type IndexerRepo interface {
 	List() ([]Indexer, error)
 	Delete(ctx context.Context, id int) error
 	FindByFilterID(ctx context.Context, id int) ([]Indexer, error)
+	SetCredentialsInvalid(ctx context.Context, identifier string, reason string) error
+	ListCredentialsInvalid(ctx context.Context) (map[string]string, error)
 }
 
 type Indexer struct {
-	ID         int64             `json:"id"`
-	Name       string            `json:"name"`
-	Identifier string            `json:"identifier"`
-	Enabled    bool              `json:"enabled"`
-	Type       string            `json:"type,omitempty"`
-	Settings   map[string]string `json:"settings,omitempty"`
+	ID               int64                   `json:"id"`
+	Name             string                  `json:"name"`
+	Identifier       string                  `json:"identifier"`
+	Enabled          bool                    `json:"enabled"`
+	Implementation   string                  `json:"implementation"`
+	Type             string                  `json:"type,omitempty"`
+	Settings         map[string]string       `json:"settings,omitempty"`
+	DownloadLimits   IndexerDownloadLimits   `json:"download_limits"`
+	DownloadRetry    IndexerDownloadRetry    `json:"download_retry"`
+	Proxy            string                  `json:"proxy"`
+	Accounts         []IndexerAccount        `json:"accounts"`
+	AccountSelection IndexerAccountSelection `json:"account_selection"`
+}
+
+// IndexerAccount extra credentials for an indexer, settings override the indexer settings when used
+type IndexerAccount struct {
+	Name     string            `json:"name"`
+	Enabled  bool              `json:"enabled"`
+	Settings map[string]string `json:"settings"`
+}
+
+type IndexerAccountSelection string
+
+const (
+	// IndexerAccountSelectionDefault use the indexer settings unless a filter pins an account
+	IndexerAccountSelectionDefault IndexerAccountSelection = "DEFAULT"
+	// IndexerAccountSelectionRoundRobin rotate between the indexer settings and all enabled accounts
+	IndexerAccountSelectionRoundRobin IndexerAccountSelection = "ROUND_ROBIN"
+)
+
+// IndexerAccountDefault name used by filters to pin the indexer settings
+const IndexerAccountDefault = "default"
+
+// IndexerDownloadLimits limits for fetching torrent files from an indexer, 0 means unlimited
+type IndexerDownloadLimits struct {
+	PerMinute     int `json:"per_minute"`
+	PerHour       int `json:"per_hour"`
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+func (l IndexerDownloadLimits) Enabled() bool {
+	return l.PerMinute > 0 || l.PerHour > 0 || l.MaxConcurrent > 0
+}
+
+// IndexerDownloadRetry retries of torrent file downloads that failed with a retryable error, 0 attempts means no retries
+type IndexerDownloadRetry struct {
+	Attempts int `json:"attempts"`
+	Delay    int `json:"delay"` // seconds before the first retry, doubled for every next one
+}
+
+// DefaultDownloadRetryDelay delay before the first retry when none is set
+const DefaultDownloadRetryDelay = 5 * time.Second
+
+// Backoff wait before retry number attempt, starting at 1
+func (r IndexerDownloadRetry) Backoff(attempt int) time.Duration {
+	delay := DefaultDownloadRetryDelay
+	if r.Delay > 0 {
+		delay = time.Duration(r.Delay) * time.Second
+	}
+
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+	}
+
+	return delay
+}
+
+// IndexerAuthSettings what to do when the downloads of an indexer keep failing on its credentials
+type IndexerAuthSettings struct {
+	Failures     int  // failed downloads in a row before the credentials are flagged invalid
+	PauseFilters bool // disable the filters of the indexer when flagged
+}
+
+// IndexerCredentialsEvent an indexer was flagged with invalid credentials
+type IndexerCredentialsEvent struct {
+	Indexer      string `json:"indexer"`
+	Name         string `json:"name"`
+	Failures     int    `json:"failures"`
+	Reason       string `json:"reason"`
+	PauseFilters bool   `json:"pause_filters"`
+}
+
+// TorrentCacheSettings downloaded torrent files kept on disk
+type TorrentCacheSettings struct {
+	TTL time.Duration // how long a file is reused, 0 turns the cache off
+}
+
+func (s TorrentCacheSettings) Enabled() bool {
+	return s.TTL > 0
+}
+
+// TorrentCacheStats files in the torrent cache and how often it saved a download since the start
+type TorrentCacheStats struct {
+	Enabled    bool  `json:"enabled"`
+	TTLMinutes int   `json:"ttl_minutes"`
+	Files      int   `json:"files"`
+	Bytes      int64 `json:"bytes"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+}
+
+// ParseIndexerProxy parse and validate an indexer proxy url, supports http, https and socks5
+func ParseIndexerProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid proxy url")
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme: %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, errors.New("proxy url is missing host")
+	}
+
+	return u, nil
 }
 
 type IndexerDefinition struct {
-	ID          int               `json:"id,omitempty"`
-	Name        string            `json:"name"`
-	Identifier  string            `json:"identifier"`
-	Enabled     bool              `json:"enabled,omitempty"`
-	Description string            `json:"description"`
-	Language    string            `json:"language"`
-	Privacy     string            `json:"privacy"`
-	Protocol    string            `json:"protocol"`
-	URLS        []string          `json:"urls"`
-	Supports    []string          `json:"supports"`
-	Settings    []IndexerSetting  `json:"settings"`
-	SettingsMap map[string]string `json:"-"`
-	IRC         *IndexerIRC       `json:"irc"`
-	Parse       IndexerParse      `json:"parse"`
+	ID               int                     `json:"id,omitempty"`
+	Name             string                  `json:"name"`
+	Identifier       string                  `json:"identifier"`
+	Implementation   string                  `json:"implementation"`
+	Enabled          bool                    `json:"enabled,omitempty"`
+	Description      string                  `json:"description"`
+	Language         string                  `json:"language"`
+	Privacy          string                  `json:"privacy"`
+	Protocol         string                  `json:"protocol"`
+	URLS             []string                `json:"urls"`
+	Supports         []string                `json:"supports"`
+	Settings         []IndexerSetting        `json:"settings"`
+	SettingsMap      map[string]string       `json:"-"`
+	DownloadLimits   IndexerDownloadLimits   `json:"download_limits"`
+	DownloadRetry    IndexerDownloadRetry    `json:"download_retry"`
+	Proxy            string                  `json:"proxy,omitempty"`
+	Health           *IndexerHealth          `json:"health,omitempty"`
+	Accounts         []IndexerAccount        `json:"accounts,omitempty"`
+	AccountSelection IndexerAccountSelection `json:"account_selection,omitempty"`
+	Test             *IndexerTest            `json:"test,omitempty"`
+	Testable         bool                    `json:"testable"`
+	API              *IndexerAPI             `json:"api,omitempty"`
+	IRC              *IndexerIRC             `json:"irc"`
+	Torznab          *Torznab                `json:"torznab"`
+	RSS              *FeedSettings           `json:"rss"`
+	Parse            IndexerParse            `json:"parse"`
 }
 
 func (i IndexerDefinition) HasApi() bool {
@@ -49,6 +181,162 @@ func (i IndexerDefinition) HasApi() bool {
 	return false
 }
 
+// IndexerTest a known path to fetch for checking the stored passkey or cookie
+type IndexerTest struct {
+	URL string `json:"url"` // template with indexer settings and baseUrl
+}
+
+var (
+	ErrIndexerNotFound = errors.New("indexer not found")
+	// ErrIndexerNotTestable the indexer has no api, test url or feed to check the settings with
+	ErrIndexerNotTestable = errors.New("no health check available for indexer")
+)
+
+type IndexerHealthStatus string
+
+const (
+	IndexerHealthOK      IndexerHealthStatus = "OK"
+	IndexerHealthError   IndexerHealthStatus = "ERROR"
+	IndexerHealthUnknown IndexerHealthStatus = "UNKNOWN"
+	// IndexerHealthCredentialsInvalid torrent downloads keep failing on the passkey or cookie
+	IndexerHealthCredentialsInvalid IndexerHealthStatus = "CREDENTIALS_INVALID"
+)
+
+type IndexerHealth struct {
+	Status    IndexerHealthStatus `json:"status"`
+	Message   string              `json:"message,omitempty"`
+	CheckedAt time.Time           `json:"checked_at"`
+}
+
+// SelectAccount pick account for a release, nil means the indexer settings.
+// n is a running counter used for round-robin.
+func (i IndexerDefinition) SelectAccount(pinned string, n uint64) (*IndexerAccount, error) {
+	var enabled []IndexerAccount
+	for _, a := range i.Accounts {
+		if a.Enabled {
+			enabled = append(enabled, a)
+		}
+	}
+
+	if pinned != "" && pinned != IndexerAccountDefault {
+		for _, a := range enabled {
+			if a.Name == pinned {
+				return &a, nil
+			}
+		}
+
+		return nil, errors.Errorf("account %q not found or disabled for indexer: %v", pinned, i.Identifier)
+	}
+
+	if pinned == IndexerAccountDefault || i.AccountSelection != IndexerAccountSelectionRoundRobin || len(enabled) == 0 {
+		return nil, nil
+	}
+
+	idx := n % uint64(len(enabled)+1)
+	if idx == 0 {
+		return nil, nil
+	}
+
+	return &enabled[idx-1], nil
+}
+
+// AccountSettings indexer settings with the account settings on top
+func (i IndexerDefinition) AccountSettings(account *IndexerAccount) map[string]string {
+	settings := make(map[string]string, len(i.SettingsMap))
+	for k, v := range i.SettingsMap {
+		settings[k] = v
+	}
+
+	if account == nil {
+		return settings
+	}
+
+	for k, v := range account.Settings {
+		if v != "" {
+			settings[k] = v
+		}
+	}
+
+	return settings
+}
+
+// AccountURL swap the indexer credentials in a ready made download url, like the ones in feeds, for the account credentials
+func (i IndexerDefinition) AccountURL(downloadURL string, account *IndexerAccount) string {
+	if account == nil {
+		return downloadURL
+	}
+
+	for k, v := range account.Settings {
+		current := i.SettingsMap[k]
+		if v == "" || current == "" || current == v {
+			continue
+		}
+
+		downloadURL = strings.ReplaceAll(downloadURL, current, v)
+		if escaped := url.QueryEscape(current); escaped != current {
+			downloadURL = strings.ReplaceAll(downloadURL, escaped, url.QueryEscape(v))
+		}
+	}
+
+	return downloadURL
+}
+
+type IndexerAPI struct {
+	URL      string           `json:"url"`
+	Type     string           `json:"type"`
+	Limits   IndexerAPILimits `json:"limits"`
+	Enrich   bool             `json:"enrich"`
+	Settings []IndexerSetting `json:"settings"`
+}
+
+type IndexerAPILimits struct {
+	Max int    `json:"max"`
+	Per string `json:"per"`
+}
+
+// Period the window of the limit, per is a unit with an optional count like "10 seconds" or "minute"
+func (l IndexerAPILimits) Period() (time.Duration, error) {
+	fields := strings.Fields(l.Per)
+
+	count := 1
+	switch len(fields) {
+	case 1:
+	case 2:
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n < 1 {
+			return 0, errors.Errorf("invalid api limit period %q", l.Per)
+		}
+		count = n
+		fields = fields[1:]
+	default:
+		return 0, errors.Errorf("invalid api limit period %q", l.Per)
+	}
+
+	var unit time.Duration
+	switch strings.TrimSuffix(strings.ToLower(fields[0]), "s") {
+	case "second":
+		unit = time.Second
+	case "minute":
+		unit = time.Minute
+	case "hour":
+		unit = time.Hour
+	default:
+		return 0, errors.Errorf("invalid api limit period %q", l.Per)
+	}
+
+	return time.Duration(count) * unit, nil
+}
+
+type Torznab struct {
+	MinInterval int              `json:"minInterval"`
+	Settings    []IndexerSetting `json:"settings"`
+}
+
+type FeedSettings struct {
+	MinInterval int              `json:"minInterval"`
+	Settings    []IndexerSetting `json:"settings"`
+}
+
 type IndexerSetting struct {
 	Name        string `json:"name"`
 	Required    bool   `json:"required,omitempty"`
@@ -104,8 +392,10 @@ type IndexerParseExtract struct {
 }
 
 type IndexerParseMatch struct {
-	TorrentURL string   `json:"torrenturl"`
-	Encode     []string `json:"encode"`
+	TorrentURL string `json:"torrenturl"`
+	// TokenURL torrent url that spends a freeleech token, empty if the indexer has no tokens
+	TokenURL string   `json:"tokenurl,omitempty"`
+	Encode   []string `json:"encode"`
 }
 
 type TorrentBasic struct {
@@ -115,6 +405,21 @@ type TorrentBasic struct {
 	Size      string `json:"Size"`
 }
 
+// TorrentMetadata release details fetched from an indexer api used to fill in what the announce lacks
+type TorrentMetadata struct {
+	Size             uint64
+	Format           string
+	Quality          string
+	Media            string
+	LogScore         int
+	HasLog           bool
+	HasCue           bool
+	IsScene          bool
+	Freeleech        bool
+	FreeleechPercent int
+	Uploader         string
+}
+
 func (t TorrentBasic) ReleaseSizeBytes() uint64 {
 	if t.Size == "" {
 		return 0
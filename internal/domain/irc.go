@@ -5,6 +5,26 @@ import (
 	"time"
 )
 
+// AnnounceSettings how announces from irc are processed
+type AnnounceSettings struct {
+	Concurrency    int           // announces checked against the filters at the same time, over all indexers
+	CoalesceWindow time.Duration // drop repeats of an announce within this window, 0 keeps them
+}
+
+// AnnounceQueueItem the lines of an announce waiting for the filter checks, stored so it survives a restart
+type AnnounceQueueItem struct {
+	ID        int64     `json:"id"`
+	Indexer   string    `json:"indexer"`
+	Lines     []string  `json:"lines"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AnnounceQueueRepo interface {
+	Store(ctx context.Context, item *AnnounceQueueItem) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]AnnounceQueueItem, error)
+}
+
 type IrcChannel struct {
 	ID         int64  `json:"id"`
 	Enabled    bool   `json:"enabled"`
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/autobrr/autobrr/pkg/wildcard"
+)
+
+// KeywordScore points a keyword in the release name adds, negative points subtract
+type KeywordScore struct {
+	Keyword string
+	Score   int
+}
+
+// ParseKeywordScores comma separated "keyword:points" entries like "soundboard:10, fm:-5, *remaster*:3"
+func ParseKeywordScores(list string) ([]KeywordScore, error) {
+	var scores []KeywordScore
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%q has no points, use keyword:points", entry)
+		}
+
+		keyword := strings.ToLower(strings.TrimSpace(entry[:i]))
+		if keyword == "" {
+			return nil, fmt.Errorf("%q has no keyword", entry)
+		}
+
+		score, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%q has invalid points: %w", entry, err)
+		}
+
+		scores = append(scores, KeywordScore{Keyword: keyword, Score: score})
+	}
+
+	return scores, nil
+}
+
+// KeywordScore sum of the points of the keywords in the release name.
+// Keywords match whole words, or the full name when they have wildcards.
+func (r *Release) KeywordScore(scores []KeywordScore) int {
+	name := strings.ToLower(r.TorrentName)
+	words := keywordWords(name)
+
+	total := 0
+	for _, s := range scores {
+		if strings.ContainsAny(s.Keyword, "?*") {
+			if wildcard.Match(s.Keyword, name) {
+				total += s.Score
+			}
+			continue
+		}
+
+		if containsWords(words, keywordWords(s.Keyword)) {
+			total += s.Score
+		}
+	}
+
+	return total
+}
+
+func keywordWords(s string) []string {
+	return SplitAny(s, " ._-[]()")
+}
+
+// containsWords the words appear next to each other and in order
+func containsWords(words []string, sequence []string) bool {
+	if len(sequence) == 0 {
+		return false
+	}
+
+	for i := 0; i+len(sequence) <= len(words); i++ {
+		match := true
+		for j, word := range sequence {
+			if words[i+j] != word {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkKeywordScore used by CheckFilter, the release has to reach the minimum score
+func (r *Release) checkKeywordScore(filter Filter) bool {
+	scores, err := ParseKeywordScores(filter.KeywordScores)
+	if err != nil {
+		r.addRejection(fmt.Sprintf("invalid keyword scores: %v", err))
+		return false
+	}
+
+	if score := r.KeywordScore(scores); score < filter.MinKeywordScore {
+		r.addRejection(fmt.Sprintf("keyword score %d below minimum %d", score, filter.MinKeywordScore))
+		return false
+	}
+
+	return true
+}
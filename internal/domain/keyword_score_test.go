@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeywordScores(t *testing.T) {
+	scores, err := ParseKeywordScores("Soundboard:10, fm : -5,,*remaster*:3")
+	assert.NoError(t, err)
+	assert.Equal(t, []KeywordScore{{Keyword: "soundboard", Score: 10}, {Keyword: "fm", Score: -5}, {Keyword: "*remaster*", Score: 3}}, scores)
+
+	for _, list := range []string{"soundboard", ":5", "fm:lots"} {
+		_, err := ParseKeywordScores(list)
+		assert.Error(t, err, list)
+	}
+}
+
+func TestRelease_CheckKeywordScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		torrentName string
+		filter      Filter
+		want        bool
+	}{
+		{name: "above_threshold", torrentName: "Artist - Live at the Venue 2022 Soundboard FLAC", filter: Filter{KeywordScores: "soundboard:10,live at:5,fm:-10", MinKeywordScore: 15}, want: true},
+		{name: "penalty", torrentName: "Artist - Live at the Venue 2022 FM Broadcast", filter: Filter{KeywordScores: "soundboard:10,live at:5,fm:-10", MinKeywordScore: 1}, want: false},
+		{name: "whole_words", torrentName: "Artist - Firmware Live 2022", filter: Filter{KeywordScores: "fm:-10,live:1", MinKeywordScore: 1}, want: true},
+		{name: "wildcard", torrentName: "Some.Match.2022.Remastered.1080p.WEB-DL", filter: Filter{KeywordScores: "*remaster*:3, web-dl:2", MinKeywordScore: 5}, want: true},
+		{name: "default_threshold", torrentName: "Some.Match.2022.720p.HDTV", filter: Filter{KeywordScores: "hdtv:-1"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Release{TorrentName: tt.torrentName}
+			assert.Equal(t, tt.want, r.checkKeywordScore(tt.filter))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SubtitleType how the subtitles of a release are shipped
+type SubtitleType string
+
+const (
+	SubtitleTypeHardcoded SubtitleType = "HARDCODED" // burned into the video
+	SubtitleTypeSoftcoded SubtitleType = "SOFTCODED" // separate tracks that can be turned off
+)
+
+type languageTag struct {
+	name string
+	rxp  *regexp.Regexp
+}
+
+// languageTagRegexp match a whole word of the release name
+func languageTagRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:` + pattern + `)(?:[^a-z0-9]|$)`)
+}
+
+// subtitleLanguages checked before the audio languages, NLSUBBED is dutch subtitles and not dutch audio
+var subtitleLanguages = []languageTag{
+	{name: "Multi", rxp: languageTagRegexp(`multi-?subs?|multisubbed`)},
+	{name: "Nordic", rxp: languageTagRegexp(`nordic-?subs?|nordicsubbed`)},
+	{name: "French", rxp: languageTagRegexp(`vostfr|subfrench|stfr|frsubs?`)},
+	{name: "Dutch", rxp: languageTagRegexp(`nlsubs?|nlsubbed`)},
+	{name: "Danish", rxp: languageTagRegexp(`dksubs?|danishsubs?`)},
+	{name: "Swedish", rxp: languageTagRegexp(`swesubs?|swedishsubs?`)},
+	{name: "Norwegian", rxp: languageTagRegexp(`norsubs?|nosubs?`)},
+	{name: "Finnish", rxp: languageTagRegexp(`finsubs?|fisubs?`)},
+	{name: "Polish", rxp: languageTagRegexp(`plsubs?|napisy`)},
+	{name: "Hebrew", rxp: languageTagRegexp(`hebsubs?`)},
+	{name: "Korean", rxp: languageTagRegexp(`korsubs?`)},
+	{name: "English", rxp: languageTagRegexp(`engsubs?|engsubbed`)},
+}
+
+// audioLanguages two letter tags like NO or HE are left out, they are common words in titles
+var audioLanguages = []languageTag{
+	{name: "Multi", rxp: languageTagRegexp(`multi`)},
+	{name: "Dual", rxp: languageTagRegexp(`dual(?:[. -]?audio)?`)},
+	{name: "Nordic", rxp: languageTagRegexp(`nordic`)},
+	{name: "French", rxp: languageTagRegexp(`(?:true)?french|vff|vfq|vf2|vfi`)},
+	{name: "German", rxp: languageTagRegexp(`german`)},
+	{name: "Dutch", rxp: languageTagRegexp(`dutch|flemish|nl`)},
+	{name: "Danish", rxp: languageTagRegexp(`danish|dk`)},
+	{name: "Swedish", rxp: languageTagRegexp(`swedish`)},
+	{name: "Norwegian", rxp: languageTagRegexp(`norwegian`)},
+	{name: "Finnish", rxp: languageTagRegexp(`finnish`)},
+	{name: "Icelandic", rxp: languageTagRegexp(`icelandic`)},
+	{name: "Polish", rxp: languageTagRegexp(`polish|pl|pldub`)},
+	{name: "Spanish", rxp: languageTagRegexp(`spanish|castellano|latino`)},
+	{name: "Italian", rxp: languageTagRegexp(`italian|ita`)},
+	{name: "Portuguese", rxp: languageTagRegexp(`portuguese`)},
+	{name: "Romanian", rxp: languageTagRegexp(`romanian`)},
+	{name: "Russian", rxp: languageTagRegexp(`russian|rus`)},
+	{name: "Hebrew", rxp: languageTagRegexp(`hebrew`)},
+	{name: "Hindi", rxp: languageTagRegexp(`hindi`)},
+	{name: "Korean", rxp: languageTagRegexp(`korean|kor`)},
+	{name: "Japanese", rxp: languageTagRegexp(`japanese|jpn`)},
+	{name: "English", rxp: languageTagRegexp(`english|eng`)},
+}
+
+var (
+	hardcodedSubsRegexp = languageTagRegexp(`hc|hardsubs?|hardsubbed|hardcoded|korsubs?`)
+	softcodedSubsRegexp = languageTagRegexp(`softsubs?|softsubbed|softcoded`)
+)
+
+// extractLanguages normalized audio and subtitle languages, like Multi, French, Nordic
+func (r *Release) extractLanguages() error {
+	name := r.TorrentName
+
+	r.Subtitles, name = findLanguageTags(name, subtitleLanguages)
+	r.Languages, _ = findLanguageTags(name, audioLanguages)
+
+	switch {
+	case hardcodedSubsRegexp.MatchString(r.TorrentName):
+		r.SubtitleType = SubtitleTypeHardcoded
+	case softcodedSubsRegexp.MatchString(r.TorrentName):
+		r.SubtitleType = SubtitleTypeSoftcoded
+	}
+
+	return nil
+}
+
+// findLanguageTags the languages found and the name without their tags
+func findLanguageTags(name string, tags []languageTag) ([]string, string) {
+	var languages []string
+
+	for _, tag := range tags {
+		if !tag.rxp.MatchString(name) {
+			continue
+		}
+
+		languages = append(languages, tag.name)
+		name = tag.rxp.ReplaceAllString(name, " ")
+	}
+
+	return languages, name
+}
+
+func validateSubtitleType(value SubtitleType) error {
+	switch value {
+	case "", SubtitleTypeHardcoded, SubtitleTypeSoftcoded:
+		return nil
+	}
+
+	return fmt.Errorf("unknown value %q", value)
+}
+
+// checkFilterLanguages used by CheckFilter. Releases without an audio language tag are english.
+func (r *Release) checkFilterLanguages(filter Filter) bool {
+	languages := r.Languages
+	if len(languages) == 0 {
+		languages = []string{"English"}
+	}
+
+	if len(filter.MatchLanguages) > 0 && !checkFilterAudio(languages, filter.MatchLanguages) {
+		r.addRejection("language not matching")
+		return false
+	}
+
+	if len(filter.ExceptLanguages) > 0 && checkFilterAudio(languages, filter.ExceptLanguages) {
+		r.addRejection("unwanted language")
+		return false
+	}
+
+	if len(filter.MatchSubtitles) > 0 && !checkFilterAudio(r.Subtitles, filter.MatchSubtitles) {
+		r.addRejection("subtitles not matching")
+		return false
+	}
+
+	if len(filter.ExceptSubtitles) > 0 && checkFilterAudio(r.Subtitles, filter.ExceptSubtitles) {
+		r.addRejection("unwanted subtitles")
+		return false
+	}
+
+	switch filter.SubtitleType {
+	case SubtitleTypeHardcoded:
+		if r.SubtitleType != SubtitleTypeHardcoded {
+			r.addRejection("subtitles not hardcoded")
+			return false
+		}
+	case SubtitleTypeSoftcoded:
+		if r.SubtitleType == SubtitleTypeHardcoded {
+			r.addRejection("hardcoded subtitles")
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasLanguageFilter the filter uses any of the language or subtitle fields
+func (f Filter) hasLanguageFilter() bool {
+	return len(f.MatchLanguages) > 0 || len(f.ExceptLanguages) > 0 || len(f.MatchSubtitles) > 0 ||
+		len(f.ExceptSubtitles) > 0 || f.SubtitleType != ""
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelease_extractLanguages(t *testing.T) {
+	tests := []struct {
+		name         string
+		want         []string
+		wantSubs     []string
+		wantSubsType SubtitleType
+	}{
+		{name: "That.Movie.2022.MULTi.1080p.BluRay.x264-GROUP", want: []string{"Multi"}},
+		{name: "That.Movie.2022.VOSTFR.1080p.WEB.H264-GROUP", wantSubs: []string{"French"}},
+		{name: "That.Movie.2022.TRUEFRENCH.1080p.WEB.H264-GROUP", want: []string{"French"}},
+		{name: "That.Show.S01E01.NORDiC.1080p.WEB-DL.H.264-GROUP", want: []string{"Nordic"}},
+		{name: "That.Show.S01E01.NLSUBBED.1080p.WEB.H264-GROUP", wantSubs: []string{"Dutch"}},
+		{name: "That.Movie.2022.GERMAN.DL.1080p.BluRay.x264-GROUP", want: []string{"German"}},
+		{name: "That.Movie.2022.HC.HDRip.x264-GROUP", wantSubsType: SubtitleTypeHardcoded},
+		{name: "That.Movie.2022.KORSUB.HDRip.x264-GROUP", wantSubs: []string{"Korean"}, wantSubsType: SubtitleTypeHardcoded},
+		{name: "[Group] That Anime - 01 (1080p) [Softsubs] [Multi-Subs]", wantSubs: []string{"Multi"}, wantSubsType: SubtitleTypeSoftcoded},
+		{name: "No.Time.To.Die.2021.1080p.BluRay.x264-GROUP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Release{TorrentName: tt.name}
+			_ = r.extractLanguages()
+
+			assert.Equal(t, tt.want, r.Languages)
+			assert.Equal(t, tt.wantSubs, r.Subtitles)
+			assert.Equal(t, tt.wantSubsType, r.SubtitleType)
+		})
+	}
+}
+
+func TestRelease_checkFilterLanguages(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "That.Movie.2022.MULTi.1080p.BluRay.x264-GROUP", filter: Filter{MatchLanguages: []string{"multi", "French"}}, want: true},
+		{name: "That.Movie.2022.1080p.BluRay.x264-GROUP", filter: Filter{MatchLanguages: []string{"French"}}, want: false},
+		{name: "That.Movie.2022.1080p.BluRay.x264-GROUP", filter: Filter{MatchLanguages: []string{"English"}}, want: true},
+		{name: "That.Movie.2022.GERMAN.1080p.BluRay.x264-GROUP", filter: Filter{ExceptLanguages: []string{"German"}}, want: false},
+		{name: "That.Movie.2022.VOSTFR.1080p.WEB.H264-GROUP", filter: Filter{MatchSubtitles: []string{"French"}}, want: true},
+		{name: "That.Movie.2022.VOSTFR.1080p.WEB.H264-GROUP", filter: Filter{ExceptSubtitles: []string{"French"}}, want: false},
+		{name: "That.Movie.2022.HC.HDRip.x264-GROUP", filter: Filter{SubtitleType: SubtitleTypeSoftcoded}, want: false},
+		{name: "That.Movie.2022.HC.HDRip.x264-GROUP", filter: Filter{SubtitleType: SubtitleTypeHardcoded}, want: true},
+		{name: "That.Movie.2022.1080p.WEB.H264-GROUP", filter: Filter{SubtitleType: SubtitleTypeHardcoded}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Release{TorrentName: tt.name}
+			_ = r.extractLanguages()
+
+			assert.Equal(t, tt.want, r.checkFilterLanguages(tt.filter))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// Topics on the internal event bus that are pushed to the web ui as live events
+const (
+	EventReleaseMatched = "release:matched"  // *Release
+	EventIrcConnection  = "irc:connection"   // *IrcConnectionEvent
+	EventNotification   = "notification"     // *NotificationEvent
+	EventUpdate         = "update:available" // *UpdateStatus
+)
+
+// EventIndexerCredentials topic for an *IndexerCredentialsEvent, handled on the server only
+const EventIndexerCredentials = "indexer:credentials"
+
+// EventIndexerCredentialsValid topic for an *IndexerCredentialsEvent when the flag is cleared, handled on the server only
+const EventIndexerCredentialsValid = "indexer:credentials-valid"
+
+type LiveEventType string
+
+const (
+	LiveEventReleaseMatched LiveEventType = "release:matched"
+	LiveEventActionResult   LiveEventType = "action:result"
+	LiveEventIrcConnection  LiveEventType = "irc:connection"
+	LiveEventNotification   LiveEventType = "notification"
+	LiveEventUpdate         LiveEventType = "update:available"
+)
+
+// LiveEvent message on the events stream of /api/events
+type LiveEvent struct {
+	Type      LiveEventType `json:"type"`
+	Timestamp time.Time     `json:"timestamp"`
+	Data      interface{}   `json:"data"`
+}
+
+// LiveRelease a matched release without urls, those hold passkeys
+type LiveRelease struct {
+	ID          int64  `json:"id"`
+	TorrentName string `json:"torrent_name"`
+	Indexer     string `json:"indexer"`
+	Filter      string `json:"filter"`
+	FilterID    int    `json:"filter_id"`
+	Size        uint64 `json:"size"`
+}
+
+func NewLiveRelease(r *Release) LiveRelease {
+	return LiveRelease{
+		ID:          r.ID,
+		TorrentName: r.TorrentName,
+		Indexer:     r.Indexer,
+		Filter:      r.FilterName,
+		FilterID:    r.FilterID,
+		Size:        r.Size,
+	}
+}
+
+// LiveActionResult outcome of an action for a release
+type LiveActionResult struct {
+	ReleaseID  int64             `json:"release_id"`
+	FilterID   int               `json:"filter_id"`
+	Action     string            `json:"action"`
+	Type       ActionType        `json:"type"`
+	Status     ReleasePushStatus `json:"status"`
+	Rejections []string          `json:"rejections"`
+}
+
+// IrcConnectionEvent a network connected or lost its connection
+type IrcConnectionEvent struct {
+	NetworkID int64  `json:"network_id"`
+	Network   string `json:"network"`
+	Server    string `json:"server"`
+	Connected bool   `json:"connected"`
+}
+
+// NotificationEvent message for the user, shown in the ui and sent by the notification agents
+type NotificationEvent struct {
+	Level   string `json:"level"` // info, warning or error
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
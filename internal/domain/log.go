@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LogEntry application log line kept in memory for the logs api
+type LogEntry struct {
+	ID      int64                  `json:"id"`
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Module  string                 `json:"module,omitempty"`
+	Message string                 `json:"message"`
+	Error   string                 `json:"error,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogQueryParams filters for recent log entries, every field is optional
+type LogQueryParams struct {
+	Level  string // minimum level like "warn"
+	Module string // module and its submodules, "filter" matches "filter.import"
+	Search string // case insensitive text in the message or error
+	After  int64  // only entries with a higher id, for polling
+	Limit  int    // newest entries up to the limit
+}
+
+// LogLevels levels accepted in the config and the log settings api
+var LogLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR"}
+
+// LogSettings log level and the rotating log file, changed at runtime through the api
+type LogSettings struct {
+	Level      string `json:"level"`
+	Path       string `json:"path"`        // no log file when empty, logs always go to stderr
+	MaxSize    int    `json:"max_size"`    // megabytes before the file is rotated
+	MaxBackups int    `json:"max_backups"` // rotated files to keep
+	MaxAge     int    `json:"max_age"`     // days to keep rotated files, 0 keeps them
+	Compress   bool   `json:"compress"`    // gzip rotated files
+}
+
+func (s LogSettings) Validate() error {
+	level := strings.ToUpper(s.Level)
+
+	valid := false
+	for _, l := range LogLevels {
+		if l == level {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid log level %q, expected one of %v", s.Level, strings.Join(LogLevels, ", "))
+	}
+
+	if s.MaxSize <= 0 {
+		return fmt.Errorf("max size has to be at least 1 MB")
+	}
+
+	if s.MaxBackups < 0 || s.MaxAge < 0 {
+		return fmt.Errorf("max backups and max age can't be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceSettings daily database maintenance, off when there are no times
+type MaintenanceSettings struct {
+	// Times minutes after midnight in local time the maintenance runs
+	Times []int
+	// Backup write a backup to the backups folder before vacuuming
+	Backup bool
+}
+
+func (s MaintenanceSettings) Enabled() bool {
+	return len(s.Times) > 0
+}
+
+// Due a maintenance time passed after last and before or at now
+func (s MaintenanceSettings) Due(last time.Time, now time.Time) bool {
+	for _, minutes := range s.Times {
+		// yesterday as well, last can be from before midnight
+		for days := 0; days >= -1; days-- {
+			at := time.Date(now.Year(), now.Month(), now.Day(), 0, minutes, 0, 0, now.Location()).AddDate(0, 0, days)
+			if at.After(last) && !at.After(now) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MaintenanceSettings maintenance times like "04:00, 16:30" from the config
+func (c Config) MaintenanceSettings() (MaintenanceSettings, error) {
+	settings := MaintenanceSettings{Backup: c.MaintenanceBackup}
+
+	for _, value := range strings.Split(c.MaintenanceTimes, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			return settings, fmt.Errorf("invalid maintenance time %q, expected HH:MM", value)
+		}
+
+		settings.Times = append(settings.Times, t.Hour()*60+t.Minute())
+	}
+
+	return settings, nil
+}
+
+// MaintenanceResult what a maintenance run did, sizes are in bytes
+type MaintenanceResult struct {
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Backup     string        `json:"backup,omitempty"`
+	SizeBefore int64         `json:"size_before"`
+	SizeAfter  int64         `json:"size_after"`
+}
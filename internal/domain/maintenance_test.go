@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_MaintenanceSettings(t *testing.T) {
+	settings, err := Config{MaintenanceTimes: "04:00, 16:30", MaintenanceBackup: true}.MaintenanceSettings()
+	require.NoError(t, err)
+	assert.Equal(t, MaintenanceSettings{Times: []int{240, 990}, Backup: true}, settings)
+
+	settings, err = Config{}.MaintenanceSettings()
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled())
+
+	_, err = Config{MaintenanceTimes: "4am"}.MaintenanceSettings()
+	assert.Error(t, err)
+}
+
+func TestMaintenanceSettings_Due(t *testing.T) {
+	settings := MaintenanceSettings{Times: []int{4 * 60}}
+
+	tests := []struct {
+		name string
+		last time.Time
+		now  time.Time
+		want bool
+	}{
+		{name: "before", last: time.Date(2022, 3, 10, 3, 58, 0, 0, time.UTC), now: time.Date(2022, 3, 10, 3, 59, 0, 0, time.UTC), want: false},
+		{name: "at", last: time.Date(2022, 3, 10, 3, 59, 0, 0, time.UTC), now: time.Date(2022, 3, 10, 4, 0, 0, 0, time.UTC), want: true},
+		{name: "already_ran", last: time.Date(2022, 3, 10, 4, 0, 0, 0, time.UTC), now: time.Date(2022, 3, 10, 4, 1, 0, 0, time.UTC), want: false},
+		{name: "missed_while_busy", last: time.Date(2022, 3, 10, 3, 50, 0, 0, time.UTC), now: time.Date(2022, 3, 10, 4, 10, 0, 0, time.UTC), want: true},
+		{name: "over_midnight", last: time.Date(2022, 3, 9, 23, 59, 0, 0, time.UTC), now: time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, settings.Due(tt.last, tt.now))
+		})
+	}
+
+	midnight := MaintenanceSettings{Times: []int{0}}
+	assert.True(t, midnight.Due(time.Date(2022, 3, 9, 23, 59, 0, 0, time.UTC), time.Date(2022, 3, 10, 0, 0, 30, 0, time.UTC)))
+}
@@ -0,0 +1,510 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type NotificationRepo interface {
+	List(ctx context.Context) ([]Notification, error)
+	FindByID(ctx context.Context, id int) (*Notification, error)
+	Store(ctx context.Context, notification *Notification) error
+	Update(ctx context.Context, notification *Notification) error
+	Delete(ctx context.Context, id int) error
+}
+
+// Notification an agent that sends events to a push service or chat
+type Notification struct {
+	ID        int                     `json:"id"`
+	Name      string                  `json:"name"`
+	Type      NotificationType        `json:"type"`
+	Enabled   bool                    `json:"enabled"`
+	Events    []NotificationEventType `json:"events"`
+	Filters   []int                   `json:"filters"`  // release events only from these filters, all when empty
+	Indexers  []string                `json:"indexers"` // release events only from these indexer identifiers, all when empty
+	Settings  NotificationSettings    `json:"settings"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
+
+// NotificationSettings what the agent needs to send, which fields are used depends on the type
+type NotificationSettings struct {
+	Host    string `json:"host,omitempty"`     // gotify or ntfy server url, matrix homeserver
+	Token   string `json:"token,omitempty"`    // pushover api token, gotify app token, ntfy or matrix access token
+	UserKey string `json:"user_key,omitempty"` // pushover user or group key
+	Device  string `json:"device,omitempty"`   // pushover devices, all devices when empty
+	Topic   string `json:"topic,omitempty"`    // ntfy
+	Webhook string `json:"webhook,omitempty"`  // slack incoming webhook url or the url the webhook agent posts to
+	Room    string `json:"room,omitempty"`     // matrix room id, the account has to be joined already
+
+	// smtp, the server is in host without scheme and port
+	Port       int            `json:"port,omitempty"`
+	Username   string         `json:"username,omitempty"`
+	Password   string         `json:"password,omitempty"`
+	From       string         `json:"from,omitempty"`
+	To         []string       `json:"to,omitempty"`
+	Encryption SmtpEncryption `json:"encryption,omitempty"`
+
+	// webhook, the payload is sent as json when there is no template
+	Template string            `json:"template,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Secret   string            `json:"secret,omitempty"` // signs the body with hmac-sha256
+
+	// DigestInterval minutes to collect events for one summary email, every event is mailed when 0
+	DigestInterval int `json:"digest_interval,omitempty"`
+
+	// Priorities per event, events without one get the default of the agent for their level
+	Priorities map[NotificationEventType]int `json:"priorities,omitempty"`
+	// Tags per event, ntfy shows tags that match an emoji short code as that emoji
+	Tags map[NotificationEventType][]string `json:"tags,omitempty"`
+	// Templates per event to word the title and message, events without one get the built in text
+	Templates map[NotificationEventType]NotificationTemplate `json:"templates,omitempty"`
+}
+
+// NotificationTemplate go templates for the title and message, they run with the NotificationPayload.
+// The built in text is in .Title and .Message, an empty template keeps it.
+type NotificationTemplate struct {
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type NotificationType string
+
+const (
+	NotificationTypePushover NotificationType = "PUSHOVER"
+	NotificationTypeGotify   NotificationType = "GOTIFY"
+	NotificationTypeNtfy     NotificationType = "NTFY"
+	NotificationTypeSlack    NotificationType = "SLACK"
+	NotificationTypeMatrix   NotificationType = "MATRIX"
+	NotificationTypeEmail    NotificationType = "EMAIL"
+	NotificationTypeWebhook  NotificationType = "WEBHOOK"
+)
+
+type SmtpEncryption string
+
+const (
+	SmtpEncryptionNone     SmtpEncryption = "NONE"
+	SmtpEncryptionStartTLS SmtpEncryption = "STARTTLS"
+	SmtpEncryptionTLS      SmtpEncryption = "TLS" // implicit tls, usually on port 465
+)
+
+// NtfyDefaultHost the public ntfy server, used when no server is set
+const NtfyDefaultHost = "https://ntfy.sh"
+
+type NotificationEventType string
+
+const (
+	NotificationEventPushApproved    NotificationEventType = "PUSH_APPROVED"
+	NotificationEventPushRejected    NotificationEventType = "PUSH_REJECTED"
+	NotificationEventPushError       NotificationEventType = "PUSH_ERROR"
+	NotificationEventIrcDisconnected NotificationEventType = "IRC_DISCONNECTED"
+	NotificationEventIrcReconnected  NotificationEventType = "IRC_RECONNECTED"
+	NotificationEventSystem          NotificationEventType = "SYSTEM" // maintenance and other messages from autobrr itself
+	NotificationEventUpdateAvailable NotificationEventType = "UPDATE_AVAILABLE"
+	NotificationEventIndexerAuth     NotificationEventType = "INDEXER_AUTH_FAILED"
+	NotificationEventTest            NotificationEventType = "TEST"
+)
+
+// NotificationEvents the events an agent can subscribe to, a test is always sent
+var NotificationEvents = []NotificationEventType{
+	NotificationEventPushApproved,
+	NotificationEventPushRejected,
+	NotificationEventPushError,
+	NotificationEventIrcDisconnected,
+	NotificationEventIrcReconnected,
+	NotificationEventSystem,
+	NotificationEventUpdateAvailable,
+	NotificationEventIndexerAuth,
+}
+
+// Levels of a notification
+const (
+	NotificationLevelInfo    = "info"
+	NotificationLevelWarning = "warning"
+	NotificationLevelError   = "error"
+)
+
+// NotificationPayload an event to send, the release fields are only set for push events
+type NotificationPayload struct {
+	Event     NotificationEventType `json:"event"`
+	Level     string                `json:"level"`
+	Title     string                `json:"title"`
+	Message   string                `json:"message"`
+	Timestamp time.Time             `json:"timestamp"`
+
+	ReleaseName string            `json:"release_name,omitempty"`
+	Indexer     string            `json:"indexer,omitempty"`
+	Filter      string            `json:"filter,omitempty"`
+	FilterID    int               `json:"filter_id,omitempty"`
+	Action      string            `json:"action,omitempty"`
+	ActionType  ActionType        `json:"action_type,omitempty"`
+	Status      ReleasePushStatus `json:"status,omitempty"`
+	Rejections  []string          `json:"rejections,omitempty"`
+	Network     string            `json:"network,omitempty"`
+}
+
+// NotificationSender sends payloads for one agent
+type NotificationSender interface {
+	Send(ctx context.Context, payload NotificationPayload) error
+}
+
+// Subscribed the agent is enabled and wants the event, tests always go through
+func (n Notification) Subscribed(event NotificationEventType) bool {
+	if event == NotificationEventTest {
+		return true
+	}
+
+	if !n.Enabled {
+		return false
+	}
+
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches the agent is subscribed to the event, and for release events the filter and indexer are in its scope.
+// Indexer events only check the indexer scope. Irc and system events don't come from a filter, only the event decides for them.
+func (n Notification) Matches(payload NotificationPayload) bool {
+	if !n.Subscribed(payload.Event) {
+		return false
+	}
+
+	switch payload.Event {
+	case NotificationEventPushApproved, NotificationEventPushRejected, NotificationEventPushError:
+	case NotificationEventIndexerAuth:
+		return len(n.Indexers) == 0 || containsString(n.Indexers, payload.Indexer)
+	default:
+		return true
+	}
+
+	if len(n.Filters) > 0 && !containsInt(n.Filters, payload.FilterID) {
+		return false
+	}
+
+	if len(n.Indexers) > 0 && !containsString(n.Indexers, payload.Indexer) {
+		return false
+	}
+
+	return true
+}
+
+// Priority for the event, the default when the agent has none set for it
+func (n Notification) Priority(event NotificationEventType, def int) int {
+	if p, ok := n.Settings.Priorities[event]; ok {
+		return p
+	}
+
+	return def
+}
+
+func (n Notification) Validate() error {
+	if n.Name == "" {
+		return errors.New("name is required")
+	}
+
+	for _, event := range n.Events {
+		if !event.Valid() {
+			return fmt.Errorf("unknown event %v", event)
+		}
+	}
+
+	for _, id := range n.Filters {
+		if id <= 0 {
+			return fmt.Errorf("invalid filter id %v", id)
+		}
+	}
+
+	for _, indexer := range n.Indexers {
+		if strings.TrimSpace(indexer) == "" {
+			return errors.New("indexer identifiers can't be empty")
+		}
+	}
+
+	for event := range n.Settings.Priorities {
+		if !event.Valid() {
+			return fmt.Errorf("priority for unknown event %v", event)
+		}
+	}
+
+	for event := range n.Settings.Tags {
+		if !event.Valid() {
+			return fmt.Errorf("tags for unknown event %v", event)
+		}
+	}
+
+	for event, tmpl := range n.Settings.Templates {
+		if !event.Valid() {
+			return fmt.Errorf("template for unknown event %v", event)
+		}
+
+		// an empty payload catches fields that don't exist, parsing alone doesn't
+		if _, err := executeNotificationTemplate("title", tmpl.Title, NotificationPayload{Event: event}); err != nil {
+			return fmt.Errorf("invalid title template for %v: %w", event, err)
+		}
+
+		if _, err := executeNotificationTemplate("message", tmpl.Message, NotificationPayload{Event: event}); err != nil {
+			return fmt.Errorf("invalid message template for %v: %w", event, err)
+		}
+	}
+
+	switch n.Type {
+	case NotificationTypePushover:
+		if n.Settings.Token == "" || n.Settings.UserKey == "" {
+			return errors.New("pushover needs an api token and a user key")
+		}
+
+		// emergency priority needs a retry and expiry, that's not for automatic messages
+		for event, p := range n.Settings.Priorities {
+			if p < -2 || p > 1 {
+				return fmt.Errorf("pushover priority for %v has to be between -2 and 1", event)
+			}
+		}
+
+	case NotificationTypeGotify:
+		if n.Settings.Token == "" {
+			return errors.New("gotify needs an app token")
+		}
+
+		if !validServerURL(n.Settings.Host) {
+			return errors.New("gotify needs the server url, like https://gotify.example.com")
+		}
+
+		for event, p := range n.Settings.Priorities {
+			if p < 0 || p > 10 {
+				return fmt.Errorf("gotify priority for %v has to be between 0 and 10", event)
+			}
+		}
+
+	case NotificationTypeNtfy:
+		if n.Settings.Topic == "" {
+			return errors.New("ntfy needs a topic")
+		}
+
+		if n.Settings.Host != "" && !validServerURL(n.Settings.Host) {
+			return errors.New("ntfy server has to be an url like https://ntfy.example.com, or empty for ntfy.sh")
+		}
+
+		for event, p := range n.Settings.Priorities {
+			if p < 1 || p > 5 {
+				return fmt.Errorf("ntfy priority for %v has to be between 1 and 5", event)
+			}
+		}
+
+	case NotificationTypeSlack:
+		if !validServerURL(n.Settings.Webhook) {
+			return errors.New("slack needs the incoming webhook url, like https://hooks.slack.com/services/...")
+		}
+
+	case NotificationTypeMatrix:
+		if !validServerURL(n.Settings.Host) {
+			return errors.New("matrix needs the homeserver url, like https://matrix.example.com")
+		}
+
+		if n.Settings.Token == "" {
+			return errors.New("matrix needs an access token")
+		}
+
+		// room ids look like !abc:example.com, aliases can't be sent to without resolving them first
+		if !strings.HasPrefix(n.Settings.Room, "!") || !strings.Contains(n.Settings.Room, ":") {
+			return errors.New("matrix needs a room id like !abc:example.com")
+		}
+
+	case NotificationTypeEmail:
+		if err := n.Settings.validateSmtp(); err != nil {
+			return err
+		}
+
+	case NotificationTypeWebhook:
+		if !validServerURL(n.Settings.Webhook) {
+			return errors.New("webhook needs the url to post to")
+		}
+
+		for name := range n.Settings.Headers {
+			if name == "" || strings.ContainsAny(name, ": \t\r\n") {
+				return fmt.Errorf("invalid header name %q", name)
+			}
+		}
+
+		if _, err := n.Settings.WebhookTemplate(); err != nil {
+			return fmt.Errorf("invalid webhook template: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported notification type %q", n.Type)
+	}
+
+	return nil
+}
+
+// Digest the agent collects events into summaries instead of sending them one by one
+func (n Notification) Digest() bool {
+	return n.Type == NotificationTypeEmail && n.Settings.DigestInterval > 0
+}
+
+// SmtpPort the port or the usual one for the encryption
+func (s NotificationSettings) SmtpPort() int {
+	if s.Port != 0 {
+		return s.Port
+	}
+
+	switch s.Encryption {
+	case SmtpEncryptionTLS:
+		return 465
+	case SmtpEncryptionNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+// notificationTemplateFuncs json quotes and escapes values for webhook bodies, join is for the rejections
+var notificationTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func parseNotificationTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(notificationTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Render the payload with the title and message from the templates for its event
+func (n Notification) Render(payload NotificationPayload) (NotificationPayload, error) {
+	tmpl, ok := n.Settings.Templates[payload.Event]
+	if !ok {
+		return payload, nil
+	}
+
+	rendered := payload
+
+	if strings.TrimSpace(tmpl.Title) != "" {
+		title, err := executeNotificationTemplate("title", tmpl.Title, payload)
+		if err != nil {
+			return payload, err
+		}
+
+		rendered.Title = strings.TrimSpace(title)
+	}
+
+	if strings.TrimSpace(tmpl.Message) != "" {
+		message, err := executeNotificationTemplate("message", tmpl.Message, payload)
+		if err != nil {
+			return payload, err
+		}
+
+		rendered.Message = strings.TrimSpace(message)
+	}
+
+	return rendered, nil
+}
+
+func executeNotificationTemplate(name, text string, payload NotificationPayload) (string, error) {
+	t, err := parseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, payload); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// WebhookTemplate the parsed template for the webhook body, nil without one. It runs with the NotificationPayload.
+func (s NotificationSettings) WebhookTemplate() (*template.Template, error) {
+	if strings.TrimSpace(s.Template) == "" {
+		return nil, nil
+	}
+
+	return parseNotificationTemplate("webhook", s.Template)
+}
+
+func (s NotificationSettings) validateSmtp() error {
+	if s.Host == "" || strings.Contains(s.Host, "/") {
+		return errors.New("email needs the smtp server, like smtp.example.com")
+	}
+
+	if s.Port < 0 || s.Port > 65535 {
+		return errors.New("smtp port has to be between 1 and 65535")
+	}
+
+	switch s.Encryption {
+	case "", SmtpEncryptionNone, SmtpEncryptionStartTLS, SmtpEncryptionTLS:
+	default:
+		return fmt.Errorf("unknown smtp encryption %q", s.Encryption)
+	}
+
+	if _, err := mail.ParseAddress(s.From); err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	if len(s.To) == 0 {
+		return errors.New("email needs at least one recipient")
+	}
+
+	for _, to := range s.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+
+	if s.DigestInterval < 0 {
+		return errors.New("digest interval can't be negative")
+	}
+
+	return nil
+}
+
+func validServerURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// Valid one of the events an agent can subscribe to, or a test
+func (e NotificationEventType) Valid() bool {
+	for _, event := range NotificationEvents {
+		if event == e {
+			return true
+		}
+	}
+
+	return e == NotificationEventTest
+}
+
+func containsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       Notification
+		wantErr bool
+	}{
+		{name: "pushover", n: Notification{Name: "phone", Type: NotificationTypePushover, Events: []NotificationEventType{NotificationEventPushError}, Settings: NotificationSettings{Token: "t", UserKey: "u", Priorities: map[NotificationEventType]int{NotificationEventPushError: 1}}}},
+		{name: "pushover_no_user", n: Notification{Name: "phone", Type: NotificationTypePushover, Settings: NotificationSettings{Token: "t"}}, wantErr: true},
+		{name: "pushover_emergency", n: Notification{Name: "phone", Type: NotificationTypePushover, Settings: NotificationSettings{Token: "t", UserKey: "u", Priorities: map[NotificationEventType]int{NotificationEventPushError: 2}}}, wantErr: true},
+		{name: "gotify", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t"}}},
+		{name: "gotify_no_url", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "gotify.example.com", Token: "t"}}, wantErr: true},
+		{name: "gotify_priority", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t", Priorities: map[NotificationEventType]int{NotificationEventPushError: 11}}}, wantErr: true},
+		{name: "ntfy", n: Notification{Name: "ntfy", Type: NotificationTypeNtfy, Settings: NotificationSettings{Topic: "autobrr", Priorities: map[NotificationEventType]int{NotificationEventPushError: 5}, Tags: map[NotificationEventType][]string{NotificationEventPushError: {"warning"}}}}},
+		{name: "ntfy_no_topic", n: Notification{Name: "ntfy", Type: NotificationTypeNtfy, Settings: NotificationSettings{Host: "https://ntfy.example.com"}}, wantErr: true},
+		{name: "ntfy_priority", n: Notification{Name: "ntfy", Type: NotificationTypeNtfy, Settings: NotificationSettings{Topic: "autobrr", Priorities: map[NotificationEventType]int{NotificationEventPushError: 0}}}, wantErr: true},
+		{name: "slack", n: Notification{Name: "slack", Type: NotificationTypeSlack, Settings: NotificationSettings{Webhook: "https://hooks.slack.com/services/T000/B000/XXXX"}}},
+		{name: "slack_no_webhook", n: Notification{Name: "slack", Type: NotificationTypeSlack}, wantErr: true},
+		{name: "matrix", n: Notification{Name: "matrix", Type: NotificationTypeMatrix, Settings: NotificationSettings{Host: "https://matrix.example.com", Token: "syt_token", Room: "!abc:example.com"}}},
+		{name: "matrix_alias", n: Notification{Name: "matrix", Type: NotificationTypeMatrix, Settings: NotificationSettings{Host: "https://matrix.example.com", Token: "syt_token", Room: "#autobrr:example.com"}}, wantErr: true},
+		{name: "email", n: Notification{Name: "mail", Type: NotificationTypeEmail, Settings: NotificationSettings{Host: "smtp.example.com", Encryption: SmtpEncryptionStartTLS, From: "autobrr <autobrr@example.com>", To: []string{"me@example.com"}, DigestInterval: 60}}},
+		{name: "email_no_recipient", n: Notification{Name: "mail", Type: NotificationTypeEmail, Settings: NotificationSettings{Host: "smtp.example.com", From: "autobrr@example.com"}}, wantErr: true},
+		{name: "email_bad_recipient", n: Notification{Name: "mail", Type: NotificationTypeEmail, Settings: NotificationSettings{Host: "smtp.example.com", From: "autobrr@example.com", To: []string{"me"}}}, wantErr: true},
+		{name: "email_encryption", n: Notification{Name: "mail", Type: NotificationTypeEmail, Settings: NotificationSettings{Host: "smtp.example.com", Encryption: "SSL", From: "autobrr@example.com", To: []string{"me@example.com"}}}, wantErr: true},
+		{name: "webhook", n: Notification{Name: "hook", Type: NotificationTypeWebhook, Settings: NotificationSettings{Webhook: "http://homeassistant.local:8123/api/webhook/autobrr", Template: `{"text": {{ json .Title }}}`, Headers: map[string]string{"Authorization": "Bearer t"}}}},
+		{name: "webhook_template", n: Notification{Name: "hook", Type: NotificationTypeWebhook, Settings: NotificationSettings{Webhook: "https://example.com/hook", Template: `{"text": {{ json .Title }`}}, wantErr: true},
+		{name: "webhook_header", n: Notification{Name: "hook", Type: NotificationTypeWebhook, Settings: NotificationSettings{Webhook: "https://example.com/hook", Headers: map[string]string{"X Token": "t"}}}, wantErr: true},
+		{name: "bad_filter", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Filters: []int{0}, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t"}}, wantErr: true},
+		{name: "template", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t", Templates: map[NotificationEventType]NotificationTemplate{NotificationEventPushApproved: {Title: "{{ .Filter }}", Message: "{{ .ReleaseName }} {{ join .Rejections \", \" }}"}}}}},
+		{name: "template_unknown_field", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t", Templates: map[NotificationEventType]NotificationTemplate{NotificationEventPushApproved: {Title: "{{ .Release }}"}}}}, wantErr: true},
+		{name: "template_syntax", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t", Templates: map[NotificationEventType]NotificationTemplate{NotificationEventPushApproved: {Message: "{{ .Filter "}}}}, wantErr: true},
+		{name: "unknown_event", n: Notification{Name: "gotify", Type: NotificationTypeGotify, Events: []NotificationEventType{"RELEASE_SEEN"}, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t"}}, wantErr: true},
+		{name: "unknown_type", n: Notification{Name: "pager", Type: "PAGER"}, wantErr: true},
+		{name: "no_name", n: Notification{Type: NotificationTypeGotify, Settings: NotificationSettings{Host: "https://gotify.example.com", Token: "t"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.n.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNotification_Subscribed(t *testing.T) {
+	n := Notification{Enabled: true, Events: []NotificationEventType{NotificationEventPushError}}
+
+	assert.True(t, n.Subscribed(NotificationEventPushError))
+	assert.False(t, n.Subscribed(NotificationEventPushApproved))
+	assert.True(t, n.Subscribed(NotificationEventTest))
+
+	n.Enabled = false
+	assert.False(t, n.Subscribed(NotificationEventPushError))
+}
+
+func TestNotificationSettings_SmtpPort(t *testing.T) {
+	assert.Equal(t, 587, NotificationSettings{}.SmtpPort())
+	assert.Equal(t, 465, NotificationSettings{Encryption: SmtpEncryptionTLS}.SmtpPort())
+	assert.Equal(t, 25, NotificationSettings{Encryption: SmtpEncryptionNone}.SmtpPort())
+	assert.Equal(t, 2525, NotificationSettings{Encryption: SmtpEncryptionNone, Port: 2525}.SmtpPort())
+}
+
+func TestNotification_Matches(t *testing.T) {
+	n := Notification{
+		Enabled:  true,
+		Events:   []NotificationEventType{NotificationEventPushApproved, NotificationEventIrcDisconnected},
+		Filters:  []int{1, 2},
+		Indexers: []string{"torrentleech"},
+	}
+
+	assert.True(t, n.Matches(NotificationPayload{Event: NotificationEventPushApproved, FilterID: 2, Indexer: "torrentleech"}))
+	assert.False(t, n.Matches(NotificationPayload{Event: NotificationEventPushApproved, FilterID: 3, Indexer: "torrentleech"}), "other filter")
+	assert.False(t, n.Matches(NotificationPayload{Event: NotificationEventPushApproved, FilterID: 1, Indexer: "ptp"}), "other indexer")
+	assert.False(t, n.Matches(NotificationPayload{Event: NotificationEventPushError, FilterID: 1, Indexer: "torrentleech"}), "not subscribed")
+	assert.True(t, n.Matches(NotificationPayload{Event: NotificationEventIrcDisconnected, Network: "IRCHighWay"}), "irc events have no filter")
+
+	n.Filters = nil
+	assert.True(t, n.Matches(NotificationPayload{Event: NotificationEventPushApproved, FilterID: 3, Indexer: "torrentleech"}), "all filters")
+}
+
+func TestNotification_Render(t *testing.T) {
+	n := Notification{Settings: NotificationSettings{Templates: map[NotificationEventType]NotificationTemplate{
+		NotificationEventPushApproved: {Title: "🍿 {{ .Filter | upper }}", Message: "{{ .ReleaseName }}\n{{ .Message }}\n"},
+		NotificationEventPushRejected: {Message: "{{ .ReleaseName }}: {{ join .Rejections \", \" }}"},
+	}}}
+
+	payload := NotificationPayload{Event: NotificationEventPushApproved, Title: "New release!", Message: "Indexer: mock", ReleaseName: "That.Movie.2022", Filter: "movies", Rejections: []string{"a", "b"}}
+
+	got, err := n.Render(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "🍿 MOVIES", got.Title)
+	assert.Equal(t, "That.Movie.2022\nIndexer: mock", got.Message)
+
+	payload.Event = NotificationEventPushRejected
+	got, err = n.Render(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "New release!", got.Title, "no title template keeps the built in one")
+	assert.Equal(t, "That.Movie.2022: a, b", got.Message)
+
+	payload.Event = NotificationEventPushError
+	got, err = n.Render(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, got)
+}
@@ -0,0 +1,158 @@
+package domain
+
+import "strings"
+
+// ProvisionExportVersion format version of exported configuration
+const ProvisionExportVersion = 1
+
+// ProvisionExport indexers, irc networks and filters of an instance, to set up another instance from a file.
+// Secrets are blanked unless they were exported on purpose.
+type ProvisionExport struct {
+	Version  int          `json:"version"`
+	Indexers []Indexer    `json:"indexers"`
+	Networks []IrcNetwork `json:"networks"`
+	Filters  []Filter     `json:"filters"`
+}
+
+// ProvisionImportResult what happened to every imported item
+type ProvisionImportResult struct {
+	Indexers []FilterImportResult `json:"indexers"`
+	Networks []FilterImportResult `json:"networks"`
+	Filters  []FilterImportResult `json:"filters"`
+}
+
+// ProvisionImportUpdated an indexer or network that already existed and was updated
+const ProvisionImportUpdated = "updated"
+
+// Portable the indexer without id, with secrets the settings, proxy and account settings are kept
+func (i Indexer) Portable(secrets bool) Indexer {
+	i.ID = 0
+	i.Type = ""
+
+	if secrets {
+		return i
+	}
+
+	i.Settings = blankValues(i.Settings)
+	i.Proxy = ""
+
+	accounts := make([]IndexerAccount, 0, len(i.Accounts))
+	for _, account := range i.Accounts {
+		account.Settings = blankValues(account.Settings)
+		accounts = append(accounts, account)
+	}
+	i.Accounts = accounts
+
+	return i
+}
+
+// RestoreSecrets fill the blank settings, proxy and account settings from the existing indexer
+func (i Indexer) RestoreSecrets(existing Indexer) Indexer {
+	i.Settings = restoreValues(i.Settings, existing.Settings)
+
+	if i.Proxy == "" {
+		i.Proxy = existing.Proxy
+	}
+
+	accounts := make([]IndexerAccount, 0, len(i.Accounts))
+	for _, account := range i.Accounts {
+		for _, e := range existing.Accounts {
+			if strings.EqualFold(e.Name, account.Name) {
+				account.Settings = restoreValues(account.Settings, e.Settings)
+				break
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	i.Accounts = accounts
+
+	return i
+}
+
+// MissingSecrets any setting of the indexer is blank, it can't be used until they are filled in
+func (i Indexer) MissingSecrets() bool {
+	for _, value := range i.Settings {
+		if value == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Portable the network without ids and connection state, with secrets the passwords and invite command are kept
+func (n IrcNetwork) Portable(secrets bool) IrcNetwork {
+	n.ID = 0
+	n.Connected = false
+	n.ConnectedSince = nil
+
+	if !secrets {
+		n.Pass = ""
+		n.InviteCommand = ""
+		n.NickServ.Password = ""
+	}
+
+	channels := make([]IrcChannel, 0, len(n.Channels))
+	for _, channel := range n.Channels {
+		channel.ID = 0
+		if !secrets {
+			channel.Password = ""
+		}
+		channels = append(channels, channel)
+	}
+	n.Channels = channels
+
+	return n
+}
+
+// RestoreSecrets fill the blank passwords and invite command from the existing network
+func (n IrcNetwork) RestoreSecrets(existing IrcNetwork) IrcNetwork {
+	if n.Pass == "" {
+		n.Pass = existing.Pass
+	}
+	if n.InviteCommand == "" {
+		n.InviteCommand = existing.InviteCommand
+	}
+	if n.NickServ.Password == "" {
+		n.NickServ.Password = existing.NickServ.Password
+	}
+
+	channels := make([]IrcChannel, 0, len(n.Channels))
+	for _, channel := range n.Channels {
+		for _, e := range existing.Channels {
+			if channel.Password == "" && strings.EqualFold(e.Name, channel.Name) {
+				channel.Password = e.Password
+				break
+			}
+		}
+		channels = append(channels, channel)
+	}
+	n.Channels = channels
+
+	return n
+}
+
+func blankValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	blank := make(map[string]string, len(m))
+	for key := range m {
+		blank[key] = ""
+	}
+
+	return blank
+}
+
+func restoreValues(m map[string]string, existing map[string]string) map[string]string {
+	restored := make(map[string]string, len(m))
+	for key, value := range m {
+		if value == "" {
+			value = existing[key]
+		}
+		restored[key] = value
+	}
+
+	return restored
+}
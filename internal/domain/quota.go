@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+type QuotaRepo interface {
+	// Usage grabs and their total size since the given time, for a single filter or all filters when filterID is 0
+	Usage(ctx context.Context, filterID int, since time.Time) (grabs int64, bytes int64, err error)
+}
+
+// Quota download limits, zero or empty means no limit
+type Quota struct {
+	GrabsPerHour  int    `json:"grabs_per_hour"`
+	GrabsPerDay   int    `json:"grabs_per_day"`
+	GrabsPerWeek  int    `json:"grabs_per_week"`
+	GrabsPerMonth int    `json:"grabs_per_month"`
+	BytesPerDay   string `json:"bytes_per_day"` // size like "100 GB"
+}
+
+// Enabled any limit set
+func (q Quota) Enabled() bool {
+	return q.GrabsPerHour > 0 || q.GrabsPerDay > 0 || q.GrabsPerWeek > 0 || q.GrabsPerMonth > 0 || q.BytesPerDay != ""
+}
+
+// QuotaSettings the global quota and when the daily counters reset
+type QuotaSettings struct {
+	Global Quota
+	// ResetHour hour of the day in local time the daily counters reset
+	ResetHour int
+}
+
+// Periods start of the current hourly and daily quota periods.
+// Hours reset on the hour, days at the reset hour.
+func (s QuotaSettings) Periods(now time.Time) (hourStart time.Time, dayStart time.Time) {
+	hourStart = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+
+	dayStart = time.Date(now.Year(), now.Month(), now.Day(), s.ResetHour, 0, 0, 0, now.Location())
+	if dayStart.After(now) {
+		dayStart = dayStart.AddDate(0, 0, -1)
+	}
+
+	return hourStart, dayStart
+}
+
+// CalendarPeriods start of the current weekly and monthly quota periods.
+// Weeks start on monday and months on the first, both at the reset hour.
+func (s QuotaSettings) CalendarPeriods(now time.Time) (weekStart time.Time, monthStart time.Time) {
+	_, dayStart := s.Periods(now)
+
+	daysSinceMonday := (int(dayStart.Weekday()) + 6) % 7
+	weekStart = dayStart.AddDate(0, 0, -daysSinceMonday)
+
+	monthStart = time.Date(dayStart.Year(), dayStart.Month(), 1, s.ResetHour, 0, 0, 0, now.Location())
+
+	return weekStart, monthStart
+}
+
+// QuotaUsage counters for the current quota periods
+type QuotaUsage struct {
+	FilterID   int       `json:"filter_id,omitempty"`
+	FilterName string    `json:"filter_name,omitempty"`
+	Quota      Quota     `json:"quota"`
+	GrabsHour  int64     `json:"grabs_hour"`
+	GrabsDay   int64     `json:"grabs_day"`
+	GrabsWeek  int64     `json:"grabs_week"`
+	GrabsMonth int64     `json:"grabs_month"`
+	BytesDay   int64     `json:"bytes_day"`
+	HourStart  time.Time `json:"hour_start"`
+	DayStart   time.Time `json:"day_start"`
+	WeekStart  time.Time `json:"week_start"`
+	MonthStart time.Time `json:"month_start"`
+}
+
+// Check returns why grabbing a release of this size would go over the quota, or an empty string if it fits
+func (u QuotaUsage) Check(size uint64) (string, error) {
+	if u.Quota.GrabsPerHour > 0 && u.GrabsHour >= int64(u.Quota.GrabsPerHour) {
+		return fmt.Sprintf("quota of %d grabs per hour reached", u.Quota.GrabsPerHour), nil
+	}
+
+	if u.Quota.GrabsPerDay > 0 && u.GrabsDay >= int64(u.Quota.GrabsPerDay) {
+		return fmt.Sprintf("quota of %d grabs per day reached", u.Quota.GrabsPerDay), nil
+	}
+
+	if u.Quota.GrabsPerWeek > 0 && u.GrabsWeek >= int64(u.Quota.GrabsPerWeek) {
+		return fmt.Sprintf("quota of %d grabs per week reached", u.Quota.GrabsPerWeek), nil
+	}
+
+	if u.Quota.GrabsPerMonth > 0 && u.GrabsMonth >= int64(u.Quota.GrabsPerMonth) {
+		return fmt.Sprintf("quota of %d grabs per month reached", u.Quota.GrabsPerMonth), nil
+	}
+
+	if u.Quota.BytesPerDay != "" {
+		maxBytes, err := humanize.ParseBytes(u.Quota.BytesPerDay)
+		if err != nil {
+			return "", fmt.Errorf("could not parse quota size %q: %w", u.Quota.BytesPerDay, err)
+		}
+
+		if uint64(u.BytesDay)+size > maxBytes {
+			return fmt.Sprintf("quota of %v per day reached, %v used", u.Quota.BytesPerDay, humanize.Bytes(uint64(u.BytesDay))), nil
+		}
+	}
+
+	return "", nil
+}
+
+// QuotaStats global usage and usage of filters with a quota
+type QuotaStats struct {
+	Global  QuotaUsage   `json:"global"`
+	Filters []QuotaUsage `json:"filters"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaSettings_Periods(t *testing.T) {
+	now := time.Date(2022, 3, 10, 5, 42, 10, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		resetHour int
+		wantHour  time.Time
+		wantDay   time.Time
+	}{
+		{name: "midnight", resetHour: 0, wantHour: time.Date(2022, 3, 10, 5, 0, 0, 0, time.UTC), wantDay: time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{name: "reset_passed", resetHour: 4, wantHour: time.Date(2022, 3, 10, 5, 0, 0, 0, time.UTC), wantDay: time.Date(2022, 3, 10, 4, 0, 0, 0, time.UTC)},
+		{name: "reset_later_today", resetHour: 8, wantHour: time.Date(2022, 3, 10, 5, 0, 0, 0, time.UTC), wantDay: time.Date(2022, 3, 9, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hourStart, dayStart := QuotaSettings{ResetHour: tt.resetHour}.Periods(now)
+			assert.Equal(t, tt.wantHour, hourStart)
+			assert.Equal(t, tt.wantDay, dayStart)
+		})
+	}
+}
+
+func TestQuotaSettings_CalendarPeriods(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		resetHour int
+		wantWeek  time.Time
+		wantMonth time.Time
+	}{
+		{name: "midweek", now: time.Date(2022, 3, 10, 5, 42, 10, 0, time.UTC), wantWeek: time.Date(2022, 3, 7, 0, 0, 0, 0, time.UTC), wantMonth: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "sunday", now: time.Date(2022, 3, 13, 23, 0, 0, 0, time.UTC), resetHour: 4, wantWeek: time.Date(2022, 3, 7, 4, 0, 0, 0, time.UTC), wantMonth: time.Date(2022, 3, 1, 4, 0, 0, 0, time.UTC)},
+		{name: "before_reset_on_the_first", now: time.Date(2022, 3, 1, 2, 0, 0, 0, time.UTC), resetHour: 4, wantWeek: time.Date(2022, 2, 28, 4, 0, 0, 0, time.UTC), wantMonth: time.Date(2022, 2, 1, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weekStart, monthStart := QuotaSettings{ResetHour: tt.resetHour}.CalendarPeriods(tt.now)
+			assert.Equal(t, tt.wantWeek, weekStart)
+			assert.Equal(t, tt.wantMonth, monthStart)
+		})
+	}
+}
+
+func TestQuotaUsage_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		usage   QuotaUsage
+		size    uint64
+		want    string
+		wantErr bool
+	}{
+		{name: "no_quota", usage: QuotaUsage{GrabsHour: 100, GrabsDay: 1000}, size: 1000},
+		{name: "under_quota", usage: QuotaUsage{Quota: Quota{GrabsPerHour: 5, GrabsPerDay: 20}, GrabsHour: 4, GrabsDay: 19}},
+		{name: "hour_reached", usage: QuotaUsage{Quota: Quota{GrabsPerHour: 5}, GrabsHour: 5}, want: "quota of 5 grabs per hour reached"},
+		{name: "day_reached", usage: QuotaUsage{Quota: Quota{GrabsPerHour: 5, GrabsPerDay: 20}, GrabsHour: 1, GrabsDay: 20}, want: "quota of 20 grabs per day reached"},
+		{name: "week_reached", usage: QuotaUsage{Quota: Quota{GrabsPerDay: 20, GrabsPerWeek: 50}, GrabsDay: 2, GrabsWeek: 50}, want: "quota of 50 grabs per week reached"},
+		{name: "month_reached", usage: QuotaUsage{Quota: Quota{GrabsPerWeek: 50, GrabsPerMonth: 100}, GrabsWeek: 10, GrabsMonth: 100}, want: "quota of 100 grabs per month reached"},
+		{name: "bytes_fit", usage: QuotaUsage{Quota: Quota{BytesPerDay: "10 GB"}, BytesDay: 8_000_000_000}, size: 2_000_000_000},
+		{name: "bytes_over", usage: QuotaUsage{Quota: Quota{BytesPerDay: "10 GB"}, BytesDay: 8_000_000_000}, size: 3_000_000_000, want: "quota of 10 GB per day reached, 8.0 GB used"},
+		{name: "bytes_invalid", usage: QuotaUsage{Quota: Quota{BytesPerDay: "lots"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.usage.Check(tt.size)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
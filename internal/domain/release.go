@@ -4,17 +4,24 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base32"
+	"encoding/hex"
 	"fmt"
 	"html"
 	"io"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/autobrr/autobrr/pkg/wildcard"
 
@@ -27,18 +34,35 @@ import (
 type ReleaseRepo interface {
 	Store(ctx context.Context, release *Release) (*Release, error)
 	Find(ctx context.Context, params ReleaseQueryParams) (res []Release, nextCursor int64, count int64, err error)
+	FindByID(ctx context.Context, id int64) (*Release, error)
+	FindDuplicate(ctx context.Context, params DuplicateQuery) (*Release, error)
+	FindGrabbedEpisodes(ctx context.Context, params EpisodeQuery) ([]Release, error)
+	FindRecent(ctx context.Context, limit uint64) ([]Release, error)
+	UpdateFilter(ctx context.Context, release *Release) error
 	GetIndexerOptions(ctx context.Context) ([]string, error)
 	GetActionStatusByReleaseID(ctx context.Context, releaseID int64) ([]ReleaseActionStatus, error)
-	Stats(ctx context.Context) (*ReleaseStats, error)
+	Stats(ctx context.Context, params ReleaseStatsParams) (*ReleaseStats, error)
 	StoreReleaseActionStatus(ctx context.Context, actionStatus *ReleaseActionStatus) error
+	StorePushTimings(ctx context.Context, releaseID int64, timings ReleaseTimings) error
 	Delete(ctx context.Context) error
+	Prune(ctx context.Context, olderThan time.Time, keep int) (*ReleasePruneResult, error)
 }
 
+var (
+	// ErrReleaseNoFilter the release was not approved by a filter and none was picked to retry it with
+	ErrReleaseNoFilter = errors.New("release has no filter, pick one to retry with")
+	// ErrReleaseNoDownloadURL the release has no torrent url or magnet link stored to retry it with
+	ErrReleaseNoDownloadURL = errors.New("release has no download url stored")
+	// ErrFilterNoActions the filter picked to retry a release with has no actions
+	ErrFilterNoActions = errors.New("filter has no actions")
+)
+
 type Release struct {
 	ID                          int64                 `json:"id"`
 	FilterStatus                ReleaseFilterStatus   `json:"filter_status"`
 	Rejections                  []string              `json:"rejections"`
 	Indexer                     string                `json:"indexer"`
+	IndexerAccount              string                `json:"indexer_account,omitempty"`
 	FilterName                  string                `json:"filter"`
 	Protocol                    ReleaseProtocol       `json:"protocol"`
 	Implementation              ReleaseImplementation `json:"implementation"` // irc, rss, api
@@ -46,8 +70,11 @@ type Release struct {
 	GroupID                     string                `json:"group_id"`
 	TorrentID                   string                `json:"torrent_id"`
 	TorrentURL                  string                `json:"-"`
+	MagnetURI                   string                `json:"-"`
+	Magnet                      bool                  `json:"has_magnet"` // set with the download url, MagnetURI itself is never sent
 	TorrentTmpFile              string                `json:"-"`
 	TorrentHash                 string                `json:"-"`
+	DownloadLog                 string                `json:"-"`            // retries of the torrent file download, kept in the action log
 	TorrentName                 string                `json:"torrent_name"` // full release name
 	Size                        uint64                `json:"size"`
 	Raw                         string                `json:"raw"`   // Raw release
@@ -56,6 +83,7 @@ type Release struct {
 	Category                    string                `json:"category"`
 	Season                      int                   `json:"season"`
 	Episode                     int                   `json:"episode"`
+	AirDate                     string                `json:"air_date,omitempty"` // daily shows, 2006-01-02
 	Year                        int                   `json:"year"`
 	Resolution                  string                `json:"resolution"`
 	Source                      string                `json:"source"` // CD, DVD, Vinyl, DAT, Cassette, WEB, Other
@@ -63,9 +91,14 @@ type Release struct {
 	Container                   string                `json:"container"`
 	HDR                         string                `json:"hdr"`
 	Audio                       string                `json:"audio"`
+	AudioFormats                []string              `json:"audio_formats"`  // normalized like TrueHD, Atmos, DTS-HD MA
+	AudioChannels               string                `json:"audio_channels"` // like 5.1
 	Group                       string                `json:"group"`
 	Region                      string                `json:"region"`
 	Language                    string                `json:"language"`
+	Languages                   []string              `json:"languages"` // audio, normalized like French, Multi, Nordic
+	Subtitles                   []string              `json:"subtitles"`
+	SubtitleType                SubtitleType          `json:"subtitle_type"`
 	Edition                     string                `json:"edition"` // Extended, directors cut
 	Unrated                     bool                  `json:"unrated"`
 	Hybrid                      bool                  `json:"hybrid"`
@@ -85,6 +118,8 @@ type Release struct {
 	Tags                        []string              `json:"tags"`
 	ReleaseTags                 string                `json:"-"`
 	Freeleech                   bool                  `json:"freeleech"`
+	FreeleechToken              bool                  `json:"freeleech_token"` // torrent url spends a freeleech token
+	FreeleechTokenURL           *TokenURL             `json:"-"`               // used once for the first download, never stored
 	FreeleechPercent            int                   `json:"freeleech_percent"`
 	Uploader                    string                `json:"uploader"`
 	PreTime                     string                `json:"pre_time"`
@@ -92,6 +127,15 @@ type Release struct {
 	FilterID                    int                   `json:"-"`
 	Filter                      *Filter               `json:"-"`
 	ActionStatus                []ReleaseActionStatus `json:"action_status"`
+	Timings                     ReleaseTimings        `json:"timings"`
+}
+
+// ReleaseTimings microseconds spent in each stage of the pipeline, 0 when the release did not go through it
+type ReleaseTimings struct {
+	Parse  int64 `json:"parse_us"`  // announce lines to a release
+	Filter int64 `json:"filter_us"` // filter checks, without a torrent file download for a size check
+	Fetch  int64 `json:"fetch_us"`  // torrent file downloads, including the wait for the indexer download limits
+	Push   int64 `json:"push_us"`   // first action that sent the release, without the download
 }
 
 type ReleaseActionStatus struct {
@@ -100,8 +144,10 @@ type ReleaseActionStatus struct {
 	Action     string            `json:"action"`
 	Type       ActionType        `json:"type"`
 	Rejections []string          `json:"rejections"`
+	Log        string            `json:"log,omitempty"` // captured output, e.g. from exec
 	Timestamp  time.Time         `json:"timestamp"`
 	ReleaseID  int64             `json:"-"`
+	FilterID   int               `json:"-"` // counted in the filter stats
 }
 
 func NewRelease(indexer string, line string) (*Release, error) {
@@ -126,15 +172,19 @@ func (r *Release) Parse() error {
 	err = r.extractYear()
 	err = r.extractSeason()
 	err = r.extractEpisode()
+	err = r.extractAirDate()
 	err = r.extractResolution()
 	err = r.extractSource()
 	err = r.extractCodec()
 	err = r.extractContainer()
 	err = r.extractHDR()
 	err = r.extractAudio()
+	err = r.extractAudioFormats()
+	err = r.extractAudioChannels()
 	err = r.extractGroup()
 	err = r.extractRegion()
 	err = r.extractLanguage()
+	err = r.extractLanguages()
 	err = r.extractEdition()
 	err = r.extractUnrated()
 	err = r.extractHybrid()
@@ -187,6 +237,25 @@ func (r *Release) extractEpisode() error {
 	return nil
 }
 
+var airDateRegexp = regexp.MustCompile(`\b((?:19|20)\d{2})[. _-](0[1-9]|1[0-2])[. _-](0[1-9]|[12]\d|3[01])\b`)
+
+// extractAirDate date of a daily show episode like That.Show.2022.03.10.Guest.1080p
+func (r *Release) extractAirDate() error {
+	matches := airDateRegexp.FindStringSubmatch(r.TorrentName)
+	if matches == nil {
+		return nil
+	}
+
+	airDate, err := time.Parse("2006-01-02", fmt.Sprintf("%v-%v-%v", matches[1], matches[2], matches[3]))
+	if err != nil {
+		return nil
+	}
+
+	r.AirDate = airDate.Format("2006-01-02")
+
+	return nil
+}
+
 func (r *Release) extractResolution() error {
 	v, err := findLast(r.TorrentName, `\b(([0-9]{3,4}p|i))\b`)
 	if err != nil {
@@ -224,7 +293,7 @@ func (r *Release) extractSourceFromTags(tag string) error {
 	if r.Source != "" {
 		return nil
 	}
-	v, err := findLast(tag, `(?i)\b(((?:PPV\.)?[HP]DTV|(?:HD)?CAM|B[DR]Rip|(?:HD-?)?TS|(?:PPV )?WEB-?DL(?: DVDRip)?|HDRip|DVDRip|DVDRIP|CamRip|WEB|W[EB]BRip|Blu-?Ray|DvDScr|telesync|CD|DVD|Vinyl|DAT|Cassette))\b`)
+	v, err := findLast(tag, `(?i)\b(((?:PPV\.)?[HP]DTV|(?:HD)?CAM|B[DR]Rip|(?:HD-?)?TS|(?:PPV )?WEB-?DL(?: DVDRip)?|HDRip|DVDRip|DVDRIP|CamRip|WEB|W[EB]BRip|Blu-?Ray|DvDScr|telesync|SACD|CD|DVD|Vinyl|Soundboard|DAT|Cassette))\b`)
 	if err != nil {
 		return err
 	}
@@ -301,6 +370,64 @@ func (r *Release) extractAudio() error {
 	return nil
 }
 
+// audioFormats most specific first, matched text is removed so DTS-HD MA is not also DTS
+var audioFormats = []struct {
+	name string
+	rxp  *regexp.Regexp
+}{
+	{name: "Atmos", rxp: audioFormatRegexp(`atmos`)},
+	{name: "TrueHD", rxp: audioFormatRegexp(`(?:dolby[. ])?true-?hd`)},
+	{name: "DTS-X", rxp: regexp.MustCompile(`(?i)(?:^|[^a-z0-9])dts[. :-]?x(?:[^a-z0-9+]|$)`)}, // not DTS.x264
+	{name: "DTS-HD MA", rxp: audioFormatRegexp(`dts-?hd[. ]ma`)},
+	{name: "DTS-HD", rxp: audioFormatRegexp(`dts-?hd`)},
+	{name: "DTS", rxp: audioFormatRegexp(`dts(?:-es)?`)},
+	{name: "DD+", rxp: audioFormatRegexp(`ddp|dd\+|e-?ac-?3|dolby[. ]digital[. ]plus`)},
+	{name: "DD", rxp: audioFormatRegexp(`dd|ac-?3|dolby[. ]digital`)},
+	{name: "FLAC", rxp: audioFormatRegexp(`flac`)},
+	{name: "LPCM", rxp: audioFormatRegexp(`l?pcm`)},
+	{name: "Opus", rxp: audioFormatRegexp(`opus`)},
+	{name: "AAC", rxp: audioFormatRegexp(`aac(?:-lc)?`)},
+	{name: "MP3", rxp: audioFormatRegexp(`mp3`)},
+}
+
+// audioFormatRegexp match a whole word, channels like DDP5.1 may follow
+func audioFormatRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:` + pattern + `)(?:[^a-z+]|$)`)
+}
+
+func (r *Release) extractAudioFormats() error {
+	name := r.TorrentName
+
+	var formats []string
+
+	for _, format := range audioFormats {
+		if !format.rxp.MatchString(name) {
+			continue
+		}
+
+		formats = append(formats, format.name)
+		name = format.rxp.ReplaceAllString(name, " ")
+	}
+
+	r.AudioFormats = formats
+
+	return nil
+}
+
+var audioChannelsRegexp = regexp.MustCompile(`(?i)(?:ddp|dd\+?|e?ac-?3|aac|dts(?:-hd)?(?:[. ]ma)?|dts[. :-]?x|truehd|atmos|flac|opus|l?pcm)[. ]?([1-9])[. ]([0-2])(?:[^0-9]|$)`)
+
+// extractAudioChannels channels following the audio codec, like 7.1 in TrueHD.7.1.Atmos
+func (r *Release) extractAudioChannels() error {
+	matches := audioChannelsRegexp.FindStringSubmatch(r.TorrentName)
+	if matches == nil {
+		return nil
+	}
+
+	r.AudioChannels = matches[1] + "." + matches[2]
+
+	return nil
+}
+
 func (r *Release) extractAudioFromTags(tag string) error {
 	if r.Audio != "" {
 		return nil
@@ -551,12 +678,12 @@ func (r *Release) extractReleaseTags() error {
 			continue
 		}
 
-		switch t {
-		case "Cue":
+		// some trackers announce the score with the log, like "Log (100%)"
+		switch {
+		case t == "Cue":
 			r.HasCue = true
-		case "Log":
+		case t == "Log" || strings.HasPrefix(t, "Log "):
 			r.HasLog = true
-			// check percent
 		}
 	}
 
@@ -603,15 +730,145 @@ func (r *Release) ParseTorrentUrl(match string, vars map[string]string, extraVar
 		return err
 	}
 
-	r.TorrentURL = urlBytes.String()
+	r.SetDownloadURL(urlBytes.String())
 
 	// TODO handle cookies
 
 	return nil
 }
 
-func (r *Release) DownloadTorrentFile(opts map[string]string) error {
+// HasMagnet release is announced with a magnet link instead of a torrent file url
+func (r *Release) HasMagnet() bool {
+	return r.MagnetURI != ""
+}
+
+// ForgetTorrentFile make the actions of this copy of the release download the torrent again, eg. with another url.
+// The file is left in place, every filter that matched gets a copy of the release and their queued actions may still use it.
+func (r *Release) ForgetTorrentFile() {
+	r.TorrentTmpFile = ""
+	r.TorrentHash = ""
+}
+
+// IsUsenet release is a nzb from a usenet indexer, TorrentURL holds the nzb url
+func (r *Release) IsUsenet() bool {
+	return r.Protocol == ReleaseProtocolNzb
+}
+
+// IsSeasonPack release has a season but no episode, like That Show S01 1080p
+func (r *Release) IsSeasonPack() bool {
+	return r.Season > 0 && r.Episode == 0
+}
+
+// DownloadLink torrent url or magnet uri for clients that fetch the torrent themselves
+func (r *Release) DownloadLink() string {
 	if r.TorrentURL == "" {
+		return r.MagnetURI
+	}
+
+	return r.torrentFileURL()
+}
+
+// torrentFileURL the freeleech token url the first time, the plain torrent url after that
+func (r *Release) torrentFileURL() string {
+	if u := r.FreeleechTokenURL.Take(); u != "" {
+		return u
+	}
+
+	return r.TorrentURL
+}
+
+// TokenURL url that may only be used once, like one that spends a freeleech token.
+// The copies of a release share it, so retries and other actions get the plain url.
+type TokenURL struct {
+	mtx  sync.Mutex
+	url  string
+	used bool
+}
+
+func NewTokenURL(url string) *TokenURL {
+	return &TokenURL{url: url}
+}
+
+// Pending the url has not been taken yet
+func (t *TokenURL) Pending() bool {
+	if t == nil {
+		return false
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return !t.used
+}
+
+// Take the url the first time it is called, empty after that
+func (t *TokenURL) Take() string {
+	if t == nil {
+		return ""
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.used {
+		return ""
+	}
+
+	t.used = true
+
+	return t.url
+}
+
+// SetDownloadURL set torrent url, or magnet uri and info hash if it is a magnet link
+func (r *Release) SetDownloadURL(downloadURL string) {
+	if !strings.HasPrefix(downloadURL, "magnet:") {
+		r.TorrentURL = downloadURL
+		return
+	}
+
+	r.MagnetURI = downloadURL
+	r.Magnet = true
+	r.TorrentURL = ""
+
+	if hash := magnetInfoHash(downloadURL); hash != "" {
+		r.TorrentHash = hash
+	}
+}
+
+// magnetInfoHash get the hex info hash from a magnet xt param, base32 hashes are converted
+func magnetInfoHash(magnetURI string) string {
+	u, err := url.Parse(magnetURI)
+	if err != nil {
+		return ""
+	}
+
+	for _, xt := range u.Query()["xt"] {
+		if !strings.HasPrefix(xt, "urn:btih:") {
+			continue
+		}
+
+		hash := strings.TrimPrefix(xt, "urn:btih:")
+
+		switch len(hash) {
+		case 40:
+			if _, err := hex.DecodeString(hash); err == nil {
+				return strings.ToLower(hash)
+			}
+		case 32:
+			if b, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+				return hex.EncodeToString(b)
+			}
+		}
+	}
+
+	return ""
+}
+
+// DownloadTorrentFile download the torrent file to a temp file. Supported opts: proxy
+func (r *Release) DownloadTorrentFile(opts map[string]string) error {
+	if r.TorrentURL == "" && r.HasMagnet() {
+		return errors.New("download_file: magnet only release has no torrent file")
+	} else if r.TorrentURL == "" {
 		return errors.New("download_file: url can't be empty")
 	} else if r.TorrentTmpFile != "" {
 		// already downloaded
@@ -620,21 +877,46 @@ func (r *Release) DownloadTorrentFile(opts map[string]string) error {
 
 	customTransport := http.DefaultTransport.(*http.Transport).Clone()
 	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	client := &http.Client{Transport: customTransport}
+
+	if proxy := opts["proxy"]; proxy != "" {
+		proxyURL, err := ParseIndexerProxy(proxy)
+		if err != nil {
+			return errors.Wrap(err, "download_file: could not set proxy")
+		}
+
+		customTransport.Proxy = http.ProxyURL(proxyURL)
+	}
+	client := &http.Client{Transport: customTransport, Timeout: downloadTimeout}
 
 	// Get the data
-	resp, err := client.Get(r.TorrentURL)
+	resp, err := client.Get(r.torrentFileURL())
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("error downloading file")
-		return err
+		return &DownloadError{Retryable: isRetryableNetError(err), Err: err}
 	}
 	defer resp.Body.Close()
 
-	// retry logic
-
 	if resp.StatusCode != http.StatusOK {
 		log.Error().Stack().Err(err).Msgf("error downloading file from: %v - bad status: %d", r.TorrentURL, resp.StatusCode)
-		return fmt.Errorf("error downloading torrent (%v) file (%v) from '%v' - status code: %d", r.TorrentName, r.TorrentURL, r.Indexer, resp.StatusCode)
+
+		// the body often says why, like a torrent the tracker has not registered yet
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return &DownloadError{
+			StatusCode: resp.StatusCode,
+			Retryable:  isRetryableStatus(resp.StatusCode) || isNotRegisteredYet(body),
+			Auth:       resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+			Err:        fmt.Errorf("error downloading torrent (%v) file (%v) from '%v' - status code: %d", r.TorrentName, r.TorrentURL, r.Indexer, resp.StatusCode),
+		}
+	}
+
+	// trackers send an expired cookie or passkey to the login page instead of the torrent
+	if isLoginRedirect(r.TorrentURL, resp.Request) {
+		return &DownloadError{
+			StatusCode: resp.StatusCode,
+			Auth:       true,
+			Err:        fmt.Errorf("error downloading torrent (%v) file from '%v' - redirected to login page", r.TorrentName, r.Indexer),
+		}
 	}
 
 	// Create tmp file
@@ -649,18 +931,25 @@ func (r *Release) DownloadTorrentFile(opts map[string]string) error {
 	_, err = io.Copy(tmpFile, resp.Body)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("error writing downloaded file: %v", tmpFile.Name())
-		return err
+		os.Remove(tmpFile.Name())
+		return &DownloadError{Retryable: isRetryableNetError(err), Err: err}
 	}
 
 	meta, err := metainfo.LoadFromFile(tmpFile.Name())
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("metainfo could not load file contents: %v", tmpFile.Name())
-		return err
+
+		// some trackers answer 200 with an error page instead of the torrent
+		head, _ := readHead(tmpFile.Name(), 1024)
+		os.Remove(tmpFile.Name())
+
+		return &DownloadError{Retryable: isNotRegisteredYet(head), Err: err}
 	}
 
 	torrentMetaInfo, err := meta.UnmarshalInfo()
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("metainfo could not unmarshal info from torrent: %v", tmpFile.Name())
+		os.Remove(tmpFile.Name())
 		return err
 	}
 
@@ -675,6 +964,96 @@ func (r *Release) DownloadTorrentFile(opts map[string]string) error {
 	return nil
 }
 
+// downloadTimeout max time for a torrent file download, a stalled tracker is retried instead of waited on
+const downloadTimeout = time.Minute
+
+// DownloadError failed torrent file download, Retryable when the same download could work a bit later
+type DownloadError struct {
+	StatusCode int // 0 when there was no response
+	Retryable  bool
+	Auth       bool // the tracker did not accept the passkey or cookie
+	Err        error
+}
+
+func (e *DownloadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryableDownload if err is a download error worth retrying, like a timeout or a 503
+func IsRetryableDownload(err error) bool {
+	var downloadErr *DownloadError
+	return errors.As(err, &downloadErr) && downloadErr.Retryable
+}
+
+// IsAuthFailure if err is a download the tracker refused because of the passkey or cookie
+func IsAuthFailure(err error) bool {
+	var downloadErr *DownloadError
+	return errors.As(err, &downloadErr) && downloadErr.Auth
+}
+
+// isLoginRedirect the download ended up on a login page of the tracker
+func isLoginRedirect(torrentURL string, req *http.Request) bool {
+	if req == nil || req.URL == nil || req.URL.String() == torrentURL {
+		return false
+	}
+
+	path := strings.ToLower(req.URL.Path)
+
+	return strings.Contains(path, "login") || strings.Contains(path, "signin")
+}
+
+// isRetryableStatus tracker overloaded or down, 401 and 403 mean a bad passkey or cookie and are not retried
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func isRetryableNetError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// notRegisteredMessages tracker replies for a torrent that is announced but not downloadable yet
+var notRegisteredMessages = []string{
+	"not registered",
+	"not yet available",
+	"torrent not found",
+}
+
+func isNotRegisteredYet(body []byte) bool {
+	text := strings.ToLower(string(body))
+
+	for _, msg := range notRegisteredMessages {
+		if strings.Contains(text, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readHead(name string, n int64) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, n))
+}
+
 func (r *Release) addRejection(reason string) {
 	r.Rejections = append(r.Rejections, reason)
 }
@@ -687,27 +1066,48 @@ func (r *Release) resetRejections() {
 func (r *Release) CheckFilter(filter Filter) bool {
 	// reset rejections first to clean previous checks
 	r.resetRejections()
+	r.AdditionalSizeCheckRequired = false
 
 	if !filter.Enabled {
 		return false
 	}
 
+	checkedAt := r.Timestamp
+	if checkedAt.IsZero() {
+		checkedAt = time.Now()
+	}
+
+	if !filter.ActiveAt(checkedAt) {
+		r.addRejection("filter not active at this time")
+		return false
+	}
+
 	// FIXME what if someone explicitly doesnt want scene, or toggles in filter. Make enum? 0,1,2? Yes, No, Dont care
-	if filter.Scene && r.IsScene != filter.Scene {
+	if filter.Scene && r.IsScene != filter.Scene && r.origin() != OriginScene {
 		r.addRejection("wanted: scene")
 		return false
 	}
 
-	if filter.Freeleech && r.Freeleech != filter.Freeleech {
+	if filter.Freeleech && r.Freeleech != filter.Freeleech && r.freeleechPercent() != 100 {
 		r.addRejection("wanted: freeleech")
 		return false
 	}
 
-	if filter.FreeleechPercent != "" && !checkFreeleechPercent(r.FreeleechPercent, filter.FreeleechPercent) {
+	if filter.FreeleechPercent != "" && !checkFreeleechPercent(r.freeleechPercent(), filter.FreeleechPercent) {
 		r.addRejection("freeleech percent not matching")
 		return false
 	}
 
+	if filter.Origins != "" && (r.origin() == "" || !checkFilterStrings(r.origin(), filter.Origins)) {
+		r.addRejection("origin not matching")
+		return false
+	}
+
+	if filter.ExceptOrigins != "" && r.origin() != "" && checkFilterStrings(r.origin(), filter.ExceptOrigins) {
+		r.addRejection("unwanted origin")
+		return false
+	}
+
 	// check against TorrentName and Clean which is a cleaned name without (. _ -)
 	if filter.Shows != "" && !checkMultipleFilterStrings(filter.Shows, r.TorrentName, r.Clean) {
 		r.addRejection("shows not matching")
@@ -724,8 +1124,27 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
+	switch filter.SeasonPacks {
+	case SeasonPacksOnly:
+		if !r.IsSeasonPack() {
+			r.addRejection("wanted: season pack")
+			return false
+		}
+	case SeasonPacksExclude:
+		if r.IsSeasonPack() {
+			r.addRejection("unwanted: season pack")
+			return false
+		}
+	}
+
+	if filter.DailyMaxAge > 0 && r.AirDate != "" {
+		if rejection := checkAirDate(r.AirDate, filter.DailyMaxAge, time.Now()); rejection != "" {
+			r.addRejection(rejection)
+			return false
+		}
+	}
+
 	// matchRelease
-	// TODO allow to match against regex
 	if filter.MatchReleases != "" && !checkMultipleFilterStrings(filter.MatchReleases, r.TorrentName, r.Clean) {
 		r.addRejection("match release not matching")
 		return false
@@ -736,6 +1155,46 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
+	if filter.MatchReleasesRegex != "" {
+		match, err := checkFilterRegex(filter.MatchReleasesRegex, r.TorrentName)
+		if err != nil {
+			r.addRejection(fmt.Sprintf("invalid match releases regex: %v", err))
+			return false
+		}
+
+		if !match {
+			r.addRejection("match releases regex not matching")
+			return false
+		}
+	}
+
+	if filter.ExceptReleasesRegex != "" {
+		match, err := checkFilterRegex(filter.ExceptReleasesRegex, r.TorrentName)
+		if err != nil {
+			r.addRejection(fmt.Sprintf("invalid except releases regex: %v", err))
+			return false
+		}
+
+		if match {
+			r.addRejection("except releases regex: unwanted release")
+			return false
+		}
+	}
+
+	if filter.MatchEvents != "" && !checkFilterEvents(filter.MatchEvents, r.TorrentName) {
+		r.addRejection("events not matching")
+		return false
+	}
+
+	if filter.ExceptEvents != "" && checkFilterEvents(filter.ExceptEvents, r.TorrentName) {
+		r.addRejection("unwanted event")
+		return false
+	}
+
+	if filter.KeywordScores != "" && !r.checkKeywordScore(filter) {
+		return false
+	}
+
 	if filter.MatchReleaseGroups != "" && !checkMultipleFilterGroups(filter.MatchReleaseGroups, r.Group, r.Clean) {
 		r.addRejection("release groups not matching")
 		return false
@@ -746,12 +1205,17 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
-	if filter.MatchUploaders != "" && !checkFilterStrings(r.Uploader, filter.MatchUploaders) {
+	if filter.MatchUploaders != "" && r.Uploader == "" {
+		r.addRejection("uploader unknown")
+		return false
+	}
+
+	if filter.MatchUploaders != "" && !checkFilterUploaders(r.Uploader, filter.MatchUploaders) {
 		r.addRejection("uploaders not matching")
 		return false
 	}
 
-	if filter.ExceptUploaders != "" && checkFilterStrings(r.Uploader, filter.ExceptUploaders) {
+	if filter.ExceptUploaders != "" && r.Uploader != "" && checkFilterUploaders(r.Uploader, filter.ExceptUploaders) {
 		r.addRejection("unwanted uploaders")
 		return false
 	}
@@ -786,6 +1250,25 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
+	if len(filter.MatchAudio) > 0 && !checkFilterAudio(r.AudioFormats, filter.MatchAudio) {
+		r.addRejection("audio not matching")
+		return false
+	}
+
+	if len(filter.ExceptAudio) > 0 && checkFilterAudio(r.AudioFormats, filter.ExceptAudio) {
+		r.addRejection("unwanted audio")
+		return false
+	}
+
+	if filter.hasLanguageFilter() && !r.checkFilterLanguages(filter) {
+		return false
+	}
+
+	if len(filter.AudioChannels) > 0 && !checkFilterAudio([]string{r.AudioChannels}, filter.AudioChannels) {
+		r.addRejection("audio channels not matching")
+		return false
+	}
+
 	if filter.Years != "" && !checkFilterIntStrings(r.Year, filter.Years) {
 		r.addRejection("year not matching")
 		return false
@@ -806,16 +1289,21 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
-	if (filter.MinSize != "" || filter.MaxSize != "") && !r.CheckSizeFilter(filter.MinSize, filter.MaxSize) {
-		return false
+	if filter.MinSize != "" || filter.MaxSize != "" {
+		if filter.VerifySize && r.TorrentTmpFile == "" && !r.HasMagnet() && !r.IsUsenet() {
+			// announced size can be wrong, check it against the torrent file once the rest of the filter matched
+			r.AdditionalSizeCheckRequired = true
+		} else if !r.CheckSizeFilter(filter.MinSize, filter.MaxSize) {
+			return false
+		}
 	}
 
-	if filter.Tags != "" && !checkFilterTags(r.Tags, filter.Tags) {
+	if filter.Tags != "" && !checkFilterTagsLogic(r.Tags, filter.Tags, filter.TagsMatchLogic) {
 		r.addRejection("tags not matching")
 		return false
 	}
 
-	if filter.ExceptTags != "" && checkFilterTags(r.Tags, filter.ExceptTags) {
+	if filter.ExceptTags != "" && checkFilterTagsLogic(r.Tags, filter.ExceptTags, filter.ExceptTagsMatchLogic) {
 		r.addRejection("unwanted tags")
 		return false
 	}
@@ -830,10 +1318,10 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
-	// Perfect flac requires Cue, Log, Log Score 100, FLAC and 24bit Lossless
+	// Perfect flac requires Cue, Log, Log Score 100, FLAC and Lossless or 24bit Lossless
 	if filter.PerfectFlac {
-		if !r.HasLog || !r.HasCue || r.LogScore != 100 || r.Format != "FLAC" && !checkFilterSlice(r.Quality, []string{"Lossless", "24bit Lossless"}) {
-			r.addRejection("wanted: log")
+		if !r.HasLog || !r.HasCue || r.LogScore != 100 || r.Format != "FLAC" || !checkFilterSlice(r.Quality, []string{"Lossless", "24bit Lossless"}) {
+			r.addRejection("wanted: perfect flac")
 			return false
 		}
 	}
@@ -858,8 +1346,9 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
-	if filter.Log && filter.LogScore != 0 && r.LogScore != filter.LogScore {
-		r.addRejection("wanted: log score")
+	// log score is a minimum
+	if filter.Log && filter.LogScore != 0 && r.LogScore < filter.LogScore {
+		r.addRejection(fmt.Sprintf("log score %d below %d", r.LogScore, filter.LogScore))
 		return false
 	}
 
@@ -868,6 +1357,10 @@ func (r *Release) CheckFilter(filter Filter) bool {
 		return false
 	}
 
+	if filter.Expression != "" && !r.checkFilterExpression(filter) {
+		return false
+	}
+
 	return true
 }
 
@@ -936,7 +1429,7 @@ func (r *Release) MapVars(def IndexerDefinition, varMap map[string]string) error
 	}
 
 	if freeleech, err := getStringMapValue(varMap, "freeleech"); err == nil {
-		r.Freeleech = strings.EqualFold(freeleech, "freeleech") || strings.EqualFold(freeleech, "yes") || strings.EqualFold(freeleech, "1")
+		r.Freeleech = isFlagSet(freeleech, "freeleech")
 	}
 
 	if freeleechPercent, err := getStringMapValue(varMap, "freeleechPercent"); err == nil {
@@ -973,6 +1466,15 @@ func (r *Release) MapVars(def IndexerDefinition, varMap map[string]string) error
 		r.IsScene = strings.EqualFold(scene, "true") || strings.EqualFold(scene, "yes")
 	}
 
+	if origin, err := getStringMapValue(varMap, "origin"); err == nil && origin != "" {
+		r.Origin = normalizeOrigin(origin)
+	}
+
+	// indexers that only flag internal releases, like "Internal!"
+	if internal, err := getStringMapValue(varMap, "internal"); err == nil && isFlagSet(internal, "internal") {
+		r.Origin = OriginInternal
+	}
+
 	if yearVal, err := getStringMapValue(varMap, "year"); err == nil {
 		year, err := strconv.Atoi(yearVal)
 		if err != nil {
@@ -998,6 +1500,133 @@ func (r *Release) MapVars(def IndexerDefinition, varMap map[string]string) error
 	return nil
 }
 
+// freeleechPercent a freeleech flag without a percent is fully free
+func (r *Release) freeleechPercent() int {
+	if r.Freeleech && r.FreeleechPercent == 0 {
+		return 100
+	}
+
+	return r.FreeleechPercent
+}
+
+// origin scene releases are often only flagged as scene
+func (r *Release) origin() string {
+	if r.Origin == "" && r.IsScene {
+		return OriginScene
+	}
+
+	return r.Origin
+}
+
+const (
+	OriginScene    = "SCENE"
+	OriginP2P      = "P2P"
+	OriginInternal = "INTERNAL"
+)
+
+// normalizeOrigin uppercase the known origins so filters can compare them
+func normalizeOrigin(origin string) string {
+	origin = strings.Trim(strings.TrimSpace(origin), "!")
+
+	switch strings.ToUpper(origin) {
+	case OriginScene, OriginP2P, OriginInternal:
+		return strings.ToUpper(origin)
+	}
+
+	return origin
+}
+
+// isFlagSet announce flags are either yes/true/1 or the name of the flag itself, like "FreeLeech!"
+func isFlagSet(value string, name string) bool {
+	value = strings.Trim(strings.TrimSpace(value), "!")
+
+	return strings.EqualFold(value, name) || strings.EqualFold(value, "yes") || strings.EqualFold(value, "true") || value == "1"
+}
+
+// ApplyMetadata fill in fields missing from the announce with metadata from the indexer api
+func (r *Release) ApplyMetadata(m *TorrentMetadata) {
+	if m == nil {
+		return
+	}
+
+	if r.Size == 0 {
+		r.Size = m.Size
+	}
+	if r.Format == "" {
+		r.Format = m.Format
+	}
+	if r.Quality == "" {
+		r.Quality = m.Quality
+	}
+	if r.Source == "" {
+		r.Source = m.Media
+	}
+	if r.LogScore == 0 {
+		r.LogScore = m.LogScore
+	}
+	if !r.HasLog {
+		r.HasLog = m.HasLog
+	}
+	if !r.HasCue {
+		r.HasCue = m.HasCue
+	}
+	if !r.IsScene {
+		r.IsScene = m.IsScene
+	}
+	if !r.Freeleech {
+		r.Freeleech = m.Freeleech
+	}
+	if r.FreeleechPercent == 0 {
+		r.FreeleechPercent = m.FreeleechPercent
+	}
+	if r.Uploader == "" {
+		r.Uploader = m.Uploader
+	}
+}
+
+// MissingMetadata whether the filter checks a field the indexer api could fill in but the announce left empty
+func (r *Release) MissingMetadata(f Filter) bool {
+	switch {
+	case f.Scene && !r.IsScene && r.origin() != OriginScene:
+		return true
+	case (f.Freeleech || f.FreeleechPercent != "") && r.freeleechPercent() == 0:
+		return true
+	case len(f.Formats) > 0 && r.Format == "":
+		return true
+	case len(f.Quality) > 0 && r.Quality == "":
+		return true
+	case len(f.Media) > 0 && r.Source == "":
+		return true
+	case f.Log && !r.HasLog:
+		return true
+	case f.Log && f.LogScore != 0 && r.LogScore == 0:
+		return true
+	case f.Cue && !r.HasCue:
+		return true
+	case (f.MatchUploaders != "" || f.ExceptUploaders != "") && r.Uploader == "":
+		return true
+	}
+
+	return false
+}
+
+// WithoutMetadataChecks the filter without the conditions on fields the indexer api fills in,
+// used to find out if the rest of the filter matches before asking the api
+func (f Filter) WithoutMetadataChecks() Filter {
+	f.Scene = false
+	f.Freeleech = false
+	f.FreeleechPercent = ""
+	f.Formats = nil
+	f.Quality = nil
+	f.Media = nil
+	f.Log = false
+	f.LogScore = 0
+	f.Cue = false
+	f.MatchUploaders = ""
+	f.ExceptUploaders = ""
+	return f
+}
+
 func checkFilterSlice(name string, filterList []string) bool {
 	name = strings.ToLower(name)
 
@@ -1048,6 +1677,41 @@ func checkFilterStrings(name string, filterList string) bool {
 	return false
 }
 
+// checkFilterUploaders exact or wildcard match, so Anon does not also match Anonymous
+func checkFilterUploaders(uploader string, filterList string) bool {
+	uploader = strings.ToLower(strings.TrimSpace(uploader))
+
+	for _, s := range strings.Split(filterList, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+
+		if strings.ContainsAny(s, "?*") {
+			if wildcard.Match(s, uploader) {
+				return true
+			}
+			continue
+		}
+
+		if s == uploader {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkFilterRegex match the full release name, the cleaned name would break patterns relying on separators
+func checkFilterRegex(pattern string, name string) (bool, error) {
+	rxp, err := cachedFilterRegex(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return rxp.MatchString(name), nil
+}
+
 // checkMultipleFilterStrings check against multiple vars of unknown length
 func checkMultipleFilterStrings(filterList string, vars ...string) bool {
 	filterSplit := strings.Split(filterList, ",")
@@ -1077,7 +1741,22 @@ func checkMultipleFilterStrings(filterList string, vars ...string) bool {
 	return false
 }
 
-// checkFilterIntStrings "1,2,3-20"
+// checkAirDate reject daily show episodes that aired more than maxAge days ago
+func checkAirDate(airDate string, maxAge int, now time.Time) string {
+	aired, err := time.ParseInLocation("2006-01-02", airDate, now.Location())
+	if err != nil {
+		return fmt.Sprintf("invalid air date: %v", airDate)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if aired.Before(today.AddDate(0, 0, -maxAge)) {
+		return fmt.Sprintf("aired %v, older than %d days", airDate, maxAge)
+	}
+
+	return ""
+}
+
+// checkFilterIntStrings "1,2,3-20", open ended ranges like "5-" or "5+" have no maximum
 func checkFilterIntStrings(value int, filterList string) bool {
 	filters := strings.Split(filterList, ",")
 
@@ -1085,29 +1764,38 @@ func checkFilterIntStrings(value int, filterList string) bool {
 		s = strings.Replace(s, "%", "", -1)
 		s = strings.Trim(s, " ")
 
+		if strings.HasSuffix(s, "+") {
+			s = strings.TrimSuffix(s, "+") + "-"
+		}
+
 		if strings.Contains(s, "-") {
-			minMax := strings.Split(s, "-")
+			minMax := strings.SplitN(s, "-", 2)
 
 			// to int
-			min, err := strconv.ParseInt(minMax[0], 10, 32)
+			min, err := strconv.ParseInt(strings.TrimSpace(minMax[0]), 10, 32)
 			if err != nil {
 				return false
 			}
 
-			max, err := strconv.ParseInt(minMax[1], 10, 32)
-			if err != nil {
-				return false
+			max := int64(math.MaxInt32)
+			if strings.TrimSpace(minMax[1]) != "" {
+				max, err = strconv.ParseInt(strings.TrimSpace(minMax[1]), 10, 32)
+				if err != nil {
+					return false
+				}
 			}
 
 			if min > max {
 				// handle error
 				return false
-			} else {
-				// if announcePercent is greater than min and less than max return true
-				if value >= int(min) && value <= int(max) {
-					return true
-				}
 			}
+
+			// if value is greater than min and less than max return true
+			if value >= int(min) && value <= int(max) {
+				return true
+			}
+
+			continue
 		}
 
 		filterInt, err := strconv.ParseInt(s, 10, 32)
@@ -1123,30 +1811,36 @@ func checkFilterIntStrings(value int, filterList string) bool {
 	return false
 }
 
+// checkMultipleFilterGroups group names are normalized, see NormalizeReleaseGroup
 func checkMultipleFilterGroups(filterList string, vars ...string) bool {
 	filterSplit := strings.Split(filterList, ",")
 
 	for _, name := range vars {
-		name = strings.ToLower(name)
+		normalized := NormalizeReleaseGroup(name)
 
 		for _, s := range filterSplit {
-			s = strings.ToLower(strings.Trim(s, " "))
-			// check if line contains * or ?, if so try wildcard match, otherwise try substring match
-			a := strings.ContainsAny(s, "?|*")
-			if a {
-				match := wildcard.Match(s, name)
-				if match {
+			// check if line contains * or ?, if so try wildcard match, otherwise try exact and word match
+			if strings.ContainsAny(s, "?|*") {
+				if wildcard.Match(strings.ToLower(strings.Trim(s, " ")), strings.ToLower(name)) {
 					return true
 				}
-			} else {
-				split := SplitAny(name, " .-")
-				for _, c := range split {
-					if c == s {
-						return true
-					}
-				}
 				continue
 			}
+
+			s = NormalizeReleaseGroup(s)
+			if s == "" {
+				continue
+			}
+
+			if s == normalized {
+				return true
+			}
+
+			for _, c := range SplitAny(name, " .-") {
+				if NormalizeReleaseGroup(c) == s {
+					return true
+				}
+			}
 		}
 	}
 
@@ -1181,6 +1875,19 @@ func checkMultipleFilterHDR(filterList []string, vars ...string) bool {
 	return false
 }
 
+// checkFilterAudio any of the parsed values equals one of the filter values, case insensitive
+func checkFilterAudio(values []string, filterList []string) bool {
+	for _, value := range values {
+		for _, filter := range filterList {
+			if value != "" && strings.EqualFold(value, strings.TrimSpace(filter)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func checkFilterSource(name string, filterList []string) bool {
 	// remove dash (-) in blu-ray web-dl and make lowercase
 	name = strings.ToLower(strings.ReplaceAll(name, "-", ""))
@@ -1198,6 +1905,25 @@ func checkFilterSource(name string, filterList []string) bool {
 	return false
 }
 
+// checkFilterTagsLogic the release has any of the filter tags, or all of them
+func checkFilterTagsLogic(tags []string, filter string, logic TagsMatchLogic) bool {
+	if logic != TagsMatchAll {
+		return checkFilterTags(tags, filter)
+	}
+
+	for _, filterTag := range strings.Split(filter, ",") {
+		if strings.TrimSpace(filterTag) == "" {
+			continue
+		}
+
+		if !checkFilterTags(tags, filterTag) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func checkFilterTags(tags []string, filter string) bool {
 	filterTags := strings.Split(filter, ",")
 
@@ -1227,57 +1953,8 @@ func checkFilterTags(tags []string, filter string) bool {
 }
 
 func checkFreeleechPercent(announcePercent int, filterPercent string) bool {
-	filters := strings.Split(filterPercent, ",")
-
-	// remove % and trim spaces
-	//announcePercent = strings.Replace(announcePercent, "%", "", -1)
-	//announcePercent = strings.Trim(announcePercent, " ")
-
-	//announcePercentInt, err := strconv.ParseInt(announcePercent, 10, 32)
-	//if err != nil {
-	//	return false
-	//}
-
-	for _, s := range filters {
-		s = strings.Replace(s, "%", "", -1)
-		s = strings.Trim(s, " ")
-
-		if strings.Contains(s, "-") {
-			minMax := strings.Split(s, "-")
-
-			// to int
-			min, err := strconv.ParseInt(minMax[0], 10, 32)
-			if err != nil {
-				return false
-			}
-
-			max, err := strconv.ParseInt(minMax[1], 10, 32)
-			if err != nil {
-				return false
-			}
-
-			if min > max {
-				// handle error
-				return false
-			} else {
-				// if announcePercent is greater than min and less than max return true
-				if announcePercent >= int(min) && announcePercent <= int(max) {
-					return true
-				}
-			}
-		}
-
-		filterPercentInt, err := strconv.ParseInt(s, 10, 32)
-		if err != nil {
-			return false
-		}
-
-		if int(filterPercentInt) == announcePercent {
-			return true
-		}
-	}
-
-	return false
+	// same format as the other number ranges, "50,75-100"
+	return checkFilterIntStrings(announcePercent, filterPercent)
 }
 
 func getStringMapValue(stringMap map[string]string, key string) (string, error) {
@@ -1442,6 +2119,19 @@ func SplitAny(s string, seps string) []string {
 //	return a
 //}
 
+// NormalizeName lowercase letters and digits only, so separators and casing used by different indexers do not matter
+func NormalizeName(name string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
 func cleanReleaseName(input string) string {
 	// Make a Regex to say we only want letters and numbers
 	reg, err := regexp.Compile(`[\x00-\x1F\x2D\x2E\x5F\x7F]`)
@@ -1458,12 +2148,93 @@ type DownloadTorrentFileResponse struct {
 	TmpFileName string
 }
 
+// ReleaseRetention releases older than MaxAgeDays or beyond the newest MaxRows are pruned, 0 keeps them
+type ReleaseRetention struct {
+	MaxAgeDays int `json:"max_age_days"`
+	MaxRows    int `json:"max_rows"`
+}
+
+func (r ReleaseRetention) Enabled() bool {
+	return r.MaxAgeDays > 0 || r.MaxRows > 0
+}
+
+func (r ReleaseRetention) Validate() error {
+	if r.MaxAgeDays < 0 || r.MaxRows < 0 {
+		return errors.New("retention can't be negative")
+	}
+
+	return nil
+}
+
+// OlderThan releases from before this time are pruned, zero when there is no age limit
+func (r ReleaseRetention) OlderThan(now time.Time) time.Time {
+	if r.MaxAgeDays <= 0 {
+		return time.Time{}
+	}
+
+	return now.AddDate(0, 0, -r.MaxAgeDays)
+}
+
+// ReleasePruneResult rows removed by a prune
+type ReleasePruneResult struct {
+	Releases     int64 `json:"releases"`
+	ActionStatus int64 `json:"action_status"`
+}
+
 type ReleaseStats struct {
 	TotalCount          int64 `json:"total_count"`
 	FilteredCount       int64 `json:"filtered_count"`
 	FilterRejectedCount int64 `json:"filter_rejected_count"`
 	PushApprovedCount   int64 `json:"push_approved_count"`
 	PushRejectedCount   int64 `json:"push_rejected_count"`
+
+	Latency ReleaseLatency `json:"latency"`
+
+	Indexers []ReleaseStatsGroup `json:"indexers,omitempty"`
+	Filters  []ReleaseStatsGroup `json:"filters,omitempty"`
+	Days     []ReleaseStatsGroup `json:"days,omitempty"`
+}
+
+// ReleaseLatency time spent in each pipeline stage, over the releases that went through it
+type ReleaseLatency struct {
+	Parse  StageLatency `json:"parse"`
+	Filter StageLatency `json:"filter"`
+	Fetch  StageLatency `json:"fetch"`
+	Push   StageLatency `json:"push"`
+}
+
+// StageLatency average and slowest time of a pipeline stage in microseconds
+type StageLatency struct {
+	Count int64 `json:"count"`
+	Avg   int64 `json:"avg_us"`
+	Max   int64 `json:"max_us"`
+}
+
+// ReleaseStatsGroup counts for a single indexer, filter or day
+type ReleaseStatsGroup struct {
+	Key                 string `json:"key"`
+	TotalCount          int64  `json:"total_count"`
+	FilteredCount       int64  `json:"filtered_count"`
+	FilterRejectedCount int64  `json:"filter_rejected_count"`
+	PushApprovedCount   int64  `json:"push_approved_count"`
+	PushRejectedCount   int64  `json:"push_rejected_count"`
+
+	Latency ReleaseLatency `json:"latency"`
+}
+
+type ReleaseStatsGroupBy string
+
+const (
+	ReleaseStatsGroupByIndexer ReleaseStatsGroupBy = "indexer"
+	ReleaseStatsGroupByFilter  ReleaseStatsGroupBy = "filter"
+	ReleaseStatsGroupByDay     ReleaseStatsGroupBy = "day"
+)
+
+type ReleaseStatsParams struct {
+	Indexers []string
+	From     time.Time
+	To       time.Time
+	GroupBy  []ReleaseStatsGroupBy
 }
 
 type ReleasePushStatus string
@@ -1487,12 +2258,16 @@ type ReleaseProtocol string
 
 const (
 	ReleaseProtocolTorrent ReleaseProtocol = "torrent"
+	ReleaseProtocolNzb     ReleaseProtocol = "usenet"
 )
 
 type ReleaseImplementation string
 
 const (
-	ReleaseImplementationIRC ReleaseImplementation = "IRC"
+	ReleaseImplementationIRC     ReleaseImplementation = "IRC"
+	ReleaseImplementationTorznab ReleaseImplementation = "TORZNAB"
+	ReleaseImplementationNewznab ReleaseImplementation = "NEWZNAB"
+	ReleaseImplementationRSS     ReleaseImplementation = "RSS"
 )
 
 type ReleaseQueryParams struct {
@@ -1500,9 +2275,35 @@ type ReleaseQueryParams struct {
 	Offset  uint64
 	Cursor  uint64
 	Sort    map[string]string
-	Filters struct {
-		Indexers   []string
-		PushStatus string
-	}
-	Search string
+	Filters ReleaseQueryFilters
+	Search  string
+}
+
+type ReleaseQueryFilters struct {
+	Indexers     []string
+	FilterNames  []string
+	FilterStatus string
+	PushStatus   string
+	From         time.Time
+	To           time.Time
+}
+
+// DuplicateQuery a grabbed release with the same info hash, or with the same normalized name and a size within tolerance
+type DuplicateQuery struct {
+	ExcludeID     int64
+	Since         time.Time
+	InfoHash      string
+	Name          string   // normalized torrent name
+	Size          uint64   // 0 when unknown, matches any size
+	SizeTolerance float64  // fraction of the larger size
+	Indexers      []string // only grabs from these indexers, all when empty
+}
+
+// EpisodeQuery grabbed releases of the same episode of a show, or season packs that contain it
+type EpisodeQuery struct {
+	ExcludeID int64
+	Since     time.Time
+	Title     string // normalized title of the show
+	Season    int
+	Episode   int
 }
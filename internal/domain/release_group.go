@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeReleaseGroup lower case group name without the brackets and separators it is often written with,
+// so "[NTb]", "-NTb" and "ntb" are the same group
+func NormalizeReleaseGroup(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for {
+		trimmed := strings.Trim(name, " -._")
+		if enclosed(trimmed, "[", "]") || enclosed(trimmed, "(", ")") {
+			trimmed = trimmed[1 : len(trimmed)-1]
+		}
+
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
+}
+
+func enclosed(s string, open string, close string) bool {
+	return len(s) >= 2 && strings.HasPrefix(s, open) && strings.HasSuffix(s, close)
+}
+
+// releaseGroupWarnings entries of a comma separated group list that are probably typos
+func releaseGroupWarnings(field string, list string) []FilterIssue {
+	var warnings []FilterIssue
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+
+		switch {
+		case entry == "":
+			warnings = append(warnings, FilterIssue{Field: field, Message: "empty entry, check for a double or trailing comma"})
+		case strings.Contains(entry, " "):
+			warnings = append(warnings, FilterIssue{Field: field, Message: fmt.Sprintf("%q contains a space, groups are separated by commas", entry)})
+		case strings.HasPrefix(entry, "-") || strings.HasSuffix(entry, "-"):
+			warnings = append(warnings, FilterIssue{Field: field, Message: fmt.Sprintf("%q has a leading or trailing dash, it is ignored", entry)})
+		}
+	}
+
+	return warnings
+}
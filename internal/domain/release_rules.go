@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/autobrr/autobrr/pkg/wildcard"
+)
+
+// ReleaseRules instance wide lists from the config, checked before any filter
+type ReleaseRules struct {
+	RejectReleases      string // comma separated words or wildcards, like "CAM, TS, *TELESYNC*"
+	RejectReleaseGroups string // comma separated, normalized like the filter groups
+	AllowReleaseGroups  string // trusted groups that are never rejected by RejectReleases
+}
+
+// Enabled any list set
+func (rr ReleaseRules) Enabled() bool {
+	return rr.RejectReleases != "" || rr.RejectReleaseGroups != ""
+}
+
+// Check returns why the release is rejected for every filter
+func (rr ReleaseRules) Check(r *Release) string {
+	if rr.RejectReleaseGroups != "" && r.Group != "" && checkMultipleFilterGroups(rr.RejectReleaseGroups, r.Group) {
+		return fmt.Sprintf("globally rejected release group: %v", r.Group)
+	}
+
+	if rr.RejectReleases == "" {
+		return ""
+	}
+
+	if rr.AllowReleaseGroups != "" && r.Group != "" && checkMultipleFilterGroups(rr.AllowReleaseGroups, r.Group) {
+		return ""
+	}
+
+	if word := matchReleaseWord(rr.RejectReleases, r.TorrentName); word != "" {
+		return fmt.Sprintf("globally rejected release: %v", word)
+	}
+
+	return ""
+}
+
+// matchReleaseWord the first entry found as whole words in the name, or matching it as a wildcard
+func matchReleaseWord(list string, name string) string {
+	lowerName := strings.ToLower(name)
+	words := keywordWords(lowerName)
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if strings.ContainsAny(entry, "?*") {
+			if wildcard.Match(entry, lowerName) {
+				return entry
+			}
+			continue
+		}
+
+		if containsWords(words, keywordWords(entry)) {
+			return entry
+		}
+	}
+
+	return ""
+}
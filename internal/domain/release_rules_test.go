@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseRules_Check(t *testing.T) {
+	rules := ReleaseRules{
+		RejectReleases:      "CAM, TS, *TELESYNC*",
+		RejectReleaseGroups: "BadGroup",
+		AllowReleaseGroups:  "Trusted",
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		want  string
+	}{
+		{name: "That.Movie.2022.1080p.CAM.x264-GROUP", group: "GROUP", want: "globally rejected release: cam"},
+		{name: "That.Movie.2022.720p.TELESYNC.x264-GROUP", group: "GROUP", want: "globally rejected release: *telesync*"},
+		{name: "That.Movie.2022.TS.x264-Trusted", group: "Trusted"},
+		{name: "Hosts.Of.Cameras.2022.1080p.WEB.H264-GROUP", group: "GROUP"},
+		{name: "That.Movie.2022.1080p.WEB.H264-BadGroup", group: "BadGroup", want: "globally rejected release group: BadGroup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.Check(&Release{TorrentName: tt.name, Group: tt.group}))
+		})
+	}
+
+	assert.False(t, ReleaseRules{AllowReleaseGroups: "Trusted"}.Enabled())
+}
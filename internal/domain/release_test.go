@@ -1,9 +1,13 @@
 package domain
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRelease_Parse(t *testing.T) {
@@ -19,17 +23,19 @@ func TestRelease_Parse(t *testing.T) {
 				TorrentName: "Servant S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-FLUX",
 			},
 			want: Release{
-				TorrentName: "Servant S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP 5.1 Atmos",
-				Group:       "FLUX",
-				Website:     "ATVP",
+				TorrentName:   "Servant S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP 5.1 Atmos",
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
 			},
 			wantErr: false,
 		},
@@ -39,17 +45,19 @@ func TestRelease_Parse(t *testing.T) {
 				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
 			},
 			want: Release{
-				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP.5.1", // need to fix audio parsing
-				Group:       "FLUX",
-				Website:     "ATVP",
+				TorrentName:   "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP.5.1", // need to fix audio parsing
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
 			},
 			wantErr: false,
 		},
@@ -60,19 +68,21 @@ func TestRelease_Parse(t *testing.T) {
 				ReleaseTags: "MKV / 2160p / WEB-DL",
 			},
 			want: Release{
-				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				ReleaseTags: "MKV / 2160p / WEB-DL",
-				Container:   "MKV",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP.5.1", // need to fix audio parsing
-				Group:       "FLUX",
-				Website:     "ATVP",
+				TorrentName:   "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				ReleaseTags:   "MKV / 2160p / WEB-DL",
+				Container:     "MKV",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP.5.1", // need to fix audio parsing
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
 			},
 			wantErr: false,
 		},
@@ -83,19 +93,21 @@ func TestRelease_Parse(t *testing.T) {
 				ReleaseTags: "MKV | 2160p | WEB-DL",
 			},
 			want: Release{
-				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				ReleaseTags: "MKV | 2160p | WEB-DL",
-				Container:   "MKV",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP.5.1", // need to fix audio parsing
-				Group:       "FLUX",
-				Website:     "ATVP",
+				TorrentName:   "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				ReleaseTags:   "MKV | 2160p | WEB-DL",
+				Container:     "MKV",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP.5.1", // need to fix audio parsing
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
 			},
 			wantErr: false,
 		},
@@ -106,19 +118,21 @@ func TestRelease_Parse(t *testing.T) {
 				ReleaseTags: "MP4 | 2160p | WEB-DL",
 			},
 			want: Release{
-				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				ReleaseTags: "MP4 | 2160p | WEB-DL",
-				Container:   "MP4",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP.5.1", // need to fix audio parsing
-				Group:       "FLUX",
-				Website:     "ATVP",
+				TorrentName:   "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				ReleaseTags:   "MP4 | 2160p | WEB-DL",
+				Container:     "MP4",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP.5.1", // need to fix audio parsing
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
 			},
 			wantErr: false,
 		},
@@ -129,20 +143,22 @@ func TestRelease_Parse(t *testing.T) {
 				ReleaseTags: "MP4 | 2160p | WEB-DL | Freeleech!",
 			},
 			want: Release{
-				TorrentName: "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
-				Clean:       "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
-				ReleaseTags: "MP4 | 2160p | WEB-DL | Freeleech!",
-				Container:   "MP4",
-				Season:      1,
-				Episode:     0,
-				Resolution:  "2160p",
-				Source:      "WEB-DL",
-				Codec:       "HEVC",
-				HDR:         "DV",
-				Audio:       "DDP.5.1", // need to fix audio parsing
-				Group:       "FLUX",
-				Website:     "ATVP",
-				Freeleech:   true,
+				TorrentName:   "Servant.S01.2160p.ATVP.WEB-DL.DDP.5.1.Atmos.DV.HEVC-FLUX",
+				Clean:         "Servant S01 2160p ATVP WEB DL DDP 5 1 Atmos DV HEVC FLUX",
+				ReleaseTags:   "MP4 | 2160p | WEB-DL | Freeleech!",
+				Container:     "MP4",
+				Season:        1,
+				Episode:       0,
+				Resolution:    "2160p",
+				Source:        "WEB-DL",
+				Codec:         "HEVC",
+				HDR:           "DV",
+				Audio:         "DDP.5.1", // need to fix audio parsing
+				AudioFormats:  []string{"Atmos", "DD+"},
+				AudioChannels: "5.1",
+				Group:         "FLUX",
+				Website:       "ATVP",
+				Freeleech:     true,
 			},
 			wantErr: false,
 		},
@@ -292,6 +308,19 @@ func TestRelease_CheckFilter(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name:   "size_larger_than_max_verify_size",
+			fields: &Release{Size: uint64(30000000001), TorrentURL: "https://mock.example.test/torrent.php?id=1"},
+			args: args{
+				filter: Filter{
+					Enabled:    true,
+					MinSize:    "10 GB",
+					MaxSize:    "20GB",
+					VerifySize: true,
+				},
+			},
+			want: true, // checked against the torrent file
+		},
 		//{
 		//	name:   "test_no_size",
 		//	fields: &Release{Size: uint64(0)},
@@ -565,6 +594,51 @@ func TestRelease_CheckFilter(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name:   "tv_match_episode_ranges",
+			fields: &Release{TorrentName: "Good show S03E14 1080p WEB-DL DDP 5.1 H.264-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled:  true,
+					Seasons:  "1-2,3+",
+					Episodes: "1-5,10-",
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "tv_season_packs_only",
+			fields: &Release{TorrentName: "Good show S01E01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2"},
+			args: args{
+				filter: Filter{
+					Enabled:     true,
+					SeasonPacks: SeasonPacksOnly,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "tv_season_packs_excluded",
+			fields: &Release{TorrentName: "Good show S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2"},
+			args: args{
+				filter: Filter{
+					Enabled:     true,
+					SeasonPacks: SeasonPacksExclude,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "tv_daily_too_old",
+			fields: &Release{TorrentName: "Late Show 2019.03.10 Guest Name 1080p WEB h264-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled:     true,
+					DailyMaxAge: 7,
+				},
+			},
+			want: false,
+		},
 		{
 			name: "tv_bad_match_season",
 			fields: &Release{
@@ -1243,6 +1317,291 @@ func TestRelease_CheckFilter(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "match_music_log_score_minimum",
+			fields: &Release{
+				TorrentName: "Artist - Albumname",
+				ReleaseTags: "FLAC / Lossless / Log (99%) / Cue / CD",
+				Category:    "Album",
+			},
+			args: args{
+				filter: Filter{
+					Enabled:  true,
+					Artists:  "Artist",
+					Media:    []string{"CD"},
+					Log:      true,
+					LogScore: 95,
+					Cue:      true,
+				},
+			},
+			want: true,
+		},
+		{
+			name: "match_music_log_score_below_minimum",
+			fields: &Release{
+				TorrentName: "Artist - Albumname",
+				ReleaseTags: "FLAC / Lossless / Log / 80% / Cue / CD",
+				Category:    "Album",
+			},
+			args: args{
+				filter: Filter{
+					Enabled:  true,
+					Artists:  "Artist",
+					Log:      true,
+					LogScore: 95,
+				},
+			},
+			want: false,
+		},
+		{
+			name: "match_music_perfect_flac_wrong_format",
+			fields: &Release{
+				TorrentName: "Artist - Albumname",
+				ReleaseTags: "MP3 / Lossless / Log / 100% / Cue / CD",
+				Category:    "Album",
+			},
+			args: args{
+				filter: Filter{
+					Enabled:     true,
+					Artists:     "Artist",
+					PerfectFlac: true,
+				},
+			},
+			want: false,
+		},
+		{
+			name: "match_music_bitrate_vinyl",
+			fields: &Release{
+				TorrentName: "Artist - Albumname",
+				ReleaseTags: "MP3 / V0 (VBR) / Vinyl",
+				Category:    "Album",
+			},
+			args: args{
+				filter: Filter{
+					Enabled: true,
+					Artists: "Artist",
+					Formats: []string{"MP3"},
+					Quality: []string{"320", "V0"},
+					Media:   []string{"Vinyl"},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "match_music_bitrate_sacd",
+			fields: &Release{
+				TorrentName: "Artist - Albumname",
+				ReleaseTags: "MP3 / V0 (VBR) / SACD",
+				Category:    "Album",
+			},
+			args: args{
+				filter: Filter{
+					Enabled: true,
+					Artists: "Artist",
+					Formats: []string{"MP3"},
+					Quality: []string{"320", "V0"},
+					Media:   []string{"SACD"},
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "match_audio",
+			fields: &Release{TorrentName: "That Movie 2020 2160p UHD BluRay REMUX DV HDR10 HEVC TrueHD 7.1 Atmos-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled:       true,
+					MatchHDR:      []string{"DV"},
+					MatchAudio:    []string{"TrueHD", "DTS-HD MA"},
+					ExceptAudio:   []string{"DD"},
+					AudioChannels: []string{"7.1"},
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "except_audio",
+			fields: &Release{TorrentName: "That Movie 2020 1080p BluRay DTS-HD MA 5.1 x264-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled:     true,
+					ExceptAudio: []string{"DTS-HD MA"},
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "audio_channels_not_matching",
+			fields: &Release{TorrentName: "That Movie 2020 1080p WEB-DL AAC2.0 H.264-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled:       true,
+					AudioChannels: []string{"5.1", "7.1"},
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_origin_internal",
+			fields: &Release{TorrentName: "That Movie 2020 1080p BluRay DD5.1 x264-GROUP1", Origin: OriginInternal, Freeleech: true},
+			args: args{
+				filter: Filter{
+					Enabled:          true,
+					Origins:          "INTERNAL",
+					FreeleechPercent: "75-100",
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "match_origin_scene_flag",
+			fields: &Release{TorrentName: "That Movie 2020 1080p BluRay DD5.1 x264-GROUP1", IsScene: true},
+			args: args{
+				filter: Filter{
+					Enabled:       true,
+					ExceptOrigins: "SCENE",
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_origin_unknown",
+			fields: &Release{TorrentName: "That Movie 2020 1080p BluRay DD5.1 x264-GROUP1"},
+			args: args{
+				filter: Filter{
+					Enabled: true,
+					Origins: "INTERNAL,P2P",
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_freeleech_percent_only",
+			fields: &Release{TorrentName: "That Movie 2020 1080p BluRay DD5.1 x264-GROUP1", FreeleechPercent: 100},
+			args: args{
+				filter: Filter{
+					Enabled:   true,
+					Freeleech: true,
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "match_tags_all",
+			fields: &Release{TorrentName: "That Movie 2021 1080p WEB-DL DD5.1 H.264-GROUP1", Tags: []string{"comedy", "2020s", "romance"}},
+			args: args{
+				filter: Filter{
+					Enabled:        true,
+					Tags:           "comedy, 2020s",
+					TagsMatchLogic: TagsMatchAll,
+					ExceptTags:     "anime",
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "match_tags_all_missing",
+			fields: &Release{TorrentName: "That Movie 2021 1080p WEB-DL DD5.1 H.264-GROUP1", Tags: []string{"comedy", "romance"}},
+			args: args{
+				filter: Filter{
+					Enabled:        true,
+					Tags:           "comedy, 2020s",
+					TagsMatchLogic: TagsMatchAll,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "except_tags_all",
+			fields: &Release{TorrentName: "That Movie 2021 1080p WEB-DL DD5.1 H.264-GROUP1", Tags: []string{"comedy", "anime"}},
+			args: args{
+				filter: Filter{
+					Enabled:              true,
+					ExceptTags:           "anime, horror",
+					ExceptTagsMatchLogic: TagsMatchAll,
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "except_tags_any",
+			fields: &Release{TorrentName: "That Movie 2021 1080p WEB-DL DD5.1 H.264-GROUP1", Tags: []string{"comedy", "anime"}},
+			args: args{
+				filter: Filter{
+					Enabled:    true,
+					Tags:       "comedy",
+					ExceptTags: "anime, horror",
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_releases_regex",
+			fields: &Release{TorrentName: "That.Show.S01E05.1080p.WEB-DL.DDP5.1.H.264-GRP"},
+			args: args{
+				filter: Filter{
+					Enabled:             true,
+					MatchReleasesRegex:  `^that\.show\.s\d{2}e\d{2}\.1080p`,
+					ExceptReleasesRegex: `\b(hdtv|xvid)\b`,
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "except_releases_regex",
+			fields: &Release{TorrentName: "That.Show.S01E05.720p.HDTV.x264-GRP"},
+			args: args{
+				filter: Filter{
+					Enabled:             true,
+					ExceptReleasesRegex: `\b(hdtv|xvid)\b`,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "invalid_releases_regex",
+			fields: &Release{TorrentName: "That.Show.S01E05.720p.HDTV.x264-GRP"},
+			args: args{
+				filter: Filter{
+					Enabled:            true,
+					MatchReleasesRegex: `(that`,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_expression",
+			fields: &Release{TorrentName: "That.Show.S01E05.1080p.WEB-DL.DDP5.1.H.264-GRP", Size: uint64(4_000_000_000), Origin: OriginInternal},
+			args: args{
+				filter: Filter{
+					Enabled:    true,
+					Expression: `resolution in ["1080p","2160p"] && size < 20GB && (freeleech || internal)`,
+				},
+			},
+			want: true,
+		},
+		{
+			name:   "match_expression_not_matching",
+			fields: &Release{TorrentName: "That.Show.S01E05.720p.WEB-DL.DDP5.1.H.264-GRP", Size: uint64(4_000_000_000)},
+			args: args{
+				filter: Filter{
+					Enabled:    true,
+					Expression: `resolution in ["1080p","2160p"] && size < 20GB`,
+				},
+			},
+			want: false,
+		},
+		{
+			name:   "match_expression_size_unknown",
+			fields: &Release{TorrentName: "That.Show.S01E05.720p.WEB-DL.DDP5.1.H.264-GRP"},
+			args: args{
+				filter: Filter{
+					Enabled:    true,
+					Expression: `size < 20GB && resolution == "1080p"`,
+				},
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1275,6 +1634,33 @@ func TestRelease_MapVars(t *testing.T) {
 				"torrentName": "Good show S02 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2",
 			}},
 		},
+		{
+			name:   "internal_flag",
+			fields: &Release{},
+			want: &Release{
+				TorrentName: "Good show S02 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2",
+				Freeleech:   true,
+				Origin:      OriginInternal,
+			},
+			args: args{varMap: map[string]string{
+				"torrentName": "Good show S02 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2",
+				"freeleech":   "FreeLeech!",
+				"internal":    "Internal!",
+			}},
+		},
+		{
+			name:   "origin",
+			fields: &Release{},
+			want: &Release{
+				TorrentName: "Good show S02 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2",
+				Origin:      OriginP2P,
+			},
+			args: args{varMap: map[string]string{
+				"torrentName": "Good show S02 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-GROUP2",
+				"origin":      "p2p",
+				"internal":    "",
+			}},
+		},
 		{
 			name:   "2",
 			fields: &Release{},
@@ -1472,3 +1858,222 @@ func TestSplitAny(t *testing.T) {
 		})
 	}
 }
+
+func TestRelease_SetDownloadURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantURL    string
+		wantMagnet bool
+		wantHash   string
+	}{
+		{
+			name:    "torrent_url",
+			url:     "https://mock.example.test/download.php?id=1&passkey=abc",
+			wantURL: "https://mock.example.test/download.php?id=1&passkey=abc",
+		},
+		{
+			name:       "magnet_hex",
+			url:        "magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A&dn=That.Show.S01E01",
+			wantMagnet: true,
+			wantHash:   "c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+		},
+		{
+			name:       "magnet_base32",
+			url:        "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK&dn=That.Show.S01E01",
+			wantMagnet: true,
+			wantHash:   "c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Release{}
+			r.SetDownloadURL(tt.url)
+
+			assert.Equal(t, tt.wantURL, r.TorrentURL)
+			assert.Equal(t, tt.wantMagnet, r.HasMagnet())
+			assert.Equal(t, tt.wantMagnet, r.Magnet)
+			assert.Equal(t, tt.wantHash, r.TorrentHash)
+			assert.Equal(t, tt.url, r.DownloadLink())
+		})
+	}
+}
+
+func Test_checkFilterIntStrings(t *testing.T) {
+	tests := []struct {
+		value  int
+		filter string
+		want   bool
+	}{
+		{value: 2, filter: "1,2,3", want: true},
+		{value: 4, filter: "1,2,3", want: false},
+		{value: 12, filter: "1-5,10-20", want: true},
+		{value: 7, filter: "1-5,7", want: true},
+		{value: 30, filter: "10-", want: true},
+		{value: 30, filter: "10+", want: true},
+		{value: 9, filter: "10+", want: false},
+		{value: 1, filter: "5-1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkFilterIntStrings(tt.value, tt.filter))
+		})
+	}
+}
+
+func Test_checkAirDate(t *testing.T) {
+	now := time.Date(2022, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "", checkAirDate("2022-03-10", 1, now))
+	assert.Equal(t, "", checkAirDate("2022-03-03", 7, now))
+	assert.Equal(t, "aired 2022-03-02, older than 7 days", checkAirDate("2022-03-02", 7, now))
+	assert.Equal(t, "invalid air date: 2022-13-01", checkAirDate("2022-13-01", 7, now))
+}
+
+func TestRelease_extractAudioFormats(t *testing.T) {
+	tests := []struct {
+		name         string
+		want         []string
+		wantChannels string
+	}{
+		{name: "That.Movie.2020.2160p.UHD.BluRay.REMUX.DV.HEVC.TrueHD.7.1.Atmos-GROUP", want: []string{"Atmos", "TrueHD"}, wantChannels: "7.1"},
+		{name: "That Movie 2020 1080p BluRay DTS-HD MA 5.1 x264-GROUP", want: []string{"DTS-HD MA"}, wantChannels: "5.1"},
+		{name: "That.Movie.2020.1080p.BluRay.DTS.x264-GROUP", want: []string{"DTS"}},
+		{name: "That.Show.S01E01.1080p.WEB-DL.DDP5.1.H.264-GROUP", want: []string{"DD+"}, wantChannels: "5.1"},
+		{name: "That.Show.S01E01.720p.HDTV.DD2.0.x264-GROUP", want: []string{"DD"}, wantChannels: "2.0"},
+		{name: "That.Show.S01E01.720p.WEB.AAC2.0.x264-GROUP", want: []string{"AAC"}, wantChannels: "2.0"},
+		{name: "That.Show.S01E01.720p.HDTV.x264-GROUP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Release{TorrentName: tt.name}
+			_ = r.Parse()
+
+			assert.Equal(t, tt.want, r.AudioFormats)
+			assert.Equal(t, tt.wantChannels, r.AudioChannels)
+		})
+	}
+}
+
+func Test_checkFilterUploaders(t *testing.T) {
+	tests := []struct {
+		uploader string
+		filter   string
+		want     bool
+	}{
+		{uploader: "Uploader1", filter: "uploader1,Uploader2", want: true},
+		{uploader: "Anonymous", filter: "Anon", want: false},
+		{uploader: "Anonymous", filter: "Anon*", want: true},
+		{uploader: "TrustedGroup", filter: "trusted?roup", want: true},
+		{uploader: "Uploader3", filter: "Uploader1, ,Uploader2", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uploader+"_"+tt.filter, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkFilterUploaders(tt.uploader, tt.filter))
+		})
+	}
+}
+
+func TestRelease_MissingMetadata(t *testing.T) {
+	music := Filter{Enabled: true, Formats: []string{"FLAC"}, Log: true, LogScore: 100}
+
+	r := &Release{TorrentName: "Artist - Album [2020] [Album] (CD)", Category: "Album"}
+	assert.True(t, r.MissingMetadata(music))
+	assert.False(t, r.MissingMetadata(Filter{Enabled: true, MatchCategories: "Album"}))
+
+	// the rest of the filter is checked without the fields the api fills in
+	assert.False(t, r.CheckFilter(music))
+	assert.True(t, r.CheckFilter(music.WithoutMetadataChecks()))
+
+	r.ApplyMetadata(&TorrentMetadata{Format: "FLAC", HasLog: true, LogScore: 100})
+	assert.False(t, r.MissingMetadata(music))
+	assert.True(t, r.CheckFilter(music))
+}
+
+func Test_checkMultipleFilterGroups(t *testing.T) {
+	tests := []struct {
+		group  string
+		clean  string
+		filter string
+		want   bool
+	}{
+		{group: "NTb", filter: "ntb,FLUX", want: true},
+		{group: "NTb", filter: "[NTb]", want: true},
+		{group: "NTb", filter: "-NTb-", want: true},
+		{group: "D-Z0N3", filter: "d-z0n3", want: true},
+		{group: "", clean: "That Show S01E01 1080p WEB-DL NTb", filter: "NTb", want: true},
+		{group: "NTbx", filter: "NTb", want: false},
+		{group: "FLUX", filter: "fl?x", want: true},
+		{group: "FLUX", filter: "NTb, ,-", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.group+"_"+tt.filter, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkMultipleFilterGroups(tt.filter, tt.group, tt.clean))
+		})
+	}
+}
+
+func TestRelease_DownloadTorrentFile_errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantStatus    int
+		wantRetryable bool
+		wantAuth      bool
+	}{
+		{name: "service_unavailable", status: http.StatusServiceUnavailable, wantStatus: 503, wantRetryable: true},
+		{name: "bad_gateway", status: http.StatusBadGateway, wantStatus: 502, wantRetryable: true},
+		{name: "bad_passkey", status: http.StatusForbidden, body: "invalid passkey", wantStatus: 403, wantAuth: true},
+		{name: "not_registered_yet", status: http.StatusNotFound, body: "Torrent not registered with this tracker", wantStatus: 404, wantRetryable: true},
+		{name: "not_found", status: http.StatusNotFound, body: "page not found", wantStatus: 404},
+		{name: "error_page", status: http.StatusOK, body: "<html>torrent not yet available</html>", wantRetryable: true},
+		{name: "not_a_torrent", status: http.StatusOK, body: "<html>login</html>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			r := &Release{TorrentName: "Test.Release-GROUP", TorrentURL: ts.URL}
+
+			err := r.DownloadTorrentFile(nil)
+			require.Error(t, err)
+
+			var downloadErr *DownloadError
+			require.ErrorAs(t, err, &downloadErr)
+			assert.Equal(t, tt.wantStatus, downloadErr.StatusCode)
+			assert.Equal(t, tt.wantRetryable, IsRetryableDownload(err))
+			assert.Equal(t, tt.wantAuth, IsAuthFailure(err))
+			assert.Empty(t, r.TorrentTmpFile)
+		})
+	}
+}
+
+func TestRelease_DownloadTorrentFile_loginRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login.php" {
+			w.Write([]byte("<html>login</html>"))
+			return
+		}
+
+		http.Redirect(w, r, "/login.php", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	r := &Release{TorrentName: "Test.Release-GROUP", TorrentURL: ts.URL + "/download.php?id=1"}
+
+	err := r.DownloadTorrentFile(nil)
+	require.Error(t, err)
+	assert.True(t, IsAuthFailure(err))
+	assert.False(t, IsRetryableDownload(err))
+	assert.Empty(t, r.TorrentTmpFile)
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "thatshows01e011080pwebdlh264grp", NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP"))
+	assert.Equal(t, NormalizeName("That Show S01E01 1080p WEB-DL H 264-GRP"), NormalizeName("That.Show.S01E01.1080p.WEB-DL.H.264-GRP"))
+}
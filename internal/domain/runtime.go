@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// RuntimeStats process diagnostics, for finding memory growth and goroutine leaks
+type RuntimeStats struct {
+	GoVersion  string    `json:"go_version"`
+	StartedAt  time.Time `json:"started_at"`
+	Goroutines int       `json:"goroutines"`
+	CPUs       int       `json:"cpus"`
+
+	Memory RuntimeMemory `json:"memory"`
+	GC     RuntimeGC     `json:"gc"`
+
+	// Handlers long running handlers and workers by name, like irc handlers and action chains
+	Handlers map[string]int `json:"handlers"`
+}
+
+// RuntimeMemory memory in bytes as reported by the go runtime
+type RuntimeMemory struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+}
+
+// RuntimeGC garbage collector runs, pauses in nanoseconds
+type RuntimeGC struct {
+	Runs         uint32    `json:"runs"`
+	LastRun      time.Time `json:"last_run"`
+	NextHeapSize uint64    `json:"next_heap_size"`
+	PauseTotal   uint64    `json:"pause_total_ns"`
+	LastPause    uint64    `json:"last_pause_ns"`
+	CPUFraction  float64   `json:"cpu_fraction"`
+	ForcedRuns   uint32    `json:"forced_runs"`
+}
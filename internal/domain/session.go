@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type SessionRepo interface {
+	Store(ctx context.Context, session Session) (*Session, error)
+	FindByID(ctx context.Context, id int) (*Session, error)
+	FindByToken(ctx context.Context, tokenHash string) (*Session, error)
+	List(ctx context.Context, username string, authMethod string) ([]Session, error)
+	Touch(ctx context.Context, id int, lastSeen time.Time) error
+	Delete(ctx context.Context, id int) error
+	DeleteByUsername(ctx context.Context, username string, authMethod string, exceptID int) error
+	DeleteExpired(ctx context.Context, now time.Time, idleBefore time.Time) error
+}
+
+// Session signed in browser, the cookie only holds the token so sessions can be listed and revoked
+type Session struct {
+	ID         int       `json:"id"`
+	TokenHash  string    `json:"-"`
+	Username   string    `json:"username"`
+	Role       UserRole  `json:"role"`
+	AuthMethod string    `json:"auth_method"` // password or oidc
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session of the request
+}
+
+// SessionSettings how long sessions last
+type SessionSettings struct {
+	Lifetime    time.Duration
+	IdleTimeout time.Duration // 0 keeps idle sessions until they expire
+}
+
+// Expired the session is past its lifetime or was not used within the idle timeout
+func (s SessionSettings) Expired(session Session, now time.Time) bool {
+	if !now.Before(session.ExpiresAt) {
+		return true
+	}
+
+	return s.IdleTimeout > 0 && now.Sub(session.LastSeenAt) >= s.IdleTimeout
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionSettings_Expired(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	session := Session{LastSeenAt: now.Add(-20 * time.Minute), ExpiresAt: now.Add(time.Hour)}
+
+	assert.False(t, SessionSettings{}.Expired(session, now))
+	assert.True(t, SessionSettings{IdleTimeout: 15 * time.Minute}.Expired(session, now))
+	assert.False(t, SessionSettings{IdleTimeout: 30 * time.Minute}.Expired(session, now))
+	assert.True(t, SessionSettings{}.Expired(session, now.Add(time.Hour)))
+}
+
+func TestConfig_SessionSettings(t *testing.T) {
+	assert.Equal(t, SessionSettings{Lifetime: 30 * 24 * time.Hour}, Config{}.SessionSettings())
+	assert.Equal(t, SessionSettings{Lifetime: 12 * time.Hour, IdleTimeout: 30 * time.Minute}, Config{SessionLifetimeHours: 12, SessionIdleTimeoutMinutes: 30}.SessionSettings())
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// UpdateStatus result of the last check for a newer autobrr release
+type UpdateStatus struct {
+	Enabled         bool       `json:"enabled"`
+	CurrentVersion  string     `json:"current_version"`
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	UpdateAvailable bool       `json:"update_available"`
+	ReleaseURL      string     `json:"release_url,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	CheckedAt       *time.Time `json:"checked_at,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
@@ -1,14 +1,73 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
 
 type UserRepo interface {
+	FindByID(ctx context.Context, id int) (*User, error)
 	FindByUsername(ctx context.Context, username string) (*User, error)
+	List(ctx context.Context) ([]User, error)
 	Store(ctx context.Context, user User) error
+	UpdateRole(ctx context.Context, id int, role UserRole) error
+	UpdateTOTP(ctx context.Context, user User) error
+	Delete(ctx context.Context, id int) error
 }
 
 type User struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	ID            int       `json:"id"`
+	Username      string    `json:"username"`
+	Password      string    `json:"-"`
+	Role          UserRole  `json:"role"`
+	TOTPSecret    string    `json:"-"`
+	TOTPEnabled   bool      `json:"totp_enabled"`
+	RecoveryCodes []string  `json:"-"` // argon2id hashes, a code is removed when used
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CreateUserRequest new user from the user management
+type CreateUserRequest struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Role     UserRole `json:"role"`
+}
+
+// ValidateUsername a local username, the colon is kept for namespaced provider accounts like OIDCUsername
+func ValidateUsername(username string) error {
+	if strings.TrimSpace(username) == "" {
+		return errors.New("username can't be empty")
+	}
+
+	if strings.Contains(username, ":") {
+		return errors.New("username can't contain a colon")
+	}
+
+	return nil
+}
+
+// UpdateUserRequest fields left empty are not changed
+type UpdateUserRequest struct {
+	Password string   `json:"password"`
+	Role     UserRole `json:"role"`
+}
+
+// ErrOnboardingUnavailable a user exists, the first account can't be created from the web ui anymore
+var ErrOnboardingUnavailable = errors.New("onboarding is only available while there are no users")
+
+// ErrTOTPRequired the password is correct but the user has two-factor authentication and sent no code
+var ErrTOTPRequired = errors.New("totp code required")
+
+// TOTPEnrollment secret of a pending enrollment, shown as QR code until it is confirmed with a code
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPStatus two-factor authentication of the user
+type TOTPStatus struct {
+	Enabled       bool `json:"enabled"`
+	RecoveryCodes int  `json:"recovery_codes"` // unused codes left
 }
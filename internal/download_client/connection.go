@@ -5,9 +5,15 @@ import (
 
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/pkg/lidarr"
+	"github.com/autobrr/autobrr/pkg/nzbget"
 	"github.com/autobrr/autobrr/pkg/qbittorrent"
 	"github.com/autobrr/autobrr/pkg/radarr"
+	"github.com/autobrr/autobrr/pkg/readarr"
+	"github.com/autobrr/autobrr/pkg/rtorrent"
+	"github.com/autobrr/autobrr/pkg/sabnzbd"
 	"github.com/autobrr/autobrr/pkg/sonarr"
+	"github.com/autobrr/autobrr/pkg/transmission"
+	"github.com/autobrr/autobrr/pkg/whisparr"
 
 	delugeClient "github.com/gdm85/go-libdeluge"
 	"github.com/rs/zerolog/log"
@@ -21,6 +27,18 @@ func (s *service) testConnection(client domain.DownloadClient) error {
 	case domain.DownloadClientTypeDelugeV1, domain.DownloadClientTypeDelugeV2:
 		return s.testDelugeConnection(client)
 
+	case domain.DownloadClientTypeTransmission:
+		return s.testTransmissionConnection(client)
+
+	case domain.DownloadClientTypeRTorrent:
+		return s.testRTorrentConnection(client)
+
+	case domain.DownloadClientTypeSabnzbd:
+		return s.testSabnzbdConnection(client)
+
+	case domain.DownloadClientTypeNzbget:
+		return s.testNzbgetConnection(client)
+
 	case domain.DownloadClientTypeRadarr:
 		return s.testRadarrConnection(client)
 
@@ -29,6 +47,12 @@ func (s *service) testConnection(client domain.DownloadClient) error {
 
 	case domain.DownloadClientTypeLidarr:
 		return s.testLidarrConnection(client)
+
+	case domain.DownloadClientTypeWhisparr:
+		return s.testWhisparrConnection(client)
+
+	case domain.DownloadClientTypeReadarr:
+		return s.testReadarrConnection(client)
 	}
 
 	return nil
@@ -36,11 +60,17 @@ func (s *service) testConnection(client domain.DownloadClient) error {
 
 func (s *service) testQbittorrentConnection(client domain.DownloadClient) error {
 	qbtSettings := qbittorrent.Settings{
-		Hostname: client.Host,
-		Port:     uint(client.Port),
-		Username: client.Username,
-		Password: client.Password,
-		SSL:      client.SSL,
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		Username:      client.Username,
+		Password:      client.Password,
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+	}
+
+	if client.Settings.Basic.Auth {
+		qbtSettings.BasicUser = client.Settings.Basic.Username
+		qbtSettings.BasicPass = client.Settings.Basic.Password
 	}
 
 	qbt := qbittorrent.NewClient(qbtSettings)
@@ -99,6 +129,88 @@ func (s *service) testDelugeConnection(client domain.DownloadClient) error {
 	return nil
 }
 
+func (s *service) testTransmissionConnection(client domain.DownloadClient) error {
+	tbt := transmission.New(transmission.Config{
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	session, err := tbt.Test()
+	if err != nil {
+		log.Error().Err(err).Msgf("error logging into client: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for Transmission: success - version: %v", session.Version)
+
+	return nil
+}
+
+func (s *service) testRTorrentConnection(client domain.DownloadClient) error {
+	rt := rtorrent.New(rtorrent.Config{
+		Hostname:      client.Host,
+		Port:          uint(client.Port),
+		SSL:           client.SSL,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	version, err := rt.Test()
+	if err != nil {
+		log.Error().Err(err).Msgf("error logging into client: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for rTorrent: success - version: %v", version)
+
+	return nil
+}
+
+func (s *service) testSabnzbdConnection(client domain.DownloadClient) error {
+	sab := sabnzbd.New(sabnzbd.Config{
+		Hostname:      client.Host,
+		APIKey:        client.Settings.APIKey,
+		TLSSkipVerify: client.TLSSkipVerify,
+		BasicAuth:     client.Settings.Basic.Auth,
+		Username:      client.Settings.Basic.Username,
+		Password:      client.Settings.Basic.Password,
+	})
+
+	version, err := sab.Version()
+	if err != nil {
+		log.Error().Err(err).Msgf("sabnzbd: connection test failed: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for SABnzbd: success - version: %v", version)
+
+	return nil
+}
+
+func (s *service) testNzbgetConnection(client domain.DownloadClient) error {
+	nzb := nzbget.New(nzbget.Config{
+		Hostname:      client.Host,
+		TLSSkipVerify: client.TLSSkipVerify,
+		Username:      client.Username,
+		Password:      client.Password,
+	})
+
+	version, err := nzb.Version()
+	if err != nil {
+		log.Error().Err(err).Msgf("nzbget: connection test failed: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for NZBGet: success - version: %v", version)
+
+	return nil
+}
+
 func (s *service) testRadarrConnection(client domain.DownloadClient) error {
 	r := radarr.New(radarr.Config{
 		Hostname:  client.Host,
@@ -158,3 +270,43 @@ func (s *service) testLidarrConnection(client domain.DownloadClient) error {
 
 	return nil
 }
+
+func (s *service) testWhisparrConnection(client domain.DownloadClient) error {
+	r := whisparr.New(whisparr.Config{
+		Hostname:  client.Host,
+		APIKey:    client.Settings.APIKey,
+		BasicAuth: client.Settings.Basic.Auth,
+		Username:  client.Settings.Basic.Username,
+		Password:  client.Settings.Basic.Password,
+	})
+
+	_, err := r.Test()
+	if err != nil {
+		log.Error().Err(err).Msgf("whisparr: connection test failed: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for Whisparr: success")
+
+	return nil
+}
+
+func (s *service) testReadarrConnection(client domain.DownloadClient) error {
+	r := readarr.New(readarr.Config{
+		Hostname:  client.Host,
+		APIKey:    client.Settings.APIKey,
+		BasicAuth: client.Settings.Basic.Auth,
+		Username:  client.Settings.Basic.Username,
+		Password:  client.Settings.Basic.Password,
+	})
+
+	_, err := r.Test()
+	if err != nil {
+		log.Error().Err(err).Msgf("readarr: connection test failed: %v", client.Host)
+		return err
+	}
+
+	log.Debug().Msgf("test client connection for Readarr: success")
+
+	return nil
+}
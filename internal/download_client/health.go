@@ -0,0 +1,145 @@
+package download_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// HealthCheckJob periodically check if the download clients are reachable
+type HealthCheckJob struct {
+	clientSvc Service
+}
+
+func NewHealthCheckJob(clientSvc Service) *HealthCheckJob {
+	return &HealthCheckJob{clientSvc: clientSvc}
+}
+
+func (j *HealthCheckJob) Run() {
+	j.clientSvc.CheckHealth()
+}
+
+// CheckHealth check all enabled clients
+func (s *service) CheckHealth() {
+	clients, err := s.repo.List()
+	if err != nil {
+		log.Error().Err(err).Msg("download_client.CheckHealth: could not get clients")
+		return
+	}
+
+	enabled := map[int]bool{}
+
+	for _, client := range clients {
+		if !client.Enabled {
+			continue
+		}
+
+		enabled[client.ID] = true
+
+		health := s.checkClient(client)
+		if health.Status == domain.DownloadClientHealthError {
+			log.Warn().Msgf("download_client.CheckHealth: %v: %v", client.Name, health.Message)
+		}
+	}
+
+	// forget removed and disabled clients
+	s.healthMtx.Lock()
+	for id := range s.health {
+		if !enabled[id] {
+			delete(s.health, id)
+		}
+	}
+	s.healthMtx.Unlock()
+}
+
+func (s *service) checkClient(client domain.DownloadClient) domain.DownloadClientHealth {
+	health := domain.DownloadClientHealth{
+		Status:    domain.DownloadClientHealthOK,
+		CheckedAt: time.Now(),
+	}
+
+	if err := s.testConnection(client); err != nil {
+		health.Status = domain.DownloadClientHealthError
+		health.Message = err.Error()
+	}
+
+	s.setHealth(client.ID, health)
+
+	return health
+}
+
+func (s *service) setHealth(id int, health domain.DownloadClientHealth) {
+	s.healthMtx.Lock()
+	s.health[id] = health
+	s.healthMtx.Unlock()
+}
+
+// MarkUnreachable record a push that could not connect, the next releases go to the failover client
+// until a health check finds the client reachable again
+func (s *service) MarkUnreachable(client domain.DownloadClient, err error) {
+	s.setHealth(client.ID, domain.DownloadClientHealth{
+		Status:    domain.DownloadClientHealthError,
+		Message:   err.Error(),
+		CheckedAt: time.Now(),
+	})
+}
+
+// reachable clients count as reachable until a health check or a failed push says otherwise
+func (s *service) reachable(client *domain.DownloadClient) bool {
+	s.healthMtx.RLock()
+	defer s.healthMtx.RUnlock()
+
+	health, ok := s.health[client.ID]
+
+	return !ok || health.Status != domain.DownloadClientHealthError
+}
+
+// FailoverChain the client followed by the clients in its failover chain to try when a push can't connect.
+// Failover clients of another type are left out, the action settings only fit the type of the client.
+// Clients known to be unreachable are moved to the end, the last check can be out of date.
+func (s *service) FailoverChain(ctx context.Context, id int32) ([]domain.DownloadClient, error) {
+	var reachable, unreachable []domain.DownloadClient
+
+	seen := map[int32]bool{}
+
+	var clientType domain.DownloadClientType
+
+	for id != 0 && !seen[id] {
+		seen[id] = true
+
+		client, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			if clientType == "" {
+				return nil, err
+			}
+
+			log.Warn().Err(err).Msgf("download_client.FailoverChain: could not find failover client: %v", id)
+			break
+		}
+
+		id = client.Settings.FailoverClientID
+
+		if clientType == "" {
+			clientType = client.Type
+		} else if client.Type != clientType {
+			log.Warn().Msgf("download_client.FailoverChain: skipping failover client %v, type %v does not match %v", client.Name, client.Type, clientType)
+			continue
+		}
+
+		if s.reachable(client) {
+			reachable = append(reachable, *client)
+		} else {
+			unreachable = append(unreachable, *client)
+		}
+	}
+
+	if len(reachable) == 0 && len(unreachable) == 0 {
+		return nil, fmt.Errorf("no client found")
+	}
+
+	return append(reachable, unreachable...), nil
+}
@@ -0,0 +1,107 @@
+package download_client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClientRepo struct {
+	clients map[int32]domain.DownloadClient
+}
+
+func (r *mockClientRepo) List() ([]domain.DownloadClient, error) {
+	var clients []domain.DownloadClient
+	for _, c := range r.clients {
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+func (r *mockClientRepo) FindByID(ctx context.Context, id int32) (*domain.DownloadClient, error) {
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("client %v not found", id)
+	}
+	return &c, nil
+}
+
+func (r *mockClientRepo) Store(client domain.DownloadClient) (*domain.DownloadClient, error) {
+	return &client, nil
+}
+
+func (r *mockClientRepo) Delete(clientID int) error {
+	return nil
+}
+
+func Test_service_FailoverChain(t *testing.T) {
+	repo := &mockClientRepo{clients: map[int32]domain.DownloadClient{
+		1: {ID: 1, Name: "primary", Type: domain.DownloadClientTypeQbittorrent, Settings: domain.DownloadClientSettings{FailoverClientID: 2}},
+		2: {ID: 2, Name: "backup", Type: domain.DownloadClientTypeQbittorrent, Settings: domain.DownloadClientSettings{FailoverClientID: 3}},
+		3: {ID: 3, Name: "last-resort", Type: domain.DownloadClientTypeQbittorrent, Settings: domain.DownloadClientSettings{FailoverClientID: 1}},
+		4: {ID: 4, Name: "no-backup", Type: domain.DownloadClientTypeQbittorrent},
+		5: {ID: 5, Name: "deluge", Type: domain.DownloadClientTypeDelugeV2, Settings: domain.DownloadClientSettings{FailoverClientID: 6}},
+		6: {ID: 6, Name: "other-type", Type: domain.DownloadClientTypeQbittorrent, Settings: domain.DownloadClientSettings{FailoverClientID: 7}},
+		7: {ID: 7, Name: "deluge-backup", Type: domain.DownloadClientTypeDelugeV2, Settings: domain.DownloadClientSettings{FailoverClientID: 8}},
+	}}
+
+	tests := []struct {
+		name        string
+		id          int32
+		unreachable []int
+		want        []string
+		wantErr     bool
+	}{
+		{name: "chain", id: 1, want: []string{"primary", "backup", "last-resort"}},
+		{name: "unknown_health_is_reachable", id: 4, want: []string{"no-backup"}},
+		{name: "unreachable_last", id: 1, unreachable: []int{1}, want: []string{"backup", "last-resort", "primary"}},
+		{name: "all_unreachable", id: 1, unreachable: []int{1, 2, 3}, want: []string{"primary", "backup", "last-resort"}},
+		{name: "same_type_only", id: 5, want: []string{"deluge", "deluge-backup"}},
+		{name: "missing", id: 9, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(repo).(*service)
+			for _, id := range tt.unreachable {
+				s.health[id] = domain.DownloadClientHealth{Status: domain.DownloadClientHealthError}
+			}
+
+			got, err := s.FailoverChain(context.Background(), tt.id)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			var names []string
+			for _, c := range got {
+				names = append(names, c.Name)
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func Test_service_MarkUnreachable(t *testing.T) {
+	repo := &mockClientRepo{clients: map[int32]domain.DownloadClient{
+		1: {ID: 1, Name: "primary", Type: domain.DownloadClientTypeQbittorrent, Settings: domain.DownloadClientSettings{FailoverClientID: 2}},
+		2: {ID: 2, Name: "backup", Type: domain.DownloadClientTypeQbittorrent},
+	}}
+
+	s := NewService(repo).(*service)
+	s.MarkUnreachable(repo.clients[1], fmt.Errorf("connection refused"))
+
+	got, err := s.FailoverChain(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "backup", got[0].Name)
+
+	// removed clients are forgotten by the next health check
+	s.health[3] = domain.DownloadClientHealth{Status: domain.DownloadClientHealthOK}
+	s.CheckHealth()
+	assert.Empty(t, s.health)
+}
@@ -3,6 +3,7 @@ package download_client
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"github.com/autobrr/autobrr/internal/domain"
 
@@ -15,14 +16,24 @@ type Service interface {
 	Store(client domain.DownloadClient) (*domain.DownloadClient, error)
 	Delete(clientID int) error
 	Test(client domain.DownloadClient) error
+	CheckHealth()
+	FailoverChain(ctx context.Context, id int32) ([]domain.DownloadClient, error)
+	MarkUnreachable(client domain.DownloadClient, err error)
 }
 
 type service struct {
 	repo domain.DownloadClientRepo
+
+	// last health check result per client id
+	health    map[int]domain.DownloadClientHealth
+	healthMtx sync.RWMutex
 }
 
 func NewService(repo domain.DownloadClientRepo) Service {
-	return &service{repo: repo}
+	return &service{
+		repo:   repo,
+		health: make(map[int]domain.DownloadClientHealth),
+	}
 }
 
 func (s *service) List() ([]domain.DownloadClient, error) {
@@ -31,6 +42,14 @@ func (s *service) List() ([]domain.DownloadClient, error) {
 		return nil, err
 	}
 
+	s.healthMtx.RLock()
+	for i := range clients {
+		if health, ok := s.health[clients[i].ID]; ok {
+			clients[i].Health = &health
+		}
+	}
+	s.healthMtx.RUnlock()
+
 	return clients, nil
 }
 
@@ -65,6 +84,10 @@ func (s *service) Delete(clientID int) error {
 		return err
 	}
 
+	s.healthMtx.Lock()
+	delete(s.health, clientID)
+	s.healthMtx.Unlock()
+
 	log.Debug().Msgf("delete client: %v", clientID)
 
 	return nil
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/r3labs/sse/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// LiveStream name of the server-sent events stream, /api/events?stream=events
+const LiveStream = "events"
+
+// Live forwards events from the internal bus to the web ui so it does not have to poll
+type Live struct {
+	eventbus EventBus.Bus
+	sse      *sse.Server
+}
+
+func NewLive(eventbus EventBus.Bus, server *sse.Server) Live {
+	server.CreateStream(LiveStream)
+
+	l := Live{eventbus: eventbus, sse: server}
+
+	l.Register()
+
+	return l
+}
+
+func (l Live) Register() {
+	l.eventbus.Subscribe(domain.EventReleaseMatched, l.releaseMatched)
+	l.eventbus.Subscribe("release:push-approved", l.actionResult)
+	l.eventbus.Subscribe("release:push-rejected", l.actionResult)
+	l.eventbus.Subscribe("release:store-action-status", l.actionResult)
+	l.eventbus.Subscribe(domain.EventIrcConnection, l.ircConnection)
+	l.eventbus.Subscribe(domain.EventNotification, l.notification)
+	l.eventbus.Subscribe(domain.EventUpdate, l.update)
+}
+
+func (l Live) releaseMatched(release *domain.Release) {
+	l.publish(domain.LiveEventReleaseMatched, domain.NewLiveRelease(release))
+}
+
+func (l Live) actionResult(actionStatus *domain.ReleaseActionStatus) {
+	l.publish(domain.LiveEventActionResult, domain.LiveActionResult{
+		ReleaseID:  actionStatus.ReleaseID,
+		FilterID:   actionStatus.FilterID,
+		Action:     actionStatus.Action,
+		Type:       actionStatus.Type,
+		Status:     actionStatus.Status,
+		Rejections: actionStatus.Rejections,
+	})
+}
+
+func (l Live) ircConnection(event *domain.IrcConnectionEvent) {
+	l.publish(domain.LiveEventIrcConnection, event)
+}
+
+func (l Live) notification(event *domain.NotificationEvent) {
+	l.publish(domain.LiveEventNotification, event)
+}
+
+func (l Live) update(status *domain.UpdateStatus) {
+	l.publish(domain.LiveEventUpdate, status)
+}
+
+func (l Live) publish(eventType domain.LiveEventType, data interface{}) {
+	b, err := json.Marshal(domain.LiveEvent{Type: eventType, Timestamp: time.Now(), Data: data})
+	if err != nil {
+		log.Error().Err(err).Msgf("events: could not encode live event %v", eventType)
+		return
+	}
+
+	l.sse.Publish(LiveStream, &sse.Event{
+		Event: []byte(eventType),
+		Data:  b,
+	})
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/r3labs/sse/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestLive_releaseMatched(t *testing.T) {
+	bus := EventBus.New()
+	server := sse.New()
+	server.AutoReplay = false
+	defer server.Close()
+
+	NewLive(bus, server)
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"?stream="+LiveStream, nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	// the subscriber is registered once the response started
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		bus.Publish(domain.EventReleaseMatched, &domain.Release{
+			ID:          1,
+			TorrentName: "That.Show.S01E01.1080p.WEB-DL-GROUP",
+			TorrentURL:  "https://tracker.example/download/1?passkey=secret",
+			FilterName:  "tv",
+		})
+	}()
+
+	var event, data string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+		}
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+			break
+		}
+	}
+
+	assert.Equal(t, "release:matched", event)
+	assert.Contains(t, data, `"type":"release:matched"`)
+	assert.Contains(t, data, `"torrent_name":"That.Show.S01E01.1080p.WEB-DL-GROUP"`)
+	assert.NotContains(t, data, "passkey")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
 	"github.com/autobrr/autobrr/internal/release"
 
 	"github.com/asaskevich/EventBus"
@@ -13,10 +14,11 @@ import (
 type Subscriber struct {
 	eventbus   EventBus.Bus
 	releaseSvc release.Service
+	filterSvc  filter.Service
 }
 
-func NewSubscribers(eventbus EventBus.Bus, releaseSvc release.Service) Subscriber {
-	s := Subscriber{eventbus: eventbus, releaseSvc: releaseSvc}
+func NewSubscribers(eventbus EventBus.Bus, releaseSvc release.Service, filterSvc filter.Service) Subscriber {
+	s := Subscriber{eventbus: eventbus, releaseSvc: releaseSvc, filterSvc: filterSvc}
 
 	s.Register()
 
@@ -27,6 +29,8 @@ func (s Subscriber) Register() {
 	s.eventbus.Subscribe("release:store-action-status", s.releaseActionStatus)
 	s.eventbus.Subscribe("release:push-rejected", s.releasePushRejected)
 	s.eventbus.Subscribe("release:push-approved", s.releasePushApproved)
+	s.eventbus.Subscribe(domain.EventIndexerCredentials, s.indexerCredentials)
+	s.eventbus.Subscribe(domain.EventIndexerCredentialsValid, s.indexerCredentialsValid)
 }
 
 func (s Subscriber) releaseActionStatus(actionStatus *domain.ReleaseActionStatus) {
@@ -36,6 +40,8 @@ func (s Subscriber) releaseActionStatus(actionStatus *domain.ReleaseActionStatus
 	if err != nil {
 		log.Error().Err(err).Msgf("events: 'release:store-action-status' error")
 	}
+
+	s.recordFilterStats(actionStatus)
 }
 
 func (s Subscriber) releasePushRejected(actionStatus *domain.ReleaseActionStatus) {
@@ -45,6 +51,8 @@ func (s Subscriber) releasePushRejected(actionStatus *domain.ReleaseActionStatus
 	if err != nil {
 		log.Error().Err(err).Msgf("events: 'release:push-rejected' error")
 	}
+
+	s.recordFilterStats(actionStatus)
 }
 
 func (s Subscriber) releasePushApproved(actionStatus *domain.ReleaseActionStatus) {
@@ -54,4 +62,43 @@ func (s Subscriber) releasePushApproved(actionStatus *domain.ReleaseActionStatus
 	if err != nil {
 		log.Error().Err(err).Msgf("events: 'release:push-approved' error")
 	}
+
+	s.recordFilterStats(actionStatus)
+}
+
+// recordFilterStats count the action outcome for the filter that matched the release
+func (s Subscriber) recordFilterStats(actionStatus *domain.ReleaseActionStatus) {
+	if err := s.filterSvc.RecordActionResult(context.Background(), actionStatus); err != nil {
+		log.Error().Err(err).Msgf("events: could not update filter stats")
+	}
+}
+
+// indexerCredentials disable the filters that only use an indexer with invalid credentials, when the config asks for it
+func (s Subscriber) indexerCredentials(event *domain.IndexerCredentialsEvent) {
+	if !event.PauseFilters {
+		return
+	}
+
+	filters, err := s.filterSvc.PauseByIndexer(context.Background(), event.Indexer)
+	if err != nil {
+		log.Error().Err(err).Msgf("events: could not pause filters for indexer: %v", event.Indexer)
+		return
+	}
+
+	for _, f := range filters {
+		log.Warn().Msgf("events: paused filter %v, credentials of indexer %v are invalid", f.Name, event.Name)
+	}
+}
+
+// indexerCredentialsValid enable the filters again that were paused for the indexer
+func (s Subscriber) indexerCredentialsValid(event *domain.IndexerCredentialsEvent) {
+	filters, err := s.filterSvc.ResumeByIndexer(context.Background(), event.Indexer)
+	if err != nil {
+		log.Error().Err(err).Msgf("events: could not resume filters for indexer: %v", event.Indexer)
+		return
+	}
+
+	for _, f := range filters {
+		log.Info().Msgf("events: resumed filter %v, credentials of indexer %v work again", f.Name, event.Name)
+	}
 }
@@ -0,0 +1,171 @@
+package feed
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/release"
+
+	"github.com/rs/zerolog/log"
+)
+
+// how long seen items are kept in the feed cache
+const feedCacheTTL = 28 * 24 * time.Hour
+
+// baseJob holds what all feed jobs share to dedupe items and push releases through filters and actions
+type baseJob struct {
+	// running count of matched releases for round-robin account selection, first for 64-bit alignment
+	accountCounter uint64
+
+	Name              string
+	IndexerIdentifier string
+	CacheBucket       string
+
+	cacheRepo  domain.FeedCacheRepo
+	filterSvc  filter.Service
+	indexerSvc indexer.Service
+	releaseSvc release.Service
+}
+
+// isNew check if the key has been seen before and if not store it in the cache
+func (j *baseJob) isNew(key string, value string) bool {
+	if key == "" {
+		return false
+	}
+
+	exists, err := j.cacheRepo.Exists(j.CacheBucket, key)
+	if err != nil {
+		log.Error().Err(err).Msgf("feed.isNew: %v: could not check if item exists", j.Name)
+		return false
+	}
+
+	if exists {
+		log.Trace().Msgf("feed.isNew: %v: cache item exists, skip", j.Name)
+		return false
+	}
+
+	ttl := time.Now().Add(feedCacheTTL)
+
+	if err := j.cacheRepo.Put(j.CacheBucket, key, []byte(value), ttl); err != nil {
+		log.Error().Stack().Err(err).Msgf("feed.isNew: %v: cache put error", j.Name)
+		return false
+	}
+
+	return true
+}
+
+func (j *baseJob) processRelease(rls *domain.Release) {
+	// find and check filter
+	filters, err := j.filterSvc.FindAndCheckFilters(rls)
+	if err != nil {
+		log.Error().Err(err).Msgf("feed.processRelease: %v: could not find filter", j.Name)
+		return
+	}
+
+	// no foundFilter found, save as rejected
+	if len(filters) == 0 {
+		log.Trace().Msgf("feed.processRelease: %v: no matching filter found for: %v", j.Name, rls.TorrentName)
+
+		rls.FilterStatus = domain.ReleaseStatusFilterRejected
+		if err := j.releaseSvc.Store(context.Background(), rls); err != nil {
+			log.Error().Err(err).Msgf("feed.processRelease: %v: error writing release to database: %+v", j.Name, rls)
+		}
+
+		return
+	}
+
+	// every matching filter gets its own copy of the release, they share the torrent file of the size check
+	fetched := *rls
+	for i := range filters {
+		matched := fetched
+		j.processMatch(&matched, &filters[i])
+	}
+}
+
+// processMatch store the release as approved by the filter and run its actions
+func (j *baseJob) processMatch(rls *domain.Release, foundFilter *domain.Filter) {
+	// switch to another account if the filter pins one or the indexer rotates them
+	if err := j.applyAccount(rls, foundFilter); err != nil {
+		log.Error().Err(err).Msgf("feed.processRelease: %v: could not select account for release: %v", j.Name, rls.TorrentName)
+		return
+	}
+
+	rls.Filter = foundFilter
+	rls.FilterName = foundFilter.Name
+	rls.FilterID = foundFilter.ID
+
+	rls.FilterStatus = domain.ReleaseStatusFilterApproved
+	if err := j.releaseSvc.Store(context.Background(), rls); err != nil {
+		log.Error().Err(err).Msgf("feed.processRelease: %v: error writing release to database: %+v", j.Name, rls)
+		return
+	}
+
+	log.Info().Msgf("Matched '%v' (%v) for %v", rls.TorrentName, rls.Filter.Name, rls.Indexer)
+
+	// process release
+	if err := j.releaseSvc.Process(*rls); err != nil {
+		log.Error().Err(err).Msgf("feed.processRelease: %v: could not process release: %+v", j.Name, rls)
+	}
+}
+
+// applyAccount swap the credentials in the download url for the selected account, read from the indexer as it is now
+func (j *baseJob) applyAccount(rls *domain.Release, filter *domain.Filter) error {
+	if j.indexerSvc == nil {
+		return nil
+	}
+
+	current := j.indexerSvc.GetByIdentifier(j.IndexerIdentifier)
+	if current == nil || len(current.Accounts) == 0 {
+		return nil
+	}
+
+	n := atomic.AddUint64(&j.accountCounter, 1) - 1
+
+	account, err := current.SelectAccount(filter.IndexerAccounts[current.Identifier], n)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return nil
+	}
+
+	torrentURL := current.AccountURL(rls.TorrentURL, account)
+
+	// the size check may have downloaded the torrent with the default account, the client has to get the one of this account
+	if rls.TorrentTmpFile != "" && torrentURL != rls.TorrentURL {
+		rls.ForgetTorrentFile()
+	}
+
+	rls.TorrentURL = torrentURL
+	rls.IndexerAccount = account.Name
+
+	log.Debug().Msgf("feed: %v: using account '%v' for %v", j.Name, account.Name, rls.TorrentName)
+
+	return nil
+}
+
+// CachePruneJob removes expired items from the feed cache
+type CachePruneJob struct {
+	cacheRepo domain.FeedCacheRepo
+}
+
+func NewCachePruneJob(cacheRepo domain.FeedCacheRepo) *CachePruneJob {
+	return &CachePruneJob{cacheRepo: cacheRepo}
+}
+
+func (j *CachePruneJob) Run() {
+	removed, err := j.cacheRepo.DeleteExpired()
+	if err != nil {
+		log.Error().Err(err).Msg("feed cache: scheduled prune failed")
+		return
+	}
+
+	if removed > 0 {
+		log.Debug().Msgf("feed cache: removed %d expired items", removed)
+	}
+}
@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/release"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockIndexerService struct {
+	indexer.Service
+	def domain.IndexerDefinition
+}
+
+func (m mockIndexerService) GetByIdentifier(identifier string) *domain.IndexerDefinition {
+	if identifier != m.def.Identifier {
+		return nil
+	}
+
+	return &m.def
+}
+
+func Test_baseJob_applyAccount(t *testing.T) {
+	def := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"passkey": "main"},
+		Accounts:    []domain.IndexerAccount{{Name: "seedbox", Enabled: true, Settings: map[string]string{"passkey": "seedbox"}}},
+	}
+
+	j := &baseJob{Name: "mock feed", IndexerIdentifier: "mock", indexerSvc: mockIndexerService{def: def}}
+
+	// downloaded by the size check with the main account, shared by the copies of every matched filter
+	tmpFile := filepath.Join(t.TempDir(), "size-check.torrent")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte("d4:infode"), 0644))
+
+	fetched := domain.Release{TorrentURL: "https://mock.example.test/dl/1?passkey=main", TorrentTmpFile: tmpFile, TorrentHash: "abc"}
+	main, seedbox := fetched, fetched
+
+	assert.NoError(t, j.applyAccount(&main, &domain.Filter{IndexerAccounts: map[string]string{"mock": domain.IndexerAccountDefault}}))
+	assert.NoError(t, j.applyAccount(&seedbox, &domain.Filter{IndexerAccounts: map[string]string{"mock": "seedbox"}}))
+
+	assert.Equal(t, "https://mock.example.test/dl/1?passkey=main", main.TorrentURL)
+	assert.Equal(t, tmpFile, main.TorrentTmpFile)
+
+	assert.Equal(t, "https://mock.example.test/dl/1?passkey=seedbox", seedbox.TorrentURL)
+	assert.Equal(t, "seedbox", seedbox.IndexerAccount)
+	assert.Empty(t, seedbox.TorrentTmpFile, "the file has the passkey of the main account")
+	assert.FileExists(t, tmpFile)
+}
+
+type mockFilterService struct {
+	filter.Service
+	filters []domain.Filter
+}
+
+func (m mockFilterService) FindAndCheckFilters(release *domain.Release) ([]domain.Filter, error) {
+	return m.filters, nil
+}
+
+type mockReleaseService struct {
+	release.Service
+	processed []domain.Release
+}
+
+func (m *mockReleaseService) Store(ctx context.Context, release *domain.Release) error {
+	return nil
+}
+
+func (m *mockReleaseService) Process(release domain.Release) error {
+	m.processed = append(m.processed, release)
+	return nil
+}
+
+func Test_baseJob_processRelease_sharedFile(t *testing.T) {
+	def := domain.IndexerDefinition{
+		Identifier:  "mock",
+		SettingsMap: map[string]string{"passkey": "main"},
+		Accounts:    []domain.IndexerAccount{{Name: "seedbox", Enabled: true, Settings: map[string]string{"passkey": "seedbox"}}},
+	}
+
+	// all-match mode, the first filter grabs with another account
+	filters := []domain.Filter{
+		{ID: 1, Name: "seedbox", IndexerAccounts: map[string]string{"mock": "seedbox"}},
+		{ID: 2, Name: "main", IndexerAccounts: map[string]string{"mock": domain.IndexerAccountDefault}},
+	}
+
+	releases := &mockReleaseService{}
+	j := &baseJob{Name: "mock feed", IndexerIdentifier: "mock", indexerSvc: mockIndexerService{def: def}, filterSvc: mockFilterService{filters: filters}, releaseSvc: releases}
+
+	tmpFile := filepath.Join(t.TempDir(), "size-check.torrent")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte("d4:infode"), 0644))
+
+	j.processRelease(&domain.Release{TorrentName: "That Movie 2021", TorrentURL: "https://mock.example.test/dl/1?passkey=main", TorrentTmpFile: tmpFile})
+
+	if assert.Len(t, releases.processed, 2) {
+		assert.Empty(t, releases.processed[0].TorrentTmpFile)
+		assert.Equal(t, tmpFile, releases.processed[1].TorrentTmpFile, "the second filter still grabs the size check download")
+	}
+	assert.FileExists(t, tmpFile)
+}
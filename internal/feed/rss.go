@@ -0,0 +1,399 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/release"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// maxFeedSize feeds larger than this are not read, a broken or hostile feed could otherwise use up all memory
+const maxFeedSize = 16 << 20
+
+type RSSJob struct {
+	baseJob
+
+	URL     string
+	Cookie  string
+	Headers map[string]string
+	Rules   domain.FeedParseRules
+
+	http *http.Client
+}
+
+func NewRSSJob(name string, indexerIdentifier string, url string, cookie string, headers map[string]string, rules domain.FeedParseRules, cacheBucket string, cacheRepo domain.FeedCacheRepo, filterSvc filter.Service, indexerSvc indexer.Service, releaseSvc release.Service) *RSSJob {
+	return &RSSJob{
+		baseJob: baseJob{
+			Name:              name,
+			IndexerIdentifier: indexerIdentifier,
+			CacheBucket:       cacheBucket,
+			cacheRepo:         cacheRepo,
+			filterSvc:         filterSvc,
+			indexerSvc:        indexerSvc,
+			releaseSvc:        releaseSvc,
+		},
+		URL:     url,
+		Cookie:  cookie,
+		Headers: headers,
+		Rules:   rules,
+		http: &http.Client{
+			Timeout: time.Second * 20,
+		},
+	}
+}
+
+func (j *RSSJob) Run() {
+	if err := j.process(); err != nil {
+		log.Error().Err(err).Msgf("rss.Run: %v: process job failed", j.Name)
+	}
+}
+
+func (j *RSSJob) process() error {
+	// the patterns were checked when the feed was saved, compile them once per run
+	rules, err := compileParseRules(j.Rules)
+	if err != nil {
+		return fmt.Errorf("rss.process: invalid parse rules: %w", err)
+	}
+
+	items, err := j.getFeed(rules)
+	if err != nil {
+		log.Error().Err(err).Msgf("rss.process: %v: error fetching feed items", j.Name)
+		return fmt.Errorf("rss.process: error getting feed items: %w", err)
+	}
+
+	log.Debug().Msgf("rss.process: %v: refreshing feed, found (%d) new items", j.Name, len(items))
+
+	for _, item := range items {
+		rls, err := j.mapRelease(item, rules)
+		if err != nil {
+			log.Error().Err(err).Msgf("rss.process: %v: could not map item: %v", j.Name, item.Title)
+			continue
+		}
+
+		j.processRelease(rls)
+	}
+
+	return nil
+}
+
+// mapRelease turn a feed item into a release using the feed parse rules
+func (j *RSSJob) mapRelease(item rssItem, rules parseRules) (*domain.Release, error) {
+	rls, err := domain.NewRelease(j.IndexerIdentifier, item.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	rls.Implementation = domain.ReleaseImplementationRSS
+	rls.TorrentName = item.Title
+	rls.SetDownloadURL(item.downloadURL(rules))
+	rls.Size = item.size(rules)
+	rls.Category = item.category(rules)
+
+	if rls.DownloadLink() == "" {
+		return nil, errors.Errorf("no download url found for: %v", item.Title)
+	}
+
+	if err := rls.Parse(); err != nil {
+		return nil, err
+	}
+
+	return rls, nil
+}
+
+// getFeed fetch the feed and return only items not seen before
+func (j *RSSJob) getFeed(rules parseRules) ([]rssItem, error) {
+	req, err := http.NewRequest(http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not build request")
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+
+	if j.Cookie != "" {
+		req.Header.Set("Cookie", j.Cookie)
+	}
+
+	for k, v := range j.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := j.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not make request")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code: %v", res.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(res.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "rss.io.Copy")
+	}
+
+	if n > maxFeedSize {
+		return nil, errors.Errorf("feed is larger than %v bytes", maxFeedSize)
+	}
+
+	feedItems, err := parseRSS(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace().Msgf("rss.getFeed: %v: refreshing feed, found (%d) items", j.Name, len(feedItems))
+
+	items := make([]rssItem, 0)
+	for _, i := range feedItems {
+		key := i.GUID
+		if key == "" {
+			key = i.downloadURL(rules)
+		}
+
+		if j.isNew(key, i.Title) {
+			items = append(items, i)
+		}
+	}
+
+	return items, nil
+}
+
+// parseRules the feed parse rules with their patterns compiled, nil when a pattern is not set
+type parseRules struct {
+	downloadURLSource  string
+	downloadURLPattern *regexp.Regexp
+	sizePattern        *regexp.Regexp
+	categoryPattern    *regexp.Regexp
+}
+
+func compileParseRules(rules domain.FeedParseRules) (parseRules, error) {
+	compiled := parseRules{downloadURLSource: rules.DownloadURLSource}
+
+	for _, p := range []struct {
+		pattern string
+		rxp     **regexp.Regexp
+	}{
+		{rules.DownloadURLPattern, &compiled.downloadURLPattern},
+		{rules.SizePattern, &compiled.sizePattern},
+		{rules.CategoryPattern, &compiled.categoryPattern},
+	} {
+		if p.pattern == "" {
+			continue
+		}
+
+		rxp, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return compiled, err
+		}
+		*p.rxp = rxp
+	}
+
+	return compiled, nil
+}
+
+// rssItem common item for both rss and atom feeds
+type rssItem struct {
+	Title       string
+	Link        string
+	GUID        string
+	Description string
+	Categories  []string
+	Enclosure   string
+	Length      string
+}
+
+type rssFeed struct {
+	Channel struct {
+		Items []struct {
+			Title       string   `xml:"title"`
+			Link        string   `xml:"link"`
+			GUID        string   `xml:"guid"`
+			Description string   `xml:"description"`
+			Categories  []string `xml:"category"`
+			Enclosure   struct {
+				URL    string `xml:"url,attr"`
+				Length string `xml:"length,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href   string `xml:"href,attr"`
+			Rel    string `xml:"rel,attr"`
+			Length string `xml:"length,attr"`
+		} `xml:"link"`
+		Summary    string `xml:"summary"`
+		Content    string `xml:"content"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+// parseRSS parse rss 2.0 and atom feeds into items
+func parseRSS(data []byte) ([]rssItem, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, errors.Wrap(err, "rss: could not decode feed")
+	}
+
+	items := make([]rssItem, 0)
+
+	switch root.XMLName.Local {
+	case "rss":
+		var feed rssFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, errors.Wrap(err, "rss: could not decode feed")
+		}
+
+		for _, i := range feed.Channel.Items {
+			items = append(items, rssItem{
+				Title:       strings.TrimSpace(i.Title),
+				Link:        strings.TrimSpace(i.Link),
+				GUID:        strings.TrimSpace(i.GUID),
+				Description: i.Description,
+				Categories:  i.Categories,
+				Enclosure:   i.Enclosure.URL,
+				Length:      i.Enclosure.Length,
+			})
+		}
+
+	case "feed":
+		var feed atomFeed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, errors.Wrap(err, "atom: could not decode feed")
+		}
+
+		for _, e := range feed.Entries {
+			item := rssItem{
+				Title:       strings.TrimSpace(e.Title),
+				GUID:        strings.TrimSpace(e.ID),
+				Description: e.Summary,
+			}
+
+			if item.Description == "" {
+				item.Description = e.Content
+			}
+
+			for _, l := range e.Links {
+				switch l.Rel {
+				case "enclosure":
+					item.Enclosure = l.Href
+					item.Length = l.Length
+				case "", "alternate":
+					item.Link = l.Href
+				}
+			}
+
+			for _, c := range e.Categories {
+				item.Categories = append(item.Categories, c.Term)
+			}
+
+			items = append(items, item)
+		}
+
+	default:
+		return nil, errors.Errorf("unsupported feed format: %v", root.XMLName.Local)
+	}
+
+	return items, nil
+}
+
+// downloadURL get download url from the configured source, defaults to enclosure then link
+func (i rssItem) downloadURL(rules parseRules) string {
+	if rules.downloadURLPattern != nil {
+		return matchPattern(rules.downloadURLPattern, i.Description)
+	}
+
+	switch rules.downloadURLSource {
+	case "link":
+		return i.Link
+	case "guid":
+		return i.GUID
+	case "enclosure":
+		return i.Enclosure
+	}
+
+	if i.Enclosure != "" {
+		return i.Enclosure
+	}
+
+	return i.Link
+}
+
+// size get size from pattern if set, otherwise from enclosure length
+func (i rssItem) size(rules parseRules) uint64 {
+	if rules.sizePattern != nil {
+		match := matchPattern(rules.sizePattern, i.Title+"\n"+i.Description)
+		if match == "" {
+			return 0
+		}
+
+		size, err := humanize.ParseBytes(match)
+		if err != nil {
+			return 0
+		}
+
+		return size
+	}
+
+	if i.Length != "" {
+		size, err := strconv.ParseUint(i.Length, 10, 64)
+		if err == nil {
+			return size
+		}
+	}
+
+	return 0
+}
+
+// category get category from pattern if set, otherwise the first item category
+func (i rssItem) category(rules parseRules) string {
+	if rules.categoryPattern != nil {
+		return matchPattern(rules.categoryPattern, i.Title+"\n"+i.Description)
+	}
+
+	if len(i.Categories) > 0 {
+		return strings.TrimSpace(i.Categories[0])
+	}
+
+	return ""
+}
+
+// matchPattern return first capture group, or the full match if the pattern has none
+func matchPattern(rxp *regexp.Regexp, value string) string {
+	matches := rxp.FindStringSubmatch(value)
+	if matches == nil {
+		return ""
+	}
+
+	if len(matches) > 1 {
+		return strings.TrimSpace(matches[1])
+	}
+
+	return strings.TrimSpace(matches[0])
+}
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Mock Tracker</title>
+    <item>
+      <title>That Show S01E01 1080p WEB H264-GROUP</title>
+      <link>https://example.test/details/1</link>
+      <guid>https://example.test/details/1</guid>
+      <description>Category: TV/HD - Size: 1.5 GiB - Download: https://example.test/dl/1.torrent?key=abc</description>
+      <category>TV</category>
+      <enclosure url="https://example.test/download/1.torrent" length="1610612736" type="application/x-bittorrent" />
+    </item>
+  </channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Mock Tracker</title>
+  <entry>
+    <title>That Movie 2020 1080p BluRay x264-GROUP</title>
+    <id>urn:mock:2</id>
+    <link href="https://example.test/details/2" />
+    <link rel="enclosure" href="https://example.test/download/2.torrent" length="8589934592" />
+    <summary>Size: 8 GiB</summary>
+    <category term="Movies" />
+  </entry>
+</feed>`
+
+func Test_parseRSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []rssItem
+		wantErr bool
+	}{
+		{
+			name: "rss",
+			data: testRSSFeed,
+			want: []rssItem{
+				{
+					Title:       "That Show S01E01 1080p WEB H264-GROUP",
+					Link:        "https://example.test/details/1",
+					GUID:        "https://example.test/details/1",
+					Description: "Category: TV/HD - Size: 1.5 GiB - Download: https://example.test/dl/1.torrent?key=abc",
+					Categories:  []string{"TV"},
+					Enclosure:   "https://example.test/download/1.torrent",
+					Length:      "1610612736",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "atom",
+			data: testAtomFeed,
+			want: []rssItem{
+				{
+					Title:       "That Movie 2020 1080p BluRay x264-GROUP",
+					Link:        "https://example.test/details/2",
+					GUID:        "urn:mock:2",
+					Description: "Size: 8 GiB",
+					Categories:  []string{"Movies"},
+					Enclosure:   "https://example.test/download/2.torrent",
+					Length:      "8589934592",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "unsupported",
+			data:    `<html><body>not a feed</body></html>`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRSS([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRSS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_rssItem_parseRules(t *testing.T) {
+	items, err := parseRSS([]byte(testRSSFeed))
+	assert.NoError(t, err)
+
+	item := items[0]
+
+	tests := []struct {
+		name         string
+		rules        domain.FeedParseRules
+		wantURL      string
+		wantSize     uint64
+		wantCategory string
+	}{
+		{
+			name:         "defaults",
+			rules:        domain.FeedParseRules{},
+			wantURL:      "https://example.test/download/1.torrent",
+			wantSize:     1610612736,
+			wantCategory: "TV",
+		},
+		{
+			name: "patterns",
+			rules: domain.FeedParseRules{
+				SizePattern:        `Size: ([\d.]+ [KMGT]i?B)`,
+				CategoryPattern:    `Category: (\S+)`,
+				DownloadURLPattern: `Download: (\S+)`,
+			},
+			wantURL:      "https://example.test/dl/1.torrent?key=abc",
+			wantSize:     1610612736,
+			wantCategory: "TV/HD",
+		},
+		{
+			name: "link source",
+			rules: domain.FeedParseRules{
+				DownloadURLSource: "link",
+			},
+			wantURL:      "https://example.test/details/1",
+			wantSize:     1610612736,
+			wantCategory: "TV",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := compileParseRules(tt.rules)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantURL, item.downloadURL(rules))
+			assert.Equal(t, tt.wantSize, item.size(rules))
+			assert.Equal(t, tt.wantCategory, item.category(rules))
+		})
+	}
+}
+
+func Test_compileParseRules(t *testing.T) {
+	_, err := compileParseRules(domain.FeedParseRules{SizePattern: `Size: (`})
+	assert.Error(t, err)
+
+	rules, err := compileParseRules(domain.FeedParseRules{DownloadURLSource: "guid"})
+	assert.NoError(t, err)
+	assert.Equal(t, parseRules{downloadURLSource: "guid"}, rules)
+}
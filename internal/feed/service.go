@@ -0,0 +1,257 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/release"
+	"github.com/autobrr/autobrr/internal/scheduler"
+	"github.com/autobrr/autobrr/pkg/torznab"
+
+	"github.com/rs/zerolog/log"
+)
+
+// minInterval lowest allowed polling interval in minutes
+const minInterval = 15
+
+type Service interface {
+	FindByID(ctx context.Context, id int) (*domain.Feed, error)
+	FindByIndexerIdentifier(ctx context.Context, indexer string) (*domain.Feed, error)
+	Find(ctx context.Context) ([]domain.Feed, error)
+	Store(ctx context.Context, feed *domain.Feed) error
+	Update(ctx context.Context, feed *domain.Feed) error
+	ToggleEnabled(ctx context.Context, id int, enabled bool) error
+	Delete(ctx context.Context, id int) error
+
+	Start() error
+}
+
+type service struct {
+	jobs map[string]int
+	m    sync.Mutex
+
+	repo       domain.FeedRepo
+	cacheRepo  domain.FeedCacheRepo
+	filterSvc  filter.Service
+	indexerSvc indexer.Service
+	releaseSvc release.Service
+	scheduler  scheduler.Service
+}
+
+func NewService(repo domain.FeedRepo, cacheRepo domain.FeedCacheRepo, filterSvc filter.Service, indexerSvc indexer.Service, releaseSvc release.Service, scheduler scheduler.Service) Service {
+	return &service{
+		jobs:       map[string]int{},
+		repo:       repo,
+		cacheRepo:  cacheRepo,
+		filterSvc:  filterSvc,
+		indexerSvc: indexerSvc,
+		releaseSvc: releaseSvc,
+		scheduler:  scheduler,
+	}
+}
+
+func (s *service) FindByID(ctx context.Context, id int) (*domain.Feed, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *service) FindByIndexerIdentifier(ctx context.Context, indexer string) (*domain.Feed, error) {
+	return s.repo.FindByIndexerIdentifier(ctx, indexer)
+}
+
+func (s *service) Find(ctx context.Context) ([]domain.Feed, error) {
+	return s.repo.Find(ctx)
+}
+
+func (s *service) Store(ctx context.Context, feed *domain.Feed) error {
+	if err := validateFeed(feed); err != nil {
+		return err
+	}
+
+	if err := s.repo.Store(ctx, feed); err != nil {
+		log.Error().Err(err).Msgf("feed.Store: error storing feed: %+v", feed)
+		return err
+	}
+
+	return s.restartJob(ctx, feed.ID)
+}
+
+func (s *service) Update(ctx context.Context, feed *domain.Feed) error {
+	if err := validateFeed(feed); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, feed); err != nil {
+		log.Error().Err(err).Msgf("feed.Update: error updating feed: %+v", feed)
+		return err
+	}
+
+	return s.restartJob(ctx, feed.ID)
+}
+
+func (s *service) ToggleEnabled(ctx context.Context, id int, enabled bool) error {
+	if err := s.repo.ToggleEnabled(ctx, id, enabled); err != nil {
+		log.Error().Err(err).Msgf("feed.ToggleEnabled: error toggle enabled: %v", id)
+		return err
+	}
+
+	return s.restartJob(ctx, id)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	if err := s.stopJob(id); err != nil {
+		log.Error().Err(err).Msgf("feed.Delete: error stopping job: %v", id)
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		log.Error().Err(err).Msgf("feed.Delete: error deleting feed: %v", id)
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) Start() error {
+	// get all feeds and start the enabled ones
+	feeds, err := s.repo.Find(context.TODO())
+	if err != nil {
+		log.Error().Err(err).Msg("feed.Start: error getting feeds")
+		return err
+	}
+
+	for _, feed := range feeds {
+		if err := s.startJob(feed); err != nil {
+			log.Error().Err(err).Msgf("feed.Start: failed to initialize feed: %v", feed.Name)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// restartJob stop any running job for the feed and start it again if it is enabled
+func (s *service) restartJob(ctx context.Context, id int) error {
+	if err := s.stopJob(id); err != nil {
+		return err
+	}
+
+	feed, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Msgf("feed.restartJob: could not find feed: %v", id)
+		return err
+	}
+
+	return s.startJob(*feed)
+}
+
+func (s *service) startJob(f domain.Feed) error {
+	// if it's not enabled we should not start it
+	if !f.Enabled {
+		return nil
+	}
+
+	// a feed without url can not be polled
+	if f.URL == "" {
+		return fmt.Errorf("no feed url provided for feed: %v", f.Name)
+	}
+
+	interval := f.Interval
+	if interval < minInterval {
+		interval = minInterval
+	}
+
+	identifier := feedJobIdentifier(f.ID)
+
+	var job scheduler.Job
+
+	switch domain.FeedType(f.Type) {
+	case domain.FeedTypeTorznab:
+		job = NewTorznabJob(f.Name, f.Indexer, torznab.NewClient(f.URL, f.ApiKey), identifier, s.cacheRepo, s.filterSvc, s.indexerSvc, s.releaseSvc)
+
+	case domain.FeedTypeNewznab:
+		job = NewNewznabJob(f.Name, f.Indexer, torznab.NewClient(f.URL, f.ApiKey), identifier, s.cacheRepo, s.filterSvc, s.indexerSvc, s.releaseSvc)
+
+	case domain.FeedTypeRSS:
+		job = NewRSSJob(f.Name, f.Indexer, f.URL, f.Cookie, f.Headers, f.ParseRules, identifier, s.cacheRepo, s.filterSvc, s.indexerSvc, s.releaseSvc)
+
+	default:
+		return fmt.Errorf("unsupported feed type: %v", f.Type)
+	}
+
+	id, err := s.scheduler.AddJob(job, time.Duration(interval)*time.Minute, identifier)
+	if err != nil {
+		log.Error().Err(err).Msgf("feed.startJob: failed to add job: %v", f.Name)
+		return err
+	}
+
+	s.m.Lock()
+	s.jobs[identifier] = id
+	s.m.Unlock()
+
+	log.Debug().Msgf("feed.startJob: successfully started feed: %v every %d minutes", f.Name, interval)
+
+	return nil
+}
+
+func (s *service) stopJob(id int) error {
+	identifier := feedJobIdentifier(id)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	jobID, ok := s.jobs[identifier]
+	if !ok {
+		return nil
+	}
+
+	if err := s.scheduler.RemoveJobByID(jobID); err != nil {
+		log.Error().Err(err).Msgf("feed.stopJob: failed to remove job: %v", identifier)
+		return err
+	}
+
+	delete(s.jobs, identifier)
+
+	return nil
+}
+
+func feedJobIdentifier(id int) string {
+	return fmt.Sprintf("feed-%d", id)
+}
+
+func validateFeed(feed *domain.Feed) error {
+	if feed.Name == "" {
+		return errors.New("feed: name is required")
+	}
+
+	if feed.URL == "" {
+		return errors.New("feed: url is required")
+	}
+
+	if feed.Type == "" {
+		feed.Type = string(domain.FeedTypeTorznab)
+	}
+
+	// catch broken parse rules before the job starts failing silently
+	for _, pattern := range []string{feed.ParseRules.SizePattern, feed.ParseRules.CategoryPattern, feed.ParseRules.DownloadURLPattern} {
+		if pattern == "" {
+			continue
+		}
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("feed: invalid parse rule pattern %q: %w", pattern, err)
+		}
+	}
+
+	if feed.Interval == 0 {
+		feed.Interval = minInterval
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package feed
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
+	"github.com/autobrr/autobrr/internal/release"
+	"github.com/autobrr/autobrr/pkg/torznab"
+
+	"github.com/rs/zerolog/log"
+)
+
+type TorznabJob struct {
+	baseJob
+
+	Client torznab.Client
+
+	implementation domain.ReleaseImplementation
+	protocol       domain.ReleaseProtocol
+}
+
+func NewTorznabJob(name string, indexerIdentifier string, client torznab.Client, cacheBucket string, cacheRepo domain.FeedCacheRepo, filterSvc filter.Service, indexerSvc indexer.Service, releaseSvc release.Service) *TorznabJob {
+	return &TorznabJob{
+		baseJob: baseJob{
+			Name:              name,
+			IndexerIdentifier: indexerIdentifier,
+			CacheBucket:       cacheBucket,
+			cacheRepo:         cacheRepo,
+			filterSvc:         filterSvc,
+			indexerSvc:        indexerSvc,
+			releaseSvc:        releaseSvc,
+		},
+		Client:         client,
+		implementation: domain.ReleaseImplementationTorznab,
+		protocol:       domain.ReleaseProtocolTorrent,
+	}
+}
+
+// NewNewznabJob newznab shares the torznab api but the items are nzb files
+func NewNewznabJob(name string, indexerIdentifier string, client torznab.Client, cacheBucket string, cacheRepo domain.FeedCacheRepo, filterSvc filter.Service, indexerSvc indexer.Service, releaseSvc release.Service) *TorznabJob {
+	job := NewTorznabJob(name, indexerIdentifier, client, cacheBucket, cacheRepo, filterSvc, indexerSvc, releaseSvc)
+	job.implementation = domain.ReleaseImplementationNewznab
+	job.protocol = domain.ReleaseProtocolNzb
+
+	return job
+}
+
+func (j *TorznabJob) Run() {
+	if err := j.process(); err != nil {
+		log.Error().Err(err).Msgf("torznab.Run: %v: process job failed", j.Name)
+	}
+}
+
+func (j *TorznabJob) process() error {
+	// get feed
+	items, err := j.getFeed()
+	if err != nil {
+		log.Error().Err(err).Msgf("torznab.process: %v: error fetching feed items", j.Name)
+		return fmt.Errorf("torznab.process: error getting feed items: %w", err)
+	}
+
+	log.Debug().Msgf("torznab.process: %v: refreshing feed, found (%d) new items", j.Name, len(items))
+
+	for _, item := range items {
+		rls, err := j.mapRelease(item)
+		if err != nil {
+			log.Error().Err(err).Msgf("torznab.process: %v: could not map item: %v", j.Name, item.Title)
+			continue
+		}
+
+		j.processRelease(rls)
+	}
+
+	return nil
+}
+
+// mapRelease turn a feed item into a release ready for filtering
+func (j *TorznabJob) mapRelease(item torznab.FeedItem) (*domain.Release, error) {
+	rls, err := domain.NewRelease(j.IndexerIdentifier, item.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	rls.Implementation = j.implementation
+	rls.Protocol = j.protocol
+	rls.TorrentName = item.Title
+
+	if j.protocol == domain.ReleaseProtocolNzb {
+		rls.TorrentURL = item.DownloadURL()
+	} else {
+		rls.SetDownloadURL(item.DownloadURL())
+	}
+	rls.Size = item.SizeBytes()
+
+	if len(item.Category) > 0 {
+		rls.Category = strconv.Itoa(item.Category[0])
+	}
+
+	// downloadvolumefactor 0 is freeleech, 0.5 is half leech
+	if factor := item.Attr("downloadvolumefactor"); factor != "" {
+		if f, err := strconv.ParseFloat(factor, 64); err == nil && f < 1 {
+			rls.Freeleech = true
+			rls.FreeleechPercent = int((1 - f) * 100)
+		}
+	}
+
+	if err := rls.Parse(); err != nil {
+		return nil, err
+	}
+
+	return rls, nil
+}
+
+// getFeed fetch the feed and return only items not seen before
+func (j *TorznabJob) getFeed() ([]torznab.FeedItem, error) {
+	feedItems, err := j.Client.GetFeed()
+	if err != nil {
+		log.Error().Err(err).Msgf("torznab.getFeed: %v: error fetching feed items", j.Name)
+		return nil, err
+	}
+
+	log.Trace().Msgf("torznab getFeed: %v: refreshing feed, found (%d) items", j.Name, len(feedItems))
+
+	items := make([]torznab.FeedItem, 0)
+	for _, i := range feedItems {
+		key := i.GUID
+		if key == "" {
+			key = i.DownloadURL()
+		}
+
+		// only append if we successfully added to cache
+		if j.isNew(key, i.Title) {
+			items = append(items, i)
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,177 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// Export the filters with their actions and indexers as portable json, every filter when no ids are given
+func (s *service) Export(ctx context.Context, filterIDs []int) (*domain.FilterExport, error) {
+	if len(filterIDs) == 0 {
+		filters, err := s.repo.ListFilters(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range filters {
+			filterIDs = append(filterIDs, f.ID)
+		}
+	}
+
+	export := &domain.FilterExport{Version: domain.FilterExportVersion, Filters: []domain.Filter{}}
+
+	for _, id := range filterIDs {
+		f, err := s.FindByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("could not find filter %v: %w", id, err)
+		}
+
+		export.Filters = append(export.Filters, f.Portable())
+	}
+
+	return export, nil
+}
+
+// Import store the exported filters. Imported filters are disabled until the user has checked the actions,
+// conflict decides what happens with filters that have the name of an existing filter.
+func (s *service) Import(ctx context.Context, data domain.FilterExport, conflict domain.FilterImportConflict) ([]domain.FilterImportResult, error) {
+	if data.Version > domain.FilterExportVersion {
+		return nil, fmt.Errorf("export version %v is newer than supported version %v", data.Version, domain.FilterExportVersion)
+	}
+
+	existing, err := s.repo.ListFilters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]domain.Filter, len(existing))
+	taken := make(map[string]bool, len(existing))
+	for _, f := range existing {
+		byName[strings.ToLower(f.Name)] = f
+		taken[strings.ToLower(f.Name)] = true
+	}
+
+	indexers, err := s.indexerSvc.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byIdentifier := make(map[string]domain.Indexer, len(indexers))
+	for _, indexer := range indexers {
+		byIdentifier[indexer.Identifier] = indexer
+	}
+
+	results := make([]domain.FilterImportResult, 0, len(data.Filters))
+
+	for _, f := range data.Filters {
+		result := domain.FilterImportResult{Name: f.Name}
+
+		// ids and secrets in the file are never trusted
+		f = f.Portable()
+
+		// indexers are matched by identifier, the ones not set up here are left out
+		var filterIndexers []domain.Indexer
+		for _, indexer := range f.Indexers {
+			if local, ok := byIdentifier[indexer.Identifier]; ok {
+				filterIndexers = append(filterIndexers, local)
+			}
+		}
+		f.Indexers = filterIndexers
+
+		if err := validateImport(f); err != nil {
+			result.Status = domain.FilterImportFailed
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		current, exists := byName[strings.ToLower(f.Name)]
+
+		switch {
+		case exists && conflict == domain.FilterImportSkip:
+			result.Status = domain.FilterImportSkipped
+			result.ID = current.ID
+
+		case exists && conflict == domain.FilterImportOverwrite:
+			err = s.importOverwrite(ctx, f, current)
+			result.Status = domain.FilterImportOverwritten
+			result.ID = current.ID
+
+		default:
+			result.Status = domain.FilterImportCreated
+			if exists {
+				f.Name = domain.UniqueFilterName(f.Name, taken)
+				result.Status = domain.FilterImportRenamed
+			}
+
+			var created *domain.Filter
+			if created, err = s.importCreate(ctx, f); err == nil {
+				result.ID = created.ID
+				taken[strings.ToLower(created.Name)] = true
+				byName[strings.ToLower(created.Name)] = *created
+			}
+		}
+
+		if err != nil {
+			log.Error().Err(err).Msgf("filter.import: could not import filter: %v", result.Name)
+			result.Status = domain.FilterImportFailed
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	log.Debug().Msgf("filter.import: imported %v filters", len(results))
+
+	return results, nil
+}
+
+func validateImport(f domain.Filter) error {
+	if f.Name == "" {
+		return fmt.Errorf("validation: name can't be empty")
+	}
+
+	return f.Validate()
+}
+
+// importCreate store a new disabled filter with its indexers and actions
+func (s *service) importCreate(ctx context.Context, f domain.Filter) (*domain.Filter, error) {
+	f.Enabled = false
+
+	filter, err := s.repo.Store(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.StoreIndexerConnections(ctx, filter.ID, f.Indexers); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.actionRepo.StoreFilterActions(ctx, f.Actions, int64(filter.ID)); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// importOverwrite replace the existing filter and disable it, the file can bring new commands or webhooks.
+// Actions keep their download client.
+func (s *service) importOverwrite(ctx context.Context, f domain.Filter, current domain.Filter) error {
+	existingActions, err := s.actionRepo.FindByFilterID(ctx, current.ID)
+	if err != nil {
+		return err
+	}
+
+	f.ID = current.ID
+	f.Enabled = false
+	f.Actions = domain.RestoreActionSecrets(f.Actions, existingActions)
+
+	_, err = s.Update(ctx, f)
+
+	return err
+}
@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// checkQuotas returns why the filter or global quota does not allow grabbing the release, or an empty string if it does
+func (s *service) checkQuotas(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+	if quota := f.Quota(); quota.Enabled() {
+		usage, err := s.quotaUsage(ctx, f.ID, quota)
+		if err != nil {
+			return "", err
+		}
+
+		rejection, err := usage.Check(release.Size)
+		if err != nil || rejection != "" {
+			return rejection, err
+		}
+	}
+
+	if s.quota.Global.Enabled() {
+		usage, err := s.quotaUsage(ctx, 0, s.quota.Global)
+		if err != nil {
+			return "", err
+		}
+
+		rejection, err := usage.Check(release.Size)
+		if err != nil {
+			return "", err
+		}
+
+		if rejection != "" {
+			return "global " + rejection, nil
+		}
+	}
+
+	return "", nil
+}
+
+// quotaUsage counters of the current quota periods for a filter, or all filters when filterID is 0
+func (s *service) quotaUsage(ctx context.Context, filterID int, quota domain.Quota) (*domain.QuotaUsage, error) {
+	now := time.Now()
+	hourStart, dayStart := s.quota.Periods(now)
+	weekStart, monthStart := s.quota.CalendarPeriods(now)
+
+	usage := domain.QuotaUsage{
+		FilterID:   filterID,
+		Quota:      quota,
+		HourStart:  hourStart,
+		DayStart:   dayStart,
+		WeekStart:  weekStart,
+		MonthStart: monthStart,
+	}
+
+	var err error
+
+	usage.GrabsHour, _, err = s.quotaRepo.Usage(ctx, filterID, hourStart)
+	if err != nil {
+		return nil, fmt.Errorf("could not get hourly quota usage: %w", err)
+	}
+
+	usage.GrabsDay, usage.BytesDay, err = s.quotaRepo.Usage(ctx, filterID, dayStart)
+	if err != nil {
+		return nil, fmt.Errorf("could not get daily quota usage: %w", err)
+	}
+
+	// the longer periods are only counted when they have a limit
+	if quota.GrabsPerWeek > 0 {
+		if usage.GrabsWeek, _, err = s.quotaRepo.Usage(ctx, filterID, weekStart); err != nil {
+			return nil, fmt.Errorf("could not get weekly quota usage: %w", err)
+		}
+	}
+
+	if quota.GrabsPerMonth > 0 {
+		if usage.GrabsMonth, _, err = s.quotaRepo.Usage(ctx, filterID, monthStart); err != nil {
+			return nil, fmt.Errorf("could not get monthly quota usage: %w", err)
+		}
+	}
+
+	return &usage, nil
+}
+
+// QuotaStats usage of the global quota and of every filter with a quota
+func (s *service) QuotaStats(ctx context.Context) (*domain.QuotaStats, error) {
+	global, err := s.quotaUsage(ctx, 0, s.quota.Global)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := domain.QuotaStats{
+		Global:  *global,
+		Filters: []domain.QuotaUsage{},
+	}
+
+	filters, err := s.repo.ListFilters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range filters {
+		quota := f.Quota()
+		if !quota.Enabled() {
+			continue
+		}
+
+		usage, err := s.quotaUsage(ctx, f.ID, quota)
+		if err != nil {
+			return nil, err
+		}
+
+		usage.FilterName = f.Name
+
+		stats.Filters = append(stats.Filters, *usage)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/action"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	// maxConcurrentScripts external scripts running at once, other announces wait for a free slot
+	maxConcurrentScripts = 4
+
+	// defaultScriptTimeout used when the filter does not set a timeout
+	defaultScriptTimeout = 15 * time.Second
+
+	// maxScriptOutput bytes of script output kept for the rejection reason and output match, the rest is dropped
+	maxScriptOutput = 64 * 1024
+)
+
+// checkExternalScript run the filter script with the release vars.
+// Returns why the release is rejected, or an error if the script could not run.
+func (s *service) checkExternalScript(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+	cmd, err := exec.LookPath(f.ExternalScriptCmd)
+	if err != nil {
+		return "", fmt.Errorf("could not find program %v: %w", f.ExternalScriptCmd, err)
+	}
+
+	// the filter is not set on the release until it matches
+	rls := *release
+	rls.FilterName = f.Name
+
+	args, err := action.ParseExecArgs(action.NewMacro(rls), f.ExternalScriptArgs)
+	if err != nil {
+		return "", fmt.Errorf("could not parse arguments: %w", err)
+	}
+
+	timeout := defaultScriptTimeout
+	if f.ExternalScriptTimeout > 0 {
+		timeout = time.Duration(f.ExternalScriptTimeout) * time.Second
+	}
+
+	select {
+	case s.scriptSlots <- struct{}{}:
+		defer func() { <-s.scriptSlots }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedWriter{limit: maxScriptOutput}
+
+	// the script and whatever it started are killed on timeout, the slot is not held by leftover children
+	command := exec.Command(cmd, args...)
+
+	start := time.Now()
+	err = action.RunCommand(ctx, command, stdout, false)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("script timed out after %v", timeout)
+	}
+
+	status := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
+
+		status = exitErr.ExitCode()
+	}
+
+	log.Debug().Msgf("filter-service.external_script: (%v) %v exited with %d after %v", f.Name, cmd, status, time.Since(start))
+
+	return scriptRejection(f, status, strings.TrimSpace(stdout.buf.String()))
+}
+
+// limitedWriter keep the first bytes up to limit and drop the rest. Writes never fail so the script is not cut off by a broken pipe.
+type limitedWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		if len(p) > room {
+			w.buf.Write(p[:room])
+		} else {
+			w.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+// scriptRejection compare the exit code and output of the script with what the filter expects
+func scriptRejection(f domain.Filter, status int, output string) (string, error) {
+	if status != f.ExternalScriptStatus {
+		reason := fmt.Sprintf("external script exited with status %d, expected %d", status, f.ExternalScriptStatus)
+
+		// scripts can explain themselves on the first line
+		if line := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0]); line != "" {
+			reason += ": " + line
+		}
+
+		return reason, nil
+	}
+
+	if f.ExternalScriptOutput != "" {
+		rxp, err := domain.NewFilterRegex(f.ExternalScriptOutput)
+		if err != nil {
+			return "", err
+		}
+
+		if !rxp.MatchString(output) {
+			return fmt.Sprintf("external script output did not match %q", f.ExternalScriptOutput), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_service_checkExternalScript(t *testing.T) {
+	s := &service{scriptSlots: make(chan struct{}, 1)}
+	release := &domain.Release{TorrentName: "That Movie 2020 1080p BluRay x264-GROUP", Indexer: "mock"}
+
+	tests := []struct {
+		name    string
+		filter  domain.Filter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "exit_ok",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "exit 0"`},
+		},
+		{
+			name:   "exit_status",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "echo disk full && exit 1"`},
+			want:   "external script exited with status 1, expected 0: disk full",
+		},
+		{
+			name:   "expected_status",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "exit 3"`, ExternalScriptStatus: 3},
+		},
+		{
+			name:   "output_match",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "echo {{ .Indexer }} {{ .Filter }}"`, ExternalScriptOutput: "^mock movies$"},
+		},
+		{
+			name:   "output_no_match",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "echo {{ .TorrentName }}"`, ExternalScriptOutput: "2160p"},
+			want:   `external script output did not match "2160p"`,
+		},
+		{
+			name:    "timeout",
+			filter:  domain.Filter{Name: "movies", ExternalScriptCmd: "sleep", ExternalScriptArgs: "5", ExternalScriptTimeout: 1},
+			wantErr: true,
+		},
+		{
+			name:    "timeout_child_holds_output",
+			filter:  domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "sleep 10 & sleep 10"`, ExternalScriptTimeout: 1},
+			wantErr: true,
+		},
+		{
+			name:   "output_limited",
+			filter: domain.Filter{Name: "movies", ExternalScriptCmd: "sh", ExternalScriptArgs: `-c "echo too much; head -c 1000000 /dev/zero; exit 1"`},
+			want:   "external script exited with status 1, expected 0: too much",
+		},
+		{
+			name:    "missing_program",
+			filter:  domain.Filter{Name: "movies", ExternalScriptCmd: "autobrr-no-such-program"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			defer func() {
+				assert.Less(t, time.Since(start), 5*time.Second)
+			}()
+
+			got, err := s.checkExternalScript(context.Background(), tt.filter, release)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_limitedWriter(t *testing.T) {
+	w := &limitedWriter{limit: 5}
+
+	n, err := w.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = w.Write([]byte("defgh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	assert.Equal(t, "abcde", w.buf.String())
+}
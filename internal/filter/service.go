@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/rs/zerolog/log"
 
+	"github.com/autobrr/autobrr/internal/dedupe"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/indexer"
 )
@@ -15,28 +18,53 @@ import (
 type Service interface {
 	FindByID(ctx context.Context, filterID int) (*domain.Filter, error)
 	FindByIndexerIdentifier(indexer string) ([]domain.Filter, error)
-	FindAndCheckFilters(release *domain.Release) (bool, *domain.Filter, error)
+	FindAndCheckFilters(release *domain.Release) ([]domain.Filter, error)
 	ListFilters(ctx context.Context) ([]domain.Filter, error)
 	Store(ctx context.Context, filter domain.Filter) (*domain.Filter, error)
 	Update(ctx context.Context, filter domain.Filter) (*domain.Filter, error)
-	Duplicate(ctx context.Context, filterID int) (*domain.Filter, error)
+	Duplicate(ctx context.Context, filterID int, name string) (*domain.Filter, error)
 	ToggleEnabled(ctx context.Context, filterID int, enabled bool) error
+	PauseByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error)
+	ResumeByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error)
+	UpdateOrder(ctx context.Context, filterIDs []int) error
+	BulkUpdate(ctx context.Context, update domain.FilterBulkUpdate) error
+	RecordActionResult(ctx context.Context, status *domain.ReleaseActionStatus) error
 	Delete(ctx context.Context, filterID int) error
+	QuotaStats(ctx context.Context) (*domain.QuotaStats, error)
+	TestRegex(pattern string, samples []string) ([]domain.FilterRegexTestResult, error)
+	Validate(ctx context.Context, filter domain.Filter) (*domain.FilterDiagnostics, error)
+	Test(ctx context.Context, filterID int, params domain.FilterTestParams) ([]domain.FilterTestResult, error)
+	Export(ctx context.Context, filterIDs []int) (*domain.FilterExport, error)
+	Import(ctx context.Context, data domain.FilterExport, conflict domain.FilterImportConflict) ([]domain.FilterImportResult, error)
 }
 
 type service struct {
-	repo       domain.FilterRepo
-	actionRepo domain.ActionRepo
-	indexerSvc indexer.Service
-	apiService indexer.APIService
+	repo        domain.FilterRepo
+	actionRepo  domain.ActionRepo
+	releaseRepo domain.ReleaseRepo
+	quotaRepo   domain.QuotaRepo
+	quota       domain.QuotaSettings
+	matchMode   domain.FilterMatchMode
+	rules       domain.ReleaseRules
+	dedupeSvc   dedupe.Service
+	scriptSlots chan struct{}
+	indexerSvc  indexer.Service
+	apiService  indexer.APIService
 }
 
-func NewService(repo domain.FilterRepo, actionRepo domain.ActionRepo, apiService indexer.APIService, indexerSvc indexer.Service) Service {
+func NewService(repo domain.FilterRepo, actionRepo domain.ActionRepo, releaseRepo domain.ReleaseRepo, quotaRepo domain.QuotaRepo, quota domain.QuotaSettings, matchMode domain.FilterMatchMode, rules domain.ReleaseRules, dedupeSvc dedupe.Service, apiService indexer.APIService, indexerSvc indexer.Service) Service {
 	return &service{
-		repo:       repo,
-		actionRepo: actionRepo,
-		apiService: apiService,
-		indexerSvc: indexerSvc,
+		repo:        repo,
+		actionRepo:  actionRepo,
+		releaseRepo: releaseRepo,
+		quotaRepo:   quotaRepo,
+		quota:       quota,
+		matchMode:   matchMode,
+		rules:       rules,
+		dedupeSvc:   dedupeSvc,
+		scriptSlots: make(chan struct{}, maxConcurrentScripts),
+		apiService:  apiService,
+		indexerSvc:  indexerSvc,
 	}
 }
 
@@ -83,6 +111,7 @@ func (s *service) FindByID(ctx context.Context, filterID int) (*domain.Filter, e
 		return nil, err
 	}
 	filter.Indexers = indexers
+	filter.Warnings = filter.CheckWarnings()
 
 	return filter, nil
 }
@@ -100,6 +129,9 @@ func (s *service) FindByIndexerIdentifier(indexer string) ([]domain.Filter, erro
 
 func (s *service) Store(ctx context.Context, filter domain.Filter) (*domain.Filter, error) {
 	// validate data
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
 
 	// store
 	f, err := s.repo.Store(ctx, filter)
@@ -108,6 +140,8 @@ func (s *service) Store(ctx context.Context, filter domain.Filter) (*domain.Filt
 		return nil, err
 	}
 
+	f.Warnings = f.CheckWarnings()
+
 	return f, nil
 }
 
@@ -117,6 +151,10 @@ func (s *service) Update(ctx context.Context, filter domain.Filter) (*domain.Fil
 		return nil, errors.New("validation: name can't be empty")
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	// update
 	f, err := s.repo.Update(ctx, filter)
 	if err != nil {
@@ -138,18 +176,35 @@ func (s *service) Update(ctx context.Context, filter domain.Filter) (*domain.Fil
 	}
 
 	f.Actions = actions
+	f.Warnings = filter.CheckWarnings()
 
 	return f, nil
 }
 
-func (s *service) Duplicate(ctx context.Context, filterID int) (*domain.Filter, error) {
+// Duplicate copy the filter with its actions and indexers, disabled and named "<name> Copy" when no name is given
+func (s *service) Duplicate(ctx context.Context, filterID int, name string) (*domain.Filter, error) {
 	// find filter
 	baseFilter, err := s.repo.FindByID(ctx, filterID)
 	if err != nil {
 		return nil, err
 	}
+
+	if name == "" {
+		filters, err := s.repo.ListFilters(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		taken := make(map[string]bool, len(filters))
+		for _, f := range filters {
+			taken[strings.ToLower(f.Name)] = true
+		}
+
+		name = domain.UniqueFilterName(fmt.Sprintf("%v Copy", baseFilter.Name), taken)
+	}
+
 	baseFilter.ID = 0
-	baseFilter.Name = fmt.Sprintf("%v Copy", baseFilter.Name)
+	baseFilter.Name = name
 	baseFilter.Enabled = false
 
 	// find actions and attach
@@ -192,6 +247,44 @@ func (s *service) Duplicate(ctx context.Context, filterID int) (*domain.Filter,
 	return filter, nil
 }
 
+// TestRegex try a release name pattern against sample names before saving it on a filter
+func (s *service) TestRegex(pattern string, samples []string) ([]domain.FilterRegexTestResult, error) {
+	return domain.TryFilterRegex(pattern, samples)
+}
+
+// Validate check a filter before it is saved, including that its indexers exist and are enabled
+func (s *service) Validate(ctx context.Context, filter domain.Filter) (*domain.FilterDiagnostics, error) {
+	diagnostics := filter.Diagnose()
+
+	if len(filter.Indexers) == 0 {
+		return &diagnostics, nil
+	}
+
+	indexers, err := s.indexerSvc.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]domain.Indexer, len(indexers))
+	for _, indexer := range indexers {
+		byID[indexer.ID] = indexer
+	}
+
+	for _, indexer := range filter.Indexers {
+		existing, ok := byID[indexer.ID]
+		switch {
+		case !ok:
+			diagnostics.Errors = append(diagnostics.Errors, domain.FilterIssue{Field: "indexers", Message: fmt.Sprintf("indexer %v does not exist", indexer.ID)})
+		case !existing.Enabled:
+			diagnostics.Warnings = append(diagnostics.Warnings, domain.FilterIssue{Field: "indexers", Message: fmt.Sprintf("indexer %v is disabled", existing.Name)})
+		}
+	}
+
+	diagnostics.Valid = len(diagnostics.Errors) == 0
+
+	return &diagnostics, nil
+}
+
 func (s *service) ToggleEnabled(ctx context.Context, filterID int, enabled bool) error {
 	if err := s.repo.ToggleEnabled(ctx, filterID, enabled); err != nil {
 		log.Error().Err(err).Msg("could not update filter enabled")
@@ -203,6 +296,105 @@ func (s *service) ToggleEnabled(ctx context.Context, filterID int, enabled bool)
 	return nil
 }
 
+// PauseByIndexer disable the filters that only use indexer, filters with other indexers keep running
+func (s *service) PauseByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error) {
+	return s.repo.PauseByIndexer(ctx, indexer)
+}
+
+// ResumeByIndexer enable the filters PauseByIndexer disabled, unless they were enabled or disabled by hand since
+func (s *service) ResumeByIndexer(ctx context.Context, indexer string) ([]domain.Filter, error) {
+	return s.repo.ResumeByIndexer(ctx, indexer)
+}
+
+// UpdateOrder set filter priorities from the given order, the first filter gets the highest priority
+func (s *service) UpdateOrder(ctx context.Context, filterIDs []int) error {
+	seen := make(map[int]bool, len(filterIDs))
+	for _, id := range filterIDs {
+		if seen[id] {
+			return fmt.Errorf("filter %v is listed more than once", id)
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.UpdatePriorities(ctx, domain.FilterPriorities(filterIDs)); err != nil {
+		log.Error().Err(err).Msg("could not update filter order")
+		return err
+	}
+
+	log.Debug().Msgf("filter.update_order: updated order of %v filters", len(filterIDs))
+
+	return nil
+}
+
+// BulkUpdate apply the operation to several filters. Filters and indexers are looked up before anything is changed.
+func (s *service) BulkUpdate(ctx context.Context, update domain.FilterBulkUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+
+	for _, id := range update.FilterIDs {
+		if _, err := s.repo.FindByID(ctx, id); err != nil {
+			return fmt.Errorf("could not find filter %v: %w", id, err)
+		}
+	}
+
+	var indexers []domain.Indexer
+	if update.Operation == domain.FilterBulkSetIndexers {
+		var err error
+		if indexers, err = s.findIndexers(update.IndexerIDs); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range update.FilterIDs {
+		var err error
+
+		switch update.Operation {
+		case domain.FilterBulkEnable:
+			err = s.ToggleEnabled(ctx, id, true)
+		case domain.FilterBulkDisable:
+			err = s.ToggleEnabled(ctx, id, false)
+		case domain.FilterBulkDelete:
+			err = s.Delete(ctx, id)
+		case domain.FilterBulkSetIndexers:
+			err = s.repo.StoreIndexerConnections(ctx, id, indexers)
+		}
+
+		if err != nil {
+			log.Error().Err(err).Msgf("filter.bulk_update: could not %v filter: %v", update.Operation, id)
+			return fmt.Errorf("could not %v filter %v: %w", update.Operation, id, err)
+		}
+	}
+
+	log.Debug().Msgf("filter.bulk_update: %v %v filters", update.Operation, len(update.FilterIDs))
+
+	return nil
+}
+
+// findIndexers the configured indexers with the ids, an unknown id is an error
+func (s *service) findIndexers(indexerIDs []int) ([]domain.Indexer, error) {
+	all, err := s.indexerSvc.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]domain.Indexer, len(all))
+	for _, indexer := range all {
+		byID[indexer.ID] = indexer
+	}
+
+	indexers := make([]domain.Indexer, 0, len(indexerIDs))
+	for _, id := range indexerIDs {
+		indexer, ok := byID[int64(id)]
+		if !ok {
+			return nil, &domain.FilterValidationError{Field: "indexer_ids", Err: fmt.Errorf("unknown indexer %v", id)}
+		}
+		indexers = append(indexers, indexer)
+	}
+
+	return indexers, nil
+}
+
 func (s *service) Delete(ctx context.Context, filterID int) error {
 	if filterID == 0 {
 		return nil
@@ -229,23 +421,71 @@ func (s *service) Delete(ctx context.Context, filterID int) error {
 	return nil
 }
 
-func (s *service) FindAndCheckFilters(release *domain.Release) (bool, *domain.Filter, error) {
+// FindAndCheckFilters check the release against the enabled filters of its indexer by priority.
+// Returns the first matching filter, or every matching filter when the match mode is all.
+func (s *service) FindAndCheckFilters(release *domain.Release) ([]domain.Filter, error) {
+	// a torrent file downloaded for a size check counts as fetch time, not filter time
+	started := time.Now()
+	fetched := release.Timings.Fetch
+	defer func() {
+		release.Timings.Filter = time.Since(started).Microseconds() - (release.Timings.Fetch - fetched)
+	}()
+
 	// find all enabled filters for indexer
 	filters, err := s.repo.FindByIndexerIdentifier(release.Indexer)
 	if err != nil {
 		log.Error().Err(err).Msgf("filter-service.find_and_check_filters: could not find filters for indexer: %v", release.Indexer)
-		return false, nil, err
+		return nil, err
 	}
 
 	log.Trace().Msgf("filter-service.find_and_check_filters: found (%d) active filters to check for indexer '%v'", len(filters), release.Indexer)
 
+	// the global lists apply to every filter
+	if len(filters) > 0 && s.rules.Enabled() {
+		if rejection := s.rules.Check(release); rejection != "" {
+			log.Debug().Msgf("filter-service.find_and_check_filters: %v: %v", rejection, release.TorrentName)
+			release.Rejections = []string{rejection}
+			return nil, nil
+		}
+	}
+
+	// the indexer api is asked at most once per release
+	var enriched bool
+
 	// save outside of loop to check multiple filters with only one fetch
 	var torrentInfo *domain.TorrentBasic
 
+	// reasons per filter, kept on the release if nothing matches
+	var rejections []string
+
+	var matched []domain.Filter
+
+	// ids for the filter stats
+	var evaluatedIDs, matchedIDs []int
+	defer func() {
+		s.recordChecks(evaluatedIDs, matchedIDs)
+	}()
+
 	// loop and check release to filter until match
 	for _, f := range filters {
 		log.Trace().Msgf("filter-service.find_and_check_filters: checking filter: %+v", f.Name)
 
+		evaluatedIDs = append(evaluatedIDs, f.ID)
+
+		// size limits can differ per indexer
+		f = f.ForIndexer(release.Indexer)
+
+		reject := func(reason string) {
+			rejections = append(rejections, fmt.Sprintf("%v: %v", f.Name, reason))
+		}
+
+		// some announces lack data needed for filtering, fill it in from the indexer api when enabled in the definition.
+		// The api is slow and rate limited, only ask it when the rest of the filter already matches.
+		if !enriched && release.MissingMetadata(f) && release.CheckFilter(f.WithoutMetadataChecks()) {
+			enriched = true
+			s.enrichRelease(release)
+		}
+
 		matchedFilter := release.CheckFilter(f)
 		if matchedFilter {
 			// if matched, do additional size check if needed, attach actions and return the filter
@@ -260,57 +500,70 @@ func (s *service) FindAndCheckFilters(release *domain.Release) (bool, *domain.Fi
 			if release.AdditionalSizeCheckRequired {
 				log.Debug().Msgf("filter-service.find_and_check_filters: (%v) additional size check required", f.Name)
 
-				// check if indexer = btn, ptp, ggn or red
-				if release.Indexer == "ptp" || release.Indexer == "btn" || release.Indexer == "ggn" || release.Indexer == "redacted" {
-					// fetch torrent info from api
-					// save outside of loop to check multiple filters with only one fetch
-					if torrentInfo == nil {
-						torrentInfo, err = s.apiService.GetTorrentByID(release.Indexer, release.TorrentID)
-						if err != nil || torrentInfo == nil {
-							log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not get torrent: '%v' from: %v", f.Name, release.TorrentID, release.Indexer)
-							continue
-						}
-
-						log.Debug().Msgf("filter-service.find_and_check_filters: (%v) got torrent info: %+v", f.Name, torrentInfo)
-					}
-
-					// compare size against filters
-					match, err := checkSizeFilter(f.MinSize, f.MaxSize, torrentInfo.ReleaseSizeBytes())
-					if err != nil {
-						log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not check size filter", f.Name)
-						continue
-					}
-
-					// no match, lets continue to next filter
-					if !match {
-						log.Debug().Msgf("filter-service.find_and_check_filters: (%v) filter did not match after additional size check, trying next", f.Name)
-						continue
-					}
-
-					// store size on the release
-					release.Size = torrentInfo.ReleaseSizeBytes()
-				} else {
-					log.Trace().Msgf("filter-service.find_and_check_filters: (%v) additional size check required: preparing to download metafile", f.Name)
-
-					// if indexer doesn't have api, download torrent and add to tmpPath
-					err = release.DownloadTorrentFile(nil)
-					if err != nil {
-						log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not download torrent file with id: '%v' from: %v", f.Name, release.TorrentID, release.Indexer)
-						return false, nil, err
-					}
-
-					// compare size against filter
-					match, err := checkSizeFilter(f.MinSize, f.MaxSize, release.Size)
-					if err != nil {
-						log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not check size filter", f.Name)
-						continue
-					}
-
-					// no match, lets continue to next filter
-					if !match {
-						log.Debug().Msgf("filter-service.find_and_check_filters: (%v) filter did not match after additional size check, trying next", f.Name)
-						continue
-					}
+				rejection, err := s.additionalSizeCheck(f, release, &torrentInfo)
+				if err != nil {
+					return nil, err
+				}
+
+				// no match, lets continue to next filter
+				if rejection != "" {
+					log.Debug().Msgf("filter-service.find_and_check_filters: (%v) filter did not match after additional size check, trying next: %v", f.Name, rejection)
+					reject(rejection)
+					continue
+				}
+			}
+
+			// duplicates and quotas are checked last, they need the size and cost a query
+			rejection, err := s.dedupeSvc.Check(context.TODO(), f, release)
+			if err != nil {
+				log.Error().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not check duplicates", f.Name)
+				rejection = fmt.Sprintf("could not check duplicates: %v", err)
+			}
+
+			if rejection != "" {
+				log.Debug().Msgf("filter-service.find_and_check_filters: (%v) release is a duplicate, trying next: %v", f.Name, rejection)
+				reject(rejection)
+				continue
+			}
+
+			rejection, err = s.checkQuotas(context.TODO(), f, release)
+			if err != nil {
+				log.Error().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not check quotas", f.Name)
+				rejection = fmt.Sprintf("could not check quotas: %v", err)
+			}
+
+			if rejection != "" {
+				log.Debug().Msgf("filter-service.find_and_check_filters: (%v) filter over quota, trying next: %v", f.Name, rejection)
+				reject(rejection)
+				continue
+			}
+
+			// the external checks go last, they are the slowest
+			if f.ExternalScriptEnabled {
+				rejection, err = s.checkExternalScript(context.TODO(), f, release)
+				if err != nil {
+					log.Error().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not run external script", f.Name)
+					rejection = fmt.Sprintf("could not run external script: %v", err)
+				}
+
+				if rejection != "" {
+					log.Debug().Msgf("filter-service.find_and_check_filters: (%v) external script did not match, trying next: %v", f.Name, rejection)
+					reject(rejection)
+					continue
+				}
+			}
+
+			if f.ExternalWebhookEnabled {
+				rejection, err = checkExternalWebhook(context.TODO(), f, release)
+				if err != nil {
+					log.Error().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not call external webhook", f.Name)
+					rejection = fmt.Sprintf("could not call external webhook: %v", err)
+				}
+
+				if rejection != "" {
+					log.Debug().Msgf("filter-service.find_and_check_filters: (%v) external webhook did not match, trying next: %v", f.Name, rejection)
+					reject(rejection)
+					continue
 				}
 			}
 
@@ -320,49 +573,177 @@ func (s *service) FindAndCheckFilters(release *domain.Release) (bool, *domain.Fi
 				log.Error().Err(err).Msgf("could not find actions for filter: %+v", f.Name)
 			}
 
+			actions = f.IndexerActions(release.Indexer, actions)
+
 			// if no actions, continue to next filter
 			if len(actions) == 0 {
 				log.Trace().Msgf("filter-service.find_and_check_filters: no actions found for filter '%v', trying next one..", f.Name)
+				reject("no actions")
 				continue
 			}
 			f.Actions = actions
 
-			return true, &f, nil
+			matched = append(matched, f)
+			matchedIDs = append(matchedIDs, f.ID)
+
+			if s.matchMode != domain.FilterMatchAll {
+				return matched, nil
+			}
+
+		} else {
+			reject(strings.Join(release.Rejections, ", "))
 		}
 	}
 
+	if len(matched) > 0 {
+		return matched, nil
+	}
+
+	if len(filters) == 0 {
+		rejections = append(rejections, "no active filters for indexer")
+	}
+
+	release.Rejections = rejections
+
 	// if no match, return nil
-	return false, nil, nil
+	return nil, nil
+}
+
+// recordChecks update the filter stats, they are not worth failing the release over
+func (s *service) recordChecks(evaluated []int, matched []int) {
+	if len(evaluated) == 0 {
+		return
+	}
+
+	if err := s.repo.RecordChecks(context.TODO(), evaluated, matched, time.Now()); err != nil {
+		log.Error().Err(err).Msg("filter-service.find_and_check_filters: could not update filter stats")
+	}
 }
 
-func checkSizeFilter(minSize string, maxSize string, releaseSize uint64) (bool, error) {
+// RecordActionResult count a finished action in the stats of its filter, pending actions are counted when they finish
+func (s *service) RecordActionResult(ctx context.Context, status *domain.ReleaseActionStatus) error {
+	if status.FilterID == 0 {
+		return nil
+	}
+
+	switch status.Status {
+	case domain.ReleasePushStatusApproved:
+		return s.repo.RecordActionResult(ctx, status.FilterID, true)
+	case domain.ReleasePushStatusRejected, domain.ReleasePushStatusErr:
+		return s.repo.RecordActionResult(ctx, status.FilterID, false)
+	}
+
+	return nil
+}
+
+// enrichTimeout how long an announce may wait on the indexer api, including its rate limit
+const enrichTimeout = 10 * time.Second
+
+// enrichRelease fill in the fields the announce lacks from the indexer api, the release is checked as is if that fails
+func (s *service) enrichRelease(release *domain.Release) {
+	ctx, cancel := context.WithTimeout(context.Background(), enrichTimeout)
+	defer cancel()
+
+	if err := s.apiService.EnrichRelease(ctx, release); err != nil {
+		log.Error().Err(err).Msgf("filter-service.find_and_check_filters: could not enrich release: %v", release.TorrentName)
+	}
+}
+
+// additionalSizeCheck get the real size from the indexer api or the torrent file and check it against the filter.
+// Returns the reason the size does not match, or an error if the torrent could not be downloaded.
+func (s *service) additionalSizeCheck(f domain.Filter, release *domain.Release, torrentInfo **domain.TorrentBasic) (string, error) {
+	// check if indexer = btn, ptp, ggn or red
+	if release.Indexer == "ptp" || release.Indexer == "btn" || release.Indexer == "ggn" || release.Indexer == "redacted" {
+		// fetch torrent info from api
+		// save outside of loop to check multiple filters with only one fetch
+		if *torrentInfo == nil {
+			info, err := s.apiService.GetTorrentByID(release.Indexer, release.TorrentID)
+			if err != nil || info == nil {
+				log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not get torrent: '%v' from: %v", f.Name, release.TorrentID, release.Indexer)
+				return "could not get size from api", nil
+			}
+
+			log.Debug().Msgf("filter-service.find_and_check_filters: (%v) got torrent info: %+v", f.Name, info)
+
+			*torrentInfo = info
+		}
+
+		// store size on the release
+		release.Size = (*torrentInfo).ReleaseSizeBytes()
+
+		return sizeRejection(f, release, "api"), nil
+	}
+
+	if release.HasMagnet() {
+		// magnet links have no metafile to read the size from
+		return "size unknown for magnet release", nil
+	}
+
+	if release.IsUsenet() {
+		// nzb files do not carry the size either
+		return "size unknown for usenet release", nil
+	}
+
+	// download once, later filters check against the parsed size
+	if release.TorrentTmpFile == "" {
+		log.Trace().Msgf("filter-service.find_and_check_filters: (%v) additional size check required: preparing to download metafile", f.Name)
+
+		// if indexer doesn't have api, download torrent and add to tmpPath
+		if err := s.indexerSvc.DownloadTorrentFile(release); err != nil {
+			log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not download torrent file with id: '%v' from: %v", f.Name, release.TorrentID, release.Indexer)
+			return "", err
+		}
+	}
+
+	return sizeRejection(f, release, "torrent file"), nil
+}
+
+func sizeRejection(f domain.Filter, release *domain.Release, source string) string {
+	rejection, err := checkSizeFilter(f.MinSize, f.MaxSize, release.Size, source)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("filter-service.find_and_check_filters: (%v) could not check size filter", f.Name)
+		return "could not check size"
+	}
+
+	if rejection != "" {
+		return rejection
+	}
+
+	// the expression was skipped while the size was unknown
+	rejection, err = release.CheckExpression(f)
+	if err != nil {
+		return fmt.Sprintf("expression: %v", err)
+	}
+
+	return rejection
+}
+
+// checkSizeFilter returns why the size does not match min and max size, or an empty string if it does
+func checkSizeFilter(minSize string, maxSize string, releaseSize uint64, source string) (string, error) {
 	// handle both min and max
 	if minSize != "" {
 		// string to bytes
 		minSizeBytes, err := humanize.ParseBytes(minSize)
 		if err != nil {
-			// log could not parse into bytes
+			return "", fmt.Errorf("could not parse min size %q: %w", minSize, err)
 		}
 
 		if releaseSize <= minSizeBytes {
-			//r.addRejection("size: smaller than min size")
-			return false, nil
+			return fmt.Sprintf("size %v from %v smaller than min size %v", humanize.Bytes(releaseSize), source, minSize), nil
 		}
-
 	}
 
 	if maxSize != "" {
 		// string to bytes
 		maxSizeBytes, err := humanize.ParseBytes(maxSize)
 		if err != nil {
-			// log could not parse into bytes
+			return "", fmt.Errorf("could not parse max size %q: %w", maxSize, err)
 		}
 
 		if releaseSize >= maxSizeBytes {
-			//r.addRejection("size: larger than max size")
-			return false, nil
+			return fmt.Sprintf("size %v from %v larger than max size %v", humanize.Bytes(releaseSize), source, maxSize), nil
 		}
 	}
 
-	return true, nil
+	return "", nil
 }
@@ -1,5 +1,13 @@
 package filter
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
 //func Test_checkFilterStrings(t *testing.T) {
 //	type args struct {
 //		name       string
@@ -642,3 +650,66 @@ package filter
 //		})
 //	}
 //}
+
+func Test_checkSizeFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		minSize   string
+		maxSize   string
+		size      uint64
+		rejection string
+		wantErr   bool
+	}{
+		{name: "between", minSize: "1 GB", maxSize: "2 GB", size: 1500000000},
+		{name: "smaller", minSize: "1 GB", size: 500000000, rejection: "size 500 MB from torrent file smaller than min size 1 GB"},
+		{name: "larger", maxSize: "2 GB", size: 3000000000, rejection: "size 3.0 GB from torrent file larger than max size 2 GB"},
+		{name: "invalid", maxSize: "two gigs", size: 3000000000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkSizeFilter(tt.minSize, tt.maxSize, tt.size, "torrent file")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rejection, got)
+		})
+	}
+}
+
+func Test_testFilter(t *testing.T) {
+	f := domain.Filter{
+		Enabled:        true,
+		Resolutions:    []string{"1080p"},
+		ExceptReleases: "*HDTV*",
+		Indexers:       []domain.Indexer{{Identifier: "mock"}},
+	}
+
+	release := func(indexer string, name string) *domain.Release {
+		r, _ := domain.NewRelease(indexer, "")
+		r.TorrentName = name
+		_ = r.Parse()
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		release        *domain.Release
+		wantMatch      bool
+		wantRejections []string
+	}{
+		{name: "match", release: release("mock", "That.Movie.2021.1080p.BluRay.x264-GRP"), wantMatch: true, wantRejections: []string{}},
+		{name: "resolution", release: release("mock", "That.Movie.2021.720p.BluRay.x264-GRP"), wantRejections: []string{"resolution not matching"}},
+		{name: "except_releases", release: release("mock", "That.Show.S01E01.1080p.HDTV.x264-GRP"), wantRejections: []string{"except_releases: unwanted release"}},
+		{name: "other_indexer", release: release("other", "That.Movie.2021.1080p.BluRay.x264-GRP"), wantRejections: []string{"indexer other not enabled on filter"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testFilter(f, tt.release)
+			assert.Equal(t, tt.wantMatch, got.Match)
+			assert.Equal(t, tt.wantRejections, got.Rejections)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	defaultTestLimit = 100
+	maxTestLimit     = 1000
+)
+
+// Test dry-run a filter against release names or recent stored releases. No actions run, sizes are not
+// looked up and duplicates and quotas are not checked. Disabled filters are checked as if enabled.
+func (s *service) Test(ctx context.Context, filterID int, params domain.FilterTestParams) ([]domain.FilterTestResult, error) {
+	f, err := s.FindByID(ctx, filterID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find filter: %v: %w", filterID, err)
+	}
+
+	releases, err := s.testReleases(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Enabled = true
+
+	results := make([]domain.FilterTestResult, 0, len(releases))
+	for i := range releases {
+		results = append(results, testFilter(*f, &releases[i]))
+	}
+
+	return results, nil
+}
+
+// testReleases parse the release names, or load the latest stored releases
+func (s *service) testReleases(ctx context.Context, params domain.FilterTestParams) ([]domain.Release, error) {
+	if len(params.ReleaseNames) > 0 {
+		releases := make([]domain.Release, 0, len(params.ReleaseNames))
+
+		for _, name := range params.ReleaseNames {
+			release, err := domain.NewRelease(params.Indexer, "")
+			if err != nil {
+				return nil, err
+			}
+
+			release.TorrentName = name
+			_ = release.Parse()
+
+			releases = append(releases, *release)
+		}
+
+		return releases, nil
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultTestLimit
+	}
+	if limit > maxTestLimit {
+		limit = maxTestLimit
+	}
+
+	releases, err := s.releaseRepo.FindRecent(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not find releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+func testFilter(f domain.Filter, release *domain.Release) domain.FilterTestResult {
+	result := domain.FilterTestResult{
+		ReleaseID:   release.ID,
+		TorrentName: release.TorrentName,
+		Indexer:     release.Indexer,
+	}
+
+	if release.Indexer != "" && !filterHasIndexer(f, release.Indexer) {
+		result.Rejections = []string{fmt.Sprintf("indexer %v not enabled on filter", release.Indexer)}
+		return result
+	}
+
+	result.Match = release.CheckFilter(f.ForIndexer(release.Indexer))
+	result.Rejections = release.Rejections
+	result.SizeCheckRequired = result.Match && release.AdditionalSizeCheckRequired
+
+	return result
+}
+
+// filterHasIndexer filters without indexers are never checked, but the dry-run still shows what they would match
+func filterHasIndexer(f domain.Filter, identifier string) bool {
+	if len(f.Indexers) == 0 {
+		return true
+	}
+
+	for _, indexer := range f.Indexers {
+		if indexer.Identifier == identifier {
+			return true
+		}
+	}
+
+	return false
+}
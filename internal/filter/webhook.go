@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/action"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// maxWebhookResponse bytes of the response body read for the expected field
+const maxWebhookResponse = 64 * 1024
+
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+// checkExternalWebhook post the release to the filter webhook.
+// Returns why the release is rejected, or an error if the webhook could not be called.
+func checkExternalWebhook(ctx context.Context, f domain.Filter, release *domain.Release) (string, error) {
+	// the filter is not set on the release until it matches
+	rls := *release
+	rls.FilterName = f.Name
+
+	m := action.NewMacro(rls)
+
+	host, err := m.Parse(f.ExternalWebhookHost)
+	if err != nil {
+		return "", fmt.Errorf("could not parse url: %w", err)
+	}
+
+	var payload []byte
+	if f.ExternalWebhookData != "" {
+		data, err := m.Parse(f.ExternalWebhookData)
+		if err != nil {
+			return "", fmt.Errorf("could not parse payload: %w", err)
+		}
+
+		if !json.Valid([]byte(data)) {
+			return "", fmt.Errorf("payload is not valid json, use {{ json .Field }} to quote values: %v", data)
+		}
+
+		payload = []byte(data)
+	} else {
+		if payload, err = json.Marshal(rls); err != nil {
+			return "", fmt.Errorf("could not marshal release: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxWebhookResponse))
+	if err != nil {
+		return "", fmt.Errorf("could not read response: %w", err)
+	}
+
+	log.Debug().Msgf("filter-service.external_webhook: (%v) %v responded %v after %v", f.Name, host, res.StatusCode, time.Since(start))
+
+	return webhookRejection(f, res.StatusCode, body)
+}
+
+// webhookRejection compare the response status and body with what the filter expects
+func webhookRejection(f domain.Filter, status int, body []byte) (string, error) {
+	if f.ExternalWebhookStatus != 0 {
+		if status != f.ExternalWebhookStatus {
+			return fmt.Sprintf("external webhook responded %d, expected %d", status, f.ExternalWebhookStatus), nil
+		}
+	} else if status < http.StatusOK || status >= http.StatusMultipleChoices {
+		return fmt.Sprintf("external webhook responded %d", status), nil
+	}
+
+	if f.ExternalWebhookField == "" {
+		return "", nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("response is not valid json: %w", err)
+	}
+
+	value, ok := jsonField(data, f.ExternalWebhookField)
+	if !ok {
+		return fmt.Sprintf("external webhook response has no field %q", f.ExternalWebhookField), nil
+	}
+
+	if accepted, _ := value.(bool); !accepted {
+		return fmt.Sprintf("external webhook field %q is %v", f.ExternalWebhookField, value), nil
+	}
+
+	return "", nil
+}
+
+// jsonField walk a dot separated path through decoded json objects
+func jsonField(data interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if data, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return data, true
+}
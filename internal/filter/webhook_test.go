@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_checkExternalWebhook(t *testing.T) {
+	release := &domain.Release{TorrentName: "That Movie 2020 1080p BluRay x264-GROUP", Indexer: "mock"}
+
+	var gotBody map[string]interface{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = nil
+		_ = json.Unmarshal(b, &gotBody)
+
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/available":
+			w.Write([]byte(`{"result": {"available": true}}`))
+		case "/unavailable":
+			w.Write([]byte(`{"result": {"available": false}}`))
+		case "/text":
+			w.Write([]byte(`ok`))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		filter   domain.Filter
+		want     string
+		wantBody map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:   "status_ok",
+			filter: domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/text"},
+		},
+		{
+			name:   "status_rejected",
+			filter: domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/missing"},
+			want:   "external webhook responded 404",
+		},
+		{
+			name:   "expected_status",
+			filter: domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/missing", ExternalWebhookStatus: 404},
+		},
+		{
+			name:     "field_true",
+			filter:   domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/available", ExternalWebhookData: `{"name": {{ json .TorrentName }}, "filter": {{ json .Filter }}}`, ExternalWebhookField: "result.available"},
+			wantBody: map[string]interface{}{"name": "That Movie 2020 1080p BluRay x264-GROUP", "filter": "movies"},
+		},
+		{
+			name:   "field_false",
+			filter: domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/unavailable", ExternalWebhookField: "result.available"},
+			want:   `external webhook field "result.available" is false`,
+		},
+		{
+			name:   "field_missing",
+			filter: domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/available", ExternalWebhookField: "available"},
+			want:   `external webhook response has no field "available"`,
+		},
+		{
+			name:    "not_json",
+			filter:  domain.Filter{Name: "movies", ExternalWebhookHost: ts.URL + "/text", ExternalWebhookField: "available"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkExternalWebhook(context.Background(), tt.filter, release)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			if tt.wantBody != nil {
+				assert.Equal(t, tt.wantBody, gotBody)
+			}
+		})
+	}
+}
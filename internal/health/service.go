@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/download_client"
+	"github.com/autobrr/autobrr/internal/irc"
+
+	"github.com/rs/zerolog/log"
+)
+
+// checkTimeout how long the database may take to answer a readiness check
+const checkTimeout = 5 * time.Second
+
+type Service interface {
+	Liveness() domain.HealthReport
+	Readiness(ctx context.Context) domain.HealthReport
+}
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type service struct {
+	db        pinger
+	ircSvc    irc.Service
+	clientSvc download_client.Service
+	startedAt time.Time
+}
+
+func NewService(db pinger, ircSvc irc.Service, clientSvc download_client.Service) Service {
+	return &service{
+		db:        db,
+		ircSvc:    ircSvc,
+		clientSvc: clientSvc,
+		startedAt: time.Now(),
+	}
+}
+
+// Liveness the process is up and serving requests
+func (s *service) Liveness() domain.HealthReport {
+	return domain.HealthReport{Status: domain.HealthStatusOK, StartedAt: s.startedAt}
+}
+
+// Readiness check the database, irc networks and download clients.
+// Only the database makes autobrr unavailable, networks and clients that are down degrade it.
+// The probe needs no login so the report only has statuses and counts, names and errors are logged.
+func (s *service) Readiness(ctx context.Context) domain.HealthReport {
+	report := domain.HealthReport{
+		Status:    domain.HealthStatusOK,
+		StartedAt: s.startedAt,
+		Checks: map[string]domain.HealthCheck{
+			"database":         s.checkDatabase(ctx),
+			"irc":              s.checkIrc(ctx),
+			"download_clients": s.checkDownloadClients(),
+		},
+	}
+
+	for _, check := range report.Checks {
+		switch {
+		case check.Status == domain.HealthStatusUnavailable:
+			report.Status = domain.HealthStatusUnavailable
+		case check.Status == domain.HealthStatusDegraded && report.Status == domain.HealthStatusOK:
+			report.Status = domain.HealthStatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (s *service) checkDatabase(ctx context.Context) domain.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	if err := s.db.Ping(ctx); err != nil {
+		log.Error().Err(err).Msg("health: database check failed")
+		return domain.HealthCheck{Status: domain.HealthStatusUnavailable, Message: "database not reachable"}
+	}
+
+	return domain.HealthCheck{Status: domain.HealthStatusOK}
+}
+
+func (s *service) checkIrc(ctx context.Context) domain.HealthCheck {
+	networks, err := s.ircSvc.GetNetworksWithHealth(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("health: could not list irc networks")
+		return domain.HealthCheck{Status: domain.HealthStatusDegraded, Message: "could not list networks"}
+	}
+
+	enabled := 0
+	var disconnected []string
+	for _, network := range networks {
+		if !network.Enabled {
+			continue
+		}
+
+		enabled++
+		if !network.Connected {
+			disconnected = append(disconnected, network.Name)
+		}
+	}
+
+	check := domain.HealthCheck{
+		Status:  domain.HealthStatusOK,
+		Message: fmt.Sprintf("%d of %d networks connected", enabled-len(disconnected), enabled),
+	}
+
+	if len(disconnected) > 0 {
+		check.Status = domain.HealthStatusDegraded
+		log.Debug().Msgf("health: disconnected irc networks: %v", strings.Join(disconnected, ", "))
+	}
+
+	return check
+}
+
+// checkDownloadClients results of the periodic client health check, clients are not contacted for every probe
+func (s *service) checkDownloadClients() domain.HealthCheck {
+	clients, err := s.clientSvc.List()
+	if err != nil {
+		log.Error().Err(err).Msg("health: could not list download clients")
+		return domain.HealthCheck{Status: domain.HealthStatusDegraded, Message: "could not list clients"}
+	}
+
+	enabled := 0
+	var unreachable []string
+	for _, client := range clients {
+		if !client.Enabled {
+			continue
+		}
+
+		enabled++
+		if client.Health != nil && client.Health.Status == domain.DownloadClientHealthError {
+			unreachable = append(unreachable, client.Name)
+		}
+	}
+
+	check := domain.HealthCheck{
+		Status:  domain.HealthStatusOK,
+		Message: fmt.Sprintf("%d of %d clients reachable", enabled-len(unreachable), enabled),
+	}
+
+	if len(unreachable) > 0 {
+		check.Status = domain.HealthStatusDegraded
+		log.Debug().Msgf("health: unreachable download clients: %v", strings.Join(unreachable, ", "))
+	}
+
+	return check
+}
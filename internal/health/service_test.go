@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/download_client"
+	"github.com/autobrr/autobrr/internal/irc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPinger struct {
+	err error
+}
+
+func (m mockPinger) Ping(ctx context.Context) error {
+	return m.err
+}
+
+type mockIrcService struct {
+	irc.Service
+	networks []domain.IrcNetworkWithHealth
+}
+
+func (m mockIrcService) GetNetworksWithHealth(ctx context.Context) ([]domain.IrcNetworkWithHealth, error) {
+	return m.networks, nil
+}
+
+type mockClientService struct {
+	download_client.Service
+	clients []domain.DownloadClient
+}
+
+func (m mockClientService) List() ([]domain.DownloadClient, error) {
+	return m.clients, nil
+}
+
+func Test_service_Readiness(t *testing.T) {
+	s := NewService(
+		mockPinger{err: errors.New("open /config/autobrr.db: permission denied")},
+		mockIrcService{networks: []domain.IrcNetworkWithHealth{
+			{Name: "SecretTracker", Enabled: true, Connected: false},
+			{Name: "OtherTracker", Enabled: true, Connected: true},
+		}},
+		mockClientService{clients: []domain.DownloadClient{
+			{Name: "seedbox-qbit", Enabled: true, Health: &domain.DownloadClientHealth{Status: domain.DownloadClientHealthError}},
+		}},
+	)
+
+	report := s.Readiness(context.Background())
+
+	assert.Equal(t, domain.HealthStatusUnavailable, report.Status)
+	assert.Equal(t, domain.HealthCheck{Status: domain.HealthStatusUnavailable, Message: "database not reachable"}, report.Checks["database"])
+	assert.Equal(t, domain.HealthCheck{Status: domain.HealthStatusDegraded, Message: "1 of 2 networks connected"}, report.Checks["irc"])
+	assert.Equal(t, domain.HealthCheck{Status: domain.HealthStatusDegraded, Message: "0 of 1 clients reachable"}, report.Checks["download_clients"])
+
+	// the probe needs no login
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "SecretTracker")
+	assert.NotContains(t, string(data), "seedbox-qbit")
+	assert.NotContains(t, string(data), "/config")
+}
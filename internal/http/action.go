@@ -16,6 +16,7 @@ type actionService interface {
 	Store(ctx context.Context, action domain.Action) (*domain.Action, error)
 	Delete(actionID int) error
 	ToggleEnabled(actionID int) error
+	HandlerCounts() map[string]int
 }
 
 type actionHandler struct {
@@ -44,6 +45,12 @@ func (h actionHandler) getActions(w http.ResponseWriter, r *http.Request) {
 		// encode error
 	}
 
+	if !canSeeSecrets(r.Context()) {
+		for i := range actions {
+			actions[i] = actions[i].Redacted()
+		}
+	}
+
 	h.encoder.StatusResponse(r.Context(), w, actions, http.StatusOK)
 }
 
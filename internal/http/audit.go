@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type auditService interface {
+	FindAuthEvents(ctx context.Context, params domain.AuthEventQueryParams) ([]domain.AuthEvent, int64, error)
+}
+
+type auditHandler struct {
+	encoder encoder
+	service auditService
+}
+
+func newAuditHandler(encoder encoder, service auditService) *auditHandler {
+	return &auditHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h auditHandler) Routes(r chi.Router) {
+	r.Use(requireRole(domain.UserRoleAdmin))
+
+	r.Get("/auth", h.findAuthEvents)
+}
+
+// findAuthEvents sign in attempts, newest first, filtered by username, ip, type and from
+func (h auditHandler) findAuthEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vals := r.URL.Query()
+
+	params := domain.AuthEventQueryParams{
+		Limit:    50,
+		Username: vals.Get("username"),
+		IP:       vals.Get("ip"),
+		Type:     domain.AuthEventType(vals.Get("type")),
+	}
+
+	if limit := vals.Get("limit"); limit != "" {
+		value, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil || value == 0 {
+			h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+				"code":    "BAD_REQUEST_PARAMS",
+				"message": "limit parameter is invalid",
+			}, http.StatusBadRequest)
+			return
+		}
+		params.Limit = value
+	}
+
+	if offset := vals.Get("offset"); offset != "" {
+		value, err := strconv.ParseUint(offset, 10, 64)
+		if err != nil {
+			h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+				"code":    "BAD_REQUEST_PARAMS",
+				"message": "offset parameter is invalid",
+			}, http.StatusBadRequest)
+			return
+		}
+		params.Offset = value
+	}
+
+	from, err := parseTimeParam(vals.Get("from"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "from parameter is invalid, expected RFC3339",
+		}, http.StatusBadRequest)
+		return
+	}
+	params.From = from
+
+	events, count, err := h.service.FindAuthEvents(ctx, params)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	ret := struct {
+		Data  []domain.AuthEvent `json:"data"`
+		Count int64              `json:"count"`
+	}{
+		Data:  events,
+		Count: count,
+	}
+
+	h.encoder.StatusResponse(ctx, w, ret, http.StatusOK)
+}
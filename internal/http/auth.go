@@ -3,31 +3,57 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/gorilla/sessions"
+	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/autobrr/internal/domain"
 )
 
 type authService interface {
-	Login(ctx context.Context, username, password string) (*domain.User, error)
+	Login(ctx context.Context, req domain.LoginRequest) (*domain.User, error)
+	CanOnboard(ctx context.Context) (bool, error)
+	Onboard(ctx context.Context, req domain.CreateUserRequest, client domain.AuthClient) (*domain.User, error)
+	FindAuthEvents(ctx context.Context, params domain.AuthEventQueryParams) ([]domain.AuthEvent, int64, error)
+	TOTPStatus(ctx context.Context, username string) (*domain.TOTPStatus, error)
+	TOTPEnroll(ctx context.Context, username string) (*domain.TOTPEnrollment, error)
+	TOTPConfirm(ctx context.Context, username, code string) ([]string, error)
+	TOTPDisable(ctx context.Context, username, code string) error
+	OIDCEnabled() bool
+	OIDCLogin(ctx context.Context) (string, *domain.OIDCLoginState, error)
+	OIDCCallback(ctx context.Context, code string, returnedState string, pending domain.OIDCLoginState, client domain.AuthClient) (*domain.OIDCIdentity, error)
+}
+
+type onboardRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 type authHandler struct {
-	encoder encoder
-	config  domain.Config
-	service authService
+	encoder  encoder
+	config   domain.Config
+	service  authService
+	sessions sessionService
+	proxies  domain.TrustedProxies
 
 	cookieStore *sessions.CookieStore
 }
 
-func newAuthHandler(encoder encoder, config domain.Config, cookieStore *sessions.CookieStore, service authService) *authHandler {
+func newAuthHandler(encoder encoder, config domain.Config, cookieStore *sessions.CookieStore, service authService, sessionSvc sessionService) *authHandler {
+	// checked on start
+	proxies, _ := config.ReverseProxies()
+
 	return &authHandler{
 		encoder:     encoder,
 		config:      config,
 		service:     service,
+		sessions:    sessionSvc,
+		proxies:     proxies,
 		cookieStore: cookieStore,
 	}
 }
@@ -36,12 +62,30 @@ func (h authHandler) Routes(r chi.Router) {
 	r.Post("/login", h.login)
 	r.Post("/logout", h.logout)
 	r.Get("/test", h.test)
+	r.Get("/onboard", h.canOnboard)
+	r.Post("/onboard", h.onboard)
+	r.Get("/oidc/config", h.oidcConfig)
+	r.Get("/oidc/login", h.oidcLogin)
+	r.Get("/oidc/callback", h.oidcCallback)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.isAuthenticated)
+
+		r.Get("/totp", h.totpStatus)
+		r.Post("/totp/enroll", h.totpEnroll)
+		r.Post("/totp/confirm", h.totpConfirm)
+		r.Post("/totp/disable", h.totpDisable)
+	})
 }
 
 func (h authHandler) login(w http.ResponseWriter, r *http.Request) {
 	var (
 		ctx  = r.Context()
-		data domain.User
+		data struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Code     string `json:"code"`
+		}
 	)
 
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
@@ -50,42 +94,147 @@ func (h authHandler) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.cookieStore.Options.HttpOnly = true
-	h.cookieStore.Options.SameSite = http.SameSiteLaxMode
-	h.cookieStore.Options.Path = h.config.BaseURL
-
-	// autobrr does not support serving on TLS / https, so this is only available behind reverse proxy
-	// if forwarded protocol is https then set cookie secure
-	// SameSite Strict can only be set with a secure cookie. So we overwrite it here if possible.
-	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Set-Cookie/SameSite
-	fwdProto := r.Header.Get("X-Forwarded-Proto")
-	if fwdProto == "https" {
-		h.cookieStore.Options.Secure = true
-		h.cookieStore.Options.SameSite = http.SameSiteStrictMode
-	}
+	h.setCookieOptions(r)
 
 	session, _ := h.cookieStore.Get(r, "user_session")
 
-	_, err := h.service.Login(ctx, data.Username, data.Password)
+	user, err := h.service.Login(ctx, domain.LoginRequest{
+		Username: data.Username,
+		Password: data.Password,
+		Code:     data.Code,
+		Client:   h.authClient(r),
+	})
 	if err != nil {
+		var locked *domain.LoginLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+			h.encoder.StatusResponse(ctx, w, map[string]string{"code": "LOGIN_LOCKED", "message": err.Error()}, http.StatusTooManyRequests)
+			return
+		}
+
+		if errors.Is(err, domain.ErrTOTPRequired) {
+			// the ui asks for the code and sends the login again
+			h.encoder.StatusResponse(ctx, w, map[string]string{"code": "TOTP_REQUIRED"}, http.StatusUnauthorized)
+			return
+		}
+
 		h.encoder.StatusResponse(ctx, w, nil, http.StatusUnauthorized)
 		return
 	}
 
-	// Set user as authenticated
-	session.Values["authenticated"] = true
-	session.Save(r, w)
+	if err := h.startSession(w, r, session, domain.Session{Username: user.Username, Role: user.Role, AuthMethod: "password"}); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
+}
+
+// canOnboard 200 while no user exists and the ui should show onboarding, 403 after
+func (h authHandler) canOnboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ok, err := h.service.CanOnboard(ctx)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	if !ok {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: domain.ErrOnboardingUnavailable.Error(), Status: http.StatusForbidden}, http.StatusForbidden)
+		return
+	}
 
 	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
 }
 
+// onboard create the first user as admin and sign it in
+func (h authHandler) onboard(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data onboardRequest
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.Onboard(ctx, domain.CreateUserRequest{Username: data.Username, Password: data.Password}, h.authClient(r))
+	if err != nil {
+		if errors.Is(err, domain.ErrOnboardingUnavailable) {
+			h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusForbidden}, http.StatusForbidden)
+			return
+		}
+
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.setCookieOptions(r)
+
+	session, _ := h.cookieStore.Get(r, "user_session")
+
+	if err := h.startSession(w, r, session, domain.Session{Username: user.Username, Role: user.Role, AuthMethod: "password"}); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, user, http.StatusCreated)
+}
+
+// startSession store the server side session and put its token in the cookie
+func (h authHandler) startSession(w http.ResponseWriter, r *http.Request, cookie *sessions.Session, session domain.Session) error {
+	client := h.authClient(r)
+	session.IP = client.IP
+	session.UserAgent = client.UserAgent
+
+	token, _, err := h.sessions.Create(r.Context(), session)
+	if err != nil {
+		return err
+	}
+
+	cookie.Options.MaxAge = int(h.sessions.Settings().Lifetime.Seconds())
+	cookie.Values = map[interface{}]interface{}{"session_id": token}
+
+	return cookie.Save(r, w)
+}
+
+func (h authHandler) authClient(r *http.Request) domain.AuthClient {
+	return domain.AuthClient{IP: clientIP(r, h.proxies), UserAgent: r.UserAgent()}
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func (h authHandler) setCookieOptions(r *http.Request) {
+	h.cookieStore.Options.HttpOnly = true
+	h.cookieStore.Options.SameSite = http.SameSiteLaxMode
+	h.cookieStore.Options.Path = h.config.BaseURL
+
+	// if autobrr serves https itself, or the forwarded protocol of a reverse proxy is https, then set cookie secure
+	// SameSite Strict can only be set with a secure cookie. So we overwrite it here if possible.
+	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Set-Cookie/SameSite
+	if isHTTPS(r) {
+		h.cookieStore.Options.Secure = true
+		h.cookieStore.Options.SameSite = http.SameSiteStrictMode
+	}
+}
+
 func (h authHandler) logout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	session, _ := h.cookieStore.Get(r, "user_session")
 
 	// Revoke users authentication
-	session.Values["authenticated"] = false
+	token, _ := session.Values["session_id"].(string)
+	if err := h.sessions.RevokeToken(ctx, token); err != nil {
+		log.Error().Err(err).Msg("auth: could not revoke session")
+	}
+
+	session.Values = map[interface{}]interface{}{}
+	session.Options.MaxAge = -1
 	session.Save(r, w)
 
 	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
@@ -93,10 +242,9 @@ func (h authHandler) logout(w http.ResponseWriter, r *http.Request) {
 
 func (h authHandler) test(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	session, _ := h.cookieStore.Get(r, "user_session")
 
 	// Check if user is authenticated
-	if auth, ok := session.Values["authenticated"].(bool); !ok || !auth {
+	if _, ok := validSession(r, h.cookieStore, h.sessions); !ok {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -104,3 +252,185 @@ func (h authHandler) test(w http.ResponseWriter, r *http.Request) {
 	// send empty response as ok
 	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
 }
+
+// oidcStateMaxAge seconds the user has to sign in at the provider
+const oidcStateMaxAge = 600
+
+func (h authHandler) oidcConfig(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+		"enabled": h.service.OIDCEnabled(),
+	}, http.StatusOK)
+}
+
+// oidcStateOptions the state cookie is lax, the callback is a cross site redirect from the provider
+func (h authHandler) oidcStateOptions(r *http.Request, maxAge int) *sessions.Options {
+	return &sessions.Options{
+		Path:     h.config.BaseURL,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (h authHandler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.service.OIDCEnabled() {
+		h.encoder.StatusNotFound(ctx, w)
+		return
+	}
+
+	authURL, state, err := h.service.OIDCLogin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("auth.oidc: could not start login")
+		http.Error(w, "could not reach the login provider", http.StatusBadGateway)
+		return
+	}
+
+	session, _ := h.cookieStore.Get(r, "oidc_state")
+	session.Options = h.oidcStateOptions(r, oidcStateMaxAge)
+	session.Values["state"] = state.State
+	session.Values["nonce"] = state.Nonce
+	session.Values["verifier"] = state.Verifier
+
+	if err := session.Save(r, w); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h authHandler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.service.OIDCEnabled() {
+		h.encoder.StatusNotFound(ctx, w)
+		return
+	}
+
+	stateSession, _ := h.cookieStore.Get(r, "oidc_state")
+
+	pending := domain.OIDCLoginState{}
+	pending.State, _ = stateSession.Values["state"].(string)
+	pending.Nonce, _ = stateSession.Values["nonce"].(string)
+	pending.Verifier, _ = stateSession.Values["verifier"].(string)
+
+	// the state is used once
+	stateSession.Options = h.oidcStateOptions(r, -1)
+	_ = stateSession.Save(r, w)
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		log.Warn().Msgf("auth.oidc: provider returned error: %v %v", providerErr, query.Get("error_description"))
+		http.Error(w, "login was not completed at the provider", http.StatusUnauthorized)
+		return
+	}
+
+	identity, err := h.service.OIDCCallback(ctx, query.Get("code"), query.Get("state"), pending, h.authClient(r))
+	if err != nil {
+		log.Error().Err(err).Msg("auth.oidc: login failed")
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	h.setCookieOptions(r)
+
+	session, _ := h.cookieStore.Get(r, "user_session")
+
+	if err := h.startSession(w, r, session, domain.Session{Username: identity.Username, Role: identity.Role, AuthMethod: "oidc"}); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.config.BaseURL, http.StatusFound)
+}
+
+func (h authHandler) isAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := validSession(r, h.cookieStore, h.sessions)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey, session)))
+	})
+}
+
+// sessionUsername the local user of the session, empty for oidc logins which have no password or totp here
+func (h authHandler) sessionUsername(r *http.Request) string {
+	session := currentSession(r.Context())
+	if session == nil || session.AuthMethod == "oidc" {
+		return ""
+	}
+
+	return session.Username
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (h authHandler) totpStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status, err := h.service.TOTPStatus(ctx, h.sessionUsername(r))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, status, http.StatusOK)
+}
+
+func (h authHandler) totpEnroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	enrollment, err := h.service.TOTPEnroll(ctx, h.sessionUsername(r))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, enrollment, http.StatusOK)
+}
+
+func (h authHandler) totpConfirm(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data totpCodeRequest
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.TOTPConfirm(ctx, h.sessionUsername(r), data.Code)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, map[string]interface{}{"recovery_codes": codes}, http.StatusOK)
+}
+
+func (h authHandler) totpDisable(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data totpCodeRequest
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.TOTPDisable(ctx, h.sessionUsername(r), data.Code); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
+}
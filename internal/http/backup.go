@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// maxRestoreSize uploads bigger than this are rejected, a database with a long release history fits easily
+const maxRestoreSize = 1 << 30
+
+type backupService interface {
+	Backup(ctx context.Context, w io.Writer, opts domain.BackupOptions) error
+	Restore(ctx context.Context, r io.Reader, opts domain.RestoreOptions) (*domain.RestoreResult, error)
+}
+
+type backupHandler struct {
+	encoder encoder
+	service backupService
+}
+
+func newBackupHandler(encoder encoder, service backupService) *backupHandler {
+	return &backupHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+// backup download an archive of the database and config, ?redact=true leaves out the secrets
+func (h backupHandler) backup(w http.ResponseWriter, r *http.Request) {
+	redact, _ := strconv.ParseBool(r.URL.Query().Get("redact"))
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+domain.BackupFileName(time.Now())+`"`)
+
+	// the archive streams out, an error halfway can only cut it short
+	if err := h.service.Backup(r.Context(), w, domain.BackupOptions{Redact: redact}); err != nil {
+		w.Header().Del("Content-Disposition")
+		h.encoder.Error(w, err)
+		return
+	}
+}
+
+// restore the archive in the body, ?config=false keeps the current config file
+func (h backupHandler) restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts := domain.RestoreOptions{Config: true}
+	if value := r.URL.Query().Get("config"); value != "" {
+		restoreConfig, err := strconv.ParseBool(value)
+		if err != nil {
+			h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+				"code":    "BAD_REQUEST_PARAMS",
+				"message": "config has to be true or false",
+			}, http.StatusBadRequest)
+			return
+		}
+		opts.Config = restoreConfig
+	}
+
+	result, err := h.service.Restore(ctx, http.MaxBytesReader(w, r.Body, maxRestoreSize), opts)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "INVALID_BACKUP",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, result, http.StatusOK)
+}
@@ -1,56 +1,107 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 
-	"github.com/autobrr/autobrr/internal/config"
-
 	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
 )
 
+type configService interface {
+	Get() domain.Config
+	RestartRequired() []string
+	Update(update domain.ConfigUpdate) (domain.Config, error)
+}
+
 type configJson struct {
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	LogLevel string `json:"log_level"`
-	LogPath  string `json:"log_path"`
-	BaseURL  string `json:"base_url"`
-	Version  string `json:"version"`
-	Commit   string `json:"commit"`
-	Date     string `json:"date"`
+	Host                      string   `json:"host"`
+	Port                      int      `json:"port"`
+	LogLevel                  string   `json:"log_level"`
+	LogPath                   string   `json:"log_path"`
+	BaseURL                   string   `json:"base_url"`
+	SessionLifetimeHours      int      `json:"session_lifetime_hours"`
+	SessionIdleTimeoutMinutes int      `json:"session_idle_timeout_minutes"`
+	CheckForUpdates           bool     `json:"check_for_updates"`
+	RestartRequired           []string `json:"restart_required"` // saved settings that apply after a restart
+	Version                   string   `json:"version"`
+	Commit                    string   `json:"commit"`
+	Date                      string   `json:"date"`
 }
 
 type configHandler struct {
 	encoder encoder
+	service configService
 
 	server Server
 }
 
-func newConfigHandler(encoder encoder, server Server) *configHandler {
+func newConfigHandler(encoder encoder, service configService, server Server) *configHandler {
 	return &configHandler{
 		encoder: encoder,
+		service: service,
 		server:  server,
 	}
 }
 
 func (h configHandler) Routes(r chi.Router) {
 	r.Get("/", h.getConfig)
+
+	r.Group(func(r chi.Router) {
+		r.Use(requireRole(domain.UserRoleAdmin))
+
+		r.Patch("/", h.updateConfig)
+	})
 }
 
 func (h configHandler) getConfig(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, h.configJson(h.service.Get()), http.StatusOK)
+}
+
+// updateConfig change the settings in the request, the rest stays as it is
+func (h configHandler) updateConfig(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	c := config.Config
-
-	conf := configJson{
-		Host:     c.Host,
-		Port:     c.Port,
-		LogLevel: c.LogLevel,
-		LogPath:  c.LogPath,
-		BaseURL:  c.BaseURL,
-		Version:  h.server.version,
-		Commit:   h.server.commit,
-		Date:     h.server.date,
+	var data domain.ConfigUpdate
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "invalid config",
+		}, http.StatusBadRequest)
+		return
 	}
 
-	h.encoder.StatusResponse(ctx, w, conf, http.StatusOK)
+	if err := data.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.service.Update(data)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, h.configJson(cfg), http.StatusOK)
+}
+
+func (h configHandler) configJson(c domain.Config) configJson {
+	return configJson{
+		Host:                      c.Host,
+		Port:                      c.Port,
+		LogLevel:                  c.LogLevel,
+		LogPath:                   c.LogPath,
+		BaseURL:                   c.BaseURL,
+		SessionLifetimeHours:      c.SessionLifetimeHours,
+		SessionIdleTimeoutMinutes: c.SessionIdleTimeoutMinutes,
+		CheckForUpdates:           c.CheckForUpdates,
+		RestartRequired:           h.service.RestartRequired(),
+		Version:                   h.server.version,
+		Commit:                    h.server.commit,
+		Date:                      h.server.date,
+	}
 }
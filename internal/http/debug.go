@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// handlerCounter a service with long running handlers or workers
+type handlerCounter interface {
+	HandlerCounts() map[string]int
+}
+
+var processStarted = time.Now()
+
+type debugHandler struct {
+	encoder  encoder
+	counters []handlerCounter
+}
+
+func newDebugHandler(encoder encoder, counters ...handlerCounter) *debugHandler {
+	return &debugHandler{
+		encoder:  encoder,
+		counters: counters,
+	}
+}
+
+func (h debugHandler) Routes(r chi.Router) {
+	r.Get("/runtime", h.runtime)
+}
+
+func (h debugHandler) runtime(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := domain.RuntimeStats{
+		GoVersion:  runtime.Version(),
+		StartedAt:  processStarted,
+		Goroutines: runtime.NumGoroutine(),
+		CPUs:       runtime.NumCPU(),
+		Memory: domain.RuntimeMemory{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapIdle:     mem.HeapIdle,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			StackInuse:   mem.StackInuse,
+			Sys:          mem.Sys,
+			TotalAlloc:   mem.TotalAlloc,
+		},
+		GC: domain.RuntimeGC{
+			Runs:         mem.NumGC,
+			NextHeapSize: mem.NextGC,
+			PauseTotal:   mem.PauseTotalNs,
+			CPUFraction:  mem.GCCPUFraction,
+			ForcedRuns:   mem.NumForcedGC,
+		},
+		Handlers: map[string]int{},
+	}
+
+	if mem.NumGC > 0 {
+		stats.GC.LastRun = time.Unix(0, int64(mem.LastGC))
+		stats.GC.LastPause = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	for _, counter := range h.counters {
+		if counter == nil {
+			continue
+		}
+
+		for name, count := range counter.HandlerCounts() {
+			stats.Handlers[name] = count
+		}
+	}
+
+	h.encoder.StatusResponse(r.Context(), w, stats, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeDebugKeys struct{}
+
+func (fakeDebugKeys) Validate(ctx context.Context, key string) (*domain.APIKey, error) {
+	switch key {
+	case "admin-key":
+		return &domain.APIKey{Name: "profiling", Username: "admin"}, nil
+	case "bob-key":
+		return &domain.APIKey{Name: "grafana", Username: "bob"}, nil
+	}
+
+	return nil, errors.New("invalid api key")
+}
+
+type fakeDebugUsers struct {
+	userService
+}
+
+func (fakeDebugUsers) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	switch username {
+	case "admin":
+		return &domain.User{Username: "admin", Role: domain.UserRoleAdmin}, nil
+	case "bob":
+		return &domain.User{Username: "bob", Role: domain.UserRoleReadOnly}, nil
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+type fakeActionCounts struct {
+	actionService
+}
+
+func (fakeActionCounts) HandlerCounts() map[string]int {
+	return map[string]int{"action_chains_running": 2}
+}
+
+type fakeIrcCounts struct {
+	ircService
+}
+
+func (fakeIrcCounts) HandlerCounts() map[string]int {
+	return map[string]int{"irc_handlers": 3}
+}
+
+func debugServer(pprof bool) Server {
+	return Server{
+		config:        domain.Config{PprofEnabled: pprof},
+		cookieStore:   sessions.NewCookieStore([]byte("secret")),
+		apiKeyService: fakeDebugKeys{},
+		userService:   fakeDebugUsers{},
+		actionService: fakeActionCounts{},
+		ircService:    fakeIrcCounts{},
+	}
+}
+
+func TestServer_pprof(t *testing.T) {
+	handler := debugServer(true).Handler()
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{name: "admin", key: "admin-key", want: http.StatusOK},
+		{name: "read only user", key: "bob-key", want: http.StatusForbidden},
+		{name: "no key or session", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			if tt.key != "" {
+				r.Header.Set(apiKeyHeader, tt.key)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusOK {
+				assert.Contains(t, w.Body.String(), "goroutine")
+			}
+		})
+	}
+}
+
+func TestServer_pprofDisabled(t *testing.T) {
+	router := debugServer(false).Handler().(chi.Routes)
+
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		assert.False(t, strings.HasPrefix(route, "/debug/"), "profiler mounted without pprofEnabled: %v", route)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDebugHandler_runtime(t *testing.T) {
+	handler := debugServer(false).Handler()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/debug/runtime", nil)
+	r.Header.Set(apiKeyHeader, "admin-key")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var stats domain.RuntimeStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+
+	assert.Greater(t, stats.Goroutines, 0)
+	assert.Greater(t, stats.Memory.HeapAlloc, uint64(0))
+	assert.Equal(t, map[string]int{"action_chains_running": 2, "irc_handlers": 3}, stats.Handlers)
+
+	// only admins
+	r = httptest.NewRequest(http.MethodGet, "/api/debug/runtime", nil)
+	r.Header.Set(apiKeyHeader, "bob-key")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/go-chi/chi"
+)
+
+type feedService interface {
+	Find(ctx context.Context) ([]domain.Feed, error)
+	Store(ctx context.Context, feed *domain.Feed) error
+	Update(ctx context.Context, feed *domain.Feed) error
+	Delete(ctx context.Context, id int) error
+	ToggleEnabled(ctx context.Context, id int, enabled bool) error
+}
+
+type feedHandler struct {
+	encoder encoder
+	service feedService
+}
+
+func newFeedHandler(encoder encoder, service feedService) *feedHandler {
+	return &feedHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h feedHandler) Routes(r chi.Router) {
+	r.Get("/", h.find)
+	r.Post("/", h.store)
+	r.Put("/{feedID}", h.update)
+	r.Patch("/{feedID}/enabled", h.toggleEnabled)
+	r.Delete("/{feedID}", h.delete)
+}
+
+func (h feedHandler) find(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feeds, err := h.service.Find(ctx)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	if !canSeeSecrets(ctx) {
+		for i := range feeds {
+			feeds[i] = feeds[i].Redacted()
+		}
+	}
+
+	h.encoder.StatusResponse(ctx, w, feeds, http.StatusOK)
+}
+
+func (h feedHandler) store(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data *domain.Feed
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Store(ctx, data); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, data, http.StatusCreated)
+}
+
+func (h feedHandler) update(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data *domain.Feed
+	)
+
+	feedID, err := parseInt(chi.URLParam(r, "feedID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	data.ID = feedID
+
+	if err := h.service.Update(ctx, data); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, data, http.StatusOK)
+}
+
+func (h feedHandler) toggleEnabled(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data struct {
+			Enabled bool `json:"enabled"`
+		}
+	)
+
+	feedID, err := parseInt(chi.URLParam(r, "feedID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ToggleEnabled(ctx, feedID, data.Enabled); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
+
+func (h feedHandler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feedID, err := parseInt(chi.URLParam(r, "feedID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(ctx, feedID); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
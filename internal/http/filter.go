@@ -3,8 +3,11 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 
@@ -17,8 +20,15 @@ type filterService interface {
 	Store(ctx context.Context, filter domain.Filter) (*domain.Filter, error)
 	Delete(ctx context.Context, filterID int) error
 	Update(ctx context.Context, filter domain.Filter) (*domain.Filter, error)
-	Duplicate(ctx context.Context, filterID int) (*domain.Filter, error)
+	Duplicate(ctx context.Context, filterID int, name string) (*domain.Filter, error)
 	ToggleEnabled(ctx context.Context, filterID int, enabled bool) error
+	UpdateOrder(ctx context.Context, filterIDs []int) error
+	BulkUpdate(ctx context.Context, update domain.FilterBulkUpdate) error
+	TestRegex(pattern string, samples []string) ([]domain.FilterRegexTestResult, error)
+	Validate(ctx context.Context, filter domain.Filter) (*domain.FilterDiagnostics, error)
+	Test(ctx context.Context, filterID int, params domain.FilterTestParams) ([]domain.FilterTestResult, error)
+	Export(ctx context.Context, filterIDs []int) (*domain.FilterExport, error)
+	Import(ctx context.Context, data domain.FilterExport, conflict domain.FilterImportConflict) ([]domain.FilterImportResult, error)
 }
 
 type filterHandler struct {
@@ -35,10 +45,18 @@ func newFilterHandler(encoder encoder, service filterService) *filterHandler {
 
 func (h filterHandler) Routes(r chi.Router) {
 	r.Get("/", h.getFilters)
+	r.Get("/export", h.export)
+	r.Post("/import", h.importFilters)
 	r.Get("/{filterID}", h.getByID)
-	r.Get("/{filterID}/duplicate", h.duplicate)
+	r.Get("/{filterID}/export", h.export)
+	r.Post("/{filterID}/duplicate", h.duplicate)
 	r.Post("/", h.store)
+	r.Post("/regex/test", h.testRegex)
+	r.Post("/validate", h.validate)
+	r.Put("/order", h.updateOrder)
+	r.Patch("/bulk", h.bulkUpdate)
 	r.Put("/{filterID}", h.update)
+	r.Post("/{filterID}/test", h.test)
 	r.Put("/{filterID}/enabled", h.toggleEnabled)
 	r.Delete("/{filterID}", h.delete)
 }
@@ -51,6 +69,12 @@ func (h filterHandler) getFilters(w http.ResponseWriter, r *http.Request) {
 		//
 	}
 
+	if !canSeeSecrets(ctx) {
+		for i := range trackers {
+			trackers[i] = trackers[i].Redacted()
+		}
+	}
+
 	h.encoder.StatusResponse(ctx, w, trackers, http.StatusOK)
 }
 
@@ -68,6 +92,11 @@ func (h filterHandler) getByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canSeeSecrets(ctx) {
+		redacted := filter.Redacted()
+		filter = &redacted
+	}
+
 	h.encoder.StatusResponse(ctx, w, filter, http.StatusOK)
 }
 
@@ -75,17 +104,26 @@ func (h filterHandler) duplicate(w http.ResponseWriter, r *http.Request) {
 	var (
 		ctx      = r.Context()
 		filterID = chi.URLParam(r, "filterID")
+		data     struct {
+			Name string `json:"name"` // "<name> Copy" when empty
+		}
 	)
 
 	id, _ := strconv.Atoi(filterID)
 
-	filter, err := h.service.Duplicate(ctx, id)
+	// the name is optional, an empty body makes a copy with the default name
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := h.service.Duplicate(ctx, id, strings.TrimSpace(data.Name))
 	if err != nil {
 		h.encoder.StatusNotFound(ctx, w)
 		return
 	}
 
-	h.encoder.StatusResponse(ctx, w, filter, http.StatusOK)
+	h.encoder.StatusResponse(ctx, w, filter, http.StatusCreated)
 }
 
 func (h filterHandler) store(w http.ResponseWriter, r *http.Request) {
@@ -101,7 +139,7 @@ func (h filterHandler) store(w http.ResponseWriter, r *http.Request) {
 
 	filter, err := h.service.Store(ctx, data)
 	if err != nil {
-		// encode error
+		h.validationError(ctx, w, err)
 		return
 	}
 
@@ -121,13 +159,165 @@ func (h filterHandler) update(w http.ResponseWriter, r *http.Request) {
 
 	filter, err := h.service.Update(ctx, data)
 	if err != nil {
-		// encode error
+		h.validationError(ctx, w, err)
 		return
 	}
 
 	h.encoder.StatusResponse(ctx, w, filter, http.StatusOK)
 }
 
+// validationError respond with bad request for invalid fields, other errors are not encoded yet
+func (h filterHandler) validationError(ctx context.Context, w http.ResponseWriter, err error) {
+	var validationErr *domain.FilterValidationError
+	if !errors.As(err, &validationErr) {
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+		"code":    "VALIDATION_ERROR",
+		"field":   validationErr.Field,
+		"message": validationErr.Error(),
+	}, http.StatusBadRequest)
+}
+
+func (h filterHandler) testRegex(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data struct {
+			Pattern string   `json:"pattern"`
+			Samples []string `json:"samples"`
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.TestRegex(data.Pattern, data.Samples)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "INVALID_REGEX",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, results, http.StatusOK)
+}
+
+func (h filterHandler) validate(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.Filter
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	diagnostics, err := h.service.Validate(ctx, data)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, diagnostics, http.StatusOK)
+}
+
+func (h filterHandler) test(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx      = r.Context()
+		filterID = chi.URLParam(r, "filterID")
+		data     domain.FilterTestParams
+	)
+
+	id, err := strconv.Atoi(filterID)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	// an empty body tests against recent releases
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.Test(ctx, id, data)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, results, http.StatusOK)
+}
+
+func (h filterHandler) export(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		filterID  = chi.URLParam(r, "filterID")
+		filterIDs []int
+	)
+
+	// without an id every filter is exported
+	if filterID != "" {
+		id, err := strconv.Atoi(filterID)
+		if err != nil {
+			h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+			return
+		}
+		filterIDs = append(filterIDs, id)
+	}
+
+	data, err := h.service.Export(ctx, filterIDs)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	if !canSeeSecrets(ctx) {
+		for i := range data.Filters {
+			data.Filters[i] = data.Filters[i].Redacted()
+		}
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="autobrr-filters.json"`)
+
+	h.encoder.StatusResponse(ctx, w, data, http.StatusOK)
+}
+
+func (h filterHandler) importFilters(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.FilterExport
+	)
+
+	// rename, overwrite or skip filters with a name that is taken
+	conflict, err := domain.ParseFilterImportConflict(r.URL.Query().Get("conflict"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "INVALID_CONFLICT",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.Import(ctx, data, conflict)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, results, http.StatusOK)
+}
+
 func (h filterHandler) toggleEnabled(w http.ResponseWriter, r *http.Request) {
 	var (
 		ctx      = r.Context()
@@ -153,6 +343,52 @@ func (h filterHandler) toggleEnabled(w http.ResponseWriter, r *http.Request) {
 	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
 }
 
+func (h filterHandler) updateOrder(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data struct {
+			FilterIDs []int `json:"filter_ids"` // highest priority first
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateOrder(ctx, data.FilterIDs); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
+}
+
+func (h filterHandler) bulkUpdate(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.FilterBulkUpdate
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.BulkUpdate(ctx, data); err != nil {
+		var validationErr *domain.FilterValidationError
+		if errors.As(err, &validationErr) {
+			h.validationError(ctx, w, err)
+			return
+		}
+
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
+}
+
 func (h filterHandler) delete(w http.ResponseWriter, r *http.Request) {
 	var (
 		ctx      = r.Context()
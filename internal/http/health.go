@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type healthService interface {
+	Liveness() domain.HealthReport
+	Readiness(ctx context.Context) domain.HealthReport
+}
+
+type healthHandler struct {
+	encoder encoder
+	service healthService
+}
+
+func newHealthHandler(encoder encoder, service healthService) *healthHandler {
+	return &healthHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h healthHandler) Routes(r chi.Router) {
+	r.Get("/liveness", h.liveness)
+	r.Get("/readiness", h.readiness)
+}
+
+func (h healthHandler) liveness(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, h.service.Liveness(), http.StatusOK)
+}
+
+// readiness 503 when autobrr can't work, like without a database, so probes stop sending traffic
+func (h healthHandler) readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Readiness(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	h.encoder.StatusResponse(r.Context(), w, report, status)
+}
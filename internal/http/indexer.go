@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,6 +19,13 @@ type indexerService interface {
 	GetAll() ([]*domain.IndexerDefinition, error)
 	GetTemplates() ([]domain.IndexerDefinition, error)
 	Delete(ctx context.Context, id int) error
+	TestIndexer(ctx context.Context, id int) (*domain.IndexerHealth, error)
+	TorrentCacheStats() domain.TorrentCacheStats
+	PurgeTorrentCache(expiredOnly bool) (int, error)
+}
+
+type torrentCachePurgeResponse struct {
+	Removed int `json:"removed"`
 }
 
 type indexerHandler struct {
@@ -40,7 +48,10 @@ func (h indexerHandler) Routes(r chi.Router) {
 	r.Put("/", h.update)
 	r.Get("/", h.getAll)
 	r.Get("/options", h.list)
+	r.Get("/cache", h.cacheStats)
+	r.Delete("/cache", h.purgeCache)
 	r.Delete("/{indexerID}", h.delete)
+	r.Post("/{indexerID}/test", h.test)
 }
 
 func (h indexerHandler) getSchema(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +115,34 @@ func (h indexerHandler) delete(w http.ResponseWriter, r *http.Request) {
 	h.encoder.StatusResponse(ctx, w, nil, http.StatusNoContent)
 }
 
+func (h indexerHandler) test(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		idParam = chi.URLParam(r, "indexerID")
+	)
+
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	health, err := h.service.TestIndexer(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIndexerNotFound):
+			h.encoder.StatusNotFound(ctx, w)
+		case errors.Is(err, domain.ErrIndexerNotTestable):
+			h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		default:
+			h.encoder.Error(w, err)
+		}
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, health, http.StatusOK)
+}
+
 func (h indexerHandler) getAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -125,3 +164,29 @@ func (h indexerHandler) list(w http.ResponseWriter, r *http.Request) {
 
 	h.encoder.StatusResponse(ctx, w, indexers, http.StatusOK)
 }
+
+func (h indexerHandler) cacheStats(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, h.service.TorrentCacheStats(), http.StatusOK)
+}
+
+// purgeCache remove the cached torrent files, with expired=true only the ones past the ttl
+func (h indexerHandler) purgeCache(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	expiredOnly := false
+	if v := r.URL.Query().Get("expired"); v != "" {
+		var err error
+		if expiredOnly, err = strconv.ParseBool(v); err != nil {
+			h.encoder.StatusResponse(ctx, w, errorResponse{Message: "expired must be true or false", Status: http.StatusBadRequest}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	removed, err := h.service.PurgeTorrentCache(expiredOnly)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, torrentCachePurgeResponse{Removed: removed}, http.StatusOK)
+}
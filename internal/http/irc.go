@@ -19,6 +19,7 @@ type ircService interface {
 	StoreNetwork(ctx context.Context, network *domain.IrcNetwork) error
 	UpdateNetwork(ctx context.Context, network *domain.IrcNetwork) error
 	StoreChannel(networkID int64, channel *domain.IrcChannel) error
+	HandlerCounts() map[string]int
 }
 
 type ircHandler struct {
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/logger"
+)
+
+type logService interface {
+	Find(params domain.LogQueryParams) ([]domain.LogEntry, error)
+	Subscribe() (<-chan domain.LogEntry, func())
+	Settings() domain.LogSettings
+	UpdateSettings(settings domain.LogSettings) error
+}
+
+type logHandler struct {
+	encoder encoder
+	service logService
+}
+
+func newLogHandler(encoder encoder, service logService) *logHandler {
+	return &logHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h logHandler) Routes(r chi.Router) {
+	// logs can hold urls with passkeys
+	r.Use(requireRole(domain.UserRoleAdmin))
+
+	r.Get("/", h.find)
+	r.Get("/tail", h.tail)
+	r.Get("/settings", h.getSettings)
+	r.Put("/settings", h.updateSettings)
+}
+
+// find recent log entries filtered by level, module, search text and after id
+func (h logHandler) find(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params, err := logQueryParams(r.URL.Query(), 200)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.Find(params)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, entries, http.StatusOK)
+}
+
+// tail stream the last lines that match as server-sent events, then new ones as they are logged
+func (h logHandler) tail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params, err := logQueryParams(r.URL.Query(), 100)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	match, err := logger.Match(params)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: "streaming not supported", Status: http.StatusInternalServerError}, http.StatusInternalServerError)
+		return
+	}
+
+	// subscribe before reading the backlog so no line falls in between
+	entries, cancel := h.service.Subscribe()
+	defer cancel()
+
+	backlog, err := h.service.Find(params)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var lastID int64
+	for _, entry := range backlog {
+		writeLogEvent(w, entry)
+		lastID = entry.ID
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case entry := <-entries:
+			if entry.ID <= lastID || !match(entry) {
+				continue
+			}
+
+			writeLogEvent(w, entry)
+			flusher.Flush()
+		}
+	}
+}
+
+func (h logHandler) getSettings(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, h.service.Settings(), http.StatusOK)
+}
+
+// updateSettings change the log level and log file until the next restart
+func (h logHandler) updateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// fields left out of the request keep their current value
+	settings := h.service.Settings()
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "invalid log settings",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateSettings(settings); err != nil {
+		h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, h.service.Settings(), http.StatusOK)
+}
+
+func writeLogEvent(w http.ResponseWriter, entry domain.LogEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", entry.ID, b)
+}
+
+func logQueryParams(vals url.Values, defaultLimit int) (domain.LogQueryParams, error) {
+	params := domain.LogQueryParams{
+		Level:  vals.Get("level"),
+		Module: vals.Get("module"),
+		Search: vals.Get("q"),
+		Limit:  defaultLimit,
+	}
+
+	if limit := vals.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return params, fmt.Errorf("limit parameter is invalid")
+		}
+		params.Limit = value
+	}
+
+	if after := vals.Get("after"); after != "" {
+		value, err := strconv.ParseInt(after, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("after parameter is invalid")
+		}
+		params.After = value
+	}
+
+	return params, nil
+}
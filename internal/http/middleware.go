@@ -1,17 +1,234 @@
 package http
 
-import "net/http"
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type contextKey string
+
+const (
+	sessionContextKey  contextKey = "session"
+	usernameContextKey contextKey = "username"
+	roleContextKey     contextKey = "role"
+)
+
+type sessionService interface {
+	Create(ctx context.Context, session domain.Session) (string, *domain.Session, error)
+	Validate(ctx context.Context, token string) (*domain.Session, error)
+	Settings() domain.SessionSettings
+	FindByID(ctx context.Context, id int) (*domain.Session, error)
+	List(ctx context.Context, username string, authMethod string) ([]domain.Session, error)
+	Revoke(ctx context.Context, id int) error
+	RevokeUser(ctx context.Context, username string, authMethod string, exceptID int) error
+	RevokeToken(ctx context.Context, token string) error
+}
+
+// apiKeyHeader scripts send their api key in this header instead of signing in
+const apiKeyHeader = "X-API-Token"
+
+type apiKeyService interface {
+	Validate(ctx context.Context, key string) (*domain.APIKey, error)
+}
 
 func (s Server) IsAuthenticated(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			username, role, ok := s.apiKeyIdentity(r.Context(), key)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), usernameContextKey, username)
+			ctx = context.WithValue(ctx, roleContextKey, role)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// check session
-		session, _ := s.cookieStore.Get(r, "user_session")
+		session, ok := validSession(r, s.cookieStore, s.sessionService)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		username, role, ok := s.sessionIdentity(r.Context(), session)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), sessionContextKey, session)
+		ctx = context.WithValue(ctx, usernameContextKey, username)
+		ctx = context.WithValue(ctx, roleContextKey, role)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validSession the server side session of the session cookie
+func validSession(r *http.Request, cookieStore *sessions.CookieStore, service sessionService) (*domain.Session, bool) {
+	cookie, _ := cookieStore.Get(r, "user_session")
+
+	token, _ := cookie.Values["session_id"].(string)
+	if token == "" {
+		return nil, false
+	}
+
+	session, err := service.Validate(r.Context(), token)
+	if err != nil {
+		return nil, false
+	}
+
+	return session, true
+}
+
+// sessionIdentity the user and role of the session. Local users are looked up on every request
+// so role changes and deleted users apply right away, oidc users keep the role from their login.
+func (s Server) sessionIdentity(ctx context.Context, session *domain.Session) (string, domain.UserRole, bool) {
+	if session.AuthMethod == "oidc" {
+		return session.Username, session.Role, true
+	}
+
+	user, err := s.userService.FindByUsername(ctx, session.Username)
+	if err != nil || user == nil {
+		log.Debug().Msgf("http: session of unknown user %v", session.Username)
+		return "", "", false
+	}
+
+	return user.Username, user.Role, true
+}
+
+// apiKeyIdentity the user and role of the api key, the key has the role the user has now
+func (s Server) apiKeyIdentity(ctx context.Context, key string) (string, domain.UserRole, bool) {
+	apiKey, err := s.apiKeyService.Validate(ctx, key)
+	if err != nil {
+		return "", "", false
+	}
+
+	user, err := s.userService.FindByUsername(ctx, apiKey.Username)
+	if err != nil || user == nil {
+		log.Debug().Msgf("http: api key %v of unknown user %v", apiKey.Name, apiKey.Username)
+		return "", "", false
+	}
+
+	return user.Username, user.Role, true
+}
+
+// clientIP address of the client. X-Forwarded-For is only read from the configured proxies, and the
+// rightmost address that is not a proxy is used. Clients can put anything in front of that,
+// so taking an earlier one would let them dodge the login limit per address.
+func clientIP(r *http.Request, proxies domain.TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !proxies.Contains(net.ParseIP(host)) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			break
+		}
+
+		if !proxies.Contains(ip) {
+			return ip.String()
+		}
+	}
+
+	return host
+}
+
+func currentSession(ctx context.Context) *domain.Session {
+	session, _ := ctx.Value(sessionContextKey).(*domain.Session)
+	return session
+}
 
-		// Check if user is authenticated
-		if auth, ok := session.Values["authenticated"].(bool); !ok || !auth {
+func sessionUsername(ctx context.Context) string {
+	username, _ := ctx.Value(usernameContextKey).(string)
+	return username
+}
+
+// sessionAuthMethod how the user of the request signed in, api keys belong to local users
+func sessionAuthMethod(ctx context.Context) string {
+	if session := currentSession(ctx); session != nil {
+		return session.AuthMethod
+	}
+
+	return "password"
+}
+
+func sessionRole(ctx context.Context) domain.UserRole {
+	role, _ := ctx.Value(roleContextKey).(domain.UserRole)
+	return role
+}
+
+// canSeeSecrets read-only users get actions, feeds and filters without the credentials in them
+func canSeeSecrets(ctx context.Context) bool {
+	return sessionRole(ctx).Allows(domain.UserRoleOperator)
+}
+
+// requireRole only let users with at least the role through
+func requireRole(role domain.UserRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !sessionRole(r.Context()).Allows(role) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireMethodRole reading needs read-only, anything that changes something needs operator
+func requireMethodRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := domain.UserRoleOperator
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			role = domain.UserRoleReadOnly
+		}
+
+		if !sessionRole(r.Context()).Allows(role) {
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// metricsToken require the bearer token for the metrics endpoint when one is configured
+func metricsToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token != "" {
+				given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_clientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "direct", remote: "203.0.113.7:51234", want: "203.0.113.7"},
+		{name: "proxy on localhost", remote: "127.0.0.1:51234", forwarded: "198.51.100.2", want: "198.51.100.2"},
+		{name: "proxy in docker network", remote: "172.18.0.3:51234", forwarded: "198.51.100.2", want: "198.51.100.2"},
+		{name: "chained proxies", remote: "127.0.0.1:51234", forwarded: "198.51.100.2, 172.18.0.5", want: "198.51.100.2"},
+		{name: "spoofed address in front", remote: "127.0.0.1:51234", forwarded: "192.0.2.1, 198.51.100.2", want: "198.51.100.2"},
+		{name: "private peer that is not a proxy", remote: "10.0.0.9:51234", forwarded: "198.51.100.2", want: "10.0.0.9"},
+		{name: "spoofed header from the internet", remote: "203.0.113.7:51234", forwarded: "198.51.100.2", want: "203.0.113.7"},
+	}
+
+	proxies, err := domain.ParseTrustedProxies("127.0.0.1, 172.18.0.0/16")
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/auth/login", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			assert.Equal(t, tt.want, clientIP(r, proxies))
+		})
+	}
+}
+
+func Test_metricsToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "no token configured", want: http.StatusOK},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", want: http.StatusOK},
+		{name: "wrong token", token: "secret", authorization: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "missing token", token: "secret", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			metricsToken(tt.token)(ok).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) Validate(ctx context.Context, key string) (*domain.APIKey, error) {
+	switch key {
+	case "bob-key":
+		return &domain.APIKey{Name: "grafana", Username: "bob"}, nil
+	case "deleted-user-key":
+		return &domain.APIKey{Name: "old", Username: "carol"}, nil
+	}
+
+	return nil, errors.New("invalid api key")
+}
+
+type fakeUsers struct {
+	userService
+}
+
+func (fakeUsers) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	if username == "bob" {
+		return &domain.User{Username: "bob", Role: domain.UserRoleReadOnly}, nil
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+func TestServer_IsAuthenticated_apiKey(t *testing.T) {
+	s := Server{
+		cookieStore:   sessions.NewCookieStore([]byte("secret")),
+		apiKeyService: fakeAPIKeys{},
+		userService:   fakeUsers{},
+	}
+
+	var role domain.UserRole
+	handler := s.IsAuthenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role = sessionRole(r.Context())
+		assert.Equal(t, "bob", sessionUsername(r.Context()))
+		assert.Nil(t, currentSession(r.Context()))
+	}))
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{name: "valid key", key: "bob-key", want: http.StatusOK},
+		{name: "wrong key", key: "nope", want: http.StatusForbidden},
+		{name: "user deleted", key: "deleted-user-key", want: http.StatusForbidden},
+		{name: "no key or session", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/filters", nil)
+			if tt.key != "" {
+				r.Header.Set("X-API-Token", tt.key)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	assert.Equal(t, domain.UserRoleReadOnly, role, "the key has the role of the user")
+}
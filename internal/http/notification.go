@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type notificationService interface {
+	List(ctx context.Context) ([]domain.Notification, error)
+	Store(ctx context.Context, notification *domain.Notification) error
+	Update(ctx context.Context, notification *domain.Notification) error
+	Delete(ctx context.Context, id int) error
+	Test(ctx context.Context, notification domain.Notification) error
+	Preview(notification domain.Notification, event domain.NotificationEventType) (domain.NotificationPayload, error)
+}
+
+type notificationPreviewRequest struct {
+	Notification domain.Notification          `json:"notification"`
+	Event        domain.NotificationEventType `json:"event"`
+}
+
+type notificationHandler struct {
+	encoder encoder
+	service notificationService
+}
+
+func newNotificationHandler(encoder encoder, service notificationService) *notificationHandler {
+	return &notificationHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h notificationHandler) Routes(r chi.Router) {
+	r.Get("/", h.list)
+	r.Post("/", h.store)
+	r.Post("/test", h.test)
+	r.Post("/preview", h.preview)
+	r.Put("/{notificationID}", h.update)
+	r.Delete("/{notificationID}", h.delete)
+}
+
+func (h notificationHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	notifications, err := h.service.List(ctx)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, notifications, http.StatusOK)
+}
+
+func (h notificationHandler) store(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.Notification
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Store(ctx, &data); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, data, http.StatusCreated)
+}
+
+func (h notificationHandler) update(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.Notification
+	)
+
+	id, err := parseInt(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: "bad param id", Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	data.ID = id
+
+	if err := data.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Update(ctx, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.encoder.StatusNotFound(ctx, w)
+			return
+		}
+
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, data, http.StatusOK)
+}
+
+func (h notificationHandler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseInt(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: "bad param id", Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(ctx, id); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
+
+// test send a test message with the settings in the body, the agent doesn't have to be stored
+func (h notificationHandler) test(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.Notification
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	// the push service turned it down, the settings are wrong rather than autobrr
+	if err := h.service.Test(ctx, data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadGateway}, http.StatusBadGateway)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
+
+// preview render the templates of the agent in the body for an example of the event, nothing is sent
+func (h notificationHandler) preview(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data notificationPreviewRequest
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if data.Event == "" {
+		data.Event = domain.NotificationEventTest
+	}
+
+	if err := data.Notification.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	// a template can still fail on values the empty payload of validate didn't have
+	payload, err := h.service.Preview(data.Notification, data.Event)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, payload, http.StatusOK)
+}
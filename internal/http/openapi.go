@@ -0,0 +1,465 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// openAPIOperation documentation of a route. The paths and methods come from the router, so the spec can't miss one.
+type openAPIOperation struct {
+	Summary  string
+	Request  interface{} // json body
+	Response interface{} // json response, empty when the route has no body
+	Status   int         // status on success, 200 when not set
+	Query    []openAPIParam
+	Public   bool // works without a session
+	Stream   bool // text/event-stream instead of json
+
+	RequestFile  string // content type of a file upload instead of a json body
+	ResponseFile string // content type of a file download instead of json
+}
+
+type openAPIParam struct {
+	Name        string
+	Description string
+	Type        string // string, integer or boolean
+	Array       bool   // the param can be repeated
+}
+
+type authEventListResponse struct {
+	Data  []domain.AuthEvent `json:"data"`
+	Count int64              `json:"count"`
+}
+
+type releaseListResponse struct {
+	Data       []domain.Release `json:"data"`
+	NextCursor int64            `json:"next_cursor"`
+	Count      int64            `json:"count"`
+}
+
+type enabledRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+var (
+	limitParam  = openAPIParam{Name: "limit", Type: "integer", Description: "Maximum number of results"}
+	offsetParam = openAPIParam{Name: "offset", Type: "integer", Description: "Results to skip"}
+	fromParam   = openAPIParam{Name: "from", Type: "string", Description: "Only results after this RFC3339 time"}
+	toParam     = openAPIParam{Name: "to", Type: "string", Description: "Only results before this RFC3339 time"}
+	logParams   = []openAPIParam{
+		{Name: "level", Type: "string", Description: "Minimum level: trace, debug, info, warn or error"},
+		{Name: "module", Type: "string", Description: "Module like irc or filter, includes submodules"},
+		{Name: "q", Type: "string", Description: "Text in the message or error"},
+		{Name: "after", Type: "integer", Description: "Only entries with a higher id"},
+		limitParam,
+	}
+)
+
+// openAPIOperations keyed by method and route as registered on the router
+var openAPIOperations = map[string]openAPIOperation{
+	"GET /api/actions/":                       {Summary: "List actions", Response: []domain.Action{}},
+	"POST /api/actions/":                      {Summary: "Create an action", Request: domain.Action{}, Response: domain.Action{}, Status: http.StatusCreated},
+	"PUT /api/actions/{id}":                   {Summary: "Update an action", Request: domain.Action{}, Response: domain.Action{}, Status: http.StatusCreated},
+	"DELETE /api/actions/{id}":                {Summary: "Delete an action", Status: http.StatusNoContent},
+	"PATCH /api/actions/{id}/toggleEnabled":   {Summary: "Enable or disable an action", Status: http.StatusCreated},
+	"GET /api/audit/auth":                     {Summary: "Sign in attempts, newest first (admin)", Response: authEventListResponse{}, Query: []openAPIParam{{Name: "username", Type: "string"}, {Name: "ip", Type: "string"}, {Name: "type", Type: "string"}, fromParam, limitParam, offsetParam}},
+	"POST /api/auth/login":                    {Summary: "Sign in and start a session", Request: domain.LoginRequest{}, Status: http.StatusNoContent, Public: true},
+	"POST /api/auth/logout":                   {Summary: "Sign out of the current session", Status: http.StatusNoContent, Public: true},
+	"GET /api/auth/onboard":                   {Summary: "Whether the first user can still be created", Status: http.StatusNoContent, Public: true},
+	"POST /api/auth/onboard":                  {Summary: "Create the first user as admin and sign in, only while there are no users", Request: onboardRequest{}, Response: domain.User{}, Status: http.StatusCreated, Public: true},
+	"GET /api/auth/test":                      {Summary: "Check the session is valid", Status: http.StatusNoContent, Public: true},
+	"GET /api/auth/oidc/config":               {Summary: "Whether single sign-on is enabled", Response: map[string]bool{}, Public: true},
+	"GET /api/auth/oidc/login":                {Summary: "Redirect to the OpenID Connect provider", Status: http.StatusFound, Public: true},
+	"GET /api/auth/oidc/callback":             {Summary: "Return from the OpenID Connect provider", Status: http.StatusFound, Public: true, Query: []openAPIParam{{Name: "code", Type: "string"}, {Name: "state", Type: "string"}}},
+	"GET /api/auth/totp":                      {Summary: "Two-factor status of the current user", Response: domain.TOTPStatus{}},
+	"POST /api/auth/totp/enroll":              {Summary: "Start two-factor enrollment", Response: domain.TOTPEnrollment{}},
+	"POST /api/auth/totp/confirm":             {Summary: "Confirm enrollment with a code, returns the recovery codes", Request: totpCodeRequest{}, Response: map[string][]string{}},
+	"POST /api/auth/totp/disable":             {Summary: "Turn off two-factor sign in", Request: totpCodeRequest{}, Status: http.StatusNoContent},
+	"POST /api/backup":                        {Summary: "Download a backup of the database and config (admin)", ResponseFile: "application/gzip", Query: []openAPIParam{{Name: "redact", Type: "boolean", Description: "Leave out passwords, api keys, tracker credentials and sessions"}}},
+	"GET /api/config/":                        {Summary: "Server configuration and version", Response: configJson{}},
+	"PATCH /api/config/":                      {Summary: "Change and save settings, applied right away where possible (admin)", Request: domain.ConfigUpdate{}, Response: configJson{}},
+	"GET /api/download_clients/":              {Summary: "List download clients", Response: []domain.DownloadClient{}},
+	"POST /api/download_clients/":             {Summary: "Create a download client", Request: domain.DownloadClient{}, Response: domain.DownloadClient{}, Status: http.StatusCreated},
+	"PUT /api/download_clients/":              {Summary: "Update a download client", Request: domain.DownloadClient{}, Response: domain.DownloadClient{}, Status: http.StatusCreated},
+	"POST /api/download_clients/test":         {Summary: "Test the connection to a download client", Request: domain.DownloadClient{}, Status: http.StatusNoContent},
+	"DELETE /api/download_clients/{clientID}": {Summary: "Delete a download client", Status: http.StatusNoContent},
+	"GET /api/events":                         {Summary: "Server-sent events, pick the stream with ?stream=logs or ?stream=events", Stream: true, Query: []openAPIParam{{Name: "stream", Type: "string"}}},
+	"GET /api/feeds/":                         {Summary: "List feeds", Response: []domain.Feed{}},
+	"POST /api/feeds/":                        {Summary: "Create a feed", Request: domain.Feed{}, Response: domain.Feed{}, Status: http.StatusCreated},
+	"PUT /api/feeds/{feedID}":                 {Summary: "Update a feed", Request: domain.Feed{}, Response: domain.Feed{}},
+	"DELETE /api/feeds/{feedID}":              {Summary: "Delete a feed", Status: http.StatusNoContent},
+	"PATCH /api/feeds/{feedID}/enabled":       {Summary: "Enable or disable a feed", Request: enabledRequest{}, Status: http.StatusNoContent},
+	"GET /api/filters/":                       {Summary: "List filters", Response: []domain.Filter{}},
+	"POST /api/filters/":                      {Summary: "Create a filter", Request: domain.Filter{}, Response: domain.Filter{}, Status: http.StatusCreated},
+	"GET /api/filters/export":                 {Summary: "Export every filter", Response: domain.FilterExport{}},
+	"POST /api/filters/import":                {Summary: "Import exported filters", Request: domain.FilterExport{}, Response: []domain.FilterImportResult{}, Query: []openAPIParam{{Name: "conflict", Type: "string", Description: "rename, overwrite or skip filters with an existing name"}}},
+	"PUT /api/filters/order": {Summary: "Set the filter priorities, highest first", Request: struct {
+		FilterIDs []int `json:"filter_ids"`
+	}{}, Status: http.StatusNoContent},
+	"PATCH /api/filters/bulk": {Summary: "Update several filters at once", Request: domain.FilterBulkUpdate{}, Status: http.StatusNoContent},
+	"POST /api/filters/regex/test": {Summary: "Test a regex against sample release names", Request: struct {
+		Pattern string   `json:"pattern"`
+		Samples []string `json:"samples"`
+	}{}, Response: []domain.FilterRegexTestResult{}},
+	"POST /api/filters/validate":     {Summary: "Check a filter for mistakes without saving it", Request: domain.Filter{}, Response: domain.FilterDiagnostics{}},
+	"GET /api/filters/{filterID}":    {Summary: "Get a filter", Response: domain.Filter{}},
+	"PUT /api/filters/{filterID}":    {Summary: "Update a filter", Request: domain.Filter{}, Response: domain.Filter{}},
+	"DELETE /api/filters/{filterID}": {Summary: "Delete a filter", Status: http.StatusNoContent},
+	"POST /api/filters/{filterID}/duplicate": {Summary: "Duplicate a filter, with a new name when one is given", Request: struct {
+		Name string `json:"name"`
+	}{}, Response: domain.Filter{}, Status: http.StatusCreated},
+	"PUT /api/filters/{filterID}/enabled":       {Summary: "Enable or disable a filter", Request: enabledRequest{}, Status: http.StatusNoContent},
+	"GET /api/filters/{filterID}/export":        {Summary: "Export a filter", Response: domain.FilterExport{}},
+	"POST /api/filters/{filterID}/test":         {Summary: "Check release names against a filter", Request: domain.FilterTestParams{}, Response: []domain.FilterTestResult{}},
+	"GET /api/healthz/liveness":                 {Summary: "The process is up", Response: domain.HealthReport{}, Public: true},
+	"GET /api/healthz/readiness":                {Summary: "Database, irc and download client checks, 503 when autobrr can't work", Response: domain.HealthReport{}, Public: true},
+	"GET /api/indexer/":                         {Summary: "List indexers with their definitions", Response: []domain.IndexerDefinition{}},
+	"POST /api/indexer/":                        {Summary: "Add an indexer", Request: domain.Indexer{}, Response: domain.Indexer{}, Status: http.StatusCreated},
+	"PUT /api/indexer/":                         {Summary: "Update an indexer", Request: domain.Indexer{}, Response: domain.Indexer{}},
+	"GET /api/indexer/options":                  {Summary: "List indexers", Response: []domain.Indexer{}},
+	"GET /api/indexer/cache":                    {Summary: "Files in the torrent cache and how often it was used", Response: domain.TorrentCacheStats{}},
+	"DELETE /api/indexer/cache":                 {Summary: "Remove cached torrent files", Response: torrentCachePurgeResponse{}, Query: []openAPIParam{{Name: "expired", Type: "boolean", Description: "Only remove the files past the ttl"}}},
+	"GET /api/indexer/schema":                   {Summary: "Indexer definitions that can be added", Response: []domain.IndexerDefinition{}},
+	"DELETE /api/indexer/{indexerID}":           {Summary: "Delete an indexer", Status: http.StatusNoContent},
+	"POST /api/indexer/{indexerID}/test":        {Summary: "Check the indexer connection and credentials", Response: domain.IndexerHealth{}},
+	"GET /api/irc/":                             {Summary: "List irc networks with their connection health", Response: []domain.IrcNetworkWithHealth{}},
+	"POST /api/irc/":                            {Summary: "Add an irc network", Request: domain.IrcNetwork{}, Status: http.StatusNoContent},
+	"GET /api/irc/network/{networkID}":          {Summary: "Get an irc network", Response: domain.IrcNetwork{}},
+	"PUT /api/irc/network/{networkID}":          {Summary: "Update an irc network", Request: domain.IrcNetwork{}, Status: http.StatusNoContent},
+	"DELETE /api/irc/network/{networkID}":       {Summary: "Delete an irc network", Status: http.StatusNoContent},
+	"POST /api/irc/network/{networkID}/channel": {Summary: "Add a channel to an irc network", Request: domain.IrcChannel{}, Status: http.StatusNoContent},
+	"GET /api/openapi.json":                     {Summary: "This OpenAPI document", Response: map[string]interface{}{}, Public: true},
+	"GET /api/debug/runtime":                    {Summary: "Goroutines, memory, garbage collector and handler counts (admin)", Response: domain.RuntimeStats{}},
+	"GET /api/logs/":                            {Summary: "Recent log entries (admin)", Response: []domain.LogEntry{}, Query: logParams},
+	"GET /api/logs/tail":                        {Summary: "Stream log entries as server-sent events (admin)", Stream: true, Query: logParams},
+	"GET /api/logs/settings":                    {Summary: "Log level and log file (admin)", Response: domain.LogSettings{}},
+	"PUT /api/logs/settings":                    {Summary: "Change the log level and log file until restart (admin)", Request: domain.LogSettings{}, Response: domain.LogSettings{}},
+	"GET /api/notification/":                    {Summary: "List notification agents", Response: []domain.Notification{}},
+	"POST /api/notification/":                   {Summary: "Add a notification agent", Request: domain.Notification{}, Response: domain.Notification{}, Status: http.StatusCreated},
+	"POST /api/notification/preview":            {Summary: "Render the templates of the agent in the body for an example of the event", Request: notificationPreviewRequest{}, Response: domain.NotificationPayload{}},
+	"POST /api/notification/test":               {Summary: "Send a test message with the agent in the body", Request: domain.Notification{}, Status: http.StatusNoContent},
+	"PUT /api/notification/{notificationID}":    {Summary: "Update a notification agent", Request: domain.Notification{}, Response: domain.Notification{}},
+	"DELETE /api/notification/{notificationID}": {Summary: "Delete a notification agent", Status: http.StatusNoContent},
+	"GET /api/release/": {Summary: "Find releases", Response: releaseListResponse{}, Query: []openAPIParam{
+		limitParam, offsetParam,
+		{Name: "cursor", Type: "integer", Description: "Releases older than this id"},
+		{Name: "indexer", Type: "string", Array: true},
+		{Name: "filter", Type: "string", Array: true},
+		{Name: "filter_status", Type: "string"},
+		{Name: "push_status", Type: "string"},
+		{Name: "q", Type: "string", Description: "Text in the release name"},
+		fromParam, toParam,
+	}},
+	"DELETE /api/release/all":                {Summary: "Delete every release", Status: http.StatusNoContent},
+	"GET /api/release/indexers":              {Summary: "Indexers that have releases", Response: []string{}},
+	"GET /api/release/pending":               {Summary: "Releases held in the action queue with the reason and when they run", Response: []domain.PendingAction{}},
+	"POST /api/release/pending/{itemID}/run": {Summary: "Run a waiting queued action now", Status: http.StatusNoContent},
+	"DELETE /api/release/pending/{itemID}":   {Summary: "Cancel a waiting queued action and the rest of its chain", Status: http.StatusNoContent},
+	"GET /api/release/stats": {Summary: "Release counts", Response: domain.ReleaseStats{}, Query: []openAPIParam{
+		{Name: "indexer", Type: "string", Array: true},
+		{Name: "group_by", Type: "string", Array: true, Description: "indexer, filter or day"},
+		fromParam, toParam,
+	}},
+	"GET /api/release/stats/quotas": {Summary: "Download quota usage", Response: domain.QuotaStats{}},
+	"POST /api/release/prune":       {Summary: "Remove old release history now, with the configured retention when the body is empty", Request: domain.ReleaseRetention{}, Response: domain.ReleasePruneResult{}},
+	"POST /api/restore":             {Summary: "Restore a backup, applied on the next start (admin)", RequestFile: "application/gzip", Response: domain.RestoreResult{}, Query: []openAPIParam{{Name: "config", Type: "boolean", Description: "Restore the config file too, default true"}}},
+	"POST /api/release/{releaseID}/retry": {Summary: "Queue the actions for a release again", Request: struct {
+		FilterID int `json:"filter_id"`
+	}{}, Status: http.StatusAccepted},
+	"GET /api/sessions/":               {Summary: "Sessions of the current user, every session for admins", Response: []domain.Session{}},
+	"DELETE /api/sessions/":            {Summary: "Sign out every other session", Status: http.StatusNoContent, Query: []openAPIParam{{Name: "username", Type: "string", Description: "Another user (admin)"}, {Name: "auth_method", Type: "string", Description: "password or oidc, how the other user signs in (default password)"}}},
+	"DELETE /api/sessions/{sessionID}": {Summary: "Sign out a session", Status: http.StatusNoContent},
+	"GET /api/updates/":                {Summary: "Result of the last check for a new autobrr release", Response: domain.UpdateStatus{}},
+	"GET /api/users/":                  {Summary: "List users (admin)", Response: []domain.User{}},
+	"POST /api/users/":                 {Summary: "Create a user (admin)", Request: domain.CreateUserRequest{}, Response: domain.User{}, Status: http.StatusCreated},
+	"GET /api/users/me": {Summary: "The signed in user", Response: struct {
+		Username string          `json:"username"`
+		Role     domain.UserRole `json:"role"`
+	}{}},
+	"PUT /api/users/{userID}":    {Summary: "Update a user (admin)", Request: domain.UpdateUserRequest{}, Response: domain.User{}},
+	"DELETE /api/users/{userID}": {Summary: "Delete a user (admin)", Status: http.StatusNoContent},
+}
+
+var pathParamRegexp = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPIHandler serve the spec, it is built on the first request from the routes of the router
+func (s Server) openAPIHandler(router chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		spec map[string]interface{}
+		err  error
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			spec, err = s.openAPISpec(router)
+		})
+
+		if err != nil {
+			encoder{}.Error(w, err)
+			return
+		}
+
+		encoder{}.StatusResponse(r.Context(), w, spec, http.StatusOK)
+	}
+}
+
+// openAPISpec OpenAPI 3 document of every /api route
+func (s Server) openAPISpec(router chi.Routes) (map[string]interface{}, error) {
+	g := &schemaGenerator{schemas: map[string]interface{}{}}
+	paths := map[string]map[string]interface{}{}
+
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		op, ok := openAPIOperations[method+" "+route]
+		if !ok || !strings.HasPrefix(route, "/api/") {
+			// /api/events takes every method, only GET is documented
+			return nil
+		}
+
+		path := route
+		if path != "/" {
+			path = strings.TrimSuffix(path, "/")
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(method)] = g.operation(route, op)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "autobrr",
+			"description": "API of the autobrr web ui. Requests are authenticated with the session cookie from /api/auth/login, or an api key from autobrrctl create-api-key in the X-API-Token header.",
+			"version":     s.version,
+		},
+		"servers":  []interface{}{map[string]interface{}{"url": strings.TrimSuffix(s.config.BaseURL, "/")}},
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"session": []string{}}, map[string]interface{}{"apiKey": []string{}}},
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]interface{}{
+				"session": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": "user_session"},
+				"apiKey":  map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"code":    map[string]interface{}{"type": "string"},
+									"message": map[string]interface{}{"type": "string"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (g *schemaGenerator) operation(route string, op openAPIOperation) map[string]interface{} {
+	tag := strings.SplitN(strings.TrimPrefix(route, "/api/"), "/", 2)[0]
+
+	operation := map[string]interface{}{
+		"summary": op.Summary,
+		"tags":    []string{tag},
+	}
+
+	var params []interface{}
+	for _, m := range pathParamRegexp.FindAllStringSubmatch(route, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "integer"},
+		})
+	}
+	for _, p := range op.Query {
+		schema := map[string]interface{}{"type": p.Type}
+		if p.Array {
+			schema = map[string]interface{}{"type": "array", "items": schema}
+		}
+
+		param := map[string]interface{}{"name": p.Name, "in": "query", "schema": schema}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+
+	switch {
+	case op.RequestFile != "":
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				op.RequestFile: map[string]interface{}{"schema": binarySchema},
+			},
+		}
+	case op.Request != nil:
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	switch {
+	case op.ResponseFile != "":
+		response["content"] = map[string]interface{}{
+			op.ResponseFile: map[string]interface{}{"schema": binarySchema},
+		}
+	case op.Stream:
+		response["content"] = map[string]interface{}{
+			"text/event-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+		}
+	case op.Response != nil:
+		response["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response))},
+		}
+	}
+
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): response,
+		"default":            map[string]interface{}{"$ref": "#/components/responses/Error"},
+	}
+
+	if op.Public {
+		operation["security"] = []interface{}{}
+	}
+
+	return operation
+}
+
+var binarySchema = map[string]interface{}{"type": "string", "format": "binary"}
+
+// schemaGenerator json schemas of go types by their json tags, named structs end up in the components
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			// placeholder first, structs can refer to themselves
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	// interface{} can be anything
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	g.properties(t, properties)
+
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (g *schemaGenerator) properties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		// embedded structs without a name are flattened like encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.properties(ft, properties)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.Contains(tag, ",string") {
+			properties[name] = map[string]interface{}{"type": "string"}
+			continue
+		}
+
+		properties[name] = g.schema(field.Type)
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+// every api route needs documentation and every documented route has to exist
+func TestOpenAPIOperations_matchRoutes(t *testing.T) {
+	router := Server{}.Handler().(chi.Routes)
+
+	routes := map[string]bool{}
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/api/") || route == "/api/events" && method != http.MethodGet {
+			return nil
+		}
+
+		routes[method+" "+route] = true
+		_, ok := openAPIOperations[method+" "+route]
+		assert.True(t, ok, "route is not documented: %v %v", method, route)
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for key := range openAPIOperations {
+		assert.True(t, routes[key], "documented route does not exist: %v", key)
+	}
+}
+
+func TestServer_openAPIHandler(t *testing.T) {
+	s := Server{version: "dev"}
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/api/filters/{filterID}")
+	assert.Contains(t, spec.Paths["/api/filters/{filterID}"], "put")
+	assert.Equal(t, []interface{}{}, spec.Paths["/api/auth/login"]["post"]["security"])
+
+	// json tags name the properties and "-" fields are left out
+	assert.Contains(t, spec.Components.Schemas["User"].Properties, "username")
+	assert.NotContains(t, spec.Components.Schemas["User"].Properties, "password")
+	assert.Contains(t, spec.Components.Schemas, "Filter")
+}
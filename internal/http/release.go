@@ -2,9 +2,15 @@ package http
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/go-chi/chi"
@@ -13,8 +19,15 @@ import (
 type releaseService interface {
 	Find(ctx context.Context, query domain.ReleaseQueryParams) (res []domain.Release, nextCursor int64, count int64, err error)
 	GetIndexerOptions(ctx context.Context) ([]string, error)
-	Stats(ctx context.Context) (*domain.ReleaseStats, error)
+	Stats(ctx context.Context, params domain.ReleaseStatsParams) (*domain.ReleaseStats, error)
+	QuotaStats(ctx context.Context) (*domain.QuotaStats, error)
+	Retry(ctx context.Context, id int64, filterID int) error
+	ListPending(ctx context.Context) ([]domain.PendingAction, error)
+	RunPending(ctx context.Context, id int64) error
+	CancelPending(ctx context.Context, id int64) error
 	Delete(ctx context.Context) error
+	Prune(ctx context.Context, retention domain.ReleaseRetention) (*domain.ReleasePruneResult, error)
+	Retention() domain.ReleaseRetention
 }
 
 type releaseHandler struct {
@@ -32,7 +45,13 @@ func newReleaseHandler(encoder encoder, service releaseService) *releaseHandler
 func (h releaseHandler) Routes(r chi.Router) {
 	r.Get("/", h.findReleases)
 	r.Get("/stats", h.getStats)
+	r.Get("/stats/quotas", h.getQuotaStats)
 	r.Get("/indexers", h.getIndexerOptions)
+	r.Get("/pending", h.listPending)
+	r.Post("/pending/{itemID}/run", h.runPending)
+	r.Delete("/pending/{itemID}", h.cancelPending)
+	r.Post("/{releaseID}/retry", h.retryRelease)
+	r.Post("/prune", h.pruneReleases)
 	r.Delete("/all", h.deleteReleases)
 }
 
@@ -65,12 +84,30 @@ func (h releaseHandler) findReleases(w http.ResponseWriter, r *http.Request) {
 	cursor := 0
 	if cursorP != "" {
 		cursor, err = strconv.Atoi(cursorP)
-		if err != nil && cursorP != "" {
+		if err != nil {
 			h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
 				"code":    "BAD_REQUEST_PARAMS",
 				"message": "cursor parameter is invalid",
 			}, http.StatusBadRequest)
+			return
 		}
+	}
+
+	from, err := parseTimeParam(r.URL.Query().Get("from"))
+	if err != nil {
+		h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "from parameter is invalid, expected RFC3339",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseTimeParam(r.URL.Query().Get("to"))
+	if err != nil {
+		h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "to parameter is invalid, expected RFC3339",
+		}, http.StatusBadRequest)
 		return
 	}
 
@@ -83,19 +120,21 @@ func (h releaseHandler) findReleases(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	vals := u.Query()
-	indexer := vals["indexer"]
-
-	pushStatus := r.URL.Query().Get("push_status")
 
 	query := domain.ReleaseQueryParams{
 		Limit:  uint64(limit),
 		Offset: uint64(offset),
 		Cursor: uint64(cursor),
 		Sort:   nil,
-		Filters: struct {
-			Indexers   []string
-			PushStatus string
-		}{Indexers: indexer, PushStatus: pushStatus},
+		Filters: domain.ReleaseQueryFilters{
+			Indexers:     vals["indexer"],
+			FilterNames:  vals["filter"],
+			FilterStatus: vals.Get("filter_status"),
+			PushStatus:   vals.Get("push_status"),
+			From:         from,
+			To:           to,
+		},
+		Search: vals.Get("q"),
 	}
 
 	releases, nextCursor, count, err := h.service.Find(r.Context(), query)
@@ -117,6 +156,15 @@ func (h releaseHandler) findReleases(w http.ResponseWriter, r *http.Request) {
 	h.encoder.StatusResponse(r.Context(), w, ret, http.StatusOK)
 }
 
+// parseTimeParam parse optional RFC3339 query param
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
 func (h releaseHandler) getIndexerOptions(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetIndexerOptions(r.Context())
 	if err != nil {
@@ -128,8 +176,49 @@ func (h releaseHandler) getIndexerOptions(w http.ResponseWriter, r *http.Request
 }
 
 func (h releaseHandler) getStats(w http.ResponseWriter, r *http.Request) {
+	vals := r.URL.Query()
+
+	from, err := parseTimeParam(vals.Get("from"))
+	if err != nil {
+		h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "from parameter is invalid, expected RFC3339",
+		}, http.StatusBadRequest)
+		return
+	}
 
-	stats, err := h.service.Stats(r.Context())
+	to, err := parseTimeParam(vals.Get("to"))
+	if err != nil {
+		h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+			"code":    "BAD_REQUEST_PARAMS",
+			"message": "to parameter is invalid, expected RFC3339",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	params := domain.ReleaseStatsParams{
+		Indexers: vals["indexer"],
+		From:     from,
+		To:       to,
+	}
+
+	// group_by=indexer&group_by=day or group_by=indexer,day
+	for _, v := range vals["group_by"] {
+		for _, g := range strings.Split(v, ",") {
+			switch groupBy := domain.ReleaseStatsGroupBy(strings.TrimSpace(g)); groupBy {
+			case domain.ReleaseStatsGroupByIndexer, domain.ReleaseStatsGroupByFilter, domain.ReleaseStatsGroupByDay:
+				params.GroupBy = append(params.GroupBy, groupBy)
+			default:
+				h.encoder.StatusResponse(r.Context(), w, map[string]interface{}{
+					"code":    "BAD_REQUEST_PARAMS",
+					"message": "group_by parameter is invalid, expected indexer, filter or day",
+				}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	stats, err := h.service.Stats(r.Context(), params)
 	if err != nil {
 		h.encoder.StatusNotFound(r.Context(), w)
 		return
@@ -138,6 +227,98 @@ func (h releaseHandler) getStats(w http.ResponseWriter, r *http.Request) {
 	h.encoder.StatusResponse(r.Context(), w, stats, http.StatusOK)
 }
 
+func (h releaseHandler) getQuotaStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.QuotaStats(r.Context())
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(r.Context(), w, stats, http.StatusOK)
+}
+
+func (h releaseHandler) retryRelease(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		idParam = chi.URLParam(r, "releaseID")
+		data    struct {
+			FilterID int `json:"filter_id"`
+		}
+	)
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+		return
+	}
+
+	// body is optional, only needed to pick a filter for releases that were rejected
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
+			h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.service.Retry(ctx, id, data.FilterID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.encoder.StatusNotFound(ctx, w)
+		case errors.Is(err, domain.ErrReleaseNoFilter), errors.Is(err, domain.ErrReleaseNoDownloadURL), errors.Is(err, domain.ErrFilterNoActions):
+			h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		default:
+			h.encoder.Error(w, err)
+		}
+		return
+	}
+
+	// the actions are queued, not done
+	h.encoder.StatusResponse(ctx, w, nil, http.StatusAccepted)
+}
+
+func (h releaseHandler) listPending(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.service.ListPending(r.Context())
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(r.Context(), w, pending, http.StatusOK)
+}
+
+func (h releaseHandler) runPending(w http.ResponseWriter, r *http.Request) {
+	h.pendingItem(w, r, h.service.RunPending)
+}
+
+func (h releaseHandler) cancelPending(w http.ResponseWriter, r *http.Request) {
+	h.pendingItem(w, r, h.service.CancelPending)
+}
+
+// pendingItem apply fn to the queued action in the url
+func (h releaseHandler) pendingItem(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id int64) error) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: "invalid id", Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if err := fn(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.encoder.StatusNotFound(ctx, w)
+		case errors.Is(err, domain.ErrActionNotWaiting):
+			h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusConflict}, http.StatusConflict)
+		default:
+			h.encoder.Error(w, err)
+		}
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
+
 func (h releaseHandler) deleteReleases(w http.ResponseWriter, r *http.Request) {
 	err := h.service.Delete(r.Context())
 	if err != nil {
@@ -147,3 +328,38 @@ func (h releaseHandler) deleteReleases(w http.ResponseWriter, r *http.Request) {
 
 	h.encoder.NoContent(w)
 }
+
+// pruneReleases prune with the retention in the body, or the configured retention without one
+func (h releaseHandler) pruneReleases(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var retention domain.ReleaseRetention
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&retention); err != nil && err != io.EOF {
+			h.encoder.StatusResponse(ctx, w, nil, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !retention.Enabled() {
+		retention = h.service.Retention()
+	}
+
+	if err := retention.Validate(); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	if !retention.Enabled() {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: "no retention set, set max_age_days or max_rows", Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.service.Prune(ctx, retention)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, res, http.StatusOK)
+}
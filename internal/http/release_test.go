@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeRetry struct {
+	releaseService
+	err error
+}
+
+func (f fakeRetry) Retry(ctx context.Context, id int64, filterID int) error {
+	return f.err
+}
+
+func Test_releaseHandler_retryRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "queued", status: http.StatusAccepted},
+		{name: "unknown_release", err: fmt.Errorf("could not find release: 1: %w", sql.ErrNoRows), status: http.StatusNotFound},
+		{name: "no_filter", err: fmt.Errorf("release 1: %w", domain.ErrReleaseNoFilter), status: http.StatusBadRequest},
+		{name: "no_download_url", err: fmt.Errorf("release 1: %w", domain.ErrReleaseNoDownloadURL), status: http.StatusBadRequest},
+		{name: "no_actions", err: fmt.Errorf("movies: %w", domain.ErrFilterNoActions), status: http.StatusBadRequest},
+		{name: "failed", err: errors.New("queue closed"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Route("/api/release", newReleaseHandler(encoder{}, fakeRetry{err: tt.err}).Routes)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/release/1/retry", nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
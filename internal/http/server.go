@@ -7,9 +7,11 @@ import (
 	"net/http"
 
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/metrics"
 	"github.com/autobrr/autobrr/web"
 
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"github.com/gorilla/sessions"
 	"github.com/r3labs/sse/v2"
 	"github.com/rs/cors"
@@ -20,21 +22,32 @@ type Server struct {
 
 	config      domain.Config
 	cookieStore *sessions.CookieStore
+	tls         *tlsLoader
 
 	version string
 	commit  string
 	date    string
 
 	actionService         actionService
+	apiKeyService         apiKeyService
 	authService           authService
+	backupService         backupService
+	configService         configService
 	downloadClientService downloadClientService
+	feedService           feedService
 	filterService         filterService
+	healthService         healthService
 	indexerService        indexerService
 	ircService            ircService
+	logService            logService
+	notificationService   notificationService
 	releaseService        releaseService
+	sessionService        sessionService
+	updateService         updateService
+	userService           userService
 }
 
-func NewServer(config domain.Config, sse *sse.Server, version string, commit string, date string, actionService actionService, authService authService, downloadClientSvc downloadClientService, filterSvc filterService, indexerSvc indexerService, ircSvc ircService, releaseSvc releaseService) Server {
+func NewServer(config domain.Config, sse *sse.Server, version string, commit string, date string, actionService actionService, apiKeySvc apiKeyService, authService authService, backupSvc backupService, configSvc configService, downloadClientSvc downloadClientService, feedSvc feedService, filterSvc filterService, healthSvc healthService, indexerSvc indexerService, ircSvc ircService, logSvc logService, notificationSvc notificationService, releaseSvc releaseService, sessionSvc sessionService, updateSvc updateService, userSvc userService) Server {
 	return Server{
 		config:  config,
 		sse:     sse,
@@ -43,14 +56,25 @@ func NewServer(config domain.Config, sse *sse.Server, version string, commit str
 		date:    date,
 
 		cookieStore: sessions.NewCookieStore([]byte(config.SessionSecret)),
+		tls:         newTLSLoader(config),
 
 		actionService:         actionService,
+		apiKeyService:         apiKeySvc,
 		authService:           authService,
+		backupService:         backupSvc,
+		configService:         configSvc,
 		downloadClientService: downloadClientSvc,
+		feedService:           feedSvc,
 		filterService:         filterSvc,
+		healthService:         healthSvc,
 		indexerService:        indexerSvc,
 		ircService:            ircSvc,
+		logService:            logSvc,
+		notificationService:   notificationSvc,
 		releaseService:        releaseSvc,
+		sessionService:        sessionSvc,
+		updateService:         updateSvc,
+		userService:           userSvc,
 	}
 }
 
@@ -65,9 +89,33 @@ func (s Server) Open() error {
 		Handler: s.Handler(),
 	}
 
+	if s.tls != nil {
+		if err := s.tls.Reload(); err != nil {
+			return err
+		}
+
+		server.TLSConfig = s.tls.serverConfig()
+
+		return server.ServeTLS(listener, "", "")
+	}
+
 	return server.Serve(listener)
 }
 
+// TLSEnabled autobrr serves https itself
+func (s Server) TLSEnabled() bool {
+	return s.tls != nil
+}
+
+// ReloadTLS read the certificate files again, used on SIGHUP after a renewal
+func (s Server) ReloadTLS() error {
+	if s.tls == nil {
+		return nil
+	}
+
+	return s.tls.Reload()
+}
+
 func (s Server) Handler() http.Handler {
 	r := chi.NewRouter()
 
@@ -95,19 +143,65 @@ func (s Server) Handler() http.Handler {
 		fileSystem.ServeHTTP(w, r)
 	})
 
-	r.Route("/api/auth", newAuthHandler(encoder, s.config, s.cookieStore, s.authService).Routes)
+	if s.config.MetricsEnabled {
+		r.With(metricsToken(s.config.MetricsToken)).Handle("/metrics", metrics.Default.Handler())
+	}
+
+	// profiles show what autobrr is working on and can take seconds of cpu, only for admins that turned them on
+	if s.config.PprofEnabled {
+		r.Group(func(r chi.Router) {
+			r.Use(s.IsAuthenticated, requireRole(domain.UserRoleAdmin))
+
+			r.Mount("/debug", middleware.Profiler())
+		})
+	}
+
+	// probes for docker and kubernetes, no session needed
+	r.Route("/api/healthz", newHealthHandler(encoder, s.healthService).Routes)
+
+	r.Route("/api/auth", newAuthHandler(encoder, s.config, s.cookieStore, s.authService, s.sessionService).Routes)
 
 	r.Group(func(r chi.Router) {
 		r.Use(s.IsAuthenticated)
 
 		r.Route("/api", func(r chi.Router) {
-			r.Route("/actions", newActionHandler(encoder, s.actionService).Routes)
-			r.Route("/config", newConfigHandler(encoder, s).Routes)
-			r.Route("/download_clients", newDownloadClientHandler(encoder, s.downloadClientService).Routes)
-			r.Route("/filters", newFilterHandler(encoder, s.filterService).Routes)
-			r.Route("/irc", newIrcHandler(encoder, s.ircService).Routes)
-			r.Route("/indexer", newIndexerHandler(encoder, s.indexerService, s.ircService).Routes)
-			r.Route("/release", newReleaseHandler(encoder, s.releaseService).Routes)
+			// every role can see and sign out its own sessions
+			r.Route("/sessions", newSessionHandler(encoder, s.sessionService).Routes)
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireMethodRole)
+
+				r.Route("/actions", newActionHandler(encoder, s.actionService).Routes)
+				r.Route("/audit", newAuditHandler(encoder, s.authService).Routes)
+				r.Route("/config", newConfigHandler(encoder, s.configService, s).Routes)
+				r.Route("/feeds", newFeedHandler(encoder, s.feedService).Routes)
+				r.Route("/filters", newFilterHandler(encoder, s.filterService).Routes)
+				r.Route("/logs", newLogHandler(encoder, s.logService).Routes)
+				r.Route("/release", newReleaseHandler(encoder, s.releaseService).Routes)
+				r.Route("/updates", newUpdateHandler(encoder, s.updateService).Routes)
+				r.Route("/users", newUserHandler(encoder, s.userService).Routes)
+
+				// a backup has every secret autobrr knows, a restore replaces everything
+				r.Group(func(r chi.Router) {
+					r.Use(requireRole(domain.UserRoleAdmin))
+
+					backup := newBackupHandler(encoder, s.backupService)
+					r.Post("/backup", backup.backup)
+					r.Post("/restore", backup.restore)
+
+					r.Route("/debug", newDebugHandler(encoder, s.actionService, s.ircService).Routes)
+				})
+
+				// these hold credentials of clients, trackers, irc networks and push services
+				r.Group(func(r chi.Router) {
+					r.Use(requireRole(domain.UserRoleOperator))
+
+					r.Route("/download_clients", newDownloadClientHandler(encoder, s.downloadClientService).Routes)
+					r.Route("/irc", newIrcHandler(encoder, s.ircService).Routes)
+					r.Route("/indexer", newIndexerHandler(encoder, s.indexerService, s.ircService).Routes)
+					r.Route("/notification", newNotificationHandler(encoder, s.notificationService).Routes)
+				})
+			})
 
 			r.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
 
@@ -124,6 +218,8 @@ func (s Server) Handler() http.Handler {
 		})
 	})
 
+	r.Get("/api/openapi.json", s.openAPIHandler(r))
+
 	//r.HandleFunc("/*", handler.ServeHTTP)
 	r.Get("/", s.index)
 	r.Get("/*", s.index)
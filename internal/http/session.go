@@ -0,0 +1,120 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type sessionHandler struct {
+	encoder encoder
+	service sessionService
+}
+
+func newSessionHandler(encoder encoder, service sessionService) *sessionHandler {
+	return &sessionHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+// Routes every user manages their own sessions, admins see and revoke the sessions of all users
+func (h sessionHandler) Routes(r chi.Router) {
+	r.Get("/", h.list)
+	r.Delete("/", h.revokeAll)
+	r.Delete("/{sessionID}", h.revoke)
+}
+
+func (h sessionHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current := currentSession(ctx)
+
+	username := sessionUsername(ctx)
+	if sessionRole(ctx).Allows(domain.UserRoleAdmin) {
+		username = ""
+	}
+
+	list, err := h.service.List(ctx, username, sessionAuthMethod(ctx))
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	for i := range list {
+		list[i].Current = current != nil && list[i].ID == current.ID
+	}
+
+	h.encoder.StatusResponse(ctx, w, list, http.StatusOK)
+}
+
+// revokeAll sign out the other sessions of the user, admins can pass ?username= and ?auth_method= for another user
+func (h sessionHandler) revokeAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	exceptID := 0
+	if current := currentSession(ctx); current != nil {
+		exceptID = current.ID
+	}
+
+	username, authMethod := sessionUsername(ctx), sessionAuthMethod(ctx)
+
+	if other := r.URL.Query().Get("username"); other != "" {
+		otherAuthMethod := r.URL.Query().Get("auth_method")
+		if otherAuthMethod == "" {
+			otherAuthMethod = "password"
+		}
+
+		if other != username || otherAuthMethod != authMethod {
+			if !sessionRole(ctx).Allows(domain.UserRoleAdmin) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			username, authMethod = other, otherAuthMethod
+		}
+	}
+
+	if err := h.service.RevokeUser(ctx, username, authMethod, exceptID); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
+
+func (h sessionHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessionID, err := parseInt(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.service.FindByID(ctx, sessionID)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	if session == nil {
+		h.encoder.StatusNotFound(ctx, w)
+		return
+	}
+
+	own := session.Username == sessionUsername(ctx) && session.AuthMethod == sessionAuthMethod(ctx)
+	if !own && !sessionRole(ctx).Allows(domain.UserRoleAdmin) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if err := h.service.Revoke(ctx, sessionID); err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
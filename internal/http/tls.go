@@ -0,0 +1,108 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// tlsLoader certificate, key and client CA from the config files. They are read again on reload
+// so renewed certificates are picked up without a restart, connections made before keep their config.
+type tlsLoader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu     sync.RWMutex
+	config *tls.Config
+}
+
+// newTLSLoader nil when no certificate is configured and autobrr serves plain http
+func newTLSLoader(cfg domain.Config) *tlsLoader {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		return nil
+	}
+
+	return &tlsLoader{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		clientCAFile: cfg.TLSClientCAFile,
+	}
+}
+
+func (l *tlsLoader) load() error {
+	if l.certFile == "" || l.keyFile == "" {
+		return errors.New("tls: tlsCertFile and tlsKeyFile are both required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: could not load certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	// mutual tls, only clients with a certificate signed by the ca get through
+	if l.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(l.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: could not read client ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in client ca %v", l.clientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	l.mu.Lock()
+	l.config = config
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Reload read the files again, the previous certificate stays in use when they are invalid
+func (l *tlsLoader) Reload() error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("tls: loaded certificate %v", l.certFile)
+
+	return nil
+}
+
+func (l *tlsLoader) current() *tls.Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.config
+}
+
+// serverConfig every handshake uses the config of the last successful load
+func (l *tlsLoader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &l.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return l.current(), nil
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// writeCertificate self signed certificate and key for the name in dir
+func writeCertificate(t *testing.T, dir string, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestTLSLoader(t *testing.T) {
+	assert.Nil(t, newTLSLoader(domain.Config{}))
+
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "first.local")
+
+	loader := newTLSLoader(domain.Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	require.NoError(t, loader.Reload())
+
+	leaf := func() string {
+		config, err := loader.serverConfig().GetConfigForClient(nil)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+		require.NoError(t, err)
+		return cert.Subject.CommonName
+	}
+	assert.Equal(t, "first.local", leaf())
+
+	// a renewed certificate replaces the old one on reload
+	renewedCert, renewedKey := writeCertificate(t, dir, "second.local")
+	copyFile(t, renewedCert, certFile)
+	copyFile(t, renewedKey, keyFile)
+	require.NoError(t, loader.Reload())
+	assert.Equal(t, "second.local", leaf())
+
+	// a broken file keeps the current certificate
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("broken"), 0600))
+	assert.Error(t, loader.Reload())
+	assert.Equal(t, "second.local", leaf())
+
+	assert.Error(t, newTLSLoader(domain.Config{TLSCertFile: certFile}).Reload())
+}
+
+func TestTLSLoader_ClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "server.local")
+	caFile, _ := writeCertificate(t, dir, "clients")
+
+	loader := newTLSLoader(domain.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile})
+	require.NoError(t, loader.Reload())
+
+	config := loader.current()
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.NotNil(t, config.ClientCAs)
+
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("no certificates"), 0600))
+	assert.Error(t, loader.Reload())
+}
+
+func copyFile(t *testing.T, from string, to string) {
+	b, err := ioutil.ReadFile(from)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(to, b, 0600))
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type updateService interface {
+	Status() domain.UpdateStatus
+}
+
+type updateHandler struct {
+	encoder encoder
+	service updateService
+}
+
+func newUpdateHandler(encoder encoder, service updateService) *updateHandler {
+	return &updateHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h updateHandler) Routes(r chi.Router) {
+	r.Get("/", h.status)
+}
+
+// status of the last check for a new release, the check itself runs in the background
+func (h updateHandler) status(w http.ResponseWriter, r *http.Request) {
+	h.encoder.StatusResponse(r.Context(), w, h.service.Status(), http.StatusOK)
+}
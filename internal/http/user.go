@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type userService interface {
+	FindByUsername(ctx context.Context, username string) (*domain.User, error)
+	List(ctx context.Context) ([]domain.User, error)
+	Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error)
+	Update(ctx context.Context, id int, req domain.UpdateUserRequest) (*domain.User, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type userHandler struct {
+	encoder encoder
+	service userService
+}
+
+func newUserHandler(encoder encoder, service userService) *userHandler {
+	return &userHandler{
+		encoder: encoder,
+		service: service,
+	}
+}
+
+func (h userHandler) Routes(r chi.Router) {
+	r.Get("/me", h.me)
+
+	r.Group(func(r chi.Router) {
+		r.Use(requireRole(domain.UserRoleAdmin))
+
+		r.Get("/", h.list)
+		r.Post("/", h.store)
+		r.Put("/{userID}", h.update)
+		r.Delete("/{userID}", h.delete)
+	})
+}
+
+// me the signed in user and role, so the ui can hide what the user is not allowed to do
+func (h userHandler) me(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	h.encoder.StatusResponse(ctx, w, map[string]interface{}{
+		"username": sessionUsername(ctx),
+		"role":     sessionRole(ctx),
+	}, http.StatusOK)
+}
+
+func (h userHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	users, err := h.service.List(ctx)
+	if err != nil {
+		h.encoder.Error(w, err)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, users, http.StatusOK)
+}
+
+func (h userHandler) store(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.CreateUserRequest
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.Create(ctx, data)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, user, http.StatusCreated)
+}
+
+func (h userHandler) update(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		data domain.UpdateUserRequest
+	)
+
+	userID, err := parseInt(chi.URLParam(r, "userID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.Update(ctx, userID, data)
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.StatusResponse(ctx, w, user, http.StatusOK)
+}
+
+func (h userHandler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := parseInt(chi.URLParam(r, "userID"))
+	if err != nil {
+		h.encoder.StatusResponse(ctx, w, errors.New("bad param id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(ctx, userID); err != nil {
+		h.encoder.StatusResponse(ctx, w, errorResponse{Message: err.Error(), Status: http.StatusBadRequest}, http.StatusBadRequest)
+		return
+	}
+
+	h.encoder.NoContent(w)
+}
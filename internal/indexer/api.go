@@ -1,7 +1,11 @@
 package indexer
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -14,8 +18,10 @@ import (
 
 type APIService interface {
 	TestConnection(indexer string) (bool, error)
+	HasClient(indexer string) bool
 	GetTorrentByID(indexer string, torrentID string) (*domain.TorrentBasic, error)
-	AddClient(indexer string, settings map[string]string) error
+	EnrichRelease(ctx context.Context, release *domain.Release) error
+	AddClient(indexer string, settings map[string]string, api *domain.IndexerAPI, proxy string) error
 	RemoveClient(indexer string) error
 }
 
@@ -24,18 +30,48 @@ type apiClient interface {
 	TestAPI() (bool, error)
 }
 
+// metadataClient is implemented by api clients that can return more than the basic torrent info
+type metadataClient interface {
+	GetTorrentMetadata(ctx context.Context, torrentID string) (*domain.TorrentMetadata, error)
+}
+
+// rateLimitSetter is implemented by api clients that can take the limits of the indexer definition
+type rateLimitSetter interface {
+	SetRateLimit(max int, per time.Duration)
+}
+
+// httpClientSetter is implemented by api clients that can send requests through a proxy
+type httpClientSetter interface {
+	SetHTTPClient(client *http.Client)
+}
+
 type apiService struct {
+	// guards apiClients and enrich, announces read them while indexers are added and updated
+	m          sync.RWMutex
 	apiClients map[string]apiClient
+
+	// indexers where the definition asks for releases to be enriched
+	enrich map[string]bool
 }
 
 func NewAPIService() APIService {
 	return &apiService{
 		apiClients: make(map[string]apiClient),
+		enrich:     make(map[string]bool),
 	}
 }
 
-func (s *apiService) GetTorrentByID(indexer string, torrentID string) (*domain.TorrentBasic, error) {
+// client the api client of the indexer
+func (s *apiService) client(indexer string) (apiClient, bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
 	v, ok := s.apiClients[indexer]
+	return v, ok
+}
+
+func (s *apiService) GetTorrentByID(indexer string, torrentID string) (*domain.TorrentBasic, error) {
+	v, ok := s.client(indexer)
 	if !ok {
 		return nil, nil
 	}
@@ -53,8 +89,48 @@ func (s *apiService) GetTorrentByID(indexer string, torrentID string) (*domain.T
 	return t, nil
 }
 
+// EnrichRelease fill in missing release fields from the indexer api if the definition has enrich enabled
+func (s *apiService) EnrichRelease(ctx context.Context, release *domain.Release) error {
+	if release.TorrentID == "" {
+		return nil
+	}
+
+	s.m.RLock()
+	v, ok := s.apiClients[release.Indexer]
+	enrich := s.enrich[release.Indexer]
+	s.m.RUnlock()
+
+	if !ok || !enrich {
+		return nil
+	}
+
+	mc, ok := v.(metadataClient)
+	if !ok {
+		return nil
+	}
+
+	log.Trace().Str("service", "api").Str("method", "EnrichRelease").Msgf("'%v' trying to fetch torrent metadata from api", release.Indexer)
+
+	m, err := mc.GetTorrentMetadata(ctx, release.TorrentID)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("could not get torrent metadata: '%v' from: %v", release.TorrentID, release.Indexer)
+		return err
+	}
+
+	release.ApplyMetadata(m)
+
+	log.Trace().Str("service", "api").Str("method", "EnrichRelease").Msgf("'%v' successfully enriched release: %v", release.Indexer, release.TorrentName)
+
+	return nil
+}
+
+func (s *apiService) HasClient(indexer string) bool {
+	_, ok := s.client(indexer)
+	return ok
+}
+
 func (s *apiService) TestConnection(indexer string) (bool, error) {
-	v, ok := s.apiClients[indexer]
+	v, ok := s.client(indexer)
 	if !ok {
 		return false, nil
 	}
@@ -67,7 +143,7 @@ func (s *apiService) TestConnection(indexer string) (bool, error) {
 	return t, nil
 }
 
-func (s *apiService) AddClient(indexer string, settings map[string]string) error {
+func (s *apiService) AddClient(indexer string, settings map[string]string, api *domain.IndexerAPI, proxy string) error {
 	// basic validation
 	if indexer == "" {
 		return fmt.Errorf("api_service.add_client: validation falied: indexer can't be empty")
@@ -78,13 +154,14 @@ func (s *apiService) AddClient(indexer string, settings map[string]string) error
 	log.Trace().Msgf("api-service.add_client: init api client for '%v'", indexer)
 
 	// init client
+	var client apiClient
 	switch indexer {
 	case "btn":
 		key, ok := settings["api_key"]
 		if !ok || key == "" {
 			return fmt.Errorf("api_service: could not initialize btn client: missing var 'api_key'")
 		}
-		s.apiClients[indexer] = btn.NewClient("", key)
+		client = btn.NewClient("", key)
 
 	case "ptp":
 		user, ok := settings["api_user"]
@@ -96,34 +173,82 @@ func (s *apiService) AddClient(indexer string, settings map[string]string) error
 		if !ok || key == "" {
 			return fmt.Errorf("api_service: could not initialize ptp client: missing var 'api_key'")
 		}
-		s.apiClients[indexer] = ptp.NewClient("", user, key)
+		client = ptp.NewClient("", user, key)
 
 	case "ggn":
 		key, ok := settings["api_key"]
 		if !ok || key == "" {
 			return fmt.Errorf("api_service: could not initialize ggn client: missing var 'api_key'")
 		}
-		s.apiClients[indexer] = ggn.NewClient("", key)
+		client = ggn.NewClient("", key)
 
 	case "redacted":
 		key, ok := settings["api_key"]
 		if !ok || key == "" {
 			return fmt.Errorf("api_service: could not initialize red client: missing var 'api_key'")
 		}
-		s.apiClients[indexer] = red.NewClient("", key)
+		client = red.NewClient("", key)
+
+	case "ops":
+		key, ok := settings["api_key"]
+		if !ok || key == "" {
+			return fmt.Errorf("api_service: could not initialize ops client: missing var 'api_key'")
+		}
+
+		// orpheus runs the same gazelle json api but wants the key prefixed with token
+		url := "https://orpheus.network/ajax.php"
+		if api != nil && api.URL != "" {
+			url = api.URL
+		}
+		client = red.NewClient(url, "token "+key)
 
 	default:
 		return fmt.Errorf("api_service: could not initialize client: unsupported indexer '%v'", indexer)
 
 	}
+
+	if proxy != "" {
+		proxyURL, err := domain.ParseIndexerProxy(proxy)
+		if err != nil {
+			// don't keep using an older client that skips the proxy
+			s.RemoveClient(indexer)
+			return fmt.Errorf("api_service: could not initialize client for '%v': %w", indexer, err)
+		}
+
+		if c, ok := client.(httpClientSetter); ok {
+			c.SetHTTPClient(&http.Client{
+				Timeout:   time.Second * 30,
+				Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)},
+			})
+		}
+	}
+
+	if api != nil && api.Limits.Max > 0 {
+		per, err := api.Limits.Period()
+		if err != nil {
+			s.RemoveClient(indexer)
+			return fmt.Errorf("api_service: could not initialize client for '%v': %w", indexer, err)
+		}
+
+		if c, ok := client.(rateLimitSetter); ok {
+			c.SetRateLimit(api.Limits.Max, per)
+		}
+	}
+
+	s.m.Lock()
+	s.apiClients[indexer] = client
+	s.enrich[indexer] = api != nil && api.Enrich
+	s.m.Unlock()
+
 	return nil
 }
 
 func (s *apiService) RemoveClient(indexer string) error {
-	_, ok := s.apiClients[indexer]
-	if ok {
-		delete(s.apiClients, indexer)
-	}
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.apiClients, indexer)
+	delete(s.enrich, indexer)
 
 	return nil
 }
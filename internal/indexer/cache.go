@@ -0,0 +1,357 @@
+package indexer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// TorrentCache downloaded torrent files kept on disk, so the same torrent is fetched from the tracker only once
+type TorrentCache interface {
+	Load(release *domain.Release) bool
+	Store(release *domain.Release)
+	Stats() domain.TorrentCacheStats
+	Purge(expiredOnly bool) (int, error)
+}
+
+type torrentCache struct {
+	dir string
+	ttl time.Duration
+
+	m sync.Mutex
+	// files per key of the download url
+	files map[string]cachedTorrent
+	// url key per infohash, for lookups of a torrent downloaded from another url
+	hashes map[string]string
+
+	// hits torrents loaded from the cache, misses torrents downloaded
+	hits   int64
+	misses int64
+}
+
+type cachedTorrent struct {
+	hash   string
+	size   uint64
+	bytes  int64
+	stored time.Time
+}
+
+// NewTorrentCache cache in dir, files already in it from an earlier run are picked up
+func NewTorrentCache(dir string, settings domain.TorrentCacheSettings) TorrentCache {
+	c := &torrentCache{
+		dir:    dir,
+		ttl:    settings.TTL,
+		files:  make(map[string]cachedTorrent),
+		hashes: make(map[string]string),
+	}
+
+	if !settings.Enabled() {
+		return c
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Error().Err(err).Msgf("torrent cache: could not create folder: %v, caching is off", dir)
+		c.ttl = 0
+		return c
+	}
+
+	c.scan()
+
+	return c
+}
+
+// cacheKey file name for a download url, the url itself often holds a passkey so it is not written to disk
+func cacheKey(torrentURL string) string {
+	sum := sha1.Sum([]byte(torrentURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *torrentCache) path(key string) string {
+	return filepath.Join(c.dir, key+".torrent")
+}
+
+// scan index the files in the cache folder, files that are not a torrent are removed
+func (c *torrentCache) scan() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		log.Error().Err(err).Msgf("torrent cache: could not read folder: %v", c.dir)
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".torrent") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		key := strings.TrimSuffix(name, ".torrent")
+
+		file, err := readCachedTorrent(c.path(key))
+		if err != nil {
+			log.Debug().Err(err).Msgf("torrent cache: removing unreadable file: %v", name)
+			os.Remove(c.path(key))
+			continue
+		}
+
+		file.bytes = info.Size()
+		file.stored = info.ModTime()
+
+		c.add(key, file)
+	}
+
+	log.Debug().Msgf("torrent cache: found %d files in %v", len(c.files), c.dir)
+}
+
+func readCachedTorrent(path string) (cachedTorrent, error) {
+	meta, err := metainfo.LoadFromFile(path)
+	if err != nil {
+		return cachedTorrent{}, err
+	}
+
+	info, err := meta.UnmarshalInfo()
+	if err != nil {
+		return cachedTorrent{}, err
+	}
+
+	return cachedTorrent{
+		hash: meta.HashInfoBytes().String(),
+		size: uint64(info.TotalLength()),
+	}, nil
+}
+
+func (c *torrentCache) add(key string, file cachedTorrent) {
+	if old, ok := c.files[key]; ok && c.hashes[old.hash] == key {
+		delete(c.hashes, old.hash)
+	}
+
+	c.files[key] = file
+	if file.hash != "" {
+		c.hashes[file.hash] = key
+	}
+}
+
+func (c *torrentCache) remove(key string) error {
+	file, ok := c.files[key]
+	if !ok {
+		return nil
+	}
+
+	delete(c.files, key)
+	if c.hashes[file.hash] == key {
+		delete(c.hashes, file.hash)
+	}
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Debug().Err(err).Msgf("torrent cache: could not remove file: %v", key)
+		return err
+	}
+
+	return nil
+}
+
+// Load give the release a copy of its cached torrent file, looked up by url or by infohash when the release has one.
+// The copy is the release's own tmp file, so actions can remove it like a downloaded one.
+func (c *torrentCache) Load(release *domain.Release) bool {
+	// the download with the freeleech token has to go to the tracker
+	if c.ttl <= 0 || release.FreeleechTokenURL.Pending() {
+		return false
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	key, file, ok := c.lookup(release)
+	if !ok {
+		return false
+	}
+
+	if time.Since(file.stored) > c.ttl {
+		c.remove(key)
+		return false
+	}
+
+	tmpFile, err := copyToTemp(c.path(key))
+	if err != nil {
+		log.Error().Err(err).Msgf("torrent cache: could not copy cached file for: %v", release.TorrentName)
+		c.remove(key)
+		return false
+	}
+
+	release.TorrentTmpFile = tmpFile
+	release.TorrentHash = file.hash
+	release.Size = file.size
+
+	c.hits++
+
+	log.Debug().Msgf("torrent cache: using cached file for: %v", release.TorrentName)
+
+	return true
+}
+
+func (c *torrentCache) lookup(release *domain.Release) (string, cachedTorrent, bool) {
+	if release.TorrentURL != "" {
+		key := cacheKey(release.TorrentURL)
+		if file, ok := c.files[key]; ok {
+			return key, file, true
+		}
+	}
+
+	if release.TorrentHash != "" {
+		if key, ok := c.hashes[strings.ToLower(release.TorrentHash)]; ok {
+			return key, c.files[key], true
+		}
+	}
+
+	return "", cachedTorrent{}, false
+}
+
+// Store keep the downloaded torrent file of the release, a failure only means it is downloaded again next time
+func (c *torrentCache) Store(release *domain.Release) {
+	if c.ttl <= 0 || release.TorrentURL == "" || release.TorrentTmpFile == "" {
+		return
+	}
+
+	c.m.Lock()
+	c.misses++
+	c.m.Unlock()
+
+	key := cacheKey(release.TorrentURL)
+
+	// write next to the final file and rename, so a half written file is never loaded
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		log.Error().Err(err).Msg("torrent cache: could not create file")
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	bytes, err := copyFile(tmp, release.TorrentTmpFile)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("torrent cache: could not store file for: %v", release.TorrentName)
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		log.Error().Err(err).Msgf("torrent cache: could not store file for: %v", release.TorrentName)
+		return
+	}
+
+	c.add(key, cachedTorrent{
+		hash:   strings.ToLower(release.TorrentHash),
+		size:   release.Size,
+		bytes:  bytes,
+		stored: time.Now(),
+	})
+}
+
+// Stats files and bytes in the cache, and the hits and misses since the start
+func (c *torrentCache) Stats() domain.TorrentCacheStats {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	stats := domain.TorrentCacheStats{
+		Enabled:    c.ttl > 0,
+		TTLMinutes: int(c.ttl / time.Minute),
+		Files:      len(c.files),
+		Hits:       c.hits,
+		Misses:     c.misses,
+	}
+
+	for _, file := range c.files {
+		stats.Bytes += file.bytes
+	}
+
+	return stats
+}
+
+// Purge remove the cached files, or only the ones past the ttl. Returns the number of files removed.
+func (c *torrentCache) Purge(expiredOnly bool) (int, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var (
+		removed  int
+		firstErr error
+	)
+
+	for key, file := range c.files {
+		if expiredOnly && time.Since(file.stored) <= c.ttl {
+			continue
+		}
+
+		if err := c.remove(key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		log.Debug().Msgf("torrent cache: removed %d files", removed)
+	}
+
+	return removed, firstErr
+}
+
+func copyToTemp(src string) (string, error) {
+	tmpFile, err := os.CreateTemp("", "autobrr-")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = copyFile(tmpFile, src)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return tmpFile.Name(), nil
+}
+
+func copyFile(dst io.Writer, src string) (int64, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(dst, f)
+}
+
+// CachePruneJob remove the torrent files past their ttl
+type CachePruneJob struct {
+	cache TorrentCache
+}
+
+func NewCachePruneJob(cache TorrentCache) *CachePruneJob {
+	return &CachePruneJob{cache: cache}
+}
+
+func (j *CachePruneJob) Run() {
+	if _, err := j.cache.Purge(true); err != nil {
+		log.Error().Err(err).Msg("torrent cache: scheduled prune failed")
+	}
+}
@@ -0,0 +1,182 @@
+package indexer
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func testTorrent(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.mkv"), []byte("some content"), 0644))
+
+	info := metainfo.Info{PieceLength: 16384}
+	require.NoError(t, info.BuildFromFilePath(dir))
+
+	infoBytes, err := bencode.Marshal(info)
+	require.NoError(t, err)
+
+	mi := metainfo.MetaInfo{InfoBytes: infoBytes}
+
+	var b bytes.Buffer
+	require.NoError(t, mi.Write(&b))
+
+	return b.Bytes(), mi.HashInfoBytes().String()
+}
+
+func Test_service_DownloadTorrentFile_cache(t *testing.T) {
+	torrent, hash := testTorrent(t)
+
+	var downloads int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		w.Write(torrent)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	settings := domain.TorrentCacheSettings{TTL: time.Hour}
+
+	s := NewService(nil, NewAPIService(), NewDownloadLimiter(), NewTorrentCache(dir, settings), nil, domain.IndexerAuthSettings{})
+
+	first := &domain.Release{TorrentName: "Show.S01E01", TorrentURL: ts.URL + "/dl/1"}
+	require.NoError(t, s.DownloadTorrentFile(first))
+	defer os.Remove(first.TorrentTmpFile)
+
+	second := &domain.Release{TorrentName: "Show.S01E01", TorrentURL: ts.URL + "/dl/1"}
+	require.NoError(t, s.DownloadTorrentFile(second))
+	defer os.Remove(second.TorrentTmpFile)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	assert.NotEqual(t, first.TorrentTmpFile, second.TorrentTmpFile, "each release gets its own copy")
+	assert.Equal(t, hash, second.TorrentHash)
+	assert.Equal(t, first.Size, second.Size)
+
+	// cross-seed: same torrent from another url, found by infohash
+	crossSeed := &domain.Release{TorrentName: "Show.S01E01", TorrentURL: ts.URL + "/dl/other", TorrentHash: hash}
+	require.NoError(t, s.DownloadTorrentFile(crossSeed))
+	defer os.Remove(crossSeed.TorrentTmpFile)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+
+	stats := s.TorrentCacheStats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, 60, stats.TTLMinutes)
+	assert.Equal(t, 1, stats.Files)
+	assert.Equal(t, int64(len(torrent)), stats.Bytes)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+
+	// a restart picks up the files already in the folder
+	restarted := NewTorrentCache(dir, settings)
+
+	again := &domain.Release{TorrentURL: ts.URL + "/dl/1"}
+	assert.True(t, restarted.Load(again))
+	defer os.Remove(again.TorrentTmpFile)
+	assert.Equal(t, hash, again.TorrentHash)
+
+	removed, err := s.PurgeTorrentCache(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 0, s.TorrentCacheStats().Files)
+
+	third := &domain.Release{TorrentURL: ts.URL + "/dl/1"}
+	require.NoError(t, s.DownloadTorrentFile(third))
+	defer os.Remove(third.TorrentTmpFile)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&downloads))
+}
+
+func Test_service_DownloadTorrentFile_freeleechToken(t *testing.T) {
+	torrent, _ := testTorrent(t)
+
+	var tokens int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("usetoken") == "1" {
+			atomic.AddInt32(&tokens, 1)
+		}
+		w.Write(torrent)
+	}))
+	defer ts.Close()
+
+	s := NewService(nil, NewAPIService(), NewDownloadLimiter(), NewTorrentCache(t.TempDir(), domain.TorrentCacheSettings{TTL: time.Hour}), nil, domain.IndexerAuthSettings{})
+
+	// the size check already put the torrent from the plain url in the cache
+	sizeCheck := &domain.Release{TorrentURL: ts.URL + "/dl/1"}
+	require.NoError(t, s.DownloadTorrentFile(sizeCheck))
+	defer os.Remove(sizeCheck.TorrentTmpFile)
+
+	release := domain.Release{TorrentURL: ts.URL + "/dl/1", FreeleechTokenURL: domain.NewTokenURL(ts.URL + "/dl/1?usetoken=1")}
+
+	// every action gets a copy of the release, only the first spends the token
+	for i := 0; i < 2; i++ {
+		rls := release
+		require.NoError(t, s.DownloadTorrentFile(&rls))
+		defer os.Remove(rls.TorrentTmpFile)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokens))
+	assert.Equal(t, ts.URL+"/dl/1", release.TorrentURL)
+}
+
+func Test_torrentCache_expired(t *testing.T) {
+	torrent, _ := testTorrent(t)
+
+	src := filepath.Join(t.TempDir(), "download.torrent")
+	require.NoError(t, os.WriteFile(src, torrent, 0644))
+
+	c := NewTorrentCache(t.TempDir(), domain.TorrentCacheSettings{TTL: time.Hour}).(*torrentCache)
+
+	c.Store(&domain.Release{TorrentURL: "https://tracker.test/dl/1", TorrentTmpFile: src})
+	c.Store(&domain.Release{TorrentURL: "https://tracker.test/dl/2", TorrentTmpFile: src})
+
+	old := c.files[cacheKey("https://tracker.test/dl/1")]
+	old.stored = time.Now().Add(-2 * time.Hour)
+	c.files[cacheKey("https://tracker.test/dl/1")] = old
+
+	assert.False(t, c.Load(&domain.Release{TorrentURL: "https://tracker.test/dl/1"}))
+
+	c.Store(&domain.Release{TorrentURL: "https://tracker.test/dl/1", TorrentTmpFile: src})
+	old = c.files[cacheKey("https://tracker.test/dl/1")]
+	old.stored = time.Now().Add(-2 * time.Hour)
+	c.files[cacheKey("https://tracker.test/dl/1")] = old
+
+	removed, err := c.Purge(true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, c.Stats().Files)
+
+	_, err = os.Stat(c.path(cacheKey("https://tracker.test/dl/1")))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_torrentCache_disabled(t *testing.T) {
+	torrent, _ := testTorrent(t)
+
+	src := filepath.Join(t.TempDir(), "download.torrent")
+	require.NoError(t, os.WriteFile(src, torrent, 0644))
+
+	dir := filepath.Join(t.TempDir(), "torrent-cache")
+	c := NewTorrentCache(dir, domain.TorrentCacheSettings{})
+
+	c.Store(&domain.Release{TorrentURL: "https://tracker.test/dl/1", TorrentTmpFile: src})
+	assert.False(t, c.Load(&domain.Release{TorrentURL: "https://tracker.test/dl/1"}))
+	assert.False(t, c.Stats().Enabled)
+
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "no folder without the cache")
+}
@@ -0,0 +1,387 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/torznab"
+)
+
+// HealthCheckJob periodically check all enabled indexers
+type HealthCheckJob struct {
+	indexerSvc Service
+}
+
+func NewHealthCheckJob(indexerSvc Service) *HealthCheckJob {
+	return &HealthCheckJob{indexerSvc: indexerSvc}
+}
+
+func (j *HealthCheckJob) Run() {
+	j.indexerSvc.CheckHealth()
+}
+
+// TestIndexer check the stored credentials for an indexer and update its health status
+func (s *service) TestIndexer(ctx context.Context, id int) (*domain.IndexerHealth, error) {
+	indexers, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, indexer := range indexers {
+		if indexer.ID != id {
+			continue
+		}
+
+		if !indexer.Testable {
+			return nil, domain.ErrIndexerNotTestable
+		}
+
+		health := s.checkIndexer(ctx, indexer)
+
+		return &health, nil
+	}
+
+	return nil, fmt.Errorf("indexer.TestIndexer: could not find indexer with id: %v: %w", id, domain.ErrIndexerNotFound)
+}
+
+// CheckHealth check all enabled indexers
+func (s *service) CheckHealth() {
+	indexers, err := s.GetAll()
+	if err != nil {
+		log.Error().Err(err).Msg("indexer.CheckHealth: could not get indexers")
+		return
+	}
+
+	for _, indexer := range indexers {
+		if !indexer.Enabled {
+			continue
+		}
+
+		health := s.checkIndexer(context.Background(), indexer)
+		if health.Status == domain.IndexerHealthError {
+			log.Warn().Msgf("indexer.CheckHealth: %v: %v", indexer.Identifier, health.Message)
+		}
+	}
+}
+
+func (s *service) checkIndexer(ctx context.Context, indexer *domain.IndexerDefinition) domain.IndexerHealth {
+	health := domain.IndexerHealth{
+		Status:    domain.IndexerHealthOK,
+		CheckedAt: time.Now(),
+	}
+
+	err := s.testIndexer(ctx, indexer)
+
+	switch {
+	case err == domain.ErrIndexerNotTestable:
+		health.Status = domain.IndexerHealthUnknown
+		health.Message = err.Error()
+	case err != nil:
+		health.Status = domain.IndexerHealthError
+		health.Message = err.Error()
+	}
+
+	s.healthMtx.Lock()
+	defer s.healthMtx.Unlock()
+
+	// a passing check does not prove the passkey, only a download that works clears the flag
+	if current, ok := s.health[indexer.Identifier]; ok && current.Status == domain.IndexerHealthCredentialsInvalid {
+		return current
+	}
+
+	s.health[indexer.Identifier] = health
+
+	return health
+}
+
+// authFailed count a download the tracker refused on the credentials,
+// the indexer is flagged once the configured number of failures in a row is reached
+func (s *service) authFailed(indexer string, err error) {
+	reason := authFailureReason(err)
+
+	s.healthMtx.Lock()
+	s.authFailures[indexer]++
+	failures := s.authFailures[indexer]
+
+	flagged := failures == s.auth.Failures
+	if flagged {
+		s.health[indexer] = domain.IndexerHealth{
+			Status:    domain.IndexerHealthCredentialsInvalid,
+			Message:   fmt.Sprintf("%d torrent downloads in a row failed: %v", failures, reason),
+			CheckedAt: time.Now(),
+		}
+	}
+	s.healthMtx.Unlock()
+
+	if !flagged {
+		return
+	}
+
+	log.Warn().Msgf("indexer %v: credentials look invalid, %d torrent downloads in a row failed: %v", indexer, failures, reason)
+
+	// kept over a restart, the filters paused for it stay paused until it works again
+	if s.repo != nil {
+		if err := s.repo.SetCredentialsInvalid(context.Background(), indexer, reason); err != nil {
+			log.Error().Err(err).Msgf("indexer %v: could not store the credentials flag", indexer)
+		}
+	}
+
+	s.publishCredentials(domain.EventIndexerCredentials, indexer, failures, reason)
+}
+
+// authOK a download worked, clear the failures and the flag
+func (s *service) authOK(indexer string) {
+	s.healthMtx.Lock()
+
+	if s.authFailures[indexer] == 0 {
+		s.healthMtx.Unlock()
+		return
+	}
+
+	delete(s.authFailures, indexer)
+
+	flagged := s.health[indexer].Status == domain.IndexerHealthCredentialsInvalid
+	if flagged {
+		s.health[indexer] = domain.IndexerHealth{Status: domain.IndexerHealthOK, CheckedAt: time.Now()}
+	}
+	s.healthMtx.Unlock()
+
+	if flagged {
+		log.Info().Msgf("indexer %v: torrent download works again, credentials no longer flagged", indexer)
+
+		s.clearCredentialsFlag(indexer)
+	}
+}
+
+// resetAuthFailures forget the failures of an indexer, the next health check or download decides its status
+func (s *service) resetAuthFailures(indexer string) {
+	s.healthMtx.Lock()
+
+	delete(s.authFailures, indexer)
+
+	flagged := s.health[indexer].Status == domain.IndexerHealthCredentialsInvalid
+	if flagged {
+		delete(s.health, indexer)
+	}
+	s.healthMtx.Unlock()
+
+	if flagged {
+		s.clearCredentialsFlag(indexer)
+	}
+}
+
+// clearCredentialsFlag remove the stored flag and resume the filters that were paused for it
+func (s *service) clearCredentialsFlag(indexer string) {
+	if s.repo != nil {
+		if err := s.repo.SetCredentialsInvalid(context.Background(), indexer, ""); err != nil {
+			log.Error().Err(err).Msgf("indexer %v: could not clear the credentials flag", indexer)
+		}
+	}
+
+	s.publishCredentials(domain.EventIndexerCredentialsValid, indexer, 0, "")
+}
+
+// loadCredentialsFlags flag the indexers again that were flagged before the restart
+func (s *service) loadCredentialsFlags(ctx context.Context) error {
+	flagged, err := s.repo.ListCredentialsInvalid(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.healthMtx.Lock()
+	defer s.healthMtx.Unlock()
+
+	for indexer, reason := range flagged {
+		// a download that works clears it
+		s.authFailures[indexer] = s.auth.Failures
+		s.health[indexer] = domain.IndexerHealth{
+			Status:    domain.IndexerHealthCredentialsInvalid,
+			Message:   fmt.Sprintf("torrent downloads failed: %v", reason),
+			CheckedAt: time.Now(),
+		}
+	}
+
+	return nil
+}
+
+func (s *service) publishCredentials(topic string, indexer string, failures int, reason string) {
+	if s.bus == nil {
+		return
+	}
+
+	name := indexer
+	if def := s.getDefinitionByName(indexer); def != nil {
+		name = def.Name
+	}
+
+	s.bus.Publish(topic, &domain.IndexerCredentialsEvent{
+		Indexer:      indexer,
+		Name:         name,
+		Failures:     failures,
+		Reason:       reason,
+		PauseFilters: s.auth.PauseFilters,
+	})
+}
+
+// authFailureReason why the download failed, without the url as it holds the passkey
+func authFailureReason(err error) string {
+	var downloadErr *domain.DownloadError
+	if errors.As(err, &downloadErr) && downloadErr.StatusCode != http.StatusOK {
+		return fmt.Sprintf("status code: %d", downloadErr.StatusCode)
+	}
+
+	return "redirected to login page"
+}
+
+// canTest whether testIndexer has a way to check the indexer
+func (s *service) canTest(indexer *domain.IndexerDefinition) bool {
+	if s.apiService.HasClient(indexer.Identifier) {
+		return true
+	}
+
+	if indexer.Test != nil && indexer.Test.URL != "" {
+		return true
+	}
+
+	switch indexer.Implementation {
+	case "torznab", "newznab", "rss":
+		return true
+	}
+
+	return false
+}
+
+// testIndexer prefer the tracker api, then the definition test path and lastly the generic feed url
+func (s *service) testIndexer(ctx context.Context, indexer *domain.IndexerDefinition) error {
+	if s.apiService.HasClient(indexer.Identifier) {
+		ok, err := s.apiService.TestConnection(indexer.Identifier)
+		if err != nil {
+			return fmt.Errorf("api test failed: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("api test failed")
+		}
+
+		return nil
+	}
+
+	if indexer.Test != nil && indexer.Test.URL != "" {
+		testURL, err := renderTestURL(indexer)
+		if err != nil {
+			return err
+		}
+
+		return s.testURL(ctx, indexer.Identifier, testURL, nil)
+	}
+
+	switch indexer.Implementation {
+	case "torznab", "newznab":
+		client := torznab.NewClient(indexer.SettingsMap["url"], indexer.SettingsMap["api_key"])
+		if _, err := client.GetFeed(); err != nil {
+			return fmt.Errorf("%v test failed: %w", indexer.Implementation, err)
+		}
+
+		return nil
+
+	case "rss":
+		headers := map[string]string{}
+		if cookie := indexer.SettingsMap["cookie"]; cookie != "" {
+			headers["Cookie"] = cookie
+		}
+
+		return s.testURL(ctx, indexer.Identifier, indexer.SettingsMap["url"], headers)
+	}
+
+	return domain.ErrIndexerNotTestable
+}
+
+func (s *service) testURL(ctx context.Context, indexer string, url string, headers map[string]string) error {
+	if url == "" {
+		return fmt.Errorf("missing test url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("could not build request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client, err := s.httpClient(indexer)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	// drain a little of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, res.Body, 1024)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %v", res.StatusCode)
+	}
+
+	return nil
+}
+
+// httpClient web client for an indexer, going through its proxy if set
+func (s *service) httpClient(indexer string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	s.proxyMtx.RLock()
+	proxy := s.proxies[indexer]
+	s.proxyMtx.RUnlock()
+
+	if proxy != "" {
+		proxyURL, err := domain.ParseIndexerProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{Transport: transport, Timeout: time.Second * 30}, nil
+}
+
+// renderTestURL fill the definition test url template with the indexer settings
+func renderTestURL(indexer *domain.IndexerDefinition) (string, error) {
+	vars := map[string]string{}
+	for k, v := range indexer.SettingsMap {
+		vars[k] = v
+	}
+
+	if len(indexer.URLS) > 0 {
+		vars["baseUrl"] = indexer.URLS[0]
+	}
+
+	tmpl, err := template.New("testUrl").Parse(indexer.Test.URL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse test url template: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("could not render test url template: %w", err)
+	}
+
+	return b.String(), nil
+}
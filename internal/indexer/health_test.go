@@ -0,0 +1,196 @@
+package indexer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renderTestURL(t *testing.T) {
+	indexer := &domain.IndexerDefinition{
+		URLS:        []string{"https://mock.example.test/"},
+		SettingsMap: map[string]string{"passkey": "abc123"},
+		Test:        &domain.IndexerTest{URL: "{{ .baseUrl }}rss.php?passkey={{ .passkey }}"},
+	}
+
+	got, err := renderTestURL(indexer)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://mock.example.test/rss.php?passkey=abc123", got)
+}
+
+func Test_service_checkIndexer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "uid=1; pass=secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		indexer  domain.IndexerDefinition
+		testable bool
+		want     domain.IndexerHealthStatus
+	}{
+		{
+			name: "rss_ok",
+			indexer: domain.IndexerDefinition{
+				Identifier:     "rss-ok",
+				Implementation: "rss",
+				SettingsMap:    map[string]string{"url": ts.URL, "cookie": "uid=1; pass=secret"},
+			},
+			testable: true,
+			want:     domain.IndexerHealthOK,
+		},
+		{
+			name: "rss_bad_cookie",
+			indexer: domain.IndexerDefinition{
+				Identifier:     "rss-bad",
+				Implementation: "rss",
+				SettingsMap:    map[string]string{"url": ts.URL, "cookie": "uid=1; pass=wrong"},
+			},
+			testable: true,
+			want:     domain.IndexerHealthError,
+		},
+		{
+			name: "no_check",
+			indexer: domain.IndexerDefinition{
+				Identifier:     "mock",
+				Implementation: "irc",
+			},
+			want: domain.IndexerHealthUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(nil, NewAPIService(), NewDownloadLimiter(), NewTorrentCache("", domain.TorrentCacheSettings{}), nil, domain.IndexerAuthSettings{}).(*service)
+
+			assert.Equal(t, tt.testable, s.canTest(&tt.indexer))
+
+			got := s.checkIndexer(context.Background(), &tt.indexer)
+			assert.Equal(t, tt.want, got.Status)
+			assert.Equal(t, got, s.health[tt.indexer.Identifier])
+		})
+	}
+}
+
+// fakeIndexerRepo stores the credentials flags only
+type fakeIndexerRepo struct {
+	domain.IndexerRepo
+	flagged map[string]string
+}
+
+func (r *fakeIndexerRepo) SetCredentialsInvalid(ctx context.Context, identifier string, reason string) error {
+	if reason == "" {
+		delete(r.flagged, identifier)
+		return nil
+	}
+
+	r.flagged[identifier] = reason
+
+	return nil
+}
+
+func (r *fakeIndexerRepo) ListCredentialsInvalid(ctx context.Context) (map[string]string, error) {
+	return r.flagged, nil
+}
+
+func Test_service_authFailures(t *testing.T) {
+	torrent, _ := testTorrent(t)
+
+	var passkey atomic.Value
+	passkey.Store("expired")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login.php":
+			w.Write([]byte("<html>login</html>"))
+		case r.URL.Path == "/cookie":
+			http.Redirect(w, r, "/login.php?returnto=dl", http.StatusFound)
+		case r.URL.Query().Get("passkey") != passkey.Load().(string):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Write(torrent)
+		}
+	}))
+	defer ts.Close()
+
+	bus := EventBus.New()
+
+	var events, valid []domain.IndexerCredentialsEvent
+	bus.Subscribe(domain.EventIndexerCredentials, func(event *domain.IndexerCredentialsEvent) {
+		events = append(events, *event)
+	})
+	bus.Subscribe(domain.EventIndexerCredentialsValid, func(event *domain.IndexerCredentialsEvent) {
+		valid = append(valid, *event)
+	})
+
+	repo := &fakeIndexerRepo{flagged: map[string]string{}}
+	s := NewService(repo, NewAPIService(), NewDownloadLimiter(), NewTorrentCache("", domain.TorrentCacheSettings{}), bus, domain.IndexerAuthSettings{Failures: 2, PauseFilters: true}).(*service)
+
+	download := func(url string) error {
+		release := &domain.Release{Indexer: "mock", TorrentURL: ts.URL + url}
+		err := s.DownloadTorrentFile(release)
+		os.Remove(release.TorrentTmpFile)
+		return err
+	}
+
+	require.Error(t, download("/dl/1?passkey=current"))
+	assert.Empty(t, events, "one failure is not enough")
+
+	// sent to the login page counts too
+	err := download("/cookie")
+	require.Error(t, err)
+	assert.True(t, domain.IsAuthFailure(err))
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "mock", events[0].Indexer)
+		assert.Equal(t, 2, events[0].Failures)
+		assert.True(t, events[0].PauseFilters)
+		assert.NotContains(t, events[0].Reason, "passkey", "no urls in the reason")
+	}
+
+	assert.Equal(t, domain.IndexerHealthCredentialsInvalid, s.health["mock"].Status)
+	assert.Equal(t, map[string]string{"mock": "redirected to login page"}, repo.flagged)
+
+	// flagged once, not on every failure after
+	require.Error(t, download("/dl/1?passkey=current"))
+	assert.Len(t, events, 1)
+
+	// a health check that can't tell keeps the flag
+	health := s.checkIndexer(context.Background(), &domain.IndexerDefinition{Identifier: "mock"})
+	assert.Equal(t, domain.IndexerHealthCredentialsInvalid, health.Status)
+
+	// still flagged after a restart
+	restarted := NewService(repo, NewAPIService(), NewDownloadLimiter(), NewTorrentCache("", domain.TorrentCacheSettings{}), bus, domain.IndexerAuthSettings{Failures: 2, PauseFilters: true}).(*service)
+	require.NoError(t, restarted.loadCredentialsFlags(context.Background()))
+	assert.Equal(t, domain.IndexerHealthCredentialsInvalid, restarted.health["mock"].Status)
+
+	// fixed passkey, the next download clears it and the paused filters resume
+	passkey.Store("current")
+	require.NoError(t, download("/dl/1?passkey=current"))
+
+	assert.Equal(t, domain.IndexerHealthOK, s.health["mock"].Status)
+	assert.Zero(t, s.authFailures["mock"])
+	assert.Empty(t, repo.flagged)
+	if assert.Len(t, valid, 1) {
+		assert.Equal(t, "mock", valid[0].Indexer)
+	}
+
+	// saving the indexer clears the flag of the restarted service too
+	repo.flagged["mock"] = "status code: 403"
+	restarted.resetAuthFailures("mock")
+	assert.Empty(t, repo.flagged)
+	assert.Len(t, valid, 2)
+}
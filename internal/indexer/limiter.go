@@ -0,0 +1,177 @@
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// DownloadLimiter queue torrent file downloads per indexer to stay within the configured limits
+type DownloadLimiter interface {
+	SetLimits(indexer string, limits domain.IndexerDownloadLimits)
+	Remove(indexer string)
+	Acquire(ctx context.Context, indexer string) (func(), error)
+}
+
+type downloadLimiter struct {
+	m        sync.RWMutex
+	limiters map[string]*indexerLimiter
+}
+
+func NewDownloadLimiter() DownloadLimiter {
+	return &downloadLimiter{
+		limiters: make(map[string]*indexerLimiter),
+	}
+}
+
+func (d *downloadLimiter) SetLimits(indexer string, limits domain.IndexerDownloadLimits) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if !limits.Enabled() {
+		delete(d.limiters, indexer)
+		return
+	}
+
+	// keep the current limiter if nothing changed so queued downloads are not lost
+	if l, ok := d.limiters[indexer]; ok && l.limits == limits {
+		return
+	}
+
+	d.limiters[indexer] = newIndexerLimiter(limits)
+
+	log.Debug().Msgf("download limiter: set limits for %v: %+v", indexer, limits)
+}
+
+func (d *downloadLimiter) Remove(indexer string) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	delete(d.limiters, indexer)
+}
+
+// Acquire blocks until a download from the indexer is allowed. The returned func must be called when the download is done.
+func (d *downloadLimiter) Acquire(ctx context.Context, indexer string) (func(), error) {
+	d.m.RLock()
+	l, ok := d.limiters[indexer]
+	d.m.RUnlock()
+
+	if !ok {
+		return func() {}, nil
+	}
+
+	return l.acquire(ctx, indexer)
+}
+
+type indexerLimiter struct {
+	limits domain.IndexerDownloadLimits
+
+	// slots for concurrent downloads, nil when unlimited
+	slots chan struct{}
+
+	m       sync.Mutex
+	history []time.Time
+	now     func() time.Time
+}
+
+func newIndexerLimiter(limits domain.IndexerDownloadLimits) *indexerLimiter {
+	l := &indexerLimiter{
+		limits: limits,
+		now:    time.Now,
+	}
+
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+
+	return l
+}
+
+func (l *indexerLimiter) acquire(ctx context.Context, indexer string) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	done := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	for {
+		wait := l.reserve()
+		if wait == 0 {
+			return done, nil
+		}
+
+		log.Debug().Msgf("download limiter: %v limit reached, queued for %v", indexer, wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			done()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// reserve record a download if within limits, otherwise return how long to wait
+func (l *indexerLimiter) reserve() time.Duration {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := l.now()
+
+	// drop everything older than the largest window
+	i := 0
+	for i < len(l.history) && now.Sub(l.history[i]) >= time.Hour {
+		i++
+	}
+	l.history = l.history[i:]
+
+	var wait time.Duration
+	if w := l.windowWait(now, time.Minute, l.limits.PerMinute); w > wait {
+		wait = w
+	}
+	if w := l.windowWait(now, time.Hour, l.limits.PerHour); w > wait {
+		wait = w
+	}
+
+	if wait > 0 {
+		return wait
+	}
+
+	l.history = append(l.history, now)
+
+	return 0
+}
+
+// windowWait time until the oldest download in the window expires if the window is full
+func (l *indexerLimiter) windowWait(now time.Time, window time.Duration, max int) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	var inWindow []time.Time
+	for _, t := range l.history {
+		if now.Sub(t) < window {
+			inWindow = append(inWindow, t)
+		}
+	}
+
+	if len(inWindow) < max {
+		return 0
+	}
+
+	return inWindow[len(inWindow)-max].Add(window).Sub(now)
+}
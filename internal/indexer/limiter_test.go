@@ -0,0 +1,73 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_indexerLimiter_reserve(t *testing.T) {
+	start := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		limits domain.IndexerDownloadLimits
+		offset []time.Duration
+		want   []time.Duration
+	}{
+		{
+			name:   "per_minute",
+			limits: domain.IndexerDownloadLimits{PerMinute: 2},
+			offset: []time.Duration{0, 10 * time.Second, 20 * time.Second, 60 * time.Second},
+			want:   []time.Duration{0, 0, 40 * time.Second, 0},
+		},
+		{
+			name:   "per_hour",
+			limits: domain.IndexerDownloadLimits{PerMinute: 5, PerHour: 2},
+			offset: []time.Duration{0, time.Minute, 2 * time.Minute, time.Hour},
+			want:   []time.Duration{0, 0, 58 * time.Minute, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newIndexerLimiter(tt.limits)
+
+			for i, offset := range tt.offset {
+				now := start.Add(offset)
+				l.now = func() time.Time { return now }
+
+				assert.Equal(t, tt.want[i], l.reserve())
+			}
+		})
+	}
+}
+
+func Test_downloadLimiter_Acquire(t *testing.T) {
+	limiter := NewDownloadLimiter()
+	limiter.SetLimits("mock", domain.IndexerDownloadLimits{MaxConcurrent: 1})
+
+	done, err := limiter.Acquire(context.Background(), "mock")
+	assert.NoError(t, err)
+
+	// second download is queued until the first is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = limiter.Acquire(ctx, "mock")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done()
+
+	done, err = limiter.Acquire(context.Background(), "mock")
+	assert.NoError(t, err)
+	done()
+
+	// indexers without limits are never queued
+	done, err = limiter.Acquire(context.Background(), "other")
+	assert.NoError(t, err)
+	done()
+}
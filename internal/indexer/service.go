@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"io/fs"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/asaskevich/EventBus"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 
@@ -22,33 +25,88 @@ type Service interface {
 	GetTemplates() ([]domain.IndexerDefinition, error)
 	LoadIndexerDefinitions() error
 	GetIndexersByIRCNetwork(server string) []domain.IndexerDefinition
+	GetByIdentifier(identifier string) *domain.IndexerDefinition
+	DownloadTorrentFile(release *domain.Release) error
+	TorrentCacheStats() domain.TorrentCacheStats
+	PurgeTorrentCache(expiredOnly bool) (int, error)
+	TestIndexer(ctx context.Context, id int) (*domain.IndexerHealth, error)
+	CheckHealth()
 	Start() error
 }
 
 type service struct {
 	repo       domain.IndexerRepo
 	apiService APIService
+	limiter    DownloadLimiter
+	cache      TorrentCache
+	bus        EventBus.Bus
+	auth       domain.IndexerAuthSettings
+
+	// web proxy per indexer identifier, used for torrent file downloads
+	proxies  map[string]string
+	proxyMtx sync.RWMutex
+
+	// torrent file download retries per indexer identifier
+	retries  map[string]domain.IndexerDownloadRetry
+	retryMtx sync.RWMutex
+
+	// last health check result per indexer identifier
+	health map[string]domain.IndexerHealth
+	// downloads in a row that failed on the credentials per indexer identifier
+	authFailures map[string]int
+	healthMtx    sync.RWMutex
 
 	// contains all raw indexer definitions
 	indexerDefinitions map[string]domain.IndexerDefinition
 
+	// indexers with their stored settings and accounts per identifier, replaced when an indexer is saved
+	indexers   map[string]domain.IndexerDefinition
+	indexerMtx sync.RWMutex
+
 	// map server:channel:announce to indexer.Identifier
 	mapIndexerIRCToName map[string]string
 
 	lookupIRCServerDefinition map[string]map[string]domain.IndexerDefinition
 }
 
-func NewService(repo domain.IndexerRepo, apiService APIService) Service {
+func NewService(repo domain.IndexerRepo, apiService APIService, limiter DownloadLimiter, cache TorrentCache, bus EventBus.Bus, auth domain.IndexerAuthSettings) Service {
 	return &service{
 		repo:                      repo,
 		apiService:                apiService,
+		limiter:                   limiter,
+		cache:                     cache,
+		bus:                       bus,
+		auth:                      auth,
+		proxies:                   make(map[string]string),
+		retries:                   make(map[string]domain.IndexerDownloadRetry),
+		health:                    make(map[string]domain.IndexerHealth),
+		authFailures:              make(map[string]int),
 		indexerDefinitions:        make(map[string]domain.IndexerDefinition),
+		indexers:                  make(map[string]domain.IndexerDefinition),
 		mapIndexerIRCToName:       make(map[string]string),
 		lookupIRCServerDefinition: make(map[string]map[string]domain.IndexerDefinition),
 	}
 }
 
 func (s *service) Store(indexer domain.Indexer) (*domain.Indexer, error) {
+	// generic torznab, newznab and rss indexers share a definition so give each one a unique identifier
+	if isGenericImplementation(indexer.Identifier) {
+		implementation := indexer.Identifier
+
+		// if the name already contains the implementation remove it
+		cleanName := strings.ReplaceAll(strings.ToLower(indexer.Name), implementation, "")
+		indexer.Identifier = slugify(fmt.Sprintf("%v-%v", implementation, cleanName))
+		indexer.Implementation = implementation
+	}
+
+	if indexer.Implementation == "" {
+		indexer.Implementation = "irc"
+	}
+
+	if err := validateIndexer(indexer); err != nil {
+		return nil, err
+	}
+
 	i, err := s.repo.Store(indexer)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("failed to store indexer: %v", indexer.Name)
@@ -66,11 +124,18 @@ func (s *service) Store(indexer domain.Indexer) (*domain.Indexer, error) {
 }
 
 func (s *service) Update(indexer domain.Indexer) (*domain.Indexer, error) {
+	if err := validateIndexer(indexer); err != nil {
+		return nil, err
+	}
+
 	i, err := s.repo.Update(indexer)
 	if err != nil {
 		return nil, err
 	}
 
+	// new credentials get a clean slate
+	s.resetAuthFailures(i.Identifier)
+
 	// add to indexerInstances
 	err = s.addIndexer(*i)
 	if err != nil {
@@ -89,6 +154,14 @@ func (s *service) Delete(ctx context.Context, id int) error {
 	// TODO remove handler if needed
 	// remove from lookup tables
 
+	s.indexerMtx.Lock()
+	for identifier, indexer := range s.indexers {
+		if indexer.ID == id {
+			delete(s.indexers, identifier)
+		}
+	}
+	s.indexerMtx.Unlock()
+
 	return nil
 }
 
@@ -128,6 +201,14 @@ func (s *service) GetAll() ([]*domain.IndexerDefinition, error) {
 			continue
 		}
 
+		indexerDefinition.Testable = s.canTest(indexerDefinition)
+
+		s.healthMtx.RLock()
+		if health, ok := s.health[indexerDefinition.Identifier]; ok {
+			indexerDefinition.Health = &health
+		}
+		s.healthMtx.RUnlock()
+
 		res = append(res, indexerDefinition)
 	}
 
@@ -136,32 +217,62 @@ func (s *service) GetAll() ([]*domain.IndexerDefinition, error) {
 
 func (s *service) mapIndexer(indexer domain.Indexer) (*domain.IndexerDefinition, error) {
 
-	in := s.getDefinitionByName(indexer.Identifier)
+	var in *domain.IndexerDefinition
+	if isGenericImplementation(indexer.Implementation) {
+		in = s.getDefinitionByName(indexer.Implementation)
+	} else {
+		in = s.getDefinitionByName(indexer.Identifier)
+	}
+
 	if in == nil {
 		// if no indexerDefinition found, continue
 		return nil, nil
 	}
 
 	indexerDefinition := domain.IndexerDefinition{
-		ID:          int(indexer.ID),
-		Name:        in.Name,
-		Identifier:  in.Identifier,
-		Enabled:     indexer.Enabled,
-		Description: in.Description,
-		Language:    in.Language,
-		Privacy:     in.Privacy,
-		Protocol:    in.Protocol,
-		URLS:        in.URLS,
-		Supports:    in.Supports,
-		Settings:    nil,
-		SettingsMap: make(map[string]string),
-		IRC:         in.IRC,
-		Parse:       in.Parse,
+		ID:               int(indexer.ID),
+		Name:             in.Name,
+		Identifier:       in.Identifier,
+		Implementation:   in.Implementation,
+		Enabled:          indexer.Enabled,
+		Description:      in.Description,
+		Language:         in.Language,
+		Privacy:          in.Privacy,
+		Protocol:         in.Protocol,
+		URLS:             in.URLS,
+		Supports:         in.Supports,
+		Settings:         nil,
+		SettingsMap:      make(map[string]string),
+		DownloadLimits:   indexer.DownloadLimits,
+		DownloadRetry:    indexer.DownloadRetry,
+		Proxy:            indexer.Proxy,
+		Test:             in.Test,
+		Accounts:         indexer.Accounts,
+		AccountSelection: indexer.AccountSelection,
+		API:              in.API,
+		IRC:              in.IRC,
+		Torznab:          in.Torznab,
+		RSS:              in.RSS,
+		Parse:            in.Parse,
+	}
+
+	// generic indexers are user named and use the generated identifier
+	if isGenericImplementation(indexer.Implementation) {
+		indexerDefinition.Name = indexer.Name
+		indexerDefinition.Identifier = indexer.Identifier
+	}
+
+	settings := in.Settings
+	if in.Torznab != nil {
+		settings = append(settings, in.Torznab.Settings...)
+	}
+	if in.RSS != nil {
+		settings = append(settings, in.RSS.Settings...)
 	}
 
 	// map settings
 	// add value to settings objects
-	for _, setting := range in.Settings {
+	for _, setting := range settings {
 		if v, ok := indexer.Settings[setting.Name]; ok {
 			setting.Value = v
 
@@ -200,17 +311,27 @@ func (s *service) Start() error {
 	}
 
 	for _, indexer := range indexerDefinitions {
-		s.mapIRCIndexerLookup(indexer.Identifier, *indexer)
+		if indexer.IRC != nil {
+			s.mapIRCIndexerLookup(indexer.Identifier, *indexer)
 
-		// add to irc server lookup table
-		s.mapIRCServerDefinitionLookup(indexer.IRC.Server, *indexer)
+			// add to irc server lookup table
+			s.mapIRCServerDefinitionLookup(indexer.IRC.Server, *indexer)
+		}
 
 		// check if it has api and add to api service
 		if indexer.Enabled && indexer.HasApi() {
-			if err := s.apiService.AddClient(indexer.Identifier, indexer.SettingsMap); err != nil {
+			if err := s.apiService.AddClient(indexer.Identifier, indexer.SettingsMap, indexer.API, indexer.Proxy); err != nil {
 				log.Error().Stack().Err(err).Msgf("indexer.start: could not init api client for: '%v'", indexer.Identifier)
 			}
 		}
+
+		s.limiter.SetLimits(indexer.Identifier, indexer.DownloadLimits)
+		s.setDownloadRetry(indexer.Identifier, indexer.DownloadRetry)
+		s.setProxy(indexer.Identifier, indexer.Proxy)
+	}
+
+	if err := s.loadCredentialsFlags(context.Background()); err != nil {
+		log.Error().Err(err).Msg("indexer.start: could not load the credentials flags")
 	}
 
 	log.Info().Msgf("Loaded %d indexers", len(indexerDefinitions))
@@ -236,26 +357,160 @@ func (s *service) addIndexer(indexer domain.Indexer) error {
 		return err
 	}
 
+	if indexerDefinition == nil {
+		return fmt.Errorf("could not find definition for indexer: %v", indexer.Identifier)
+	}
+
 	// TODO only add enabled?
 	//if !indexer.Enabled {
 	//	continue
 	//}
 
-	s.mapIRCIndexerLookup(indexer.Identifier, *indexerDefinition)
+	if indexerDefinition.IRC != nil {
+		s.mapIRCIndexerLookup(indexer.Identifier, *indexerDefinition)
 
-	// add to irc server lookup table
-	s.mapIRCServerDefinitionLookup(indexerDefinition.IRC.Server, *indexerDefinition)
+		// add to irc server lookup table
+		s.mapIRCServerDefinitionLookup(indexerDefinition.IRC.Server, *indexerDefinition)
+	}
 
 	// check if it has api and add to api service
 	if indexerDefinition.Enabled && indexerDefinition.HasApi() {
-		if err := s.apiService.AddClient(indexerDefinition.Identifier, indexerDefinition.SettingsMap); err != nil {
+		if err := s.apiService.AddClient(indexerDefinition.Identifier, indexerDefinition.SettingsMap, indexerDefinition.API, indexerDefinition.Proxy); err != nil {
 			log.Error().Stack().Err(err).Msgf("indexer.start: could not init api client for: '%v'", indexer.Identifier)
 		}
 	}
 
+	s.indexerMtx.Lock()
+	s.indexers[indexerDefinition.Identifier] = *indexerDefinition
+	s.indexerMtx.Unlock()
+
+	s.limiter.SetLimits(indexerDefinition.Identifier, indexerDefinition.DownloadLimits)
+	s.setDownloadRetry(indexerDefinition.Identifier, indexerDefinition.DownloadRetry)
+	s.setProxy(indexerDefinition.Identifier, indexerDefinition.Proxy)
+
+	return nil
+}
+
+func (s *service) setProxy(indexer string, proxy string) {
+	s.proxyMtx.Lock()
+	defer s.proxyMtx.Unlock()
+
+	if proxy == "" {
+		delete(s.proxies, indexer)
+		return
+	}
+
+	s.proxies[indexer] = proxy
+}
+
+func (s *service) setDownloadRetry(indexer string, retry domain.IndexerDownloadRetry) {
+	s.retryMtx.Lock()
+	defer s.retryMtx.Unlock()
+
+	if retry.Attempts <= 0 {
+		delete(s.retries, indexer)
+		return
+	}
+
+	s.retries[indexer] = retry
+}
+
+// sleep wait between download retries, replaced in tests
+var sleep = time.Sleep
+
+// DownloadTorrentFile download the release torrent file through the indexer proxy, queued by the indexer download limits.
+// A torrent file still in the cache is used instead. Retryable failures are tried again with the indexer retry settings,
+// the failed attempts end up in the release DownloadLog or the returned error.
+func (s *service) DownloadTorrentFile(release *domain.Release) error {
+	if release.TorrentTmpFile != "" {
+		return nil
+	}
+
+	started := time.Now()
+	defer func() {
+		release.Timings.Fetch += time.Since(started).Microseconds()
+	}()
+
+	s.retryMtx.RLock()
+	retry := s.retries[release.Indexer]
+	s.retryMtx.RUnlock()
+
+	var failures []string
+
+	for attempt := 1; ; attempt++ {
+		err := s.downloadTorrentFile(release)
+		if err == nil {
+			if len(failures) > 0 {
+				release.DownloadLog = fmt.Sprintf("torrent file downloaded on attempt %d/%d, %v", attempt, retry.Attempts+1, strings.Join(failures, ", "))
+			}
+
+			return nil
+		}
+
+		if !domain.IsRetryableDownload(err) || attempt > retry.Attempts {
+			if len(failures) > 0 {
+				return fmt.Errorf("torrent file download failed after %d attempts: %w", attempt, err)
+			}
+
+			return err
+		}
+
+		failures = append(failures, fmt.Sprintf("attempt %d: %v", attempt, err))
+
+		wait := retry.Backoff(attempt)
+		log.Warn().Err(err).Msgf("indexer %v: torrent file download failed, retry %d/%d in %v", release.Indexer, attempt, retry.Attempts, wait)
+
+		sleep(wait)
+	}
+}
+
+func (s *service) downloadTorrentFile(release *domain.Release) error {
+	if s.cache.Load(release) {
+		return nil
+	}
+
+	done, err := s.limiter.Acquire(context.Background(), release.Indexer)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// another action may have downloaded it while this one waited for the limiter
+	if s.cache.Load(release) {
+		return nil
+	}
+
+	var opts map[string]string
+
+	s.proxyMtx.RLock()
+	if proxy, ok := s.proxies[release.Indexer]; ok {
+		opts = map[string]string{"proxy": proxy}
+	}
+	s.proxyMtx.RUnlock()
+
+	if err := release.DownloadTorrentFile(opts); err != nil {
+		if domain.IsAuthFailure(err) {
+			s.authFailed(release.Indexer, err)
+		}
+
+		return err
+	}
+
+	s.authOK(release.Indexer)
+
+	s.cache.Store(release)
+
 	return nil
 }
 
+func (s *service) TorrentCacheStats() domain.TorrentCacheStats {
+	return s.cache.Stats()
+}
+
+func (s *service) PurgeTorrentCache(expiredOnly bool) (int, error) {
+	return s.cache.Purge(expiredOnly)
+}
+
 func (s *service) mapIRCIndexerLookup(indexerIdentifier string, indexerDefinition domain.IndexerDefinition) {
 	// map irc stuff to indexer.name
 	// map[irc.network.test:channel:announcer1] = indexer1
@@ -325,6 +580,10 @@ func (s *service) LoadIndexerDefinitions() error {
 				return err
 			}
 
+			if d.Implementation == "" {
+				d.Implementation = "irc"
+			}
+
 			s.indexerDefinitions[d.Identifier] = d
 		}
 	}
@@ -349,6 +608,18 @@ func (s *service) GetIndexersByIRCNetwork(server string) []domain.IndexerDefinit
 	return indexerDefinitions
 }
 
+// GetByIdentifier indexer with its current settings and accounts, nil if there is no such indexer
+func (s *service) GetByIdentifier(identifier string) *domain.IndexerDefinition {
+	s.indexerMtx.RLock()
+	defer s.indexerMtx.RUnlock()
+
+	if v, ok := s.indexers[identifier]; ok {
+		return &v
+	}
+
+	return nil
+}
+
 func (s *service) getDefinitionByName(name string) *domain.IndexerDefinition {
 
 	if v, ok := s.indexerDefinitions[name]; ok {
@@ -368,3 +639,71 @@ func (s *service) getDefinitionForAnnounce(name string) *domain.IndexerDefinitio
 
 	return nil
 }
+
+const (
+	maxDownloadRetries    = 10
+	maxDownloadRetryDelay = 600
+)
+
+func validateIndexer(indexer domain.Indexer) error {
+	if indexer.Proxy != "" {
+		if _, err := domain.ParseIndexerProxy(indexer.Proxy); err != nil {
+			return err
+		}
+	}
+
+	if indexer.DownloadRetry.Attempts < 0 || indexer.DownloadRetry.Attempts > maxDownloadRetries {
+		return fmt.Errorf("download retry attempts must be between 0 and %d", maxDownloadRetries)
+	}
+
+	if indexer.DownloadRetry.Delay < 0 || indexer.DownloadRetry.Delay > maxDownloadRetryDelay {
+		return fmt.Errorf("download retry delay must be between 0 and %d seconds", maxDownloadRetryDelay)
+	}
+
+	switch indexer.AccountSelection {
+	case "", domain.IndexerAccountSelectionDefault, domain.IndexerAccountSelectionRoundRobin:
+	default:
+		return fmt.Errorf("invalid account selection: %v", indexer.AccountSelection)
+	}
+
+	names := make(map[string]bool)
+	for _, account := range indexer.Accounts {
+		if account.Name == "" || account.Name == domain.IndexerAccountDefault {
+			return fmt.Errorf("invalid account name: %q", account.Name)
+		}
+
+		if names[account.Name] {
+			return fmt.Errorf("duplicate account name: %v", account.Name)
+		}
+
+		names[account.Name] = true
+	}
+
+	return nil
+}
+
+// isGenericImplementation torznab, newznab and rss indexers are set up by the user and not from a tracker definition
+func isGenericImplementation(implementation string) bool {
+	return implementation == "torznab" || implementation == "newznab" || implementation == "rss"
+}
+
+// slugify lowercase and replace everything but letters and numbers with dashes
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,78 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func Test_service_DownloadTorrentFile_retry(t *testing.T) {
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	torrent, _ := testTorrent(t)
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+
+		switch {
+		case r.URL.Path == "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Path == "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case n == 1:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("torrent not registered with this tracker"))
+		default:
+			w.Write(torrent)
+		}
+	}))
+	defer ts.Close()
+
+	s := NewService(nil, NewAPIService(), NewDownloadLimiter(), NewTorrentCache("", domain.TorrentCacheSettings{}), nil, domain.IndexerAuthSettings{}).(*service)
+	s.setDownloadRetry("mock", domain.IndexerDownloadRetry{Attempts: 2, Delay: 1})
+
+	// not registered yet, then downloaded on the retry
+	release := &domain.Release{Indexer: "mock", TorrentURL: ts.URL + "/dl/1"}
+	require.NoError(t, s.DownloadTorrentFile(release))
+	defer os.Remove(release.TorrentTmpFile)
+
+	assert.Equal(t, []time.Duration{time.Second}, waits)
+	assert.Contains(t, release.DownloadLog, "attempt 2/3")
+	assert.Contains(t, release.DownloadLog, "status code: 404")
+
+	// bad passkey is not retried
+	atomic.StoreInt32(&requests, 0)
+	waits = nil
+
+	err := s.DownloadTorrentFile(&domain.Release{Indexer: "mock", TorrentURL: ts.URL + "/forbidden"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Empty(t, waits)
+
+	// tracker down, retried with backoff until the attempts are used up
+	atomic.StoreInt32(&requests, 0)
+
+	err = s.DownloadTorrentFile(&domain.Release{Indexer: "mock", TorrentURL: ts.URL + "/down"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	// indexers without retry settings fail right away
+	atomic.StoreInt32(&requests, 0)
+
+	err = s.DownloadTorrentFile(&domain.Release{Indexer: "other", TorrentURL: ts.URL + "/down"})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
@@ -1,6 +1,7 @@
 package irc
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"regexp"
@@ -11,9 +12,11 @@ import (
 	"github.com/autobrr/autobrr/internal/announce"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/filter"
+	"github.com/autobrr/autobrr/internal/indexer"
 	"github.com/autobrr/autobrr/internal/logger"
 	"github.com/autobrr/autobrr/internal/release"
 
+	"github.com/asaskevich/EventBus"
 	"github.com/ergochat/irc-go/ircevent"
 	"github.com/ergochat/irc-go/ircmsg"
 	"github.com/rs/zerolog/log"
@@ -58,13 +61,21 @@ func (h *channelHealth) resetMonitoring() {
 type Handler struct {
 	network            *domain.IrcNetwork
 	filterService      filter.Service
+	indexerService     indexer.Service
 	releaseService     release.Service
+	bus                EventBus.Bus
 	announceProcessors map[string]announce.Processor
 	definitions        map[string]*domain.IndexerDefinition
+	queueRepo          domain.AnnounceQueueRepo
+	limiter            *announce.Limiter
+	announceSettings   domain.AnnounceSettings
 
 	client *ircevent.Connection
 	m      sync.RWMutex
 
+	// stopped is closed when the connection loop of Run returns
+	stopped chan struct{}
+
 	lastPing       time.Time
 	connected      bool
 	connectedSince time.Time
@@ -75,12 +86,17 @@ type Handler struct {
 	channelHealth   map[string]*channelHealth
 }
 
-func NewHandler(network domain.IrcNetwork, filterService filter.Service, releaseService release.Service, definitions []domain.IndexerDefinition) *Handler {
+func NewHandler(network domain.IrcNetwork, filterService filter.Service, indexerService indexer.Service, releaseService release.Service, bus EventBus.Bus, definitions []domain.IndexerDefinition, queueRepo domain.AnnounceQueueRepo, limiter *announce.Limiter, announceSettings domain.AnnounceSettings) *Handler {
 	h := &Handler{
 		client:             nil,
 		network:            &network,
 		filterService:      filterService,
+		indexerService:     indexerService,
 		releaseService:     releaseService,
+		bus:                bus,
+		queueRepo:          queueRepo,
+		limiter:            limiter,
+		announceSettings:   announceSettings,
 		definitions:        map[string]*domain.IndexerDefinition{},
 		announceProcessors: map[string]announce.Processor{},
 		validAnnouncers:    map[string]struct{}{},
@@ -95,6 +111,9 @@ func NewHandler(network domain.IrcNetwork, filterService filter.Service, release
 }
 
 func (h *Handler) InitIndexers(definitions []domain.IndexerDefinition) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
 	// Networks can be shared by multiple indexers but channels are unique
 	// so let's add a new AnnounceProcessor per channel
 	for _, definition := range definitions {
@@ -109,7 +128,7 @@ func (h *Handler) InitIndexers(definitions []domain.IndexerDefinition) {
 			// some channels are defined in mixed case
 			channel = strings.ToLower(channel)
 
-			h.announceProcessors[channel] = announce.NewAnnounceProcessor(definition, h.filterService, h.releaseService)
+			h.announceProcessors[channel] = announce.NewAnnounceProcessor(definition, h.filterService, h.indexerService, h.releaseService, h.queueRepo, h.limiter, h.announceSettings)
 
 			h.channelHealth[channel] = &channelHealth{
 				name:       channel,
@@ -157,6 +176,12 @@ func (h *Handler) Run() error {
 		h.client.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
+	stopped := make(chan struct{})
+	h.m.Lock()
+	h.stopped = stopped
+	h.m.Unlock()
+	defer close(stopped)
+
 	h.client.AddConnectCallback(h.onConnect)
 	h.client.AddCallback("MODE", h.handleMode)
 	h.client.AddCallback("INVITE", h.handleInvite)
@@ -169,6 +194,7 @@ func (h *Handler) Run() error {
 
 		// reset connection status on handler and channels
 		h.resetConnectionStatus()
+		h.publishConnection(false)
 
 		//return err
 	}
@@ -178,9 +204,30 @@ func (h *Handler) Run() error {
 
 	h.client.Loop()
 
+	// loop only returns when the network is stopped
+	h.publishConnection(false)
+
 	return nil
 }
 
+// publishConnection tell the ui the network connected or disconnected
+func (h *Handler) publishConnection(connected bool) {
+	if h.bus == nil {
+		return
+	}
+
+	h.m.RLock()
+	event := &domain.IrcConnectionEvent{
+		NetworkID: h.network.ID,
+		Network:   h.network.Name,
+		Server:    h.network.Server,
+		Connected: connected,
+	}
+	h.m.RUnlock()
+
+	h.bus.Publish(domain.EventIrcConnection, event)
+}
+
 func (h *Handler) isOurNick(nick string) bool {
 	return h.network.NickServ.Account == nick
 }
@@ -209,6 +256,15 @@ func (h *Handler) resetConnectionStatus() {
 	h.m.Unlock()
 }
 
+// getChannelHealth health of channel, the service reads it for the api while the handler adds channels
+func (h *Handler) getChannelHealth(channel string) (*channelHealth, bool) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+
+	v, ok := h.channelHealth[channel]
+	return v, ok
+}
+
 func (h *Handler) GetNetwork() *domain.IrcNetwork {
 	return h.network
 }
@@ -230,6 +286,37 @@ func (h *Handler) Stop() {
 	h.client.Quit()
 }
 
+// Shutdown send QUIT and wait until the server closed the connection, or ctx is done
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.m.RLock()
+	stopped := h.stopped
+	h.m.RUnlock()
+
+	if h.client == nil || stopped == nil {
+		return nil
+	}
+
+	h.Stop()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%v: no reply to quit: %w", h.network.Server, ctx.Err())
+	}
+}
+
+// Drain wait for the announces that were received before the network stopped
+func (h *Handler) Drain(ctx context.Context) error {
+	for _, processor := range h.announceProcessors {
+		if err := processor.Drain(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (h *Handler) Restart() error {
 	log.Debug().Msgf("%v: Restarting network...", h.network.Server)
 
@@ -243,6 +330,9 @@ func (h *Handler) Restart() error {
 func (h *Handler) onConnect(m ircmsg.Message) {
 	identified := false
 
+	// also called on every reconnect
+	h.publishConnection(true)
+
 	time.Sleep(4 * time.Second)
 
 	if h.network.NickServ.Password != "" {
@@ -315,7 +405,9 @@ func (h *Handler) sendToAnnounceProcessor(channel string, msg string) error {
 	channel = strings.ToLower(channel)
 
 	// check if queue exists
+	h.m.RLock()
 	queue, ok := h.announceProcessors[channel]
+	h.m.RUnlock()
 	if !ok {
 		return fmt.Errorf("queue '%v' not found", channel)
 	}
@@ -327,7 +419,7 @@ func (h *Handler) sendToAnnounceProcessor(channel string, msg string) error {
 		return err
 	}
 
-	v, ok := h.channelHealth[channel]
+	v, ok := h.getChannelHealth(channel)
 	if !ok {
 		return nil
 	}
@@ -375,7 +467,7 @@ func (h *Handler) handlePart(msg ircmsg.Message) {
 	}
 
 	// reset monitoring status
-	v, ok := h.channelHealth[channel]
+	v, ok := h.getChannelHealth(channel)
 	if !ok {
 		return
 	}
@@ -399,7 +491,7 @@ func (h *Handler) HandlePartChannel(channel string) error {
 	}
 
 	// reset monitoring status
-	v, ok := h.channelHealth[channel]
+	v, ok := h.getChannelHealth(channel)
 	if !ok {
 		return nil
 	}
@@ -408,7 +500,7 @@ func (h *Handler) HandlePartChannel(channel string) error {
 
 	// TODO remove announceProcessor
 
-	log.Info().Msgf("Left channel '%v' on network '%h'", channel, h.network.Server)
+	log.Info().Msgf("Left channel '%v' on network '%v'", channel, h.network.Server)
 
 	return nil
 }
@@ -434,15 +526,17 @@ func (h *Handler) handleJoined(msg ircmsg.Message) {
 	log.Debug().Msgf("%v: JOINED: %v", h.network.Server, msg.Params[1])
 
 	// set monitoring on current channelHealth, or add new
-	v, ok := h.channelHealth[strings.ToLower(channel)]
+	v, ok := h.getChannelHealth(strings.ToLower(channel))
 	if ok {
 		v.SetMonitoring()
 	} else if v == nil {
+		h.m.Lock()
 		h.channelHealth[channel] = &channelHealth{
 			name:            channel,
 			monitoring:      true,
 			monitoringSince: time.Now(),
 		}
+		h.m.Unlock()
 	}
 
 	log.Info().Msgf("%v: Monitoring channel %v", h.network.Server, msg.Params[1])
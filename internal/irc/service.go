@@ -5,18 +5,21 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/autobrr/autobrr/internal/announce"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/filter"
 	"github.com/autobrr/autobrr/internal/indexer"
 	"github.com/autobrr/autobrr/internal/release"
 
+	"github.com/asaskevich/EventBus"
 	"github.com/rs/zerolog/log"
 )
 
 type Service interface {
 	StartHandlers()
-	StopHandlers()
+	StopHandlers(ctx context.Context)
 	StopNetwork(key handlerKey) error
 	ListNetworks(ctx context.Context) ([]domain.IrcNetwork, error)
 	GetNetworksWithHealth(ctx context.Context) ([]domain.IrcNetworkWithHealth, error)
@@ -25,28 +28,46 @@ type Service interface {
 	StoreNetwork(ctx context.Context, network *domain.IrcNetwork) error
 	UpdateNetwork(ctx context.Context, network *domain.IrcNetwork) error
 	StoreChannel(networkID int64, channel *domain.IrcChannel) error
+	ResumeQueued(ctx context.Context) error
+	HandlerCounts() map[string]int
 }
 
 type service struct {
 	repo           domain.IrcRepo
+	queueRepo      domain.AnnounceQueueRepo
 	filterService  filter.Service
 	indexerService indexer.Service
 	releaseService release.Service
+	bus            EventBus.Bus
 	indexerMap     map[string]string
-	handlers       map[handlerKey]*Handler
+
+	// handlers map[handlerKey]*Handler that is never changed in place, every change stores a copy.
+	// Reads load it without locking, so they don't wait on a network being started or stopped.
+	handlers atomic.Value
+
+	// limiter shared by the announce processors of every handler
+	limiter          *announce.Limiter
+	announceSettings domain.AnnounceSettings
 
 	stopWG sync.WaitGroup
-	lock   sync.Mutex
+	// lock serializes changes to handlers
+	lock sync.Mutex
 }
 
-func NewService(repo domain.IrcRepo, filterService filter.Service, indexerSvc indexer.Service, releaseSvc release.Service) Service {
-	return &service{
-		repo:           repo,
-		filterService:  filterService,
-		indexerService: indexerSvc,
-		releaseService: releaseSvc,
-		handlers:       make(map[handlerKey]*Handler),
+func NewService(repo domain.IrcRepo, queueRepo domain.AnnounceQueueRepo, filterService filter.Service, indexerSvc indexer.Service, releaseSvc release.Service, bus EventBus.Bus, announceSettings domain.AnnounceSettings) Service {
+	s := &service{
+		repo:             repo,
+		queueRepo:        queueRepo,
+		filterService:    filterService,
+		indexerService:   indexerSvc,
+		releaseService:   releaseSvc,
+		bus:              bus,
+		limiter:          announce.NewLimiter(announceSettings.Concurrency),
+		announceSettings: announceSettings,
 	}
+	s.handlers.Store(map[handlerKey]*Handler{})
+
+	return s
 }
 
 type handlerKey struct {
@@ -54,6 +75,76 @@ type handlerKey struct {
 	nick   string
 }
 
+// loadHandlers current handlers, the map must not be changed
+func (s *service) loadHandlers() map[handlerKey]*Handler {
+	return s.handlers.Load().(map[handlerKey]*Handler)
+}
+
+func (s *service) getHandler(key handlerKey) (*Handler, bool) {
+	handler, ok := s.loadHandlers()[key]
+	return handler, ok
+}
+
+// addHandler store handler under key, unless another handler got there first.
+// Returns the handler stored under key and if it is the one passed in.
+func (s *service) addHandler(key handlerKey, handler *Handler) (*Handler, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := s.loadHandlers()
+	if existing, ok := current[key]; ok {
+		return existing, false
+	}
+
+	handlers := make(map[handlerKey]*Handler, len(current)+1)
+	for k, h := range current {
+		handlers[k] = h
+	}
+	handlers[key] = handler
+
+	s.handlers.Store(handlers)
+
+	return handler, true
+}
+
+// removeHandler remove the handler under key and return it
+func (s *service) removeHandler(key handlerKey) (*Handler, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := s.loadHandlers()
+	handler, ok := current[key]
+	if !ok {
+		return nil, false
+	}
+
+	handlers := make(map[handlerKey]*Handler, len(current))
+	for k, h := range current {
+		if k != key {
+			handlers[k] = h
+		}
+	}
+
+	s.handlers.Store(handlers)
+
+	return handler, true
+}
+
+// newHandler handler for network with its channels and indexers
+func (s *service) newHandler(network domain.IrcNetwork) *Handler {
+	channels, err := s.repo.ListChannels(network.ID)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to list channels for network %q", network.Server)
+	}
+	network.Channels = channels
+
+	// find indexer definitions for network and add
+	definitions := s.indexerService.GetIndexersByIRCNetwork(network.Server)
+
+	// init new irc handler
+	return NewHandler(network, s.filterService, s.indexerService, s.releaseService, s.bus, definitions, s.queueRepo, s.limiter, s.announceSettings)
+}
+
 func (s *service) StartHandlers() {
 	networks, err := s.repo.FindActiveNetworks(context.Background())
 	if err != nil {
@@ -65,26 +156,14 @@ func (s *service) StartHandlers() {
 			continue
 		}
 
-		// check if already in handlers
-		//v, ok := s.handlers[network.Name]
-
-		s.lock.Lock()
-		channels, err := s.repo.ListChannels(network.ID)
-		if err != nil {
-			log.Error().Err(err).Msgf("failed to list channels for network %q", network.Server)
-		}
-		network.Channels = channels
-
-		// find indexer definitions for network and add
-		definitions := s.indexerService.GetIndexersByIRCNetwork(network.Server)
-
-		// init new irc handler
-		handler := NewHandler(network, s.filterService, s.releaseService, definitions)
+		handler := s.newHandler(network)
 
 		// use network.Server + nick to use multiple indexers with different nick per network
 		// this allows for multiple handlers to one network
-		s.handlers[handlerKey{network.Server, network.NickServ.Account}] = handler
-		s.lock.Unlock()
+		if _, added := s.addHandler(handlerKey{network.Server, network.NickServ.Account}, handler); !added {
+			log.Debug().Msgf("network already running: %+v", network.Name)
+			continue
+		}
 
 		log.Debug().Msgf("starting network: %+v", network.Name)
 
@@ -100,18 +179,41 @@ func (s *service) StartHandlers() {
 	}
 }
 
-func (s *service) StopHandlers() {
-	for _, handler := range s.handlers {
+// StopHandlers quit every network and wait for the announces they already received, until ctx is done
+func (s *service) StopHandlers(ctx context.Context) {
+	current := s.loadHandlers()
+	handlers := make([]*Handler, 0, len(current))
+	for _, handler := range current {
+		handlers = append(handlers, handler)
+	}
+
+	var wg sync.WaitGroup
+	for _, handler := range handlers {
 		log.Info().Msgf("stopping network: %+v", handler.network.Name)
-		handler.Stop()
+
+		wg.Add(1)
+		go func(h *Handler) {
+			defer wg.Done()
+
+			if err := h.Shutdown(ctx); err != nil {
+				log.Warn().Err(err).Msg("irc: network did not disconnect in time")
+			}
+		}(handler)
 	}
+	wg.Wait()
 
 	log.Info().Msg("stopped all irc handlers")
+
+	for _, handler := range handlers {
+		if err := handler.Drain(ctx); err != nil {
+			log.Warn().Err(err).Msg("irc: announces dropped on shutdown")
+		}
+	}
 }
 
 func (s *service) startNetwork(network domain.IrcNetwork) error {
 	// look if we have the network in handlers already, if so start it
-	if existingHandler, found := s.handlers[handlerKey{network.Server, network.NickServ.Account}]; found {
+	if existingHandler, found := s.getHandler(handlerKey{network.Server, network.NickServ.Account}); found {
 		log.Debug().Msgf("starting network: %+v", network.Name)
 
 		if !existingHandler.client.Connected() {
@@ -123,22 +225,13 @@ func (s *service) startNetwork(network domain.IrcNetwork) error {
 		}
 	} else {
 		// if not found in handlers, lets add it and run it
+		handler := s.newHandler(network)
 
-		s.lock.Lock()
-		channels, err := s.repo.ListChannels(network.ID)
-		if err != nil {
-			log.Error().Err(err).Msgf("failed to list channels for network %q", network.Server)
+		// another update could have started the network in the meantime
+		if _, added := s.addHandler(handlerKey{network.Server, network.NickServ.Account}, handler); !added {
+			log.Debug().Msgf("network already running: %+v", network.Name)
+			return nil
 		}
-		network.Channels = channels
-
-		// find indexer definitions for network and add
-		definitions := s.indexerService.GetIndexersByIRCNetwork(network.Server)
-
-		// init new irc handler
-		handler := NewHandler(network, s.filterService, s.releaseService, definitions)
-
-		s.handlers[handlerKey{network.Server, network.NickServ.Account}] = handler
-		s.lock.Unlock()
 
 		log.Debug().Msgf("starting network: %+v", network.Name)
 
@@ -158,7 +251,7 @@ func (s *service) startNetwork(network domain.IrcNetwork) error {
 
 func (s *service) checkIfNetworkRestartNeeded(network *domain.IrcNetwork) error {
 	// look if we have the network in handlers, if so restart it
-	if existingHandler, found := s.handlers[handlerKey{network.Server, network.NickServ.Account}]; found {
+	if existingHandler, found := s.getHandler(handlerKey{network.Server, network.NickServ.Account}); found {
 		log.Debug().Msgf("irc: decide if irc network handler needs restart or updating: %+v", network.Server)
 
 		// if server, tls, invite command, port : changed - restart
@@ -288,7 +381,7 @@ func (s *service) checkIfNetworkRestartNeeded(network *domain.IrcNetwork) error
 
 func (s *service) restartNetwork(network domain.IrcNetwork) error {
 	// look if we have the network in handlers, if so restart it
-	if existingHandler, found := s.handlers[handlerKey{network.Server, network.NickServ.Account}]; found {
+	if existingHandler, found := s.getHandler(handlerKey{network.Server, network.NickServ.Account}); found {
 		log.Info().Msgf("restarting network: %v", network.Name)
 
 		if existingHandler.client.Connected() {
@@ -306,7 +399,7 @@ func (s *service) restartNetwork(network domain.IrcNetwork) error {
 }
 
 func (s *service) StopNetwork(key handlerKey) error {
-	if handler, found := s.handlers[key]; found {
+	if handler, found := s.getHandler(key); found {
 		handler.Stop()
 		log.Debug().Msgf("stopped network: %+v", key.server)
 	}
@@ -315,11 +408,10 @@ func (s *service) StopNetwork(key handlerKey) error {
 }
 
 func (s *service) StopAndRemoveNetwork(key handlerKey) error {
-	if handler, found := s.handlers[key]; found {
+	// remove from handlers
+	if handler, found := s.removeHandler(key); found {
 		handler.Stop()
 
-		// remove from handlers
-		delete(s.handlers, key)
 		log.Debug().Msgf("stopped network: %+v", key)
 	}
 
@@ -327,7 +419,7 @@ func (s *service) StopAndRemoveNetwork(key handlerKey) error {
 }
 
 func (s *service) StopNetworkIfRunning(key handlerKey) error {
-	if handler, found := s.handlers[key]; found {
+	if handler, found := s.getHandler(key); found {
 		handler.Stop()
 		log.Debug().Msgf("stopped network: %+v", key.server)
 	}
@@ -384,6 +476,8 @@ func (s *service) GetNetworksWithHealth(ctx context.Context) ([]domain.IrcNetwor
 
 	var ret []domain.IrcNetworkWithHealth
 
+	handlers := s.loadHandlers()
+
 	for _, n := range networks {
 		netw := domain.IrcNetworkWithHealth{
 			ID:            n.ID,
@@ -399,7 +493,7 @@ func (s *service) GetNetworksWithHealth(ctx context.Context) ([]domain.IrcNetwor
 			Channels:      []domain.ChannelWithHealth{},
 		}
 
-		handler, ok := s.handlers[handlerKey{n.Server, n.NickServ.Account}]
+		handler, ok := handlers[handlerKey{n.Server, n.NickServ.Account}]
 		if ok {
 			// only set connected and connected since if we have an active handler and connection
 			if handler.client.Connected() {
@@ -433,7 +527,7 @@ func (s *service) GetNetworksWithHealth(ctx context.Context) ([]domain.IrcNetwor
 			if handler != nil {
 				name := strings.ToLower(channel.Name)
 
-				chan1, ok := handler.channelHealth[name]
+				chan1, ok := handler.getChannelHealth(name)
 				if ok {
 					chan1.m.RLock()
 					ch.Monitoring = chan1.monitoring
@@ -576,3 +670,30 @@ func (s *service) StoreChannel(networkID int64, channel *domain.IrcChannel) erro
 
 	return nil
 }
+
+// ResumeQueued check the announces again that were still waiting for the filters on shutdown or a crash
+func (s *service) ResumeQueued(ctx context.Context) error {
+	return announce.ResumeQueued(ctx, s.queueRepo, s.filterService, s.indexerService, s.releaseService, s.limiter)
+}
+
+// HandlerCounts running irc handlers, how many are connected and their announce processors
+func (s *service) HandlerCounts() map[string]int {
+	handlers := s.loadHandlers()
+
+	counts := map[string]int{
+		"irc_handlers":        len(handlers),
+		"irc_connected":       0,
+		"announce_processors": 0,
+	}
+
+	for _, h := range handlers {
+		h.m.RLock()
+		if h.connected {
+			counts["irc_connected"]++
+		}
+		counts["announce_processors"] += len(h.announceProcessors)
+		h.m.RUnlock()
+	}
+
+	return counts
+}
@@ -0,0 +1,82 @@
+package irc
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func testHandler(server string) *Handler {
+	return NewHandler(domain.IrcNetwork{Server: server}, nil, nil, nil, nil, nil, nil, nil, domain.AnnounceSettings{})
+}
+
+func Test_service_addHandler(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, nil, nil, domain.AnnounceSettings{}).(*service)
+	key := handlerKey{"irc.example.com", "autobrr"}
+
+	first := testHandler("irc.example.com")
+	stored, added := s.addHandler(key, first)
+	assert.True(t, added)
+	assert.Same(t, first, stored)
+
+	// a second start of the same network keeps the running handler
+	stored, added = s.addHandler(key, testHandler("irc.example.com"))
+	assert.False(t, added)
+	assert.Same(t, first, stored)
+
+	removed, ok := s.removeHandler(key)
+	assert.True(t, ok)
+	assert.Same(t, first, removed)
+
+	_, ok = s.getHandler(key)
+	assert.False(t, ok)
+}
+
+func Test_service_handlers_readsDoNotBlock(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, nil, nil, domain.AnnounceSettings{}).(*service)
+	s.addHandler(handlerKey{"irc.example.com", "autobrr"}, testHandler("irc.example.com"))
+
+	// a change in progress
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	done := make(chan map[string]int)
+	go func() {
+		done <- s.HandlerCounts()
+	}()
+
+	select {
+	case counts := <-done:
+		assert.Equal(t, 1, counts["irc_handlers"])
+	case <-time.After(time.Second):
+		t.Fatal("reading the handlers waited on the lock")
+	}
+}
+
+func Test_service_handlers_concurrent(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, nil, nil, domain.AnnounceSettings{}).(*service)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := handlerKey{fmt.Sprintf("irc%d.example.com", i), "autobrr"}
+			for j := 0; j < 100; j++ {
+				s.addHandler(key, testHandler(key.server))
+				s.getHandler(key)
+				s.HandlerCounts()
+				s.removeHandler(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Empty(t, s.loadHandlers())
+}
@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// DefaultBufferSize log lines kept in memory for the logs api
+const DefaultBufferSize = 1000
+
+// modulePrefixRegexp prefix like "filter.import: " most log messages start with
+var modulePrefixRegexp = regexp.MustCompile(`^([a-z][a-z0-9_\-]*(?:\.[a-z0-9_\-]+)*): `)
+
+// Buffer ring buffer of the most recent log entries, written to by zerolog as json lines
+type Buffer struct {
+	mu          sync.Mutex
+	entries     []domain.LogEntry
+	next        int
+	full        bool
+	lastID      int64
+	subscribers map[chan domain.LogEntry]struct{}
+}
+
+func NewBuffer(size int) *Buffer {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	return &Buffer{
+		entries:     make([]domain.LogEntry, size),
+		subscribers: map[chan domain.LogEntry]struct{}{},
+	}
+}
+
+// Write one zerolog event, lines that are not json are kept as the message
+func (b *Buffer) Write(p []byte) (int, error) {
+	entry := parseEntry(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	entry.ID = b.lastID
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	// slow readers miss lines instead of holding up logging
+	for ch := range b.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// Find the newest entries matching the params, oldest first
+func (b *Buffer) Find(params domain.LogQueryParams) ([]domain.LogEntry, error) {
+	match, err := Match(params)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]domain.LogEntry, 0)
+	for _, entry := range b.ordered() {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if params.Limit > 0 && len(entries) > params.Limit {
+		entries = entries[len(entries)-params.Limit:]
+	}
+
+	return entries, nil
+}
+
+// Subscribe new entries until cancel is called
+func (b *Buffer) Subscribe() (<-chan domain.LogEntry, func()) {
+	ch := make(chan domain.LogEntry, 100)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+
+	return ch, cancel
+}
+
+func (b *Buffer) ordered() []domain.LogEntry {
+	if !b.full {
+		return b.entries[:b.next]
+	}
+
+	ordered := make([]domain.LogEntry, 0, len(b.entries))
+	ordered = append(ordered, b.entries[b.next:]...)
+	ordered = append(ordered, b.entries[:b.next]...)
+
+	return ordered
+}
+
+// Match filter for the params, also used for entries from Subscribe
+func Match(params domain.LogQueryParams) (func(entry domain.LogEntry) bool, error) {
+	minLevel := zerolog.TraceLevel
+	if params.Level != "" {
+		level, err := zerolog.ParseLevel(strings.ToLower(params.Level))
+		if err != nil {
+			return nil, err
+		}
+		minLevel = level
+	}
+
+	module := strings.ToLower(params.Module)
+	search := strings.ToLower(params.Search)
+
+	return func(entry domain.LogEntry) bool {
+		if entry.ID <= params.After {
+			return false
+		}
+
+		if level, err := zerolog.ParseLevel(entry.Level); err == nil && level < minLevel {
+			return false
+		}
+
+		if module != "" && entry.Module != module && !strings.HasPrefix(entry.Module, module+".") {
+			return false
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(entry.Message), search) && !strings.Contains(strings.ToLower(entry.Error), search) {
+			return false
+		}
+
+		return true
+	}, nil
+}
+
+func parseEntry(p []byte) domain.LogEntry {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return domain.LogEntry{Time: time.Now(), Message: strings.TrimSpace(string(p))}
+	}
+
+	entry := domain.LogEntry{Time: time.Now()}
+
+	if value, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(zerolog.TimeFieldFormat, value); err == nil {
+			entry.Time = t
+		}
+	}
+
+	entry.Level, _ = fields[zerolog.LevelFieldName].(string)
+	entry.Message, _ = fields[zerolog.MessageFieldName].(string)
+	entry.Error, _ = fields[zerolog.ErrorFieldName].(string)
+	entry.Module, _ = fields["module"].(string)
+
+	if entry.Module == "" {
+		if m := modulePrefixRegexp.FindStringSubmatch(entry.Message); m != nil {
+			entry.Module = m[1]
+		}
+	}
+
+	for _, key := range []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.ErrorFieldName, "module"} {
+		delete(fields, key)
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry
+}
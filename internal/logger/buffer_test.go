@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestBuffer_Find(t *testing.T) {
+	buffer := NewBuffer(3)
+	log := zerolog.New(buffer)
+
+	log.Debug().Msg("irc: connecting")
+	log.Info().Msg("filter.import: imported 2 filters")
+	log.Warn().Msg("irc: lost connection")
+	log.Error().Str("module", "action").Err(errors.New("timeout")).Msg("could not send torrent")
+
+	tests := []struct {
+		name   string
+		params domain.LogQueryParams
+		want   []string
+	}{
+		{name: "oldest dropped", params: domain.LogQueryParams{}, want: []string{"filter.import: imported 2 filters", "irc: lost connection", "could not send torrent"}},
+		{name: "min level", params: domain.LogQueryParams{Level: "warn"}, want: []string{"irc: lost connection", "could not send torrent"}},
+		{name: "module with submodules", params: domain.LogQueryParams{Module: "filter"}, want: []string{"filter.import: imported 2 filters"}},
+		{name: "module field", params: domain.LogQueryParams{Module: "action"}, want: []string{"could not send torrent"}},
+		{name: "search error", params: domain.LogQueryParams{Search: "TIMEOUT"}, want: []string{"could not send torrent"}},
+		{name: "after id", params: domain.LogQueryParams{After: 3}, want: []string{"could not send torrent"}},
+		{name: "limit keeps newest", params: domain.LogQueryParams{Limit: 1}, want: []string{"could not send torrent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := buffer.Find(tt.params)
+			assert.NoError(t, err)
+
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Message)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := buffer.Find(domain.LogQueryParams{Level: "loud"})
+	assert.Error(t, err)
+}
+
+func TestBuffer_Subscribe(t *testing.T) {
+	buffer := NewBuffer(10)
+	log := zerolog.New(buffer)
+
+	entries, cancel := buffer.Subscribe()
+
+	log.Info().Msg("irc: connected")
+
+	entry := <-entries
+	assert.Equal(t, "irc", entry.Module)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, int64(1), entry.ID)
+
+	cancel()
+	log.Info().Msg("not delivered")
+
+	assert.Len(t, entries, 0)
+}
@@ -1,9 +1,13 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	stdlog "log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/autobrr/autobrr/internal/domain"
@@ -21,45 +25,39 @@ var (
 	StdLeveledLogger *stdlog.Logger
 )
 
-func Setup(cfg domain.Config, sse *sse.Server) {
+// Logger output of the global logger, the level and log file can change while running
+type Logger struct {
+	*Buffer
 
-	zerolog.TimeFieldFormat = time.RFC3339
-	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+	mu       sync.Mutex
+	settings domain.LogSettings
+	console  io.Writer
+	file     *lumberjack.Logger
+	writer   io.Writer
+}
 
-	switch cfg.LogLevel {
-	case "INFO":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case "DEBUG":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "ERROR":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	case "WARN":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
-	case "TRACE":
-		zerolog.SetGlobalLevel(zerolog.TraceLevel)
-	default:
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	}
+// Setup the global logger, writing to the console, the log file when set and the in memory buffer
+func Setup(cfg domain.Config, sse *sse.Server) *Logger {
 
-	// setup console writer
-	consoleWriter := zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}
+	zerolog.TimeFieldFormat = time.RFC3339
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
-	writers := io.MultiWriter(consoleWriter)
+	l := &Logger{
+		Buffer: NewBuffer(DefaultBufferSize),
 
-	// if logPath set create file writer
-	if cfg.LogPath != "" {
-		fileWriter := &lumberjack.Logger{
-			Filename:   cfg.LogPath,
-			MaxSize:    100, // megabytes
-			MaxBackups: 3,
-		}
+		// setup console writer
+		console: zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339},
+	}
 
-		// overwrite writers
-		writers = io.MultiWriter(consoleWriter, fileWriter)
+	settings := cfg.LogSettings()
+	if err := l.apply(settings); err != nil {
+		// an unknown level in the config keeps the old behaviour of only logging errors
+		settings.Level = "ERROR"
+		l.apply(settings)
 	}
 
 	log.Logger = log.Hook(&ServerSentEventHook{sse: sse})
-	log.Logger = log.Output(writers)
+	log.Logger = log.Output(l)
 
 	// init a logger to use
 	//log := zerolog.New(os.Stdout)
@@ -69,4 +67,110 @@ func Setup(cfg domain.Config, sse *sse.Server) {
 
 	// creates a *log.Logger with a level prefix
 	StdLeveledLogger = zstdlog.NewStdLoggerWithLevel(log.Logger, zerolog.TraceLevel)
+
+	return l
+}
+
+func (l *Logger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	w := l.writer
+	l.mu.Unlock()
+
+	return w.Write(p)
+}
+
+func (l *Logger) Settings() domain.LogSettings {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.settings
+}
+
+// UpdateSettings change the level and log file without a restart, the settings are not written to the config
+func (l *Logger) UpdateSettings(settings domain.LogSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.apply(settings); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("logger: level %v, log file %q", settings.Level, settings.Path)
+
+	return nil
+}
+
+func (l *Logger) apply(settings domain.LogSettings) error {
+	level, err := parseLevel(settings.Level)
+	if err != nil {
+		return err
+	}
+	settings.Level = strings.ToUpper(settings.Level)
+
+	var file *lumberjack.Logger
+	if settings.Path != "" {
+		if err := checkLogFile(settings.Path); err != nil {
+			return err
+		}
+
+		file = &lumberjack.Logger{
+			Filename:   settings.Path,
+			MaxSize:    settings.MaxSize, // megabytes
+			MaxBackups: settings.MaxBackups,
+			MaxAge:     settings.MaxAge, // days
+			Compress:   settings.Compress,
+		}
+	}
+
+	writers := []io.Writer{l.console, l.Buffer}
+	if file != nil {
+		writers = append(writers, file)
+	}
+
+	l.mu.Lock()
+	previous := l.file
+	l.file = file
+	l.writer = io.MultiWriter(writers...)
+	l.settings = settings
+	l.mu.Unlock()
+
+	zerolog.SetGlobalLevel(level)
+
+	if previous != nil {
+		previous.Close()
+	}
+
+	return nil
+}
+
+func parseLevel(level string) (zerolog.Level, error) {
+	switch strings.ToUpper(level) {
+	case "TRACE":
+		return zerolog.TraceLevel, nil
+	case "DEBUG":
+		return zerolog.DebugLevel, nil
+	case "INFO":
+		return zerolog.InfoLevel, nil
+	case "WARN":
+		return zerolog.WarnLevel, nil
+	case "ERROR":
+		return zerolog.ErrorLevel, nil
+	}
+
+	return zerolog.ErrorLevel, fmt.Errorf("invalid log level %q", level)
+}
+
+// checkLogFile the directory exists and the file can be written, lumberjack would only fail on the first line
+func checkLogFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+
+	return f.Close()
 }
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestLogger_UpdateSettings(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	l := &Logger{Buffer: NewBuffer(10), console: ioutil.Discard}
+	assert.NoError(t, l.apply(domain.LogSettings{Level: "INFO", MaxSize: 1}))
+
+	path := filepath.Join(t.TempDir(), "logs", "autobrr.log")
+
+	err := l.UpdateSettings(domain.LogSettings{Level: "warn", Path: path, MaxSize: 1, MaxBackups: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+	assert.Equal(t, "WARN", l.Settings().Level)
+
+	log := zerolog.New(l)
+	log.Info().Msg("not written")
+	log.Warn().Msg("written to file")
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "written to file")
+	assert.NotContains(t, string(b), "not written")
+
+	err = l.UpdateSettings(domain.LogSettings{Level: "LOUD", MaxSize: 1})
+	assert.Error(t, err)
+	assert.Equal(t, path, l.Settings().Path)
+}
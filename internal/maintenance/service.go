@@ -0,0 +1,136 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type Service interface {
+	Run(ctx context.Context) (*domain.MaintenanceResult, error)
+}
+
+type maintenanceDB interface {
+	Size(ctx context.Context) (int64, error)
+	Vacuum(ctx context.Context) error
+}
+
+type backupService interface {
+	RunScheduled(ctx context.Context) (string, error)
+}
+
+type service struct {
+	db       maintenanceDB
+	backups  backupService
+	bus      EventBus.Bus
+	settings domain.MaintenanceSettings
+
+	// one run at a time, vacuum needs the database to itself
+	lock sync.Mutex
+}
+
+func NewService(db maintenanceDB, backups backupService, bus EventBus.Bus, settings domain.MaintenanceSettings) Service {
+	return &service{
+		db:       db,
+		backups:  backups,
+		bus:      bus,
+		settings: settings,
+	}
+}
+
+// Run back up and vacuum the database, the outcome is sent as a notification
+func (s *service) Run(ctx context.Context) (*domain.MaintenanceResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res, err := s.run(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("maintenance: database maintenance failed")
+
+		s.bus.Publish(domain.EventNotification, &domain.NotificationEvent{
+			Level:   "error",
+			Title:   "Database maintenance failed",
+			Message: err.Error(),
+		})
+
+		return nil, err
+	}
+
+	log.Info().Msgf("maintenance: database maintenance done in %v, %v to %v", res.Duration, humanize.IBytes(uint64(res.SizeBefore)), humanize.IBytes(uint64(res.SizeAfter)))
+
+	s.bus.Publish(domain.EventNotification, &domain.NotificationEvent{
+		Level:   "info",
+		Title:   "Database maintenance done",
+		Message: message(res),
+	})
+
+	return res, nil
+}
+
+func (s *service) run(ctx context.Context) (*domain.MaintenanceResult, error) {
+	res := &domain.MaintenanceResult{StartedAt: time.Now()}
+
+	size, err := s.db.Size(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get database size: %w", err)
+	}
+	res.SizeBefore = size
+
+	if s.settings.Backup {
+		if res.Backup, err = s.backups.RunScheduled(ctx); err != nil {
+			return nil, fmt.Errorf("could not back up database: %w", err)
+		}
+	}
+
+	if err := s.db.Vacuum(ctx); err != nil {
+		return nil, err
+	}
+
+	if res.SizeAfter, err = s.db.Size(ctx); err != nil {
+		return nil, fmt.Errorf("could not get database size: %w", err)
+	}
+
+	res.Duration = time.Since(res.StartedAt).Round(time.Millisecond)
+
+	return res, nil
+}
+
+func message(res *domain.MaintenanceResult) string {
+	msg := fmt.Sprintf("Compacted from %v to %v in %v.", humanize.IBytes(uint64(res.SizeBefore)), humanize.IBytes(uint64(res.SizeAfter)), res.Duration)
+	if res.Backup != "" {
+		msg += fmt.Sprintf(" Backup written to %v.", res.Backup)
+	}
+
+	return msg
+}
+
+// Job run the maintenance at the configured times, checked every minute
+type Job struct {
+	service  Service
+	settings domain.MaintenanceSettings
+	last     time.Time
+}
+
+func NewJob(service Service, settings domain.MaintenanceSettings) *Job {
+	// not right away on start, only at the next configured time
+	return &Job{service: service, settings: settings, last: time.Now()}
+}
+
+func (j *Job) Run() {
+	now := time.Now()
+	if !j.settings.Due(j.last, now) {
+		return
+	}
+
+	j.last = now
+
+	// failures are logged and notified by the service
+	_, _ = j.service.Run(context.Background())
+}
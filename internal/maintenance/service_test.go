@@ -0,0 +1,63 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeBackups struct {
+	path string
+	err  error
+	runs int
+}
+
+func (b *fakeBackups) RunScheduled(ctx context.Context) (string, error) {
+	b.runs++
+	return b.path, b.err
+}
+
+func TestService_Run(t *testing.T) {
+	db := database.NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	defer db.Close()
+
+	bus := EventBus.New()
+
+	var notifications []*domain.NotificationEvent
+	require.NoError(t, bus.Subscribe(domain.EventNotification, func(event *domain.NotificationEvent) {
+		notifications = append(notifications, event)
+	}))
+
+	backups := &fakeBackups{path: "/config/backups/autobrr-backup.tar.gz"}
+
+	res, err := NewService(db, backups, bus, domain.MaintenanceSettings{Backup: true}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, backups.runs)
+	assert.Equal(t, backups.path, res.Backup)
+	assert.Greater(t, res.SizeAfter, int64(0))
+
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "info", notifications[0].Level)
+	assert.Contains(t, notifications[0].Message, backups.path)
+
+	// without a backup the vacuum still runs
+	_, err = NewService(db, backups, bus, domain.MaintenanceSettings{}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, backups.runs)
+
+	backups.err = errors.New("disk full")
+	_, err = NewService(db, backups, bus, domain.MaintenanceSettings{Backup: true}).Run(context.Background())
+	assert.Error(t, err)
+
+	require.Len(t, notifications, 3)
+	assert.Equal(t, "error", notifications[2].Level)
+	assert.Contains(t, notifications[2].Message, "disk full")
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// Default registry with the autobrr metrics, served on /metrics
+var Default = NewRegistry()
+
+var (
+	AnnouncesProcessed = Default.NewCounter("autobrr_announces_processed_total",
+		"Announces parsed into a release.", "indexer")
+
+	FilterMatches = Default.NewCounter("autobrr_filter_matches_total",
+		"Releases matched by a filter.", "filter")
+
+	ActionResults = Default.NewCounter("autobrr_action_results_total",
+		"Action outcomes by client type and status: approved, rejected or error.", "type", "status")
+
+	IrcConnected = Default.NewGauge("autobrr_irc_network_connected",
+		"1 when the irc network is connected, 0 when not.", "network")
+
+	DatabaseQueryDuration = Default.NewHistogram("autobrr_database_query_duration_seconds",
+		"Time spent running database statements.", DefaultBuckets, "operation")
+
+	ReleasePipelineDuration = Default.NewHistogram("autobrr_release_pipeline_duration_seconds",
+		"Time from the announce until the filters are checked (filter) and time to run an action (action).", DefaultBuckets, "stage")
+
+	started = time.Now()
+
+	_ = Default.NewGaugeFunc("autobrr_uptime_seconds", "Seconds since autobrr started.", func() float64 {
+		return time.Since(started).Seconds()
+	})
+
+	_ = Default.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+)
+
+// Pipeline stages of ReleasePipelineDuration
+const (
+	StageFilter = "filter"
+	StageAction = "action"
+)
+
+// Subscriber counts matches, action results and irc connection changes from the event bus
+type Subscriber struct {
+	eventbus EventBus.Bus
+}
+
+func NewSubscriber(eventbus EventBus.Bus) Subscriber {
+	s := Subscriber{eventbus: eventbus}
+
+	s.Register()
+
+	return s
+}
+
+func (s Subscriber) Register() {
+	s.eventbus.Subscribe(domain.EventReleaseMatched, s.releaseMatched)
+	s.eventbus.Subscribe("release:push-approved", s.actionApproved)
+	s.eventbus.Subscribe("release:push-rejected", s.actionRejected)
+	s.eventbus.Subscribe("release:store-action-status", s.actionStatus)
+	s.eventbus.Subscribe(domain.EventIrcConnection, s.ircConnection)
+}
+
+func (s Subscriber) releaseMatched(release *domain.Release) {
+	FilterMatches.Inc(release.FilterName)
+}
+
+func (s Subscriber) actionApproved(status *domain.ReleaseActionStatus) {
+	ActionResults.Inc(string(status.Type), "approved")
+}
+
+func (s Subscriber) actionRejected(status *domain.ReleaseActionStatus) {
+	ActionResults.Inc(string(status.Type), "rejected")
+}
+
+// actionStatus only errors, pending and other statuses are not an outcome
+func (s Subscriber) actionStatus(status *domain.ReleaseActionStatus) {
+	if status.Status == domain.ReleasePushStatusErr {
+		ActionResults.Inc(string(status.Type), "error")
+	}
+}
+
+func (s Subscriber) ircConnection(event *domain.IrcConnectionEvent) {
+	connected := 0.0
+	if event.Connected {
+		connected = 1
+	}
+
+	IrcConnected.Set(connected, event.Network)
+}
+
+// ObserveDuration seconds since start in the histogram
+func ObserveDuration(h *Histogram, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
@@ -0,0 +1,341 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// labelSeparator joins label values into series keys, it can't be part of a label value in the output
+const labelSeparator = "\xff"
+
+// DefaultBuckets histogram buckets in seconds, from a millisecond to a minute
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// Registry metrics in the prometheus text format
+type Registry struct {
+	mu      sync.Mutex
+	metrics []collector
+}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, c)
+}
+
+// WriteTo write every metric in the prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := make([]collector, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	for _, m := range metrics {
+		m.write(bw)
+	}
+
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+// Handler serves the metrics for prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// desc name, help and label names shared by all series of a metric
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %v has %d labels, got %d values", d.name, len(d.labels), len(values)))
+	}
+
+	return strings.Join(values, labelSeparator)
+}
+
+func (d desc) writeHeader(w *bufio.Writer, t metricType) {
+	fmt.Fprintf(w, "# HELP %v %v\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %v %v\n", d.name, t)
+}
+
+// writeSample one line like name{label="value"} 1
+func (d desc) writeSample(w *bufio.Writer, name string, key string, extra string, value float64) {
+	w.WriteString(name)
+
+	var pairs []string
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, labelSeparator) {
+			pairs = append(pairs, fmt.Sprintf(`%v="%v"`, d.labels[i], escapeLabel(value)))
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// Counter value per label set that only goes up
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: map[string]float64{}}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w, counterType)
+	for _, key := range sortedKeys(c.values) {
+		c.writeSample(w, c.name, key, "", c.values[key])
+	}
+}
+
+// Gauge value per label set that can go up and down
+type Gauge struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+	fn     func() float64
+}
+
+func (r *Registry) NewGauge(name string, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help, labels: labels}, values: map[string]float64{}}
+	r.register(g)
+	return g
+}
+
+// NewGaugeFunc gauge without labels that calls fn on every scrape
+func (r *Registry) NewGaugeFunc(name string, help string, fn func() float64) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help}, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mu.Lock()
+	g.values[key] = v
+	g.mu.Unlock()
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mu.Lock()
+	g.values[key] += v
+	g.mu.Unlock()
+}
+
+// Delete drop the series, for things that are gone like a removed network
+func (g *Gauge) Delete(labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mu.Lock()
+	delete(g.values, key)
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeHeader(w, gaugeType)
+
+	if g.fn != nil {
+		g.writeSample(w, g.name, "", "", g.fn())
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, key := range sortedKeys(g.values) {
+		g.writeSample(w, g.name, key, "", g.values[key])
+	}
+}
+
+// Histogram observations per label set counted in buckets
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	h := &Histogram{desc: desc{name: name, help: help, labels: labels}, buckets: sorted, series: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w, histogramType)
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			h.writeSample(w, h.name+"_bucket", key, fmt.Sprintf(`le="%v"`, formatFloat(bound)), float64(cumulative))
+		}
+		h.writeSample(w, h.name+"_bucket", key, `le="+Inf"`, float64(s.count))
+		h.writeSample(w, h.name+"_sum", key, "", s.sum)
+		h.writeSample(w, h.name+"_count", key, "", float64(s.count))
+	}
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+
+	announces := r.NewCounter("test_announces_total", "Announces.", "indexer")
+	announces.Inc("red")
+	announces.Add(2, "ptp")
+	announces.Inc("red")
+
+	connected := r.NewGauge("test_connected", "Connected networks.", "network")
+	connected.Set(1, `Net "1"`)
+
+	duration := r.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "stage")
+	duration.Observe(0.05, "filter")
+	duration.Observe(0.5, "filter")
+	duration.Observe(3, "filter")
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	want := `# HELP test_announces_total Announces.
+# TYPE test_announces_total counter
+test_announces_total{indexer="ptp"} 2
+test_announces_total{indexer="red"} 2
+# HELP test_connected Connected networks.
+# TYPE test_connected gauge
+test_connected{network="Net \"1\""} 1
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{stage="filter",le="0.1"} 1
+test_duration_seconds_bucket{stage="filter",le="1"} 2
+test_duration_seconds_bucket{stage="filter",le="+Inf"} 3
+test_duration_seconds_sum{stage="filter"} 3.55
+test_duration_seconds_count{stage="filter"} 3
+`
+
+	assert.Equal(t, want, buf.String())
+}
+
+func TestCounter_wrongLabels(t *testing.T) {
+	c := NewRegistry().NewCounter("test_total", "Test.", "a", "b")
+
+	assert.Panics(t, func() { c.Inc("only one") })
+}
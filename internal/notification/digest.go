@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// digest collects the events of an agent and sends them as one summary every interval
+type digest struct {
+	name     string
+	sender   domain.NotificationSender
+	interval time.Duration
+
+	pending []domain.NotificationPayload
+	last    time.Time
+	lock    sync.Mutex
+}
+
+func newDigest(now time.Time) *digest {
+	return &digest{last: now}
+}
+
+// update the agent behind the digest after it was changed, collected events stay
+func (d *digest) update(n domain.Notification, sender domain.NotificationSender) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.name = n.Name
+	d.sender = sender
+	d.interval = time.Duration(n.Settings.DigestInterval) * time.Minute
+}
+
+func (d *digest) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.pending = append(d.pending, payload)
+
+	return nil
+}
+
+// Flush send the summary once the interval passed since the last one, events of a failed send are dropped
+func (d *digest) Flush(ctx context.Context, now time.Time) error {
+	d.lock.Lock()
+	if len(d.pending) == 0 || now.Sub(d.last) < d.interval {
+		d.lock.Unlock()
+		return nil
+	}
+
+	pending, sender := d.pending, d.sender
+	d.pending = nil
+	d.last = now
+	d.lock.Unlock()
+
+	return sender.Send(ctx, digestPayload(pending, now))
+}
+
+func digestPayload(pending []domain.NotificationPayload, now time.Time) domain.NotificationPayload {
+	payload := domain.NotificationPayload{
+		Level:     domain.NotificationLevelInfo,
+		Timestamp: now,
+	}
+
+	if len(pending) == 1 {
+		payload.Title = "1 notification"
+	} else {
+		payload.Title = fmt.Sprintf("%d notifications", len(pending))
+	}
+
+	// counts first, the details of a busy day are a long read
+	var titles []string
+	counts := map[string]int{}
+	for _, p := range pending {
+		if counts[p.Title] == 0 {
+			titles = append(titles, p.Title)
+		}
+		counts[p.Title]++
+
+		switch {
+		case p.Level == domain.NotificationLevelError:
+			payload.Level = domain.NotificationLevelError
+		case p.Level == domain.NotificationLevelWarning && payload.Level != domain.NotificationLevelError:
+			payload.Level = domain.NotificationLevelWarning
+		}
+	}
+
+	var b strings.Builder
+	for _, title := range titles {
+		fmt.Fprintf(&b, "%v: %d\n", title, counts[title])
+	}
+
+	for _, p := range pending {
+		fmt.Fprintf(&b, "\n%v %v\n", p.Timestamp.Format("2006-01-02 15:04"), p.Title)
+		if p.Message != "" {
+			b.WriteString(p.Message + "\n")
+		}
+	}
+
+	payload.Message = strings.TrimSuffix(b.String(), "\n")
+
+	return payload
+}
+
+// DigestJob send the digests that are due, runs every minute
+type DigestJob struct {
+	service Service
+}
+
+func NewDigestJob(service Service) *DigestJob {
+	return &DigestJob{service: service}
+}
+
+func (j *DigestJob) Run() {
+	j.service.FlushDigests(context.Background())
+}
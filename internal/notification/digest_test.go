@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeSender struct {
+	sent []domain.NotificationPayload
+}
+
+func (s *fakeSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	s.sent = append(s.sent, payload)
+	return nil
+}
+
+func TestDigest_Flush(t *testing.T) {
+	start := time.Date(2022, 4, 15, 12, 0, 0, 0, time.UTC)
+	sender := &fakeSender{}
+
+	d := newDigest(start)
+	d.update(domain.Notification{Name: "mail", Type: domain.NotificationTypeEmail, Settings: domain.NotificationSettings{DigestInterval: 60}}, sender)
+
+	require.NoError(t, d.Flush(context.Background(), start.Add(2*time.Hour)))
+	assert.Empty(t, sender.sent, "nothing collected")
+
+	d.Send(context.Background(), domain.NotificationPayload{Level: domain.NotificationLevelInfo, Title: "New release!", Message: "Release.One", Timestamp: start.Add(time.Minute)})
+	d.Send(context.Background(), domain.NotificationPayload{Level: domain.NotificationLevelError, Title: "Action failed", Message: "Release.Two", Timestamp: start.Add(2 * time.Minute)})
+	d.Send(context.Background(), domain.NotificationPayload{Level: domain.NotificationLevelInfo, Title: "New release!", Message: "Release.Three", Timestamp: start.Add(3 * time.Minute)})
+
+	require.NoError(t, d.Flush(context.Background(), start.Add(30*time.Minute)))
+	assert.Empty(t, sender.sent, "interval not passed")
+
+	require.NoError(t, d.Flush(context.Background(), start.Add(time.Hour)))
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "3 notifications", sender.sent[0].Title)
+	assert.Equal(t, domain.NotificationLevelError, sender.sent[0].Level)
+	assert.Equal(t, "New release!: 2\nAction failed: 1\n\n2022-04-15 12:01 New release!\nRelease.One\n\n2022-04-15 12:02 Action failed\nRelease.Two\n\n2022-04-15 12:03 New release!\nRelease.Three", sender.sent[0].Message)
+
+	d.Send(context.Background(), domain.NotificationPayload{Title: "New release!"})
+	require.NoError(t, d.Flush(context.Background(), start.Add(90*time.Minute)))
+	assert.Len(t, sender.sent, 1, "next digest an interval after the last")
+}
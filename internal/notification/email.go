@@ -0,0 +1,174 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const smtpTimeout = 30 * time.Second
+
+type emailSender struct {
+	notification domain.Notification
+}
+
+func NewEmailSender(notification domain.Notification) domain.NotificationSender {
+	return &emailSender{notification: notification}
+}
+
+func (s *emailSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	if err := s.send(ctx, payload); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailSender) send(ctx context.Context, payload domain.NotificationPayload) error {
+	settings := s.notification.Settings
+
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return err
+	}
+
+	msg, err := emailMessage(settings, payload)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dialSmtp(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// starttls is the default, credentials and releases don't go out in plain text unless asked for
+	if settings.Encryption == "" || settings.Encryption == domain.SmtpEncryptionStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server doesn't support STARTTLS")
+		}
+
+		if err := c.StartTLS(&tls.Config{ServerName: settings.Host}); err != nil {
+			return err
+		}
+	}
+
+	if settings.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+
+	for _, to := range settings.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func dialSmtp(ctx context.Context, settings domain.NotificationSettings) (net.Conn, error) {
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(settings.SmtpPort()))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if settings.Encryption == domain.SmtpEncryptionTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: settings.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// net/smtp has no context, a server that stops answering would hang the sender
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func emailMessage(settings domain.NotificationSettings, payload domain.NotificationPayload) ([]byte, error) {
+	date := payload.Timestamp
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var to []string
+	for _, addr := range settings.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		to = append(to, a.String())
+	}
+
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", from.String())
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", "autobrr: "+payload.Title))
+	fmt.Fprintf(&b, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(payload.Message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type smtpMail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSmtp a plain text smtp server that takes one mail per connection
+func fakeSmtp(t *testing.T) (int, chan smtpMail) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	mails := make(chan smtpMail, 1)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("220 localhost ESMTP")
+
+				var m smtpMail
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+
+					cmd := strings.ToUpper(line)
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"):
+						tp.PrintfLine("250-localhost")
+						tp.PrintfLine("250 AUTH PLAIN")
+					case strings.HasPrefix(cmd, "AUTH PLAIN "):
+						b, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+						m.auth = string(b)
+						tp.PrintfLine("235 ok")
+					case strings.HasPrefix(cmd, "MAIL FROM:"):
+						m.from = line[len("MAIL FROM:"):]
+						tp.PrintfLine("250 ok")
+					case strings.HasPrefix(cmd, "RCPT TO:"):
+						m.to = append(m.to, line[len("RCPT TO:"):])
+						tp.PrintfLine("250 ok")
+					case cmd == "DATA":
+						tp.PrintfLine("354 go ahead")
+						data, _ := tp.ReadDotBytes()
+						m.data = string(data)
+						tp.PrintfLine("250 ok")
+						mails <- m
+					case cmd == "QUIT":
+						tp.PrintfLine("221 bye")
+						return
+					default:
+						tp.PrintfLine("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, mails
+}
+
+func TestEmailSender_Send(t *testing.T) {
+	port, mails := fakeSmtp(t)
+
+	n := domain.Notification{
+		Type: domain.NotificationTypeEmail,
+		Settings: domain.NotificationSettings{
+			Host:       "127.0.0.1",
+			Port:       port,
+			Encryption: domain.SmtpEncryptionNone,
+			Username:   "user",
+			Password:   "pass",
+			From:       "autobrr <autobrr@example.com>",
+			To:         []string{"me@example.com", "Other <other@example.com>"},
+		},
+	}
+
+	err := NewEmailSender(n).Send(context.Background(), domain.NotificationPayload{Title: "Action failed", Message: "That.Movie.2022\nAction: qbit (QBITTORRENT)", Timestamp: time.Unix(1650000000, 0).UTC()})
+	require.NoError(t, err)
+
+	m := <-mails
+	assert.Equal(t, "\x00user\x00pass", m.auth)
+	assert.Equal(t, "<autobrr@example.com>", m.from)
+	assert.Equal(t, []string{"<me@example.com>", "<other@example.com>"}, m.to)
+	assert.Contains(t, m.data, "From: \"autobrr\" <autobrr@example.com>\n")
+	assert.Contains(t, m.data, "To: <me@example.com>, \"Other\" <other@example.com>\n")
+	assert.Contains(t, m.data, "Subject: autobrr: Action failed\n")
+	assert.Contains(t, m.data, "Date: Fri, 15 Apr 2022 05:20:00 +0000\n")
+	assert.True(t, strings.HasSuffix(m.data, "\nThat.Movie.2022\nAction: qbit (QBITTORRENT)\n"), m.data)
+
+	// the fake server has no starttls, the default refuses to send without it
+	n.Settings.Encryption = ""
+	err = NewEmailSender(n).Send(context.Background(), domain.NotificationPayload{Title: "Action failed"})
+	assert.EqualError(t, err, "email: server doesn't support STARTTLS")
+
+	// nothing listens on port 1
+	n.Settings.Port = 1
+	n.Settings.Encryption = domain.SmtpEncryptionNone
+	assert.Error(t, NewEmailSender(n).Send(context.Background(), domain.NotificationPayload{}))
+}
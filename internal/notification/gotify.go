@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type gotifySender struct {
+	notification domain.Notification
+}
+
+func NewGotifySender(notification domain.Notification) domain.NotificationSender {
+	return &gotifySender{notification: notification}
+}
+
+type gotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+func (s *gotifySender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	body, err := json.Marshal(gotifyMessage{
+		Title:    payload.Title,
+		Message:  payload.Message,
+		Priority: s.notification.Priority(payload.Event, gotifyPriority(payload.Level)),
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(s.notification.Settings.Host, "/") + "/message"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", s.notification.Settings.Token)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gotify: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("gotify: status %v: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// gotifyPriority the android app shows 8 and up as a popup, 4 to 7 with sound
+func gotifyPriority(level string) int {
+	switch level {
+	case domain.NotificationLevelError:
+		return 8
+	case domain.NotificationLevelWarning:
+		return 6
+	default:
+		return 5
+	}
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// matrixTxn makes the transaction ids unique, the homeserver drops a repeated id as a retry
+var matrixTxn uint64
+
+type matrixSender struct {
+	notification domain.Notification
+}
+
+func NewMatrixSender(notification domain.Notification) domain.NotificationSender {
+	return &matrixSender{notification: notification}
+}
+
+// matrixMessage sent as a notice, clients don't notify on notices from bots in loops
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+type matrixError struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+func (s *matrixSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	settings := s.notification.Settings
+
+	body, err := json.Marshal(matrixMessage{
+		MsgType:       "m.notice",
+		Body:          payload.Title + "\n" + payload.Message,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "<b>" + html.EscapeString(payload.Title) + "</b><br>" + strings.ReplaceAll(html.EscapeString(payload.Message), "\n", "<br>"),
+	})
+	if err != nil {
+		return err
+	}
+
+	txn := fmt.Sprintf("autobrr-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&matrixTxn, 1))
+	endpoint := fmt.Sprintf("%v/_matrix/client/v3/rooms/%v/send/m.room.message/%v", strings.TrimSuffix(settings.Host, "/"), url.PathEscape(settings.Room), txn)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+settings.Token)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("matrix: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+
+		var e matrixError
+		if err := json.Unmarshal(msg, &e); err == nil && e.ErrCode != "" {
+			return fmt.Errorf("matrix: status %v: %v %v", res.StatusCode, e.ErrCode, e.Error)
+		}
+
+		return fmt.Errorf("matrix: status %v: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
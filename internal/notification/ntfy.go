@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type ntfySender struct {
+	notification domain.Notification
+}
+
+func NewNtfySender(notification domain.Notification) domain.NotificationSender {
+	return &ntfySender{notification: notification}
+}
+
+// ntfyMessage published as json to the server root, headers can't hold the emoji and accents of release names
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+func (s *ntfySender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	settings := s.notification.Settings
+
+	tags, ok := settings.Tags[payload.Event]
+	if !ok {
+		tags = ntfyTags(payload.Level)
+	}
+
+	body, err := json.Marshal(ntfyMessage{
+		Topic:    settings.Topic,
+		Title:    payload.Title,
+		Message:  payload.Message,
+		Priority: s.notification.Priority(payload.Event, ntfyPriority(payload.Level)),
+		Tags:     tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	host := settings.Host
+	if host == "" {
+		host = domain.NtfyDefaultHost
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(host, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// only needed for servers and topics with access control
+	if settings.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+settings.Token)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("ntfy: status %v: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// ntfyPriority 3 is the default, 4 and 5 get a longer vibration and pop over other apps
+func ntfyPriority(level string) int {
+	switch level {
+	case domain.NotificationLevelError:
+		return 5
+	case domain.NotificationLevelWarning:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// ntfyTags emoji for the level when the agent has no tags for the event
+func ntfyTags(level string) []string {
+	switch level {
+	case domain.NotificationLevelError:
+		return []string{"rotating_light"}
+	case domain.NotificationLevelWarning:
+		return []string{"warning"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// samplePayload an example of the event with the built in text, for tests and template previews
+func samplePayload(n domain.Notification, event domain.NotificationEventType) domain.NotificationPayload {
+	p := domain.NotificationPayload{
+		Event:     event,
+		Timestamp: time.Now(),
+	}
+
+	switch event {
+	case domain.NotificationEventPushApproved, domain.NotificationEventPushRejected, domain.NotificationEventPushError:
+		p.ReleaseName = "That.Movie.2022.1080p.BluRay.x264-GROUP"
+		p.Indexer = "mock"
+		p.Filter = "movies"
+		p.FilterID = 1
+		p.Action = "qbittorrent"
+		p.ActionType = domain.ActionTypeQbittorrent
+		p.Status = domain.ReleasePushStatusApproved
+
+		switch event {
+		case domain.NotificationEventPushRejected:
+			p.Status = domain.ReleasePushStatusRejected
+			p.Rejections = []string{"max active downloads reached"}
+		case domain.NotificationEventPushError:
+			p.Status = domain.ReleasePushStatusErr
+			p.Rejections = []string{"could not connect to client"}
+		}
+
+		actionText(&p)
+
+	case domain.NotificationEventIrcDisconnected, domain.NotificationEventIrcReconnected:
+		p.Network = "IRCHighWay"
+		ircText(&p, "irc.example.com:6697")
+
+	case domain.NotificationEventSystem:
+		p.Level = domain.NotificationLevelInfo
+		p.Title = "Database maintenance done"
+		p.Message = "Compacted from 120 MiB to 96 MiB in 2.1s."
+
+	case domain.NotificationEventUpdateAvailable:
+		updateText(&p, domain.UpdateStatus{CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0", ReleaseURL: "https://github.com/autobrr/autobrr/releases/tag/v1.1.0"})
+
+	case domain.NotificationEventIndexerAuth:
+		p.Indexer = "mock"
+		indexerAuthText(&p, domain.IndexerCredentialsEvent{Indexer: "mock", Name: "Mock", Failures: 3, Reason: "status code: 403"})
+
+	default:
+		p.Level = domain.NotificationLevelInfo
+		p.Title = "autobrr test"
+		p.Message = fmt.Sprintf("Notifications from autobrr reach %v.", n.Name)
+	}
+
+	return p
+}
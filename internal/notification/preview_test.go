@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestService_Preview(t *testing.T) {
+	s := NewService(nil)
+
+	n := domain.Notification{
+		Name:     "gotify",
+		Type:     domain.NotificationTypeGotify,
+		Settings: domain.NotificationSettings{Host: "https://gotify.example.com", Token: "t"},
+	}
+
+	got, err := s.Preview(n, domain.NotificationEventPushRejected)
+	require.NoError(t, err)
+	assert.Equal(t, "Release rejected", got.Title)
+	assert.Equal(t, "That.Movie.2022.1080p.BluRay.x264-GROUP\nIndexer: mock\nFilter: movies\nAction: qbittorrent (QBITTORRENT)\nmax active downloads reached", got.Message)
+
+	n.Settings.Templates = map[domain.NotificationEventType]domain.NotificationTemplate{
+		domain.NotificationEventIrcDisconnected: {Title: "⚠️ {{ .Network }} down"},
+	}
+
+	got, err = s.Preview(n, domain.NotificationEventIrcDisconnected)
+	require.NoError(t, err)
+	assert.Equal(t, "⚠️ IRCHighWay down", got.Title)
+	assert.Equal(t, "Lost the connection to IRCHighWay (irc.example.com:6697)", got.Message)
+
+	_, err = s.Preview(n, "RELEASE_SEEN")
+	assert.Error(t, err)
+}
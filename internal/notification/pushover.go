@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+var pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// pushover limits, longer messages are rejected
+const (
+	pushoverMaxTitle   = 250
+	pushoverMaxMessage = 1024
+)
+
+type pushoverSender struct {
+	notification domain.Notification
+}
+
+func NewPushoverSender(notification domain.Notification) domain.NotificationSender {
+	return &pushoverSender{notification: notification}
+}
+
+type pushoverResponse struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+func (s *pushoverSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	form := url.Values{}
+	form.Set("token", s.notification.Settings.Token)
+	form.Set("user", s.notification.Settings.UserKey)
+	form.Set("title", truncate(payload.Title, pushoverMaxTitle))
+	form.Set("message", truncate(payload.Message, pushoverMaxMessage))
+	form.Set("priority", strconv.Itoa(s.notification.Priority(payload.Event, pushoverPriority(payload.Level))))
+	form.Set("timestamp", strconv.FormatInt(payload.Timestamp.Unix(), 10))
+
+	if s.notification.Settings.Device != "" {
+		form.Set("device", s.notification.Settings.Device)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushover: %w", err)
+	}
+	defer res.Body.Close()
+
+	var body pushoverResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil && res.StatusCode == http.StatusOK {
+		return fmt.Errorf("pushover: invalid response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || body.Status != 1 {
+		return fmt.Errorf("pushover: status %v: %v", res.StatusCode, strings.Join(body.Errors, ", "))
+	}
+
+	return nil
+}
+
+// pushoverPriority failures are high priority so they get through quiet hours
+func pushoverPriority(level string) int {
+	if level == domain.NotificationLevelError {
+		return 1
+	}
+
+	return 0
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+
+	return string(r[:max-1]) + "…"
+}
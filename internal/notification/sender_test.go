@@ -0,0 +1,243 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestPushoverSender_Send(t *testing.T) {
+	var form map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		form = map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+
+		if form["token"] != "app-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":0,"errors":["application token is invalid"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"status":1}`))
+	}))
+	defer ts.Close()
+
+	defer func(u string) { pushoverURL = u }(pushoverURL)
+	pushoverURL = ts.URL
+
+	n := domain.Notification{
+		Type: domain.NotificationTypePushover,
+		Settings: domain.NotificationSettings{
+			Token:      "app-token",
+			UserKey:    "user-key",
+			Priorities: map[domain.NotificationEventType]int{domain.NotificationEventPushApproved: -1},
+		},
+	}
+
+	now := time.Unix(1650000000, 0)
+
+	require.NoError(t, NewPushoverSender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventPushError, Level: domain.NotificationLevelError, Title: "Action failed", Message: "Release", Timestamp: now}))
+	assert.Equal(t, map[string]string{"token": "app-token", "user": "user-key", "title": "Action failed", "message": "Release", "priority": "1", "timestamp": "1650000000"}, form)
+
+	require.NoError(t, NewPushoverSender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventPushApproved, Level: domain.NotificationLevelInfo, Title: "New release!", Timestamp: now}))
+	assert.Equal(t, "-1", form["priority"])
+
+	n.Settings.Token = "wrong"
+	err := NewPushoverSender(n).Send(context.Background(), domain.NotificationPayload{Timestamp: now})
+	assert.EqualError(t, err, "pushover: status 400: application token is invalid")
+}
+
+func TestGotifySender_Send(t *testing.T) {
+	var msg gotifyMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gotify/message" || r.Header.Get("X-Gotify-Key") != "app-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized"}`))
+			return
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer ts.Close()
+
+	n := domain.Notification{
+		Type:     domain.NotificationTypeGotify,
+		Settings: domain.NotificationSettings{Host: ts.URL + "/gotify/", Token: "app-token"},
+	}
+
+	require.NoError(t, NewGotifySender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventIrcDisconnected, Level: domain.NotificationLevelWarning, Title: "IRC network disconnected", Message: "Network"}))
+	assert.Equal(t, gotifyMessage{Title: "IRC network disconnected", Message: "Network", Priority: 6}, msg)
+
+	n.Settings.Token = "wrong"
+	assert.Error(t, NewGotifySender(n).Send(context.Background(), domain.NotificationPayload{}))
+}
+
+func TestNtfySender_Send(t *testing.T) {
+	var msg ntfyMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tk_token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"code":40301,"http":403,"error":"forbidden"}`))
+			return
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer ts.Close()
+
+	n := domain.Notification{
+		Type: domain.NotificationTypeNtfy,
+		Settings: domain.NotificationSettings{
+			Host:  ts.URL,
+			Token: "tk_token",
+			Topic: "autobrr",
+			Tags:  map[domain.NotificationEventType][]string{domain.NotificationEventPushApproved: {"tada"}},
+		},
+	}
+
+	require.NoError(t, NewNtfySender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventPushApproved, Level: domain.NotificationLevelInfo, Title: "New release!", Message: "Release"}))
+	assert.Equal(t, ntfyMessage{Topic: "autobrr", Title: "New release!", Message: "Release", Priority: 3, Tags: []string{"tada"}}, msg)
+
+	require.NoError(t, NewNtfySender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventPushError, Level: domain.NotificationLevelError, Title: "Action failed"}))
+	assert.Equal(t, 5, msg.Priority)
+	assert.Equal(t, []string{"rotating_light"}, msg.Tags)
+
+	n.Settings.Token = ""
+	assert.Error(t, NewNtfySender(n).Send(context.Background(), domain.NotificationPayload{}))
+}
+
+func TestSlackSender_Send(t *testing.T) {
+	var msg slackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/T000/B000/XXXX" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("invalid_token"))
+			return
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	n := domain.Notification{
+		Type:     domain.NotificationTypeSlack,
+		Settings: domain.NotificationSettings{Webhook: ts.URL + "/services/T000/B000/XXXX"},
+	}
+
+	require.NoError(t, NewSlackSender(n).Send(context.Background(), domain.NotificationPayload{Event: domain.NotificationEventPushError, Level: domain.NotificationLevelError, Title: "Action failed", Message: "Release", Timestamp: time.Unix(1650000000, 0)}))
+	assert.Equal(t, slackMessage{Text: "Action failed", Attachments: []slackAttachment{{Color: "danger", Title: "Action failed", Text: "Release", Timestamp: 1650000000}}}, msg)
+
+	n.Settings.Webhook = ts.URL + "/services/T000/B000/YYYY"
+	err := NewSlackSender(n).Send(context.Background(), domain.NotificationPayload{})
+	assert.EqualError(t, err, "slack: status 403: invalid_token")
+}
+
+func TestMatrixSender_Send(t *testing.T) {
+	var msg matrixMessage
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer syt_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token passed."}`))
+			return
+		}
+
+		assert.Equal(t, http.MethodPut, r.Method)
+		paths = append(paths, r.URL.EscapedPath())
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		w.Write([]byte(`{"event_id":"$abc"}`))
+	}))
+	defer ts.Close()
+
+	n := domain.Notification{
+		Type:     domain.NotificationTypeMatrix,
+		Settings: domain.NotificationSettings{Host: ts.URL + "/", Token: "syt_token", Room: "!room:example.com"},
+	}
+
+	payload := domain.NotificationPayload{Event: domain.NotificationEventPushApproved, Level: domain.NotificationLevelInfo, Title: "New release!", Message: "That.Movie.2022 <1080p>\nIndexer: mock"}
+
+	require.NoError(t, NewMatrixSender(n).Send(context.Background(), payload))
+	require.NoError(t, NewMatrixSender(n).Send(context.Background(), payload))
+	assert.Equal(t, matrixMessage{
+		MsgType:       "m.notice",
+		Body:          "New release!\nThat.Movie.2022 <1080p>\nIndexer: mock",
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "<b>New release!</b><br>That.Movie.2022 &lt;1080p&gt;<br>Indexer: mock",
+	}, msg)
+
+	require.Len(t, paths, 2)
+	assert.Contains(t, paths[0], "/_matrix/client/v3/rooms/%21room:example.com/send/m.room.message/")
+	assert.NotEqual(t, paths[0], paths[1])
+
+	n.Settings.Token = "wrong"
+	err := NewMatrixSender(n).Send(context.Background(), payload)
+	assert.EqualError(t, err, "matrix: status 401: M_UNKNOWN_TOKEN Invalid access token passed.")
+}
+
+func TestWebhookSender_Send(t *testing.T) {
+	var body []byte
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	n := domain.Notification{
+		Type: domain.NotificationTypeWebhook,
+		Settings: domain.NotificationSettings{
+			Webhook:  ts.URL,
+			Template: `{"text": {{ json .Title }}, "release": {{ json .ReleaseName }}}`,
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+			Secret:   "secret",
+		},
+	}
+
+	payload := domain.NotificationPayload{Event: domain.NotificationEventPushApproved, Title: `New "release"!`, ReleaseName: "That.Movie.2022"}
+
+	require.NoError(t, NewWebhookSender(n).Send(context.Background(), payload))
+	assert.JSONEq(t, `{"text": "New \"release\"!", "release": "That.Movie.2022"}`, string(body))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, "PUSH_APPROVED", header.Get("X-Autobrr-Event"))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), header.Get("X-Autobrr-Signature"))
+
+	// without a template the payload goes out as is
+	n.Settings.Template = ""
+	n.Settings.Secret = ""
+	require.NoError(t, NewWebhookSender(n).Send(context.Background(), payload))
+
+	var got domain.NotificationPayload
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, payload.ReleaseName, got.ReleaseName)
+	assert.Empty(t, header.Get("X-Autobrr-Signature"))
+
+	n.Settings.Template = `{"text": "{{ .Title }}"}`
+	err := NewWebhookSender(n).Send(context.Background(), payload)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template did not produce valid json")
+}
@@ -0,0 +1,263 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// httpClient shared by the senders, a push service that doesn't answer shouldn't hold up the next message
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+type Service interface {
+	List(ctx context.Context) ([]domain.Notification, error)
+	FindByID(ctx context.Context, id int) (*domain.Notification, error)
+	Store(ctx context.Context, notification *domain.Notification) error
+	Update(ctx context.Context, notification *domain.Notification) error
+	Delete(ctx context.Context, id int) error
+	Test(ctx context.Context, notification domain.Notification) error
+	Preview(notification domain.Notification, event domain.NotificationEventType) (domain.NotificationPayload, error)
+	Subscribed(event domain.NotificationEventType) bool
+	Send(payload domain.NotificationPayload)
+	FlushDigests(ctx context.Context)
+}
+
+type agent struct {
+	notification domain.Notification
+	sender       domain.NotificationSender
+}
+
+type service struct {
+	repo domain.NotificationRepo
+
+	// enabled agents, loaded on the first event and again after every change
+	agents []agent
+	loaded bool
+	lock   sync.RWMutex
+
+	// digests by agent id, kept across reloads so editing an agent doesn't lose collected events
+	digests map[int]*digest
+}
+
+func NewService(repo domain.NotificationRepo) Service {
+	return &service{repo: repo, digests: map[int]*digest{}}
+}
+
+func (s *service) List(ctx context.Context) ([]domain.Notification, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) FindByID(ctx context.Context, id int) (*domain.Notification, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *service) Store(ctx context.Context, notification *domain.Notification) error {
+	if err := notification.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Store(ctx, notification); err != nil {
+		return err
+	}
+
+	return s.reload(ctx)
+}
+
+func (s *service) Update(ctx context.Context, notification *domain.Notification) error {
+	if err := notification.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, notification); err != nil {
+		return err
+	}
+
+	return s.reload(ctx)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return s.reload(ctx)
+}
+
+// Test send a test message with the settings, they don't have to be stored
+func (s *service) Test(ctx context.Context, notification domain.Notification) error {
+	if err := notification.Validate(); err != nil {
+		return err
+	}
+
+	sender, err := newSender(notification)
+	if err != nil {
+		return err
+	}
+
+	payload, err := notification.Render(samplePayload(notification, domain.NotificationEventTest))
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(ctx, payload)
+}
+
+// Preview the title and message the agent would send for an example of the event
+func (s *service) Preview(notification domain.Notification, event domain.NotificationEventType) (domain.NotificationPayload, error) {
+	if err := notification.Validate(); err != nil {
+		return domain.NotificationPayload{}, err
+	}
+
+	if !event.Valid() {
+		return domain.NotificationPayload{}, fmt.Errorf("unknown event %v", event)
+	}
+
+	return notification.Render(samplePayload(notification, event))
+}
+
+// Subscribed any enabled agent wants the event, to skip building payloads nobody gets
+func (s *service) Subscribed(event domain.NotificationEventType) bool {
+	for _, a := range s.enabledAgents() {
+		if a.notification.Subscribed(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Send the payload to every agent subscribed to its event and with the release in scope, in the background
+func (s *service) Send(payload domain.NotificationPayload) {
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now()
+	}
+
+	for _, a := range s.enabledAgents() {
+		if !a.notification.Matches(payload) {
+			continue
+		}
+
+		go func(a agent) {
+			// a broken template still gets the event out, with the built in text
+			rendered, err := a.notification.Render(payload)
+			if err != nil {
+				log.Warn().Err(err).Msgf("notification: template of %v failed for %v", a.notification.Name, payload.Event)
+			}
+
+			if err := a.sender.Send(context.Background(), rendered); err != nil {
+				log.Error().Err(err).Msgf("notification: could not send %v to %v", payload.Event, a.notification.Name)
+			}
+		}(a)
+	}
+}
+
+// FlushDigests send the digests that are due
+func (s *service) FlushDigests(ctx context.Context) {
+	s.lock.RLock()
+	digests := make([]*digest, 0, len(s.digests))
+	for _, d := range s.digests {
+		digests = append(digests, d)
+	}
+	s.lock.RUnlock()
+
+	now := time.Now()
+	for _, d := range digests {
+		if err := d.Flush(ctx, now); err != nil {
+			log.Error().Err(err).Msgf("notification: could not send digest to %v", d.name)
+		}
+	}
+}
+
+func (s *service) enabledAgents() []agent {
+	s.lock.RLock()
+	loaded, agents := s.loaded, s.agents
+	s.lock.RUnlock()
+
+	if loaded {
+		return agents
+	}
+
+	if err := s.reload(context.Background()); err != nil {
+		log.Error().Err(err).Msg("notification: could not load notification agents")
+		return nil
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.agents
+}
+
+func (s *service) reload(ctx context.Context) error {
+	notifications, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var agents []agent
+	for _, n := range notifications {
+		if !n.Enabled {
+			continue
+		}
+
+		sender, err := newSender(n)
+		if err != nil {
+			log.Error().Err(err).Msgf("notification: skipping %v", n.Name)
+			continue
+		}
+
+		agents = append(agents, agent{notification: n, sender: sender})
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	digests := map[int]*digest{}
+	for i, a := range agents {
+		if !a.notification.Digest() {
+			continue
+		}
+
+		d, ok := s.digests[a.notification.ID]
+		if !ok {
+			d = newDigest(time.Now())
+		}
+
+		d.update(a.notification, a.sender)
+		digests[a.notification.ID] = d
+		agents[i].sender = d
+	}
+
+	s.digests = digests
+	s.agents = agents
+	s.loaded = true
+
+	return nil
+}
+
+func newSender(n domain.Notification) (domain.NotificationSender, error) {
+	switch n.Type {
+	case domain.NotificationTypePushover:
+		return NewPushoverSender(n), nil
+	case domain.NotificationTypeGotify:
+		return NewGotifySender(n), nil
+	case domain.NotificationTypeNtfy:
+		return NewNtfySender(n), nil
+	case domain.NotificationTypeSlack:
+		return NewSlackSender(n), nil
+	case domain.NotificationTypeMatrix:
+		return NewMatrixSender(n), nil
+	case domain.NotificationTypeEmail:
+		return NewEmailSender(n), nil
+	case domain.NotificationTypeWebhook:
+		return NewWebhookSender(n), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification type %q", n.Type)
+	}
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type slackSender struct {
+	notification domain.Notification
+}
+
+func NewSlackSender(notification domain.Notification) domain.NotificationSender {
+	return &slackSender{notification: notification}
+}
+
+// slackMessage the text is what shows in the push notification, the attachment in the channel
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color     string `json:"color"`
+	Title     string `json:"title"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"ts,omitempty"`
+}
+
+func (s *slackSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	var ts int64
+	if !payload.Timestamp.IsZero() {
+		ts = payload.Timestamp.Unix()
+	}
+
+	body, err := json.Marshal(slackMessage{
+		Text: payload.Title,
+		Attachments: []slackAttachment{{
+			Color:     slackColor(payload.Level),
+			Title:     payload.Title,
+			Text:      payload.Message,
+			Timestamp: ts,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.notification.Settings.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	defer res.Body.Close()
+
+	// errors come back as plain text like invalid_token or channel_not_found
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("slack: status %v: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+func slackColor(level string) string {
+	switch level {
+	case domain.NotificationLevelError:
+		return "danger"
+	case domain.NotificationLevelWarning:
+		return "warning"
+	default:
+		return "good"
+	}
+}
@@ -0,0 +1,235 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type releaseFinder interface {
+	FindByID(ctx context.Context, id int64) (*domain.Release, error)
+}
+
+// Subscriber turns action results, irc connection changes, indexer credential problems and messages from autobrr into notifications
+type Subscriber struct {
+	eventbus EventBus.Bus
+	service  Service
+	releases releaseFinder
+
+	// networks that lost their connection, only those send a reconnected event
+	disconnected map[int64]bool
+	lock         sync.Mutex
+}
+
+func NewSubscriber(eventbus EventBus.Bus, service Service, releases releaseFinder) *Subscriber {
+	s := &Subscriber{
+		eventbus:     eventbus,
+		service:      service,
+		releases:     releases,
+		disconnected: map[int64]bool{},
+	}
+
+	s.Register()
+
+	return s
+}
+
+func (s *Subscriber) Register() {
+	s.eventbus.Subscribe("release:push-approved", s.actionApproved)
+	s.eventbus.Subscribe("release:push-rejected", s.actionRejected)
+	s.eventbus.Subscribe("release:store-action-status", s.actionStatus)
+	s.eventbus.Subscribe(domain.EventIrcConnection, s.ircConnection)
+	s.eventbus.Subscribe(domain.EventNotification, s.notification)
+	s.eventbus.Subscribe(domain.EventUpdate, s.update)
+	s.eventbus.Subscribe(domain.EventIndexerCredentials, s.indexerCredentials)
+}
+
+func (s *Subscriber) actionApproved(status *domain.ReleaseActionStatus) {
+	s.actionResult(domain.NotificationEventPushApproved, *status)
+}
+
+func (s *Subscriber) actionRejected(status *domain.ReleaseActionStatus) {
+	s.actionResult(domain.NotificationEventPushRejected, *status)
+}
+
+// actionStatus only errors, pending is not an outcome
+func (s *Subscriber) actionStatus(status *domain.ReleaseActionStatus) {
+	if status.Status == domain.ReleasePushStatusErr {
+		s.actionResult(domain.NotificationEventPushError, *status)
+	}
+}
+
+func (s *Subscriber) actionResult(event domain.NotificationEventType, status domain.ReleaseActionStatus) {
+	if !s.service.Subscribed(event) {
+		return
+	}
+
+	// the release is looked up, don't hold up the action chain for it
+	go func() {
+		payload := domain.NotificationPayload{
+			Event:      event,
+			Timestamp:  status.Timestamp,
+			Action:     status.Action,
+			ActionType: status.Type,
+			Status:     status.Status,
+			Rejections: status.Rejections,
+			FilterID:   status.FilterID,
+		}
+
+		if status.ReleaseID != 0 {
+			release, err := s.releases.FindByID(context.Background(), status.ReleaseID)
+			if err != nil {
+				log.Error().Err(err).Msgf("notification: could not find release %v", status.ReleaseID)
+			} else {
+				payload.ReleaseName = release.TorrentName
+				payload.Indexer = release.Indexer
+				payload.Filter = release.FilterName
+
+				if payload.FilterID == 0 {
+					payload.FilterID = release.FilterID
+				}
+			}
+		}
+
+		actionText(&payload)
+
+		s.service.Send(payload)
+	}()
+}
+
+// actionText the built in title, level and message of an action result
+func actionText(p *domain.NotificationPayload) {
+	p.Level = domain.NotificationLevelInfo
+
+	switch p.Event {
+	case domain.NotificationEventPushApproved:
+		p.Title = "New release!"
+	case domain.NotificationEventPushRejected:
+		p.Title = "Release rejected"
+	case domain.NotificationEventPushError:
+		p.Title = "Action failed"
+		p.Level = domain.NotificationLevelError
+	}
+
+	p.Message = actionMessage(*p)
+}
+
+func actionMessage(p domain.NotificationPayload) string {
+	var b strings.Builder
+
+	if p.ReleaseName != "" {
+		b.WriteString(p.ReleaseName + "\n")
+	}
+	if p.Indexer != "" {
+		fmt.Fprintf(&b, "Indexer: %v\n", p.Indexer)
+	}
+	if p.Filter != "" {
+		fmt.Fprintf(&b, "Filter: %v\n", p.Filter)
+	}
+
+	fmt.Fprintf(&b, "Action: %v (%v)", p.Action, p.ActionType)
+
+	if len(p.Rejections) > 0 {
+		fmt.Fprintf(&b, "\n%v", strings.Join(p.Rejections, ", "))
+	}
+
+	return b.String()
+}
+
+func (s *Subscriber) ircConnection(event *domain.IrcConnectionEvent) {
+	s.lock.Lock()
+	wasDisconnected := s.disconnected[event.NetworkID]
+	s.disconnected[event.NetworkID] = !event.Connected
+	s.lock.Unlock()
+
+	payload := domain.NotificationPayload{
+		Network:   event.Network,
+		Timestamp: time.Now(),
+	}
+
+	switch {
+	case !event.Connected && !wasDisconnected:
+		payload.Event = domain.NotificationEventIrcDisconnected
+	case event.Connected && wasDisconnected:
+		payload.Event = domain.NotificationEventIrcReconnected
+	default:
+		// the first connect after start, or the same state again
+		return
+	}
+
+	ircText(&payload, event.Server)
+
+	s.service.Send(payload)
+}
+
+// ircText the built in title, level and message of a connection change
+func ircText(p *domain.NotificationPayload, server string) {
+	if p.Event == domain.NotificationEventIrcDisconnected {
+		p.Level = domain.NotificationLevelWarning
+		p.Title = "IRC network disconnected"
+		p.Message = fmt.Sprintf("Lost the connection to %v (%v)", p.Network, server)
+		return
+	}
+
+	p.Level = domain.NotificationLevelInfo
+	p.Title = "IRC network reconnected"
+	p.Message = fmt.Sprintf("Connected to %v (%v) again", p.Network, server)
+}
+
+func (s *Subscriber) notification(event *domain.NotificationEvent) {
+	s.service.Send(domain.NotificationPayload{
+		Event:     domain.NotificationEventSystem,
+		Level:     event.Level,
+		Title:     event.Title,
+		Message:   event.Message,
+		Timestamp: time.Now(),
+	})
+}
+
+func (s *Subscriber) update(status *domain.UpdateStatus) {
+	payload := domain.NotificationPayload{
+		Event:     domain.NotificationEventUpdateAvailable,
+		Timestamp: time.Now(),
+	}
+
+	updateText(&payload, *status)
+
+	s.service.Send(payload)
+}
+
+// updateText the built in title, level and message of a new release
+func updateText(p *domain.NotificationPayload, status domain.UpdateStatus) {
+	p.Level = domain.NotificationLevelInfo
+	p.Title = fmt.Sprintf("autobrr %v is available", status.LatestVersion)
+	p.Message = fmt.Sprintf("Running %v, %v is out: %v", status.CurrentVersion, status.LatestVersion, status.ReleaseURL)
+}
+
+func (s *Subscriber) indexerCredentials(event *domain.IndexerCredentialsEvent) {
+	payload := domain.NotificationPayload{
+		Event:     domain.NotificationEventIndexerAuth,
+		Indexer:   event.Indexer,
+		Timestamp: time.Now(),
+	}
+
+	indexerAuthText(&payload, *event)
+
+	s.service.Send(payload)
+}
+
+// indexerAuthText the built in title, level and message of an indexer with invalid credentials
+func indexerAuthText(p *domain.NotificationPayload, event domain.IndexerCredentialsEvent) {
+	p.Level = domain.NotificationLevelError
+	p.Title = fmt.Sprintf("%v credentials invalid", event.Name)
+	p.Message = fmt.Sprintf("%d torrent downloads in a row failed (%v), check the passkey or cookie.", event.Failures, event.Reason)
+
+	if event.PauseFilters {
+		p.Message += " Its filters are paused until it works again, filters that use other indexers too keep running."
+	}
+}
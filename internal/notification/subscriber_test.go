@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeService struct {
+	Service
+	sent []domain.NotificationPayload
+}
+
+func (s *fakeService) Subscribed(event domain.NotificationEventType) bool { return true }
+
+func (s *fakeService) Send(payload domain.NotificationPayload) {
+	s.sent = append(s.sent, payload)
+}
+
+type fakeReleases struct{}
+
+func (fakeReleases) FindByID(ctx context.Context, id int64) (*domain.Release, error) {
+	return &domain.Release{ID: id, TorrentName: "Release.Name", Indexer: "indexer", FilterName: "filter"}, nil
+}
+
+func TestSubscriber_ircConnection(t *testing.T) {
+	svc := &fakeService{}
+	s := NewSubscriber(EventBus.New(), svc, fakeReleases{})
+
+	s.ircConnection(&domain.IrcConnectionEvent{NetworkID: 1, Network: "Network", Connected: true})
+	assert.Empty(t, svc.sent, "first connect after start")
+
+	s.ircConnection(&domain.IrcConnectionEvent{NetworkID: 1, Network: "Network", Connected: false})
+	s.ircConnection(&domain.IrcConnectionEvent{NetworkID: 1, Network: "Network", Connected: false})
+	s.ircConnection(&domain.IrcConnectionEvent{NetworkID: 1, Network: "Network", Connected: true})
+
+	if assert.Len(t, svc.sent, 2) {
+		assert.Equal(t, domain.NotificationEventIrcDisconnected, svc.sent[0].Event)
+		assert.Equal(t, domain.NotificationEventIrcReconnected, svc.sent[1].Event)
+		assert.Equal(t, "Network", svc.sent[1].Network)
+	}
+}
+
+func TestSubscriber_notification(t *testing.T) {
+	bus := EventBus.New()
+	svc := &fakeService{}
+	NewSubscriber(bus, svc, fakeReleases{})
+
+	bus.Publish(domain.EventNotification, &domain.NotificationEvent{Level: "error", Title: "Database maintenance failed", Message: "disk full"})
+
+	if assert.Len(t, svc.sent, 1) {
+		assert.Equal(t, domain.NotificationEventSystem, svc.sent[0].Event)
+		assert.Equal(t, domain.NotificationLevelError, svc.sent[0].Level)
+	}
+}
+
+func TestSubscriber_indexerCredentials(t *testing.T) {
+	bus := EventBus.New()
+	svc := &fakeService{}
+	NewSubscriber(bus, svc, fakeReleases{})
+
+	bus.Publish(domain.EventIndexerCredentials, &domain.IndexerCredentialsEvent{Indexer: "mock", Name: "Mock", Failures: 3, Reason: "status code: 403", PauseFilters: true})
+
+	if assert.Len(t, svc.sent, 1) {
+		assert.Equal(t, domain.NotificationEventIndexerAuth, svc.sent[0].Event)
+		assert.Equal(t, domain.NotificationLevelError, svc.sent[0].Level)
+		assert.Equal(t, "mock", svc.sent[0].Indexer)
+		assert.Equal(t, "Mock credentials invalid", svc.sent[0].Title)
+		assert.Contains(t, svc.sent[0].Message, "filters are paused")
+	}
+}
+
+func Test_actionMessage(t *testing.T) {
+	msg := actionMessage(domain.NotificationPayload{ReleaseName: "Release.Name", Indexer: "indexer", Filter: "filter", Action: "qbit", ActionType: domain.ActionTypeQbittorrent, Rejections: []string{"max downloads reached"}})
+	assert.Equal(t, "Release.Name\nIndexer: indexer\nFilter: filter\nAction: qbit (QBITTORRENT)\nmax downloads reached", msg)
+}
@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// webhook headers, receivers check the signature against the raw body with their copy of the secret
+const (
+	webhookEventHeader     = "X-Autobrr-Event"
+	webhookSignatureHeader = "X-Autobrr-Signature"
+)
+
+type webhookSender struct {
+	notification domain.Notification
+}
+
+func NewWebhookSender(notification domain.Notification) domain.NotificationSender {
+	return &webhookSender{notification: notification}
+}
+
+func (s *webhookSender) Send(ctx context.Context, payload domain.NotificationPayload) error {
+	settings := s.notification.Settings
+
+	body, err := webhookBody(settings, payload)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "autobrr")
+
+	for name, value := range settings.Headers {
+		req.Header.Set(name, value)
+	}
+
+	req.Header.Set(webhookEventHeader, string(payload.Event))
+
+	if settings.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(settings.Secret, body))
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("webhook: status %v: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// webhookBody the payload through the template of the agent, or as is without one
+func webhookBody(settings domain.NotificationSettings, payload domain.NotificationPayload) ([]byte, error) {
+	tmpl, err := settings.WebhookTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl == nil {
+		return json.Marshal(payload)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, payload); err != nil {
+		return nil, err
+	}
+
+	// a stray quote in the template would otherwise only show up on the receiving end
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("template did not produce valid json, quote values with json: %v", strconv.Quote(truncate(b.String(), 200)))
+	}
+
+	return b.Bytes(), nil
+}
+
+// webhookSignature hex hmac-sha256 of the body, prefixed like github does
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
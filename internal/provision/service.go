@@ -0,0 +1,219 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type filterService interface {
+	Export(ctx context.Context, filterIDs []int) (*domain.FilterExport, error)
+	Import(ctx context.Context, data domain.FilterExport, conflict domain.FilterImportConflict) ([]domain.FilterImportResult, error)
+}
+
+type Service interface {
+	Export(ctx context.Context, secrets bool) (*domain.ProvisionExport, error)
+	Import(ctx context.Context, data domain.ProvisionExport, conflict domain.FilterImportConflict) (*domain.ProvisionImportResult, error)
+}
+
+type service struct {
+	indexerRepo domain.IndexerRepo
+	ircRepo     domain.IrcRepo
+	filterSvc   filterService
+}
+
+// NewService works on the repos directly, it's used by autobrrctl while autobrr isn't running
+func NewService(indexerRepo domain.IndexerRepo, ircRepo domain.IrcRepo, filterSvc filterService) Service {
+	return &service{
+		indexerRepo: indexerRepo,
+		ircRepo:     ircRepo,
+		filterSvc:   filterSvc,
+	}
+}
+
+// Export every indexer, irc network and filter. Without secrets api keys, passkeys and passwords are blanked.
+func (s *service) Export(ctx context.Context, secrets bool) (*domain.ProvisionExport, error) {
+	export := &domain.ProvisionExport{
+		Version:  domain.ProvisionExportVersion,
+		Indexers: []domain.Indexer{},
+		Networks: []domain.IrcNetwork{},
+		Filters:  []domain.Filter{},
+	}
+
+	indexers, err := s.indexerRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, indexer := range indexers {
+		export.Indexers = append(export.Indexers, indexer.Portable(secrets))
+	}
+
+	networks, err := s.listNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, network := range networks {
+		export.Networks = append(export.Networks, network.Portable(secrets))
+	}
+
+	filters, err := s.filterSvc.Export(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Filters = filters.Filters
+
+	return export, nil
+}
+
+// Import indexers by identifier and networks by server and nickserv account, existing ones are updated
+// and keep the secrets the file leaves blank. Filters come last so they find the imported indexers.
+func (s *service) Import(ctx context.Context, data domain.ProvisionExport, conflict domain.FilterImportConflict) (*domain.ProvisionImportResult, error) {
+	if data.Version > domain.ProvisionExportVersion {
+		return nil, fmt.Errorf("export version %v is newer than supported version %v", data.Version, domain.ProvisionExportVersion)
+	}
+
+	result := &domain.ProvisionImportResult{
+		Indexers: []domain.FilterImportResult{},
+		Networks: []domain.FilterImportResult{},
+		Filters:  []domain.FilterImportResult{},
+	}
+
+	indexers, err := s.indexerRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byIdentifier := make(map[string]domain.Indexer, len(indexers))
+	for _, indexer := range indexers {
+		byIdentifier[indexer.Identifier] = indexer
+	}
+
+	for _, indexer := range data.Indexers {
+		r := s.importIndexer(indexer.Portable(true), byIdentifier)
+		result.Indexers = append(result.Indexers, r)
+	}
+
+	for _, network := range data.Networks {
+		r := s.importNetwork(ctx, network.Portable(true))
+		result.Networks = append(result.Networks, r)
+	}
+
+	if len(data.Filters) > 0 {
+		filters, err := s.filterSvc.Import(ctx, domain.FilterExport{Version: domain.FilterExportVersion, Filters: data.Filters}, conflict)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Filters = filters
+	}
+
+	return result, nil
+}
+
+func (s *service) importIndexer(indexer domain.Indexer, byIdentifier map[string]domain.Indexer) domain.FilterImportResult {
+	result := domain.FilterImportResult{Name: indexer.Name}
+
+	var err error
+	switch existing, ok := byIdentifier[indexer.Identifier]; {
+	case indexer.Name == "" || indexer.Identifier == "" || indexer.Implementation == "":
+		err = fmt.Errorf("validation: name, identifier and implementation are required")
+
+	case ok:
+		indexer = indexer.RestoreSecrets(existing)
+		indexer.ID = existing.ID
+
+		_, err = s.indexerRepo.Update(indexer)
+		result.Status = domain.ProvisionImportUpdated
+		result.ID = int(existing.ID)
+
+	default:
+		// an indexer without its keys can't download anything yet
+		if indexer.MissingSecrets() {
+			indexer.Enabled = false
+		}
+
+		var created *domain.Indexer
+		if created, err = s.indexerRepo.Store(indexer); err == nil {
+			result.Status = domain.FilterImportCreated
+			result.ID = int(created.ID)
+			byIdentifier[created.Identifier] = *created
+		}
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msgf("provision.import: could not import indexer: %v", indexer.Name)
+		result.Status = domain.FilterImportFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func (s *service) importNetwork(ctx context.Context, network domain.IrcNetwork) domain.FilterImportResult {
+	result := domain.FilterImportResult{Name: network.Name}
+
+	if err := s.storeNetwork(ctx, &network, &result); err != nil {
+		log.Error().Err(err).Msgf("provision.import: could not import network: %v", network.Name)
+		result.Status = domain.FilterImportFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func (s *service) storeNetwork(ctx context.Context, network *domain.IrcNetwork, result *domain.FilterImportResult) error {
+	if network.Name == "" || network.Server == "" || network.Port == 0 {
+		return fmt.Errorf("validation: name, server and port are required")
+	}
+
+	existing, err := s.ircRepo.CheckExistingNetwork(ctx, network)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if err := s.ircRepo.StoreNetwork(network); err != nil {
+			return err
+		}
+
+		result.Status = domain.FilterImportCreated
+	} else {
+		if existing.Channels, err = s.ircRepo.ListChannels(existing.ID); err != nil {
+			return err
+		}
+
+		*network = network.RestoreSecrets(*existing)
+		network.ID = existing.ID
+
+		if err := s.ircRepo.UpdateNetwork(ctx, network); err != nil {
+			return err
+		}
+
+		result.Status = domain.ProvisionImportUpdated
+	}
+
+	result.ID = int(network.ID)
+
+	return s.ircRepo.StoreNetworkChannels(ctx, network.ID, network.Channels)
+}
+
+func (s *service) listNetworks(ctx context.Context) ([]domain.IrcNetwork, error) {
+	networks, err := s.ircRepo.ListNetworks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, network := range networks {
+		if networks[i].Channels, err = s.ircRepo.ListChannels(network.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,130 @@
+package provision
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/database"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeFilters struct {
+	imported []domain.Filter
+}
+
+func (f *fakeFilters) Export(ctx context.Context, filterIDs []int) (*domain.FilterExport, error) {
+	return &domain.FilterExport{Version: domain.FilterExportVersion, Filters: []domain.Filter{{Name: "Movies"}}}, nil
+}
+
+func (f *fakeFilters) Import(ctx context.Context, data domain.FilterExport, conflict domain.FilterImportConflict) ([]domain.FilterImportResult, error) {
+	f.imported = append(f.imported, data.Filters...)
+
+	results := []domain.FilterImportResult{}
+	for _, filter := range data.Filters {
+		results = append(results, domain.FilterImportResult{Name: filter.Name, Status: domain.FilterImportCreated})
+	}
+
+	return results, nil
+}
+
+func openDB(t *testing.T) *database.DB {
+	db := database.NewSqliteDB(t.TempDir())
+	require.NoError(t, db.Open())
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_service_ExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	source := openDB(t)
+	sourceIndexers := database.NewIndexerRepo(source)
+	sourceIrc := database.NewIrcRepo(source)
+
+	_, err := sourceIndexers.Store(domain.Indexer{Name: "Mock", Identifier: "mock", Implementation: "irc", Enabled: true, Settings: map[string]string{"passkey": "source-passkey"}})
+	require.NoError(t, err)
+	_, err = sourceIndexers.Store(domain.Indexer{Name: "Other", Identifier: "other", Implementation: "irc", Enabled: true, Settings: map[string]string{"rsskey": "other-key"}})
+	require.NoError(t, err)
+
+	network := &domain.IrcNetwork{Name: "Mock", Enabled: true, Server: "irc.mock.test", Port: 6697, TLS: true, NickServ: domain.NickServ{Account: "bob", Password: "nickserv"}}
+	require.NoError(t, sourceIrc.StoreNetwork(network))
+	require.NoError(t, sourceIrc.StoreNetworkChannels(ctx, network.ID, []domain.IrcChannel{{Name: "#announce", Enabled: true, Password: "key"}}))
+
+	export, err := NewService(sourceIndexers, sourceIrc, &fakeFilters{}).Export(ctx, false)
+	require.NoError(t, err)
+
+	require.Len(t, export.Indexers, 2)
+	assert.Equal(t, "", export.Indexers[0].Settings["passkey"])
+	require.Len(t, export.Networks, 1)
+	assert.Equal(t, "", export.Networks[0].NickServ.Password)
+	require.Len(t, export.Networks[0].Channels, 1)
+	assert.Equal(t, "", export.Networks[0].Channels[0].Password)
+	assert.Len(t, export.Filters, 1)
+
+	withSecrets, err := NewService(sourceIndexers, sourceIrc, &fakeFilters{}).Export(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, "source-passkey", withSecrets.Indexers[0].Settings["passkey"])
+	assert.Equal(t, "key", withSecrets.Networks[0].Channels[0].Password)
+
+	// the target already has the mock indexer with its own passkey
+	target := openDB(t)
+	targetIndexers := database.NewIndexerRepo(target)
+	targetIrc := database.NewIrcRepo(target)
+
+	_, err = targetIndexers.Store(domain.Indexer{Name: "Mock", Identifier: "mock", Implementation: "irc", Settings: map[string]string{"passkey": "target-passkey"}})
+	require.NoError(t, err)
+
+	filters := &fakeFilters{}
+	result, err := NewService(targetIndexers, targetIrc, filters).Import(ctx, *export, domain.FilterImportRename)
+	require.NoError(t, err)
+
+	require.Len(t, result.Indexers, 2)
+	assert.Equal(t, domain.ProvisionImportUpdated, result.Indexers[0].Status)
+	assert.Equal(t, domain.FilterImportCreated, result.Indexers[1].Status)
+	require.Len(t, result.Networks, 1)
+	assert.Equal(t, domain.FilterImportCreated, result.Networks[0].Status)
+	assert.Len(t, filters.imported, 1)
+
+	indexers, err := targetIndexers.List()
+	require.NoError(t, err)
+	require.Len(t, indexers, 2)
+	assert.Equal(t, "target-passkey", indexers[0].Settings["passkey"])
+	assert.True(t, indexers[0].Enabled)
+	assert.False(t, indexers[1].Enabled, "new indexer without its keys")
+
+	networks, err := targetIrc.ListNetworks(ctx)
+	require.NoError(t, err)
+	require.Len(t, networks, 1)
+	channels, err := targetIrc.ListChannels(networks[0].ID)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "#announce", channels[0].Name)
+
+	// importing again updates the network and keeps the passwords set in between
+	networks[0].NickServ.Password = "target-nickserv"
+	require.NoError(t, targetIrc.UpdateNetwork(ctx, &networks[0]))
+	require.NoError(t, targetIrc.StoreNetworkChannels(ctx, networks[0].ID, []domain.IrcChannel{{Name: "#announce", Enabled: true, Password: "target-key"}}))
+
+	result, err = NewService(targetIndexers, targetIrc, filters).Import(ctx, *export, domain.FilterImportRename)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProvisionImportUpdated, result.Networks[0].Status)
+
+	existing, err := targetIrc.CheckExistingNetwork(ctx, &networks[0])
+	require.NoError(t, err)
+	assert.Equal(t, "target-nickserv", existing.NickServ.Password)
+
+	channels, err = targetIrc.ListChannels(networks[0].ID)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "target-key", channels[0].Password)
+}
+
+func Test_service_Import_newerVersion(t *testing.T) {
+	db := openDB(t)
+
+	_, err := NewService(database.NewIndexerRepo(db), database.NewIrcRepo(db), &fakeFilters{}).Import(context.Background(), domain.ProvisionExport{Version: domain.ProvisionExportVersion + 1}, domain.FilterImportRename)
+	require.Error(t, err)
+}
@@ -2,32 +2,50 @@ package release
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/asaskevich/EventBus"
 	"github.com/rs/zerolog/log"
 
 	"github.com/autobrr/autobrr/internal/action"
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
 )
 
 type Service interface {
 	Find(ctx context.Context, query domain.ReleaseQueryParams) (res []domain.Release, nextCursor int64, count int64, err error)
 	GetIndexerOptions(ctx context.Context) ([]string, error)
-	Stats(ctx context.Context) (*domain.ReleaseStats, error)
+	Stats(ctx context.Context, params domain.ReleaseStatsParams) (*domain.ReleaseStats, error)
+	QuotaStats(ctx context.Context) (*domain.QuotaStats, error)
 	Store(ctx context.Context, release *domain.Release) error
 	StoreReleaseActionStatus(ctx context.Context, actionStatus *domain.ReleaseActionStatus) error
 	Process(release domain.Release) error
+	Retry(ctx context.Context, id int64, filterID int) error
+	ListPending(ctx context.Context) ([]domain.PendingAction, error)
+	RunPending(ctx context.Context, id int64) error
+	CancelPending(ctx context.Context, id int64) error
 	Delete(ctx context.Context) error
+	Prune(ctx context.Context, retention domain.ReleaseRetention) (*domain.ReleasePruneResult, error)
+	Retention() domain.ReleaseRetention
 }
 
 type service struct {
 	repo      domain.ReleaseRepo
 	actionSvc action.Service
+	filterSvc filter.Service
+	bus       EventBus.Bus
+	retention domain.ReleaseRetention
 }
 
-func NewService(repo domain.ReleaseRepo, actionService action.Service) Service {
+func NewService(repo domain.ReleaseRepo, actionService action.Service, filterService filter.Service, bus EventBus.Bus, retention domain.ReleaseRetention) Service {
 	return &service{
 		repo:      repo,
 		actionSvc: actionService,
+		filterSvc: filterService,
+		bus:       bus,
+		retention: retention,
 	}
 }
 
@@ -44,8 +62,12 @@ func (s *service) GetIndexerOptions(ctx context.Context) ([]string, error) {
 	return s.repo.GetIndexerOptions(ctx)
 }
 
-func (s *service) Stats(ctx context.Context) (*domain.ReleaseStats, error) {
-	return s.repo.Stats(ctx)
+func (s *service) Stats(ctx context.Context, params domain.ReleaseStatsParams) (*domain.ReleaseStats, error) {
+	return s.repo.Stats(ctx, params)
+}
+
+func (s *service) QuotaStats(ctx context.Context) (*domain.QuotaStats, error) {
+	return s.filterSvc.QuotaStats(ctx)
 }
 
 func (s *service) Store(ctx context.Context, release *domain.Release) error {
@@ -68,6 +90,8 @@ func (s *service) Process(release domain.Release) error {
 		return fmt.Errorf("no actions for filter: %v", release.Filter.Name)
 	}
 
+	s.bus.Publish(domain.EventReleaseMatched, &release)
+
 	// smart episode?
 
 	// run actions (watchFolder, test, exec, qBittorrent, Deluge etc.)
@@ -80,6 +104,113 @@ func (s *service) Process(release domain.Release) error {
 	return nil
 }
 
+// ListPending releases held in the action queue by a delay, schedule window, client rules or a retry
+func (s *service) ListPending(ctx context.Context) ([]domain.PendingAction, error) {
+	return s.actionSvc.ListPending(ctx)
+}
+
+func (s *service) RunPending(ctx context.Context, id int64) error {
+	return s.actionSvc.RunPending(ctx, id)
+}
+
+func (s *service) CancelPending(ctx context.Context, id int64) error {
+	return s.actionSvc.CancelPending(ctx, id)
+}
+
+// Retry queue the actions again for a stored release. Rejected releases without a filter need a filterID to pick the actions.
+func (s *service) Retry(ctx context.Context, id int64, filterID int) error {
+	release, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("could not find release: %v: %w", id, err)
+	}
+
+	if filterID == 0 {
+		filterID = release.FilterID
+	}
+
+	if filterID == 0 {
+		return fmt.Errorf("release %v: %w", id, domain.ErrReleaseNoFilter)
+	}
+
+	f, err := s.filterSvc.FindByID(ctx, filterID)
+	if err != nil {
+		return fmt.Errorf("could not find filter: %v: %w", filterID, err)
+	}
+
+	if release.TorrentURL == "" && !release.HasMagnet() {
+		return fmt.Errorf("release %v: %w", id, domain.ErrReleaseNoDownloadURL)
+	}
+
+	// check everything before the release is marked approved for the filter
+	if len(f.Actions) == 0 {
+		return fmt.Errorf("%v: %w", f.Name, domain.ErrFilterNoActions)
+	}
+
+	// the cached torrent file is not kept between runs so let the actions fetch it again, and time that download
+	release.TorrentTmpFile = ""
+	release.Timings.Fetch = 0
+
+	release.Filter = f
+	release.FilterName = f.Name
+
+	if release.FilterID != f.ID || release.FilterStatus != domain.ReleaseStatusFilterApproved {
+		release.FilterID = f.ID
+		release.FilterStatus = domain.ReleaseStatusFilterApproved
+		release.Rejections = []string{}
+
+		if err := s.repo.UpdateFilter(ctx, release); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Msgf("Retry '%v' (%v) for %v", release.TorrentName, f.Name, release.Indexer)
+
+	// hand the chains to the action queue, they run on its workers and survive a restart
+	return s.actionSvc.RunActions(f.Actions, *release)
+}
+
 func (s *service) Delete(ctx context.Context) error {
 	return s.repo.Delete(ctx)
 }
+
+// Retention the configured release history retention
+func (s *service) Retention() domain.ReleaseRetention {
+	return s.retention
+}
+
+// Prune remove the release history the retention doesn't keep
+func (s *service) Prune(ctx context.Context, retention domain.ReleaseRetention) (*domain.ReleasePruneResult, error) {
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !retention.Enabled() {
+		return nil, errors.New("no retention set, set a max age or max rows")
+	}
+
+	res, err := s.repo.Prune(ctx, retention.OlderThan(time.Now()), retention.MaxRows)
+	if err != nil {
+		return nil, fmt.Errorf("could not prune releases: %w", err)
+	}
+
+	if res.Releases > 0 {
+		log.Info().Msgf("release: pruned %v releases and %v action results", res.Releases, res.ActionStatus)
+	}
+
+	return res, nil
+}
+
+// PruneJob prune the release history on a schedule with the configured retention
+type PruneJob struct {
+	service Service
+}
+
+func NewPruneJob(service Service) *PruneJob {
+	return &PruneJob{service: service}
+}
+
+func (j *PruneJob) Run() {
+	if _, err := j.service.Prune(context.Background(), j.service.Retention()); err != nil {
+		log.Error().Err(err).Msg("release: scheduled prune failed")
+	}
+}
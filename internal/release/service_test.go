@@ -0,0 +1,118 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/action"
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/internal/filter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReleaseRepo struct {
+	domain.ReleaseRepo
+	releases map[int64]domain.Release
+	updated  []domain.Release
+}
+
+func (m *mockReleaseRepo) FindByID(ctx context.Context, id int64) (*domain.Release, error) {
+	rls, ok := m.releases[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &rls, nil
+}
+
+func (m *mockReleaseRepo) UpdateFilter(ctx context.Context, release *domain.Release) error {
+	m.updated = append(m.updated, *release)
+	return nil
+}
+
+type mockFilterService struct {
+	filter.Service
+	filters map[int]domain.Filter
+}
+
+func (m mockFilterService) FindByID(ctx context.Context, filterID int) (*domain.Filter, error) {
+	f, ok := m.filters[filterID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &f, nil
+}
+
+type mockActionService struct {
+	action.Service
+	runs []domain.Release
+}
+
+func (m *mockActionService) RunActions(actions []domain.Action, release domain.Release) error {
+	m.runs = append(m.runs, release)
+	return nil
+}
+
+func Test_service_Retry(t *testing.T) {
+	filters := map[int]domain.Filter{
+		1: {ID: 1, Name: "movies", Actions: []domain.Action{{Name: "qbit", Type: domain.ActionTypeQbittorrent}}},
+		2: {ID: 2, Name: "no actions"},
+	}
+
+	releases := map[int64]domain.Release{
+		1: {ID: 1, TorrentName: "That Movie 2021", TorrentURL: "https://mock.example.test/dl/1", FilterID: 1, FilterStatus: domain.ReleaseStatusFilterApproved, TorrentTmpFile: "/tmp/gone.torrent"},
+		2: {ID: 2, TorrentName: "That Show S01E01", TorrentURL: "https://mock.example.test/dl/2", FilterStatus: domain.ReleaseStatusFilterRejected, Rejections: []string{"movies: resolution not matching"}},
+		3: {ID: 3, TorrentName: "That Movie 2022", FilterID: 1, FilterStatus: domain.ReleaseStatusFilterApproved},
+	}
+
+	tests := []struct {
+		name      string
+		id        int64
+		filterID  int
+		wantErr   error
+		wantRun   bool
+		wantStore bool
+	}{
+		{name: "unknown_release", id: 99, wantErr: sql.ErrNoRows},
+		{name: "unknown_filter", id: 2, filterID: 99, wantErr: sql.ErrNoRows},
+		{name: "rejected_without_filter", id: 2, wantErr: domain.ErrReleaseNoFilter},
+		{name: "no_download_url", id: 3, wantErr: domain.ErrReleaseNoDownloadURL},
+		{name: "filter_without_actions", id: 2, filterID: 2, wantErr: domain.ErrFilterNoActions},
+		{name: "approved", id: 1, wantRun: true},
+		{name: "rejected_with_filter", id: 2, filterID: 1, wantRun: true, wantStore: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockReleaseRepo{releases: releases}
+			actions := &mockActionService{}
+			s := &service{repo: repo, actionSvc: actions, filterSvc: mockFilterService{filters: filters}}
+
+			err := s.Retry(context.Background(), tt.id, tt.filterID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, repo.updated, "a rejected retry leaves the release as it is")
+				assert.Empty(t, actions.runs)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			if tt.wantStore {
+				if assert.Len(t, repo.updated, 1) {
+					assert.Equal(t, domain.ReleaseStatusFilterApproved, repo.updated[0].FilterStatus)
+					assert.Equal(t, tt.filterID, repo.updated[0].FilterID)
+					assert.Empty(t, repo.updated[0].Rejections)
+				}
+			} else {
+				assert.Empty(t, repo.updated)
+			}
+
+			if assert.Len(t, actions.runs, 1) {
+				assert.Empty(t, actions.runs[0].TorrentTmpFile, "the actions download the torrent again")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Job interface {
+	Run()
+}
+
+type Service interface {
+	Start()
+	Stop()
+	AddJob(job Job, interval time.Duration, identifier string) (int, error)
+	RemoveJobByID(id int) error
+	RemoveJobByIdentifier(id string) error
+}
+
+type scheduledJob struct {
+	id         int
+	identifier string
+	interval   time.Duration
+	job        Job
+	stop       chan struct{}
+}
+
+type service struct {
+	lock    sync.Mutex
+	running bool
+	nextID  int
+
+	jobs map[int]*scheduledJob
+}
+
+func NewService() Service {
+	return &service{
+		jobs: make(map[int]*scheduledJob),
+	}
+}
+
+func (s *service) Start() {
+	log.Debug().Msg("scheduler.Start")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.running = true
+
+	for _, j := range s.jobs {
+		s.run(j)
+	}
+}
+
+func (s *service) Stop() {
+	log.Debug().Msg("scheduler.Stop")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, j := range s.jobs {
+		close(j.stop)
+	}
+
+	s.jobs = make(map[int]*scheduledJob)
+	s.running = false
+}
+
+func (s *service) AddJob(job Job, interval time.Duration, identifier string) (int, error) {
+	if interval <= 0 {
+		return 0, fmt.Errorf("scheduler: invalid interval %v for job: %v", interval, identifier)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextID++
+
+	j := &scheduledJob{
+		id:         s.nextID,
+		identifier: identifier,
+		interval:   interval,
+		job:        job,
+		stop:       make(chan struct{}),
+	}
+
+	s.jobs[j.id] = j
+
+	if s.running {
+		s.run(j)
+	}
+
+	log.Debug().Msgf("scheduler.AddJob: job successfully added: %v every %v", identifier, interval)
+
+	return j.id, nil
+}
+
+func (s *service) RemoveJobByID(id int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	close(j.stop)
+	delete(s.jobs, id)
+
+	log.Debug().Msgf("scheduler.RemoveJobByID: job successfully removed: %v", j.identifier)
+
+	return nil
+}
+
+func (s *service) RemoveJobByIdentifier(identifier string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for id, j := range s.jobs {
+		if j.identifier != identifier {
+			continue
+		}
+
+		close(j.stop)
+		delete(s.jobs, id)
+
+		log.Debug().Msgf("scheduler.RemoveJobByIdentifier: job successfully removed: %v", identifier)
+	}
+
+	return nil
+}
+
+// run start the job loop, the first run happens right away
+func (s *service) run(j *scheduledJob) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.job.Run()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				j.job.Run()
+			}
+		}
+	}()
+}
@@ -1,29 +1,46 @@
 package server
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/autobrr/autobrr/internal/action"
+	"github.com/autobrr/autobrr/internal/download_client"
+	"github.com/autobrr/autobrr/internal/feed"
 	"github.com/autobrr/autobrr/internal/indexer"
 	"github.com/autobrr/autobrr/internal/irc"
+	"github.com/autobrr/autobrr/internal/scheduler"
 )
 
+// ShutdownTimeout for the in-flight announces and actions, docker stop kills the container after 10 seconds
+const ShutdownTimeout = 8 * time.Second
+
 type Server struct {
 	Hostname string
 	Port     int
 
+	actionService  action.Service
 	indexerService indexer.Service
 	ircService     irc.Service
+	feedService    feed.Service
+	clientService  download_client.Service
+	scheduler      scheduler.Service
 
 	stopWG sync.WaitGroup
 	lock   sync.Mutex
 }
 
-func NewServer(ircSvc irc.Service, indexerSvc indexer.Service) *Server {
+func NewServer(actionSvc action.Service, ircSvc irc.Service, indexerSvc indexer.Service, feedSvc feed.Service, clientSvc download_client.Service, scheduler scheduler.Service) *Server {
 	return &Server{
+		actionService:  actionSvc,
 		indexerService: indexerSvc,
 		ircService:     ircSvc,
+		feedService:    feedSvc,
+		clientService:  clientSvc,
+		scheduler:      scheduler,
 	}
 }
 
@@ -39,12 +56,41 @@ func (s *Server) Start() error {
 	// instantiate and start irc networks
 	s.ircService.StartHandlers()
 
+	// start torznab feeds
+	if err := s.feedService.Start(); err != nil {
+		log.Error().Err(err).Msg("Could not start feed service")
+	}
+
+	// check indexer passkeys and api keys every hour
+	if _, err := s.scheduler.AddJob(indexer.NewHealthCheckJob(s.indexerService), time.Hour, "indexer-health"); err != nil {
+		log.Error().Err(err).Msg("Could not add indexer health check job")
+	}
+
+	// check that download clients are reachable so actions can fail over
+	if _, err := s.scheduler.AddJob(download_client.NewHealthCheckJob(s.clientService), 5*time.Minute, "download-client-health"); err != nil {
+		log.Error().Err(err).Msg("Could not add download client health check job")
+	}
+
+	// start background jobs
+	s.scheduler.Start()
+
 	return nil
 }
 
-func (s *Server) Shutdown() {
+// Shutdown stop taking new announces and let the ones in flight finish until ctx is done.
+// Actions still waiting for their delay, schedule window or a retry are queued for the next start.
+func (s *Server) Shutdown(ctx context.Context) {
 	log.Info().Msg("Shutting down server")
 
-	// stop all irc handlers
-	s.ircService.StopHandlers()
+	// stop background jobs, feeds don't fetch new releases anymore
+	s.scheduler.Stop()
+
+	// quit all irc networks and process the announces they already received
+	s.ircService.StopHandlers(ctx)
+
+	if err := s.actionService.Shutdown(ctx); err != nil {
+		log.Warn().Err(err).Msg("actions interrupted by shutdown")
+	}
+
+	log.Info().Msg("Server stopped")
 }
@@ -0,0 +1,180 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// touchInterval last seen is written at most this often, not on every request
+const touchInterval = time.Minute
+
+var ErrInvalidSession = errors.New("session is invalid or expired")
+
+type Service interface {
+	Create(ctx context.Context, session domain.Session) (string, *domain.Session, error)
+	Validate(ctx context.Context, token string) (*domain.Session, error)
+	Settings() domain.SessionSettings
+	UpdateSettings(settings domain.SessionSettings)
+	FindByID(ctx context.Context, id int) (*domain.Session, error)
+	List(ctx context.Context, username string, authMethod string) ([]domain.Session, error)
+	Revoke(ctx context.Context, id int) error
+	RevokeUser(ctx context.Context, username string, authMethod string, exceptID int) error
+	RevokeToken(ctx context.Context, token string) error
+}
+
+type service struct {
+	repo domain.SessionRepo
+
+	settings    domain.SessionSettings
+	settingsMtx sync.RWMutex
+}
+
+func NewService(repo domain.SessionRepo, settings domain.SessionSettings) Service {
+	return &service{
+		repo:     repo,
+		settings: settings,
+	}
+}
+
+func (s *service) Settings() domain.SessionSettings {
+	s.settingsMtx.RLock()
+	defer s.settingsMtx.RUnlock()
+
+	return s.settings
+}
+
+// UpdateSettings new lifetime and idle timeout, existing sessions keep their expiry but are idle checked with the new timeout
+func (s *service) UpdateSettings(settings domain.SessionSettings) {
+	s.settingsMtx.Lock()
+	s.settings = settings
+	s.settingsMtx.Unlock()
+}
+
+// Create store a new session and return the token for the cookie, only its hash is stored
+func (s *service) Create(ctx context.Context, session domain.Session) (string, *domain.Session, error) {
+	now := time.Now()
+
+	s.deleteExpired(ctx, now)
+
+	token, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	session.TokenHash = hashToken(token)
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.Settings().Lifetime)
+
+	created, err := s.repo.Store(ctx, session)
+	if err != nil {
+		return "", nil, err
+	}
+
+	log.Debug().Msgf("session: %v signed in from %v", session.Username, session.IP)
+
+	return token, created, nil
+}
+
+// Validate the session of the token, expired sessions are removed
+func (s *service) Validate(ctx context.Context, token string) (*domain.Session, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	session, err := s.repo.FindByToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	now := time.Now()
+
+	if s.Settings().Expired(*session, now) {
+		if err := s.repo.Delete(ctx, session.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidSession
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		if err := s.repo.Touch(ctx, session.ID, now); err != nil {
+			log.Error().Err(err).Msg("session: could not update last seen")
+		}
+		session.LastSeenAt = now
+	}
+
+	return session, nil
+}
+
+func (s *service) FindByID(ctx context.Context, id int) (*domain.Session, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// List active sessions of the user signed in with authMethod, of every user when username is empty
+func (s *service) List(ctx context.Context, username string, authMethod string) ([]domain.Session, error) {
+	s.deleteExpired(ctx, time.Now())
+
+	return s.repo.List(ctx, username, authMethod)
+}
+
+func (s *service) Revoke(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) RevokeUser(ctx context.Context, username string, authMethod string, exceptID int) error {
+	return s.repo.DeleteByUsername(ctx, username, authMethod, exceptID)
+}
+
+// RevokeToken sign out the session of the token, used by logout
+func (s *service) RevokeToken(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	session, err := s.repo.FindByToken(ctx, hashToken(token))
+	if err != nil || session == nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, session.ID)
+}
+
+func (s *service) deleteExpired(ctx context.Context, now time.Time) {
+	var idleBefore time.Time
+	if settings := s.Settings(); settings.IdleTimeout > 0 {
+		idleBefore = now.Add(-settings.IdleTimeout)
+	}
+
+	if err := s.repo.DeleteExpired(ctx, now, idleBefore); err != nil {
+		log.Error().Err(err).Msg("session: could not remove expired sessions")
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,215 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/rs/zerolog/log"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+// releasesURL latest stable release, drafts and pre-releases are left out by github
+const releasesURL = "https://api.github.com/repos/autobrr/autobrr/releases/latest"
+
+// Interval between checks, github allows 60 unauthenticated requests an hour per address
+const Interval = 6 * time.Hour
+
+type Service interface {
+	Status() domain.UpdateStatus
+	Check(ctx context.Context) (domain.UpdateStatus, error)
+}
+
+type configService interface {
+	Get() domain.Config
+}
+
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type service struct {
+	version string
+	config  configService
+	bus     EventBus.Bus
+	client  *http.Client
+	url     string
+
+	status domain.UpdateStatus
+	// notified the version a notification went out for, once per release
+	notified string
+	lock     sync.Mutex
+}
+
+func NewService(version string, config configService, bus EventBus.Bus) Service {
+	return &service{
+		version: version,
+		config:  config,
+		bus:     bus,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		url:     releasesURL,
+		status:  domain.UpdateStatus{CurrentVersion: version},
+	}
+}
+
+// Status of the last check
+func (s *service) Status() domain.UpdateStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	status := s.status
+	status.Enabled = s.config.Get().CheckForUpdates
+
+	return status
+}
+
+// Check ask github for the latest release unless checkForUpdates is turned off, the first check
+// that finds a newer version publishes it on the event bus
+func (s *service) Check(ctx context.Context) (domain.UpdateStatus, error) {
+	if !s.config.Get().CheckForUpdates {
+		return s.Status(), nil
+	}
+
+	release, err := s.latest(ctx)
+
+	s.lock.Lock()
+
+	now := time.Now()
+	s.status.CheckedAt = &now
+	s.status.Error = ""
+
+	if err != nil {
+		s.status.Error = err.Error()
+		s.lock.Unlock()
+
+		log.Warn().Err(err).Msg("update: could not check for a new version")
+
+		return s.Status(), err
+	}
+
+	s.status.LatestVersion = release.TagName
+	s.status.ReleaseURL = release.HTMLURL
+	s.status.PublishedAt = &release.PublishedAt
+	s.status.UpdateAvailable = newer(release.TagName, s.version)
+
+	notify := s.status.UpdateAvailable && s.notified != release.TagName
+	if notify {
+		s.notified = release.TagName
+	}
+
+	status := s.status
+	s.lock.Unlock()
+
+	if notify {
+		log.Info().Msgf("update: autobrr %v is available, running %v: %v", release.TagName, s.version, release.HTMLURL)
+
+		s.bus.Publish(domain.EventUpdate, &status)
+	}
+
+	return s.Status(), nil
+}
+
+func (s *service) latest(ctx context.Context) (*githubRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", "autobrr/"+s.version)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from github: %v", res.Status)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(res.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+
+	if release.TagName == "" {
+		return nil, fmt.Errorf("github release without tag")
+	}
+
+	return &release, nil
+}
+
+// newer latest is a higher version than current. Development builds aren't versions, they never get updates.
+func newer(latest string, current string) bool {
+	l, lpre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+
+	c, cpre, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+
+	// a pre-release comes before the release of the same version
+	return cpre && !lpre
+}
+
+// parseVersion like v1.2.3 or 1.2.3-rc1, the pre-release part only counts as being there
+func parseVersion(v string) ([3]int, bool, bool) {
+	var parts [3]int
+
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+
+	pre := false
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		pre = v[i] == '-'
+		v = v[:i]
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) != 3 {
+		return parts, false, false
+	}
+
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false, false
+		}
+		parts[i] = n
+	}
+
+	return parts, pre, true
+}
+
+// Job check for a new version on the interval
+type Job struct {
+	service Service
+}
+
+func NewJob(service Service) *Job {
+	return &Job{service: service}
+}
+
+func (j *Job) Run() {
+	// failures are logged and kept in the status
+	_, _ = j.service.Check(context.Background())
+}
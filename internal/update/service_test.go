@@ -0,0 +1,99 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+type fakeConfig struct {
+	cfg domain.Config
+}
+
+func (c *fakeConfig) Get() domain.Config { return c.cfg }
+
+func Test_newer(t *testing.T) {
+	tests := []struct {
+		latest  string
+		current string
+		want    bool
+	}{
+		{"v1.2.0", "v1.1.0", true},
+		{"v1.10.0", "v1.9.3", true},
+		{"v2.0.0", "1.99.99", true},
+		{"v1.1.0", "v1.1.0", false},
+		{"v1.0.0", "v1.1.0", false},
+		{"v1.1.0", "v1.1.0-rc1", true},
+		{"v1.1.0-rc1", "v1.1.0", false},
+		{"v1.2.0", "dev", false},
+		{"latest", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.latest+" "+tt.current, func(t *testing.T) {
+			assert.Equal(t, tt.want, newer(tt.latest, tt.current))
+		})
+	}
+}
+
+func Test_service_Check(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "autobrr/v1.0.0", r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"tag_name": "v1.1.0", "html_url": "https://github.com/autobrr/autobrr/releases/tag/v1.1.0", "published_at": "2022-04-20T12:00:00Z"}`))
+	}))
+	defer ts.Close()
+
+	bus := EventBus.New()
+	var published []domain.UpdateStatus
+	require.NoError(t, bus.Subscribe(domain.EventUpdate, func(status *domain.UpdateStatus) {
+		published = append(published, *status)
+	}))
+
+	config := &fakeConfig{cfg: domain.Config{CheckForUpdates: true}}
+	svc := NewService("v1.0.0", config, bus).(*service)
+	svc.url = ts.URL
+
+	status, err := svc.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.True(t, status.UpdateAvailable)
+	assert.Equal(t, "v1.1.0", status.LatestVersion)
+	assert.NotNil(t, status.CheckedAt)
+
+	// the same release only notifies once
+	_, err = svc.Check(context.Background())
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+	assert.Equal(t, "v1.1.0", published[0].LatestVersion)
+
+	// turned off in the settings, github isn't asked anymore
+	config.cfg.CheckForUpdates = false
+	status, err = svc.Check(context.Background())
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func Test_service_Check_error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	svc := NewService("v1.0.0", &fakeConfig{cfg: domain.Config{CheckForUpdates: true}}, EventBus.New()).(*service)
+	svc.url = ts.URL
+
+	status, err := svc.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, status.Error, "403")
+	assert.False(t, status.UpdateAvailable)
+}
@@ -2,11 +2,25 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
 	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/argon2id"
 )
 
 type Service interface {
+	FindByID(ctx context.Context, id int) (*domain.User, error)
 	FindByUsername(ctx context.Context, username string) (*domain.User, error)
+	List(ctx context.Context) ([]domain.User, error)
+	Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error)
+	Update(ctx context.Context, id int, req domain.UpdateUserRequest) (*domain.User, error)
+	UpdateTOTP(ctx context.Context, user domain.User) error
+	Delete(ctx context.Context, id int) error
 }
 
 type service struct {
@@ -19,6 +33,10 @@ func NewService(repo domain.UserRepo) Service {
 	}
 }
 
+func (s *service) FindByID(ctx context.Context, id int) (*domain.User, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
 func (s *service) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
 	user, err := s.repo.FindByUsername(ctx, username)
 	if err != nil {
@@ -27,3 +45,126 @@ func (s *service) FindByUsername(ctx context.Context, username string) (*domain.
 
 	return user, nil
 }
+
+func (s *service) List(ctx context.Context) ([]domain.User, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	if err := domain.ValidateUsername(req.Username); err != nil {
+		return nil, err
+	}
+
+	if req.Password == "" {
+		return nil, errors.New("password can't be empty")
+	}
+
+	role, err := domain.ParseUserRole(string(req.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByUsername(ctx, req.Username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("user %v already exists", req.Username)
+	}
+
+	hash, err := argon2id.CreateHash(req.Password, argon2id.DefaultParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Store(ctx, domain.User{Username: req.Username, Password: hash, Role: role}); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("user: created user %v with role %v", req.Username, role)
+
+	return s.repo.FindByUsername(ctx, req.Username)
+}
+
+func (s *service) Update(ctx context.Context, id int, req domain.UpdateUserRequest) (*domain.User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Role != "" {
+		role, err := domain.ParseUserRole(string(req.Role))
+		if err != nil {
+			return nil, err
+		}
+
+		if user.Role == domain.UserRoleAdmin && role != domain.UserRoleAdmin {
+			if err := s.checkNotLastAdmin(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := s.repo.UpdateRole(ctx, user.ID, role); err != nil {
+			return nil, err
+		}
+
+		user.Role = role
+	}
+
+	if req.Password != "" {
+		hash, err := argon2id.CreateHash(req.Password, argon2id.DefaultParams)
+		if err != nil {
+			return nil, err
+		}
+
+		user.Password = hash
+
+		if err := s.repo.Store(ctx, *user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *service) UpdateTOTP(ctx context.Context, user domain.User) error {
+	return s.repo.UpdateTOTP(ctx, user)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.Role == domain.UserRoleAdmin {
+		if err := s.checkNotLastAdmin(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("user: deleted user %v", user.Username)
+
+	return nil
+}
+
+// checkNotLastAdmin there has to be an admin left to manage users
+func (s *service) checkNotLastAdmin(ctx context.Context, id int) error {
+	users, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if u.ID != id && u.Role == domain.UserRoleAdmin {
+			return nil
+		}
+	}
+
+	return errors.New("the last admin can't be removed or demoted")
+}
@@ -17,6 +17,7 @@ type BTNClient interface {
 }
 
 type Client struct {
+	URL         string
 	Timeout     int
 	client      *http.Client
 	rpcClient   jsonrpc.Client
@@ -31,6 +32,7 @@ func NewClient(url string, apiKey string) BTNClient {
 	}
 
 	c := &Client{
+		URL:    url,
 		client: http.DefaultClient,
 		rpcClient: jsonrpc.NewClientWithOpts(url, &jsonrpc.ClientOpts{
 			Headers: map[string]string{
@@ -44,6 +46,17 @@ func NewClient(url string, apiKey string) BTNClient {
 	return c
 }
 
+// SetHTTPClient set http client for both plain and rpc requests
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.client = client
+	c.rpcClient = jsonrpc.NewClientWithOpts(c.URL, &jsonrpc.ClientOpts{
+		HTTPClient: client,
+		Headers: map[string]string{
+			"User-Agent": "autobrr",
+		},
+	})
+}
+
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	ctx := context.Background()
 	err := c.Ratelimiter.Wait(ctx) // This is a blocking call. Honors the rate limit
@@ -0,0 +1,320 @@
+// Package expr is a small boolean expression language for filters, like
+//
+//	resolution in ["1080p", "2160p"] && size < 20GB && (freeleech || internal)
+//
+// Values are booleans, numbers, strings and lists. Sizes like 20GB are numbers in bytes,
+// strings compare case insensitive and matches takes a case insensitive RE2 pattern.
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Program a compiled expression
+type Program struct {
+	source string
+	root   node
+	vars   map[string]bool
+}
+
+// Compile parse the expression. Variables not in known are an error, any variable is allowed when known is nil.
+func Compile(source string, known []string) (*Program, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, vars: map[string]bool{}}
+
+	if known != nil {
+		p.known = make(map[string]bool, len(known))
+		for _, name := range known {
+			p.known[strings.ToLower(name)] = true
+		}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", t.text)
+	}
+
+	return &Program{source: source, root: root, vars: p.vars}, nil
+}
+
+// String the source of the program
+func (p *Program) String() string {
+	return p.source
+}
+
+// Uses whether the expression reads the variable
+func (p *Program) Uses(name string) bool {
+	return p.vars[strings.ToLower(name)]
+}
+
+// Eval run the program against the variables, the expression has to evaluate to a boolean
+func (p *Program) Eval(env map[string]interface{}) (bool, error) {
+	value, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression is %v, not true or false", describe(value))
+	}
+
+	return result, nil
+}
+
+type node interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type varNode struct {
+	name string
+}
+
+func (n *varNode) eval(env map[string]interface{}) (interface{}, error) {
+	value, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", n.name)
+	}
+
+	return normalize(value), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env map[string]interface{}) (interface{}, error) {
+	list := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+
+	return list, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(env map[string]interface{}) (interface{}, error) {
+	value, err := evalBool(n.operand, env, "!")
+	if err != nil {
+		return nil, err
+	}
+
+	return !value, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(env map[string]interface{}) (interface{}, error) {
+	left, err := evalBool(n.left, env, n.op)
+	if err != nil {
+		return nil, err
+	}
+
+	// short circuit
+	if n.op == "&&" && !left || n.op == "||" && left {
+		return left, nil
+	}
+
+	return evalBool(n.right, env, n.op)
+}
+
+func evalBool(n node, env map[string]interface{}, op string) (bool, error) {
+	value, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%v needs true or false, got %v", op, describe(value))
+	}
+
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	rxp         *regexp.Regexp
+}
+
+func (n *compareNode) eval(env map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right)
+
+	case "!=":
+		eq, err := equal(left, right)
+		return !eq, err
+
+	case "<", "<=", ">", ">=":
+		l, lok := left.(float64)
+		r, rok := right.(float64)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%v needs numbers, got %v and %v", n.op, describe(left), describe(right))
+		}
+
+		switch n.op {
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		default:
+			return l >= r, nil
+		}
+
+	case "in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("in needs a list, got %v", describe(right))
+		}
+		return containsValue(list, left)
+
+	case "contains":
+		switch l := left.(type) {
+		case string:
+			r, ok := right.(string)
+			if !ok {
+				return nil, fmt.Errorf("contains on a string needs a string, got %v", describe(right))
+			}
+			return strings.Contains(strings.ToLower(l), strings.ToLower(r)), nil
+		case []interface{}:
+			return containsValue(l, right)
+		}
+		return nil, fmt.Errorf("contains needs a string or list, got %v", describe(left))
+
+	case "matches":
+		l, ok := left.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches needs a string, got %v", describe(left))
+		}
+
+		rxp := n.rxp
+		if rxp == nil {
+			pattern, ok := right.(string)
+			if !ok {
+				return nil, fmt.Errorf("matches needs a string pattern, got %v", describe(right))
+			}
+
+			if rxp, err = regexp.Compile("(?i)" + pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+
+		return rxp.MatchString(l), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %v", n.op)
+}
+
+func containsValue(list []interface{}, value interface{}) (bool, error) {
+	for _, item := range list {
+		eq, err := equal(item, value)
+		if err != nil {
+			return false, err
+		}
+		if eq {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func equal(left, right interface{}) (bool, error) {
+	switch l := left.(type) {
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.EqualFold(l, r), nil
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			return l == r, nil
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			return l == r, nil
+		}
+	}
+
+	return false, fmt.Errorf("can not compare %v and %v", describe(left), describe(right))
+}
+
+// normalize variables to the value types of the language
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		list := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			list = append(list, s)
+		}
+		return list
+	}
+
+	return value
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "a list"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,94 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_Eval(t *testing.T) {
+	env := map[string]interface{}{
+		"resolution": "1080p",
+		"size":       uint64(15_000_000_000),
+		"freeleech":  false,
+		"internal":   true,
+		"season":     2,
+		"tags":       []string{"comedy", "2020s"},
+		"name":       "That.Show.S02E01.1080p.WEB-DL.DDP5.1.H.264-GROUP",
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "example", source: `resolution in ["1080p","2160p"] && size < 20GB && (freeleech || internal)`, want: true},
+		{name: "keywords", source: `not freeleech and season >= 2 or false`, want: true},
+		{name: "string_case_insensitive", source: `resolution == "1080P"`, want: true},
+		{name: "not_equal", source: `resolution != '2160p'`, want: true},
+		{name: "size_units", source: `size > 14GB && size <= 15000MB`, want: true},
+		{name: "list_contains", source: `tags contains "comedy" && !(tags contains "anime")`, want: true},
+		{name: "string_contains", source: `name contains "web-dl"`, want: true},
+		{name: "matches", source: `name matches "s\\d{2}e\\d{2}"`, want: true},
+		{name: "no_match", source: `size > 20GB`, want: false},
+		{name: "not_boolean", source: `season`, wantErr: true},
+		{name: "type_mismatch", source: `resolution > 720`, wantErr: true},
+		{name: "and_needs_booleans", source: `internal && season`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.source, nil)
+			assert.NoError(t, err)
+
+			got, err := p.Eval(env)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	known := []string{"resolution", "size"}
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{name: "valid", source: `resolution == "1080p" && size < 10GB`},
+		{name: "unknown_variable", source: `codec == "x264"`, wantErr: true},
+		{name: "unterminated_string", source: `resolution == "1080p`, wantErr: true},
+		{name: "missing_paren", source: `(size < 10GB`, wantErr: true},
+		{name: "trailing", source: `size < 10GB size`, wantErr: true},
+		{name: "invalid_number", source: `size < 10XB`, wantErr: true},
+		{name: "invalid_pattern", source: `resolution matches "(1080"`, wantErr: true},
+		{name: "unexpected_char", source: `size < 10GB & true`, wantErr: true},
+		{name: "empty", source: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.source, known)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProgram_Uses(t *testing.T) {
+	p, err := Compile(`Size < 10GB && resolution == "1080p"`, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, p.Uses("size"))
+	assert.True(t, p.Uses("resolution"))
+	assert.False(t, p.Uses("freeleech"))
+}
@@ -0,0 +1,339 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex split the source into identifiers, numbers, strings and operators
+func lex(source string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsDigit(r):
+			// sizes like 20GB or 1.5GiB are numbers with a unit
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || unicode.IsLetter(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+
+			switch two {
+			case "&&", "||", "==", "!=", "<=", ">=":
+				tokens = append(tokens, token{kind: tokenOp, text: two, pos: start})
+				i += 2
+				continue
+			}
+
+			if !strings.ContainsRune("()[],!<>", r) {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, start)
+			}
+
+			tokens = append(tokens, token{kind: tokenOp, text: string(r), pos: start})
+			i++
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	known  map[string]bool
+	vars   map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consume the next token if it is one of the operators or keywords
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOp && t.kind != tokenIdent {
+		return "", false
+	}
+
+	for _, text := range texts {
+		if t.text == text || (t.kind == tokenIdent && strings.EqualFold(t.text, text)) {
+			p.next()
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		return p.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf(format+" at end of expression", args...)
+	}
+	return fmt.Errorf(format+" at position %d", append(args, t.pos)...)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "in", "contains", "matches")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &compareNode{op: op, left: left, right: right}
+
+	// literal patterns are compiled once and validated when the filter is saved
+	if op == "matches" {
+		if lit, ok := right.(*literalNode); ok {
+			pattern, ok := lit.value.(string)
+			if !ok {
+				return nil, fmt.Errorf("matches needs a string pattern")
+			}
+
+			rxp, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+
+			cmp.rxp = rxp
+		}
+	}
+
+	return cmp, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenNumber:
+		p.next()
+
+		value, err := parseNumber(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+
+		return &literalNode{value: value}, nil
+
+	case tokenString:
+		p.next()
+		return &literalNode{value: t.text}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			p.next()
+			return &literalNode{value: true}, nil
+		case "false":
+			p.next()
+			return &literalNode{value: false}, nil
+		case "and", "or", "not", "in", "contains", "matches":
+			return nil, p.errorf("unexpected %q", t.text)
+		}
+
+		p.next()
+
+		name := strings.ToLower(t.text)
+		if p.known != nil && !p.known[name] {
+			return nil, fmt.Errorf("unknown variable %q at position %d", t.text, t.pos)
+		}
+		p.vars[name] = true
+
+		return &varNode{name: name}, nil
+
+	case tokenOp:
+		switch t.text {
+		case "(":
+			p.next()
+
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+
+			return inner, nil
+
+		case "[":
+			p.next()
+
+			list := &listNode{}
+			if _, ok := p.accept("]"); ok {
+				return list, nil
+			}
+
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+
+				if _, ok := p.accept(","); ok {
+					continue
+				}
+
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+
+				return list, nil
+			}
+		}
+	}
+
+	return nil, p.errorf("unexpected %q", t.text)
+}
+
+// parseNumber plain numbers or sizes like 20GB, which are turned into bytes
+func parseNumber(text string) (float64, error) {
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return value, nil
+	}
+
+	size, err := humanize.ParseBytes(text)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(size), nil
+}
@@ -47,6 +47,11 @@ func NewClient(url string, apiKey string) Client {
 	return c
 }
 
+// SetHTTPClient use a custom http client for requests
+func (c *client) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
 type Group struct {
 	BbWikiBody   string   `json:"bbWikiBody"`
 	WikiBody     string   `json:"wikiBody"`
@@ -0,0 +1,168 @@
+package nzbget
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Config struct {
+	// Hostname base url, eg. http://localhost:6789
+	Hostname      string
+	TLSSkipVerify bool
+
+	// control username and password
+	Username string
+	Password string
+}
+
+type Client interface {
+	Version() (string, error)
+	AppendURL(nzbURL string, opts AppendOptions) (int64, error)
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+}
+
+func New(config Config) Client {
+	httpClient := &http.Client{
+		Timeout: time.Second * 30,
+	}
+
+	if config.TLSSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+		httpClient.Transport = transport
+	}
+
+	return &client{
+		config: config,
+		http:   httpClient,
+	}
+}
+
+type AppendOptions struct {
+	Name     string
+	Category string
+	Paused   bool
+}
+
+type rpcRequest struct {
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *client) Version() (string, error) {
+	var version string
+	if err := c.call("version", nil, &version); err != nil {
+		log.Error().Stack().Err(err).Msg("nzbget client get version error")
+		return "", err
+	}
+
+	return version, nil
+}
+
+// AppendURL let nzbget fetch the nzb and return the new queue id
+func (c *client) AppendURL(nzbURL string, opts AppendOptions) (int64, error) {
+	name := opts.Name
+	if name != "" {
+		name += ".nzb"
+	}
+
+	// NZBFilename, Content, Category, Priority, AddToTop, AddPaused, DupeKey, DupeScore, DupeMode, PPParameters
+	params := []interface{}{name, nzbURL, opts.Category, 0, false, opts.Paused, "", 0, "SCORE", []interface{}{}}
+
+	var id int64
+	if err := c.call("append", params, &id); err != nil {
+		log.Error().Stack().Err(err).Msg("nzbget client append error")
+		return 0, err
+	}
+
+	if id <= 0 {
+		return 0, errors.New("nzbget: could not add nzb")
+	}
+
+	return id, nil
+}
+
+func (c *client) call(method string, params []interface{}, out interface{}) error {
+	u, err := url.Parse(c.config.Hostname)
+	if err != nil {
+		return err
+	}
+
+	u.Path = path.Join(u.Path, "/jsonrpc")
+
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, err := json.Marshal(rpcRequest{Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Msgf("nzbget client request error: %v", u.Host)
+		return err
+	}
+
+	if c.config.Username != "" || c.config.Password != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "autobrr")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msgf("nzbget client request error: %v", u.Host)
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return errors.New("unauthorized: bad credentials")
+	} else if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("nzbget: unexpected status code: %v", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("nzbget client error reading body")
+		return err
+	}
+
+	var rpcRes rpcResponse
+	if err := json.Unmarshal(data, &rpcRes); err != nil {
+		log.Error().Err(err).Msg("nzbget client error unmarshal body")
+		return err
+	}
+
+	if rpcRes.Error != nil {
+		return fmt.Errorf("nzbget: %v", rpcRes.Error.Message)
+	}
+
+	return json.Unmarshal(rpcRes.Result, out)
+}
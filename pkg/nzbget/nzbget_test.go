@@ -0,0 +1,61 @@
+package nzbget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_client_AppendURL(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	var params []interface{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if r.URL.Path != "/jsonrpc" || !ok || user != "nzbget" || pass != "tegbzn6789" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "version":
+			w.Write([]byte(`{"version": "1.1", "result": "21.1"}`))
+		case "append":
+			params = req.Params
+			w.Write([]byte(`{"version": "1.1", "result": 42}`))
+		default:
+			w.Write([]byte(`{"version": "1.1", "error": {"name": "JSONRPCError", "code": 1, "message": "Invalid procedure"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	c := New(Config{Hostname: ts.URL, Username: "nzbget", Password: "tegbzn6789"})
+
+	version, err := c.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, "21.1", version)
+
+	id, err := c.AppendURL("https://indexer.example.test/getnzb/1", AppendOptions{Name: "Mock.S01E01.1080p", Category: "tv", Paused: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	assert.Equal(t, "Mock.S01E01.1080p.nzb", params[0])
+	assert.Equal(t, "https://indexer.example.test/getnzb/1", params[1])
+	assert.Equal(t, "tv", params[2])
+	assert.Equal(t, true, params[5])
+
+	_, err = New(Config{Hostname: ts.URL}).Version()
+	assert.Error(t, err)
+}
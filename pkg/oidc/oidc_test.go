@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tp := &testProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 tp.server.URL,
+			"authorization_endpoint": tp.server.URL + "/authorize",
+			"token_endpoint":         tp.server.URL + "/token",
+			"jwks_uri":               tp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "autobrr" || pass != "secret" || r.PostFormValue("code") != "good-code" || r.PostFormValue("code_verifier") != "verifier" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "id_token": tp.sign(t, tp.claims)})
+	})
+
+	tp.server = httptest.NewServer(mux)
+	t.Cleanup(tp.server.Close)
+
+	return tp
+}
+
+func (tp *testProvider) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	h := crypto.SHA256.New()
+	h.Write([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, tp.key, crypto.SHA256, h.Sum(nil))
+	assert.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestProvider_Flow(t *testing.T) {
+	tp := newTestProvider(t)
+	ctx := context.Background()
+	cfg := Config{ClientID: "autobrr", ClientSecret: "secret", RedirectURL: "http://localhost:7474/api/auth/oidc/callback", Scopes: []string{"openid", "groups"}}
+
+	p, err := Discover(ctx, nil, tp.server.URL+"/")
+	assert.NoError(t, err)
+
+	authURL, err := url.Parse(p.AuthCodeURL(cfg, "state", "nonce", "verifier"))
+	assert.NoError(t, err)
+	assert.Equal(t, "openid groups", authURL.Query().Get("scope"))
+	assert.Equal(t, codeChallenge("verifier"), authURL.Query().Get("code_challenge"))
+
+	tp.claims = map[string]interface{}{
+		"iss":    tp.server.URL,
+		"aud":    "autobrr",
+		"sub":    "1234",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"nonce":  "nonce",
+		"groups": []string{"autobrr-admins", "users"},
+	}
+
+	_, err = p.Exchange(ctx, cfg, "bad-code", "verifier")
+	assert.Error(t, err)
+
+	rawIDToken, err := p.Exchange(ctx, cfg, "good-code", "verifier")
+	assert.NoError(t, err)
+
+	claims, err := p.Verify(ctx, cfg, rawIDToken, "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", claims.String("sub"))
+	assert.Equal(t, []string{"autobrr-admins", "users"}, claims.Strings("groups"))
+
+	_, err = p.Verify(ctx, cfg, rawIDToken, "other-nonce")
+	assert.Error(t, err)
+
+	// tampered payload
+	tp.claims["sub"] = "5678"
+	forged := tp.sign(t, tp.claims)
+	parts := []byte(forged)
+	copy(parts[len(parts)-10:], "AAAAAAAAAA")
+	_, err = p.Verify(ctx, cfg, string(parts), "nonce")
+	assert.Error(t, err)
+}
+
+func TestProvider_checkClaims(t *testing.T) {
+	p := &Provider{Issuer: "https://auth.example.com"}
+	cfg := Config{ClientID: "autobrr"}
+	now := time.Now()
+
+	valid := func() Claims {
+		return Claims{"iss": "https://auth.example.com/", "aud": []interface{}{"other", "autobrr"}, "exp": float64(now.Add(time.Minute).Unix()), "nonce": "n"}
+	}
+
+	assert.NoError(t, p.checkClaims(cfg, valid(), "n", now))
+
+	tests := map[string]func(c Claims){
+		"issuer":   func(c Claims) { c["iss"] = "https://evil.example.com" },
+		"audience": func(c Claims) { c["aud"] = "other" },
+		"expired":  func(c Claims) { c["exp"] = float64(now.Add(-time.Hour).Unix()) },
+		"no_exp":   func(c Claims) { delete(c, "exp") },
+		"nonce":    func(c Claims) { c["nonce"] = "x" },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := valid()
+			change(c)
+			assert.Error(t, p.checkClaims(cfg, c, "n", now))
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384, RS512, ES384 and ES512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew allowed difference between the provider clock and ours
+const clockSkew = time.Minute
+
+// keysRefreshInterval minimum time between fetching the keys for an unknown key id
+const keysRefreshInterval = time.Minute
+
+// Claims of a verified id token
+type Claims map[string]interface{}
+
+// String claim value, empty when missing or not a string
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings claim with a list of strings like groups, a single string counts as a list of one
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Verify check the signature and claims of the id token from Exchange
+func (p *Provider) Verify(ctx context.Context, cfg Config, rawIDToken string, nonce string) (Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id token header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id token signature: %w", err)
+	}
+
+	key, err := p.publicKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id token claims: %w", err)
+	}
+
+	if err := p.checkClaims(cfg, claims, nonce, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) checkClaims(cfg Config, claims Claims, nonce string, now time.Time) error {
+	if strings.TrimSuffix(claims.String("iss"), "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return fmt.Errorf("id token issued by %q", claims.String("iss"))
+	}
+
+	audience := false
+	for _, aud := range claims.Strings("aud") {
+		if aud == cfg.ClientID {
+			audience = true
+		}
+	}
+	if !audience {
+		return errors.New("id token is not issued for this client")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("id token has no expiry")
+	}
+	if now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return errors.New("id token expired")
+	}
+
+	if claims.String("nonce") != nonce {
+		return errors.New("id token nonce does not match")
+	}
+
+	return nil
+}
+
+// publicKey the signing key with the id, the keys are fetched again when the provider rotated them
+func (p *Provider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := findKey(p.keys, kid); key != nil {
+		return key.publicKey()
+	}
+
+	if time.Since(p.keysFetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, p.client, p.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("could not fetch signing keys: %w", err)
+	}
+
+	p.keys = set.Keys
+	p.keysFetched = time.Now()
+
+	if key := findKey(p.keys, kid); key != nil {
+		return key.publicKey()
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// findKey signing key by id, or the only signing key when the token has no id
+func findKey(keys []jsonWebKey, kid string) *jsonWebKey {
+	var signing []jsonWebKey
+	for _, key := range keys {
+		if key.Use == "" || key.Use == "sig" {
+			signing = append(signing, key)
+		}
+	}
+
+	for i := range signing {
+		if signing[i].Kid == kid {
+			return &signing[i]
+		}
+	}
+
+	if kid == "" && len(signing) == 1 {
+		return &signing[0]
+	}
+
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %v does not match rsa key", alg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.New("invalid id token signature")
+		}
+
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %v does not match ec key", alg)
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid id token signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid id token signature")
+		}
+
+	default:
+		return errors.New("unsupported key")
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed key: %w", err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
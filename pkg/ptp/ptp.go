@@ -48,6 +48,11 @@ func NewClient(url string, apiUser string, apiKey string) PTPClient {
 	return c
 }
 
+// SetHTTPClient use a custom http client for requests
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
 type TorrentResponse struct {
 	Page          string    `json:"Page"`
 	Result        string    `json:"Result"`
@@ -2,6 +2,7 @@ package qbittorrent
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -32,12 +33,15 @@ type Client struct {
 }
 
 type Settings struct {
-	Hostname string
-	Port     uint
-	Username string
-	Password string
-	SSL      bool
-	protocol string
+	Hostname      string
+	Port          uint
+	Username      string
+	Password      string
+	SSL           bool
+	TLSSkipVerify bool
+	BasicUser     string
+	BasicPass     string
+	protocol      string
 }
 
 func NewClient(s Settings) *Client {
@@ -52,6 +56,13 @@ func NewClient(s Settings) *Client {
 		Jar:     jar,
 	}
 
+	if s.TLSSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+		httpClient.Transport = transport
+	}
+
 	c := &Client{
 		settings: s,
 		http:     httpClient,
@@ -77,6 +88,8 @@ func (c *Client) get(endpoint string, opts map[string]string) (*http.Response, e
 		return nil, err
 	}
 
+	c.setBasicAuth(req)
+
 	// try request and if fail run 3 retries
 	for i, backoff := range backoffSchedule {
 		resp, err = c.http.Do(req)
@@ -96,7 +109,7 @@ func (c *Client) get(endpoint string, opts map[string]string) (*http.Response, e
 		return nil, err
 	}
 
-	return resp, nil
+	return c.relogin(endpoint, req, resp)
 }
 
 func (c *Client) post(endpoint string, opts map[string]string) (*http.Response, error) {
@@ -118,6 +131,8 @@ func (c *Client) post(endpoint string, opts map[string]string) (*http.Response,
 		return nil, err
 	}
 
+	c.setBasicAuth(req)
+
 	// add the content-type so qbittorrent knows what to expect
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
@@ -140,7 +155,7 @@ func (c *Client) post(endpoint string, opts map[string]string) (*http.Response,
 		return nil, err
 	}
 
-	return resp, nil
+	return c.relogin(endpoint, req, resp)
 }
 
 func (c *Client) postFile(endpoint string, fileName string, opts map[string]string) (*http.Response, error) {
@@ -202,6 +217,8 @@ func (c *Client) postFile(endpoint string, fileName string, opts map[string]stri
 		return nil, err
 	}
 
+	c.setBasicAuth(req)
+
 	// Set correct content type
 	req.Header.Set("Content-Type", multiPartWriter.FormDataContentType())
 
@@ -224,7 +241,44 @@ func (c *Client) postFile(endpoint string, fileName string, opts map[string]stri
 		return nil, err
 	}
 
-	return resp, nil
+	return c.relogin(endpoint, req, resp)
+}
+
+// relogin log in again and send the request once more when qBittorrent answered 403 because the session expired.
+// A client is kept logged in between actions so its session can run out.
+func (c *Client) relogin(endpoint string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusForbidden || endpoint == "auth/login" {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	log.Debug().Msgf("qbit session expired, logging in again: %v", c.Name)
+
+	if err := c.Login(); err != nil {
+		return nil, fmt.Errorf("session expired, could not log in again: %w", err)
+	}
+
+	// the cookie jar added the old session to the request, it adds the new one again
+	retry := req.Clone(req.Context())
+	retry.Header.Del("Cookie")
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+
+	return c.http.Do(retry)
+}
+
+// setBasicAuth for clients behind a reverse proxy with basic auth
+func (c *Client) setBasicAuth(req *http.Request) {
+	if c.settings.BasicUser != "" {
+		req.SetBasicAuth(c.settings.BasicUser, c.settings.BasicPass)
+	}
 }
 
 func (c *Client) setCookies(cookies []*http.Cookie) {
@@ -0,0 +1,189 @@
+package qbittorrent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Login(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	mux := http.NewServeMux()
+	ts := httptest.NewTLSServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "proxy-user" || pass != "proxy-pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "adminadmin" {
+			w.Write([]byte("Fails."))
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "mock-sid"})
+		w.Write([]byte("Ok."))
+	})
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{
+			name: "ok",
+			settings: Settings{
+				Username:      "admin",
+				Password:      "adminadmin",
+				TLSSkipVerify: true,
+				BasicUser:     "proxy-user",
+				BasicPass:     "proxy-pass",
+			},
+		},
+		{
+			name: "missing_basic_auth",
+			settings: Settings{
+				Username:      "admin",
+				Password:      "adminadmin",
+				TLSSkipVerify: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad_credentials",
+			settings: Settings{
+				Username:      "admin",
+				Password:      "wrong",
+				TLSSkipVerify: true,
+				BasicUser:     "proxy-user",
+				BasicPass:     "proxy-pass",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings.Hostname = u.Hostname()
+			tt.settings.Port = uint(port)
+			tt.settings.SSL = true
+
+			c := NewClient(tt.settings)
+
+			err := c.Login()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_GetFreeSpaceOnDisk(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api/v2/sync/maindata", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"rid":1,"full_update":true,"server_state":{"free_space_on_disk":53687091200,"dl_info_speed":1024}}`))
+	})
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	c := NewClient(Settings{Hostname: u.Hostname(), Port: uint(port)})
+
+	free, err := c.GetFreeSpaceOnDisk()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(53687091200), free)
+}
+
+func TestClient_TopPrioTorrents(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	queueing := true
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api/v2/torrents/topPrio", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("hashes") != "abc|def" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !queueing {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	})
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	c := NewClient(Settings{Hostname: u.Hostname(), Port: uint(port)})
+
+	assert.NoError(t, c.TopPrioTorrents([]string{"abc", "def"}))
+
+	queueing = false
+	assert.Error(t, c.TopPrioTorrents([]string{"abc", "def"}))
+}
+
+func TestClient_relogin(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	sid := "first"
+	logins := 0
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: sid})
+		w.Write([]byte("Ok."))
+	})
+
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("SID"); err != nil || c.Value != sid {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.FormValue("urls") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	})
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	c := NewClient(Settings{Hostname: u.Hostname(), Port: uint(port), Username: "admin", Password: "adminadmin"})
+	assert.NoError(t, c.Login())
+
+	// qBittorrent restarted or the session timed out
+	sid = "second"
+
+	assert.NoError(t, c.AddTorrentFromUrl("magnet:?xt=urn:btih:mock", nil))
+	assert.Equal(t, 2, logins)
+}
@@ -151,6 +151,9 @@ const (
 	// Torrent is being seeded, but no connection were made
 	TorrentFilterStalledUploading TorrentFilter = "stalled_uploading"
 
+	// Torrent is completed and not paused, transferring data or not
+	TorrentFilterSeeding TorrentFilter = "seeding"
+
 	// Torrent is being downloaded and data is being transferred
 	TorrentFilterDownloading TorrentFilter = "downloading"
 
@@ -216,3 +219,16 @@ type TransferInfo struct {
 	UpInfoSpeed      int64            `json:"up_info_speed"`
 	UpRateLimit      int64            `json:"up_rate_limit"`
 }
+
+// MainData https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)#get-main-data
+// only the server state is used, torrents are fetched through torrents/info
+type MainData struct {
+	Rid         int64       `json:"rid"`
+	ServerState ServerState `json:"server_state"`
+}
+
+type ServerState struct {
+	FreeSpaceOnDisk int64 `json:"free_space_on_disk"`
+	DlInfoSpeed     int64 `json:"dl_info_speed"`
+	UpInfoSpeed     int64 `json:"up_info_speed"`
+}
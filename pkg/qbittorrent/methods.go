@@ -3,6 +3,7 @@ package qbittorrent
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -23,8 +24,9 @@ func (c *Client) Login() error {
 		log.Error().Err(err).Msg("login error")
 		return err
 	} else if resp.StatusCode == http.StatusForbidden {
-		log.Error().Err(err).Msg("User's IP is banned for too many failed login attempts")
-		return err
+		resp.Body.Close()
+		log.Error().Msg("User's IP is banned for too many failed login attempts")
+		return errors.New("qbittorrent login forbidden, ip is banned for too many failed attempts")
 
 	} else if resp.StatusCode != http.StatusOK { // check for correct status code
 		log.Error().Err(err).Msgf("login bad status %v error", resp.StatusCode)
@@ -204,13 +206,38 @@ func (c *Client) AddTorrentFromFile(file string, options map[string]string) erro
 	if err != nil {
 		log.Error().Err(err).Msgf("add torrents error: %v", file)
 		return err
-	} else if res.StatusCode != http.StatusOK {
-		log.Error().Err(err).Msgf("add torrents bad status: %v", file)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Error().Msgf("add torrents bad status: %v %v", res.StatusCode, file)
+		return fmt.Errorf("add torrents bad status: %v", res.StatusCode)
+	}
+
+	return nil
+}
+
+// AddTorrentFromUrl add new torrent from url or magnet link
+func (c *Client) AddTorrentFromUrl(torrentUrl string, options map[string]string) error {
+	opts := map[string]string{"urls": torrentUrl}
+	for k, v := range options {
+		opts[k] = v
+	}
+
+	res, err := c.post("torrents/add", opts)
+	if err != nil {
+		log.Error().Err(err).Msgf("add torrents error: %v", torrentUrl)
 		return err
 	}
 
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		log.Error().Msgf("add torrents bad status: %v", res.StatusCode)
+		return fmt.Errorf("add torrents bad status: %v", res.StatusCode)
+	}
+
 	return nil
 }
 
@@ -286,3 +313,80 @@ func (c *Client) GetTransferInfo() (*TransferInfo, error) {
 
 	return &info, nil
 }
+
+// GetTorrentsSeeding completed torrents that are not paused
+func (c *Client) GetTorrentsSeeding() ([]Torrent, error) {
+	torrents, err := c.GetTorrentsFilter(TorrentFilterSeeding)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Torrent, 0)
+	for _, torrent := range torrents {
+		// skip torrents that are still checking or moving
+		switch torrent.State {
+		case TorrentStateUploading, TorrentStateStalledUp, TorrentStateForcedUp, TorrentStateQueuedUp:
+			res = append(res, torrent)
+		}
+	}
+
+	return res, nil
+}
+
+// GetFreeSpaceOnDisk free space in bytes of the default save path
+func (c *Client) GetFreeSpaceOnDisk() (int64, error) {
+	var data MainData
+
+	resp, err := c.get("sync/maindata", nil)
+	if err != nil {
+		log.Error().Err(err).Msg("get main data error")
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		log.Error().Err(readErr).Msg("get main data read error")
+		return 0, readErr
+	}
+
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		log.Error().Err(err).Msg("get main data unmarshal error")
+		return 0, err
+	}
+
+	return data.ServerState.FreeSpaceOnDisk, nil
+}
+
+// TopPrioTorrents move torrents to the top of the queue, only works with torrent queueing enabled
+func (c *Client) TopPrioTorrents(hashes []string) error {
+	return c.setQueuePrio("torrents/topPrio", hashes)
+}
+
+// BottomPrioTorrents move torrents to the bottom of the queue, only works with torrent queueing enabled
+func (c *Client) BottomPrioTorrents(hashes []string) error {
+	return c.setQueuePrio("torrents/bottomPrio", hashes)
+}
+
+func (c *Client) setQueuePrio(endpoint string, hashes []string) error {
+	opts := map[string]string{
+		"hashes": strings.Join(hashes, "|"),
+	}
+
+	resp, err := c.post(endpoint, opts)
+	if err != nil {
+		log.Error().Err(err).Msgf("%v error: %v", endpoint, hashes)
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// 409 when queueing is disabled
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: unexpected status: %v", endpoint, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package readarr
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_client_Push(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	key := "mock-key"
+
+	mux.HandleFunc("/api/v1/release/push", func(w http.ResponseWriter, r *http.Request) {
+		// request validation logic
+		apiKey := r.Header.Get("X-Api-Key")
+		if apiKey != key {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(nil)
+			return
+		}
+
+		// read json response
+		jsonPayload, _ := ioutil.ReadFile("testdata/release_push_response.json")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonPayload)
+	})
+
+	release := Release{
+		Title:            "Some Author - That Book (2022) [EPUB]",
+		DownloadUrl:      "https://www.test.org/rss/download/0000001/00000000000000000000/Some Author - That Book (2022) [EPUB].torrent",
+		Size:             0,
+		Indexer:          "test",
+		DownloadProtocol: "torrent",
+		Protocol:         "torrent",
+		PublishDate:      "2022-02-20T15:36:00Z",
+	}
+
+	tests := []struct {
+		name       string
+		config     Config
+		rejections []string
+		err        error
+		wantErr    bool
+	}{
+		{
+			name:       "push",
+			config:     Config{Hostname: ts.URL, APIKey: key},
+			rejections: []string{"Unknown Author"},
+		},
+		{
+			name:    "push_unauthorized",
+			config:  Config{Hostname: ts.URL, APIKey: "bad-mock-key"},
+			err:     errors.New("unauthorized: bad credentials"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.config)
+
+			rejections, err := c.Push(release)
+			assert.Equal(t, tt.rejections, rejections)
+			if tt.wantErr && assert.Error(t, err) {
+				assert.Equal(t, tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_client_Test(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	key := "mock-key"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-Api-Key")
+		if apiKey != key {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(nil)
+			return
+		}
+		jsonPayload, _ := ioutil.ReadFile("testdata/system_status_response.json")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonPayload)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    *SystemStatusResponse
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "fetch",
+			cfg:     Config{Hostname: srv.URL, APIKey: key},
+			want:    &SystemStatusResponse{Version: "0.1.1.1320"},
+			err:     nil,
+			wantErr: false,
+		},
+		{
+			name:    "fetch_unauthorized",
+			cfg:     Config{Hostname: srv.URL, APIKey: "bad-mock-key"},
+			want:    nil,
+			wantErr: true,
+			err:     errors.New("unauthorized: bad credentials"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.cfg)
+
+			got, err := c.Test()
+			if tt.wantErr && assert.Error(t, err) {
+				assert.Equal(t, tt.err, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 type REDClient interface {
 	GetTorrentByID(torrentID string) (*domain.TorrentBasic, error)
+	GetTorrentMetadata(ctx context.Context, torrentID string) (*domain.TorrentMetadata, error)
 	TestAPI() (bool, error)
 }
 
@@ -37,7 +38,7 @@ func NewClient(url string, apiKey string) REDClient {
 
 	c := &Client{
 		APIKey:      apiKey,
-		client:      http.DefaultClient,
+		client:      &http.Client{Timeout: time.Second * 30},
 		URL:         url,
 		RateLimiter: rate.NewLimiter(rate.Every(10*time.Second), 10),
 	}
@@ -45,6 +46,16 @@ func NewClient(url string, apiKey string) REDClient {
 	return c
 }
 
+// SetRateLimit allow max requests per period, with a burst of max
+func (c *Client) SetRateLimit(max int, per time.Duration) {
+	c.RateLimiter = rate.NewLimiter(rate.Every(per/time.Duration(max)), max)
+}
+
+// SetHTTPClient replace the http client used for api requests, eg. to go through a proxy
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
 type TorrentDetailsResponse struct {
 	Status   string `json:"status"`
 	Response struct {
@@ -116,8 +127,7 @@ type Torrent struct {
 }
 
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	ctx := context.Background()
-	err := c.RateLimiter.Wait(ctx) // This is a blocking call. Honors the rate limit
+	err := c.RateLimiter.Wait(req.Context()) // This is a blocking call. Honors the rate limit
 	if err != nil {
 		return nil, err
 	}
@@ -128,8 +138,8 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
-func (c *Client) get(url string) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
 		log.Error().Err(err).Msgf("red client request error : %v", url)
 		return nil, err
@@ -158,6 +168,49 @@ func (c *Client) get(url string) (*http.Response, error) {
 }
 
 func (c *Client) GetTorrentByID(torrentID string) (*domain.TorrentBasic, error) {
+	r, err := c.getTorrent(context.Background(), torrentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TorrentBasic{
+		Id:       strconv.Itoa(r.Response.Torrent.Id),
+		InfoHash: r.Response.Torrent.InfoHash,
+		Size:     strconv.Itoa(r.Response.Torrent.Size),
+	}, nil
+
+}
+
+// GetTorrentMetadata get torrent details used to enrich releases
+func (c *Client) GetTorrentMetadata(ctx context.Context, torrentID string) (*domain.TorrentMetadata, error) {
+	r, err := c.getTorrent(ctx, torrentID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := r.Response.Torrent
+
+	m := &domain.TorrentMetadata{
+		Size:     uint64(t.Size),
+		Format:   t.Format,
+		Quality:  t.Encoding,
+		Media:    t.Media,
+		LogScore: t.LogScore,
+		HasLog:   t.HasLog,
+		HasCue:   t.HasCue,
+		IsScene:  t.Scene,
+		Uploader: t.Username,
+	}
+
+	if t.FreeTorrent {
+		m.Freeleech = true
+		m.FreeleechPercent = 100
+	}
+
+	return m, nil
+}
+
+func (c *Client) getTorrent(ctx context.Context, torrentID string) (*TorrentDetailsResponse, error) {
 	if torrentID == "" {
 		return nil, fmt.Errorf("red client: must have torrentID")
 	}
@@ -170,11 +223,16 @@ func (c *Client) GetTorrentByID(torrentID string) (*domain.TorrentBasic, error)
 
 	url := fmt.Sprintf("%v?action=torrent&%v", c.URL, params)
 
-	resp, err := c.get(url)
+	resp, err := c.get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
+	// forbidden returns no response
+	if resp == nil {
+		return nil, fmt.Errorf("red client: could not get torrent: %v", torrentID)
+	}
+
 	defer resp.Body.Close()
 
 	body, readErr := ioutil.ReadAll(resp.Body)
@@ -187,17 +245,12 @@ func (c *Client) GetTorrentByID(torrentID string) (*domain.TorrentBasic, error)
 		return nil, err
 	}
 
-	return &domain.TorrentBasic{
-		Id:       strconv.Itoa(r.Response.Torrent.Id),
-		InfoHash: r.Response.Torrent.InfoHash,
-		Size:     strconv.Itoa(r.Response.Torrent.Size),
-	}, nil
-
+	return &r, nil
 }
 
 // TestAPI try api access against torrents page
 func (c *Client) TestAPI() (bool, error) {
-	resp, err := c.get(c.URL + "?action=index")
+	resp, err := c.get(context.Background(), c.URL+"?action=index")
 	if err != nil {
 		return false, err
 	}
@@ -1,6 +1,7 @@
 package red
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -107,3 +108,42 @@ func TestREDClient_GetTorrentByID(t *testing.T) {
 		})
 	}
 }
+
+func TestREDClient_GetTorrentMetadata(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	key := "mock-key"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// request validation logic
+		apiKey := r.Header.Get("Authorization")
+		if apiKey != key {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(nil)
+			return
+		}
+
+		// read json response
+		jsonPayload, _ := ioutil.ReadFile("testdata/get_torrent_by_id.json")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonPayload)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, key)
+
+	got, err := c.GetTorrentMetadata(context.Background(), "29991962")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.TorrentMetadata{
+		Size:     527749302,
+		Format:   "FLAC",
+		Quality:  "Lossless",
+		Media:    "CD",
+		LogScore: 0,
+		HasLog:   false,
+		HasCue:   false,
+		IsScene:  true,
+	}, got)
+}
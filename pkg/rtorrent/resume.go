@@ -0,0 +1,89 @@
+package rtorrent
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+type resumeFile struct {
+	Priority  int   `bencode:"priority"`
+	Mtime     int64 `bencode:"mtime"`
+	Completed int   `bencode:"completed"`
+}
+
+type resumeData struct {
+	Bitfield int          `bencode:"bitfield"`
+	Files    []resumeFile `bencode:"files"`
+}
+
+// AddFastResume add libtorrent_resume data to a torrent so rtorrent skips hashing files already in dir.
+// The files must be complete, for multi file torrents they are expected in dir/name like rtorrent stores them.
+func AddFastResume(data []byte, dir string) ([]byte, error) {
+	mi, err := metainfo.Load(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not load torrent: %w", err)
+	}
+
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return nil, fmt.Errorf("could not read torrent info: %w", err)
+	}
+
+	base := filepath.Join(dir, info.Name)
+
+	resume := resumeData{Bitfield: info.NumPieces()}
+
+	var offset int64
+	for _, f := range info.UpvertedFiles() {
+		p := base
+		if len(f.Path) > 0 {
+			p = filepath.Join(append([]string{base}, f.Path...)...)
+		}
+
+		stat, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not find file for fast resume: %w", err)
+		}
+
+		if stat.Size() != f.Length {
+			return nil, fmt.Errorf("file size mismatch for fast resume: %v", p)
+		}
+
+		resume.Files = append(resume.Files, resumeFile{
+			Priority:  1,
+			Mtime:     stat.ModTime().Unix(),
+			Completed: filePieces(offset, f.Length, info.PieceLength),
+		})
+
+		offset += f.Length
+	}
+
+	// keep every other key as is so the info hash does not change
+	var torrent map[string]bencode.Bytes
+	if err := bencode.Unmarshal(data, &torrent); err != nil {
+		return nil, fmt.Errorf("could not decode torrent: %w", err)
+	}
+
+	resumeBytes, err := bencode.Marshal(resume)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent["libtorrent_resume"] = resumeBytes
+
+	return bencode.Marshal(torrent)
+}
+
+// filePieces number of pieces a file at offset touches
+func filePieces(offset, length, pieceLength int64) int {
+	if length == 0 || pieceLength == 0 {
+		return 0
+	}
+
+	return int((offset+length-1)/pieceLength - offset/pieceLength + 1)
+}
@@ -0,0 +1,206 @@
+package rtorrent
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Config struct {
+	// Hostname of the web server with the xml-rpc endpoint, with its path when it is not /RPC2
+	// eg. host or host/rutorrent/plugins/httprpc/action.php. A full url is used as is.
+	Hostname      string
+	Port          uint
+	SSL           bool
+	TLSSkipVerify bool
+
+	// basic auth username and password
+	Username string
+	Password string
+}
+
+type Client interface {
+	Test() (string, error)
+	AddTorrent(data []byte, opts AddOptions) error
+	AddMagnet(magnetURI string, opts AddOptions) error
+}
+
+type client struct {
+	config Config
+	url    string
+	http   *http.Client
+}
+
+func New(config Config) Client {
+	httpClient := &http.Client{
+		Timeout: time.Second * 30,
+	}
+
+	if config.TLSSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+		httpClient.Transport = transport
+	}
+
+	return &client{
+		config: config,
+		url:    buildURL(config),
+		http:   httpClient,
+	}
+}
+
+// buildURL the xml-rpc endpoint from the host, port and ssl like the other clients, /RPC2 unless the host has a path
+func buildURL(config Config) string {
+	if strings.Contains(config.Hostname, "://") {
+		return config.Hostname
+	}
+
+	protocol := "http"
+	if config.SSL {
+		protocol = "https"
+	}
+
+	host, path := config.Hostname, "/RPC2"
+	if i := strings.Index(host, "/"); i >= 0 {
+		host, path = host[:i], host[i:]
+	}
+
+	if config.Port > 0 {
+		host = fmt.Sprintf("%v:%v", host, config.Port)
+	}
+
+	return fmt.Sprintf("%v://%v%v", protocol, host, path)
+}
+
+type AddOptions struct {
+	// Label stored in d.custom1 like ruTorrent does
+	Label string
+	// Directory target directory for the download
+	Directory string
+	// Paused add the torrent without starting it
+	Paused bool
+	// Priority 0 off, 1 low, 2 normal, 3 high. Zero value leaves the rtorrent default
+	Priority int
+}
+
+// commands rtorrent commands run on the new item when it is loaded
+func (o AddOptions) commands() []interface{} {
+	var commands []interface{}
+
+	if o.Label != "" {
+		// ruTorrent stores labels url encoded
+		commands = append(commands, fmt.Sprintf(`d.custom1.set="%v"`, url.PathEscape(o.Label)))
+	}
+
+	if o.Directory != "" {
+		commands = append(commands, fmt.Sprintf(`d.directory.set="%v"`, quote(o.Directory)))
+	}
+
+	if o.Priority > 0 {
+		commands = append(commands, fmt.Sprintf(`d.priority.set=%d`, o.Priority))
+	}
+
+	return commands
+}
+
+// quote escape a value for a double quoted command argument, backslashes first so the added ones stay as they are
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// Test get the rtorrent client version
+func (c *client) Test() (string, error) {
+	version, err := c.call("system.client_version")
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("rtorrent client could not get version")
+		return "", err
+	}
+
+	return version, nil
+}
+
+func (c *client) AddTorrent(data []byte, opts AddOptions) error {
+	method := "load.raw_start"
+	if opts.Paused {
+		method = "load.raw"
+	}
+
+	// first param is the target, always empty
+	params := append([]interface{}{"", data}, opts.commands()...)
+
+	if _, err := c.call(method, params...); err != nil {
+		log.Error().Stack().Err(err).Msg("rtorrent client could not add torrent")
+		return err
+	}
+
+	return nil
+}
+
+func (c *client) AddMagnet(magnetURI string, opts AddOptions) error {
+	method := "load.start"
+	if opts.Paused {
+		method = "load.normal"
+	}
+
+	params := append([]interface{}{"", magnetURI}, opts.commands()...)
+
+	if _, err := c.call(method, params...); err != nil {
+		log.Error().Stack().Err(err).Msg("rtorrent client could not add magnet")
+		return err
+	}
+
+	return nil
+}
+
+func (c *client) call(method string, params ...interface{}) (string, error) {
+	body, err := encodeCall(method, params...)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Msgf("rtorrent client request error: %v", c.url)
+		return "", err
+	}
+
+	if c.config.Username != "" || c.config.Password != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	req.Header.Set("Content-Type", "text/xml")
+	req.Header.Set("User-Agent", "autobrr")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msgf("rtorrent client request error: %v", c.url)
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return "", errors.New("unauthorized: bad credentials")
+	} else if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("rtorrent: unexpected status code: %v", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Error().Err(err).Msgf("rtorrent client error reading body: %v", c.url)
+		return "", err
+	}
+
+	return decodeResponse(data)
+}
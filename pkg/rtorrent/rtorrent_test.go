@@ -0,0 +1,128 @@
+package rtorrent
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_client_AddTorrent(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	var body string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><methodResponse><params><param><value><i8>0</i8></value></param></params></methodResponse>`))
+	}))
+	defer ts.Close()
+
+	c := New(Config{Hostname: ts.URL + "/RPC2", Username: "user", Password: "pass"})
+
+	err := c.AddTorrent([]byte("d4:infod4:name4:mockee"), AddOptions{Label: "tv shows", Directory: "/data/tv", Paused: true, Priority: 3})
+	assert.NoError(t, err)
+
+	assert.Contains(t, body, "<methodName>load.raw</methodName>")
+	assert.Contains(t, body, "<base64>ZDQ6aW5mb2Q0Om5hbWU0Om1vY2tlZQ==</base64>")
+	assert.Contains(t, body, `<string>d.custom1.set=&#34;tv%20shows&#34;</string>`)
+	assert.Contains(t, body, `<string>d.directory.set=&#34;/data/tv&#34;</string>`)
+	assert.Contains(t, body, `<string>d.priority.set=3</string>`)
+
+	c = New(Config{Hostname: ts.URL + "/RPC2"})
+	assert.Error(t, c.AddMagnet("magnet:?xt=urn:btih:abc", AddOptions{}))
+}
+
+func Test_buildURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{name: "host_port", config: Config{Hostname: "localhost", Port: 8080}, want: "http://localhost:8080/RPC2"},
+		{name: "ssl", config: Config{Hostname: "seedbox.example.com", Port: 443, SSL: true}, want: "https://seedbox.example.com:443/RPC2"},
+		{name: "path", config: Config{Hostname: "seedbox.example.com/rutorrent/plugins/httprpc/action.php", SSL: true}, want: "https://seedbox.example.com/rutorrent/plugins/httprpc/action.php"},
+		{name: "full_url", config: Config{Hostname: "https://seedbox.example.com/RPC2", Port: 8080}, want: "https://seedbox.example.com/RPC2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildURL(tt.config))
+		})
+	}
+}
+
+func Test_quote(t *testing.T) {
+	assert.Equal(t, `/data/tv`, quote(`/data/tv`))
+	assert.Equal(t, `/data/\"tv\"`, quote(`/data/"tv"`))
+	assert.Equal(t, `D:\\tv\\`, quote(`D:\tv\`))
+}
+
+func Test_decodeResponse(t *testing.T) {
+	got, err := decodeResponse([]byte(`<methodResponse><params><param><value><string>0.9.8</string></value></param></params></methodResponse>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9.8", got)
+
+	got, err = decodeResponse([]byte(`<methodResponse><params><param><value>0.9.6</value></param></params></methodResponse>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9.6", got)
+
+	_, err = decodeResponse([]byte(`<methodResponse><fault><value><struct><member><name>faultCode</name><value><i4>-506</i4></value></member><member><name>faultString</name><value><string>Method 'foo' not defined</string></value></member></struct></value></fault></methodResponse>`))
+	assert.EqualError(t, err, "xmlrpc: fault -506: Method 'foo' not defined")
+}
+
+func TestAddFastResume(t *testing.T) {
+	dir := t.TempDir()
+
+	root := filepath.Join(dir, "Mock.Release")
+	assert.NoError(t, os.MkdirAll(root, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a.mkv"), bytes.Repeat([]byte("a"), 40000), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b.nfo"), bytes.Repeat([]byte("b"), 100), 0644))
+
+	info := metainfo.Info{PieceLength: 16384}
+	assert.NoError(t, info.BuildFromFilePath(root))
+
+	infoBytes, err := bencode.Marshal(info)
+	assert.NoError(t, err)
+
+	mi := metainfo.MetaInfo{InfoBytes: infoBytes, Announce: "https://tracker.example.test/announce"}
+
+	var b bytes.Buffer
+	assert.NoError(t, mi.Write(&b))
+
+	got, err := AddFastResume(b.Bytes(), dir)
+	assert.NoError(t, err)
+
+	resumed, err := metainfo.Load(bytes.NewReader(got))
+	assert.NoError(t, err)
+	assert.Equal(t, mi.HashInfoBytes(), resumed.HashInfoBytes())
+
+	var torrent struct {
+		Resume resumeData `bencode:"libtorrent_resume"`
+	}
+	assert.NoError(t, bencode.Unmarshal(got, &torrent))
+	assert.Equal(t, 3, torrent.Resume.Bitfield)
+	assert.Len(t, torrent.Resume.Files, 2)
+	assert.Equal(t, 3, torrent.Resume.Files[0].Completed)
+	assert.Equal(t, 1, torrent.Resume.Files[1].Completed)
+
+	// missing files
+	_, err = AddFastResume(b.Bytes(), t.TempDir())
+	assert.Error(t, err)
+}
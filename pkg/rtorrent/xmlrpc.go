@@ -0,0 +1,109 @@
+package rtorrent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// encodeCall build a xml-rpc method call. Only the types rtorrent needs for adding torrents are supported.
+func encodeCall(method string, params ...interface{}) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString(`<?xml version="1.0"?><methodCall><methodName>`)
+	if err := xml.EscapeText(&b, []byte(method)); err != nil {
+		return nil, err
+	}
+	b.WriteString(`</methodName><params>`)
+
+	for _, p := range params {
+		b.WriteString(`<param><value>`)
+
+		switch v := p.(type) {
+		case string:
+			b.WriteString(`<string>`)
+			if err := xml.EscapeText(&b, []byte(v)); err != nil {
+				return nil, err
+			}
+			b.WriteString(`</string>`)
+		case int:
+			b.WriteString(`<i4>` + strconv.Itoa(v) + `</i4>`)
+		case []byte:
+			b.WriteString(`<base64>` + base64.StdEncoding.EncodeToString(v) + `</base64>`)
+		default:
+			return nil, fmt.Errorf("xmlrpc: unsupported param type %T", p)
+		}
+
+		b.WriteString(`</value></param>`)
+	}
+
+	b.WriteString(`</params></methodCall>`)
+
+	return b.Bytes(), nil
+}
+
+type xmlValue struct {
+	String  *string     `xml:"string"`
+	Int     *string     `xml:"int"`
+	I4      *string     `xml:"i4"`
+	I8      *string     `xml:"i8"`
+	Members []xmlMember `xml:"struct>member"`
+	Text    string      `xml:",chardata"`
+}
+
+type xmlMember struct {
+	Name  string   `xml:"name"`
+	Value xmlValue `xml:"value"`
+}
+
+type methodResponse struct {
+	Params []xmlValue `xml:"params>param>value"`
+	Fault  *xmlValue  `xml:"fault>value"`
+}
+
+// string value as text regardless of type
+func (v xmlValue) string() string {
+	switch {
+	case v.String != nil:
+		return *v.String
+	case v.Int != nil:
+		return *v.Int
+	case v.I4 != nil:
+		return *v.I4
+	case v.I8 != nil:
+		return *v.I8
+	}
+
+	return strings.TrimSpace(v.Text)
+}
+
+// decodeResponse return the first response value as string, or the fault as error
+func decodeResponse(data []byte) (string, error) {
+	var res methodResponse
+	if err := xml.Unmarshal(data, &res); err != nil {
+		return "", fmt.Errorf("xmlrpc: could not decode response: %w", err)
+	}
+
+	if res.Fault != nil {
+		var code, msg string
+		for _, m := range res.Fault.Members {
+			switch m.Name {
+			case "faultCode":
+				code = m.Value.string()
+			case "faultString":
+				msg = m.Value.string()
+			}
+		}
+
+		return "", fmt.Errorf("xmlrpc: fault %v: %v", code, msg)
+	}
+
+	if len(res.Params) == 0 {
+		return "", nil
+	}
+
+	return res.Params[0].string(), nil
+}
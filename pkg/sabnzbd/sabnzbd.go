@@ -0,0 +1,167 @@
+package sabnzbd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Config struct {
+	// Hostname base url, eg. http://localhost:8080/sabnzbd
+	Hostname      string
+	APIKey        string
+	TLSSkipVerify bool
+
+	// basic auth username and password
+	BasicAuth bool
+	Username  string
+	Password  string
+}
+
+type Client interface {
+	Version() (string, error)
+	AddFromURL(nzbURL string, opts AddOptions) ([]string, error)
+}
+
+type client struct {
+	config Config
+	http   *http.Client
+}
+
+func New(config Config) Client {
+	httpClient := &http.Client{
+		Timeout: time.Second * 30,
+	}
+
+	if config.TLSSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+		httpClient.Transport = transport
+	}
+
+	return &client{
+		config: config,
+		http:   httpClient,
+	}
+}
+
+type AddOptions struct {
+	Name     string
+	Category string
+	Paused   bool
+}
+
+type apiResponse struct {
+	Status  bool     `json:"status"`
+	Error   string   `json:"error"`
+	NzoIDs  []string `json:"nzo_ids"`
+	Version string   `json:"version"`
+}
+
+func (c *client) Version() (string, error) {
+	res, err := c.get(url.Values{"mode": {"version"}})
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("sabnzbd client get version error")
+		return "", err
+	}
+
+	return res.Version, nil
+}
+
+// AddFromURL let sabnzbd fetch the nzb and return the new queue ids
+func (c *client) AddFromURL(nzbURL string, opts AddOptions) ([]string, error) {
+	params := url.Values{
+		"mode": {"addurl"},
+		"name": {nzbURL},
+	}
+
+	if opts.Name != "" {
+		params.Set("nzbname", opts.Name)
+	}
+	if opts.Category != "" {
+		params.Set("cat", opts.Category)
+	}
+	if opts.Paused {
+		// -2 is the paused priority
+		params.Set("priority", "-2")
+	}
+
+	res, err := c.get(params)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("sabnzbd client add url error")
+		return nil, err
+	}
+
+	if !res.Status {
+		return nil, fmt.Errorf("sabnzbd: could not add nzb: %v", res.Error)
+	}
+
+	return res.NzoIDs, nil
+}
+
+func (c *client) get(params url.Values) (*apiResponse, error) {
+	u, err := url.Parse(c.config.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, "/api")
+
+	params.Set("apikey", c.config.APIKey)
+	params.Set("output", "json")
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		log.Error().Err(err).Msgf("sabnzbd client request error: %v", u.Host)
+		return nil, err
+	}
+
+	if c.config.BasicAuth {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msgf("sabnzbd client request error: %v", u.Host)
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, errors.New("unauthorized: bad credentials")
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sabnzbd: unexpected status code: %v", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("sabnzbd client error reading body")
+		return nil, err
+	}
+
+	var apiRes apiResponse
+	if err := json.Unmarshal(body, &apiRes); err != nil {
+		log.Error().Err(err).Msg("sabnzbd client error unmarshal body")
+		return nil, err
+	}
+
+	// a wrong api key is returned as an error with status 200
+	if apiRes.Error != "" {
+		return nil, fmt.Errorf("sabnzbd: %v", apiRes.Error)
+	}
+
+	return &apiRes, nil
+}
@@ -0,0 +1,57 @@
+package sabnzbd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_client_AddFromURL(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		if r.URL.Path != "/sabnzbd/api" || q.Get("output") != "json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if q.Get("apikey") != "mock-key" {
+			w.Write([]byte(`{"status": false, "error": "API Key Incorrect"}`))
+			return
+		}
+
+		switch q.Get("mode") {
+		case "version":
+			w.Write([]byte(`{"version": "3.5.0"}`))
+		case "addurl":
+			if q.Get("name") != "https://indexer.example.test/getnzb/1" || q.Get("cat") != "tv" || q.Get("nzbname") != "Mock.S01E01.1080p" {
+				w.Write([]byte(`{"status": false, "error": "bad params"}`))
+				return
+			}
+			w.Write([]byte(`{"status": true, "nzo_ids": ["SABnzbd_nzo_mock"]}`))
+		}
+	}))
+	defer ts.Close()
+
+	c := New(Config{Hostname: ts.URL + "/sabnzbd", APIKey: "mock-key"})
+
+	version, err := c.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, "3.5.0", version)
+
+	ids, err := c.AddFromURL("https://indexer.example.test/getnzb/1", AddOptions{Name: "Mock.S01E01.1080p", Category: "tv"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SABnzbd_nzo_mock"}, ids)
+
+	_, err = c.AddFromURL("https://indexer.example.test/getnzb/1", AddOptions{Category: "movies"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Hostname: ts.URL + "/sabnzbd", APIKey: "wrong"}).Version()
+	assert.EqualError(t, err, "sabnzbd: API Key Incorrect")
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -78,8 +81,12 @@ func (c *client) post(endpoint string, data interface{}) (*http.Response, error)
 		log.Error().Err(err).Msgf("sonarr client bad request: %v", reqUrl)
 		return nil, errors.New("unauthorized: bad credentials")
 	} else if res.StatusCode != http.StatusOK {
-		log.Error().Err(err).Msgf("sonarr client request error: %v", reqUrl)
-		return nil, errors.New("sonarr: bad request")
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		log.Error().Msgf("sonarr client request error: %v status: %v body: %s", reqUrl, res.StatusCode, body)
+		return nil, fmt.Errorf("sonarr: bad request: status %v: %s", res.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	// return raw response and let the caller handle json unmarshal of body
@@ -2,6 +2,7 @@ package sonarr
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -23,6 +24,7 @@ type Config struct {
 type Client interface {
 	Test() (*SystemStatusResponse, error)
 	Push(release Release) ([]string, error)
+	PushRelease(release Release) (*PushResponse, error)
 }
 
 type client struct {
@@ -53,13 +55,22 @@ type Release struct {
 	DownloadProtocol string `json:"downloadProtocol"`
 	Protocol         string `json:"protocol"`
 	PublishDate      string `json:"publishDate"`
+
+	// hints for season packs and episodes, sonarr parses the title as well
+	FullSeason     bool  `json:"fullSeason,omitempty"`
+	SeasonNumber   int   `json:"seasonNumber,omitempty"`
+	EpisodeNumbers []int `json:"episodeNumbers,omitempty"`
 }
 
 type PushResponse struct {
-	Approved     bool     `json:"approved"`
-	Rejected     bool     `json:"rejected"`
-	TempRejected bool     `json:"temporarilyRejected"`
-	Rejections   []string `json:"rejections"`
+	Approved       bool     `json:"approved"`
+	Rejected       bool     `json:"rejected"`
+	TempRejected   bool     `json:"temporarilyRejected"`
+	Rejections     []string `json:"rejections"`
+	SeriesTitle    string   `json:"seriesTitle"`
+	FullSeason     bool     `json:"fullSeason"`
+	SeasonNumber   int      `json:"seasonNumber"`
+	EpisodeNumbers []int    `json:"episodeNumbers"`
 }
 
 type SystemStatusResponse struct {
@@ -94,6 +105,20 @@ func (c *client) Test() (*SystemStatusResponse, error) {
 }
 
 func (c *client) Push(release Release) ([]string, error) {
+	pushResponse, err := c.PushRelease(release)
+	if err != nil {
+		return nil, err
+	}
+
+	if pushResponse == nil || !pushResponse.Rejected {
+		return nil, nil
+	}
+
+	return pushResponse.Rejections, nil
+}
+
+// PushRelease push a release and return what sonarr made of it
+func (c *client) PushRelease(release Release) (*PushResponse, error) {
 	res, err := c.post("release/push", release)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("sonarr client post error")
@@ -121,14 +146,16 @@ func (c *client) Push(release Release) ([]string, error) {
 
 	log.Trace().Msgf("sonarr release/push response body: %+v", string(body))
 
-	// log and return if rejected
+	if len(pushResponse) == 0 {
+		return nil, errors.New("sonarr: empty push response")
+	}
+
+	// log if rejected
 	if pushResponse[0].Rejected {
 		rejections := strings.Join(pushResponse[0].Rejections, ", ")
 
 		log.Trace().Msgf("sonarr push rejected: %s - reasons: %q", release.Title, rejections)
-		return pushResponse[0].Rejections, nil
 	}
 
-	// successful push
-	return nil, nil
+	return &pushResponse[0], nil
 }
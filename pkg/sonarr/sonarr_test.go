@@ -185,3 +185,58 @@ func Test_client_Test(t *testing.T) {
 		})
 	}
 }
+
+func Test_client_PushRelease(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api/v3/release/push", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") == "bad-request" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`[{"propertyName":"Title","errorMessage":"'Title' must not be empty."}]`))
+			return
+		}
+
+		jsonPayload, _ := ioutil.ReadFile("testdata/release_push_response.json")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonPayload)
+	})
+
+	release := Release{
+		Title:            "That Show S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-NOGROUP",
+		DownloadUrl:      "https://www.test.org/rss/download/0000001/00000000000000000000/That Show S01 2160p ATVP WEB-DL DDP 5.1 Atmos DV HEVC-NOGROUP.torrent",
+		Indexer:          "test",
+		DownloadProtocol: "torrent",
+		Protocol:         "torrent",
+		PublishDate:      "2021-08-21T15:36:00Z",
+		FullSeason:       true,
+		SeasonNumber:     1,
+	}
+
+	t.Run("season_pack", func(t *testing.T) {
+		c := New(Config{Hostname: ts.URL})
+
+		got, err := c.PushRelease(release)
+		assert.NoError(t, err)
+		assert.Equal(t, &PushResponse{
+			Rejected:       true,
+			Rejections:     []string{"Unknown Series"},
+			SeriesTitle:    "That Show",
+			FullSeason:     true,
+			SeasonNumber:   1,
+			EpisodeNumbers: []int{},
+		}, got)
+	})
+
+	t.Run("bad_request", func(t *testing.T) {
+		c := New(Config{Hostname: ts.URL, APIKey: "bad-request"})
+
+		_, err := c.PushRelease(release)
+		assert.EqualError(t, err, `sonarr: bad request: status 400: [{"propertyName":"Title","errorMessage":"'Title' must not be empty."}]`)
+	})
+}
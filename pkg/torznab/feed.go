@@ -0,0 +1,111 @@
+package torznab
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+type Response struct {
+	Channel struct {
+		Items []FeedItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type FeedItem struct {
+	Title     string `xml:"title,omitempty"`
+	GUID      string `xml:"guid,omitempty"`
+	PubDate   Time   `xml:"pubDate,omitempty"`
+	Comments  string `xml:"comments"`
+	Size      string `xml:"size"`
+	Link      string `xml:"link"`
+	Category  []int  `xml:"category,omitempty"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length string `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+
+	// attributes
+	Attributes []ItemAttr `xml:"attr"`
+}
+
+type ItemAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Attr get torznab attribute value by name
+func (f FeedItem) Attr(name string) string {
+	for _, attr := range f.Attributes {
+		if attr.Name == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// DownloadURL get link and fall back to enclosure url, then magneturl attr
+func (f FeedItem) DownloadURL() string {
+	if f.Link != "" {
+		return f.Link
+	}
+
+	if f.Enclosure.URL != "" {
+		return f.Enclosure.URL
+	}
+
+	return f.Attr("magneturl")
+}
+
+// SizeBytes get size from size field, torznab attr or enclosure length
+func (f FeedItem) SizeBytes() uint64 {
+	for _, v := range []string{f.Size, f.Attr("size"), f.Enclosure.Length} {
+		if v == "" {
+			continue
+		}
+
+		size, err := strconv.ParseUint(v, 10, 64)
+		if err == nil && size > 0 {
+			return size
+		}
+	}
+
+	return 0
+}
+
+// Time credits: https://github.com/mrobinsn/go-newznab/blob/cd89d9c56447859fa1298dc9a0053c92c45ac7ef/newznab/structs.go#L150
+type Time struct {
+	time.Time
+}
+
+func (t *Time) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(xml.CharData([]byte(t.UTC().Format(time.RFC1123Z)))); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(xml.EndElement{Name: start.Name}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw string
+
+	err := d.DecodeElement(&raw, &start)
+	if err != nil {
+		return err
+	}
+	date, err := time.Parse(time.RFC1123Z, raw)
+
+	if err != nil {
+		return err
+	}
+
+	*t = Time{date}
+	return nil
+}
@@ -0,0 +1,117 @@
+package torznab
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type Client interface {
+	GetFeed() ([]FeedItem, error)
+}
+
+type client struct {
+	http *http.Client
+
+	Host   string
+	ApiKey string
+
+	UseBasicAuth bool
+	BasicAuth    BasicAuth
+}
+
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// NewClient create new torznab client
+func NewClient(url string, apiKey string) Client {
+	httpClient := &http.Client{
+		Timeout: time.Second * 20,
+	}
+
+	c := &client{
+		http:   httpClient,
+		Host:   url,
+		ApiKey: apiKey,
+	}
+
+	return c
+}
+
+func (c *client) get(params url.Values) (int, *Response, error) {
+	u, err := url.Parse(c.Host)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not parse url")
+	}
+
+	// keep any query params already part of the feed url
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not build request")
+	}
+
+	if c.UseBasicAuth {
+		req.SetBasicAuth(c.BasicAuth.Username, c.BasicAuth.Password)
+	}
+
+	if c.ApiKey != "" {
+		req.Header.Add("X-API-Key", c.ApiKey)
+	}
+
+	req.Header.Set("User-Agent", "autobrr")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not make request")
+	}
+
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err = io.Copy(&buf, resp.Body); err != nil {
+		return resp.StatusCode, nil, errors.Wrap(err, "torznab.io.Copy")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil, errors.Errorf("unexpected status code: %v", resp.StatusCode)
+	}
+
+	var response Response
+	if err := xml.Unmarshal(buf.Bytes(), &response); err != nil {
+		return resp.StatusCode, nil, errors.Wrap(err, "torznab: could not decode feed")
+	}
+
+	return resp.StatusCode, &response, nil
+}
+
+// GetFeed fetch latest items from torznab feed
+func (c *client) GetFeed() ([]FeedItem, error) {
+	params := url.Values{}
+	params.Set("t", "search")
+
+	if c.ApiKey != "" {
+		params.Set("apikey", c.ApiKey)
+	}
+
+	status, res, err := c.get(params)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("torznab client could not get feed: %v", status)
+		return nil, err
+	}
+
+	return res.Channel.Items, nil
+}
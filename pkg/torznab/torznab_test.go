@@ -0,0 +1,82 @@
+package torznab
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rs/zerolog"
+)
+
+func TestClient_GetFeed(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	key := "mock-key"
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		// request validation logic
+		if r.URL.Query().Get("apikey") != key {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(nil)
+			return
+		}
+
+		payload, _ := ioutil.ReadFile("testdata/torznab_response.xml")
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.WriteHeader(http.StatusOK)
+		w.Write(payload)
+	})
+
+	tests := []struct {
+		name      string
+		url       string
+		apiKey    string
+		wantItems int
+		wantErr   bool
+	}{
+		{
+			name:      "get feed",
+			url:       ts.URL + "/api",
+			apiKey:    key,
+			wantItems: 2,
+			wantErr:   false,
+		},
+		{
+			name:      "bad api key",
+			url:       ts.URL + "/api",
+			apiKey:    "bad-key",
+			wantItems: 0,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.url, tt.apiKey)
+
+			got, err := c.GetFeed()
+			if tt.wantErr && assert.Error(t, err) {
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, got, tt.wantItems)
+
+			assert.Equal(t, "That Show S01E01 1080p WEB H264-GROUP", got[0].Title)
+			assert.Equal(t, uint64(1520435200), got[0].SizeBytes())
+			assert.Equal(t, "0", got[0].Attr("downloadvolumefactor"))
+			assert.Equal(t, []int{5000, 5040}, got[0].Category)
+
+			// falls back to enclosure when link and size are missing
+			assert.Equal(t, "http://localhost/download/1002.torrent?passkey=abc", got[1].DownloadURL())
+			assert.Equal(t, uint64(20971520000), got[1].SizeBytes())
+		})
+	}
+}
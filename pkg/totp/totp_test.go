@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret the SHA-1 key "12345678901234567890" from the RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	assert.True(t, Validate(rfcSecret, "005924", now))
+	assert.True(t, Validate(rfcSecret, "005 924", now.Add(Period*time.Second)))
+	assert.False(t, Validate(rfcSecret, "005924", now.Add(3*Period*time.Second)))
+	assert.False(t, Validate(rfcSecret, "123456", now))
+	assert.False(t, Validate(rfcSecret, "5924", now))
+	assert.False(t, Validate("not base32!", "005924", now))
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := Code(secret, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, Validate(secret, code, time.Now()))
+
+	assert.Equal(t, "otpauth://totp/autobrr:admin?algorithm=SHA1&digits=6&issuer=autobrr&period=30&secret="+secret, URI("autobrr", "admin", secret))
+}
@@ -0,0 +1,129 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+const sessionIDHeader = "X-Transmission-Session-Id"
+
+var ErrUnauthorized = errors.New("unauthorized: bad credentials")
+
+type rpcRequest struct {
+	Method    string      `json:"method"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type rpcResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// call send a rpc request and decode the response arguments into out.
+// Transmission answers 409 with a new session id when it is missing or expired, the request is then sent again with it.
+func (c *client) call(method string, args interface{}, out interface{}) error {
+	body, err := json.Marshal(rpcRequest{Method: method, Arguments: args})
+	if err != nil {
+		log.Error().Err(err).Msgf("transmission client could not marshal request: %v", method)
+		return err
+	}
+
+	var res *http.Response
+
+	for attempt := 0; attempt < 2; attempt++ {
+		res, err = c.do(body)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusConflict {
+			break
+		}
+
+		res.Body.Close()
+
+		c.setSessionID(res.Header.Get(sessionIDHeader))
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	default:
+		return fmt.Errorf("transmission: unexpected status code: %v", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Error().Err(err).Msgf("transmission client error reading body: %v", method)
+		return err
+	}
+
+	var rpcRes rpcResponse
+	if err := json.Unmarshal(data, &rpcRes); err != nil {
+		log.Error().Err(err).Msgf("transmission client could not unmarshal response: %v", method)
+		return err
+	}
+
+	if rpcRes.Result != "success" {
+		return fmt.Errorf("transmission: %v: %v", method, rpcRes.Result)
+	}
+
+	if out != nil && len(rpcRes.Arguments) > 0 {
+		if err := json.Unmarshal(rpcRes.Arguments, out); err != nil {
+			log.Error().Err(err).Msgf("transmission client could not unmarshal arguments: %v", method)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *client) do(body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Msgf("transmission client request error: %v", c.url)
+		return nil, err
+	}
+
+	if c.config.Username != "" || c.config.Password != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "autobrr")
+
+	if sessionID := c.getSessionID(); sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msgf("transmission client request error: %v", c.url)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *client) getSessionID() string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.sessionID
+}
+
+func (c *client) setSessionID(sessionID string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.sessionID = sessionID
+}
@@ -0,0 +1,276 @@
+package transmission
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Config struct {
+	Hostname      string
+	Port          uint
+	SSL           bool
+	TLSSkipVerify bool
+
+	// rpc username and password, sent as basic auth
+	Username string
+	Password string
+}
+
+type Client interface {
+	Test() (*Session, error)
+	AddTorrent(args AddTorrentArgs) (*Torrent, error)
+	SetTorrent(args SetTorrentArgs) error
+	GetTorrents(ids []string) ([]Torrent, error)
+	FreeSpace(path string) (int64, error)
+	GetTrackerStats(hash string) ([]TrackerStat, error)
+	Reannounce(ids []string) error
+	RemoveTorrents(ids []string, deleteData bool) error
+}
+
+type client struct {
+	config Config
+	url    string
+	http   *http.Client
+
+	m         sync.RWMutex
+	sessionID string
+}
+
+func New(config Config) Client {
+	httpClient := &http.Client{
+		Timeout: time.Second * 30,
+	}
+
+	if config.TLSSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+		httpClient.Transport = transport
+	}
+
+	protocol := "http"
+	if config.SSL {
+		protocol = "https"
+	}
+
+	c := &client{
+		config: config,
+		url:    fmt.Sprintf("%v://%v:%v/transmission/rpc", protocol, config.Hostname, config.Port),
+		http:   httpClient,
+	}
+
+	return c
+}
+
+type Session struct {
+	Version     string `json:"version"`
+	RPCVersion  int    `json:"rpc-version"`
+	DownloadDir string `json:"download-dir"`
+}
+
+type TorrentStatus int
+
+// https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md#33-torrent-accessor-torrent-get
+const (
+	TorrentStatusStopped      TorrentStatus = 0
+	TorrentStatusCheckWait    TorrentStatus = 1
+	TorrentStatusCheck        TorrentStatus = 2
+	TorrentStatusDownloadWait TorrentStatus = 3
+	TorrentStatusDownload     TorrentStatus = 4
+	TorrentStatusSeedWait     TorrentStatus = 5
+	TorrentStatusSeed         TorrentStatus = 6
+)
+
+type Torrent struct {
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	HashString   string        `json:"hashString"`
+	Status       TorrentStatus `json:"status"`
+	RateDownload int64         `json:"rateDownload"`
+}
+
+// TrackerStat announce state of one tracker of a torrent
+type TrackerStat struct {
+	Host                  string `json:"host"`
+	HasAnnounced          bool   `json:"hasAnnounced"`
+	LastAnnounceSucceeded bool   `json:"lastAnnounceSucceeded"`
+	LastAnnounceResult    string `json:"lastAnnounceResult"`
+	SeederCount           int    `json:"seederCount"`
+	LeecherCount          int    `json:"leecherCount"`
+}
+
+// BandwidthPriority -1 low, 0 normal, 1 high
+type BandwidthPriority int
+
+const (
+	BandwidthPriorityLow    BandwidthPriority = -1
+	BandwidthPriorityNormal BandwidthPriority = 0
+	BandwidthPriorityHigh   BandwidthPriority = 1
+)
+
+type AddTorrentArgs struct {
+	// Filename url or magnet link, either this or MetaInfo must be set
+	Filename string `json:"filename,omitempty"`
+	// MetaInfo base64 encoded torrent file
+	MetaInfo          string            `json:"metainfo,omitempty"`
+	DownloadDir       string            `json:"download-dir,omitempty"`
+	Paused            bool              `json:"paused"`
+	BandwidthPriority BandwidthPriority `json:"bandwidthPriority"`
+}
+
+type SetTorrentArgs struct {
+	// IDs torrent ids or hashes
+	IDs             []string `json:"ids"`
+	Labels          []string `json:"labels,omitempty"`
+	DownloadLimit   int64    `json:"downloadLimit,omitempty"`
+	DownloadLimited *bool    `json:"downloadLimited,omitempty"`
+	UploadLimit     int64    `json:"uploadLimit,omitempty"`
+	UploadLimited   *bool    `json:"uploadLimited,omitempty"`
+}
+
+func (c *client) Test() (*Session, error) {
+	var session Session
+	if err := c.call("session-get", nil, &session); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client session-get error")
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (c *client) AddTorrent(args AddTorrentArgs) (*Torrent, error) {
+	if args.Filename == "" && args.MetaInfo == "" {
+		return nil, errors.New("transmission: filename or metainfo required")
+	}
+
+	var res struct {
+		Added     *Torrent `json:"torrent-added"`
+		Duplicate *Torrent `json:"torrent-duplicate"`
+	}
+
+	if err := c.call("torrent-add", args, &res); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-add error")
+		return nil, err
+	}
+
+	if res.Added != nil {
+		return res.Added, nil
+	}
+
+	if res.Duplicate != nil {
+		log.Debug().Msgf("transmission client torrent already added: %v", res.Duplicate.HashString)
+		return res.Duplicate, nil
+	}
+
+	return nil, errors.New("transmission: torrent-add returned no torrent")
+}
+
+func (c *client) SetTorrent(args SetTorrentArgs) error {
+	if err := c.call("torrent-set", args, nil); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-set error")
+		return err
+	}
+
+	return nil
+}
+
+// GetTorrents get torrents by id or hash, all torrents if ids is empty
+func (c *client) GetTorrents(ids []string) ([]Torrent, error) {
+	args := map[string]interface{}{
+		"fields": []string{"id", "name", "hashString", "status", "rateDownload"},
+	}
+	if len(ids) > 0 {
+		args["ids"] = ids
+	}
+
+	var res struct {
+		Torrents []Torrent `json:"torrents"`
+	}
+
+	if err := c.call("torrent-get", args, &res); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-get error")
+		return nil, err
+	}
+
+	return res.Torrents, nil
+}
+
+// FreeSpace free bytes at path, the default download dir if path is empty
+func (c *client) FreeSpace(path string) (int64, error) {
+	if path == "" {
+		session, err := c.Test()
+		if err != nil {
+			return 0, err
+		}
+
+		path = session.DownloadDir
+	}
+
+	var res struct {
+		Path      string `json:"path"`
+		SizeBytes int64  `json:"size-bytes"`
+	}
+
+	if err := c.call("free-space", map[string]string{"path": path}, &res); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client free-space error")
+		return 0, err
+	}
+
+	return res.SizeBytes, nil
+}
+
+// GetTrackerStats tracker announce stats for a torrent
+func (c *client) GetTrackerStats(hash string) ([]TrackerStat, error) {
+	args := map[string]interface{}{
+		"fields": []string{"hashString", "trackerStats"},
+		"ids":    []string{hash},
+	}
+
+	var res struct {
+		Torrents []struct {
+			HashString   string        `json:"hashString"`
+			TrackerStats []TrackerStat `json:"trackerStats"`
+		} `json:"torrents"`
+	}
+
+	if err := c.call("torrent-get", args, &res); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-get error")
+		return nil, err
+	}
+
+	if len(res.Torrents) == 0 {
+		return nil, fmt.Errorf("transmission: torrent not found: %v", hash)
+	}
+
+	return res.Torrents[0].TrackerStats, nil
+}
+
+// Reannounce ask the trackers for more peers now
+func (c *client) Reannounce(ids []string) error {
+	if err := c.call("torrent-reannounce", map[string][]string{"ids": ids}, nil); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-reannounce error")
+		return err
+	}
+
+	return nil
+}
+
+func (c *client) RemoveTorrents(ids []string, deleteData bool) error {
+	args := map[string]interface{}{
+		"ids":               ids,
+		"delete-local-data": deleteData,
+	}
+
+	if err := c.call("torrent-remove", args, nil); err != nil {
+		log.Error().Stack().Err(err).Msg("transmission client torrent-remove error")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package transmission
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockServer(t *testing.T, handler func(req map[string]interface{}) interface{}) (*httptest.Server, Config) {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transmission/rpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// first request of a session is always rejected with the id to use
+		if r.Header.Get(sessionIDHeader) != "mock-session" {
+			w.Header().Set(sessionIDHeader, "mock-session")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result":    "success",
+			"arguments": handler(req),
+		})
+	}))
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	return ts, Config{Hostname: u.Hostname(), Port: uint(port), Username: "admin", Password: "secret"}
+}
+
+func Test_client_Test(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	ts, cfg := mockServer(t, func(req map[string]interface{}) interface{} {
+		return map[string]interface{}{"version": "3.00", "rpc-version": 16}
+	})
+	defer ts.Close()
+
+	session, err := New(cfg).Test()
+	assert.NoError(t, err)
+	assert.Equal(t, &Session{Version: "3.00", RPCVersion: 16}, session)
+
+	cfg.Password = "wrong"
+	_, err = New(cfg).Test()
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func Test_client_AddTorrent(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	var got map[string]interface{}
+
+	ts, cfg := mockServer(t, func(req map[string]interface{}) interface{} {
+		got = req["arguments"].(map[string]interface{})
+
+		return map[string]interface{}{
+			"torrent-duplicate": map[string]interface{}{"id": 1, "name": "mock", "hashString": "abc123"},
+		}
+	})
+	defer ts.Close()
+
+	torrent, err := New(cfg).AddTorrent(AddTorrentArgs{
+		MetaInfo:          "ZDQ6bmFtZTQ6bW9ja2U=",
+		DownloadDir:       "/downloads",
+		Paused:            true,
+		BandwidthPriority: BandwidthPriorityHigh,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", torrent.HashString)
+
+	assert.Equal(t, "/downloads", got["download-dir"])
+	assert.Equal(t, true, got["paused"])
+	assert.Equal(t, float64(1), got["bandwidthPriority"])
+
+	_, err = New(cfg).AddTorrent(AddTorrentArgs{})
+	assert.Error(t, err)
+}
+
+func Test_client_FreeSpace(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	ts, cfg := mockServer(t, func(req map[string]interface{}) interface{} {
+		switch req["method"] {
+		case "session-get":
+			return map[string]interface{}{"version": "3.00", "rpc-version": 16, "download-dir": "/downloads"}
+		case "free-space":
+			args := req["arguments"].(map[string]interface{})
+			if args["path"] != "/downloads" {
+				return map[string]interface{}{"path": args["path"], "size-bytes": -1}
+			}
+			return map[string]interface{}{"path": "/downloads", "size-bytes": 1073741824}
+		}
+		return nil
+	})
+	defer ts.Close()
+
+	free, err := New(cfg).FreeSpace("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1073741824), free)
+}
+
+func Test_client_GetTrackerStats(t *testing.T) {
+	// disable logger
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	var reannounced interface{}
+
+	ts, cfg := mockServer(t, func(req map[string]interface{}) interface{} {
+		args := req["arguments"].(map[string]interface{})
+
+		switch req["method"] {
+		case "torrent-get":
+			return map[string]interface{}{"torrents": []interface{}{
+				map[string]interface{}{
+					"hashString": "abc123",
+					"trackerStats": []interface{}{
+						map[string]interface{}{"host": "tracker.example.test", "hasAnnounced": true, "lastAnnounceSucceeded": true, "seederCount": 1},
+					},
+				},
+			}}
+		case "torrent-reannounce":
+			reannounced = args["ids"]
+		}
+		return map[string]interface{}{}
+	})
+	defer ts.Close()
+
+	c := New(cfg)
+
+	stats, err := c.GetTrackerStats("abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, []TrackerStat{{Host: "tracker.example.test", HasAnnounced: true, LastAnnounceSucceeded: true, SeederCount: 1}}, stats)
+
+	assert.NoError(t, c.Reannounce([]string{"abc123"}))
+	assert.Equal(t, []interface{}{"abc123"}, reannounced)
+}
@@ -50,6 +50,8 @@ export const APIClient = {
         login: (username: string, password: string) => appClient.Post("api/auth/login", { username: username, password: password }),
         logout: () => appClient.Post("api/auth/logout", null),
         test: () => appClient.Get<void>("api/auth/test"),
+        canOnboard: () => appClient.Get<void>("api/auth/onboard"),
+        onboard: (username: string, password: string) => appClient.Post("api/auth/onboard", { username: username, password: password }),
     },
     actions: {
         create: (action: Action) => appClient.Post("api/actions", action),
@@ -60,6 +62,10 @@ export const APIClient = {
     config: {
         get: () => appClient.Get<Config>("api/config")
     },
+    debug: {
+        // goroutines, memory and handler counts, admin only
+        runtime: () => appClient.Get<RuntimeStats>("api/debug/runtime"),
+    },
     download_clients: {
         getAll: () => appClient.Get<DownloadClient[]>("api/download_clients"),
         create: (dc: DownloadClient) => appClient.Post("api/download_clients", dc),
@@ -72,7 +78,7 @@ export const APIClient = {
         getByID: (id: number) => appClient.Get<Filter>(`api/filters/${id}`),
         create: (filter: Filter) => appClient.Post("api/filters", filter),
         update: (filter: Filter) => appClient.Put(`api/filters/${filter.id}`, filter),
-        duplicate: (id: number) => appClient.Get<Filter>(`api/filters/${id}/duplicate`),
+        duplicate: (id: number) => appClient.Post(`api/filters/${id}/duplicate`, {}),
         toggleEnable: (id: number, enabled: boolean) => appClient.Put(`api/filters/${id}/enabled`, { enabled }),
         delete: (id: number) => appClient.Delete(`api/filters/${id}`),
     },
@@ -86,6 +92,9 @@ export const APIClient = {
         create: (indexer: Indexer) => appClient.Post("api/indexer", indexer),
         update: (indexer: Indexer) => appClient.Put("api/indexer", indexer),
         delete: (id: number) => appClient.Delete(`api/indexer/${id}`),
+        cacheStats: () => appClient.Get<TorrentCacheStats>("api/indexer/cache"),
+        // removes all cached torrent files, or only the expired ones
+        purgeCache: (expired = false) => HttpClient<TorrentCachePurge>(`api/indexer/cache?expired=${expired}`, "DELETE"),
     },
     irc: {
         getNetworks: () => appClient.Get<IrcNetworkWithHealth[]>("api/irc"),
@@ -120,6 +129,9 @@ export const APIClient = {
         },
         indexerOptions: () => appClient.Get<string[]>(`api/release/indexers`),
         stats: () => appClient.Get<ReleaseStats>("api/release/stats"),
+        pending: () => appClient.Get<PendingAction[]>("api/release/pending"),
+        runPending: (id: number) => appClient.Post(`api/release/pending/${id}/run`, null),
+        cancelPending: (id: number) => appClient.Delete(`api/release/pending/${id}`),
         delete: () => appClient.Delete(`api/release/all`),
     }
 };
\ No newline at end of file
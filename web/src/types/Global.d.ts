@@ -1,3 +1,30 @@
 interface APP {
     baseUrl: string;
 }
+
+interface RuntimeStats {
+    go_version: string;
+    started_at: string;
+    goroutines: number;
+    cpus: number;
+    memory: {
+        heap_alloc: number;
+        heap_inuse: number;
+        heap_idle: number;
+        heap_released: number;
+        heap_objects: number;
+        stack_inuse: number;
+        sys: number;
+        total_alloc: number;
+    };
+    gc: {
+        runs: number;
+        last_run: string;
+        next_heap_size: number;
+        pause_total_ns: number;
+        last_pause_ns: number;
+        cpu_fraction: number;
+        forced_runs: number;
+    };
+    handlers: Record<string, number>;
+}
@@ -62,3 +62,16 @@ interface IndexerParseMatch {
   torrentUrl: string;
   encode: string[];
 }
+
+interface TorrentCacheStats {
+  enabled: boolean;
+  ttl_minutes: number;
+  files: number;
+  bytes: number;
+  hits: number;
+  misses: number;
+}
+
+interface TorrentCachePurge {
+  removed: number;
+}
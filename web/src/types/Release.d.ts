@@ -5,11 +5,21 @@ interface Release {
     indexer: string;
     filter: string;
     protocol: string;
+    has_magnet: boolean;
     title: string;
     size: number;
     raw: string;
     timestamp: Date
     action_status: ReleaseActionStatus[]
+    timings: ReleaseTimings
+}
+
+// microseconds per pipeline stage, 0 when the release did not go through it
+interface ReleaseTimings {
+    parse_us: number;
+    filter_us: number;
+    fetch_us: number;
+    push_us: number;
 }
 
 interface ReleaseActionStatus {
@@ -33,9 +43,40 @@ interface ReleaseStats {
     filter_rejected_count: number;
     push_approved_count: number;
     push_rejected_count: number;
+    latency: ReleaseLatency;
+}
+
+interface ReleaseLatency {
+    parse: StageLatency;
+    filter: StageLatency;
+    fetch: StageLatency;
+    push: StageLatency;
+}
+
+interface StageLatency {
+    count: number;
+    avg_us: number;
+    max_us: number;
 }
 
 interface ReleaseFilter {
   id: string;
   value: string;
 }
+
+interface PendingAction {
+    id: number;
+    release_id: number;
+    torrent_name: string;
+    indexer: string;
+    filter_id: number;
+    filter: string;
+    action_id: number;
+    action: string;
+    type: string;
+    reason: string;
+    attempts: number;
+    run_at: string;
+    created_at: string;
+    waiting: boolean;
+}